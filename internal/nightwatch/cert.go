@@ -0,0 +1,440 @@
+package nightwatch
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gitlab.com/caffeinatedjack/sleepless/internal/nightwatch/jwt"
+	"gitlab.com/caffeinatedjack/sleepless/pkg/cert"
+)
+
+var (
+	certJSON bool
+
+	certWarnDays int
+	certWithin   string
+	certFPAlg    string
+
+	certRoots         []string
+	certIntermediates []string
+	certSystemRoots   bool
+	certHost          string
+	certAt            string
+	certPurpose       string
+)
+
+var certCmd = &cobra.Command{
+	Use:   "cert",
+	Short: "Inspect and issue X.509 certificates offline",
+	Long: `Offline X.509 certificate utilities for PEM and DER bundles, plus a
+local development CA for short-lived test certificates.
+
+Private key material is never printed; keys are summarized by algorithm and size only.
+
+Examples:
+    nightwatch cert inspect server.pem
+    nightwatch cert expiry server.pem --warn-days 14
+    nightwatch cert fingerprint server.pem
+    nightwatch cert verify-host server.pem api.example.com
+    nightwatch cert chain server.pem --roots ca.pem
+    nightwatch cert ca init
+    nightwatch cert issue --san localhost --san 127.0.0.1`,
+}
+
+func init() {
+	rootCmd.AddCommand(certCmd)
+	certCmd.PersistentFlags().BoolVar(&certJSON, "json", false, "Output in JSON format")
+
+	certCmd.AddCommand(certInspectCmd)
+	certCmd.AddCommand(certExpiryCmd)
+	certCmd.AddCommand(certFingerprintCmd)
+	certCmd.AddCommand(certVerifyHostCmd)
+	certCmd.AddCommand(certChainCmd)
+
+	certExpiryCmd.Flags().IntVar(&certWarnDays, "warn-days", 30, "Exit with code 2 when expiry is within this many days")
+	certExpiryCmd.Flags().StringVar(&certWithin, "within", "", "Warning window as a duration (e.g., 72h, 14d); overrides --warn-days")
+
+	certFingerprintCmd.Flags().StringVar(&certFPAlg, "alg", "sha256", "Digest algorithm (sha256, sha1)")
+
+	certChainCmd.Flags().StringArrayVar(&certRoots, "roots", nil, "Trusted root bundle (repeatable)")
+	certChainCmd.Flags().StringArrayVar(&certIntermediates, "intermediates", nil, "Extra intermediate bundle (repeatable)")
+	certChainCmd.Flags().BoolVar(&certSystemRoots, "system-roots", false, "Also trust the platform root store")
+	certChainCmd.Flags().StringVar(&certHost, "host", "", "Also verify the leaf against this hostname")
+	certChainCmd.Flags().StringVar(&certAt, "at", "", "Verify at this time (RFC3339) instead of now")
+	certChainCmd.Flags().StringVar(&certPurpose, "purpose", "any", "Required key usage: server, client or any")
+}
+
+// printCertJSON writes v as indented JSON.
+func printCertJSON(v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// loadCertBundle loads a bundle and requires at least one certificate.
+func loadCertBundle(path string) (*cert.Bundle, error) {
+	bundle, err := cert.LoadBundle(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(bundle.Certificates) == 0 {
+		return nil, fmt.Errorf("%s: no certificates found", path)
+	}
+	return bundle, nil
+}
+
+// --- inspect command ---
+
+type certInspectOutput struct {
+	File         string         `json:"file"`
+	Certificates []cert.Summary `json:"certificates"`
+	Keys         []cert.KeyInfo `json:"keys"`
+	Skipped      []string       `json:"skipped,omitempty"`
+}
+
+var certInspectCmd = &cobra.Command{
+	Use:   "inspect <file>...",
+	Short: "Summarize certificates and keys in a bundle",
+	Long: `Parse PEM or DER files and print a summary of each certificate and key.
+
+Warnings are shown for weak keys, deprecated signature algorithms and
+certificates without SANs. Encrypted keys are listed without being read.
+Other PEM blocks, such as EC PARAMETERS, are skipped and noted.
+
+Examples:
+    nightwatch cert inspect server.pem
+    nightwatch cert inspect fullchain.pem privkey.pem --json`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var outputs []certInspectOutput
+		for _, path := range args {
+			bundle, err := cert.LoadBundle(path)
+			if err != nil {
+				return err
+			}
+			out := certInspectOutput{File: path, Certificates: []cert.Summary{}, Keys: bundle.Keys, Skipped: bundle.Skipped}
+			for _, c := range bundle.Certificates {
+				out.Certificates = append(out.Certificates, cert.Summarize(c))
+			}
+			if out.Keys == nil {
+				out.Keys = []cert.KeyInfo{}
+			}
+			outputs = append(outputs, out)
+		}
+
+		if certJSON {
+			return printCertJSON(outputs)
+		}
+
+		for i, out := range outputs {
+			if i > 0 {
+				fmt.Println()
+			}
+			if len(outputs) > 1 {
+				fmt.Printf("== %s ==\n", out.File)
+			}
+			for j, s := range out.Certificates {
+				if j > 0 {
+					fmt.Println()
+				}
+				printCertSummary(j+1, s)
+			}
+			for _, k := range out.Keys {
+				if len(out.Certificates) > 0 {
+					fmt.Println()
+				}
+				kind := "Public key"
+				if k.Private {
+					kind = "Private key"
+				}
+				if k.Size == 0 {
+					fmt.Printf("%s: %s (material not shown)\n", kind, k.Algorithm)
+					continue
+				}
+				fmt.Printf("%s: %s %d bits (material not shown)\n", kind, k.Algorithm, k.Size)
+			}
+			for _, t := range out.Skipped {
+				fmt.Printf("Skipped PEM block: %s\n", t)
+			}
+		}
+		return nil
+	},
+}
+
+func printCertSummary(n int, s cert.Summary) {
+	fmt.Printf("Certificate %d:\n", n)
+	fmt.Printf("  Subject:    %s\n", s.Subject)
+	fmt.Printf("  Issuer:     %s\n", s.Issuer)
+	fmt.Printf("  Serial:     %s\n", s.Serial)
+	fmt.Printf("  Not Before: %s\n", s.NotBefore.Format(time.RFC3339))
+	fmt.Printf("  Not After:  %s\n", s.NotAfter.Format(time.RFC3339))
+	if len(s.DNSNames) > 0 {
+		fmt.Printf("  DNS Names:  %s\n", strings.Join(s.DNSNames, ", "))
+	}
+	if len(s.IPAddresses) > 0 {
+		fmt.Printf("  IPs:        %s\n", strings.Join(s.IPAddresses, ", "))
+	}
+	if len(s.EmailAddresses) > 0 {
+		fmt.Printf("  Emails:     %s\n", strings.Join(s.EmailAddresses, ", "))
+	}
+	if len(s.URIs) > 0 {
+		fmt.Printf("  URIs:       %s\n", strings.Join(s.URIs, ", "))
+	}
+	fmt.Printf("  Key:        %s %d bits\n", s.KeyAlgorithm, s.KeySize)
+	fmt.Printf("  Signature:  %s\n", s.SignatureAlgorithm)
+	fmt.Printf("  CA:         %v (self-signed: %v)\n", s.IsCA, s.SelfSigned)
+	if len(s.KeyUsage) > 0 {
+		fmt.Printf("  Key Usage:  %s\n", strings.Join(s.KeyUsage, ", "))
+	}
+	if len(s.ExtKeyUsage) > 0 {
+		fmt.Printf("  Ext Usage:  %s\n", strings.Join(s.ExtKeyUsage, ", "))
+	}
+	fmt.Printf("  SHA-256:    %s\n", s.SHA256)
+	for _, w := range s.Warnings {
+		fmt.Printf("  Warning: %s\n", w)
+	}
+}
+
+// --- expiry command ---
+
+var certExpiryCmd = &cobra.Command{
+	Use:     "expiry <file>",
+	Aliases: []string{"expires"},
+	Short:   "Report the earliest expiry in a bundle",
+	Long: `Report the earliest NotAfter across all certificates in a bundle.
+
+Exit codes (for CI):
+  0 - Valid and outside the warning window
+  1 - Error reading or parsing the bundle
+  2 - Expiring within the warning window
+  3 - Expired
+  4 - Not yet valid
+
+Examples:
+    nightwatch cert expiry server.pem
+    nightwatch cert expiry server.pem --warn-days 14
+    nightwatch cert expiry server.pem --within 72h --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		bundle, err := loadCertBundle(args[0])
+		if err != nil {
+			return err
+		}
+
+		warn := time.Duration(certWarnDays) * 24 * time.Hour
+		if certWithin != "" {
+			if warn, err = jwt.ParseDuration(certWithin); err != nil {
+				return fmt.Errorf("invalid --within: %w", err)
+			}
+		}
+
+		result, err := cert.CheckExpiry(bundle.Certificates, time.Now(), warn)
+		if err != nil {
+			return err
+		}
+
+		if certJSON {
+			if err := printCertJSON(result); err != nil {
+				return err
+			}
+		} else {
+			fmt.Printf("Subject:   %s\n", result.Subject)
+			fmt.Printf("Not After: %s\n", result.NotAfter.Format(time.RFC3339))
+			fmt.Printf("Status:    %s", result.Status)
+			switch result.Status {
+			case cert.StatusExpired:
+				fmt.Printf(" (%d days ago)\n", -result.DaysRemaining)
+			case cert.StatusNotYetValid:
+				fmt.Printf(" (a certificate starts after now)\n")
+			default:
+				fmt.Printf(" (%d days remaining)\n", result.DaysRemaining)
+			}
+		}
+
+		switch result.Status {
+		case cert.StatusExpiring:
+			os.Exit(2)
+		case cert.StatusExpired:
+			os.Exit(3)
+		case cert.StatusNotYetValid:
+			os.Exit(4)
+		}
+		return nil
+	},
+}
+
+// --- fingerprint command ---
+
+type certFingerprintOutput struct {
+	Subject     string `json:"subject"`
+	Algorithm   string `json:"algorithm"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+var certFingerprintCmd = &cobra.Command{
+	Use:   "fingerprint <file>",
+	Short: "Print certificate fingerprints",
+	Long: `Print the fingerprint of each certificate in a bundle.
+
+Examples:
+    nightwatch cert fingerprint server.pem
+    nightwatch cert fingerprint server.der --alg sha1`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		alg := strings.ToLower(certFPAlg)
+		if alg != "sha256" && alg != "sha1" {
+			return fmt.Errorf("unsupported fingerprint algorithm: %s (use sha256 or sha1)", certFPAlg)
+		}
+
+		bundle, err := loadCertBundle(args[0])
+		if err != nil {
+			return err
+		}
+
+		outputs := make([]certFingerprintOutput, 0, len(bundle.Certificates))
+		for _, c := range bundle.Certificates {
+			outputs = append(outputs, certFingerprintOutput{
+				Subject:     c.Subject.String(),
+				Algorithm:   alg,
+				Fingerprint: cert.Fingerprint(c, alg),
+			})
+		}
+
+		if certJSON {
+			return printCertJSON(outputs)
+		}
+		for _, o := range outputs {
+			fmt.Printf("%s  %s\n", o.Fingerprint, o.Subject)
+		}
+		return nil
+	},
+}
+
+// --- verify-host command ---
+
+var certVerifyHostCmd = &cobra.Command{
+	Use:   "verify-host <file> <hostname>",
+	Short: "Check whether a certificate covers a hostname",
+	Long: `Check whether any leaf certificate in the bundle is valid for a hostname or IP,
+using standard X.509 SAN rules (the common name is not consulted).
+
+Exits with code 2 when no certificate matches.
+
+Examples:
+    nightwatch cert verify-host server.pem api.example.com
+    nightwatch cert verify-host server.pem 10.0.0.1 --json`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		bundle, err := loadCertBundle(args[0])
+		if err != nil {
+			return err
+		}
+
+		result := cert.VerifyHost(bundle.Certificates, args[1])
+		if certJSON {
+			if err := printCertJSON(result); err != nil {
+				return err
+			}
+		} else if result.Valid {
+			fmt.Printf("\u2713 %s is covered by %s\n", result.Host, result.Subject)
+		} else {
+			fmt.Printf("\u2717 %s is not covered by any certificate\n", result.Host)
+			for _, e := range result.Errors {
+				fmt.Printf("  %s\n", e)
+			}
+		}
+
+		if !result.Valid {
+			os.Exit(2)
+		}
+		return nil
+	},
+}
+
+// --- chain command ---
+
+var certChainCmd = &cobra.Command{
+	Use:   "chain <file>",
+	Short: "Build and verify a certificate chain",
+	Long: `Treat the first certificate in the file as the leaf and the rest as
+intermediates, then build chains to the supplied root bundle.
+
+Exits with code 2 when no valid chain can be built.
+
+Examples:
+    nightwatch cert chain fullchain.pem --roots ca.pem
+    nightwatch cert chain leaf.pem --intermediates intermediate.pem --roots root.pem --host api.example.com
+    nightwatch cert chain fullchain.pem --system-roots --purpose server --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		bundle, err := loadCertBundle(args[0])
+		if err != nil {
+			return err
+		}
+
+		opts := cert.ChainOptions{SystemRoots: certSystemRoots, DNSName: certHost}
+		if len(certRoots) > 0 {
+			if opts.Roots, err = cert.LoadCertificates(certRoots...); err != nil {
+				return err
+			}
+		}
+		if len(certIntermediates) > 0 {
+			if opts.Intermediates, err = cert.LoadCertificates(certIntermediates...); err != nil {
+				return err
+			}
+		}
+		if certAt != "" {
+			if opts.At, err = time.Parse(time.RFC3339, certAt); err != nil {
+				return fmt.Errorf("invalid --at (expected RFC3339): %w", err)
+			}
+		}
+		switch strings.ToLower(certPurpose) {
+		case "server":
+			opts.KeyUsages = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+		case "client":
+			opts.KeyUsages = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+		case "any", "":
+		default:
+			return fmt.Errorf("invalid --purpose: %s (use server, client or any)", certPurpose)
+		}
+
+		result, err := cert.VerifyChain(bundle.Certificates, opts)
+		if err != nil {
+			return err
+		}
+
+		if certJSON {
+			if err := printCertJSON(result); err != nil {
+				return err
+			}
+		} else {
+			if result.Valid {
+				fmt.Printf("\u2713 Chain verified for %s\n", result.Leaf)
+				for i, chain := range result.Chains {
+					fmt.Printf("Chain %d:\n", i+1)
+					for depth, link := range chain {
+						fmt.Printf("  %d %s (expires %s)\n", depth, link.Subject, link.NotAfter.Format("2006-01-02"))
+					}
+				}
+			} else {
+				fmt.Printf("\u2717 Chain verification failed for %s: %s\n", result.Leaf, result.Error)
+			}
+			for _, w := range result.Warnings {
+				fmt.Printf("Warning: %s\n", w)
+			}
+		}
+
+		if !result.Valid {
+			os.Exit(2)
+		}
+		return nil
+	},
+}
@@ -0,0 +1,665 @@
+package nightwatch
+
+import (
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gitlab.com/caffeinatedjack/sleepless/internal/nightwatch/jwt"
+	"gitlab.com/caffeinatedjack/sleepless/pkg/cert"
+)
+
+var (
+	certCADir string
+
+	certCACommonName        string
+	certCAOrg               string
+	certCAKeyType           string
+	certCAPermitDNS         []string
+	certCAPermitIP          []string
+	certCANoConstraints     bool
+	certCARootValidity      string
+	certCAIntermediateValid string
+
+	certRevokeReason string
+	certCRLValidity  string
+	certCRLOut       string
+
+	certIssueSANs     []string
+	certIssueCN       string
+	certIssueServer   bool
+	certIssueClient   bool
+	certIssueValidity string
+	certIssueKeyType  string
+	certIssueOutDir   string
+	certIssueName     string
+
+	certCSRCommonName string
+	certCSRSANs       []string
+	certCSRKeyType    string
+	certCSROut        string
+)
+
+var certCACmd = &cobra.Command{
+	Use:   "ca",
+	Short: "Manage a local development CA",
+	Long: `Manage a local two-tier certificate authority for development HTTPS and mTLS tests.
+
+'ca init' creates a self-signed root and an intermediate that signs everything
+else. By default the intermediate is name-constrained to localhost, .test,
+.example, .internal, .local and private IP ranges, so a leaked dev CA cannot
+mint certificates for real sites.
+
+The CA lives in $NIGHTWATCH_CA_DIR, or <user config dir>/nightwatch/ca.
+Keys are written with 0600 permissions and never overwritten.
+
+Examples:
+    nightwatch cert ca init
+    nightwatch cert ca show
+    nightwatch cert ca revoke api.pem --reason keycompromise
+    nightwatch cert ca crl --out ca.crl`,
+}
+
+func init() {
+	certCmd.AddCommand(certCACmd)
+	certCmd.AddCommand(certIssueCmd)
+	certCmd.AddCommand(certCSRCmd)
+
+	certCACmd.PersistentFlags().StringVar(&certCADir, "ca-dir", "", "CA directory (default: $NIGHTWATCH_CA_DIR or <config dir>/nightwatch/ca)")
+	certIssueCmd.Flags().StringVar(&certCADir, "ca-dir", "", "CA directory (default: $NIGHTWATCH_CA_DIR or <config dir>/nightwatch/ca)")
+	certCSRSignCmd.Flags().StringVar(&certCADir, "ca-dir", "", "CA directory (default: $NIGHTWATCH_CA_DIR or <config dir>/nightwatch/ca)")
+
+	certCACmd.AddCommand(certCAInitCmd)
+	certCACmd.AddCommand(certCAShowCmd)
+	certCACmd.AddCommand(certCARevokeCmd)
+	certCACmd.AddCommand(certCACRLCmd)
+
+	certCAInitCmd.Flags().StringVar(&certCACommonName, "cn", "", "Root common name (default: Nightwatch Development CA)")
+	certCAInitCmd.Flags().StringVar(&certCAOrg, "org", "", "Organization for the CA subjects")
+	certCAInitCmd.Flags().StringVar(&certCAKeyType, "key-type", "ecdsa", "CA key type (ecdsa, ecdsa-p384, rsa, rsa-4096, ed25519)")
+	certCAInitCmd.Flags().StringSliceVar(&certCAPermitDNS, "permit-dns", cert.DefaultPermittedDNS, "DNS suffixes the intermediate may issue for")
+	certCAInitCmd.Flags().StringSliceVar(&certCAPermitIP, "permit-ip", cert.DefaultPermittedIPs, "CIDR ranges the intermediate may issue for")
+	certCAInitCmd.Flags().BoolVar(&certCANoConstraints, "no-name-constraints", false, "Create an unconstrained intermediate")
+	certCAInitCmd.Flags().StringVar(&certCARootValidity, "root-validity", "3650d", "Root certificate lifetime (e.g., 3650d)")
+	certCAInitCmd.Flags().StringVar(&certCAIntermediateValid, "intermediate-validity", "1825d", "Intermediate certificate lifetime (e.g., 1825d)")
+
+	certCARevokeCmd.Flags().StringVar(&certRevokeReason, "reason", "unspecified", "Revocation reason (unspecified, keycompromise, superseded, cessationofoperation, ...)")
+
+	certCACRLCmd.Flags().StringVar(&certCRLValidity, "validity", "7d", "Time until the CRL's next update")
+	certCACRLCmd.Flags().StringVar(&certCRLOut, "out", "", "Write the CRL to a file instead of stdout")
+
+	certIssueCmd.Flags().StringSliceVar(&certIssueSANs, "san", nil, "Subject alternative name: DNS name, IP, email or URI (repeatable)")
+	certIssueCmd.Flags().StringVar(&certIssueCN, "cn", "", "Common name (default: first DNS SAN)")
+	certIssueCmd.Flags().BoolVar(&certIssueServer, "server", false, "Issue for TLS server authentication (default)")
+	certIssueCmd.Flags().BoolVar(&certIssueClient, "client", false, "Issue for TLS client authentication")
+	certIssueCmd.Flags().StringVar(&certIssueValidity, "validity", "30d", "Certificate lifetime (e.g., 24h, 30d)")
+	certIssueCmd.Flags().StringVar(&certIssueKeyType, "key-type", "ecdsa", "Key type (ecdsa, ecdsa-p384, rsa, rsa-4096, ed25519)")
+	certIssueCmd.Flags().StringVar(&certIssueOutDir, "out-dir", ".", "Directory for the certificate and key")
+	certIssueCmd.Flags().StringVar(&certIssueName, "name", "", "Base file name (default: common name)")
+	_ = certIssueCmd.MarkFlagRequired("san")
+
+	certCSRCmd.AddCommand(certCSRCreateCmd)
+	certCSRCmd.AddCommand(certCSRInspectCmd)
+	certCSRCmd.AddCommand(certCSRSignCmd)
+
+	certCSRCreateCmd.Flags().StringVar(&certCSRCommonName, "cn", "", "Common name (default: first DNS SAN)")
+	certCSRCreateCmd.Flags().StringSliceVar(&certCSRSANs, "san", nil, "Subject alternative name (repeatable)")
+	certCSRCreateCmd.Flags().StringVar(&certCSRKeyType, "key-type", "ecdsa", "Key type (ecdsa, ecdsa-p384, rsa, rsa-4096, ed25519)")
+	certCSRCreateCmd.Flags().StringVar(&certCSROut, "out", "", "Base file name for <out>.csr and <out>.key (default: common name)")
+
+	certCSRSignCmd.Flags().BoolVar(&certIssueServer, "server", false, "Issue for TLS server authentication (default)")
+	certCSRSignCmd.Flags().BoolVar(&certIssueClient, "client", false, "Issue for TLS client authentication")
+	certCSRSignCmd.Flags().StringVar(&certIssueValidity, "validity", "30d", "Certificate lifetime (e.g., 24h, 30d)")
+	certCSRSignCmd.Flags().StringVar(&certCSROut, "out", "", "Certificate output file (default: <csr name>.pem)")
+}
+
+// resolveCADir returns --ca-dir or the default CA directory.
+func resolveCADir() (string, error) {
+	if certCADir != "" {
+		return certCADir, nil
+	}
+	return cert.DefaultCADir()
+}
+
+// openCA loads the local CA and warns about an exposed signing key.
+func openCA() (*cert.Authority, error) {
+	dir, err := resolveCADir()
+	if err != nil {
+		return nil, err
+	}
+	ca, err := cert.LoadCA(dir)
+	if err != nil {
+		return nil, err
+	}
+	jwt.CheckKeyPermissions(ca.IntermediateKeyPath())
+	return ca, nil
+}
+
+// parseValidity parses a lifetime flag, accepting the "d" suffix.
+func parseValidity(flag, value string) (time.Duration, error) {
+	d, err := jwt.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid --%s: %w", flag, err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("invalid --%s: must be positive", flag)
+	}
+	return d, nil
+}
+
+// certFileBase turns a common name into a safe file name.
+func certFileBase(name string) string {
+	name = strings.TrimPrefix(name, "*.")
+	name = strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ':' || r == '*' || r == ' ' {
+			return '_'
+		}
+		return r
+	}, name)
+	if name == "" {
+		return "cert"
+	}
+	return name
+}
+
+// --- ca init command ---
+
+type certCAInitOutput struct {
+	Dir          string       `json:"dir"`
+	Root         cert.Summary `json:"root"`
+	Intermediate cert.Summary `json:"intermediate"`
+}
+
+var certCAInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Create a root and name-constrained intermediate",
+	Long: `Create a new local CA: a self-signed root and an intermediate signed by it.
+
+Trust root.pem in your browser or system store; everything else is signed by
+the intermediate. Refuses to overwrite an existing CA.
+
+Examples:
+    nightwatch cert ca init
+    nightwatch cert ca init --cn "Team Dev CA" --permit-dns test,dev.internal
+    nightwatch cert ca init --ca-dir ./ca --no-name-constraints`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, err := resolveCADir()
+		if err != nil {
+			return err
+		}
+		kt, err := cert.ParseKeyType(certCAKeyType)
+		if err != nil {
+			return err
+		}
+		rootValidity, err := parseValidity("root-validity", certCARootValidity)
+		if err != nil {
+			return err
+		}
+		interValidity, err := parseValidity("intermediate-validity", certCAIntermediateValid)
+		if err != nil {
+			return err
+		}
+
+		opts := cert.CAOptions{
+			CommonName:           certCACommonName,
+			Organization:         certCAOrg,
+			KeyType:              kt,
+			PermittedDNS:         certCAPermitDNS,
+			PermittedIPs:         certCAPermitIP,
+			RootValidity:         rootValidity,
+			IntermediateValidity: interValidity,
+		}
+		if certCANoConstraints {
+			opts.PermittedDNS = nil
+			opts.PermittedIPs = nil
+		}
+
+		ca, err := cert.InitCA(dir, opts)
+		if err != nil {
+			return err
+		}
+
+		if certJSON {
+			return printCertJSON(certCAInitOutput{
+				Dir:          ca.Dir,
+				Root:         cert.Summarize(ca.Root),
+				Intermediate: cert.Summarize(ca.Intermediate),
+			})
+		}
+		fmt.Printf("Created CA in %s\n", ca.Dir)
+		fmt.Printf("  Root:         %s (expires %s)\n", ca.Root.Subject, ca.Root.NotAfter.Format("2006-01-02"))
+		fmt.Printf("  Intermediate: %s (expires %s)\n", ca.Intermediate.Subject, ca.Intermediate.NotAfter.Format("2006-01-02"))
+		if len(opts.PermittedDNS) > 0 || len(opts.PermittedIPs) > 0 {
+			fmt.Printf("  Constraints:  %s\n", strings.Join(append(append([]string{}, opts.PermittedDNS...), opts.PermittedIPs...), ", "))
+		} else {
+			fmt.Println("  Constraints:  none")
+		}
+		fmt.Printf("\nTrust %s to accept certificates issued by this CA.\n", ca.RootPath())
+		return nil
+	},
+}
+
+// --- ca show command ---
+
+type certCAShowOutput struct {
+	Dir          string               `json:"dir"`
+	Root         cert.Summary         `json:"root"`
+	Intermediate cert.Summary         `json:"intermediate"`
+	CRLNumber    int64                `json:"crl_number"`
+	Issued       []cert.IssuedRecord  `json:"issued"`
+	Revoked      []cert.RevokedRecord `json:"revoked"`
+}
+
+var certCAShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the CA and the certificates it has issued",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ca, err := openCA()
+		if err != nil {
+			return err
+		}
+
+		if certJSON {
+			return printCertJSON(certCAShowOutput{
+				Dir:          ca.Dir,
+				Root:         cert.Summarize(ca.Root),
+				Intermediate: cert.Summarize(ca.Intermediate),
+				CRLNumber:    ca.State.CRLNumber,
+				Issued:       ca.State.Issued,
+				Revoked:      ca.State.Revoked,
+			})
+		}
+
+		revoked := make(map[string]bool, len(ca.State.Revoked))
+		for _, r := range ca.State.Revoked {
+			revoked[r.Serial] = true
+		}
+
+		fmt.Printf("CA directory: %s\n", ca.Dir)
+		fmt.Printf("Root:         %s (expires %s)\n", ca.Root.Subject, ca.Root.NotAfter.Format("2006-01-02"))
+		fmt.Printf("Intermediate: %s (expires %s)\n", ca.Intermediate.Subject, ca.Intermediate.NotAfter.Format("2006-01-02"))
+		if names := append(append([]string{}, ca.Intermediate.PermittedDNSDomains...), ipNetStrings(ca.Intermediate)...); len(names) > 0 {
+			fmt.Printf("Constraints:  %s\n", strings.Join(names, ", "))
+		}
+		fmt.Printf("\nIssued (%d):\n", len(ca.State.Issued))
+		now := time.Now()
+		for _, r := range ca.State.Issued {
+			status := "valid"
+			switch {
+			case revoked[r.Serial]:
+				status = "revoked"
+			case now.After(r.NotAfter):
+				status = "expired"
+			}
+			fmt.Printf("  %s  %-8s %s  %s\n", r.NotAfter.Format("2006-01-02"), status, r.Serial, strings.Join(r.Names, ", "))
+		}
+		return nil
+	},
+}
+
+func ipNetStrings(c *x509.Certificate) []string {
+	out := make([]string, 0, len(c.PermittedIPRanges))
+	for _, n := range c.PermittedIPRanges {
+		out = append(out, n.String())
+	}
+	return out
+}
+
+// --- ca revoke command ---
+
+var certCARevokeCmd = &cobra.Command{
+	Use:   "revoke <cert.pem|serial>",
+	Short: "Mark an issued certificate as revoked",
+	Long: `Mark a certificate issued by this CA as revoked. It is listed in every
+CRL generated afterwards.
+
+The argument is either a certificate file or a hex serial number
+(colons optional, as printed by 'cert inspect' and 'cert ca show').
+
+Examples:
+    nightwatch cert ca revoke api.test.pem
+    nightwatch cert ca revoke 3f:a2:91:... --reason keycompromise`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		reason, err := cert.ParseRevocationReason(certRevokeReason)
+		if err != nil {
+			return err
+		}
+		ca, err := openCA()
+		if err != nil {
+			return err
+		}
+
+		serial := args[0]
+		if _, err := os.Stat(serial); err == nil {
+			bundle, err := loadCertBundle(serial)
+			if err != nil {
+				return err
+			}
+			serial = cert.SerialHex(bundle.Certificates[0])
+		}
+
+		record, err := ca.Revoke(serial, reason)
+		if err != nil {
+			return err
+		}
+		if certJSON {
+			return printCertJSON(record)
+		}
+		fmt.Printf("Revoked %s (%s)\n", record.Serial, record.Subject)
+		fmt.Println("Run 'nightwatch cert ca crl' to publish an updated CRL.")
+		return nil
+	},
+}
+
+// --- ca crl command ---
+
+type certCRLOutput struct {
+	Number     int64     `json:"number"`
+	ThisUpdate time.Time `json:"this_update"`
+	NextUpdate time.Time `json:"next_update"`
+	Revoked    int       `json:"revoked"`
+	File       string    `json:"file,omitempty"`
+	PEM        string    `json:"pem,omitempty"`
+}
+
+var certCACRLCmd = &cobra.Command{
+	Use:   "crl",
+	Short: "Generate a signed certificate revocation list",
+	Long: `Generate a CRL signed by the intermediate listing every revoked certificate.
+Each run increments the CRL number.
+
+Examples:
+    nightwatch cert ca crl > ca.crl
+    nightwatch cert ca crl --validity 30d --out ca.crl`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		validity, err := parseValidity("validity", certCRLValidity)
+		if err != nil {
+			return err
+		}
+		ca, err := openCA()
+		if err != nil {
+			return err
+		}
+
+		crl, data, err := ca.CRL(validity)
+		if err != nil {
+			return err
+		}
+
+		out := certCRLOutput{
+			Number:     crl.Number.Int64(),
+			ThisUpdate: crl.ThisUpdate,
+			NextUpdate: crl.NextUpdate,
+			Revoked:    len(crl.RevokedCertificateEntries),
+		}
+		if certCRLOut != "" {
+			if err := os.WriteFile(certCRLOut, data, 0644); err != nil {
+				return fmt.Errorf("failed to write CRL: %w", err)
+			}
+			out.File = certCRLOut
+		}
+
+		if certJSON {
+			if certCRLOut == "" {
+				out.PEM = string(data)
+			}
+			return printCertJSON(out)
+		}
+		if certCRLOut == "" {
+			fmt.Print(string(data))
+			return nil
+		}
+		fmt.Printf("Wrote CRL #%d with %d revoked certificate(s) to %s (next update %s)\n",
+			out.Number, out.Revoked, certCRLOut, out.NextUpdate.Format(time.RFC3339))
+		return nil
+	},
+}
+
+// --- issue command ---
+
+type certIssueOutput struct {
+	Certificate string       `json:"certificate"`
+	Key         string       `json:"key,omitempty"`
+	Summary     cert.Summary `json:"summary"`
+}
+
+// issueOptions builds IssueOptions from the shared issue/sign flags.
+func issueOptions() (cert.IssueOptions, error) {
+	validity, err := parseValidity("validity", certIssueValidity)
+	if err != nil {
+		return cert.IssueOptions{}, err
+	}
+	return cert.IssueOptions{
+		CommonName: certIssueCN,
+		SANs:       certIssueSANs,
+		Server:     certIssueServer,
+		Client:     certIssueClient,
+		Validity:   validity,
+	}, nil
+}
+
+func printIssued(out certIssueOutput) error {
+	if certJSON {
+		return printCertJSON(out)
+	}
+	fmt.Printf("Issued %s\n", out.Summary.Subject)
+	fmt.Printf("  Certificate: %s\n", out.Certificate)
+	if out.Key != "" {
+		fmt.Printf("  Key:         %s\n", out.Key)
+	}
+	fmt.Printf("  Names:       %s\n", strings.Join(append(append(append([]string{}, out.Summary.DNSNames...), out.Summary.IPAddresses...), out.Summary.EmailAddresses...), ", "))
+	fmt.Printf("  Usage:       %s\n", strings.Join(out.Summary.ExtKeyUsage, ", "))
+	fmt.Printf("  Serial:      %s\n", out.Summary.Serial)
+	fmt.Printf("  Expires:     %s\n", out.Summary.NotAfter.Format(time.RFC3339))
+	return nil
+}
+
+var certIssueCmd = &cobra.Command{
+	Use:   "issue",
+	Short: "Issue a short-lived certificate from the local CA",
+	Long: `Generate a key pair and issue a certificate from the local CA.
+
+Writes <name>.pem (leaf followed by the intermediate) and <name>.key (0600).
+SANs are classified automatically: IPs, emails (contain @), URIs (contain ://)
+and DNS names. The CA refuses names outside its constraints.
+
+Examples:
+    nightwatch cert issue --san localhost --san 127.0.0.1
+    nightwatch cert issue --san api.test --server --client --validity 7d
+    nightwatch cert issue --san alice@example.test --client --name alice`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts, err := issueOptions()
+		if err != nil {
+			return err
+		}
+		if opts.KeyType, err = cert.ParseKeyType(certIssueKeyType); err != nil {
+			return err
+		}
+		ca, err := openCA()
+		if err != nil {
+			return err
+		}
+
+		leaf, key, err := ca.Issue(opts)
+		if err != nil {
+			return err
+		}
+
+		name := certIssueName
+		if name == "" {
+			name = certFileBase(leaf.Subject.CommonName)
+		}
+		certPath := filepath.Join(certIssueOutDir, name+".pem")
+		keyPath := filepath.Join(certIssueOutDir, name+".key")
+		if err := cert.WriteKey(keyPath, key); err != nil {
+			return err
+		}
+		if err := cert.WriteCertificates(certPath, append([]*x509.Certificate{leaf}, ca.Chain()...)...); err != nil {
+			return err
+		}
+
+		return printIssued(certIssueOutput{Certificate: certPath, Key: keyPath, Summary: cert.Summarize(leaf)})
+	},
+}
+
+// --- csr commands ---
+
+var certCSRCmd = &cobra.Command{
+	Use:   "csr",
+	Short: "Create, inspect and sign certificate requests",
+	Long: `Work with PKCS#10 certificate signing requests.
+
+Examples:
+    nightwatch cert csr create --san svc.internal --out svc
+    nightwatch cert csr inspect svc.csr
+    nightwatch cert csr sign svc.csr --client`,
+}
+
+var certCSRCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Generate a key and certificate request",
+	Long: `Generate a private key and a CSR for it. Writes <out>.key (0600) and <out>.csr.
+
+Examples:
+    nightwatch cert csr create --san svc.internal --san 10.0.0.5 --out svc
+    nightwatch cert csr create --cn "build agent" --key-type rsa`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if certCSRCommonName == "" && len(certCSRSANs) == 0 {
+			return fmt.Errorf("at least one of --cn or --san is required")
+		}
+		kt, err := cert.ParseKeyType(certCSRKeyType)
+		if err != nil {
+			return err
+		}
+		key, err := cert.GenerateKey(kt)
+		if err != nil {
+			return fmt.Errorf("failed to generate key: %w", err)
+		}
+		csr, err := cert.CreateCSR(key, certCSRCommonName, certCSRSANs)
+		if err != nil {
+			return err
+		}
+
+		base := certCSROut
+		if base == "" {
+			base = certFileBase(csr.Subject.CommonName)
+		}
+		if err := cert.WriteKey(base+".key", key); err != nil {
+			return err
+		}
+		if err := cert.WriteCSR(base+".csr", csr); err != nil {
+			return err
+		}
+
+		if certJSON {
+			return printCertJSON(map[string]interface{}{
+				"csr":     base + ".csr",
+				"key":     base + ".key",
+				"summary": cert.SummarizeCSR(csr),
+			})
+		}
+		fmt.Printf("Wrote %s.csr and %s.key\n", base, base)
+		return nil
+	},
+}
+
+var certCSRInspectCmd = &cobra.Command{
+	Use:   "inspect <file>",
+	Short: "Summarize a certificate request",
+	Long: `Parse a PEM or DER certificate request and verify its self-signature.
+
+Exits with code 2 when the signature is invalid.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		csr, err := cert.LoadCSR(args[0])
+		if err != nil {
+			return err
+		}
+		s := cert.SummarizeCSR(csr)
+
+		if certJSON {
+			if err := printCertJSON(s); err != nil {
+				return err
+			}
+		} else {
+			fmt.Printf("Subject:    %s\n", s.Subject)
+			if len(s.DNSNames) > 0 {
+				fmt.Printf("DNS Names:  %s\n", strings.Join(s.DNSNames, ", "))
+			}
+			if len(s.IPAddresses) > 0 {
+				fmt.Printf("IPs:        %s\n", strings.Join(s.IPAddresses, ", "))
+			}
+			if len(s.EmailAddresses) > 0 {
+				fmt.Printf("Emails:     %s\n", strings.Join(s.EmailAddresses, ", "))
+			}
+			if len(s.URIs) > 0 {
+				fmt.Printf("URIs:       %s\n", strings.Join(s.URIs, ", "))
+			}
+			fmt.Printf("Key:        %s %d bits\n", s.KeyAlgorithm, s.KeySize)
+			fmt.Printf("Signature:  %s\n", s.SignatureAlgorithm)
+			if s.SignatureValid {
+				fmt.Println("\u2713 Signature valid")
+			} else {
+				fmt.Println("\u2717 Signature invalid")
+			}
+		}
+
+		if !s.SignatureValid {
+			os.Exit(2)
+		}
+		return nil
+	},
+}
+
+var certCSRSignCmd = &cobra.Command{
+	Use:   "sign <file>",
+	Short: "Sign a certificate request with the local CA",
+	Long: `Issue a certificate for a CSR using the request's subject and SANs.
+
+Writes the leaf followed by the intermediate to --out (default: <csr name>.pem).
+
+Examples:
+    nightwatch cert csr sign svc.csr
+    nightwatch cert csr sign agent.csr --client --validity 24h --out agent.pem`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		csr, err := cert.LoadCSR(args[0])
+		if err != nil {
+			return err
+		}
+		opts, err := issueOptions()
+		if err != nil {
+			return err
+		}
+		opts.CommonName, opts.SANs = "", nil
+		ca, err := openCA()
+		if err != nil {
+			return err
+		}
+
+		leaf, err := ca.SignCSR(csr, opts)
+		if err != nil {
+			return err
+		}
+
+		out := certCSROut
+		if out == "" {
+			out = strings.TrimSuffix(args[0], filepath.Ext(args[0])) + ".pem"
+		}
+		if err := cert.WriteCertificates(out, append([]*x509.Certificate{leaf}, ca.Chain()...)...); err != nil {
+			return err
+		}
+
+		return printIssued(certIssueOutput{Certificate: out, Summary: cert.Summarize(leaf)})
+	},
+}
@@ -4,6 +4,7 @@ import (
 	_ "embed"
 	"encoding/json"
 	"fmt"
+	"os"
 	"strconv"
 	"strings"
 
@@ -32,15 +33,22 @@ var streetTypesData string
 //go:embed data/postcodes.txt
 var postcodesData string
 
+//go:embed data/localities.txt
+var localitiesData string
+
 var (
 	fakeCount     int
 	fakeSeed      *int64
 	fakeJSON      bool
 	fakeSeparator string
+	fakeLocale    string
 )
 
-// Cached generator
-var fakeGenerator *fake.Generator
+// Cached generator and the locale it was built for
+var (
+	fakeGenerator       *fake.Generator
+	fakeGeneratorLocale string
+)
 
 func init() {
 	rootCmd.AddCommand(fakeCmd)
@@ -50,6 +58,7 @@ func init() {
 	fakeCmd.PersistentFlags().BoolVar(&fakeJSON, "json", false, "Output in JSON format")
 	fakeCmd.PersistentFlags().StringVar(&fakeSeparator, "separator", "\n", "Separator for multi-item output")
 	fakeCmd.PersistentFlags().Int64("seed", 0, "Random seed for reproducibility")
+	fakeCmd.PersistentFlags().StringVar(&fakeLocale, "locale", "", "Data locale: "+strings.Join(fake.LocaleCodes(), ", ")+" (default: $NIGHTWATCH_FAKE_LOCALE or "+fake.BaseLocale+")")
 	fakeCmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
 		if cmd.Flags().Changed("seed") {
 			seed, _ := cmd.Flags().GetInt64("seed")
@@ -104,12 +113,34 @@ func init() {
 	fakeLoremCmd.Flags().Int("paragraphs", 0, "Number of paragraphs to generate")
 }
 
-// getGenerator returns the cached generator, initializing it if needed.
+// getGenerator returns the cached generator for the selected locale,
+// initializing it if needed.
 func getGenerator() (*fake.Generator, error) {
-	if fakeGenerator != nil {
+	code := fakeLocale
+	if code == "" {
+		code = os.Getenv("NIGHTWATCH_FAKE_LOCALE")
+	}
+	if code == "" {
+		code = fake.BaseLocale
+	}
+	if fakeGenerator != nil && fakeGeneratorLocale == code {
 		return fakeGenerator, nil
 	}
 
+	base, err := baseGenerator()
+	if err != nil {
+		return nil, err
+	}
+	g, err := localizeGenerator(base, code)
+	if err != nil {
+		return nil, err
+	}
+	fakeGenerator, fakeGeneratorLocale = g, code
+	return fakeGenerator, nil
+}
+
+// baseGenerator builds a generator from the base locale data in data/.
+func baseGenerator() (*fake.Generator, error) {
 	// Parse all embedded data files
 	firstnames := parseNameData(firstnamesData)
 	lastnames := parseNameData(surnamesData)
@@ -121,8 +152,14 @@ func getGenerator() (*fake.Generator, error) {
 	streetTypes := parseNameData(streetTypesData)
 	postcodes := parseNameData(postcodesData)
 
-	fakeGenerator = fake.NewGenerator(firstnames, lastnames, loremWords, cities, counties, countries, streets, streetTypes, postcodes)
-	return fakeGenerator, nil
+	localities, err := fake.ParseLocalities(parseNameData(localitiesData))
+	if err != nil {
+		return nil, err
+	}
+
+	g := fake.NewGenerator(firstnames, lastnames, loremWords, cities, counties, countries, streets, streetTypes, postcodes)
+	g.Localities = localities
+	return g, nil
 }
 
 // parseNameData parses embedded name data into a slice.
@@ -189,13 +226,16 @@ var fakeCmd = &cobra.Command{
 
 Supports various data types including names, emails, addresses, dates, and more.
 Use --seed for reproducible output and --json for machine-readable format.
+Use --locale to generate names, addresses, phone numbers and postcodes for
+another locale; data a locale does not provide falls back to en_GB.
 
 Examples:
     nightwatch fake name
     nightwatch fake email --count 5
     nightwatch fake number --min 1 --max 100
     nightwatch fake template "{{name}} <{{email}}>"
-    nightwatch fake name --count 3 --seed 42`,
+    nightwatch fake name --count 3 --seed 42
+    nightwatch fake person --locale de_DE`,
 }
 
 var fakeNameCmd = &cobra.Command{
@@ -368,7 +408,7 @@ var fakeCityCmd = &cobra.Command{
 
 var fakeCountyCmd = &cobra.Command{
 	Use:   "county",
-	Short: "Generate a random UK county",
+	Short: "Generate a random county, state or region",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		g, err := getGenerator()
 		if err != nil {
@@ -410,7 +450,7 @@ var fakeCountryCmd = &cobra.Command{
 
 var fakePostcodeCmd = &cobra.Command{
 	Use:   "postcode",
-	Short: "Generate a random UK postcode",
+	Short: "Generate a random postcode",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		g, err := getGenerator()
 		if err != nil {
@@ -769,7 +809,9 @@ Supported types:
   date, datetime, time
   uuid, hex, number
   lorem, word, sentence, paragraph
-  url, ipv4, ipv6, mac
+  url, ipv4, ipv6, mac, domain, useragent
+  card, iban, amount, company, jobtitle
+  semver, hash, jwt, colour (or color), latlong, timestamp
 
 Parameterized placeholders:
   {{number:min:max}}       - number in range
@@ -778,9 +820,38 @@ Parameterized placeholders:
   {{date:past:N}}          - date up to N days ago
   {{date:future:N}}        - date up to N days ahead
   {{hex:N}}                - hex string of length N
+  {{card:visa}}            - Luhn-valid card number (visa, mastercard, amex, discover, jcb)
+  {{iban:DE}}              - IBAN with valid check digits (default: locale country)
+  {{amount:min:max:CUR}}   - money in the locale's format (default: locale currency)
+  {{useragent:firefox}}    - chrome, firefox, safari, edge or mobile
+  {{semver:pre}}           - version, possibly with a prerelease tag
+  {{hash:md5}}             - md5, sha1, sha256 or sha512 digest
+  {{jwt:HS512}}            - signed token with fake user claims
+  {{colour:hsl}}           - hex, rgb, hsl or name
+  {{latlong:a:b:c:d}}      - point in box min lat:min long:max lat:max long
+  {{timestamp:rfc3339}}    - unix, unixms, rfc3339, iso8601, rfc2822, rfc1123, http, sql, date
+
+Person fields:
+  {{person.name}}, {{person.email}}, {{person.city}}, ... all refer to the
+  same generated person within one result (see 'fake person').
+
+Captures, choices and filters:
+  {{name as n}} ... {{ref n}}  - reuse a generated value
+  {{oneof a|b|c}}              - pick one of the choices
+  {{name | upper}}             - filters: upper, lower, title, slug, json
+                                 (oneof needs spaces around a filter '|')
+
+Blocks:
+  {{#repeat 3}}...{{/repeat}}          - repeat N or min-max times, optional sep=","
+  {{#maybe 30%}}...{{/maybe}}          - include with a probability (default 50%)
+  Each repetition gets its own person.
 
 Examples:
     nightwatch fake template "{{name}} <{{email}}>"
+    nightwatch fake template '{"user":{{name as n | json}},"slug":"{{ref n | slug}}"}'
+    nightwatch fake template '{"tags":[{{#repeat 1-3 sep=","}}"{{oneof red|green|blue}}"{{/repeat}}]}'
+    nightwatch fake template "{{person.name}}{{#maybe 0.3}} ({{jobtitle}}){{/maybe}}"
+    nightwatch fake template "{{person.name}} <{{person.email}}>, {{person.city}} {{person.postcode}}"
     nightwatch fake template "{{firstname}} lives in {{city}}, {{county}}"
     nightwatch fake template '{"name":"{{name}}","age":{{number:18:65}}}'`,
 	Args: cobra.ExactArgs(1),
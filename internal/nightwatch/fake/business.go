@@ -0,0 +1,122 @@
+package fake
+
+var (
+	companyAdjectives = []string{"Northern", "Blue", "Summit", "Apex", "Green", "Silver", "Bright", "Granite", "Harbour", "Evergreen", "Pioneer", "Crescent"}
+	companyNouns      = []string{"Systems", "Logistics", "Holdings", "Partners", "Labs", "Foods", "Energy", "Media", "Dynamics", "Ventures", "Analytics", "Works"}
+)
+
+// Company generates a company name with a locale-appropriate suffix.
+func (g *Generator) Company(rng RNG) (string, error) {
+	suffixes := g.locale().CompanySuffixes
+	if len(suffixes) == 0 {
+		suffixes = locales[BaseLocale].CompanySuffixes
+	}
+	pick := func(list []string) (string, error) {
+		idx, err := rng.Intn(len(list))
+		if err != nil {
+			return "", err
+		}
+		return list[idx], nil
+	}
+
+	style, err := rng.Intn(4)
+	if err != nil {
+		return "", err
+	}
+	suffix, err := pick(suffixes)
+	if err != nil {
+		return "", err
+	}
+	switch style {
+	case 0:
+		last, err := g.Lastname(rng)
+		if err != nil {
+			return "", err
+		}
+		return last + " " + suffix, nil
+	case 1:
+		a, err := g.Lastname(rng)
+		if err != nil {
+			return "", err
+		}
+		b, err := g.Lastname(rng)
+		if err != nil {
+			return "", err
+		}
+		return a + " & " + b, nil
+	case 2:
+		last, err := g.Lastname(rng)
+		if err != nil {
+			return "", err
+		}
+		noun, err := pick(companyNouns)
+		if err != nil {
+			return "", err
+		}
+		return last + " " + noun + " " + suffix, nil
+	default:
+		adj, err := pick(companyAdjectives)
+		if err != nil {
+			return "", err
+		}
+		noun, err := pick(companyNouns)
+		if err != nil {
+			return "", err
+		}
+		return adj + " " + noun + " " + suffix, nil
+	}
+}
+
+// jobAreas maps a department to the roles that make sense in it.
+var jobAreas = []struct {
+	area  string
+	roles []string
+}{
+	{"Software", []string{"Engineer", "Developer", "Architect"}},
+	{"Data", []string{"Engineer", "Analyst", "Scientist"}},
+	{"Product", []string{"Manager", "Designer", "Owner"}},
+	{"Marketing", []string{"Manager", "Specialist", "Coordinator"}},
+	{"Sales", []string{"Executive", "Manager", "Representative"}},
+	{"Finance", []string{"Analyst", "Controller", "Manager"}},
+	{"Security", []string{"Engineer", "Analyst", "Architect"}},
+	{"Customer Success", []string{"Manager", "Specialist"}},
+	{"People", []string{"Partner", "Coordinator", "Manager"}},
+	{"Infrastructure", []string{"Engineer", "Architect"}},
+	{"Operations", []string{"Manager", "Analyst", "Coordinator"}},
+}
+
+var jobLevels = []string{"Junior", "Senior", "Lead", "Principal", "Associate"}
+
+// JobTitle generates a job title such as "Senior Data Engineer".
+func (g *Generator) JobTitle(rng RNG) (string, error) {
+	idx, err := rng.Intn(len(jobAreas))
+	if err != nil {
+		return "", err
+	}
+	area := jobAreas[idx]
+
+	style, err := rng.Intn(5)
+	if err != nil {
+		return "", err
+	}
+	switch style {
+	case 0:
+		return "Head of " + area.area, nil
+	case 1, 2:
+		roleIdx, err := rng.Intn(len(area.roles))
+		if err != nil {
+			return "", err
+		}
+		return area.area + " " + area.roles[roleIdx], nil
+	default:
+		levelIdx, err := rng.Intn(len(jobLevels))
+		if err != nil {
+			return "", err
+		}
+		roleIdx, err := rng.Intn(len(area.roles))
+		if err != nil {
+			return "", err
+		}
+		return jobLevels[levelIdx] + " " + area.area + " " + area.roles[roleIdx], nil
+	}
+}
@@ -0,0 +1,320 @@
+package fake
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// maxUniqueAttempts bounds retries when a unique field draws a duplicate.
+const maxUniqueAttempts = 1000
+
+// Table holds the generated rows of one table. Each row has one value per
+// column, in column order; nil is a null.
+type Table struct {
+	Name    string
+	Columns []string
+	Rows    [][]interface{}
+}
+
+// Dataset is the generated output of a Schema, in schema order.
+type Dataset struct {
+	Tables []*Table
+
+	// order lists the tables in generation order, referenced tables first.
+	order []*Table
+}
+
+// dependencyOrder returns the tables with referenced tables first. A
+// Dataset not made by GenerateDataset is taken to be in that order already.
+func (d *Dataset) dependencyOrder() []*Table {
+	if len(d.order) == len(d.Tables) {
+		return d.order
+	}
+	return d.Tables
+}
+
+// Table returns the generated table with the given name.
+func (d *Dataset) Table(name string) (*Table, bool) {
+	for _, t := range d.Tables {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+// GenerateDataset fills every table in the schema. Referenced tables are
+// generated first so foreign keys always point at existing rows; with a
+// seeded RNG the output is reproducible.
+func GenerateDataset(s *Schema, g *Generator, rng RNG) (*Dataset, error) {
+	order, err := s.generationOrder()
+	if err != nil {
+		return nil, err
+	}
+
+	ds := &Dataset{}
+	generated := make(map[string]*Table, len(order))
+	for _, spec := range order {
+		t, err := generateTable(spec, generated, g, rng)
+		if err != nil {
+			return nil, err
+		}
+		generated[spec.Name] = t
+		ds.order = append(ds.order, t)
+	}
+
+	for _, spec := range s.Tables {
+		ds.Tables = append(ds.Tables, generated[spec.Name])
+	}
+	return ds, nil
+}
+
+func generateTable(spec *TableSpec, generated map[string]*Table, g *Generator, rng RNG) (*Table, error) {
+	t := &Table{Name: spec.Name, Rows: make([][]interface{}, 0, spec.Count)}
+	for _, f := range spec.Fields {
+		t.Columns = append(t.Columns, f.Name)
+	}
+
+	columns := make([]*fieldGenerator, len(spec.Fields))
+	for i, f := range spec.Fields {
+		fg, err := newFieldGenerator(f, generated)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", spec.Name, f.Name, err)
+		}
+		columns[i] = fg
+	}
+
+	for n := 0; n < spec.Count; n++ {
+		row := &datasetRow{index: n}
+		values := make([]interface{}, len(columns))
+		for i, fg := range columns {
+			v, err := fg.next(g, rng, row)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s row %d: %w", spec.Name, spec.Fields[i].Name, n+1, err)
+			}
+			values[i] = v
+		}
+		t.Rows = append(t.Rows, values)
+	}
+	return t, nil
+}
+
+// datasetRow is the state shared by the fields of one row. Person fields in
+// the same row describe the same person.
+type datasetRow struct {
+	index  int
+	person *Person
+}
+
+// fieldGenerator produces values for one column, enforcing its constraints.
+type fieldGenerator struct {
+	spec     FieldSpec
+	tmpl     *Template
+	refs     []interface{}
+	seen     map[string]bool
+	cumw     []float64
+	totalW   float64
+	min, max float64
+}
+
+func newFieldGenerator(f FieldSpec, generated map[string]*Table) (*fieldGenerator, error) {
+	fg := &fieldGenerator{spec: f}
+	if f.Unique {
+		fg.seen = make(map[string]bool)
+	}
+
+	switch f.Type {
+	case "integer":
+		fg.min, fg.max = bounds(f, 0, 100)
+	case "float":
+		fg.min, fg.max = bounds(f, 0, 1)
+	case "template":
+		tmpl, err := ParseTemplate(f.Template)
+		if err != nil {
+			return nil, err
+		}
+		fg.tmpl = tmpl
+	case "enum":
+		for i := range f.Values {
+			w := 1.0
+			if len(f.Weights) > 0 {
+				w = f.Weights[i]
+			}
+			fg.totalW += w
+			fg.cumw = append(fg.cumw, fg.totalW)
+		}
+	case "ref":
+		tableName, fieldName, _ := strings.Cut(f.Ref, ".")
+		target, ok := generated[tableName]
+		if !ok {
+			return nil, fmt.Errorf("referenced table %q has not been generated", tableName)
+		}
+		col := -1
+		for i, c := range target.Columns {
+			if c == fieldName {
+				col = i
+			}
+		}
+		for _, row := range target.Rows {
+			if row[col] != nil {
+				fg.refs = append(fg.refs, row[col])
+			}
+		}
+	}
+	return fg, nil
+}
+
+func bounds(f FieldSpec, defMin, defMax float64) (float64, float64) {
+	lo, hi := defMin, defMax
+	if f.Min != nil {
+		lo = *f.Min
+	}
+	if f.Max != nil {
+		hi = *f.Max
+	}
+	if f.Min != nil && f.Max == nil && hi < lo {
+		hi = lo + defMax - defMin
+	}
+	return lo, hi
+}
+
+func (fg *fieldGenerator) next(g *Generator, rng RNG, row *datasetRow) (interface{}, error) {
+	if fg.spec.Nullable > 0 {
+		hit, err := chance(rng, fg.spec.Nullable)
+		if err != nil {
+			return nil, err
+		}
+		if hit {
+			return nil, nil
+		}
+	}
+
+	for attempt := 0; attempt < maxUniqueAttempts; attempt++ {
+		if attempt > 0 && strings.HasPrefix(fg.spec.Type, "person.") {
+			// A duplicate person field needs a different person; put unique
+			// person fields first so the rest of the row follows the new one.
+			row.person = nil
+		}
+		v, err := fg.value(g, rng, row)
+		if err != nil {
+			return nil, err
+		}
+		if fg.seen == nil {
+			return v, nil
+		}
+		key := fmt.Sprint(v)
+		if !fg.seen[key] {
+			fg.seen[key] = true
+			return v, nil
+		}
+	}
+	return nil, fmt.Errorf("could not produce a unique value after %d attempts", maxUniqueAttempts)
+}
+
+func (fg *fieldGenerator) value(g *Generator, rng RNG, row *datasetRow) (interface{}, error) {
+	f := fg.spec
+	switch f.Type {
+	case "sequence":
+		start := 1
+		if f.Start != nil {
+			start = *f.Start
+		}
+		return start + row.index, nil
+	case "integer":
+		return g.Number(rng, int(math.Ceil(fg.min)), int(math.Floor(fg.max)))
+	case "float":
+		frac, err := fraction(rng)
+		if err != nil {
+			return nil, err
+		}
+		v := fg.min + frac*(fg.max-fg.min)
+		precision := f.Precision
+		if precision == 0 {
+			precision = 2
+		}
+		scale := math.Pow(10, float64(precision))
+		return math.Round(v*scale) / scale, nil
+	case "bool":
+		p := 0.5
+		if f.Probability != nil {
+			p = *f.Probability
+		}
+		return chance(rng, p)
+	case "enum":
+		frac, err := fraction(rng)
+		if err != nil {
+			return nil, err
+		}
+		target := frac * fg.totalW
+		for i, c := range fg.cumw {
+			if target < c {
+				return f.Values[i], nil
+			}
+		}
+		return f.Values[len(f.Values)-1], nil
+	case "ref":
+		if len(fg.refs) == 0 {
+			return nil, fmt.Errorf("referenced field %s has no values", f.Ref)
+		}
+		idx, err := rng.Intn(len(fg.refs))
+		if err != nil {
+			return nil, err
+		}
+		return fg.refs[idx], nil
+	case "template":
+		return fg.tmpl.Render(g, rng)
+	case "const":
+		return f.Value, nil
+	case "number":
+		// Keep generator numbers numeric so JSON and SQL output stay typed.
+		s, err := generateForType(g, rng, f.Type, f.Args)
+		if err != nil {
+			return nil, err
+		}
+		return strconv.Atoi(s)
+	default:
+		if field, ok := strings.CutPrefix(f.Type, "person."); ok {
+			if row.person == nil {
+				p, err := g.Person(rng)
+				if err != nil {
+					return nil, err
+				}
+				row.person = p
+			}
+			if field == "age" {
+				return row.person.Age, nil
+			}
+			return personField(row.person, field)
+		}
+		return generateForType(g, rng, f.Type, f.Args)
+	}
+}
+
+// fractionSteps is the resolution of fraction.
+const fractionSteps = 1 << 30
+
+// fraction returns a uniform value in [0, 1) drawn from rng.
+func fraction(rng RNG) (float64, error) {
+	n, err := rng.Intn(fractionSteps)
+	if err != nil {
+		return 0, err
+	}
+	return float64(n) / fractionSteps, nil
+}
+
+// chance reports true with probability p.
+func chance(rng RNG, p float64) (bool, error) {
+	if p <= 0 {
+		return false, nil
+	}
+	if p >= 1 {
+		return true, nil
+	}
+	frac, err := fraction(rng)
+	if err != nil {
+		return false, err
+	}
+	return frac < p, nil
+}
@@ -0,0 +1,292 @@
+package fake
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testSchemaYAML = `
+tables:
+  - name: users
+    count: 20
+    fields:
+      - name: id
+        type: sequence
+      - name: email
+        type: email
+        unique: true
+      - name: age
+        type: integer
+        min: 18
+        max: 65
+      - name: plan
+        type: enum
+        values: [free, pro]
+        weights: [3, 1]
+      - name: nickname
+        type: firstname
+        nullable: 0.5
+  - name: orders
+    count: 50
+    fields:
+      - name: id
+        type: sequence
+        start: 1000
+      - name: user_id
+        type: ref
+        ref: users.id
+      - name: total
+        type: float
+        min: 1
+        max: 500
+        precision: 2
+      - name: note
+        type: template
+        template: "Order for {{city}}"
+`
+
+func writeSchema(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("write schema: %v", err)
+	}
+	return path
+}
+
+func loadTestSchema(t *testing.T) *Schema {
+	t.Helper()
+	s, err := LoadSchema(writeSchema(t, "schema.yaml", testSchemaYAML))
+	if err != nil {
+		t.Fatalf("LoadSchema: %v", err)
+	}
+	return s
+}
+
+func TestGenerateDataset_Deterministic(t *testing.T) {
+	s := loadTestSchema(t)
+	g := testGenerator()
+
+	var out [2]bytes.Buffer
+	for i := range out {
+		ds, err := GenerateDataset(s, g, seededRNG(7))
+		if err != nil {
+			t.Fatalf("GenerateDataset: %v", err)
+		}
+		if err := ds.WriteJSON(&out[i]); err != nil {
+			t.Fatalf("WriteJSON: %v", err)
+		}
+	}
+	if out[0].String() != out[1].String() {
+		t.Error("expected identical output for the same seed")
+	}
+}
+
+func TestGenerateDataset_Constraints(t *testing.T) {
+	s := loadTestSchema(t)
+	ds, err := GenerateDataset(s, testGenerator(), seededRNG(1))
+	if err != nil {
+		t.Fatalf("GenerateDataset: %v", err)
+	}
+
+	users, _ := ds.Table("users")
+	orders, _ := ds.Table("orders")
+	if len(users.Rows) != 20 || len(orders.Rows) != 50 {
+		t.Fatalf("unexpected row counts: %d users, %d orders", len(users.Rows), len(orders.Rows))
+	}
+
+	emails := make(map[string]bool)
+	ids := make(map[interface{}]bool)
+	nulls := 0
+	for i, row := range users.Rows {
+		if row[0] != i+1 {
+			t.Errorf("row %d: expected id %d, got %v", i, i+1, row[0])
+		}
+		ids[row[0]] = true
+		email := row[1].(string)
+		if emails[email] {
+			t.Errorf("duplicate email %q", email)
+		}
+		emails[email] = true
+		if age := row[2].(int); age < 18 || age > 65 {
+			t.Errorf("age %d out of range", age)
+		}
+		if plan := row[3]; plan != "free" && plan != "pro" {
+			t.Errorf("unexpected plan %v", plan)
+		}
+		if row[4] == nil {
+			nulls++
+		}
+	}
+	if nulls == 0 || nulls == len(users.Rows) {
+		t.Errorf("expected some but not all nicknames to be null, got %d", nulls)
+	}
+
+	for _, row := range orders.Rows {
+		if !ids[row[1]] {
+			t.Errorf("order references unknown user %v", row[1])
+		}
+		if total := row[2].(float64); total < 1 || total > 500 {
+			t.Errorf("total %v out of range", total)
+		}
+		if !strings.HasPrefix(row[3].(string), "Order for ") {
+			t.Errorf("unexpected note %q", row[3])
+		}
+	}
+}
+
+func TestGenerateDataset_UniqueExhausted(t *testing.T) {
+	s := &Schema{Tables: []TableSpec{{
+		Name:   "t",
+		Count:  5,
+		Fields: []FieldSpec{{Name: "flag", Type: "bool", Unique: true}},
+	}}}
+	if _, err := GenerateDataset(s, testGenerator(), seededRNG(1)); err == nil {
+		t.Error("expected error when unique values run out")
+	}
+}
+
+func TestGenerateDataset_SequenceStart(t *testing.T) {
+	s, err := LoadSchema(writeSchema(t, "schema.yaml", `
+tables:
+  - name: t
+    count: 2
+    fields:
+      - name: zero
+        type: sequence
+        start: 0
+      - name: default
+        type: sequence
+`))
+	if err != nil {
+		t.Fatalf("LoadSchema: %v", err)
+	}
+	ds, err := GenerateDataset(s, testGenerator(), seededRNG(1))
+	if err != nil {
+		t.Fatalf("GenerateDataset: %v", err)
+	}
+	rows := ds.Tables[0].Rows
+	if rows[0][0] != 0 || rows[1][0] != 1 || rows[0][1] != 1 || rows[1][1] != 2 {
+		t.Errorf("rows = %v, want zero-based and default one-based sequences", rows)
+	}
+}
+
+func TestSchemaValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+	}{
+		{"path in table name", `{"tables":[{"name":"../x","count":1,"fields":[{"name":"id","type":"sequence"}]}]}`},
+		{"backslash in table name", `{"tables":[{"name":"a\\b","count":1,"fields":[{"name":"id","type":"sequence"}]}]}`},
+		{"unknown type", `{"tables":[{"name":"a","count":1,"fields":[{"name":"x","type":"bogus"}]}]}`},
+		{"unknown ref", `{"tables":[{"name":"a","count":1,"fields":[{"name":"x","type":"ref","ref":"b.id"}]}]}`},
+		{"self ref", `{"tables":[{"name":"a","count":1,"fields":[{"name":"id","type":"ref","ref":"a.id"}]}]}`},
+		{"cycle", `{"tables":[
+			{"name":"a","count":1,"fields":[{"name":"id","type":"ref","ref":"b.id"}]},
+			{"name":"b","count":1,"fields":[{"name":"id","type":"ref","ref":"a.id"}]}]}`},
+		{"weights mismatch", `{"tables":[{"name":"a","count":1,"fields":[{"name":"x","type":"enum","values":["a","b"],"weights":[1]}]}]}`},
+		{"nullable range", `{"tables":[{"name":"a","count":1,"fields":[{"name":"x","type":"name","nullable":2}]}]}`},
+		{"bad template", `{"tables":[{"name":"a","count":1,"fields":[{"name":"x","type":"template","template":"{{nope}}"}]}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadSchema(writeSchema(t, "schema.json", tt.schema)); err == nil {
+				t.Error("expected validation error")
+			}
+		})
+	}
+}
+
+func TestDatasetWrite_Formats(t *testing.T) {
+	ds := &Dataset{Tables: []*Table{{
+		Name:    "people",
+		Columns: []string{"id", "name", "active", "score"},
+		Rows: [][]interface{}{
+			{1, "O'Brien", true, 1.5},
+			{2, "Smith, Jo", false, nil},
+		},
+	}}}
+
+	var buf bytes.Buffer
+	if err := ds.Write(&buf, FormatJSON); err != nil {
+		t.Fatalf("json: %v", err)
+	}
+	var parsed map[string][]map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &parsed); err != nil {
+		t.Fatalf("json output does not parse: %v\n%s", err, buf.String())
+	}
+	if len(parsed["people"]) != 2 || parsed["people"][0]["name"] != "O'Brien" {
+		t.Errorf("unexpected json: %s", buf.String())
+	}
+	if !strings.Contains(buf.String(), `{"id":1,"name":"O'Brien","active":true,"score":1.5}`) {
+		t.Errorf("expected field order to be preserved: %s", buf.String())
+	}
+
+	buf.Reset()
+	if err := ds.Write(&buf, FormatJSONL); err != nil {
+		t.Fatalf("jsonl: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || strings.Contains(lines[0], "_table") {
+		t.Errorf("unexpected jsonl: %s", buf.String())
+	}
+
+	buf.Reset()
+	if err := ds.Write(&buf, FormatCSV); err != nil {
+		t.Fatalf("csv: %v", err)
+	}
+	want := "id,name,active,score\n1,O'Brien,true,1.5\n2,\"Smith, Jo\",false,\n"
+	if buf.String() != want {
+		t.Errorf("csv: got %q, want %q", buf.String(), want)
+	}
+
+	buf.Reset()
+	if err := ds.Write(&buf, FormatSQL); err != nil {
+		t.Fatalf("sql: %v", err)
+	}
+	if !strings.Contains(buf.String(), `INSERT INTO "people" ("id", "name", "active", "score") VALUES (1, 'O''Brien', TRUE, 1.5);`) ||
+		!strings.Contains(buf.String(), `VALUES (2, 'Smith, Jo', FALSE, NULL);`) {
+		t.Errorf("unexpected sql: %s", buf.String())
+	}
+
+	if err := ds.Write(&buf, "xml"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
+
+func TestDatasetWrite_SQLReferencedTablesFirst(t *testing.T) {
+	s, err := LoadSchema(writeSchema(t, "schema.json", `{"tables":[
+		{"name":"orders","count":2,"fields":[{"name":"id","type":"sequence"},{"name":"user_id","type":"ref","ref":"users.id"}]},
+		{"name":"users","count":2,"fields":[{"name":"id","type":"sequence"}]}]}`))
+	if err != nil {
+		t.Fatalf("LoadSchema: %v", err)
+	}
+	ds, err := GenerateDataset(s, testGenerator(), seededRNG(1))
+	if err != nil {
+		t.Fatalf("GenerateDataset: %v", err)
+	}
+	if ds.Tables[0].Name != "orders" {
+		t.Errorf("Tables should keep schema order, got %s first", ds.Tables[0].Name)
+	}
+
+	var buf bytes.Buffer
+	if err := ds.WriteSQL(&buf); err != nil {
+		t.Fatalf("WriteSQL: %v", err)
+	}
+	sql := buf.String()
+	if u, o := strings.Index(sql, `INSERT INTO "users"`), strings.Index(sql, `INSERT INTO "orders"`); u < 0 || o < 0 || u > o {
+		t.Errorf("users should be inserted before orders:\n%s", sql)
+	}
+}
+
+func TestDatasetWrite_CSVMultipleTables(t *testing.T) {
+	ds := &Dataset{Tables: []*Table{{Name: "a"}, {Name: "b"}}}
+	if err := ds.Write(&bytes.Buffer{}, FormatCSV); err == nil {
+		t.Error("expected error writing several tables as one csv")
+	}
+}
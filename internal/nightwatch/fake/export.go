@@ -0,0 +1,195 @@
+package fake
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Dataset output formats.
+const (
+	FormatJSON  = "json"
+	FormatJSONL = "jsonl"
+	FormatCSV   = "csv"
+	FormatSQL   = "sql"
+)
+
+// DatasetFormats lists the supported output formats.
+func DatasetFormats() []string {
+	return []string{FormatJSON, FormatJSONL, FormatCSV, FormatSQL}
+}
+
+// Write renders the dataset in the given format. CSV holds a single table
+// per file, so datasets with several tables must be written per table.
+func (d *Dataset) Write(w io.Writer, format string) error {
+	switch format {
+	case FormatJSON:
+		return d.WriteJSON(w)
+	case FormatJSONL:
+		return d.WriteJSONL(w)
+	case FormatCSV:
+		if len(d.Tables) != 1 {
+			return fmt.Errorf("csv output holds one table, dataset has %d", len(d.Tables))
+		}
+		return d.Tables[0].WriteCSV(w)
+	case FormatSQL:
+		return d.WriteSQL(w)
+	default:
+		return fmt.Errorf("unknown format %q (valid: %s)", format, strings.Join(DatasetFormats(), ", "))
+	}
+}
+
+// WriteJSON writes an object mapping each table name to its rows, keeping
+// schema order for tables and fields.
+func (d *Dataset) WriteJSON(w io.Writer) error {
+	var buf bytes.Buffer
+	buf.WriteString("{\n")
+	for i, t := range d.Tables {
+		name, _ := json.Marshal(t.Name)
+		fmt.Fprintf(&buf, "  %s: [", name)
+		for j, row := range t.Rows {
+			if j > 0 {
+				buf.WriteString(",")
+			}
+			buf.WriteString("\n    ")
+			if err := t.writeObject(&buf, row, ""); err != nil {
+				return err
+			}
+		}
+		if len(t.Rows) > 0 {
+			buf.WriteString("\n  ")
+		}
+		buf.WriteString("]")
+		if i < len(d.Tables)-1 {
+			buf.WriteString(",")
+		}
+		buf.WriteString("\n")
+	}
+	buf.WriteString("}\n")
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// WriteJSONL writes one object per line. With several tables each object
+// carries a "_table" key naming its table.
+func (d *Dataset) WriteJSONL(w io.Writer) error {
+	var buf bytes.Buffer
+	for _, t := range d.Tables {
+		tag := ""
+		if len(d.Tables) > 1 {
+			tag = t.Name
+		}
+		for _, row := range t.Rows {
+			if err := t.writeObject(&buf, row, tag); err != nil {
+				return err
+			}
+			buf.WriteString("\n")
+		}
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// writeObject encodes a row as a single-line JSON object in column order.
+func (t *Table) writeObject(buf *bytes.Buffer, row []interface{}, table string) error {
+	buf.WriteString("{")
+	sep := ""
+	if table != "" {
+		name, _ := json.Marshal(table)
+		fmt.Fprintf(buf, `"_table":%s`, name)
+		sep = ","
+	}
+	for i, col := range t.Columns {
+		key, _ := json.Marshal(col)
+		val, err := json.Marshal(row[i])
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", t.Name, col, err)
+		}
+		fmt.Fprintf(buf, "%s%s:%s", sep, key, val)
+		sep = ","
+	}
+	buf.WriteString("}")
+	return nil
+}
+
+// WriteCSV writes a header row followed by the table rows. Nulls are empty
+// cells.
+func (t *Table) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(t.Columns); err != nil {
+		return err
+	}
+	record := make([]string, len(t.Columns))
+	for _, row := range t.Rows {
+		for i, v := range row {
+			record[i] = formatCell(v)
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteSQL writes one INSERT statement per row. Referenced tables are
+// written first, so the script loads into a database enforcing foreign keys.
+func (d *Dataset) WriteSQL(w io.Writer) error {
+	var buf bytes.Buffer
+	for _, t := range d.dependencyOrder() {
+		cols := make([]string, len(t.Columns))
+		for i, c := range t.Columns {
+			cols[i] = quoteIdent(c)
+		}
+		prefix := fmt.Sprintf("INSERT INTO %s (%s) VALUES (", quoteIdent(t.Name), strings.Join(cols, ", "))
+		for _, row := range t.Rows {
+			buf.WriteString(prefix)
+			for i, v := range row {
+				if i > 0 {
+					buf.WriteString(", ")
+				}
+				buf.WriteString(sqlLiteral(v))
+			}
+			buf.WriteString(");\n")
+		}
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func sqlLiteral(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return "NULL"
+	case bool:
+		if x {
+			return "TRUE"
+		}
+		return "FALSE"
+	case int, int64, float64:
+		return formatCell(x)
+	default:
+		return "'" + strings.ReplaceAll(formatCell(x), "'", "''") + "'"
+	}
+}
+
+func formatCell(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	default:
+		return fmt.Sprint(x)
+	}
+}
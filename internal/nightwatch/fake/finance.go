@@ -0,0 +1,222 @@
+package fake
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// cardBrand describes the number ranges of a card scheme.
+type cardBrand struct {
+	prefixes []string
+	length   int
+}
+
+var cardBrands = map[string]cardBrand{
+	"visa":       {prefixes: []string{"4"}, length: 16},
+	"mastercard": {prefixes: []string{"51", "52", "53", "54", "55", "2221", "2720"}, length: 16},
+	"amex":       {prefixes: []string{"34", "37"}, length: 15},
+	"discover":   {prefixes: []string{"6011", "644", "65"}, length: 16},
+	"jcb":        {prefixes: []string{"3528", "3589"}, length: 16},
+}
+
+// CardBrands returns the supported card brands in sorted order.
+func CardBrands() []string {
+	brands := make([]string, 0, len(cardBrands))
+	for b := range cardBrands {
+		brands = append(brands, b)
+	}
+	sort.Strings(brands)
+	return brands
+}
+
+// CardNumber generates a Luhn-valid card number for brand, or for a random
+// brand when brand is empty.
+func (g *Generator) CardNumber(rng RNG, brand string) (string, error) {
+	if brand == "" {
+		names := CardBrands()
+		idx, err := rng.Intn(len(names))
+		if err != nil {
+			return "", err
+		}
+		brand = names[idx]
+	}
+	b, ok := cardBrands[strings.ToLower(brand)]
+	if !ok {
+		return "", fmt.Errorf("unknown card brand %q (valid: %s)", brand, strings.Join(CardBrands(), ", "))
+	}
+
+	idx, err := rng.Intn(len(b.prefixes))
+	if err != nil {
+		return "", err
+	}
+	digits := []byte(b.prefixes[idx])
+	for len(digits) < b.length-1 {
+		d, err := rng.Intn(10)
+		if err != nil {
+			return "", err
+		}
+		digits = append(digits, byte('0'+d))
+	}
+	return string(append(digits, luhnCheckDigit(digits))), nil
+}
+
+// luhnCheckDigit returns the digit that makes digits+check pass the Luhn test.
+func luhnCheckDigit(digits []byte) byte {
+	sum := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if (len(digits)-1-i)%2 == 0 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+// ibanFormats holds the BBAN layout per country, as fillPattern patterns.
+var ibanFormats = map[string]string{
+	"BE": "############",
+	"CH": "#################",
+	"DE": "##################",
+	"ES": "####################",
+	"FR": "#######################",
+	"GB": "AAAA##############",
+	"IE": "AAAA##############",
+	"IT": "A######################",
+	"NL": "AAAA##########",
+}
+
+// IBANCountries returns the countries IBAN can generate for.
+func IBANCountries() []string {
+	codes := make([]string, 0, len(ibanFormats))
+	for c := range ibanFormats {
+		codes = append(codes, c)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// IBAN generates an IBAN with valid check digits. An empty country uses the
+// locale's region, falling back to GB.
+func (g *Generator) IBAN(rng RNG, country string) (string, error) {
+	country = strings.ToUpper(country)
+	if country == "" {
+		country = g.locale().Region
+		if _, ok := ibanFormats[country]; !ok {
+			country = "GB"
+		}
+	}
+	format, ok := ibanFormats[country]
+	if !ok {
+		return "", fmt.Errorf("unsupported IBAN country %q (valid: %s)", country, strings.Join(IBANCountries(), ", "))
+	}
+
+	bban, err := fillPattern(rng, format, "")
+	if err != nil {
+		return "", err
+	}
+	return country + ibanCheckDigits(country, bban) + bban, nil
+}
+
+// ibanCheckDigits computes the ISO 7064 mod 97-10 check digits.
+func ibanCheckDigits(country, bban string) string {
+	var numeric strings.Builder
+	for _, r := range bban + country + "00" {
+		if r >= 'A' && r <= 'Z' {
+			numeric.WriteString(strconv.Itoa(int(r-'A') + 10))
+		} else {
+			numeric.WriteRune(r)
+		}
+	}
+	n, _ := new(big.Int).SetString(numeric.String(), 10)
+	mod := new(big.Int).Mod(n, big.NewInt(97)).Int64()
+	return fmt.Sprintf("%02d", 98-mod)
+}
+
+// currency describes how an ISO 4217 currency is written.
+type currency struct {
+	symbol   string
+	decimals int
+}
+
+var currencies = map[string]currency{
+	"GBP": {"£", 2},
+	"USD": {"$", 2},
+	"EUR": {"€", 2},
+	"JPY": {"¥", 0},
+	"CHF": {"CHF", 2},
+	"CAD": {"CA$", 2},
+	"AUD": {"A$", 2},
+}
+
+// Amount generates a money amount between min and max in the given currency
+// (default: the locale's), formatted with the locale's separators.
+func (g *Generator) Amount(rng RNG, min, max float64, code string) (string, error) {
+	if min > max {
+		return "", fmt.Errorf("min cannot be greater than max")
+	}
+	loc := g.locale()
+	if code == "" {
+		code = loc.Currency
+	}
+	code = strings.ToUpper(code)
+	cur, ok := currencies[code]
+	if !ok {
+		if len(code) != 3 {
+			return "", fmt.Errorf("invalid currency code %q", code)
+		}
+		cur = currency{symbol: code, decimals: 2}
+	}
+
+	scale := 1
+	for i := 0; i < cur.decimals; i++ {
+		scale *= 10
+	}
+	lo, hi := int64(math.Round(min*float64(scale))), int64(math.Round(max*float64(scale)))
+	span := hi - lo + 1
+	if span > 1<<31 {
+		return "", fmt.Errorf("amount range too large")
+	}
+	n, err := rng.Intn(int(span))
+	if err != nil {
+		return "", err
+	}
+	return formatAmount(lo+int64(n), cur, loc), nil
+}
+
+// formatAmount writes minor units using the locale's conventions.
+func formatAmount(minor int64, cur currency, loc *Locale) string {
+	sign := ""
+	if minor < 0 {
+		sign, minor = "-", -minor
+	}
+	scale := int64(1)
+	for i := 0; i < cur.decimals; i++ {
+		scale *= 10
+	}
+
+	whole := strconv.FormatInt(minor/scale, 10)
+	var grouped strings.Builder
+	for i, r := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			grouped.WriteString(loc.GroupSep)
+		}
+		grouped.WriteRune(r)
+	}
+	number := grouped.String()
+	if cur.decimals > 0 {
+		number += loc.DecimalSep + fmt.Sprintf("%0*d", cur.decimals, minor%scale)
+	}
+
+	if loc.CurrencyAfter {
+		return sign + number + " " + cur.symbol
+	}
+	return sign + cur.symbol + number
+}
@@ -0,0 +1,179 @@
+package fake
+
+import (
+	"math/big"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func luhnValid(number string) bool {
+	sum := 0
+	for i := len(number) - 1; i >= 0; i-- {
+		d := int(number[i] - '0')
+		if (len(number)-1-i)%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
+
+func ibanValid(iban string) bool {
+	rearranged := iban[4:] + iban[:4]
+	var numeric strings.Builder
+	for _, r := range rearranged {
+		if r >= 'A' && r <= 'Z' {
+			numeric.WriteString(big.NewInt(int64(r - 'A' + 10)).String())
+		} else {
+			numeric.WriteRune(r)
+		}
+	}
+	n, ok := new(big.Int).SetString(numeric.String(), 10)
+	return ok && new(big.Int).Mod(n, big.NewInt(97)).Int64() == 1
+}
+
+func TestCardNumber_Luhn(t *testing.T) {
+	g := testGenerator()
+	rng := seededRNG(42)
+
+	tests := map[string]*regexp.Regexp{
+		"visa":       regexp.MustCompile(`^4\d{15}$`),
+		"mastercard": regexp.MustCompile(`^(5[1-5]|222|2720)\d+$`),
+		"amex":       regexp.MustCompile(`^3[47]\d{13}$`),
+		"discover":   regexp.MustCompile(`^6\d{15}$`),
+		"jcb":        regexp.MustCompile(`^35\d{14}$`),
+		"":           regexp.MustCompile(`^\d{15,16}$`),
+	}
+	for brand, pattern := range tests {
+		for i := 0; i < 20; i++ {
+			n, err := g.CardNumber(rng, brand)
+			if err != nil {
+				t.Fatalf("CardNumber(%q): %v", brand, err)
+			}
+			if !pattern.MatchString(n) {
+				t.Errorf("%s number %q does not match %s", brand, n, pattern)
+			}
+			if !luhnValid(n) {
+				t.Errorf("%s number %q fails the Luhn check", brand, n)
+			}
+		}
+	}
+
+	if _, err := g.CardNumber(rng, "diners"); err == nil {
+		t.Error("expected error for unknown brand")
+	}
+}
+
+func TestIBAN_CheckDigits(t *testing.T) {
+	g := testGenerator()
+	rng := seededRNG(7)
+
+	lengths := map[string]int{"GB": 22, "DE": 22, "FR": 27, "ES": 24, "NL": 18, "IT": 27, "BE": 16, "CH": 21, "IE": 22}
+	for country, length := range lengths {
+		for i := 0; i < 10; i++ {
+			iban, err := g.IBAN(rng, country)
+			if err != nil {
+				t.Fatalf("IBAN(%s): %v", country, err)
+			}
+			if len(iban) != length || !strings.HasPrefix(iban, country) {
+				t.Errorf("unexpected %s IBAN %q", country, iban)
+			}
+			if !ibanValid(iban) {
+				t.Errorf("IBAN %q has invalid check digits", iban)
+			}
+		}
+	}
+
+	// A known-good example from the IBAN registry.
+	if got := ibanCheckDigits("GB", "WEST12345698765432"); got != "82" {
+		t.Errorf("expected check digits 82, got %s", got)
+	}
+	if _, err := g.IBAN(rng, "US"); err == nil {
+		t.Error("expected error for unsupported country")
+	}
+}
+
+func TestIBAN_LocaleDefault(t *testing.T) {
+	loc, _ := LookupLocale("fr_FR")
+	iban, err := testGenerator().WithLocale(loc, &Generator{}).IBAN(seededRNG(1), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(iban, "FR") {
+		t.Errorf("expected a French IBAN, got %q", iban)
+	}
+
+	loc, _ = LookupLocale("ja_JP")
+	iban, _ = testGenerator().WithLocale(loc, &Generator{}).IBAN(seededRNG(1), "")
+	if !strings.HasPrefix(iban, "GB") {
+		t.Errorf("expected GB fallback for a locale without IBANs, got %q", iban)
+	}
+}
+
+func TestFormatAmount(t *testing.T) {
+	tests := []struct {
+		locale string
+		minor  int64
+		code   string
+		want   string
+	}{
+		{"en_GB", 123456789, "GBP", "£1,234,567.89"},
+		{"en_US", 5, "USD", "$0.05"},
+		{"de_DE", 123456, "EUR", "1.234,56 €"},
+		{"fr_FR", 123456, "EUR", "1 234,56 €"},
+		{"ja_JP", 1234567, "JPY", "¥1,234,567"},
+		{"en_GB", -1050, "GBP", "-£10.50"},
+	}
+	for _, tt := range tests {
+		loc, _ := LookupLocale(tt.locale)
+		if got := formatAmount(tt.minor, currencies[tt.code], loc); got != tt.want {
+			t.Errorf("%s %d %s: got %q, want %q", tt.locale, tt.minor, tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestAmount_Range(t *testing.T) {
+	g := testGenerator()
+	rng := seededRNG(3)
+	for i := 0; i < 50; i++ {
+		s, err := g.Amount(rng, 1, 9.99, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !regexp.MustCompile(`^£[1-9]\.\d\d$`).MatchString(s) {
+			t.Errorf("amount %q out of range", s)
+		}
+	}
+	if s, _ := g.Amount(rng, 1, 1, "XYZ"); s != "XYZ1.00" {
+		t.Errorf("expected unknown currency to use its code, got %q", s)
+	}
+	if _, err := g.Amount(rng, 5, 1, ""); err == nil {
+		t.Error("expected error when min > max")
+	}
+}
+
+func TestCompanyAndJobTitle(t *testing.T) {
+	loc, _ := LookupLocale("de_DE")
+	g := testGenerator().WithLocale(loc, &Generator{})
+	rng := seededRNG(9)
+	for i := 0; i < 30; i++ {
+		c, err := g.Company(rng)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(c, "Ltd") || strings.Contains(c, "Inc.") {
+			t.Errorf("company %q uses a suffix from another locale", c)
+		}
+		title, err := g.JobTitle(rng)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(strings.Fields(title)) < 2 {
+			t.Errorf("unexpected job title %q", title)
+		}
+	}
+}
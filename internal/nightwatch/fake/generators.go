@@ -19,6 +19,14 @@ type Generator struct {
 	Streets     []string
 	StreetTypes []string
 	Postcodes   []string
+
+	// Localities links cities to counties and postcode areas for Person.
+	// It is optional; NewGenerator leaves it empty.
+	Localities []Locality
+
+	// Locale controls name order and address, phone and postcode formats.
+	// Nil means BaseLocale.
+	Locale *Locale
 }
 
 // NewGenerator creates a Generator with the provided data.
@@ -46,7 +54,7 @@ func (g *Generator) Name(rng RNG) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	return first + " " + last, nil
+	return g.fullName(first, last), nil
 }
 
 // Firstname generates a random first name.
@@ -84,14 +92,13 @@ func (g *Generator) Email(rng RNG) (string, error) {
 		return "", err
 	}
 
-	domains := []string{"example.com", "test.com", "email.com", "mail.com", "sample.org"}
-	domainIdx, err := rng.Intn(len(domains))
+	domainIdx, err := rng.Intn(len(emailDomains))
 	if err != nil {
 		return "", err
 	}
 
 	// Format: firstname.lastname@domain
-	email := strings.ToLower(first) + "." + strings.ToLower(last) + "@" + domains[domainIdx]
+	email := nameSlug(first) + "." + nameSlug(last) + "@" + emailDomains[domainIdx]
 	return email, nil
 }
 
@@ -107,11 +114,20 @@ func (g *Generator) Username(rng RNG) (string, error) {
 		return "", err
 	}
 
-	return strings.ToLower(first) + fmt.Sprintf("%d", num+1), nil
+	return nameSlug(first) + fmt.Sprintf("%d", num+1), nil
 }
 
-// Phone generates a random UK-formatted phone number.
+// Phone generates a random phone number in the locale's format, by default a
+// UK mobile number.
 func (g *Generator) Phone(rng RNG) (string, error) {
+	if g.Locale != nil && len(g.Locale.PhoneFormats) > 0 {
+		idx, err := rng.Intn(len(g.Locale.PhoneFormats))
+		if err != nil {
+			return "", err
+		}
+		return fillPattern(rng, g.Locale.PhoneFormats[idx], "")
+	}
+
 	// UK phone formats: 07XXX XXXXXX (mobile) or 01onal area codes
 	// Using mobile format for simplicity: 07XXX XXXXXX
 	prefixes := []string{"07700", "07701", "07702", "07703", "07704", "07705",
@@ -135,7 +151,7 @@ func (g *Generator) Phone(rng RNG) (string, error) {
 		prefixes[prefixIdx], digits[0], digits[1], digits[2], digits[3], digits[4], digits[5]), nil
 }
 
-// Address generates a random UK street address.
+// Address generates a random street address in the locale's format.
 func (g *Generator) Address(rng RNG) (string, error) {
 	if len(g.Streets) == 0 || len(g.StreetTypes) == 0 {
 		return "", fmt.Errorf("no street data loaded")
@@ -157,10 +173,27 @@ func (g *Generator) Address(rng RNG) (string, error) {
 		return "", err
 	}
 
-	return fmt.Sprintf("%d %s %s", num, g.Streets[nameIdx], g.StreetTypes[typeIdx]), nil
+	if g.Locale == nil || g.Locale.AddressFormat == "" {
+		return fmt.Sprintf("%d %s %s", num, g.Streets[nameIdx], g.StreetTypes[typeIdx]), nil
+	}
+
+	format := g.Locale.AddressFormat
+	block := 0
+	if strings.Contains(format, "{block}") {
+		if block, err = rng.Intn(9); err != nil {
+			return "", err
+		}
+		block++
+	}
+	return strings.NewReplacer(
+		"{number}", fmt.Sprintf("%d", num),
+		"{street}", g.Streets[nameIdx],
+		"{type}", g.StreetTypes[typeIdx],
+		"{block}", fmt.Sprintf("%d", block),
+	).Replace(format), nil
 }
 
-// City generates a random UK city name.
+// City generates a random city name.
 func (g *Generator) City(rng RNG) (string, error) {
 	if len(g.Cities) == 0 {
 		return "", fmt.Errorf("no cities loaded")
@@ -173,7 +206,7 @@ func (g *Generator) City(rng RNG) (string, error) {
 	return g.Cities[idx], nil
 }
 
-// County generates a random UK county name.
+// County generates a random county (or state, region or prefecture) name.
 func (g *Generator) County(rng RNG) (string, error) {
 	if len(g.Counties) == 0 {
 		return "", fmt.Errorf("no counties loaded")
@@ -199,8 +232,12 @@ func (g *Generator) Country(rng RNG) (string, error) {
 	return g.Countries[idx], nil
 }
 
-// Postcode generates a random UK postcode.
+// Postcode generates a random postcode in the locale's format, by default a
+// UK postcode.
 func (g *Generator) Postcode(rng RNG) (string, error) {
+	if g.Locale != nil && g.Locale.PostcodeFormat != "" {
+		return fillPattern(rng, g.Locale.PostcodeFormat, "")
+	}
 	if len(g.Postcodes) == 0 {
 		return "", fmt.Errorf("no postcodes loaded")
 	}
@@ -211,8 +248,11 @@ func (g *Generator) Postcode(rng RNG) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	prefix := g.Postcodes[prefixIdx]
+	return postcodeInArea(rng, g.Postcodes[prefixIdx])
+}
 
+// postcodeInArea generates a UK postcode within the given area prefix.
+func postcodeInArea(rng RNG, prefix string) (string, error) {
 	// First part: prefix + 1-2 digits
 	digit1, err := rng.Intn(10)
 	if err != nil {
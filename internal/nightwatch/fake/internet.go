@@ -0,0 +1,333 @@
+package fake
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitlab.com/caffeinatedjack/sleepless/internal/nightwatch/jwt"
+)
+
+var (
+	domainWords = []string{"tech", "labs", "cloud", "data", "digital", "systems", "group", "media", "works", "studio", "hq", "online"}
+	domainTLDs  = []string{"com", "net", "org", "io", "dev"}
+)
+
+// Domain generates a domain name built from a surname and, sometimes, a
+// business word, using generic TLDs or the locale's country TLD.
+func (g *Generator) Domain(rng RNG) (string, error) {
+	last, err := g.Lastname(rng)
+	if err != nil {
+		return "", err
+	}
+	name := nameSlug(last)
+
+	style, err := rng.Intn(3)
+	if err != nil {
+		return "", err
+	}
+	if style > 0 {
+		idx, err := rng.Intn(len(domainWords))
+		if err != nil {
+			return "", err
+		}
+		sep := ""
+		if style == 2 {
+			sep = "-"
+		}
+		name += sep + domainWords[idx]
+	}
+
+	tlds := domainTLDs
+	if tld := g.locale().TLD; tld != "" {
+		tlds = append(append([]string{}, tlds...), tld, tld)
+	}
+	idx, err := rng.Intn(len(tlds))
+	if err != nil {
+		return "", err
+	}
+	return name + "." + tlds[idx], nil
+}
+
+// UserAgentKinds returns the browser families UserAgent can produce.
+func UserAgentKinds() []string {
+	return []string{"chrome", "firefox", "safari", "edge", "mobile"}
+}
+
+var desktopPlatforms = []string{
+	"Windows NT 10.0; Win64; x64",
+	"Macintosh; Intel Mac OS X 10_15_7",
+	"X11; Linux x86_64",
+}
+
+// UserAgent generates a browser user agent string for kind, or a random
+// browser when kind is empty.
+func (g *Generator) UserAgent(rng RNG, kind string) (string, error) {
+	if kind == "" {
+		kinds := UserAgentKinds()
+		idx, err := rng.Intn(len(kinds))
+		if err != nil {
+			return "", err
+		}
+		kind = kinds[idx]
+	}
+
+	num := func(lo, hi int) (int, error) { return g.Number(rng, lo, hi) }
+	platform := func() (string, error) {
+		idx, err := rng.Intn(len(desktopPlatforms))
+		if err != nil {
+			return "", err
+		}
+		return desktopPlatforms[idx], nil
+	}
+	chromeVersion := func() (string, error) {
+		major, err := num(110, 130)
+		if err != nil {
+			return "", err
+		}
+		build, err := num(5000, 6800)
+		if err != nil {
+			return "", err
+		}
+		patch, err := num(0, 200)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%d.0.%d.%d", major, build, patch), nil
+	}
+
+	switch strings.ToLower(kind) {
+	case "chrome", "edge":
+		p, err := platform()
+		if err != nil {
+			return "", err
+		}
+		v, err := chromeVersion()
+		if err != nil {
+			return "", err
+		}
+		ua := fmt.Sprintf("Mozilla/5.0 (%s) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/%s Safari/537.36", p, v)
+		if strings.EqualFold(kind, "edge") {
+			ua += " Edg/" + v
+		}
+		return ua, nil
+	case "firefox":
+		p, err := platform()
+		if err != nil {
+			return "", err
+		}
+		v, err := num(110, 130)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Mozilla/5.0 (%s; rv:%d.0) Gecko/20100101 Firefox/%d.0", p, v, v), nil
+	case "safari":
+		major, err := num(15, 18)
+		if err != nil {
+			return "", err
+		}
+		minor, err := num(0, 6)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/%d.%d Safari/605.1.15", major, minor), nil
+	case "mobile":
+		android, err := rng.Intn(2)
+		if err != nil {
+			return "", err
+		}
+		if android == 1 {
+			release, err := num(11, 15)
+			if err != nil {
+				return "", err
+			}
+			v, err := chromeVersion()
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("Mozilla/5.0 (Linux; Android %d; Pixel %d) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/%s Mobile Safari/537.36", release, release-6, v), nil
+		}
+		major, err := num(15, 18)
+		if err != nil {
+			return "", err
+		}
+		minor, err := num(0, 6)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Mozilla/5.0 (iPhone; CPU iPhone OS %d_%d like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/%d.%d Mobile/15E148 Safari/604.1", major, minor, major, minor), nil
+	default:
+		return "", fmt.Errorf("unknown user agent kind %q (valid: %s)", kind, strings.Join(UserAgentKinds(), ", "))
+	}
+}
+
+// Semver generates a semantic version; with prerelease set it may carry an
+// alpha, beta or rc suffix.
+func (g *Generator) Semver(rng RNG, prerelease bool) (string, error) {
+	parts := make([]int, 3)
+	limits := []int{9, 30, 50}
+	for i, limit := range limits {
+		n, err := rng.Intn(limit + 1)
+		if err != nil {
+			return "", err
+		}
+		parts[i] = n
+	}
+	v := fmt.Sprintf("%d.%d.%d", parts[0], parts[1], parts[2])
+	if !prerelease {
+		return v, nil
+	}
+
+	tags := []string{"alpha", "beta", "rc"}
+	idx, err := rng.Intn(len(tags))
+	if err != nil {
+		return "", err
+	}
+	n, err := rng.Intn(9)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s-%s.%d", v, tags[idx], n+1), nil
+}
+
+var hashFuncs = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// HashAlgorithms returns the algorithms Hash supports.
+func HashAlgorithms() []string {
+	return []string{"md5", "sha1", "sha256", "sha512"}
+}
+
+// Hash generates the hex digest of random input with the given algorithm
+// (default sha256).
+func (g *Generator) Hash(rng RNG, algorithm string) (string, error) {
+	if algorithm == "" {
+		algorithm = "sha256"
+	}
+	newHash, ok := hashFuncs[strings.ToLower(algorithm)]
+	if !ok {
+		return "", fmt.Errorf("unknown hash algorithm %q (valid: %s)", algorithm, strings.Join(HashAlgorithms(), ", "))
+	}
+	input := make([]byte, 32)
+	for i := range input {
+		b, err := rng.Intn(256)
+		if err != nil {
+			return "", err
+		}
+		input[i] = byte(b)
+	}
+	h := newHash()
+	h.Write(input)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// JWT generates an HMAC-signed token for a fake user. The signing secret is
+// random and discarded, so the token decodes but cannot be verified.
+func (g *Generator) JWT(rng RNG, alg string) (string, error) {
+	if alg == "" {
+		alg = "HS256"
+	}
+	alg = strings.ToUpper(alg)
+	if jwt.GetAlgorithmType(alg) != "HMAC" {
+		return "", fmt.Errorf("fake JWTs use HS256, HS384 or HS512, got %q", alg)
+	}
+
+	secret, err := g.Hex(rng, 64)
+	if err != nil {
+		return "", err
+	}
+	sub, err := g.UUID(rng)
+	if err != nil {
+		return "", err
+	}
+	jti, err := g.UUID(rng)
+	if err != nil {
+		return "", err
+	}
+	p, err := g.Person(rng)
+	if err != nil {
+		return "", err
+	}
+	domain, err := g.Domain(rng)
+	if err != nil {
+		return "", err
+	}
+	ago, err := rng.Intn(30 * 24 * 3600)
+	if err != nil {
+		return "", err
+	}
+	iat := time.Now().Add(-time.Duration(ago) * time.Second).Unix()
+
+	claims := map[string]interface{}{
+		"name":  p.Name,
+		"email": p.Email,
+		"exp":   iat + 3600,
+	}
+	result, err := jwt.CreateToken(alg, secret, "", claims, "", "https://auth."+domain, sub, "api", "", "", strconv.FormatInt(iat, 10), jti)
+	if err != nil {
+		return "", err
+	}
+	return result.Token, nil
+}
+
+var colourNames = []string{
+	"black", "white", "red", "green", "blue", "yellow", "orange", "purple", "teal", "navy",
+	"maroon", "olive", "silver", "gray", "coral", "crimson", "gold", "indigo", "salmon", "turquoise",
+}
+
+// ColourFormats returns the formats Colour supports.
+func ColourFormats() []string {
+	return []string{"hex", "rgb", "hsl", "name"}
+}
+
+// Colour generates a colour as #rrggbb, rgb(), hsl() or a CSS colour name.
+func (g *Generator) Colour(rng RNG, format string) (string, error) {
+	switch strings.ToLower(format) {
+	case "", "hex", "rgb":
+		var c [3]int
+		for i := range c {
+			n, err := rng.Intn(256)
+			if err != nil {
+				return "", err
+			}
+			c[i] = n
+		}
+		if strings.EqualFold(format, "rgb") {
+			return fmt.Sprintf("rgb(%d, %d, %d)", c[0], c[1], c[2]), nil
+		}
+		return fmt.Sprintf("#%02x%02x%02x", c[0], c[1], c[2]), nil
+	case "hsl":
+		h, err := rng.Intn(360)
+		if err != nil {
+			return "", err
+		}
+		s, err := rng.Intn(101)
+		if err != nil {
+			return "", err
+		}
+		l, err := rng.Intn(101)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("hsl(%d, %d%%, %d%%)", h, s, l), nil
+	case "name":
+		idx, err := rng.Intn(len(colourNames))
+		if err != nil {
+			return "", err
+		}
+		return colourNames[idx], nil
+	default:
+		return "", fmt.Errorf("unknown colour format %q (valid: %s)", format, strings.Join(ColourFormats(), ", "))
+	}
+}
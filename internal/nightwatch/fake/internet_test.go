@@ -0,0 +1,220 @@
+package fake
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/caffeinatedjack/sleepless/internal/nightwatch/jwt"
+)
+
+func TestDomain_Format(t *testing.T) {
+	g := testGenerator()
+	rng := seededRNG(1)
+	pattern := regexp.MustCompile(`^[a-z0-9-]+\.(com|net|org|io|dev|co\.uk)$`)
+	for i := 0; i < 30; i++ {
+		d, err := g.Domain(rng)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !pattern.MatchString(d) {
+			t.Errorf("unexpected domain %q", d)
+		}
+	}
+}
+
+func TestUserAgent_Kinds(t *testing.T) {
+	g := testGenerator()
+	rng := seededRNG(2)
+	markers := map[string]string{
+		"chrome":  "Chrome/",
+		"firefox": "Firefox/",
+		"safari":  "Version/",
+		"edge":    "Edg/",
+		"mobile":  "Mobile",
+	}
+	for kind, marker := range markers {
+		ua, err := g.UserAgent(rng, kind)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(ua, "Mozilla/5.0 (") || !strings.Contains(ua, marker) {
+			t.Errorf("%s user agent %q missing %q", kind, ua, marker)
+		}
+	}
+	if _, err := g.UserAgent(rng, "netscape"); err == nil {
+		t.Error("expected error for unknown kind")
+	}
+}
+
+func TestSemver_Format(t *testing.T) {
+	g := testGenerator()
+	rng := seededRNG(3)
+	plain := regexp.MustCompile(`^\d+\.\d+\.\d+$`)
+	pre := regexp.MustCompile(`^\d+\.\d+\.\d+-(alpha|beta|rc)\.\d$`)
+	for i := 0; i < 20; i++ {
+		v, _ := g.Semver(rng, false)
+		if !plain.MatchString(v) {
+			t.Errorf("unexpected version %q", v)
+		}
+		v, _ = g.Semver(rng, true)
+		if !pre.MatchString(v) {
+			t.Errorf("unexpected prerelease %q", v)
+		}
+	}
+}
+
+func TestHash_Lengths(t *testing.T) {
+	g := testGenerator()
+	rng := seededRNG(4)
+	lengths := map[string]int{"md5": 32, "sha1": 40, "sha256": 64, "sha512": 128, "": 64}
+	for alg, length := range lengths {
+		h, err := g.Hash(rng, alg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !regexp.MustCompile(`^[0-9a-f]+$`).MatchString(h) || len(h) != length {
+			t.Errorf("%s hash %q has wrong format", alg, h)
+		}
+	}
+	if _, err := g.Hash(rng, "crc32"); err == nil {
+		t.Error("expected error for unknown algorithm")
+	}
+}
+
+func TestJWT_Decodes(t *testing.T) {
+	g := testPersonGenerator(t)
+	token, err := g.JWT(seededRNG(5), "hs384")
+	if err != nil {
+		t.Fatalf("JWT: %v", err)
+	}
+	decoded, err := jwt.DecodeWithoutVerification(token)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if decoded.Header["alg"] != "HS384" {
+		t.Errorf("expected HS384, got %v", decoded.Header["alg"])
+	}
+	for _, claim := range []string{"sub", "name", "email", "iss", "aud", "iat", "exp", "jti"} {
+		if _, ok := decoded.Payload[claim]; !ok {
+			t.Errorf("missing claim %q", claim)
+		}
+	}
+	iat, _ := decoded.Payload["iat"].(float64)
+	exp, _ := decoded.Payload["exp"].(float64)
+	if exp-iat != 3600 {
+		t.Errorf("expected a one hour lifetime, got %v", exp-iat)
+	}
+
+	if _, err := g.JWT(seededRNG(5), "RS256"); err == nil {
+		t.Error("expected error for non-HMAC algorithm")
+	}
+}
+
+func TestColour_Formats(t *testing.T) {
+	g := testGenerator()
+	rng := seededRNG(6)
+	patterns := map[string]*regexp.Regexp{
+		"":     regexp.MustCompile(`^#[0-9a-f]{6}$`),
+		"hex":  regexp.MustCompile(`^#[0-9a-f]{6}$`),
+		"rgb":  regexp.MustCompile(`^rgb\(\d{1,3}, \d{1,3}, \d{1,3}\)$`),
+		"hsl":  regexp.MustCompile(`^hsl\(\d{1,3}, \d{1,3}%, \d{1,3}%\)$`),
+		"name": regexp.MustCompile(`^[a-z]+$`),
+	}
+	for format, pattern := range patterns {
+		c, err := g.Colour(rng, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !pattern.MatchString(c) {
+			t.Errorf("%q colour %q does not match %s", format, c, pattern)
+		}
+	}
+}
+
+func TestLatLong_Bounds(t *testing.T) {
+	g := testGenerator()
+	rng := seededRNG(7)
+	check := func(s string, box [4]float64) {
+		parts := strings.Split(s, ",")
+		lat, _ := strconv.ParseFloat(parts[0], 64)
+		lon, _ := strconv.ParseFloat(parts[1], 64)
+		if lat < box[0] || lat > box[2] || lon < box[1] || lon > box[3] {
+			t.Errorf("%s outside %v", s, box)
+		}
+	}
+
+	box := [4]float64{-10.5, 20, -10, 21}
+	for i := 0; i < 50; i++ {
+		s, err := g.LatLong(rng, box)
+		if err != nil {
+			t.Fatal(err)
+		}
+		check(s, box)
+		s, _ = g.LatLong(rng, [4]float64{})
+		check(s, locales[BaseLocale].Bounds)
+	}
+
+	if _, err := g.LatLong(rng, [4]float64{10, 0, 5, 1}); err == nil {
+		t.Error("expected error for inverted box")
+	}
+	if _, err := g.LatLong(rng, [4]float64{0, 0, 95, 1}); err == nil {
+		t.Error("expected error for latitude above 90")
+	}
+}
+
+func TestTimestamp_Formats(t *testing.T) {
+	g := testGenerator()
+	rng := seededRNG(8)
+	yearAgo := time.Now().AddDate(-1, 0, -1)
+
+	for _, format := range TimestampFormats() {
+		s, err := g.Timestamp(rng, format)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		var ts time.Time
+		switch format {
+		case "unix":
+			n, _ := strconv.ParseInt(s, 10, 64)
+			ts = time.Unix(n, 0)
+		case "unixms":
+			n, _ := strconv.ParseInt(s, 10, 64)
+			ts = time.UnixMilli(n)
+		default:
+			ts, err = time.Parse(timestampLayouts[format], s)
+			if err != nil {
+				t.Errorf("%s: %q does not parse: %v", format, s, err)
+				continue
+			}
+		}
+		if ts.Before(yearAgo) || ts.After(time.Now()) {
+			t.Errorf("%s: %q outside the past year", format, s)
+		}
+	}
+	if _, err := g.Timestamp(rng, "julian"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
+
+func TestTemplate_NewTypes(t *testing.T) {
+	g := testGenerator()
+	tmpl, err := ParseTemplate("{{card:visa}} {{iban:NL}} {{amount:10:20:USD}} {{hash:md5}} {{latlong:0:0:1:1}} {{timestamp:unix}} {{colour}} {{color:rgb}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tmpl.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tmpl.Render(g, seededRNG(9)); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+
+	tmpl, _ = ParseTemplate("x {{card:diners}}")
+	_, err = tmpl.Render(g, seededRNG(9))
+	if err == nil || !strings.Contains(err.Error(), "{{card:diners}}") {
+		t.Errorf("expected render error naming the placeholder, got %v", err)
+	}
+}
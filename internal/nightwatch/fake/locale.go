@@ -0,0 +1,243 @@
+package fake
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// BaseLocale is the locale of the default data set. Other locales fall back
+// to it for any data they do not provide.
+const BaseLocale = "en_GB"
+
+// Locale describes how a locale formats generated values. Word lists (names,
+// streets, cities) are data and are supplied separately to WithLocale.
+//
+// Phone and postcode formats are patterns: '#' is a digit, 'N' a digit from 2
+// to 9 and 'A' an uppercase letter; anything else is copied. An empty
+// PostcodeFormat keeps the UK area-based postcodes and no PhoneFormats keeps
+// UK mobile numbers.
+type Locale struct {
+	Code            string
+	Country         string // English name, as in the base countries list
+	Region          string // ISO 3166 alpha-2 code
+	FamilyNameFirst bool
+	// AddressFormat places {number}, {street}, {type} and {block}. Empty
+	// means "{number} {street} {type}".
+	AddressFormat  string
+	PhoneFormats   []string
+	PostcodeFormat string
+
+	// Currency is the default ISO 4217 code for amounts, written with the
+	// locale's separators and symbol placement.
+	Currency      string
+	DecimalSep    string
+	GroupSep      string
+	CurrencyAfter bool
+
+	CompanySuffixes []string
+	TLD             string
+	// Bounds is the default lat/long box: min lat, min long, max lat, max long.
+	Bounds [4]float64
+}
+
+var locales = map[string]*Locale{
+	"en_GB": {
+		Code:            "en_GB",
+		Country:         "United Kingdom",
+		Region:          "GB",
+		Currency:        "GBP",
+		DecimalSep:      ".",
+		GroupSep:        ",",
+		CompanySuffixes: []string{"Ltd", "PLC", "LLP", "Group"},
+		TLD:             "co.uk",
+		Bounds:          [4]float64{49.9, -8.2, 58.7, 1.8},
+	},
+	"en_US": {
+		Code:            "en_US",
+		Country:         "United States",
+		Region:          "US",
+		PhoneFormats:    []string{"(N##) N##-####", "N##-N##-####"},
+		PostcodeFormat:  "#####",
+		Currency:        "USD",
+		DecimalSep:      ".",
+		GroupSep:        ",",
+		CompanySuffixes: []string{"Inc.", "LLC", "Corp.", "Group"},
+		TLD:             "com",
+		Bounds:          [4]float64{24.5, -124.8, 49.4, -66.9},
+	},
+	"de_DE": {
+		Code:            "de_DE",
+		Country:         "Germany",
+		Region:          "DE",
+		AddressFormat:   "{street}{type} {number}",
+		PhoneFormats:    []string{"0151 ########", "0160 #######", "0171 #######", "030 #######", "089 #######"},
+		PostcodeFormat:  "#####",
+		Currency:        "EUR",
+		DecimalSep:      ",",
+		GroupSep:        ".",
+		CurrencyAfter:   true,
+		CompanySuffixes: []string{"GmbH", "AG", "KG", "GmbH & Co. KG"},
+		TLD:             "de",
+		Bounds:          [4]float64{47.3, 5.9, 55.1, 15.0},
+	},
+	"fr_FR": {
+		Code:            "fr_FR",
+		Country:         "France",
+		Region:          "FR",
+		AddressFormat:   "{number} {type} {street}",
+		PhoneFormats:    []string{"06 ## ## ## ##", "07 ## ## ## ##", "01 ## ## ## ##"},
+		PostcodeFormat:  "#####",
+		Currency:        "EUR",
+		DecimalSep:      ",",
+		GroupSep:        " ",
+		CurrencyAfter:   true,
+		CompanySuffixes: []string{"SARL", "SA", "SAS", "et Fils"},
+		TLD:             "fr",
+		Bounds:          [4]float64{42.3, -4.8, 51.1, 8.2},
+	},
+	"ja_JP": {
+		Code:            "ja_JP",
+		Country:         "Japan",
+		Region:          "JP",
+		FamilyNameFirst: true,
+		AddressFormat:   "{street} {block}-{number}",
+		PhoneFormats:    []string{"090-####-####", "080-####-####", "03-####-####"},
+		PostcodeFormat:  "###-####",
+		Currency:        "JPY",
+		DecimalSep:      ".",
+		GroupSep:        ",",
+		CompanySuffixes: []string{"K.K.", "Co., Ltd.", "Holdings"},
+		TLD:             "jp",
+		Bounds:          [4]float64{31.0, 129.5, 45.5, 145.8},
+	},
+}
+
+// LookupLocale returns a built-in locale. Codes are matched case-insensitively
+// and may use '-' instead of '_'.
+func LookupLocale(code string) (*Locale, error) {
+	norm := strings.ReplaceAll(strings.TrimSpace(code), "-", "_")
+	for c, loc := range locales {
+		if strings.EqualFold(c, norm) {
+			return loc, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown locale %q (valid: %s)", code, strings.Join(LocaleCodes(), ", "))
+}
+
+// LocaleCodes returns the built-in locale codes in sorted order.
+func LocaleCodes() []string {
+	codes := make([]string, 0, len(locales))
+	for c := range locales {
+		codes = append(codes, c)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// WithLocale returns a copy of g that uses loc's formats and every non-empty
+// list from data. Lists data leaves empty fall back to g's. When data has
+// localities but no cities or counties, those are taken from the localities;
+// when it replaces cities without localities, the fallback localities are
+// dropped so Person does not mix places from two locales.
+func (g *Generator) WithLocale(loc *Locale, data *Generator) *Generator {
+	out := *g
+	out.Locale = loc
+
+	override := func(dst *[]string, src []string) {
+		if len(src) > 0 {
+			*dst = src
+		}
+	}
+	override(&out.Firstnames, data.Firstnames)
+	override(&out.Lastnames, data.Lastnames)
+	override(&out.LoremWords, data.LoremWords)
+	override(&out.Countries, data.Countries)
+	override(&out.Streets, data.Streets)
+	override(&out.StreetTypes, data.StreetTypes)
+	override(&out.Postcodes, data.Postcodes)
+
+	if len(data.Localities) > 0 {
+		out.Localities = data.Localities
+		cities, counties := localityNames(data.Localities)
+		override(&out.Cities, cities)
+		override(&out.Counties, counties)
+	} else if len(data.Cities) > 0 || len(data.Counties) > 0 {
+		out.Localities = nil
+	}
+	override(&out.Cities, data.Cities)
+	override(&out.Counties, data.Counties)
+	return &out
+}
+
+// localityNames returns the distinct cities and counties of locs in order.
+func localityNames(locs []Locality) (cities, counties []string) {
+	seenCity := make(map[string]bool)
+	seenCounty := make(map[string]bool)
+	for _, l := range locs {
+		if !seenCity[l.City] {
+			seenCity[l.City] = true
+			cities = append(cities, l.City)
+		}
+		if l.County != "" && !seenCounty[l.County] {
+			seenCounty[l.County] = true
+			counties = append(counties, l.County)
+		}
+	}
+	return cities, counties
+}
+
+// locale returns the generator's locale, defaulting to BaseLocale.
+func (g *Generator) locale() *Locale {
+	if g.Locale != nil {
+		return g.Locale
+	}
+	return locales[BaseLocale]
+}
+
+// country returns the generator's home country.
+func (g *Generator) country() string {
+	if c := g.locale().Country; c != "" {
+		return c
+	}
+	return defaultCountry
+}
+
+// fullName joins a first and last name in the locale's order.
+func (g *Generator) fullName(first, last string) string {
+	if g.locale().FamilyNameFirst {
+		return last + " " + first
+	}
+	return first + " " + last
+}
+
+// fillPattern expands a phone or postcode pattern. Placeholders are filled
+// from prefix first, then at random.
+func fillPattern(rng RNG, pattern, prefix string) (string, error) {
+	var b strings.Builder
+	for _, r := range pattern {
+		var lo, span int
+		switch r {
+		case '#':
+			lo, span = '0', 10
+		case 'N':
+			lo, span = '2', 8
+		case 'A':
+			lo, span = 'A', 26
+		default:
+			b.WriteRune(r)
+			continue
+		}
+		if prefix != "" {
+			b.WriteByte(prefix[0])
+			prefix = prefix[1:]
+			continue
+		}
+		n, err := rng.Intn(span)
+		if err != nil {
+			return "", err
+		}
+		b.WriteRune(rune(lo + n))
+	}
+	return b.String(), nil
+}
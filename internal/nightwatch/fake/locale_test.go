@@ -0,0 +1,179 @@
+package fake
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestLookupLocale(t *testing.T) {
+	for _, code := range []string{"de_DE", "de-DE", "DE_de", " ja_JP "} {
+		if _, err := LookupLocale(code); err != nil {
+			t.Errorf("LookupLocale(%q): %v", code, err)
+		}
+	}
+	if _, err := LookupLocale("xx_XX"); err == nil {
+		t.Error("expected error for unknown locale")
+	}
+	if codes := LocaleCodes(); len(codes) != 5 || codes[0] != "de_DE" {
+		t.Errorf("unexpected locale codes %v", codes)
+	}
+}
+
+func TestWithLocale_Fallback(t *testing.T) {
+	base := testPersonGenerator(t)
+	loc, _ := LookupLocale("en_US")
+	locs, err := ParseLocalities([]string{"Austin|Texas|787", "Boston|Massachusetts|021,022"})
+	if err != nil {
+		t.Fatalf("ParseLocalities: %v", err)
+	}
+
+	g := base.WithLocale(loc, &Generator{Streets: []string{"Maple"}, Localities: locs})
+
+	if strings.Join(g.Firstnames, ",") != strings.Join(base.Firstnames, ",") {
+		t.Error("expected first names to fall back to the base locale")
+	}
+	if strings.Join(g.Cities, ",") != "Austin,Boston" || strings.Join(g.Counties, ",") != "Texas,Massachusetts" {
+		t.Errorf("expected cities and counties from localities, got %v and %v", g.Cities, g.Counties)
+	}
+	if len(base.Streets) == 1 || base.Locale != nil {
+		t.Error("WithLocale must not modify the base generator")
+	}
+
+	rng := seededRNG(5)
+	for i := 0; i < 20; i++ {
+		p, err := g.Person(rng)
+		if err != nil {
+			t.Fatalf("Person: %v", err)
+		}
+		if p.Country != "United States" {
+			t.Errorf("expected United States, got %q", p.Country)
+		}
+		if !regexp.MustCompile(`^(787|021|022)\d\d$`).MatchString(p.Postcode) {
+			t.Errorf("postcode %q does not match %s", p.Postcode, p.City)
+		}
+		if !strings.Contains(p.Address, "Maple") {
+			t.Errorf("expected locale street in %q", p.Address)
+		}
+	}
+}
+
+func TestWithLocale_CitiesWithoutLocalities(t *testing.T) {
+	base := testPersonGenerator(t)
+	loc, _ := LookupLocale("fr_FR")
+	g := base.WithLocale(loc, &Generator{Cities: []string{"Lyon"}})
+	if g.Localities != nil {
+		t.Error("expected base localities to be dropped when cities are replaced")
+	}
+	p, err := g.Person(seededRNG(1))
+	if err != nil {
+		t.Fatalf("Person: %v", err)
+	}
+	if p.City != "Lyon" || p.Country != "France" {
+		t.Errorf("unexpected person location %q, %q", p.City, p.Country)
+	}
+}
+
+func TestLocaleFormats(t *testing.T) {
+	tests := []struct {
+		locale   string
+		phone    string
+		postcode string
+		address  string
+	}{
+		{"en_US", `^(\([2-9]\d\d\) [2-9]\d\d-\d{4}|[2-9]\d\d-[2-9]\d\d-\d{4})$`, `^\d{5}$`, `^\d+ \w+ \w+$`},
+		{"de_DE", `^0\d{2,3} \d{7,8}$`, `^\d{5}$`, `^\w+ \d+$`},
+		{"fr_FR", `^0\d( \d\d){4}$`, `^\d{5}$`, `^\d+ \w+ \w+$`},
+		{"ja_JP", `^0\d0?-\d{4}-\d{4}$`, `^\d{3}-\d{4}$`, `^\w+ \d-\d+$`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			loc, err := LookupLocale(tt.locale)
+			if err != nil {
+				t.Fatal(err)
+			}
+			g := testGenerator().WithLocale(loc, &Generator{})
+			rng := seededRNG(9)
+			for i := 0; i < 20; i++ {
+				phone, _ := g.Phone(rng)
+				if !regexp.MustCompile(tt.phone).MatchString(phone) {
+					t.Errorf("phone %q does not match %s", phone, tt.phone)
+				}
+				postcode, _ := g.Postcode(rng)
+				if !regexp.MustCompile(tt.postcode).MatchString(postcode) {
+					t.Errorf("postcode %q does not match %s", postcode, tt.postcode)
+				}
+				address, _ := g.Address(rng)
+				if !regexp.MustCompile(tt.address).MatchString(address) {
+					t.Errorf("address %q does not match %s", address, tt.address)
+				}
+			}
+		})
+	}
+}
+
+func TestLocale_FamilyNameFirst(t *testing.T) {
+	loc, _ := LookupLocale("ja_JP")
+	g := testGenerator().WithLocale(loc, &Generator{Firstnames: []string{"Yuki"}, Lastnames: []string{"Sato"}})
+	name, err := g.Name(seededRNG(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "Sato Yuki" {
+		t.Errorf("expected family name first, got %q", name)
+	}
+	p, _ := g.Person(seededRNG(1))
+	if p.Name != "Sato Yuki" || !strings.Contains(p.Email, "sato") {
+		t.Errorf("unexpected person %q <%s>", p.Name, p.Email)
+	}
+}
+
+func TestLocale_EmailAndUsernameASCII(t *testing.T) {
+	ascii := regexp.MustCompile(`^[a-z0-9.@]+$`)
+	for code, names := range map[string][2][]string{
+		"de_DE": {{"Jürgen", "Björn"}, {"Schröder", "Weiß"}},
+		"fr_FR": {{"Élodie", "Zoé"}, {"Lefèvre", "D'Arc"}},
+	} {
+		loc, _ := LookupLocale(code)
+		g := testGenerator().WithLocale(loc, &Generator{Firstnames: names[0], Lastnames: names[1]})
+		rng := seededRNG(3)
+		for i := 0; i < 10; i++ {
+			email, _ := g.Email(rng)
+			username, _ := g.Username(rng)
+			if !ascii.MatchString(email) || !ascii.MatchString(username) {
+				t.Errorf("%s: expected ASCII, got %q and %q", code, email, username)
+			}
+		}
+	}
+}
+
+func TestBaseLocale_Unchanged(t *testing.T) {
+	loc, _ := LookupLocale(BaseLocale)
+	base := testGenerator()
+	g := base.WithLocale(loc, &Generator{})
+	for _, gen := range []func(*Generator, RNG) (string, error){
+		(*Generator).Phone, (*Generator).Postcode, (*Generator).Address, (*Generator).Name,
+	} {
+		want, _ := gen(base, seededRNG(11))
+		got, _ := gen(g, seededRNG(11))
+		if got != want {
+			t.Errorf("base locale changed output: %q != %q", got, want)
+		}
+	}
+}
+
+func TestNameSlug(t *testing.T) {
+	tests := map[string]string{
+		"Jürgen":   "juergen",
+		"Élodie":   "elodie",
+		"O'Brien":  "obrien",
+		"Schröder": "schroeder",
+		"太郎":       "user",
+	}
+	for in, want := range tests {
+		if got := nameSlug(in); got != want {
+			t.Errorf("nameSlug(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
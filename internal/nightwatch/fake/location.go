@@ -0,0 +1,82 @@
+package fake
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// LatLong generates a "lat,long" pair with six decimal places inside the
+// bounding box (min lat, min long, max lat, max long). A zero box uses the
+// locale's bounds.
+func (g *Generator) LatLong(rng RNG, box [4]float64) (string, error) {
+	if box == [4]float64{} {
+		box = g.locale().Bounds
+	}
+	minLat, minLon, maxLat, maxLon := box[0], box[1], box[2], box[3]
+	if minLat < -90 || maxLat > 90 || minLon < -180 || maxLon > 180 {
+		return "", fmt.Errorf("bounding box outside valid coordinates")
+	}
+	if minLat > maxLat || minLon > maxLon {
+		return "", fmt.Errorf("bounding box minimum cannot exceed maximum")
+	}
+
+	lat, err := fraction(rng)
+	if err != nil {
+		return "", err
+	}
+	lon, err := fraction(rng)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%.6f,%.6f", minLat+lat*(maxLat-minLat), minLon+lon*(maxLon-minLon)), nil
+}
+
+// timestampLayouts maps Timestamp formats to time layouts. unix and unixms
+// are handled separately.
+var timestampLayouts = map[string]string{
+	"rfc3339": time.RFC3339,
+	"iso8601": "2006-01-02T15:04:05.000Z07:00",
+	"rfc2822": "Mon, 02 Jan 2006 15:04:05 -0700",
+	"rfc1123": time.RFC1123,
+	"http":    "Mon, 02 Jan 2006 15:04:05 GMT",
+	"sql":     "2006-01-02 15:04:05",
+	"date":    "2006-01-02",
+}
+
+// TimestampFormats returns the formats Timestamp supports.
+func TimestampFormats() []string {
+	return []string{"rfc3339", "iso8601", "rfc2822", "rfc1123", "http", "sql", "date", "unix", "unixms"}
+}
+
+// Timestamp generates a UTC time within the past year in the given format
+// (default rfc3339).
+func (g *Generator) Timestamp(rng RNG, format string) (string, error) {
+	if format == "" {
+		format = "rfc3339"
+	}
+	layout, ok := timestampLayouts[format]
+	if !ok && format != "unix" && format != "unixms" {
+		return "", fmt.Errorf("unknown timestamp format %q (valid: %s)", format, strings.Join(TimestampFormats(), ", "))
+	}
+
+	secs, err := rng.Intn(365 * 24 * 3600)
+	if err != nil {
+		return "", err
+	}
+	ms, err := rng.Intn(1000)
+	if err != nil {
+		return "", err
+	}
+	t := time.Now().UTC().Truncate(time.Second).
+		Add(-time.Duration(secs) * time.Second).
+		Add(time.Duration(ms) * time.Millisecond)
+
+	switch format {
+	case "unix":
+		return fmt.Sprintf("%d", t.Unix()), nil
+	case "unixms":
+		return fmt.Sprintf("%d", t.UnixMilli()), nil
+	}
+	return t.Format(layout), nil
+}
@@ -0,0 +1,300 @@
+package fake
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Locality ties a city to its county and postcode areas so addresses can be
+// generated consistently. Areas are UK postcode areas, or leading postcode
+// digits for locales with a PostcodeFormat.
+type Locality struct {
+	City    string
+	County  string
+	Areas   []string
+	Country string
+}
+
+// ParseLocalities parses "City|County|AREA[,AREA...][|Country]" lines.
+func ParseLocalities(lines []string) ([]Locality, error) {
+	var result []Locality
+	for _, line := range lines {
+		parts := strings.Split(line, "|")
+		if len(parts) < 3 || len(parts) > 4 {
+			return nil, fmt.Errorf("invalid locality %q: expected City|County|AREA[,AREA...][|Country]", line)
+		}
+		loc := Locality{
+			City:   strings.TrimSpace(parts[0]),
+			County: strings.TrimSpace(parts[1]),
+		}
+		for _, area := range strings.Split(parts[2], ",") {
+			if area = strings.TrimSpace(area); area != "" {
+				loc.Areas = append(loc.Areas, area)
+			}
+		}
+		if len(parts) == 4 {
+			loc.Country = strings.TrimSpace(parts[3])
+		}
+		if loc.City == "" || len(loc.Areas) == 0 {
+			return nil, fmt.Errorf("invalid locality %q: city and postcode area are required", line)
+		}
+		result = append(result, loc)
+	}
+	return result, nil
+}
+
+// defaultCountry is the country of BaseLocale.
+const defaultCountry = "United Kingdom"
+
+// Person age bounds for Generator.Person.
+const (
+	PersonMinAge = 18
+	PersonMaxAge = 85
+)
+
+// Person is a coherent fake identity: the email and username are derived from
+// the name, the address fields belong together and the date of birth matches
+// the age.
+type Person struct {
+	Firstname string `json:"firstname"`
+	Lastname  string `json:"lastname"`
+	Name      string `json:"name"`
+	Email     string `json:"email"`
+	Username  string `json:"username"`
+	Phone     string `json:"phone"`
+	Address   string `json:"address"`
+	City      string `json:"city"`
+	County    string `json:"county"`
+	Postcode  string `json:"postcode"`
+	Country   string `json:"country"`
+	Birthdate string `json:"birthdate"`
+	Age       int    `json:"age"`
+}
+
+// PersonFields returns the field names addressable as {{person.<field>}}.
+func PersonFields() []string {
+	return []string{
+		"firstname", "lastname", "name", "email", "username", "phone",
+		"address", "city", "county", "postcode", "country", "birthdate", "age",
+	}
+}
+
+// Field returns the named field as a string.
+func (p *Person) Field(name string) (string, bool) {
+	switch name {
+	case "firstname":
+		return p.Firstname, true
+	case "lastname":
+		return p.Lastname, true
+	case "name":
+		return p.Name, true
+	case "email":
+		return p.Email, true
+	case "username":
+		return p.Username, true
+	case "phone":
+		return p.Phone, true
+	case "address":
+		return p.Address, true
+	case "city":
+		return p.City, true
+	case "county":
+		return p.County, true
+	case "postcode":
+		return p.Postcode, true
+	case "country":
+		return p.Country, true
+	case "birthdate":
+		return p.Birthdate, true
+	case "age":
+		return fmt.Sprintf("%d", p.Age), true
+	}
+	return "", false
+}
+
+// Person generates a coherent identity. Without locality data the city,
+// county and postcode are drawn independently.
+func (g *Generator) Person(rng RNG) (*Person, error) {
+	p := &Person{}
+	var err error
+
+	if p.Firstname, err = g.Firstname(rng); err != nil {
+		return nil, err
+	}
+	if p.Lastname, err = g.Lastname(rng); err != nil {
+		return nil, err
+	}
+	p.Name = g.fullName(p.Firstname, p.Lastname)
+
+	first, last := nameSlug(p.Firstname), nameSlug(p.Lastname)
+	if p.Email, err = personEmail(rng, first, last); err != nil {
+		return nil, err
+	}
+	if p.Username, err = personUsername(rng, first, last); err != nil {
+		return nil, err
+	}
+	if p.Phone, err = g.Phone(rng); err != nil {
+		return nil, err
+	}
+	if p.Address, err = g.Address(rng); err != nil {
+		return nil, err
+	}
+	if err := g.personLocality(rng, p); err != nil {
+		return nil, err
+	}
+
+	age, err := g.Number(rng, PersonMinAge, PersonMaxAge)
+	if err != nil {
+		return nil, err
+	}
+	if p.Birthdate, err = birthdateForAge(rng, time.Now(), age); err != nil {
+		return nil, err
+	}
+	p.Age = age
+	return p, nil
+}
+
+func (g *Generator) personLocality(rng RNG, p *Person) error {
+	if len(g.Localities) == 0 {
+		var err error
+		if p.City, err = g.City(rng); err != nil {
+			return err
+		}
+		if p.County, err = g.County(rng); err != nil {
+			return err
+		}
+		if p.Postcode, err = g.Postcode(rng); err != nil {
+			return err
+		}
+		p.Country = g.country()
+		return nil
+	}
+
+	idx, err := rng.Intn(len(g.Localities))
+	if err != nil {
+		return err
+	}
+	loc := g.Localities[idx]
+	areaIdx, err := rng.Intn(len(loc.Areas))
+	if err != nil {
+		return err
+	}
+	area := loc.Areas[areaIdx]
+	if g.Locale != nil && g.Locale.PostcodeFormat != "" {
+		p.Postcode, err = fillPattern(rng, g.Locale.PostcodeFormat, area)
+	} else {
+		p.Postcode, err = postcodeInArea(rng, area)
+	}
+	if err != nil {
+		return err
+	}
+	p.City, p.County, p.Country = loc.City, loc.County, loc.Country
+	if p.Country == "" {
+		p.Country = g.country()
+	}
+	return nil
+}
+
+// birthdateForAge picks a date of birth for someone who is exactly age years
+// old on the given day.
+func birthdateForAge(rng RNG, today time.Time, age int) (string, error) {
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	latest := yearsBefore(today, age)
+	earliest := yearsBefore(today, age+1).AddDate(0, 0, 1)
+	span := int(latest.Sub(earliest).Hours()/24) + 1
+	offset, err := rng.Intn(span)
+	if err != nil {
+		return "", err
+	}
+	return earliest.AddDate(0, 0, offset).Format("2006-01-02"), nil
+}
+
+// yearsBefore goes back n years, clamping 29 February to the 28th rather than
+// rolling over into March.
+func yearsBefore(t time.Time, n int) time.Time {
+	d := time.Date(t.Year()-n, t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	if d.Month() != t.Month() {
+		d = d.AddDate(0, 0, -d.Day())
+	}
+	return d
+}
+
+var emailDomains = []string{"example.com", "test.com", "email.com", "mail.com", "sample.org"}
+
+func personEmail(rng RNG, first, last string) (string, error) {
+	styles := []string{
+		first + "." + last,
+		first + last,
+		first[:1] + "." + last,
+		first + "_" + last,
+	}
+	styleIdx, err := rng.Intn(len(styles))
+	if err != nil {
+		return "", err
+	}
+	local := styles[styleIdx]
+
+	withNumber, err := rng.Intn(4)
+	if err != nil {
+		return "", err
+	}
+	if withNumber == 0 {
+		n, err := rng.Intn(99)
+		if err != nil {
+			return "", err
+		}
+		local += fmt.Sprintf("%d", n+1)
+	}
+
+	domainIdx, err := rng.Intn(len(emailDomains))
+	if err != nil {
+		return "", err
+	}
+	return local + "@" + emailDomains[domainIdx], nil
+}
+
+func personUsername(rng RNG, first, last string) (string, error) {
+	styles := []string{
+		first + last[:1],
+		first[:1] + last,
+		first + "_" + last,
+		first + "." + last,
+	}
+	styleIdx, err := rng.Intn(len(styles))
+	if err != nil {
+		return "", err
+	}
+	n, err := rng.Intn(999)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s%d", styles[styleIdx], n+1), nil
+}
+
+// nameReplacer folds common Latin diacritics so names make valid addresses.
+var nameReplacer = strings.NewReplacer(
+	"ä", "ae", "ö", "oe", "ü", "ue", "ß", "ss",
+	"à", "a", "á", "a", "â", "a", "ã", "a", "å", "a",
+	"ç", "c", "è", "e", "é", "e", "ê", "e", "ë", "e",
+	"ì", "i", "í", "i", "î", "i", "ï", "i", "ñ", "n",
+	"ò", "o", "ó", "o", "ô", "o", "õ", "o", "ø", "o",
+	"ù", "u", "ú", "u", "û", "u", "ý", "y", "ÿ", "y",
+	"ā", "a", "ē", "e", "ī", "i", "ō", "o", "ū", "u",
+)
+
+// nameSlug lowercases a name and keeps only ASCII letters and digits, falling
+// back to "user" when nothing is left.
+func nameSlug(name string) string {
+	name = nameReplacer.Replace(strings.ToLower(name))
+	var b strings.Builder
+	for _, r := range name {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	if b.Len() == 0 {
+		return "user"
+	}
+	return b.String()
+}
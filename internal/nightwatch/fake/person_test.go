@@ -0,0 +1,137 @@
+package fake
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func testPersonGenerator(t *testing.T) *Generator {
+	t.Helper()
+	g := testGenerator()
+	locs, err := ParseLocalities([]string{
+		"London|Greater London|E,SW",
+		"Leeds|West Yorkshire|LS",
+		"Cork|County Cork|T12|Ireland",
+	})
+	if err != nil {
+		t.Fatalf("ParseLocalities: %v", err)
+	}
+	g.Localities = locs
+	return g
+}
+
+func TestPerson_Coherent(t *testing.T) {
+	g := testPersonGenerator(t)
+	rng := seededRNG(42)
+
+	for i := 0; i < 50; i++ {
+		p, err := g.Person(rng)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		first, last := strings.ToLower(p.Firstname), strings.ToLower(p.Lastname)
+		local := strings.SplitN(p.Email, "@", 2)[0]
+		if !strings.Contains(local, last) || !strings.HasPrefix(local, first[:1]) {
+			t.Errorf("email %q does not match name %q", p.Email, p.Name)
+		}
+		if !strings.Contains(p.Username, first) && !strings.Contains(p.Username, last) {
+			t.Errorf("username %q does not match name %q", p.Username, p.Name)
+		}
+
+		switch p.City {
+		case "London":
+			if p.County != "Greater London" || !(strings.HasPrefix(p.Postcode, "E") || strings.HasPrefix(p.Postcode, "SW")) {
+				t.Errorf("inconsistent London address: %+v", p)
+			}
+		case "Leeds":
+			if p.County != "West Yorkshire" || !strings.HasPrefix(p.Postcode, "LS") {
+				t.Errorf("inconsistent Leeds address: %+v", p)
+			}
+		case "Cork":
+			if p.Country != "Ireland" || !strings.HasPrefix(p.Postcode, "T12") {
+				t.Errorf("inconsistent Cork address: %+v", p)
+			}
+		default:
+			t.Errorf("unexpected city %q", p.City)
+		}
+		if p.City != "Cork" && p.Country != defaultCountry {
+			t.Errorf("expected country %q, got %q", defaultCountry, p.Country)
+		}
+
+		if p.Age < PersonMinAge || p.Age > PersonMaxAge {
+			t.Errorf("age %d out of range", p.Age)
+		}
+		dob, err := time.Parse("2006-01-02", p.Birthdate)
+		if err != nil {
+			t.Fatalf("invalid birthdate %q: %v", p.Birthdate, err)
+		}
+		if got := ageOn(dob, time.Now()); got != p.Age {
+			t.Errorf("birthdate %s gives age %d, want %d", p.Birthdate, got, p.Age)
+		}
+	}
+}
+
+func TestBirthdateForAge_Boundaries(t *testing.T) {
+	today := time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)
+	rng := seededRNG(1)
+	for i := 0; i < 2000; i++ {
+		s, err := birthdateForAge(rng, today, 30)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		dob, _ := time.Parse("2006-01-02", s)
+		if got := ageOn(dob, today); got != 30 {
+			t.Fatalf("birthdate %s gives age %d on %s", s, got, today.Format("2006-01-02"))
+		}
+	}
+}
+
+func TestTemplate_PersonFieldsShared(t *testing.T) {
+	g := testPersonGenerator(t)
+	tmpl, err := ParseTemplate("{{person.name}}|{{person.email}}|{{person.city}}")
+	if err != nil {
+		t.Fatalf("ParseTemplate: %v", err)
+	}
+	if err := tmpl.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	out, err := tmpl.Render(g, seededRNG(3))
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	parts := strings.Split(out, "|")
+	last := strings.ToLower(strings.Fields(parts[0])[1])
+	if !strings.Contains(parts[1], last) {
+		t.Errorf("email %q does not belong to %q", parts[1], parts[0])
+	}
+}
+
+func TestTemplate_UnknownPersonField(t *testing.T) {
+	tmpl, err := ParseTemplate("{{person.shoe_size}}")
+	if err != nil {
+		t.Fatalf("ParseTemplate: %v", err)
+	}
+	err = tmpl.Validate()
+	if err == nil || !strings.Contains(err.Error(), "{{person.shoe_size}}") {
+		t.Errorf("expected error naming the placeholder, got %v", err)
+	}
+}
+
+func TestParseLocalities_Invalid(t *testing.T) {
+	for _, line := range []string{"London", "London|Greater London|", "|x|E"} {
+		if _, err := ParseLocalities([]string{line}); err == nil {
+			t.Errorf("expected error for %q", line)
+		}
+	}
+}
+
+func ageOn(dob, day time.Time) int {
+	age := day.Year() - dob.Year()
+	if day.Month() < dob.Month() || (day.Month() == dob.Month() && day.Day() < dob.Day()) {
+		age--
+	}
+	return age
+}
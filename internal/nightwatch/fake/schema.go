@@ -0,0 +1,256 @@
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Schema describes a set of related tables for dataset generation.
+type Schema struct {
+	Tables []TableSpec `yaml:"tables" json:"tables"`
+}
+
+// TableSpec describes one table (or collection of objects).
+type TableSpec struct {
+	Name   string      `yaml:"name" json:"name"`
+	Count  int         `yaml:"count" json:"count"`
+	Fields []FieldSpec `yaml:"fields" json:"fields"`
+}
+
+// FieldSpec describes one column.
+//
+// Type is either a generator type from ValidTypes (with optional Args, as in
+// {{type:arg:arg}}) or one of the dataset types:
+//
+//	sequence  incrementing integer starting at Start (default 1)
+//	integer   integer in [Min, Max]
+//	float     decimal in [Min, Max] rounded to Precision places
+//	bool      true with probability Probability (default 0.5)
+//	enum      one of Values, optionally weighted by Weights
+//	ref       a value of another table's field, given as Ref "table.field"
+//	template  a fake template string rendered per row
+//	const     the literal Value
+type FieldSpec struct {
+	Name        string        `yaml:"name" json:"name"`
+	Type        string        `yaml:"type" json:"type"`
+	Args        []string      `yaml:"args,omitempty" json:"args,omitempty"`
+	Unique      bool          `yaml:"unique,omitempty" json:"unique,omitempty"`
+	Nullable    float64       `yaml:"nullable,omitempty" json:"nullable,omitempty"`
+	Min         *float64      `yaml:"min,omitempty" json:"min,omitempty"`
+	Max         *float64      `yaml:"max,omitempty" json:"max,omitempty"`
+	Precision   int           `yaml:"precision,omitempty" json:"precision,omitempty"`
+	Start       *int          `yaml:"start,omitempty" json:"start,omitempty"`
+	Probability *float64      `yaml:"probability,omitempty" json:"probability,omitempty"`
+	Values      []interface{} `yaml:"values,omitempty" json:"values,omitempty"`
+	Weights     []float64     `yaml:"weights,omitempty" json:"weights,omitempty"`
+	Ref         string        `yaml:"ref,omitempty" json:"ref,omitempty"`
+	Template    string        `yaml:"template,omitempty" json:"template,omitempty"`
+	Value       interface{}   `yaml:"value,omitempty" json:"value,omitempty"`
+}
+
+// Dataset field types in addition to the template generator types.
+var datasetTypes = []string{"sequence", "integer", "float", "bool", "enum", "ref", "template", "const"}
+
+// LoadSchema reads a YAML or JSON schema file and validates it.
+func LoadSchema(path string) (*Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema: %w", err)
+	}
+
+	var s Schema
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Unmarshal(data, &s)
+	} else {
+		err = yaml.Unmarshal(data, &s)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse schema %s: %w", path, err)
+	}
+
+	if err := s.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid schema %s: %w", path, err)
+	}
+	return &s, nil
+}
+
+// Table returns the table spec with the given name.
+func (s *Schema) Table(name string) (*TableSpec, bool) {
+	for i := range s.Tables {
+		if s.Tables[i].Name == name {
+			return &s.Tables[i], true
+		}
+	}
+	return nil, false
+}
+
+// FieldIndex returns the position of a field in the table, or -1.
+func (t *TableSpec) FieldIndex(name string) int {
+	for i, f := range t.Fields {
+		if f.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Validate checks field types, constraints and references.
+func (s *Schema) Validate() error {
+	if len(s.Tables) == 0 {
+		return fmt.Errorf("schema defines no tables")
+	}
+
+	seen := make(map[string]bool)
+	for _, t := range s.Tables {
+		if t.Name == "" {
+			return fmt.Errorf("table without a name")
+		}
+		// Table names become file names with --out.
+		if strings.ContainsAny(t.Name, `/\`) || strings.Contains(t.Name, "..") {
+			return fmt.Errorf("table name %q cannot contain path separators or \"..\"", t.Name)
+		}
+		if seen[t.Name] {
+			return fmt.Errorf("duplicate table %q", t.Name)
+		}
+		seen[t.Name] = true
+		if t.Count < 0 {
+			return fmt.Errorf("table %q: count cannot be negative", t.Name)
+		}
+		if len(t.Fields) == 0 {
+			return fmt.Errorf("table %q has no fields", t.Name)
+		}
+
+		fields := make(map[string]bool)
+		for _, f := range t.Fields {
+			if f.Name == "" {
+				return fmt.Errorf("table %q: field without a name", t.Name)
+			}
+			if fields[f.Name] {
+				return fmt.Errorf("table %q: duplicate field %q", t.Name, f.Name)
+			}
+			fields[f.Name] = true
+			if err := s.validateField(f); err != nil {
+				return fmt.Errorf("%s.%s: %w", t.Name, f.Name, err)
+			}
+		}
+	}
+
+	if _, err := s.generationOrder(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *Schema) validateField(f FieldSpec) error {
+	if f.Nullable < 0 || f.Nullable > 1 {
+		return fmt.Errorf("nullable must be between 0 and 1")
+	}
+	if f.Min != nil && f.Max != nil && *f.Min > *f.Max {
+		return fmt.Errorf("min cannot be greater than max")
+	}
+
+	switch f.Type {
+	case "sequence", "integer", "float", "const":
+	case "bool":
+		if f.Probability != nil && (*f.Probability < 0 || *f.Probability > 1) {
+			return fmt.Errorf("probability must be between 0 and 1")
+		}
+	case "enum":
+		if len(f.Values) == 0 {
+			return fmt.Errorf("enum requires values")
+		}
+		if len(f.Weights) > 0 && len(f.Weights) != len(f.Values) {
+			return fmt.Errorf("enum has %d values but %d weights", len(f.Values), len(f.Weights))
+		}
+		total := 0.0
+		for _, w := range f.Weights {
+			if w < 0 {
+				return fmt.Errorf("enum weights cannot be negative")
+			}
+			total += w
+		}
+		if len(f.Weights) > 0 && total == 0 {
+			return fmt.Errorf("enum weights sum to zero")
+		}
+	case "ref":
+		table, field, ok := strings.Cut(f.Ref, ".")
+		if !ok {
+			return fmt.Errorf("ref must be table.field, got %q", f.Ref)
+		}
+		target, found := s.Table(table)
+		if !found {
+			return fmt.Errorf("ref to unknown table %q", table)
+		}
+		if target.FieldIndex(field) < 0 {
+			return fmt.Errorf("ref to unknown field %q in table %q", field, table)
+		}
+	case "template":
+		tmpl, err := ParseTemplate(f.Template)
+		if err != nil {
+			return err
+		}
+		if err := tmpl.Validate(); err != nil {
+			return err
+		}
+	case "":
+		return fmt.Errorf("missing type")
+	default:
+		if !isValidType(f.Type) {
+			return fmt.Errorf("unknown type %q (valid: %s, %s)", f.Type,
+				strings.Join(ValidTypes(), ", "), strings.Join(datasetTypes, ", "))
+		}
+	}
+	return nil
+}
+
+// generationOrder sorts tables so referenced tables are generated first,
+// keeping schema order among independent tables.
+func (s *Schema) generationOrder() ([]*TableSpec, error) {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int, len(s.Tables))
+	var order []*TableSpec
+
+	var visit func(t *TableSpec, path []string) error
+	visit = func(t *TableSpec, path []string) error {
+		switch state[t.Name] {
+		case done:
+			return nil
+		case visiting:
+			return fmt.Errorf("reference cycle: %s", strings.Join(append(path, t.Name), " -> "))
+		}
+		state[t.Name] = visiting
+		for _, f := range t.Fields {
+			if f.Type != "ref" {
+				continue
+			}
+			name, _, _ := strings.Cut(f.Ref, ".")
+			if name == t.Name {
+				return fmt.Errorf("%s.%s: a table cannot reference itself", t.Name, f.Name)
+			}
+			if dep, ok := s.Table(name); ok {
+				if err := visit(dep, append(path, t.Name)); err != nil {
+					return err
+				}
+			}
+		}
+		state[t.Name] = done
+		order = append(order, t)
+		return nil
+	}
+
+	for i := range s.Tables {
+		if err := visit(&s.Tables[i], nil); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
@@ -0,0 +1,310 @@
+package fake
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Pagination defaults for list endpoints.
+const (
+	DefaultPerPage = 20
+	MaxPerPage     = 100
+)
+
+// ServerOptions configures a fixture server.
+type ServerOptions struct {
+	// Latency delays every response; Jitter adds up to that much more at
+	// random.
+	Latency time.Duration
+	Jitter  time.Duration
+	// ErrorRate is the fraction of requests (0-1) answered with ErrorStatus
+	// (default 500) instead of data.
+	ErrorRate   float64
+	ErrorStatus int
+	// RNG drives jitter and error injection; nil disables both.
+	RNG RNG
+}
+
+// Server serves a generated dataset as a read-only REST API:
+//
+//	GET /                   tables and row counts
+//	GET /{table}            rows, paginated and filtered
+//	GET /{table}/{id}       one row by its id column
+//
+// List endpoints accept page and per_page, q for a case-insensitive search of
+// every column, and column=value filters (repeat a column to match any of
+// several values).
+type Server struct {
+	ds    *Dataset
+	opts  ServerOptions
+	mux   *http.ServeMux
+	sleep func(time.Duration)
+
+	mu sync.Mutex // guards opts.RNG, which is not safe for concurrent use
+}
+
+// NewServer returns a handler serving ds.
+func NewServer(ds *Dataset, opts ServerOptions) *Server {
+	if opts.ErrorStatus == 0 {
+		opts.ErrorStatus = http.StatusInternalServerError
+	}
+	s := &Server{ds: ds, opts: opts, mux: http.NewServeMux(), sleep: time.Sleep}
+	s.mux.HandleFunc("GET /{$}", s.handleIndex)
+	s.mux.HandleFunc("GET /{table}", s.handleList)
+	s.mux.HandleFunc("GET /{table}/{id}", s.handleGet)
+	return s
+}
+
+// ServeHTTP applies CORS headers and fault injection before routing.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Expose-Headers", "X-Total-Count")
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "*")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	delay, fail, err := s.inject()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if delay > 0 {
+		s.sleep(delay)
+	}
+	if fail {
+		writeError(w, s.opts.ErrorStatus, "injected failure")
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// inject draws this request's delay and whether it should fail.
+func (s *Server) inject() (time.Duration, bool, error) {
+	delay := s.opts.Latency
+	if s.opts.RNG == nil {
+		return delay, false, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.opts.Jitter > 0 {
+		n, err := s.opts.RNG.Intn(int(s.opts.Jitter/time.Millisecond) + 1)
+		if err != nil {
+			return 0, false, err
+		}
+		delay += time.Duration(n) * time.Millisecond
+	}
+	fail, err := chance(s.opts.RNG, s.opts.ErrorRate)
+	return delay, fail, err
+}
+
+type tableSummary struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+	URL   string `json:"url"`
+}
+
+func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
+	tables := make([]tableSummary, 0, len(s.ds.Tables))
+	for _, t := range s.ds.Tables {
+		tables = append(tables, tableSummary{Name: t.Name, Count: len(t.Rows), URL: "/" + t.Name})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"tables": tables})
+}
+
+type listResponse struct {
+	Data       []json.RawMessage `json:"data"`
+	Page       int               `json:"page"`
+	PerPage    int               `json:"per_page"`
+	Total      int               `json:"total"`
+	TotalPages int               `json:"total_pages"`
+}
+
+func (s *Server) handleList(w http.ResponseWriter, r *http.Request) {
+	t, ok := s.ds.Table(r.PathValue("table"))
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no table %q", r.PathValue("table")))
+		return
+	}
+
+	query := r.URL.Query()
+	page, err := queryInt(query.Get("page"), 1)
+	if err != nil || page < 1 {
+		writeError(w, http.StatusBadRequest, "page must be a positive integer")
+		return
+	}
+	perPage, err := queryInt(query.Get("per_page"), DefaultPerPage)
+	if err != nil || perPage < 1 || perPage > MaxPerPage {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("per_page must be between 1 and %d", MaxPerPage))
+		return
+	}
+
+	rows, err := filterRows(t, query)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	resp := listResponse{
+		Data:       []json.RawMessage{},
+		Page:       page,
+		PerPage:    perPage,
+		Total:      len(rows),
+		TotalPages: (len(rows) + perPage - 1) / perPage,
+	}
+	start := (page - 1) * perPage
+	for i := start; i < len(rows) && i < start+perPage; i++ {
+		obj, err := rowJSON(t, rows[i])
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		resp.Data = append(resp.Data, obj)
+	}
+	w.Header().Set("X-Total-Count", strconv.Itoa(len(rows)))
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleGet(w http.ResponseWriter, r *http.Request) {
+	t, ok := s.ds.Table(r.PathValue("table"))
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no table %q", r.PathValue("table")))
+		return
+	}
+	col := t.idColumn()
+	id := r.PathValue("id")
+	for _, row := range t.Rows {
+		if cellString(row[col]) == id {
+			obj, err := rowJSON(t, row)
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+			writeJSON(w, http.StatusOK, obj)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, fmt.Sprintf("no %s with %s %q", t.Name, t.Columns[col], id))
+}
+
+// idColumn returns the index of the "id" column, or the first column.
+func (t *Table) idColumn() int {
+	for i, c := range t.Columns {
+		if c == "id" {
+			return i
+		}
+	}
+	return 0
+}
+
+// filterRows returns the rows matching the q search and column filters.
+func filterRows(t *Table, query map[string][]string) ([][]interface{}, error) {
+	filters := make(map[int][]string)
+	var unknown []string
+	for key, values := range query {
+		switch key {
+		case "page", "per_page", "q":
+			continue
+		}
+		col := -1
+		for i, c := range t.Columns {
+			if c == key {
+				col = i
+			}
+		}
+		if col < 0 {
+			unknown = append(unknown, key)
+			continue
+		}
+		filters[col] = values
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unknown filter %s (columns: %s)", strings.Join(unknown, ", "), strings.Join(t.Columns, ", "))
+	}
+
+	search := ""
+	if q := query["q"]; len(q) > 0 {
+		search = strings.ToLower(q[0])
+	}
+
+	var rows [][]interface{}
+	for _, row := range t.Rows {
+		if matchesFilters(row, filters) && matchesSearch(row, search) {
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+func matchesFilters(row []interface{}, filters map[int][]string) bool {
+	for col, values := range filters {
+		cell := cellString(row[col])
+		found := false
+		for _, v := range values {
+			if cell == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func matchesSearch(row []interface{}, search string) bool {
+	if search == "" {
+		return true
+	}
+	for _, v := range row {
+		if v != nil && strings.Contains(strings.ToLower(cellString(v)), search) {
+			return true
+		}
+	}
+	return false
+}
+
+// cellString formats a value as it would appear in a URL.
+func cellString(v interface{}) string {
+	if v == nil {
+		return "null"
+	}
+	return fmt.Sprint(v)
+}
+
+func rowJSON(t *Table, row []interface{}) (json.RawMessage, error) {
+	var buf bytes.Buffer
+	if err := t.writeObject(&buf, row, ""); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func queryInt(s string, def int) (int, error) {
+	if s == "" {
+		return def, nil
+	}
+	return strconv.Atoi(s)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
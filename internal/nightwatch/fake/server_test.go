@@ -0,0 +1,200 @@
+package fake
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testServerDataset(t *testing.T) *Dataset {
+	t.Helper()
+	ds, err := GenerateDataset(loadTestSchema(t), testGenerator(), seededRNG(11))
+	if err != nil {
+		t.Fatalf("GenerateDataset: %v", err)
+	}
+	return ds
+}
+
+func getJSON(t *testing.T, url string, v interface{}) *http.Response {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	if v != nil {
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			t.Fatalf("decode %s: %v", url, err)
+		}
+	}
+	return resp
+}
+
+type testListResponse struct {
+	Data       []map[string]interface{} `json:"data"`
+	Page       int                      `json:"page"`
+	PerPage    int                      `json:"per_page"`
+	Total      int                      `json:"total"`
+	TotalPages int                      `json:"total_pages"`
+}
+
+func TestServer_IndexAndList(t *testing.T) {
+	srv := httptest.NewServer(NewServer(testServerDataset(t), ServerOptions{}))
+	defer srv.Close()
+
+	var index struct {
+		Tables []tableSummary `json:"tables"`
+	}
+	getJSON(t, srv.URL+"/", &index)
+	if len(index.Tables) != 2 || index.Tables[0].Name != "users" || index.Tables[1].Count != 50 {
+		t.Errorf("unexpected index %+v", index)
+	}
+
+	var list testListResponse
+	resp := getJSON(t, srv.URL+"/orders?page=3&per_page=20", &list)
+	if resp.Header.Get("X-Total-Count") != "50" || resp.Header.Get("Access-Control-Allow-Origin") != "*" {
+		t.Errorf("unexpected headers %v", resp.Header)
+	}
+	if list.Total != 50 || list.TotalPages != 3 || list.Page != 3 || len(list.Data) != 10 {
+		t.Errorf("unexpected page: total=%d pages=%d page=%d rows=%d", list.Total, list.TotalPages, list.Page, len(list.Data))
+	}
+	if list.Data[0]["id"] != float64(1040) {
+		t.Errorf("expected page 3 to start at id 1040, got %v", list.Data[0]["id"])
+	}
+
+	getJSON(t, srv.URL+"/orders?page=9", &list)
+	if len(list.Data) != 0 || list.Data == nil {
+		t.Errorf("expected an empty data array past the last page, got %v", list.Data)
+	}
+}
+
+func TestServer_Filter(t *testing.T) {
+	ds := testServerDataset(t)
+	srv := httptest.NewServer(NewServer(ds, ServerOptions{}))
+	defer srv.Close()
+
+	var list testListResponse
+	getJSON(t, srv.URL+"/orders?user_id=3&per_page=100", &list)
+	want := 0
+	for _, row := range ds.Tables[1].Rows {
+		if row[1] == 3 {
+			want++
+		}
+	}
+	if want == 0 {
+		t.Fatal("test dataset has no orders for user 3")
+	}
+	if list.Total != want {
+		t.Errorf("expected %d orders for user 3, got %d", want, list.Total)
+	}
+	for _, row := range list.Data {
+		if row["user_id"] != float64(3) {
+			t.Errorf("filter returned %v", row)
+		}
+	}
+
+	getJSON(t, srv.URL+"/users?plan=free&plan=pro", &list)
+	if list.Total != 20 {
+		t.Errorf("expected repeated filter to match either value, got %d", list.Total)
+	}
+
+	email := ds.Tables[0].Rows[4][1].(string)
+	getJSON(t, srv.URL+"/users?q="+strings.ToUpper(email[:len(email)-2]), &list)
+	if list.Total < 1 {
+		t.Errorf("search for %q found nothing", email)
+	}
+
+	resp := getJSON(t, srv.URL+"/users?shoe=9", nil)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 for unknown filter column, got %d", resp.StatusCode)
+	}
+	resp = getJSON(t, srv.URL+"/users?per_page=1000", nil)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 for oversized page, got %d", resp.StatusCode)
+	}
+}
+
+func TestServer_GetByID(t *testing.T) {
+	srv := httptest.NewServer(NewServer(testServerDataset(t), ServerOptions{}))
+	defer srv.Close()
+
+	var row map[string]interface{}
+	getJSON(t, srv.URL+"/users/7", &row)
+	if row["id"] != float64(7) || row["email"] == "" {
+		t.Errorf("unexpected row %v", row)
+	}
+
+	var errBody map[string]string
+	resp := getJSON(t, srv.URL+"/users/999", &errBody)
+	if resp.StatusCode != http.StatusNotFound || !strings.Contains(errBody["error"], "999") {
+		t.Errorf("expected 404 naming the id, got %d %v", resp.StatusCode, errBody)
+	}
+	resp = getJSON(t, srv.URL+"/widgets", nil)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown table, got %d", resp.StatusCode)
+	}
+
+	resp, err := http.Post(srv.URL+"/users", "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for POST, got %d", resp.StatusCode)
+	}
+}
+
+func TestServer_Deterministic(t *testing.T) {
+	var bodies [2]string
+	for i := range bodies {
+		rec := httptest.NewRecorder()
+		NewServer(testServerDataset(t), ServerOptions{}).ServeHTTP(rec, httptest.NewRequest("GET", "/users?per_page=5", nil))
+		bodies[i] = rec.Body.String()
+	}
+	if bodies[0] != bodies[1] {
+		t.Error("same seed served different data")
+	}
+}
+
+func TestServer_Injection(t *testing.T) {
+	var slept []time.Duration
+	s := NewServer(testServerDataset(t), ServerOptions{
+		Latency:     100 * time.Millisecond,
+		Jitter:      50 * time.Millisecond,
+		ErrorRate:   0.3,
+		ErrorStatus: http.StatusServiceUnavailable,
+		RNG:         seededRNG(1),
+	})
+	s.sleep = func(d time.Duration) { slept = append(slept, d) }
+
+	failures := 0
+	for i := 0; i < 200; i++ {
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, httptest.NewRequest("GET", "/users/1", nil))
+		switch rec.Code {
+		case http.StatusServiceUnavailable:
+			failures++
+		case http.StatusOK:
+		default:
+			t.Fatalf("unexpected status %d", rec.Code)
+		}
+	}
+	if failures < 30 || failures > 90 {
+		t.Errorf("expected about 30%% failures, got %d/200", failures)
+	}
+	for _, d := range slept {
+		if d < 100*time.Millisecond || d > 150*time.Millisecond {
+			t.Errorf("delay %s outside latency+jitter", d)
+		}
+	}
+
+	// Preflight requests skip injection.
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest("OPTIONS", "/users", nil))
+	if rec.Code != http.StatusNoContent || len(slept) != 200 {
+		t.Errorf("unexpected preflight handling: %d", rec.Code)
+	}
+}
@@ -1,19 +1,32 @@
 package fake
 
 import (
+	"encoding/json"
 	"fmt"
 	"regexp"
 	"strconv"
 	"strings"
+	"unicode"
 )
 
 // Token represents a parsed template token.
 type Token struct {
 	Literal     string   // literal text (if IsLiteral)
-	Type        string   // generator type (if !IsLiteral)
-	Args        []string // arguments for the generator
+	Type        string   // generator type, "ref", "oneof", or a block ("repeat", "maybe")
+	Args        []string // arguments for the generator; captured name for ref; choices for oneof
 	IsLiteral   bool
 	RawTemplate string // the original {{...}} string for error messages
+	Pos         int    // byte offset of RawTemplate in the template
+
+	Capture string   // name given with "as name"
+	Filters []string // filters applied in order, from "| filter"
+
+	// Block tokens render Body Min..Max times (repeat) or with probability
+	// Chance (maybe). Sep is written between repetitions.
+	Body     []Token
+	Min, Max int
+	Chance   float64
+	Sep      string
 }
 
 // Template represents a parsed template.
@@ -22,65 +35,262 @@ type Template struct {
 	Tokens []Token
 }
 
-// templateRegex matches {{type}} or {{type:arg1:arg2:...}}
-var templateRegex = regexp.MustCompile(`\{\{(\w+)(?::([^}]+))?\}\}`)
+// Block and expression keywords.
+const (
+	blockRepeat = "repeat"
+	blockMaybe  = "maybe"
+	exprRef     = "ref"
+	exprOneOf   = "oneof"
+
+	// maxRepeat bounds a repeat block so a typo cannot exhaust memory.
+	maxRepeat = 10000
+)
+
+var (
+	// placeholderRegex matches any {{...}} placeholder.
+	placeholderRegex = regexp.MustCompile(`\{\{([^{}]*)\}\}`)
+	// typeRegex matches {{type}}, {{type:arg1:arg2:...}} or {{person.field}}.
+	typeRegex    = regexp.MustCompile(`^(\w+(?:\.\w+)?)(?::(.+))?$`)
+	captureRegex = regexp.MustCompile(`^(.*?)\s+as\s+(\w+)$`)
+	// oneofFilterSep separates filters from oneof choices, which use '|'
+	// themselves: a filter pipe must have whitespace on at least one side.
+	oneofFilterSep = regexp.MustCompile(`\s+\|\s*|\s*\|\s+`)
+	repeatRegex    = regexp.MustCompile(`^(\d+)(?:-(\d+))?(?:\s+sep=(.*))?$`)
+	refNameRegex   = regexp.MustCompile(`^\w+$`)
+)
 
 // ParseTemplate parses a template string into tokens.
+//
+// Besides {{type}} and {{type:arg:...}} placeholders, templates support:
+//
+//	{{type as n}} ... {{ref n}}        reuse a generated value
+//	{{oneof a|b|c}}                    pick one of the choices
+//	{{type | upper | json}}            filters: upper, lower, title, slug, json
+//	{{#repeat 3}}...{{/repeat}}        repeat a section (also 2-5, sep=",")
+//	{{#maybe 0.3}}...{{/maybe}}        include a section with a probability
 func ParseTemplate(s string) (*Template, error) {
 	t := &Template{Raw: s}
 
-	matches := templateRegex.FindAllStringSubmatchIndex(s, -1)
+	matches := placeholderRegex.FindAllStringSubmatchIndex(s, -1)
 	if len(matches) == 0 {
 		// No placeholders, entire string is literal
 		t.Tokens = []Token{{Literal: s, IsLiteral: true}}
 		return t, nil
 	}
 
+	// stack[0] collects top-level tokens; each open block is pushed on top.
+	stack := []*Token{{}}
+	add := func(tok Token) {
+		top := stack[len(stack)-1]
+		top.Body = append(top.Body, tok)
+	}
+
 	lastEnd := 0
 	for _, match := range matches {
 		// match[0], match[1] = full match start/end
-		// match[2], match[3] = type group start/end
-		// match[4], match[5] = args group start/end (if present)
+		// match[2], match[3] = placeholder body start/end
 
 		// Add literal before this match
 		if match[0] > lastEnd {
-			t.Tokens = append(t.Tokens, Token{
-				Literal:   s[lastEnd:match[0]],
-				IsLiteral: true,
-			})
+			add(Token{Literal: s[lastEnd:match[0]], IsLiteral: true})
 		}
+		lastEnd = match[1]
 
-		// Extract type
-		typeName := s[match[2]:match[3]]
+		raw := s[match[0]:match[1]]
+		body := strings.TrimSpace(s[match[2]:match[3]])
 
-		// Extract args if present
-		var args []string
-		if match[4] != -1 {
-			argsStr := s[match[4]:match[5]]
-			args = strings.Split(argsStr, ":")
+		if name, ok := strings.CutPrefix(body, "/"); ok {
+			top := stack[len(stack)-1]
+			if len(stack) == 1 || top.Type != strings.TrimSpace(name) {
+				return nil, fmt.Errorf("unexpected %s at offset %d", raw, match[0])
+			}
+			stack = stack[:len(stack)-1]
+			add(*top)
+			continue
 		}
 
-		t.Tokens = append(t.Tokens, Token{
-			Type:        typeName,
-			Args:        args,
-			IsLiteral:   false,
-			RawTemplate: s[match[0]:match[1]],
-		})
+		tok, err := parsePlaceholder(body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid placeholder %s at offset %d: %w", raw, match[0], err)
+		}
+		tok.RawTemplate = raw
+		tok.Pos = match[0]
+		if tok.Type == blockRepeat || tok.Type == blockMaybe {
+			stack = append(stack, &tok)
+			continue
+		}
+		add(tok)
+	}
 
-		lastEnd = match[1]
+	if len(stack) > 1 {
+		top := stack[len(stack)-1]
+		return nil, fmt.Errorf("unclosed %s at offset %d", top.RawTemplate, top.Pos)
 	}
 
 	// Add any remaining literal after the last match
 	if lastEnd < len(s) {
-		t.Tokens = append(t.Tokens, Token{
-			Literal:   s[lastEnd:],
-			IsLiteral: true,
-		})
+		add(Token{Literal: s[lastEnd:], IsLiteral: true})
 	}
 
+	t.Tokens = stack[0].Body
 	return t, nil
 }
 
+// parsePlaceholder parses the trimmed body of a {{...}} placeholder.
+func parsePlaceholder(body string) (Token, error) {
+	if block, ok := strings.CutPrefix(body, "#"); ok {
+		return parseBlock(block)
+	}
+
+	var tok Token
+	var parts []string
+	if strings.HasPrefix(body, exprOneOf+" ") {
+		parts = oneofFilterSep.Split(body, -1)
+	} else {
+		parts = strings.Split(body, "|")
+	}
+	head := strings.TrimSpace(parts[0])
+	for _, f := range parts[1:] {
+		tok.Filters = append(tok.Filters, strings.TrimSpace(f))
+	}
+
+	if m := captureRegex.FindStringSubmatch(head); m != nil {
+		head, tok.Capture = strings.TrimSpace(m[1]), m[2]
+	}
+
+	keyword, rest, _ := strings.Cut(head, " ")
+	rest = strings.TrimSpace(rest)
+	switch keyword {
+	case exprRef:
+		if !refNameRegex.MatchString(rest) {
+			return tok, fmt.Errorf("ref needs a name")
+		}
+		tok.Type, tok.Args = exprRef, []string{rest}
+		return tok, nil
+	case exprOneOf:
+		if rest == "" {
+			return tok, fmt.Errorf("oneof needs choices separated by '|'")
+		}
+		tok.Type, tok.Args = exprOneOf, strings.Split(rest, "|")
+		return tok, nil
+	}
+
+	m := typeRegex.FindStringSubmatch(head)
+	if m == nil {
+		return tok, fmt.Errorf("expected {{type}} or {{type:args}}")
+	}
+	tok.Type = m[1]
+	if m[2] != "" {
+		tok.Args = strings.Split(m[2], ":")
+	}
+	return tok, nil
+}
+
+// parseBlock parses the body of a {{#block ...}} opening placeholder.
+func parseBlock(body string) (Token, error) {
+	name, rest, _ := strings.Cut(body, " ")
+	rest = strings.TrimSpace(rest)
+	tok := Token{Type: name}
+
+	switch name {
+	case blockRepeat:
+		m := repeatRegex.FindStringSubmatch(rest)
+		if m == nil {
+			return tok, fmt.Errorf("repeat takes a count or min-max range, optionally followed by sep=...")
+		}
+		tok.Min, _ = strconv.Atoi(m[1])
+		tok.Max = tok.Min
+		if m[2] != "" {
+			tok.Max, _ = strconv.Atoi(m[2])
+		}
+		if tok.Min > tok.Max {
+			return tok, fmt.Errorf("repeat range %d-%d is inverted", tok.Min, tok.Max)
+		}
+		if tok.Max > maxRepeat {
+			return tok, fmt.Errorf("repeat count cannot exceed %d", maxRepeat)
+		}
+		tok.Sep = m[3]
+		if unquoted, err := strconv.Unquote(m[3]); err == nil {
+			tok.Sep = unquoted
+		}
+	case blockMaybe:
+		tok.Chance = 0.5
+		if rest != "" {
+			p, err := parseProbability(rest)
+			if err != nil {
+				return tok, err
+			}
+			tok.Chance = p
+		}
+	default:
+		return tok, fmt.Errorf("unknown block %q (valid: %s, %s)", name, blockRepeat, blockMaybe)
+	}
+	return tok, nil
+}
+
+// parseProbability accepts a fraction (0.3) or a percentage (30%).
+func parseProbability(s string) (float64, error) {
+	scale := 1.0
+	if pct, ok := strings.CutSuffix(s, "%"); ok {
+		s, scale = pct, 100
+	}
+	p, err := strconv.ParseFloat(s, 64)
+	if err != nil || p < 0 || p/scale > 1 {
+		return 0, fmt.Errorf("invalid probability %q (use 0-1 or 0-100%%)", s)
+	}
+	return p / scale, nil
+}
+
+// templateFilters transform a rendered value.
+var templateFilters = map[string]func(string) string{
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"title": titleCase,
+	"slug":  slugify,
+	"json": func(s string) string {
+		b, _ := json.Marshal(s)
+		return string(b)
+	},
+}
+
+// Filters returns the names of the template filters.
+func Filters() []string {
+	return []string{"upper", "lower", "title", "slug", "json"}
+}
+
+// titleCase upper-cases the first letter of each word.
+func titleCase(s string) string {
+	var b strings.Builder
+	prev := ' '
+	for _, r := range s {
+		if unicode.IsSpace(prev) || prev == '-' {
+			r = unicode.ToUpper(r)
+		}
+		b.WriteRune(r)
+		prev = r
+	}
+	return b.String()
+}
+
+// slugify lower-cases s, folds diacritics and joins words with hyphens.
+func slugify(s string) string {
+	s = nameReplacer.Replace(strings.ToLower(s))
+	var b strings.Builder
+	dash := false
+	for _, r := range s {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	return b.String()
+}
+
 // ValidTypes returns the list of valid generator types.
 func ValidTypes() []string {
 	return []string{
@@ -90,11 +300,22 @@ func ValidTypes() []string {
 		"uuid", "hex", "number",
 		"lorem", "word", "sentence", "paragraph",
 		"url", "ipv4", "ipv6", "mac",
+		"card", "iban", "amount", "company", "jobtitle",
+		"domain", "useragent", "semver", "hash", "jwt",
+		"color", "colour", "latlong", "timestamp",
 	}
 }
 
 // isValidType checks if a type name is valid.
 func isValidType(typeName string) bool {
+	if field, ok := strings.CutPrefix(typeName, "person."); ok {
+		for _, f := range PersonFields() {
+			if f == field {
+				return true
+			}
+		}
+		return false
+	}
 	for _, t := range ValidTypes() {
 		if t == typeName {
 			return true
@@ -103,36 +324,158 @@ func isValidType(typeName string) bool {
 	return false
 }
 
-// Validate checks that all placeholders use valid types.
+// Validate checks that all placeholders use valid types and filters, and
+// that every {{ref name}} follows an "as name" capture.
 func (t *Template) Validate() error {
-	for _, token := range t.Tokens {
-		if !token.IsLiteral {
+	return validateTokens(t.Tokens, make(map[string]bool))
+}
+
+func validateTokens(tokens []Token, captured map[string]bool) error {
+	for _, token := range tokens {
+		if token.IsLiteral {
+			continue
+		}
+		switch token.Type {
+		case blockRepeat, blockMaybe:
+			if err := validateTokens(token.Body, captured); err != nil {
+				return err
+			}
+		case exprRef:
+			if !captured[token.Args[0]] {
+				return fmt.Errorf("unknown reference %q in template placeholder %s (capture it first with \"as %s\")", token.Args[0], token.RawTemplate, token.Args[0])
+			}
+		case exprOneOf:
+		default:
 			if !isValidType(token.Type) {
 				return fmt.Errorf("unknown type %q in template placeholder %s", token.Type, token.RawTemplate)
 			}
 		}
+		for _, f := range token.Filters {
+			if _, ok := templateFilters[f]; !ok {
+				return fmt.Errorf("unknown filter %q in template placeholder %s (valid: %s)", f, token.RawTemplate, strings.Join(Filters(), ", "))
+			}
+		}
+		if token.Capture != "" {
+			captured[token.Capture] = true
+		}
 	}
 	return nil
 }
 
-// Render renders the template using the generator and RNG.
+// renderState carries the values shared across one rendering.
+type renderState struct {
+	g      *Generator
+	rng    RNG
+	person *Person
+	vars   map[string]string
+}
+
+// Render renders the template using the generator and RNG. All
+// {{person.field}} placeholders in one rendering refer to the same person,
+// except inside a repeat block, where each repetition gets its own.
 func (t *Template) Render(g *Generator, rng RNG) (string, error) {
 	var result strings.Builder
+	st := &renderState{g: g, rng: rng, vars: make(map[string]string)}
+	if err := st.render(&result, t.Tokens); err != nil {
+		return "", err
+	}
+	return result.String(), nil
+}
 
-	for _, token := range t.Tokens {
+func (st *renderState) render(out *strings.Builder, tokens []Token) error {
+	for _, token := range tokens {
 		if token.IsLiteral {
-			result.WriteString(token.Literal)
+			out.WriteString(token.Literal)
 			continue
 		}
 
-		value, err := generateForType(g, rng, token.Type, token.Args)
+		switch token.Type {
+		case blockRepeat:
+			n := token.Min
+			if token.Max > token.Min {
+				extra, err := st.rng.Intn(token.Max - token.Min + 1)
+				if err != nil {
+					return err
+				}
+				n += extra
+			}
+			outer := st.person
+			for i := 0; i < n; i++ {
+				if i > 0 {
+					out.WriteString(token.Sep)
+				}
+				st.person = nil
+				if err := st.render(out, token.Body); err != nil {
+					return err
+				}
+			}
+			st.person = outer
+			continue
+		case blockMaybe:
+			include, err := chance(st.rng, token.Chance)
+			if err != nil {
+				return err
+			}
+			if include {
+				if err := st.render(out, token.Body); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		value, err := st.value(token)
 		if err != nil {
-			return "", fmt.Errorf("error generating %s: %w", token.RawTemplate, err)
+			return fmt.Errorf("error generating %s: %w", token.RawTemplate, err)
+		}
+		if token.Capture != "" {
+			st.vars[token.Capture] = value
+		}
+		for _, name := range token.Filters {
+			filter, ok := templateFilters[name]
+			if !ok {
+				return fmt.Errorf("unknown filter %q in template placeholder %s", name, token.RawTemplate)
+			}
+			value = filter(value)
 		}
-		result.WriteString(value)
+		out.WriteString(value)
 	}
+	return nil
+}
 
-	return result.String(), nil
+// value produces the unfiltered value of an expression token. A reference to
+// a capture inside a skipped optional section renders empty.
+func (st *renderState) value(token Token) (string, error) {
+	switch token.Type {
+	case exprRef:
+		return st.vars[token.Args[0]], nil
+	case exprOneOf:
+		idx, err := st.rng.Intn(len(token.Args))
+		if err != nil {
+			return "", err
+		}
+		return token.Args[idx], nil
+	}
+
+	if field, ok := strings.CutPrefix(token.Type, "person."); ok {
+		if st.person == nil {
+			p, err := st.g.Person(st.rng)
+			if err != nil {
+				return "", err
+			}
+			st.person = p
+		}
+		return personField(st.person, field)
+	}
+	return generateForType(st.g, st.rng, token.Type, token.Args)
+}
+
+// Generate produces one value of a template type, as {{type:args...}} would.
+func (g *Generator) Generate(rng RNG, typeName string, args ...string) (string, error) {
+	if !isValidType(typeName) {
+		return "", fmt.Errorf("unknown type %q", typeName)
+	}
+	return generateForType(g, rng, typeName, args)
 }
 
 // generateForType generates a value for the given type and args.
@@ -223,7 +566,92 @@ func generateForType(g *Generator, rng RNG, typeName string, args []string) (str
 		return g.IPv6(rng)
 	case "mac":
 		return g.MAC(rng)
+	case "card":
+		return g.CardNumber(rng, argAt(args, 0))
+	case "iban":
+		return g.IBAN(rng, argAt(args, 0))
+	case "amount":
+		min, max := 1.0, 1000.0
+		nums, err := parseFloatArgs(args, 2)
+		if err != nil {
+			return "", err
+		}
+		switch len(nums) {
+		case 1:
+			max = nums[0]
+		case 2:
+			min, max = nums[0], nums[1]
+		}
+		return g.Amount(rng, min, max, argAt(args, 2))
+	case "company":
+		return g.Company(rng)
+	case "jobtitle":
+		return g.JobTitle(rng)
+	case "domain":
+		return g.Domain(rng)
+	case "useragent":
+		return g.UserAgent(rng, argAt(args, 0))
+	case "semver":
+		return g.Semver(rng, argAt(args, 0) == "pre")
+	case "hash":
+		return g.Hash(rng, argAt(args, 0))
+	case "jwt":
+		return g.JWT(rng, argAt(args, 0))
+	case "color", "colour":
+		return g.Colour(rng, argAt(args, 0))
+	case "latlong":
+		var box [4]float64
+		if len(args) > 0 {
+			if len(args) != 4 {
+				return "", fmt.Errorf("latlong takes minLat:minLong:maxLat:maxLong")
+			}
+			nums, err := parseFloatArgs(args, 4)
+			if err != nil {
+				return "", err
+			}
+			copy(box[:], nums)
+		}
+		return g.LatLong(rng, box)
+	case "timestamp":
+		return g.Timestamp(rng, argAt(args, 0))
 	default:
+		if field, ok := strings.CutPrefix(typeName, "person."); ok {
+			p, err := g.Person(rng)
+			if err != nil {
+				return "", err
+			}
+			return personField(p, field)
+		}
 		return "", fmt.Errorf("unknown type: %s", typeName)
 	}
 }
+
+// argAt returns args[i], or "" when absent.
+func argAt(args []string, i int) string {
+	if i < len(args) {
+		return args[i]
+	}
+	return ""
+}
+
+// parseFloatArgs parses up to n leading args as numbers.
+func parseFloatArgs(args []string, n int) ([]float64, error) {
+	var nums []float64
+	for i := 0; i < len(args) && i < n; i++ {
+		f, err := strconv.ParseFloat(args[i], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", args[i])
+		}
+		nums = append(nums, f)
+	}
+	return nums, nil
+}
+
+// personField returns a field of p, or an error naming the valid fields.
+func personField(p *Person, field string) (string, error) {
+	value, ok := p.Field(field)
+	if !ok {
+		return "", fmt.Errorf("unknown person field %q (valid: %s)", field, strings.Join(PersonFields(), ", "))
+	}
+	return value, nil
+}
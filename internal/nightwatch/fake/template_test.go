@@ -0,0 +1,159 @@
+package fake
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func renderTemplate(t *testing.T, s string, seed int64) string {
+	t.Helper()
+	tmpl, err := ParseTemplate(s)
+	if err != nil {
+		t.Fatalf("ParseTemplate(%q): %v", s, err)
+	}
+	if err := tmpl.Validate(); err != nil {
+		t.Fatalf("Validate(%q): %v", s, err)
+	}
+	out, err := tmpl.Render(testGenerator(), seededRNG(seed))
+	if err != nil {
+		t.Fatalf("Render(%q): %v", s, err)
+	}
+	return out
+}
+
+func TestTemplate_BackwardCompatible(t *testing.T) {
+	out := renderTemplate(t, `{"n":{{number:5:5}},"h":"{{hex:4}}"} {{ not a placeholder`, 1)
+	if !regexp.MustCompile(`^\{"n":5,"h":"[0-9a-f]{4}"\} \{\{ not a placeholder$`).MatchString(out) {
+		t.Errorf("unexpected output %q", out)
+	}
+}
+
+func TestTemplate_CaptureAndRef(t *testing.T) {
+	out := renderTemplate(t, "{{name as who}} / {{ref who}} / {{ref who | upper}}", 2)
+	parts := strings.Split(out, " / ")
+	if len(parts) != 3 || parts[0] != parts[1] || parts[2] != strings.ToUpper(parts[0]) {
+		t.Errorf("references do not match capture: %q", out)
+	}
+}
+
+func TestTemplate_Filters(t *testing.T) {
+	tests := map[string]string{
+		"{{oneof Crème Brûlée | slug}}":        "creme-brulee",
+		"{{oneof hello world | upper}}":        "HELLO WORLD",
+		"{{oneof MiXeD | lower}}":              "mixed",
+		"{{oneof jean-luc picard | title}}":    "Jean-Luc Picard",
+		`{{oneof say "hi" | json}}`:            `"say \"hi\""`,
+		"{{oneof  --Hello,  World!-- | slug}}": "hello-world",
+	}
+	for tmpl, want := range tests {
+		if got := renderTemplate(t, tmpl, 3); got != want {
+			t.Errorf("%s: got %q, want %q", tmpl, got, want)
+		}
+	}
+}
+
+func TestTemplate_OneOf(t *testing.T) {
+	seen := map[string]bool{}
+	for seed := int64(0); seed < 50; seed++ {
+		seen[renderTemplate(t, "{{oneof red|green|blue}}", seed)] = true
+	}
+	if len(seen) != 3 || !seen["red"] || !seen["green"] || !seen["blue"] {
+		t.Errorf("expected all three choices, got %v", seen)
+	}
+}
+
+func TestTemplate_Repeat(t *testing.T) {
+	out := renderTemplate(t, `[{{#repeat 3 sep=","}}{{number:1:1}}{{/repeat}}]`, 4)
+	if out != "[1,1,1]" {
+		t.Errorf("got %q", out)
+	}
+
+	for seed := int64(0); seed < 20; seed++ {
+		out := renderTemplate(t, "{{#repeat 2-4}}x{{/repeat}}", seed)
+		if len(out) < 2 || len(out) > 4 {
+			t.Errorf("repeat 2-4 produced %q", out)
+		}
+	}
+
+	// Each repetition gets its own person; the outer person is kept.
+	out = renderTemplate(t, `{{person.email}}|{{#repeat 5 sep=" "}}{{person.name}}/{{person.email}}{{/repeat}}|{{person.email}}`, 5)
+	parts := strings.Split(out, "|")
+	if parts[0] != parts[2] {
+		t.Errorf("outer person changed across repeat: %q", out)
+	}
+	if strings.Count(parts[1], "@") != 5 {
+		t.Errorf("unexpected repeat body %q", parts[1])
+	}
+}
+
+func TestTemplate_Maybe(t *testing.T) {
+	included := 0
+	for seed := int64(0); seed < 200; seed++ {
+		if renderTemplate(t, "{{#maybe 25%}}x{{/maybe}}", seed) == "x" {
+			included++
+		}
+		if renderTemplate(t, "{{#maybe 0}}x{{/maybe}}", seed) != "" {
+			t.Fatal("maybe 0 included its section")
+		}
+		if renderTemplate(t, "{{#maybe 1}}x{{/maybe}}", seed) != "x" {
+			t.Fatal("maybe 1 skipped its section")
+		}
+	}
+	if included < 25 || included > 75 {
+		t.Errorf("maybe 25%% included %d/200 times", included)
+	}
+
+	// A capture in a skipped section renders empty.
+	if out := renderTemplate(t, "{{#maybe 0}}{{word as w}}{{/maybe}}[{{ref w}}]", 1); out != "[]" {
+		t.Errorf("got %q", out)
+	}
+}
+
+func TestTemplate_Nested(t *testing.T) {
+	out := renderTemplate(t, "{{#repeat 2}}({{#repeat 3}}{{#maybe 1}}a{{/maybe}}{{/repeat}}){{/repeat}}", 6)
+	if out != "(aaa)(aaa)" {
+		t.Errorf("got %q", out)
+	}
+}
+
+func TestTemplate_ParseErrors(t *testing.T) {
+	tests := map[string]string{
+		"a {{#repeat 2}}b":                  "unclosed {{#repeat 2}} at offset 2",
+		"{{/repeat}}":                       "unexpected {{/repeat}} at offset 0",
+		"{{#repeat 2}}{{/maybe}}":           "unexpected {{/maybe}} at offset 13",
+		"{{#repeat x}}{{/repeat}}":          "invalid placeholder {{#repeat x}}",
+		"{{#repeat 5-2}}{{/repeat}}":        "inverted",
+		"{{#repeat 99999}}{{/repeat}}":      "cannot exceed",
+		"{{#maybe 150%}}{{/maybe}}":         "invalid probability",
+		"{{#loop 2}}{{/loop}}":              "unknown block",
+		"{{ref}}":                           "ref needs a name",
+		"x {{hello world}}":                 "invalid placeholder {{hello world}} at offset 2",
+		"{{oneof}}":                         "oneof needs choices",
+		"{{#maybe}}{{#repeat 1}}{{/maybe}}": "unexpected {{/maybe}}",
+	}
+	for tmpl, want := range tests {
+		_, err := ParseTemplate(tmpl)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: expected error containing %q, got %v", tmpl, want, err)
+		}
+	}
+}
+
+func TestTemplate_ValidateErrors(t *testing.T) {
+	tests := map[string]string{
+		"{{#repeat 2}}{{nope}}{{/repeat}}": `unknown type "nope" in template placeholder {{nope}}`,
+		"{{name | shout}}":                 `unknown filter "shout" in template placeholder {{name | shout}}`,
+		"{{ref who}} {{name as who}}":      `unknown reference "who" in template placeholder {{ref who}}`,
+	}
+	for s, want := range tests {
+		tmpl, err := ParseTemplate(s)
+		if err != nil {
+			t.Fatalf("ParseTemplate(%q): %v", s, err)
+		}
+		err = tmpl.Validate()
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: expected error containing %q, got %v", s, want, err)
+		}
+	}
+}
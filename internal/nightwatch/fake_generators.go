@@ -0,0 +1,89 @@
+package nightwatch
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gitlab.com/caffeinatedjack/sleepless/internal/nightwatch/fake"
+)
+
+// argGenerator describes a fake subcommand whose positional arguments are
+// passed through as template arguments, so "fake card visa" matches
+// {{card:visa}}.
+type argGenerator struct {
+	use     string
+	short   string
+	long    string
+	maxArgs int
+}
+
+var argGenerators = []argGenerator{
+	{"card [brand]", "Generate a Luhn-valid card number",
+		"Brands: " + strings.Join(fake.CardBrands(), ", ") + ". Default: random brand.", 1},
+	{"iban [country]", "Generate an IBAN with valid check digits",
+		"Countries: " + strings.Join(fake.IBANCountries(), ", ") + ". Default: the locale's country, else GB.", 1},
+	{"amount [min] [max] [currency]", "Generate a money amount",
+		"Defaults to 1-1000 in the locale's currency, formatted with the locale's separators.\nA single argument is the maximum.", 3},
+	{"company", "Generate a company name", "Suffixes (Ltd, GmbH, Inc., ...) follow the locale.", 0},
+	{"jobtitle", "Generate a job title", "", 0},
+	{"domain", "Generate a domain name", "", 0},
+	{"useragent [kind]", "Generate a browser user agent",
+		"Kinds: " + strings.Join(fake.UserAgentKinds(), ", ") + ". Default: random.", 1},
+	{"semver [pre]", "Generate a semantic version", "Pass 'pre' to add an alpha, beta or rc suffix.", 1},
+	{"hash [algorithm]", "Generate a hash digest",
+		"Algorithms: " + strings.Join(fake.HashAlgorithms(), ", ") + ". Default: sha256.", 1},
+	{"jwt [alg]", "Generate a signed JWT for a fake user",
+		"Signed with a random, discarded HMAC secret (HS256, HS384 or HS512), so it\ndecodes with 'nightwatch jwt decode' but cannot be verified.", 1},
+	{"colour [format]", "Generate a colour",
+		"Formats: " + strings.Join(fake.ColourFormats(), ", ") + ". Default: hex. Also available as 'color'.", 1},
+	{"latlong [minLat minLong maxLat maxLong]", "Generate a lat/long pair",
+		"Without a bounding box the point falls inside the locale's country.", 4},
+	{"timestamp [format]", "Generate a timestamp within the past year",
+		"Formats: " + strings.Join(fake.TimestampFormats(), ", ") + ". Default: rfc3339.", 1},
+}
+
+func init() {
+	for _, ag := range argGenerators {
+		fakeCmd.AddCommand(newArgGeneratorCmd(ag))
+	}
+}
+
+func newArgGeneratorCmd(ag argGenerator) *cobra.Command {
+	typeName := strings.Fields(ag.use)[0]
+	long := ag.short + "."
+	if ag.long != "" {
+		long += "\n\n" + ag.long
+	}
+	long += "\n\nTemplate form: {{" + typeName
+	if ag.maxArgs > 0 {
+		long += ":arg..."
+	}
+	long += "}}"
+
+	cmd := &cobra.Command{
+		Use:   ag.use,
+		Short: ag.short,
+		Long:  long,
+		Args:  cobra.MaximumNArgs(ag.maxArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			g, err := getGenerator()
+			if err != nil {
+				return err
+			}
+			rng := fake.NewRNG(fakeSeed)
+			results := make([]string, fakeCount)
+			for i := 0; i < fakeCount; i++ {
+				results[i], err = g.Generate(rng, typeName, args...)
+				if err != nil {
+					return err
+				}
+			}
+			outputFakeResults(typeName, results)
+			return nil
+		},
+	}
+	if typeName == "colour" {
+		cmd.Aliases = []string{"color"}
+	}
+	return cmd
+}
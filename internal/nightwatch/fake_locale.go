@@ -0,0 +1,67 @@
+package nightwatch
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+
+	"gitlab.com/caffeinatedjack/sleepless/internal/nightwatch/fake"
+)
+
+// Locale packs live in data/locales/<code>/ and use the same file names as
+// the base data in data/. Any file a pack leaves out falls back to the base:
+// en_US, for one, keeps the base lorem ipsum words.
+//
+//go:embed data/locales
+var localeData embed.FS
+
+// localizeGenerator overlays the embedded data for a locale onto base.
+func localizeGenerator(base *fake.Generator, code string) (*fake.Generator, error) {
+	loc, err := fake.LookupLocale(code)
+	if err != nil {
+		return nil, err
+	}
+	if loc.Code == fake.BaseLocale {
+		return base.WithLocale(loc, &fake.Generator{}), nil
+	}
+
+	dir := path.Join("data", "locales", loc.Code)
+	read := func(name string) ([]string, error) {
+		data, err := localeData.ReadFile(path.Join(dir, name))
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read locale data %s/%s: %w", loc.Code, name, err)
+		}
+		return parseNameData(string(data)), nil
+	}
+
+	data := &fake.Generator{}
+	for name, dst := range map[string]*[]string{
+		"firstnames.txt":   &data.Firstnames,
+		"surnames.txt":     &data.Lastnames,
+		"lorem.txt":        &data.LoremWords,
+		"cities.txt":       &data.Cities,
+		"counties.txt":     &data.Counties,
+		"countries.txt":    &data.Countries,
+		"streets.txt":      &data.Streets,
+		"street_types.txt": &data.StreetTypes,
+		"postcodes.txt":    &data.Postcodes,
+	} {
+		if *dst, err = read(name); err != nil {
+			return nil, err
+		}
+	}
+
+	lines, err := read("localities.txt")
+	if err != nil {
+		return nil, err
+	}
+	if data.Localities, err = fake.ParseLocalities(lines); err != nil {
+		return nil, fmt.Errorf("locale %s: %w", loc.Code, err)
+	}
+	return base.WithLocale(loc, data), nil
+}
@@ -0,0 +1,95 @@
+package nightwatch
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gitlab.com/caffeinatedjack/sleepless/internal/nightwatch/fake"
+)
+
+var fakePersonField string
+
+func init() {
+	fakeCmd.AddCommand(fakePersonCmd)
+
+	fakePersonCmd.Flags().StringVar(&fakePersonField, "field", "", "Only output this field (e.g. email)")
+}
+
+var fakePersonCmd = &cobra.Command{
+	Use:   "person",
+	Short: "Generate a coherent fake person",
+	Long: `Generate a person whose fields belong together: the email and username
+are derived from the name, the city, county and postcode area match, and the
+date of birth agrees with the age.
+
+Fields: ` + strings.Join(fake.PersonFields(), ", ") + `
+
+The same fields are available in templates as {{person.<field>}}; every
+person placeholder in one result refers to the same person.
+
+Examples:
+    nightwatch fake person
+    nightwatch fake person --count 5 --json
+    nightwatch fake person --field email --count 3 --seed 42
+    nightwatch fake template "{{person.name}} <{{person.email}}>"`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		g, err := getGenerator()
+		if err != nil {
+			return err
+		}
+		rng := fake.NewRNG(fakeSeed)
+		people := make([]*fake.Person, fakeCount)
+		for i := 0; i < fakeCount; i++ {
+			people[i], err = g.Person(rng)
+			if err != nil {
+				return err
+			}
+		}
+
+		if fakePersonField != "" {
+			results := make([]string, len(people))
+			for i, p := range people {
+				v, ok := p.Field(fakePersonField)
+				if !ok {
+					return fmt.Errorf("unknown person field %q (valid: %s)", fakePersonField, strings.Join(fake.PersonFields(), ", "))
+				}
+				results[i] = v
+			}
+			outputFakeResults("person."+fakePersonField, results)
+			return nil
+		}
+
+		if fakeJSON {
+			output := struct {
+				Type    string         `json:"type"`
+				Seed    *int64         `json:"seed"`
+				Count   int            `json:"count"`
+				Results []*fake.Person `json:"results"`
+			}{
+				Type:    "person",
+				Seed:    fakeSeed,
+				Count:   fakeCount,
+				Results: people,
+			}
+			data, _ := json.MarshalIndent(output, "", "  ")
+			fmt.Println(string(data))
+			return nil
+		}
+
+		for i, p := range people {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("Name:      %s\n", p.Name)
+			fmt.Printf("Email:     %s\n", p.Email)
+			fmt.Printf("Username:  %s\n", p.Username)
+			fmt.Printf("Phone:     %s\n", p.Phone)
+			fmt.Printf("Address:   %s, %s, %s %s, %s\n", p.Address, p.City, p.County, p.Postcode, p.Country)
+			fmt.Printf("Born:      %s (age %d)\n", p.Birthdate, p.Age)
+		}
+		return nil
+	},
+}
@@ -0,0 +1,137 @@
+package nightwatch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gitlab.com/caffeinatedjack/sleepless/internal/nightwatch/fake"
+)
+
+// defaultServeSeed keeps served data stable across restarts when --seed is
+// not given.
+const defaultServeSeed = 1
+
+var (
+	serveSchema      string
+	serveAddr        string
+	serveLatency     time.Duration
+	serveJitter      time.Duration
+	serveErrorRate   float64
+	serveErrorStatus int
+)
+
+func init() {
+	fakeCmd.AddCommand(fakeServeCmd)
+
+	fakeServeCmd.Flags().StringVar(&serveSchema, "schema", "", "Schema file (YAML or JSON, required)")
+	fakeServeCmd.Flags().StringVar(&serveAddr, "addr", "127.0.0.1:8080", "Address to listen on")
+	fakeServeCmd.Flags().DurationVar(&serveLatency, "latency", 0, "Delay every response by this long")
+	fakeServeCmd.Flags().DurationVar(&serveJitter, "jitter", 0, "Add up to this much random delay")
+	fakeServeCmd.Flags().Float64Var(&serveErrorRate, "error-rate", 0, "Fraction of requests (0-1) that fail")
+	fakeServeCmd.Flags().IntVar(&serveErrorStatus, "error-status", http.StatusInternalServerError, "HTTP status for injected failures")
+	_ = fakeServeCmd.MarkFlagRequired("schema")
+}
+
+var fakeServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve a fake dataset as a local REST API",
+	Long: `Start an HTTP server exposing the tables of a dataset schema (see 'fake
+dataset') as read-only REST endpoints:
+
+  GET /                  tables and row counts
+  GET /<table>           rows, paginated: ?page=2&per_page=50 (max 100)
+  GET /<table>/<id>      one row by its id column (or first column)
+
+List endpoints filter by column (?plan=pro, repeat to match any of several
+values) and search every column with ?q=text. Responses carry an
+X-Total-Count header and allow cross-origin requests.
+
+The data is generated once at startup. Without --seed, seed 1 is used so the
+data is the same on every run.
+
+Use --latency, --jitter and --error-rate to test loading states and error
+handling.
+
+Examples:
+    nightwatch fake serve --schema shop.yaml
+    nightwatch fake serve --schema shop.yaml --addr :3001 --seed 7
+    nightwatch fake serve --schema shop.yaml --latency 300ms --jitter 200ms --error-rate 0.1`,
+	Args: cobra.NoArgs,
+	RunE: runFakeServe,
+}
+
+func runFakeServe(cmd *cobra.Command, args []string) error {
+	if serveErrorRate < 0 || serveErrorRate > 1 {
+		return fmt.Errorf("--error-rate must be between 0 and 1")
+	}
+	if serveLatency < 0 || serveJitter < 0 {
+		return fmt.Errorf("--latency and --jitter cannot be negative")
+	}
+	if serveErrorStatus < 400 || serveErrorStatus > 599 {
+		return fmt.Errorf("--error-status must be a 4xx or 5xx code")
+	}
+
+	schema, err := loadFakeSchema(cmd, serveSchema)
+	if err != nil {
+		return err
+	}
+	g, err := getGenerator()
+	if err != nil {
+		return err
+	}
+
+	seed := int64(defaultServeSeed)
+	if fakeSeed != nil {
+		seed = *fakeSeed
+	}
+	ds, err := fake.GenerateDataset(schema, g, fake.NewRNG(&seed))
+	if err != nil {
+		return err
+	}
+
+	handler := fake.NewServer(ds, fake.ServerOptions{
+		Latency:     serveLatency,
+		Jitter:      serveJitter,
+		ErrorRate:   serveErrorRate,
+		ErrorStatus: serveErrorStatus,
+		RNG:         fake.NewRNG(&seed),
+	})
+
+	ln, err := net.Listen("tcp", serveAddr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", serveAddr, err)
+	}
+	base := "http://" + ln.Addr().String()
+	fmt.Fprintf(os.Stderr, "Serving %d table(s) on %s (seed %d)\n", len(ds.Tables), base, seed)
+	for _, t := range ds.Tables {
+		fmt.Fprintf(os.Stderr, "  %s/%s  (%d rows)\n", base, t.Name, len(t.Rows))
+	}
+	fmt.Fprintln(os.Stderr, "Press Ctrl+C to stop.")
+
+	srv := &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- srv.Serve(ln) }()
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("server failed: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return srv.Shutdown(shutdownCtx)
+	}
+}
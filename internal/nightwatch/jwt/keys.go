@@ -16,7 +16,7 @@ func LoadPrivateKey(path string) (interface{}, error) {
 		return nil, NewError(ErrKeyLoad, fmt.Sprintf("failed to read key file: %s", path), err)
 	}
 
-	checkKeyPermissions(path)
+	CheckKeyPermissions(path)
 
 	block, _ := pem.Decode(data)
 	if block == nil {
@@ -71,8 +71,9 @@ func LoadPublicKey(path string) (interface{}, error) {
 	return nil, NewError(ErrKeyLoad, "unable to parse public key (supported formats: PKIX, PKCS1, X.509)", nil)
 }
 
-// checkKeyPermissions warns if a private key file has insecure permissions.
-func checkKeyPermissions(path string) {
+// CheckKeyPermissions warns if a private key file has insecure permissions.
+// It is shared by every nightwatch command that reads private keys.
+func CheckKeyPermissions(path string) {
 	info, err := os.Stat(path)
 	if err != nil {
 		return
@@ -0,0 +1,453 @@
+package nightwatch
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gitlab.com/caffeinatedjack/sleepless/internal/nightwatch/jwt"
+	"gitlab.com/caffeinatedjack/sleepless/pkg/oidc"
+)
+
+var (
+	oidcIssuer           string
+	oidcEndpoint         string
+	oidcRefreshDiscovery bool
+	oidcTimeout          time.Duration
+
+	oidcClientID         string
+	oidcClientSecret     string
+	oidcClientSecretFile string
+	oidcAuthMethod       string
+	oidcKey              string
+	oidcAlg              string
+
+	oidcScope         string
+	oidcTokenTypeHint string
+	oidcDiscoverClear bool
+)
+
+func init() {
+	initOIDCToken()
+}
+
+func initOIDCToken() {
+	oidcCmd.AddCommand(discoverCmd)
+	oidcCmd.AddCommand(tokenCmd)
+	oidcCmd.AddCommand(userinfoCmd)
+	tokenCmd.AddCommand(tokenRefreshCmd)
+	tokenCmd.AddCommand(tokenIntrospectCmd)
+	tokenCmd.AddCommand(tokenRevokeCmd)
+
+	for _, cmd := range []*cobra.Command{discoverCmd, tokenCmd, userinfoCmd} {
+		cmd.PersistentFlags().StringVar(&oidcIssuer, "issuer", "", "Issuer URL used to look up endpoints via discovery")
+		cmd.PersistentFlags().BoolVar(&oidcRefreshDiscovery, "refresh-discovery", false, "Ignore the cached discovery document")
+		cmd.PersistentFlags().DurationVar(&oidcTimeout, "timeout", 10*time.Second, "HTTP request timeout")
+	}
+	for _, cmd := range []*cobra.Command{tokenCmd, userinfoCmd} {
+		cmd.PersistentFlags().StringVar(&oidcEndpoint, "endpoint", "", "Endpoint URL (overrides discovery)")
+	}
+
+	tokenCmd.PersistentFlags().StringVar(&oidcClientID, "client-id", "", "Client identifier (required)")
+	tokenCmd.PersistentFlags().StringVar(&oidcClientSecret, "client-secret", "", "Client secret")
+	tokenCmd.PersistentFlags().StringVar(&oidcClientSecretFile, "client-secret-file", "", "Read client secret from file")
+	tokenCmd.PersistentFlags().StringVar(&oidcAuthMethod, "auth-method", "client_secret_basic",
+		"Client authentication (client_secret_basic, client_secret_post, private_key_jwt, none)")
+	tokenCmd.PersistentFlags().StringVar(&oidcKey, "key", "", "Path to PEM-encoded private key for private_key_jwt")
+	tokenCmd.PersistentFlags().StringVar(&oidcAlg, "alg", "", "Signing algorithm for private_key_jwt (default RS256)")
+
+	tokenRefreshCmd.Flags().StringVar(&oidcScope, "scope", "", "Requested scope (defaults to the original grant)")
+	tokenIntrospectCmd.Flags().StringVar(&oidcTokenTypeHint, "token-type-hint", "", "Token type hint (access_token or refresh_token)")
+	tokenRevokeCmd.Flags().StringVar(&oidcTokenTypeHint, "token-type-hint", "", "Token type hint (access_token or refresh_token)")
+
+	discoverCmd.Flags().BoolVar(&oidcDiscoverClear, "clear", false, "Remove the cached discovery document")
+}
+
+// oidcHTTPClient returns an HTTP client honoring --timeout.
+func oidcHTTPClient() *http.Client {
+	return &http.Client{Timeout: oidcTimeout}
+}
+
+// oidcDiscoveryStore opens the shared discovery cache.
+func oidcDiscoveryStore() (*oidc.DiscoveryStore, error) {
+	dir, err := oidc.DefaultDiscoveryDir()
+	if err != nil {
+		return nil, err
+	}
+	return oidc.NewDiscoveryStore(dir, oidcHTTPClient()), nil
+}
+
+// resolveOIDCEndpoint returns --endpoint when set, otherwise the named
+// endpoint from the issuer's discovery document.
+func resolveOIDCEndpoint(name string, pick func(*oidc.Discovery) string) (string, error) {
+	if oidcEndpoint != "" {
+		return oidcEndpoint, nil
+	}
+	if oidcIssuer == "" {
+		return "", fmt.Errorf("either --issuer or --endpoint is required")
+	}
+
+	store, err := oidcDiscoveryStore()
+	if err != nil {
+		return "", err
+	}
+	doc, err := store.Get(oidcIssuer, oidcRefreshDiscovery)
+	if err != nil {
+		return "", err
+	}
+
+	endpoint := pick(doc)
+	if endpoint == "" {
+		return "", fmt.Errorf("issuer %s does not advertise a %s endpoint (use --endpoint)", oidcIssuer, name)
+	}
+	return endpoint, nil
+}
+
+// resolveClientAuth builds client credentials from flags. For private_key_jwt
+// the assertion is signed for the given endpoint using the jwt create code.
+func resolveClientAuth(endpoint string) (oidc.ClientAuth, error) {
+	method, err := oidc.ParseAuthMethod(oidcAuthMethod)
+	if err != nil {
+		return oidc.ClientAuth{}, err
+	}
+	if oidcClientID == "" {
+		return oidc.ClientAuth{}, fmt.Errorf("--client-id is required")
+	}
+
+	auth := oidc.ClientAuth{Method: method, ClientID: oidcClientID}
+	switch method {
+	case oidc.AuthClientSecretBasic, oidc.AuthClientSecretPost:
+		secret, err := resolveSecret(oidcClientSecret, oidcClientSecretFile)
+		if err != nil {
+			return auth, err
+		}
+		if secret == "" {
+			return auth, fmt.Errorf("--client-secret or --client-secret-file is required for %s", method)
+		}
+		auth.ClientSecret = secret
+	case oidc.AuthPrivateKeyJWT:
+		if oidcKey == "" {
+			return auth, fmt.Errorf("--key is required for private_key_jwt")
+		}
+		jti, err := oidc.GenerateNonce()
+		if err != nil {
+			return auth, err
+		}
+		// RFC 7523: iss and sub are the client_id, aud is the endpoint being called.
+		result, err := jwt.CreateToken(oidcAlg, "", oidcKey, nil, "",
+			oidcClientID, oidcClientID, endpoint, "5m", "", "", jti)
+		if err != nil {
+			return auth, fmt.Errorf("failed to sign client assertion: %w", err)
+		}
+		auth.Assertion = result.Token
+	}
+	return auth, nil
+}
+
+// printOIDCJSON writes v as indented JSON.
+func printOIDCJSON(v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// printClaims writes claims sorted by key for human-readable output.
+func printClaims(claims map[string]interface{}) {
+	keys := make([]string, 0, len(claims))
+	for k := range claims {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		switch v := claims[k].(type) {
+		case float64:
+			if v == float64(int64(v)) {
+				fmt.Printf("  %s: %d\n", k, int64(v))
+			} else {
+				fmt.Printf("  %s: %g\n", k, v)
+			}
+		default:
+			fmt.Printf("  %s: %v\n", k, v)
+		}
+	}
+}
+
+// --- discover command ---
+
+var discoverCmd = &cobra.Command{
+	Use:   "discover",
+	Short: "Fetch and cache an issuer's discovery document",
+	Long: `Fetch the OpenID Provider configuration for an issuer and cache it.
+
+Discovery documents are cached under the user config directory
+(NIGHTWATCH_OIDC_CACHE_DIR overrides the location) for 24 hours and shared by
+the token and userinfo commands.
+
+This command makes a network request to the issuer.
+
+Examples:
+    nightwatch oidc discover --issuer https://issuer.example.com
+    nightwatch oidc discover --issuer https://issuer.example.com --refresh-discovery
+    nightwatch oidc discover --issuer https://issuer.example.com --clear`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if oidcIssuer == "" {
+			return fmt.Errorf("--issuer is required")
+		}
+
+		store, err := oidcDiscoveryStore()
+		if err != nil {
+			return err
+		}
+
+		if oidcDiscoverClear {
+			if err := store.Clear(oidcIssuer); err != nil {
+				return err
+			}
+			fmt.Printf("Cleared cached discovery document for %s\n", oidcIssuer)
+			return nil
+		}
+
+		doc, err := store.Get(oidcIssuer, oidcRefreshDiscovery)
+		if err != nil {
+			return err
+		}
+
+		if oidcJSON {
+			return printOIDCJSON(doc)
+		}
+		fmt.Printf("Issuer:        %s\n", doc.Issuer)
+		fmt.Printf("Authorization: %s\n", doc.AuthorizationEndpoint)
+		fmt.Printf("Token:         %s\n", doc.TokenEndpoint)
+		fmt.Printf("Userinfo:      %s\n", doc.UserinfoEndpoint)
+		fmt.Printf("Introspection: %s\n", doc.IntrospectionEndpoint)
+		fmt.Printf("Revocation:    %s\n", doc.RevocationEndpoint)
+		fmt.Printf("JWKS:          %s\n", doc.JWKSURI)
+		if len(doc.TokenEndpointAuthMethodsSupported) > 0 {
+			fmt.Printf("Auth methods:  %v\n", doc.TokenEndpointAuthMethodsSupported)
+		}
+		return nil
+	},
+}
+
+// --- token commands ---
+
+var tokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "Refresh, introspect and revoke tokens",
+	Long: `Call a provider's token, introspection (RFC 7662) and revocation (RFC 7009) endpoints.
+
+Endpoints are read from the issuer's discovery document (--issuer) unless
+--endpoint is given. Client authentication supports client_secret_basic,
+client_secret_post, private_key_jwt (signed with --key) and none.
+
+These commands make network requests to the provider.
+
+Examples:
+    nightwatch oidc token refresh <refresh-token> --issuer https://issuer.example.com \
+      --client-id my-client --client-secret-file secret.txt
+    nightwatch oidc token introspect <token> --issuer https://issuer.example.com \
+      --client-id my-client --auth-method private_key_jwt --key client.pem
+    nightwatch oidc token revoke <token> --endpoint https://issuer.example.com/revoke \
+      --client-id my-client --auth-method post --client-secret-file secret.txt`,
+}
+
+var tokenRefreshCmd = &cobra.Command{
+	Use:   "refresh [refresh-token]",
+	Short: "Exchange a refresh token for new tokens",
+	Long: `Exchange a refresh token for a new access token (RFC 6749 section 6).
+
+The refresh token is read from the argument or stdin.
+
+Examples:
+    nightwatch oidc token refresh <refresh-token> --issuer https://issuer.example.com --client-id my-client --client-secret-file secret.txt
+    cat refresh.txt | nightwatch oidc token refresh --issuer https://issuer.example.com --client-id my-client --auth-method none --json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		refreshToken, err := readOIDCTokenArg(args)
+		if err != nil {
+			return err
+		}
+
+		endpoint, err := resolveOIDCEndpoint("token", func(d *oidc.Discovery) string { return d.TokenEndpoint })
+		if err != nil {
+			return err
+		}
+		auth, err := resolveClientAuth(endpoint)
+		if err != nil {
+			return err
+		}
+
+		resp, err := oidc.NewClient(oidcHTTPClient()).Refresh(endpoint, auth, refreshToken, oidcScope)
+		if err != nil {
+			return err
+		}
+
+		if oidcJSON {
+			return printOIDCJSON(resp)
+		}
+		fmt.Printf("Access Token:  %s\n", resp.AccessToken)
+		if resp.TokenType != "" {
+			fmt.Printf("Token Type:    %s\n", resp.TokenType)
+		}
+		if resp.ExpiresIn > 0 {
+			expiry := time.Now().Add(time.Duration(resp.ExpiresIn) * time.Second)
+			fmt.Printf("Expires In:    %ds (%s)\n", resp.ExpiresIn, expiry.Format(time.RFC3339))
+		}
+		if resp.Scope != "" {
+			fmt.Printf("Scope:         %s\n", resp.Scope)
+		}
+		if resp.RefreshToken != "" {
+			fmt.Printf("Refresh Token: %s\n", resp.RefreshToken)
+		}
+		if resp.IDToken != "" {
+			fmt.Printf("ID Token:      %s\n", resp.IDToken)
+		}
+		return nil
+	},
+}
+
+var tokenIntrospectCmd = &cobra.Command{
+	Use:   "introspect [token]",
+	Short: "Ask the provider whether a token is active",
+	Long: `Query the provider's introspection endpoint for a token's state (RFC 7662).
+
+Exits with code 2 when the provider reports the token as inactive.
+
+Examples:
+    nightwatch oidc token introspect <token> --issuer https://issuer.example.com --client-id my-client --client-secret-file secret.txt
+    nightwatch oidc token introspect <token> --token-type-hint refresh_token --json ...`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		token, err := readOIDCTokenArg(args)
+		if err != nil {
+			return err
+		}
+
+		endpoint, err := resolveOIDCEndpoint("introspection", func(d *oidc.Discovery) string { return d.IntrospectionEndpoint })
+		if err != nil {
+			return err
+		}
+		auth, err := resolveClientAuth(endpoint)
+		if err != nil {
+			return err
+		}
+
+		resp, err := oidc.NewClient(oidcHTTPClient()).Introspect(endpoint, auth, token, oidcTokenTypeHint)
+		if err != nil {
+			return err
+		}
+
+		if oidcJSON {
+			if err := printOIDCJSON(resp.Claims); err != nil {
+				return err
+			}
+		} else {
+			fmt.Printf("Active: %v\n", resp.Active)
+			if resp.Exp > 0 {
+				exp := time.Unix(resp.Exp, 0).UTC()
+				fmt.Printf("Expiry: %s (%s)\n", exp.Format(time.RFC3339), describeRemaining(exp))
+			}
+			fmt.Println("Claims:")
+			printClaims(resp.Claims)
+		}
+
+		if !resp.Active {
+			os.Exit(2)
+		}
+		return nil
+	},
+}
+
+var tokenRevokeCmd = &cobra.Command{
+	Use:   "revoke [token]",
+	Short: "Revoke an access or refresh token",
+	Long: `Ask the provider to revoke a token (RFC 7009).
+
+Providers report success for unknown tokens too, so a successful revocation
+does not prove the token was ever valid.
+
+Examples:
+    nightwatch oidc token revoke <token> --issuer https://issuer.example.com --client-id my-client --client-secret-file secret.txt
+    nightwatch oidc token revoke <token> --token-type-hint refresh_token ...`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		token, err := readOIDCTokenArg(args)
+		if err != nil {
+			return err
+		}
+
+		endpoint, err := resolveOIDCEndpoint("revocation", func(d *oidc.Discovery) string { return d.RevocationEndpoint })
+		if err != nil {
+			return err
+		}
+		auth, err := resolveClientAuth(endpoint)
+		if err != nil {
+			return err
+		}
+
+		if err := oidc.NewClient(oidcHTTPClient()).Revoke(endpoint, auth, token, oidcTokenTypeHint); err != nil {
+			return err
+		}
+
+		if oidcJSON {
+			return printOIDCJSON(map[string]interface{}{"revoked": true, "endpoint": endpoint})
+		}
+		fmt.Println("Token revoked")
+		return nil
+	},
+}
+
+// --- userinfo command ---
+
+var userinfoCmd = &cobra.Command{
+	Use:   "userinfo [access-token]",
+	Short: "Fetch claims from the userinfo endpoint",
+	Long: `Call the provider's userinfo endpoint with a bearer access token.
+
+The access token is read from the argument or stdin. This command makes a
+network request to the provider.
+
+Examples:
+    nightwatch oidc userinfo <access-token> --issuer https://issuer.example.com
+    echo "<access-token>" | nightwatch oidc userinfo --endpoint https://issuer.example.com/userinfo --json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		token, err := readOIDCTokenArg(args)
+		if err != nil {
+			return err
+		}
+
+		endpoint, err := resolveOIDCEndpoint("userinfo", func(d *oidc.Discovery) string { return d.UserinfoEndpoint })
+		if err != nil {
+			return err
+		}
+
+		claims, err := oidc.NewClient(oidcHTTPClient()).UserInfo(endpoint, token)
+		if err != nil {
+			return err
+		}
+
+		if oidcJSON {
+			return printOIDCJSON(claims)
+		}
+		fmt.Println("Userinfo claims:")
+		printClaims(claims)
+		return nil
+	},
+}
+
+// describeRemaining renders the time until t, or how long ago it passed.
+func describeRemaining(t time.Time) string {
+	d := time.Until(t).Round(time.Second)
+	if d < 0 {
+		return fmt.Sprintf("expired %s ago", -d)
+	}
+	return fmt.Sprintf("%s remaining", d)
+}
@@ -30,6 +30,10 @@ var (
 	pwdNoDigits       bool
 	pwdAllowAmbiguous bool
 	pwdNoRequireAll   bool
+	pwdPolicy         string
+
+	// Check flags
+	checkBreachCorpus string
 
 	// Phrase flags
 	phraseWords      int
@@ -76,6 +80,11 @@ func init() {
 	passwordGenerateCmd.Flags().BoolVar(&pwdNoDigits, "no-digits", false, "Exclude digits")
 	passwordGenerateCmd.Flags().BoolVar(&pwdAllowAmbiguous, "allow-ambiguous", false, "Include ambiguous characters (0O, 1lI, etc.)")
 	passwordGenerateCmd.Flags().BoolVar(&pwdNoRequireAll, "no-require-all", false, "Don't require one character from each enabled set")
+	passwordGenerateCmd.Flags().StringVar(&pwdPolicy, "policy", "", "Policy file (YAML/JSON) generated passwords must satisfy")
+
+	// Check flags
+	passwordCheckCmd.Flags().StringVar(&pwdPolicy, "policy", "", "Policy file (YAML/JSON) to enforce")
+	passwordCheckCmd.Flags().StringVar(&checkBreachCorpus, "breach-corpus", "", "Offline Pwned Passwords SHA-1 file or prefix directory (default: $NIGHTWATCH_BREACH_CORPUS)")
 
 	// Phrase flags
 	passwordPhraseCmd.Flags().IntVar(&phraseWords, "words", 6, "Number of words")
@@ -101,6 +110,7 @@ Subcommands:
   api       API key with optional prefix and encoding
   pattern   Pattern-based generation (XXX-999-xxx)
   check     Analyze password strength
+  derive    Deterministic site password from a master passphrase
 
 Examples:
   nightwatch password generate
@@ -120,12 +130,16 @@ By default, passwords include lowercase, uppercase, and digits.
 Use --symbols to add special characters.
 Use --no-uppercase, --no-lowercase, --no-digits to exclude sets.
 
+With --policy, the length and character sets are adjusted to the policy and
+passwords are regenerated until every rule passes.
+
 Examples:
   nightwatch password generate
   nightwatch password generate --length 24
   nightwatch password generate --length 20 --symbols
   nightwatch password generate --count 5
-  nightwatch password generate --no-uppercase --no-digits`,
+  nightwatch password generate --no-uppercase --no-digits
+  nightwatch password generate --policy policy.yaml`,
 	RunE: runPasswordGenerate,
 }
 
@@ -201,11 +215,32 @@ Reports:
   - Entropy (bits)
   - Strength rating (weak/fair/good/strong/excellent)
   - Common pattern detection
+  - Guessable patterns (dictionary, l33t, keyboard, sequences, repeats, dates)
+    with an estimated guess count and a 0-4 score
+
+--breach-corpus looks the password up in a local copy of the Pwned Passwords
+SHA-1 list (a sorted HASH:COUNT file, or a directory of per-prefix files as
+written by the HIBP downloader). Nothing is sent over the network.
+
+Policy files are YAML or JSON:
+  min_length: 14
+  max_length: 64
+  require_lowercase: true
+  require_uppercase: true
+  require_digits: true
+  require_symbols: false
+  min_classes: 3
+  max_repeat: 3
+  banned_words: [acme, winter]
+  min_entropy: 60
+
+Exits with code 2 when the password violates the policy or is breached.
 
 Examples:
   nightwatch password check "password123"
   nightwatch password check "Tr0ub4dor&3"
-  nightwatch password check "correct-horse-battery-staple"`,
+  nightwatch password check "correct-horse-battery-staple"
+  nightwatch password check "$PW" --policy policy.yaml --breach-corpus pwned-passwords.txt`,
 	Args: cobra.ExactArgs(1),
 	RunE: runPasswordCheck,
 }
@@ -229,6 +264,15 @@ func runPasswordGenerate(cmd *cobra.Command, args []string) error {
 		RequireAll: !pwdNoRequireAll,
 	}
 
+	var policy *password.Policy
+	if pwdPolicy != "" {
+		var err error
+		if policy, err = password.LoadPolicy(pwdPolicy); err != nil {
+			return err
+		}
+		opts = policy.Apply(opts)
+	}
+
 	// Create RNG and generator
 	rng := decide.NewRNG(pwdSeed)
 	gen := password.NewGenerator(rng)
@@ -236,7 +280,13 @@ func runPasswordGenerate(cmd *cobra.Command, args []string) error {
 	// Generate passwords
 	results := make([]string, pwdCount)
 	for i := 0; i < pwdCount; i++ {
-		pwd, err := gen.GeneratePassword(opts)
+		var pwd string
+		var err error
+		if policy != nil {
+			pwd, err = gen.GenerateWithPolicy(opts, policy)
+		} else {
+			pwd, err = gen.GeneratePassword(opts)
+		}
 		if err != nil {
 			return err
 		}
@@ -407,16 +457,48 @@ func runPasswordPattern(cmd *cobra.Command, args []string) error {
 	return outputPasswordText(results, entropy)
 }
 
+type checkOutput struct {
+	*password.StrengthReport
+	PolicyViolations []string `json:"policy_violations,omitempty"`
+}
+
 func runPasswordCheck(cmd *cobra.Command, args []string) error {
 	pwd := args[0]
 	report := password.CheckPassword(pwd)
 
+	var violations []string
+	if pwdPolicy != "" {
+		policy, err := password.LoadPolicy(pwdPolicy)
+		if err != nil {
+			return err
+		}
+		violations = policy.Check(pwd)
+	}
+
+	if checkBreachCorpus == "" {
+		checkBreachCorpus = os.Getenv("NIGHTWATCH_BREACH_CORPUS")
+	}
+	if checkBreachCorpus != "" {
+		corpus, err := password.OpenBreachCorpus(checkBreachCorpus)
+		if err != nil {
+			return err
+		}
+		if err := report.CheckBreach(corpus, pwd); err != nil {
+			return err
+		}
+	}
+
+	failed := len(violations) > 0 || report.Breached
+
 	if pwdJSON {
-		data, err := json.MarshalIndent(report, "", "  ")
+		data, err := json.MarshalIndent(checkOutput{StrengthReport: report, PolicyViolations: violations}, "", "  ")
 		if err != nil {
 			return err
 		}
 		fmt.Println(string(data))
+		if failed {
+			os.Exit(2)
+		}
 		return nil
 	}
 
@@ -433,6 +515,31 @@ func runPasswordCheck(cmd *cobra.Command, args []string) error {
 		fmt.Println("Warning: Contains keyboard walk pattern")
 	}
 
+	fmt.Printf("Score: %d/4 (~10^%.1f guesses)\n", report.Score, report.GuessesLog10)
+	for _, m := range report.Patterns {
+		detail := ""
+		if m.Detail != "" {
+			detail = fmt.Sprintf(" (%s)", m.Detail)
+		}
+		fmt.Printf("  Pattern: %-10s %q%s\n", m.Pattern, m.Token, detail)
+	}
+
+	if report.Breached {
+		fmt.Printf("Warning: Found in breach corpus %d times\n", report.BreachCount)
+	} else if checkBreachCorpus != "" {
+		fmt.Println("Not found in breach corpus")
+	}
+
+	for _, v := range violations {
+		fmt.Printf("Policy violation: %s\n", v)
+	}
+	if pwdPolicy != "" && len(violations) == 0 {
+		fmt.Println("Policy: satisfied")
+	}
+
+	if failed {
+		os.Exit(2)
+	}
 	return nil
 }
 
@@ -0,0 +1,169 @@
+package nightwatch
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gitlab.com/caffeinatedjack/sleepless/pkg/password"
+	"golang.org/x/term"
+)
+
+var (
+	deriveSite            string
+	deriveUser            string
+	deriveCounter         int
+	deriveLength          int
+	deriveSymbols         bool
+	deriveNoSymbols       bool
+	derivePattern         string
+	deriveProfiles        string
+	derivePassphraseStdin bool
+)
+
+func init() {
+	passwordCmd.AddCommand(passwordDeriveCmd)
+
+	passwordDeriveCmd.Flags().StringVar(&deriveSite, "site", "", "Site or host name (required)")
+	passwordDeriveCmd.Flags().StringVar(&deriveUser, "user", "", "Account name (default: from site profile)")
+	passwordDeriveCmd.Flags().IntVar(&deriveCounter, "counter", 0, "Rotation counter, starting at 1 (default: from site profile, else 1)")
+	passwordDeriveCmd.Flags().IntVar(&deriveLength, "length", 0, "Override password length")
+	passwordDeriveCmd.Flags().BoolVar(&deriveSymbols, "symbols", false, "Include symbol characters")
+	passwordDeriveCmd.Flags().BoolVar(&deriveNoSymbols, "no-symbols", false, "Exclude symbol characters")
+	passwordDeriveCmd.Flags().StringVar(&derivePattern, "pattern", "", "Derive into a pattern (same syntax as 'password pattern')")
+	passwordDeriveCmd.Flags().StringVar(&deriveProfiles, "profiles", "", "Site profile file (default: <config dir>/nightwatch/sites.yaml)")
+	passwordDeriveCmd.Flags().BoolVar(&derivePassphraseStdin, "passphrase-stdin", false, "Read the master passphrase from stdin")
+	_ = passwordDeriveCmd.MarkFlagRequired("site")
+}
+
+var passwordDeriveCmd = &cobra.Command{
+	Use:   "derive",
+	Short: "Derive a site password from a master passphrase",
+	Long: `Deterministically derive a password from a master passphrase, so test
+environment credentials can be regenerated instead of stored.
+
+The master passphrase is stretched with Argon2id (the same cost parameters as
+wiki encryption) together with the site, user and counter. The result drives
+the normal generator, so length, character sets and patterns work as in
+'generate' and 'pattern'. Bump --counter to rotate a password.
+
+Site profiles (YAML or JSON) hold per-site rules. A profile for example.com
+also applies to its subdomains:
+
+  example.com:
+    length: 24
+    symbols: true
+    user: alice
+  bank.test:
+    pattern: "999999"
+    counter: 2
+
+Flags override the profile. The derived password is never written to disk.
+
+Examples:
+  nightwatch password derive --site example.com --user alice
+  nightwatch password derive --site staging.internal --user ci --counter 3 --symbols
+  echo "$MASTER" | nightwatch password derive --site db.test --passphrase-stdin --json`,
+	Args: cobra.NoArgs,
+	RunE: runPasswordDerive,
+}
+
+type deriveOutput struct {
+	Site     string  `json:"site"`
+	User     string  `json:"user,omitempty"`
+	Counter  int     `json:"counter"`
+	Profile  bool    `json:"profile"`
+	Entropy  float64 `json:"entropy"`
+	Password string  `json:"password"`
+}
+
+func runPasswordDerive(cmd *cobra.Command, args []string) error {
+	if deriveSymbols && deriveNoSymbols {
+		return fmt.Errorf("--symbols and --no-symbols are mutually exclusive")
+	}
+
+	path := deriveProfiles
+	if path == "" {
+		var err error
+		if path, err = password.DefaultSiteProfilesPath(); err != nil {
+			return err
+		}
+	}
+	profiles, err := password.LoadSiteProfiles(path)
+	if err != nil {
+		return err
+	}
+	profile, found := profiles.Lookup(deriveSite)
+
+	if deriveLength > 0 {
+		profile.Length = deriveLength
+	}
+	if deriveSymbols || deriveNoSymbols {
+		profile.Symbols = &deriveSymbols
+	}
+	if derivePattern != "" {
+		profile.Pattern = derivePattern
+	}
+
+	in := password.DeriveInput{Site: deriveSite, User: profile.User, Counter: profile.Counter}
+	if deriveUser != "" {
+		in.User = deriveUser
+	}
+	if deriveCounter != 0 {
+		in.Counter = deriveCounter
+	}
+	if in.Counter == 0 {
+		in.Counter = 1
+	}
+
+	entropy, err := profile.Entropy()
+	if err != nil {
+		return err
+	}
+
+	var master string
+	if derivePassphraseStdin {
+		scanner := bufio.NewScanner(os.Stdin)
+		if !scanner.Scan() {
+			return fmt.Errorf("no passphrase provided on stdin")
+		}
+		master = strings.TrimRight(scanner.Text(), "\r\n")
+	} else {
+		fd := int(os.Stdin.Fd())
+		if !term.IsTerminal(fd) {
+			return fmt.Errorf("stdin is not a terminal; use --passphrase-stdin")
+		}
+		fmt.Fprint(os.Stderr, "Master passphrase: ")
+		b, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return fmt.Errorf("failed to read passphrase: %w", err)
+		}
+		master = string(b)
+	}
+
+	pwd, err := password.DerivePassword(master, in, profile, password.DefaultDeriveParams)
+	if err != nil {
+		return err
+	}
+
+	if pwdJSON {
+		data, err := json.MarshalIndent(deriveOutput{
+			Site:     password.NormalizeSite(in.Site),
+			User:     in.User,
+			Counter:  in.Counter,
+			Profile:  found,
+			Entropy:  entropy,
+			Password: pwd,
+		}, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	return outputPasswordText([]string{pwd}, entropy)
+}
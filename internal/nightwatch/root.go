@@ -24,13 +24,15 @@ Security-focused tools for development and operations:
   - Password and passphrase generation
   - JWT token operations
   - Fake data generation for testing
+  - X.509 certificate inspection and a local development CA
 
 Examples:
     nightwatch redact "Contact john@example.com"
     nightwatch password generate --length 24
     nightwatch password phrase --words 6
     nightwatch jwt decode <token>
-    nightwatch fake email --count 5`,
+    nightwatch fake email --count 5
+    nightwatch cert inspect server.pem`,
 }
 
 func init() {
@@ -4,8 +4,8 @@ import (
 	"bufio"
 	"encoding/json"
 	"fmt"
+	"math"
 	"os"
-	"regexp"
 	"strconv"
 	"strings"
 
@@ -23,17 +23,22 @@ var (
 
 // DecideResult for JSON output
 type DecideResult struct {
-	Mode    string      `json:"mode"`
-	Seed    *int64      `json:"seed"`
-	Count   int         `json:"count"`
-	Results interface{} `json:"results"`
+	Mode       string      `json:"mode"`
+	Seed       *int64      `json:"seed"`
+	Commitment string      `json:"commitment,omitempty"`
+	Nonce      string      `json:"nonce,omitempty"`
+	Count      int         `json:"count"`
+	Results    interface{} `json:"results"`
 }
 
-// RollResult for dice roll JSON output
+// RollResult for dice roll JSON output. Rolls holds the values of the kept
+// dice; Dice has every individual roll, including dropped and rerolled ones.
 type RollResult struct {
-	Notation string `json:"notation"`
-	Rolls    []int  `json:"rolls"`
-	Total    int    `json:"total"`
+	Notation string            `json:"notation"`
+	Rolls    []int             `json:"rolls"`
+	Total    int               `json:"total"`
+	Detail   string            `json:"detail"`
+	Dice     []decide.DiceTerm `json:"dice"`
 }
 
 var decideCmd = &cobra.Command{
@@ -45,10 +50,20 @@ Commands:
     decide <options>           Pick one option at random
     decide pick <n> <options>  Pick n unique options
     decide shuffle <options>   Shuffle options randomly
-    decide roll <NdM>          Roll dice (e.g., 2d6)
+    decide roll <expression>   Roll dice (e.g., 2d6, 4d6kh3+2)
     decide coin                Flip a coin
     decide number <min> <max>  Random integer in range
     decide weighted <opt:wt>   Weighted random selection
+    decide rotate <group>      Round-robin pick with no repeats per cycle
+    decide commit [options]    Publish a seed hash before deciding
+    decide verify <hash> <seed> <nonce> [options]
+                               Check a revealed seed against its hash
+    decide history             Show logged decisions
+
+Use --log to record a decision in the decisions log (kept next to the goals
+metadata in <wiki>/tasks). Logged decisions always use a seed, so anyone can
+replay them with --seed. Use --reveal <hash> to decide with the seed of an
+earlier 'decide commit', given the same options in the same order.
 
 Examples:
     regimen decide pizza tacos sushi
@@ -57,7 +72,9 @@ Examples:
     regimen decide roll 2d6
     regimen decide coin
     regimen decide number 1 100
-    regimen decide weighted "a:3" "b:1"`,
+    regimen decide weighted "a:3" "b:1"
+    regimen decide rotate standup alice bob carol
+    regimen decide pizza tacos sushi --log --label "Friday lunch"`,
 	Args: cobra.MinimumNArgs(1),
 	Run:  runDecidePickOne,
 }
@@ -68,6 +85,9 @@ func init() {
 	decideCmd.PersistentFlags().StringVar(&decideFrom, "from", "", "Read options from file")
 	decideCmd.PersistentFlags().IntVar(&decideCount, "count", 1, "Repeat operation n times")
 	decideCmd.PersistentFlags().BoolVar(&decideJSON, "json", false, "Output as JSON")
+	decideCmd.PersistentFlags().BoolVar(&decideLog, "log", false, "Record the decision in the decisions log")
+	decideCmd.PersistentFlags().StringVar(&decideLabel, "label", "", "Describe the decision in the log")
+	decideCmd.PersistentFlags().StringVar(&decideReveal, "reveal", "", "Use the seed of a pending commitment (hash or prefix)")
 
 	decideCmd.PersistentFlags().Int64("seed", 0, "Random seed for reproducibility")
 	decideCmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
@@ -85,6 +105,8 @@ func init() {
 	decideCmd.AddCommand(coinCmd)
 	decideCmd.AddCommand(numberCmd)
 	decideCmd.AddCommand(weightedCmd)
+
+	rollCmd.Flags().BoolVar(&rollStats, "stats", false, "Show the exact probability distribution instead of rolling")
 }
 
 func getOptions(args []string) ([]string, error) {
@@ -139,6 +161,10 @@ func outputResult(mode string, results interface{}) {
 			Count:   decideCount,
 			Results: results,
 		}
+		if decideRevealed != nil {
+			output.Commitment = decideRevealed.Hash
+			output.Nonce = decideRevealed.Nonce
+		}
 		data, _ := json.MarshalIndent(output, "", "  ")
 		fmt.Println(string(data))
 	} else {
@@ -169,14 +195,10 @@ func outputResult(mode string, results interface{}) {
 				if decideCount > 1 {
 					fmt.Printf("Run %d: ", i+1)
 				}
-				if len(r.Rolls) == 1 {
+				if r.Detail == fmt.Sprintf("[%d]", r.Total) {
 					fmt.Printf("%d\n", r.Total)
 				} else {
-					rollStrs := make([]string, len(r.Rolls))
-					for j, roll := range r.Rolls {
-						rollStrs[j] = strconv.Itoa(roll)
-					}
-					fmt.Printf("%s = %d\n", strings.Join(rollStrs, " + "), r.Total)
+					fmt.Printf("%s = %d\n", r.Detail, r.Total)
 				}
 			}
 		default:
@@ -192,7 +214,7 @@ func runDecidePickOne(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
-	rng := decide.NewRNG(decideSeed)
+	rng := decisionRNG(options)
 	results := make([]string, decideCount)
 
 	for i := 0; i < decideCount; i++ {
@@ -204,7 +226,7 @@ func runDecidePickOne(cmd *cobra.Command, args []string) {
 		results[i] = options[idx]
 	}
 
-	outputResult("pickOne", results)
+	finishDecision("pickOne", options, results)
 }
 
 var pickCmd = &cobra.Command{
@@ -229,7 +251,7 @@ var pickCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
-		rng := decide.NewRNG(decideSeed)
+		rng := decisionRNG(options)
 		results := make([][]string, decideCount)
 
 		for run := 0; run < decideCount; run++ {
@@ -252,7 +274,7 @@ var pickCmd = &cobra.Command{
 			results[run] = picked
 		}
 
-		outputResult("pickN", results)
+		finishDecision("pickN", options, results)
 	},
 }
 
@@ -267,7 +289,7 @@ var shuffleCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
-		rng := decide.NewRNG(decideSeed)
+		rng := decisionRNG(options)
 		results := make([][]string, decideCount)
 
 		for run := 0; run < decideCount; run++ {
@@ -285,64 +307,125 @@ var shuffleCmd = &cobra.Command{
 			results[run] = shuffled
 		}
 
-		outputResult("shuffle", results)
+		finishDecision("shuffle", options, results)
 	},
 }
 
-var diceRegex = regexp.MustCompile(`^(\d+)d(\d+)$`)
+var rollStats bool
 
 var rollCmd = &cobra.Command{
-	Use:   "roll <NdM>",
-	Short: "Roll dice (e.g., 2d6)",
-	Args:  cobra.ExactArgs(1),
+	Use:   "roll <expression>",
+	Short: "Roll dice (e.g., 2d6, 4d6kh3+2)",
+	Long: `Roll dice written in tabletop notation. Spaces are ignored.
+
+Notation:
+    2d6        two six-sided dice (d6 is one)
+    d%         percentile die (1-100)
+    4dF        fate dice (-1, 0 or +1 each)
+    4d6kh3     keep the highest 3 (kl keeps the lowest)
+    4d6dl1     drop the lowest 1 (dh drops the highest)
+    d6!        explode: a 6 rolls again and adds to the die
+    2d6r1      reroll 1s until they stop (ro rerolls once, r<2 rerolls 2 or less)
+    2d20kl1+5  arithmetic with + - * / and parentheses
+
+Use --stats for the exact probability of every total instead of a roll.
+
+Examples:
+    regimen decide roll 2d6
+    regimen decide roll 4d6kh3 --count 6
+    regimen decide roll "2d20kh1 + 7"
+    regimen decide roll 3d6 --stats`,
+	Args: cobra.MinimumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		notation := strings.ToLower(args[0])
-		matches := diceRegex.FindStringSubmatch(notation)
-		if matches == nil {
-			ui.Error("Invalid dice notation (use NdM, e.g., 2d6)")
+		expr, err := decide.ParseDice(strings.Join(args, " "))
+		if err != nil {
+			ui.Error(err.Error())
 			os.Exit(1)
 		}
 
-		numDice, _ := strconv.Atoi(matches[1])
-		numSides, _ := strconv.Atoi(matches[2])
-
-		if numDice < 1 || numSides < 1 {
-			ui.Error("Number of dice and sides must be positive")
-			os.Exit(1)
+		if rollStats {
+			stats, err := expr.Stats()
+			if err != nil {
+				ui.Error(err.Error())
+				os.Exit(1)
+			}
+			printRollStats(stats)
+			return
 		}
 
-		rng := decide.NewRNG(decideSeed)
+		rng := decisionRNG([]string{expr.String()})
 		results := make([]RollResult, decideCount)
 
 		for run := 0; run < decideCount; run++ {
-			rolls := make([]int, numDice)
-			total := 0
-			for i := 0; i < numDice; i++ {
-				roll, err := rng.Intn(numSides)
-				if err != nil {
-					ui.Error(fmt.Sprintf("Random generation failed: %v", err))
-					os.Exit(1)
+			roll, err := expr.Roll(rng)
+			if err != nil {
+				ui.Error(fmt.Sprintf("Roll failed: %v", err))
+				os.Exit(1)
+			}
+			var kept []int
+			for _, term := range roll.Terms {
+				for _, d := range term.Dice {
+					if !d.Dropped {
+						kept = append(kept, d.Value)
+					}
 				}
-				rolls[i] = roll + 1
-				total += rolls[i]
 			}
 			results[run] = RollResult{
-				Notation: notation,
-				Rolls:    rolls,
-				Total:    total,
+				Notation: roll.Expression,
+				Rolls:    kept,
+				Total:    roll.Total,
+				Detail:   roll.Detail,
+				Dice:     roll.Terms,
 			}
 		}
 
-		outputResult("roll", results)
+		finishDecision("roll", []string{expr.String()}, results)
 	},
 }
 
+// printRollStats shows the distribution as a bar chart, leaving out totals
+// rarer than 0.01%.
+func printRollStats(stats *decide.DiceStats) {
+	if decideJSON {
+		data, _ := json.MarshalIndent(stats, "", "  ")
+		fmt.Println(string(data))
+		return
+	}
+
+	fmt.Println(ui.BoldStyle.Render(stats.Expression))
+	fmt.Printf("  Range %d to %d, mean %.2f, std dev %.2f\n\n", stats.Min, stats.Max, stats.Mean, stats.StdDev)
+
+	peak := 0.0
+	for _, o := range stats.Distribution {
+		if o.Probability > peak {
+			peak = o.Probability
+		}
+	}
+	width := len(strconv.Itoa(stats.Min))
+	if w := len(strconv.Itoa(stats.Max)); w > width {
+		width = w
+	}
+	fmt.Printf("  %*s  %7s  %8s\n", width, "", "exactly", "at least")
+	hidden := 0
+	for _, o := range stats.Distribution {
+		if o.Probability < 0.0001 {
+			hidden++
+			continue
+		}
+		bar := strings.Repeat("█", int(math.Round(o.Probability/peak*40)))
+		fmt.Printf("  %*d  %6.2f%%  %7.2f%%  %s\n", width, o.Total, o.Probability*100, o.AtLeast*100, bar)
+	}
+	if hidden > 0 {
+		fmt.Println(ui.DimStyle.Render(fmt.Sprintf("  (%d totals under 0.01%% not shown)", hidden)))
+	}
+}
+
 var coinCmd = &cobra.Command{
 	Use:   "coin",
 	Short: "Flip a coin",
 	Args:  cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		rng := decide.NewRNG(decideSeed)
+		rng := decisionRNG(nil)
 		results := make([]string, decideCount)
 
 		for i := 0; i < decideCount; i++ {
@@ -358,7 +441,7 @@ var coinCmd = &cobra.Command{
 			}
 		}
 
-		outputResult("coin", results)
+		finishDecision("coin", nil, results)
 	},
 }
 
@@ -384,7 +467,7 @@ var numberCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
-		rng := decide.NewRNG(decideSeed)
+		rng := decisionRNG(args)
 		results := make([]int, decideCount)
 
 		for i := 0; i < decideCount; i++ {
@@ -396,7 +479,7 @@ var numberCmd = &cobra.Command{
 			results[i] = n + min
 		}
 
-		outputResult("number", results)
+		finishDecision("number", args, results)
 	},
 }
 
@@ -442,7 +525,7 @@ Examples:
 			totalWeight += weight
 		}
 
-		rng := decide.NewRNG(decideSeed)
+		rng := decisionRNG(options)
 		results := make([]string, decideCount)
 
 		for i := 0; i < decideCount; i++ {
@@ -462,6 +545,6 @@ Examples:
 			}
 		}
 
-		outputResult("weighted", results)
+		finishDecision("weighted", options, results)
 	},
 }
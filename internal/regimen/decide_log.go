@@ -0,0 +1,386 @@
+package regimen
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gitlab.com/caffeinatedjack/sleepless/pkg/decide"
+	"gitlab.com/caffeinatedjack/sleepless/pkg/ui"
+)
+
+var (
+	decideLog    bool
+	decideLabel  string
+	decideReveal string
+
+	// decideRevealed is the commitment consumed by --reveal in this run.
+	decideRevealed *decide.Commitment
+	decisionLog    *decide.Log
+
+	rotateShow         bool
+	decideHistoryGroup string
+	decideHistoryLimit int
+)
+
+func init() {
+	decideCmd.AddCommand(rotateCmd)
+	decideCmd.AddCommand(commitCmd)
+	decideCmd.AddCommand(verifyCmd)
+	decideCmd.AddCommand(decideHistoryCmd)
+
+	rotateCmd.Flags().BoolVar(&rotateShow, "show", false, "Show the group's members and who is left this cycle")
+	decideHistoryCmd.Flags().StringVar(&decideHistoryGroup, "group", "", "Only show picks for this rotation group")
+	decideHistoryCmd.Flags().IntVar(&decideHistoryLimit, "limit", 20, "Number of records to show")
+}
+
+// loadDecisionLog loads the decisions log from the goals directory once per
+// run, exiting if the wiki is encrypted.
+func loadDecisionLog() *decide.Log {
+	if decisionLog != nil {
+		return decisionLog
+	}
+	dir := getWikiDir()
+	if err := checkWikiEncrypted(dir); err != nil {
+		ui.Error(err.Error())
+		os.Exit(1)
+	}
+	l, err := decide.LoadLog(filepath.Join(dir, "tasks", decide.LogFile))
+	if err != nil {
+		ui.Error(err.Error())
+		os.Exit(1)
+	}
+	if l.SecretsPath, err = decide.DefaultSecretsPath(); err != nil {
+		ui.Error(err.Error())
+		os.Exit(1)
+	}
+	decisionLog = l
+	return l
+}
+
+func saveDecisionLog() {
+	if err := decisionLog.Save(); err != nil {
+		ui.Error(fmt.Sprintf("Failed to save decisions log: %v", err))
+		os.Exit(1)
+	}
+}
+
+// decisionRNG returns the RNG for a decision between options. A revealed
+// commitment supplies the seed, once the options are checked against it; a
+// logged decision without --seed draws one so the log can replay it.
+// Otherwise the choice is cryptographically random.
+func decisionRNG(options []string) decide.RNG {
+	switch {
+	case decideReveal != "":
+		if decideSeed != nil {
+			ui.Error("--reveal supplies the seed; do not combine it with --seed")
+			os.Exit(1)
+		}
+		c, err := loadDecisionLog().Reveal(decideReveal, decideLabel, options)
+		if err != nil {
+			ui.Error(err.Error())
+			os.Exit(1)
+		}
+		decideRevealed = &c
+		decideSeed = &c.Seed
+	case decideLog && decideSeed == nil:
+		seed, err := decide.RandomSeed()
+		if err != nil {
+			ui.Error(fmt.Sprintf("Random generation failed: %v", err))
+			os.Exit(1)
+		}
+		decideSeed = &seed
+	}
+	return decide.NewRNG(decideSeed)
+}
+
+// finishDecision prints the results and, for logged or revealed decisions,
+// records them.
+func finishDecision(mode string, options []string, results interface{}) {
+	outputResult(mode, results)
+	if !decideLog && decideRevealed == nil {
+		return
+	}
+
+	record := decide.Record{
+		Mode:    mode,
+		Label:   decideLabel,
+		Options: options,
+		Seed:    decideSeed,
+		Result:  resultStrings(results),
+	}
+	if decideRevealed != nil {
+		record.Commitment = decideRevealed.Hash
+		record.Nonce = decideRevealed.Nonce
+		if record.Label == "" {
+			record.Label = decideRevealed.Label
+		}
+		if !decideJSON {
+			fmt.Printf("\nRevealed seed %d, nonce %s\n", decideRevealed.Seed, decideRevealed.Nonce)
+			fmt.Printf("Verify: %s\n", verifyCommand(decideRevealed))
+		}
+	}
+	l := loadDecisionLog()
+	l.Add(record)
+	saveDecisionLog()
+}
+
+// resultStrings flattens any decide result for the log.
+func resultStrings(results interface{}) []string {
+	var out []string
+	switch v := results.(type) {
+	case []string:
+		out = v
+	case []int:
+		for _, n := range v {
+			out = append(out, strconv.Itoa(n))
+		}
+	case [][]string:
+		for _, run := range v {
+			out = append(out, strings.Join(run, ", "))
+		}
+	case []RollResult:
+		for _, r := range v {
+			out = append(out, strconv.Itoa(r.Total))
+		}
+	default:
+		out = []string{fmt.Sprint(results)}
+	}
+	return out
+}
+
+var rotateCmd = &cobra.Command{
+	Use:   "rotate <group> [members...]",
+	Short: "Pick the next member of a rotation fairly",
+	Long: `Pick from a named group round-robin: members come up in a random order and
+nobody is picked twice until everyone has been picked once. A new cycle never
+starts with the person who ended the previous one.
+
+Give members the first time (or to change them); afterwards the group name is
+enough. Every pick is recorded in the decisions log.
+
+Examples:
+    regimen decide rotate standup alice bob carol dave
+    regimen decide rotate standup
+    regimen decide rotate standup --show
+    regimen decide history --group standup`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name := args[0]
+		l := loadDecisionLog()
+
+		group, ok := l.Groups[name]
+		members, err := getOptions(args[1:])
+		switch {
+		case err == nil:
+			if group, err = l.SetMembers(name, members); err != nil {
+				ui.Error(err.Error())
+				os.Exit(1)
+			}
+		case len(args) > 1 || decideFrom != "":
+			// Members were given but could not be read.
+			ui.Error(err.Error())
+			os.Exit(1)
+		case !ok:
+			ui.Error(fmt.Sprintf("Unknown group %q; list its members the first time", name))
+			os.Exit(1)
+		}
+
+		if rotateShow {
+			showRotation(name, group)
+			if len(args) > 1 || decideFrom != "" {
+				saveDecisionLog()
+			}
+			return
+		}
+
+		// Rotations are always logged, so they always use a replayable seed.
+		decideLog = true
+		rng := decisionRNG(group.Members)
+		record := decide.Record{
+			Mode:    "rotate",
+			Label:   decideLabel,
+			Group:   name,
+			Options: group.Members,
+			Picked:  append([]string(nil), group.Picked...),
+			Last:    group.Last,
+			Seed:    decideSeed,
+		}
+		results := make([]string, decideCount)
+		for i := range results {
+			pick, err := group.Next(rng)
+			if err != nil {
+				ui.Error(fmt.Sprintf("Random generation failed: %v", err))
+				os.Exit(1)
+			}
+			results[i] = pick
+		}
+		outputResult("rotate", results)
+
+		record.Result = results
+		if decideRevealed != nil {
+			record.Commitment = decideRevealed.Hash
+			record.Nonce = decideRevealed.Nonce
+		}
+		l.Add(record)
+		saveDecisionLog()
+	},
+}
+
+// verifyCommand returns the command that checks a revealed commitment. The
+// arguments follow "--" as a seed may be negative.
+func verifyCommand(c *decide.Commitment) string {
+	parts := []string{"regimen decide verify"}
+	if c.Label != "" {
+		parts = append(parts, "--label", strconv.Quote(c.Label))
+	}
+	parts = append(parts, "--", c.Hash, strconv.FormatInt(c.Seed, 10), c.Nonce)
+	for _, o := range c.Options {
+		parts = append(parts, strconv.Quote(o))
+	}
+	return strings.Join(parts, " ")
+}
+
+func showRotation(name string, g *decide.Group) {
+	remaining := g.Remaining()
+	if decideJSON {
+		data, _ := json.MarshalIndent(map[string]interface{}{
+			"group":     name,
+			"members":   g.Members,
+			"picked":    g.Picked,
+			"remaining": remaining,
+		}, "", "  ")
+		fmt.Println(string(data))
+		return
+	}
+	fmt.Println(ui.BoldStyle.Render(name))
+	fmt.Printf("  Members:   %s\n", strings.Join(g.Members, ", "))
+	fmt.Printf("  Picked:    %s\n", joinOrNone(g.Picked))
+	fmt.Printf("  Remaining: %s\n", joinOrNone(remaining))
+}
+
+func joinOrNone(items []string) string {
+	if len(items) == 0 {
+		return ui.DimStyle.Render("(none)")
+	}
+	return strings.Join(items, ", ")
+}
+
+var commitCmd = &cobra.Command{
+	Use:   "commit [options...]",
+	Short: "Publish a seed hash before deciding",
+	Long: `Commit to a secret random seed by publishing a SHA-256 hash of it together
+with the label and the options, in order. Share the hash, then decide with
+--reveal <hash> and the same options: the decision uses the committed seed
+and prints it, so anyone can check that neither the seed nor the options
+changed after the commitment.
+
+The log records the hash, label and options. The seed stays in
+~/.config/regimen/decide-secrets.json, readable only by you, until the
+reveal, so the reveal must run on the same machine. Decisions without
+options, such as coin, are committed with none.
+
+Examples:
+    regimen decide commit alice bob carol --label "standup order"
+    regimen decide shuffle alice bob carol --reveal 3fa9c1
+    regimen decide verify --label "standup order" -- <hash> <seed> <nonce> alice bob carol`,
+	Run: func(cmd *cobra.Command, args []string) {
+		var options []string
+		if len(args) > 0 || decideFrom != "" {
+			var err error
+			if options, err = getOptions(args); err != nil {
+				ui.Error(err.Error())
+				os.Exit(1)
+			}
+		}
+		l := loadDecisionLog()
+		c, err := l.Commit(decideLabel, options)
+		if err != nil {
+			ui.Error(fmt.Sprintf("Random generation failed: %v", err))
+			os.Exit(1)
+		}
+		saveDecisionLog()
+
+		if decideJSON {
+			data, _ := json.MarshalIndent(map[string]interface{}{"commitment": c.Hash, "label": c.Label, "options": c.Options}, "", "  ")
+			fmt.Println(string(data))
+			return
+		}
+		fmt.Println(c.Hash)
+		fmt.Fprintf(os.Stderr, "Share this hash, then decide with --reveal %s\n", decide.ShortHash(c.Hash))
+	},
+}
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify <commitment> <seed> <nonce> [options...]",
+	Short: "Check a revealed seed against its commitment",
+	Long: `Check that a revealed seed and nonce, with the label (--label) and the
+options in order, hash to the commitment. Put the arguments after "--" when
+the seed is negative.
+
+Examples:
+    regimen decide verify --label "standup order" -- <hash> <seed> <nonce> alice bob carol`,
+	Args: cobra.MinimumNArgs(3),
+	Run: func(cmd *cobra.Command, args []string) {
+		seed, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			ui.Error("seed must be an integer")
+			os.Exit(1)
+		}
+		var options []string
+		if len(args) > 3 || decideFrom != "" {
+			if options, err = getOptions(args[3:]); err != nil {
+				ui.Error(err.Error())
+				os.Exit(1)
+			}
+		}
+		if !decide.VerifyCommitment(args[0], decideLabel, options, seed, args[2]) {
+			ui.Error("Seed, nonce, label and options do not match the commitment")
+			os.Exit(1)
+		}
+		ui.Success(fmt.Sprintf("Commitment matches seed %d", seed))
+	},
+}
+
+var decideHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show logged decisions",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		records := loadDecisionLog().Recent(decideHistoryLimit, decideHistoryGroup)
+		if decideJSON {
+			if records == nil {
+				records = []decide.Record{}
+			}
+			data, _ := json.MarshalIndent(records, "", "  ")
+			fmt.Println(string(data))
+			return
+		}
+		if len(records) == 0 {
+			ui.Info("No decisions logged yet")
+			return
+		}
+		for _, r := range records {
+			label := r.Mode
+			if r.Group != "" {
+				label += " " + r.Group
+			}
+			if r.Label != "" {
+				label += " (" + r.Label + ")"
+			}
+			seed := ""
+			if r.Seed != nil {
+				seed = fmt.Sprintf("seed %d", *r.Seed)
+			}
+			if r.Commitment != "" {
+				seed += ", commitment " + decide.ShortHash(r.Commitment)
+			}
+			fmt.Printf("%s  %s: %s  %s\n", ui.DimStyle.Render(r.Timestamp), label,
+				strings.Join(r.Result, "; "), ui.DimStyle.Render(seed))
+		}
+	},
+}
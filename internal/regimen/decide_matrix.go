@@ -0,0 +1,277 @@
+package regimen
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gitlab.com/caffeinatedjack/sleepless/pkg/decide"
+	"gitlab.com/caffeinatedjack/sleepless/pkg/notes"
+	"gitlab.com/caffeinatedjack/sleepless/pkg/ui"
+)
+
+var (
+	matrixPairwise bool
+	matrixSave     bool
+)
+
+func init() {
+	decideCmd.AddCommand(matrixCmd)
+	matrixCmd.Flags().BoolVar(&matrixPairwise, "pairwise", false, "Set criteria weights by answering pairwise comparisons (AHP)")
+	matrixCmd.Flags().BoolVar(&matrixSave, "save", false, "Save the decision as a floating note")
+}
+
+var matrixCmd = &cobra.Command{
+	Use:   "matrix <file>",
+	Short: "Score options against weighted criteria",
+	Long: `Rank options scored against weighted criteria, read from YAML or CSV.
+
+YAML:
+    title: New laptop
+    criteria:
+      - {name: price, weight: 3, direction: min}   # lower is better
+      - {name: battery, weight: 2}
+      - {name: screen, weight: 1}
+    options:
+      - {name: Alpha, scores: {price: 1200, battery: 14, screen: 8}}
+      - {name: Beta,  scores: {price: 900,  battery: 9,  screen: 7}}
+
+CSV:
+    option,price,battery,screen
+    weight,3,2,1
+    direction,min,max,max
+    Alpha,1200,14,8
+    Beta,900,9,7
+
+Two methods are shown:
+    weighted sum   scores rescaled to 0-1 per criterion, then weighted
+    rank-based     only the order of options on each criterion counts
+
+Weights can instead come from AHP pairwise comparisons: use --pairwise to
+answer them interactively, or give "pairwise: {battery/screen: 3, ...}" in
+YAML. A consistency ratio above 0.1 means the answers contradict each other.
+
+Sensitivity analysis shows, for each criterion, the weight at which a
+different option would win the weighted sum (other weights keep their
+proportions).
+
+Examples:
+    regimen decide matrix laptop.yaml
+    regimen decide matrix laptop.csv --pairwise
+    regimen decide matrix laptop.yaml --save
+    regimen decide matrix laptop.yaml --json`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		m, err := decide.LoadMatrix(args[0])
+		if err != nil {
+			ui.Error(err.Error())
+			os.Exit(1)
+		}
+
+		if matrixPairwise {
+			judgements, err := promptPairwise(m.CriteriaNames(), bufio.NewReader(os.Stdin), os.Stderr)
+			if err != nil {
+				ui.Error(err.Error())
+				os.Exit(1)
+			}
+			m.Pairwise = judgements
+		}
+
+		res, err := m.Evaluate()
+		if err != nil {
+			ui.Error(err.Error())
+			os.Exit(1)
+		}
+
+		if decideJSON {
+			data, _ := json.MarshalIndent(res, "", "  ")
+			fmt.Println(string(data))
+		} else {
+			printMatrixResult(res, m)
+		}
+
+		if matrixSave {
+			saveMatrixNote(res, m)
+		}
+	},
+}
+
+// promptPairwise asks for a judgement on every pair of criteria.
+func promptPairwise(criteria []string, in *bufio.Reader, out io.Writer) (map[string]float64, error) {
+	if len(criteria) < 2 {
+		return nil, fmt.Errorf("pairwise comparison needs at least two criteria")
+	}
+	fmt.Fprintln(out, "Compare each pair: how many times more important is the first?")
+	fmt.Fprintln(out, "  1 = equal, 3 = moderately, 5 = strongly, 9 = extremely; 1/3 etc. if the second matters more")
+
+	judgements := make(map[string]float64)
+	for i := 0; i < len(criteria); i++ {
+		for j := i + 1; j < len(criteria); j++ {
+			for {
+				fmt.Fprintf(out, "%s vs %s: ", criteria[i], criteria[j])
+				line, err := in.ReadString('\n')
+				if err != nil && (err != io.EOF || strings.TrimSpace(line) == "") {
+					return nil, fmt.Errorf("pairwise comparison aborted")
+				}
+				v, perr := decide.ParseJudgement(line)
+				if perr != nil {
+					fmt.Fprintln(out, "  "+perr.Error())
+					if err == io.EOF {
+						return nil, perr
+					}
+					continue
+				}
+				judgements[criteria[i]+"/"+criteria[j]] = v
+				break
+			}
+		}
+	}
+	return judgements, nil
+}
+
+func criterionLabel(m *decide.Matrix, i int) string {
+	if m.Criteria[i].Direction == decide.DirectionMin {
+		return m.Criteria[i].Name + " (lower is better)"
+	}
+	return m.Criteria[i].Name
+}
+
+func printMatrixResult(res *decide.MatrixResult, m *decide.Matrix) {
+	if res.Title != "" {
+		fmt.Println(ui.BoldStyle.Render(res.Title))
+		fmt.Println()
+	}
+
+	fmt.Println("Criteria:")
+	for i, w := range res.Weights {
+		fmt.Printf("  %5.1f%%  %s\n", w*100, criterionLabel(m, i))
+	}
+	if res.Consistency != nil {
+		line := fmt.Sprintf("  Consistency ratio %.3f", *res.Consistency)
+		if *res.Consistency > 0.1 {
+			fmt.Println(ui.WarningStyle.Render(line + " (above 0.1: judgements are inconsistent)"))
+		} else {
+			fmt.Println(ui.DimStyle.Render(line))
+		}
+	}
+	fmt.Println()
+
+	width := len("Option")
+	for _, o := range res.Options {
+		if len(o.Name) > width {
+			width = len(o.Name)
+		}
+	}
+	fmt.Printf("  %-*s  %-14s  %s\n", width, "Option", "Weighted sum", "Rank-based")
+	for _, o := range sortedByWeighted(res.Options) {
+		fmt.Printf("  %-*s  %.3f  (#%d)    %.3f  (#%d)\n", width, o.Name, o.WeightedScore, o.WeightedRank, o.RankScore, o.RankRank)
+	}
+	fmt.Println()
+
+	if res.WeightedWinner == res.RankWinner {
+		ui.Success(fmt.Sprintf("Winner: %s", res.WeightedWinner))
+	} else {
+		ui.Warning(fmt.Sprintf("Methods disagree: %s (weighted sum) vs %s (rank-based)", res.WeightedWinner, res.RankWinner))
+	}
+
+	fmt.Println()
+	fmt.Println(ui.BoldStyle.Render("Sensitivity"))
+	for _, line := range sensitivityLines(res) {
+		fmt.Println("  " + line)
+	}
+}
+
+func sortedByWeighted(opts []decide.OptionResult) []decide.OptionResult {
+	sorted := append([]decide.OptionResult(nil), opts...)
+	for i := 1; i < len(sorted); i++ {
+		for j := i; j > 0 && sorted[j].WeightedRank < sorted[j-1].WeightedRank; j-- {
+			sorted[j], sorted[j-1] = sorted[j-1], sorted[j]
+		}
+	}
+	return sorted
+}
+
+func sensitivityLines(res *decide.MatrixResult) []string {
+	var lines []string
+	for _, s := range res.Sensitivity {
+		if s.FlipAt == nil {
+			lines = append(lines, fmt.Sprintf("%s: %.1f%%, no weight change makes %s lose", s.Criterion, s.Weight*100, res.WeightedWinner))
+			continue
+		}
+		dir := "above"
+		if *s.FlipAt < s.Weight {
+			dir = "below"
+		}
+		lines = append(lines, fmt.Sprintf("%s: %.1f%%, %s wins %s %.1f%% (%+.1f points)",
+			s.Criterion, s.Weight*100, s.FlipTo, dir, *s.FlipAt*100, (*s.FlipAt-s.Weight)*100))
+	}
+	return lines
+}
+
+// matrixMarkdown renders the decision for a note.
+func matrixMarkdown(res *decide.MatrixResult, m *decide.Matrix) string {
+	var b strings.Builder
+	b.WriteString("## Criteria\n\n| Criterion | Weight |\n|---|---|\n")
+	for i, w := range res.Weights {
+		fmt.Fprintf(&b, "| %s | %.1f%% |\n", criterionLabel(m, i), w*100)
+	}
+	if res.Consistency != nil {
+		fmt.Fprintf(&b, "\nWeights from pairwise comparison, consistency ratio %.3f.\n", *res.Consistency)
+	}
+
+	b.WriteString("\n## Scores\n\n| Option |")
+	for _, c := range m.Criteria {
+		fmt.Fprintf(&b, " %s |", c.Name)
+	}
+	b.WriteString(" Weighted sum | Rank-based |\n|---|")
+	b.WriteString(strings.Repeat("---|", len(m.Criteria)+2))
+	b.WriteString("\n")
+	for _, o := range sortedByWeighted(res.Options) {
+		fmt.Fprintf(&b, "| %s |", o.Name)
+		for _, opt := range m.Options {
+			if opt.Name == o.Name {
+				for _, c := range m.Criteria {
+					fmt.Fprintf(&b, " %g |", opt.Scores[c.Name])
+				}
+			}
+		}
+		fmt.Fprintf(&b, " %.3f (#%d) | %.3f (#%d) |\n", o.WeightedScore, o.WeightedRank, o.RankScore, o.RankRank)
+	}
+
+	fmt.Fprintf(&b, "\n## Result\n\nWeighted sum: **%s**. Rank-based: **%s**.\n", res.WeightedWinner, res.RankWinner)
+	b.WriteString("\n## Sensitivity\n\n")
+	for _, line := range sensitivityLines(res) {
+		b.WriteString("- " + line + "\n")
+	}
+	return b.String()
+}
+
+func saveMatrixNote(res *decide.MatrixResult, m *decide.Matrix) {
+	wikiDir := getWikiDir()
+	if err := checkWikiEncrypted(wikiDir); err != nil {
+		ui.Error(err.Error())
+		os.Exit(1)
+	}
+	store := notes.NewStore(wikiDir)
+	if err := store.EnsureStructure(); err != nil {
+		ui.Error(err.Error())
+		os.Exit(1)
+	}
+
+	note, err := store.CreateFloating("Decision: "+res.Title, matrixMarkdown(res, m), []string{"decision"})
+	if err != nil {
+		ui.Error(err.Error())
+		os.Exit(1)
+	}
+	if err := store.SaveFloating(note); err != nil {
+		ui.Error(fmt.Sprintf("Failed to save note: %v", err))
+		os.Exit(1)
+	}
+	if !decideJSON {
+		ui.Success(fmt.Sprintf("Saved decision as floating note %s", note.ID))
+	}
+}
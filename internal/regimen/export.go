@@ -0,0 +1,109 @@
+package regimen
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gitlab.com/caffeinatedjack/sleepless/pkg/notes"
+	"gitlab.com/caffeinatedjack/sleepless/pkg/site"
+	"gitlab.com/caffeinatedjack/sleepless/pkg/storage"
+	"gitlab.com/caffeinatedjack/sleepless/pkg/ui"
+)
+
+var (
+	exportOut        string
+	exportTitle      string
+	exportTheme      string
+	exportRecipesDir string
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the wiki to other formats",
+	Long: `Export the wiki to other formats.
+
+Commands:
+    html    Render notes, goals and recipes as a static HTML site
+
+Examples:
+    regimen export html --out ~/wiki-site`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// Exporting an encrypted wiki would leave pages out or publish
+		// ciphertext, so refuse outright.
+		return checkWikiEncrypted(getWikiDir())
+	},
+}
+
+var exportHTMLCmd = &cobra.Command{
+	Use:   "html --out <dir>",
+	Short: "Render notes, goals and recipes as a static HTML site",
+	Long: `Render the wiki as a static HTML site for reading on a tablet or phone.
+
+The site has a page for every daily, floating and review note, goal topic
+and recipe, with:
+    - links between pages, and the pages linking to each one
+    - a calendar of daily notes, linked to weekly and monthly reviews
+    - a page for each tag, listing the notes, goals and recipes using it
+    - a search page that runs in the browser, with no server needed
+    - a light and dark theme that follows the device (--theme)
+
+Every link is relative, so the directory can be opened straight from disk,
+synced to a tablet or served by any static web server. Running the export
+again replaces the previous one; any other non-empty directory is refused.
+An encrypted wiki is refused too: run 'regimen decrypt' first.
+
+Examples:
+    regimen export html --out ~/wiki-site
+    regimen export html --out /tmp/site --title "My wiki" --theme dark`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if exportOut == "" {
+			return fmt.Errorf("--out is required")
+		}
+		wikiDir := getWikiDir()
+		if _, err := os.Stat(wikiDir); err != nil {
+			return fmt.Errorf("wiki directory not found: %s", wikiDir)
+		}
+
+		recipesPath := exportRecipesDir
+		if recipesPath == "" {
+			recipesPath = os.Getenv(envRecipesDir)
+		}
+		if recipesPath == "" {
+			recipesPath = filepath.Join(wikiDir, "recipes")
+		}
+
+		s := &site.Site{
+			Notes:      notes.NewStore(wikiDir),
+			Goals:      storage.New(filepath.Join(wikiDir, "tasks")),
+			RecipesDir: expandPath(recipesPath),
+			Title:      exportTitle,
+			Theme:      exportTheme,
+			Now:        time.Now(),
+		}
+		out := expandPath(exportOut)
+		report, err := s.Build(out)
+		if err != nil {
+			return err
+		}
+
+		ui.Success(fmt.Sprintf("Exported %d pages to %s", report.Pages, out))
+		ui.PrintDim(fmt.Sprintf("  %d notes, %d goals in %d topics, %d recipes, %d tags, %d attachments",
+			report.Notes, report.Goals, report.Topics, report.Recipes, report.Tags, report.Attachments))
+		ui.PrintDim("  Open " + filepath.Join(out, "index.html"))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	exportCmd.AddCommand(exportHTMLCmd)
+	exportHTMLCmd.Flags().StringVarP(&exportOut, "out", "o", "", "Directory to write the site to")
+	exportHTMLCmd.Flags().StringVar(&exportTitle, "title", "Wiki", "Site title")
+	exportHTMLCmd.Flags().StringVar(&exportTheme, "theme", "auto", "Colour theme: auto, light or dark")
+	exportHTMLCmd.Flags().StringVar(&exportRecipesDir, "recipes-dir", "",
+		fmt.Sprintf("Recipe directory (default <wiki>/recipes, env: %s)", envRecipesDir))
+}
@@ -0,0 +1,202 @@
+package regimen
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gitlab.com/caffeinatedjack/sleepless/pkg/ics"
+	"gitlab.com/caffeinatedjack/sleepless/pkg/task"
+	"gitlab.com/caffeinatedjack/sleepless/pkg/ui"
+)
+
+var icsCmd = &cobra.Command{
+	Use:   "ics",
+	Short: "Export and import goals as iCalendar",
+	Long: `Export and import goals as iCalendar (.ics) files.
+
+Goals with due dates are exported as to-dos (VTODO). With --events, open
+goals due in the coming days are also exported as all-day events, matching
+'goals view calendar', for calendar apps that do not show to-dos.
+
+Examples:
+    regimen goals ics export -o goals.ics
+    regimen goals ics export --events --days 30 > calendar.ics
+    regimen goals ics import tasks.ics --topic work`,
+}
+
+var (
+	icsOutput    string
+	icsEvents    bool
+	icsDays      int
+	icsCompleted bool
+	icsTopic     string
+)
+
+func init() {
+	goalsCmd.AddCommand(icsCmd)
+	icsCmd.AddCommand(icsExportCmd)
+	icsCmd.AddCommand(icsImportCmd)
+
+	icsExportCmd.Flags().StringVarP(&icsOutput, "output", "o", "", "Write to a file instead of stdout")
+	icsExportCmd.Flags().BoolVar(&icsEvents, "events", false, "Also export upcoming due dates as events")
+	icsExportCmd.Flags().IntVarP(&icsDays, "days", "d", 14, "Days of events to export with --events")
+	icsExportCmd.Flags().BoolVar(&icsCompleted, "completed", false, "Include completed goals")
+
+	icsImportCmd.Flags().StringVarP(&icsTopic, "topic", "t", "", "Topic for imported goals (default: the first category, or inbox)")
+	icsImportCmd.Flags().BoolVar(&icsEvents, "events", false, "Also import events as goals due on their start date")
+}
+
+// walkTasks calls fn for each task and its subtasks.
+func walkTasks(tasks []*task.Task, fn func(*task.Task)) {
+	for _, t := range tasks {
+		fn(t)
+		walkTasks(t.Subtasks, fn)
+	}
+}
+
+var icsExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export goals with due dates",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		allTasks, err := store.LoadTasks("")
+		if err != nil {
+			ui.Error(fmt.Sprintf("Failed to load goals: %v", err))
+			os.Exit(1)
+		}
+
+		today := time.Now().Truncate(24 * time.Hour)
+		cutoff := today.AddDate(0, 0, icsDays)
+		cal := &ics.Calendar{}
+		walkTasks(allTasks, func(t *task.Task) {
+			if t.Due == nil || (t.IsComplete() && !icsCompleted) {
+				return
+			}
+			cal.Todos = append(cal.Todos, ics.TodoFromTask(t))
+			if icsEvents && !t.IsComplete() && !t.Due.Before(today) && !t.Due.After(cutoff) {
+				cal.Events = append(cal.Events, ics.DueEvent(t))
+			}
+		})
+
+		var w io.Writer = os.Stdout
+		if icsOutput != "" {
+			f, err := os.Create(icsOutput)
+			if err != nil {
+				ui.Error(fmt.Sprintf("Failed to create %s: %v", icsOutput, err))
+				os.Exit(1)
+			}
+			defer f.Close()
+			w = f
+		}
+		if err := ics.Encode(w, cal, time.Time{}); err != nil {
+			ui.Error(fmt.Sprintf("Failed to write calendar: %v", err))
+			os.Exit(1)
+		}
+		if icsOutput != "" {
+			ui.Success(fmt.Sprintf("Exported %d goals and %d events to %s", len(cal.Todos), len(cal.Events), icsOutput))
+		}
+	},
+}
+
+var icsImportCmd = &cobra.Command{
+	Use:   "import <file.ics>",
+	Short: "Create goals from to-dos in an iCalendar file",
+	Long: `Create goals from the to-dos (VTODO) in an iCalendar file.
+
+To-dos that were exported by regimen, or that match an existing open goal's
+title and due date, are skipped, so importing the same file twice is safe.
+With --events, events become goals due on the day they start; recurring
+events are imported once, on their first date.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cal, err := ics.Open(args[0])
+		if err != nil {
+			ui.Error(err.Error())
+			os.Exit(1)
+		}
+		warnCalendar(cal)
+		allTasks, err := store.LoadTasks("")
+		if err != nil {
+			ui.Error(fmt.Sprintf("Failed to load goals: %v", err))
+			os.Exit(1)
+		}
+
+		existing := make(map[string]bool)
+		walkTasks(allTasks, func(t *task.Task) {
+			existing[t.ID] = true
+			if !t.IsComplete() {
+				existing[goalKey(t)] = true
+			}
+		})
+
+		var imported []*task.Task
+		for _, todo := range cal.Todos {
+			imported = append(imported, ics.TaskFromTodo(todo))
+		}
+		if icsEvents {
+			for _, ev := range cal.Events {
+				t := ics.TaskFromTodo(ics.Todo{Summary: ev.Summary, Description: ev.Description})
+				start := ev.Start
+				if !ev.AllDay {
+					start = start.Local()
+				}
+				due := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+				t.Due = &due
+				imported = append(imported, t)
+			}
+		}
+
+		uids := make([]string, 0, len(cal.Todos))
+		for _, todo := range cal.Todos {
+			uids = append(uids, todo.UID)
+		}
+
+		added, skipped := 0, 0
+		for i, t := range imported {
+			if i < len(uids) && existing[ics.TaskIDFromUID(uids[i])] {
+				skipped++
+				continue
+			}
+			if existing[goalKey(t)] {
+				skipped++
+				continue
+			}
+			if icsTopic != "" {
+				t.Topic = icsTopic
+			}
+			if err := store.SaveTask(t); err != nil {
+				ui.Error(fmt.Sprintf("Failed to save: %v", err))
+				os.Exit(1)
+			}
+			existing[goalKey(t)] = true
+			added++
+			fmt.Printf("  %s %s %s\n", ui.DimStyle.Render(t.ShortID()), t.Title, ui.DimStyle.Render(t.Topic))
+		}
+
+		ui.Success(fmt.Sprintf("Imported %d goals from %s", added, args[0]))
+		if skipped > 0 {
+			ui.PrintDim(fmt.Sprintf("Skipped %d already in the wiki", skipped))
+		}
+	},
+}
+
+// goalKey identifies a goal by title and due date for duplicate detection.
+func goalKey(t *task.Task) string {
+	key := strings.ToLower(strings.TrimSpace(t.Title))
+	if t.Due != nil {
+		key += "|" + t.Due.Format("2006-01-02")
+	}
+	return key
+}
+
+// warnCalendar prints the calendar's parse warnings to stderr, keeping
+// JSON output clean.
+func warnCalendar(cal *ics.Calendar) {
+	for _, w := range cal.Warnings {
+		fmt.Fprintln(os.Stderr, ui.WarningStyle.Render(w))
+	}
+}
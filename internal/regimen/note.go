@@ -28,6 +28,16 @@ Commands:
     report        Generate a report from recent notes
     stats         Display statistics
     template      Manage note templates
+    agenda        Add calendar events to a daily note
+    backlinks     List the pages that link to a note
+    orphans       List notes with no links in or out
+    graph         Export the wiki link graph
+    rename        Rename a note and update links to it
+    merge         Merge a note into another
+    review        Review flashcards, or open a week/month/quarter/year review
+    log           Log mood, energy, sleep and habits
+    trends        Show mood, energy, sleep and habit trends
+    import        Import notes from Obsidian, Logseq, Day One or a folder
 
 Examples:
     regimen note add "Had an idea for improving the login flow"
@@ -5,11 +5,14 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 	"gitlab.com/caffeinatedjack/sleepless/pkg/notes"
+	"gitlab.com/caffeinatedjack/sleepless/pkg/storage"
+	"gitlab.com/caffeinatedjack/sleepless/pkg/task"
 	"gitlab.com/caffeinatedjack/sleepless/pkg/ui"
 )
 
@@ -19,6 +22,7 @@ var (
 	addFloating bool
 	addDaily    bool
 	addTemplate string
+	addVars     []string
 )
 
 var noteAddCmd = &cobra.Command{
@@ -34,6 +38,7 @@ Examples:
     regimen note add "Sprint planning meeting" --tags "meeting,work"
     regimen note add --floating "Research: Distributed caching"
     regimen note add --date 2026-01-20
+    regimen note add --template meeting --var meeting_title="Sprint planning"
     regimen note add`,
 	RunE: runNoteAdd,
 }
@@ -45,6 +50,7 @@ func init() {
 	noteAddCmd.Flags().BoolVar(&addFloating, "floating", false, "Create floating note")
 	noteAddCmd.Flags().BoolVar(&addDaily, "daily", false, "Create daily note (default)")
 	noteAddCmd.Flags().StringVar(&addTemplate, "template", "", "Use template")
+	noteAddCmd.Flags().StringArrayVar(&addVars, "var", nil, "Template variable as key=value (repeatable)")
 }
 
 func runNoteAdd(cmd *cobra.Command, args []string) error {
@@ -174,9 +180,30 @@ func runAddFromTemplate(store *notes.Store, args []string) error {
 		return err
 	}
 
-	// Apply template with prompts
-	reader := bufio.NewReader(os.Stdin)
-	content, err := notes.ApplyTemplate(tmpl.Content, reader)
+	// Render for the note's date, so {{date}} and friends match it
+	now := time.Now()
+	if addDate != "" {
+		day, err := time.ParseInLocation("2006-01-02", addDate, time.Local)
+		if err != nil {
+			return fmt.Errorf("invalid date format: %w", err)
+		}
+		now = time.Date(day.Year(), day.Month(), day.Day(), now.Hour(), now.Minute(), 0, 0, time.Local)
+	}
+
+	ctx := store.NewTemplateContext(now)
+	ctx.Prompt = bufio.NewReader(os.Stdin)
+	for _, kv := range addVars {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return fmt.Errorf("invalid --var %q: expected key=value", kv)
+		}
+		ctx.Vars[strings.TrimSpace(key)] = value
+	}
+	if _, ok := ctx.Vars["goals_due"]; !ok {
+		ctx.Vars["goals_due"] = goalsDue(now)
+	}
+
+	content, err := notes.RenderTemplate(tmpl.Content, ctx)
 	if err != nil {
 		return fmt.Errorf("failed to apply template: %w", err)
 	}
@@ -240,3 +267,25 @@ func runAddFromTemplate(store *notes.Store, args []string) error {
 	ui.Success(fmt.Sprintf("Created floating note %s from template %q", note.ID, addTemplate))
 	return nil
 }
+
+// goalsDue lists the open goals due on or before the day of now as a
+// markdown checklist, or returns "" if there are none.
+func goalsDue(now time.Time) string {
+	tasksPath := filepath.Join(getWikiDir(), "tasks")
+	if _, err := os.Stat(tasksPath); err != nil {
+		return ""
+	}
+	tasks, err := storage.New(tasksPath).LoadTasks("")
+	if err != nil {
+		return ""
+	}
+	day := now.Format("2006-01-02")
+	var lines []string
+	walkTasks(tasks, func(t *task.Task) {
+		if t.IsComplete() || t.Due == nil || t.Due.Format("2006-01-02") > day {
+			return
+		}
+		lines = append(lines, fmt.Sprintf("- [ ] %s @task:%s", t.Title, t.ShortID()))
+	})
+	return strings.Join(lines, "\n")
+}
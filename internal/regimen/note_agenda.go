@@ -0,0 +1,94 @@
+package regimen
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gitlab.com/caffeinatedjack/sleepless/pkg/ics"
+	"gitlab.com/caffeinatedjack/sleepless/pkg/notes"
+	"gitlab.com/caffeinatedjack/sleepless/pkg/ui"
+)
+
+var agendaDate string
+
+var noteAgendaCmd = &cobra.Command{
+	Use:   "agenda <file.ics>",
+	Short: "Add a day's calendar events to its daily note",
+	Long: `Add a day's events from an iCalendar (.ics) file to the daily note as an
+"## Agenda" section. Running it again replaces the section, so it can be
+refreshed from an updated calendar export. Times are shown in local time.
+Recurring events only appear on their first date.
+
+Examples:
+    regimen note agenda ~/Downloads/work.ics
+    regimen note agenda work.ics --date 2026-01-20`,
+	Args: cobra.ExactArgs(1),
+	RunE: runNoteAgenda,
+}
+
+func init() {
+	noteCmd.AddCommand(noteAgendaCmd)
+	noteAgendaCmd.Flags().StringVar(&agendaDate, "date", "", "Date for daily note (YYYY-MM-DD, default today)")
+}
+
+func runNoteAgenda(cmd *cobra.Command, args []string) error {
+	date := agendaDate
+	if date == "" {
+		date = time.Now().Format("2006-01-02")
+	} else if _, err := time.Parse("2006-01-02", date); err != nil {
+		return fmt.Errorf("invalid date format: %s (use YYYY-MM-DD)", date)
+	}
+
+	cal, err := ics.Open(args[0])
+	if err != nil {
+		return err
+	}
+	warnCalendar(cal)
+	events := cal.On(date, time.Local)
+	if len(events) == 0 {
+		ui.Info(fmt.Sprintf("No events on %s in %s", date, args[0]))
+		return nil
+	}
+
+	store := notes.NewStore(getWikiDir())
+	if err := store.EnsureStructure(); err != nil {
+		return err
+	}
+	note, err := store.GetOrCreateDaily(date)
+	if err != nil {
+		return err
+	}
+	notes.SetSection(note, "Agenda", agendaLines(events))
+	if err := store.SaveDaily(note); err != nil {
+		return err
+	}
+
+	ui.Success(fmt.Sprintf("Added %d events to the agenda for %s", len(events), date))
+	return nil
+}
+
+// agendaLines formats events as a Markdown list in local time.
+func agendaLines(events []ics.Event) string {
+	var b strings.Builder
+	for _, ev := range events {
+		when := "all day"
+		if !ev.AllDay {
+			when = ev.Start.Local().Format("15:04")
+			if ev.End.After(ev.Start) {
+				when += "-" + ev.End.Local().Format("15:04")
+			}
+		}
+		summary := ev.Summary
+		if summary == "" {
+			summary = "(untitled)"
+		}
+		fmt.Fprintf(&b, "- %s %s", when, summary)
+		if ev.Location != "" {
+			fmt.Fprintf(&b, " (%s)", ev.Location)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -9,8 +9,9 @@ import (
 )
 
 var (
-	editDate   string
-	deleteDate string
+	editDate    string
+	deleteDate  string
+	deleteForce bool
 )
 
 var noteEditCmd = &cobra.Command{
@@ -35,6 +36,10 @@ var noteDeleteCmd = &cobra.Command{
 For floating notes: provide the ID or unique prefix.
 For daily notes: use --date flag.
 
+If other pages link to the note, they are listed and you are asked to
+confirm; use --force to skip the question. 'note merge' keeps the links
+working instead.
+
 Examples:
     regimen note delete abc123
     regimen note delete --date 2026-01-20`,
@@ -47,6 +52,7 @@ func init() {
 
 	noteEditCmd.Flags().StringVar(&editDate, "date", "", "Date for daily note (YYYY-MM-DD)")
 	noteDeleteCmd.Flags().StringVar(&deleteDate, "date", "", "Date for daily note (YYYY-MM-DD)")
+	noteDeleteCmd.Flags().BoolVarP(&deleteForce, "force", "f", false, "Delete without asking, even if linked")
 }
 
 func runNoteEdit(cmd *cobra.Command, args []string) error {
@@ -83,6 +89,9 @@ func runNoteDelete(cmd *cobra.Command, args []string) error {
 
 	if deleteDate != "" {
 		// Delete daily note
+		if !confirmDeleteLinked(store, deleteDate) {
+			return nil
+		}
 		if err := store.DeleteDaily(deleteDate); err != nil {
 			return err
 		}
@@ -101,6 +110,9 @@ func runNoteDelete(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if !confirmDeleteLinked(store, note.ID) {
+		return nil
+	}
 	if err := store.DeleteFloating(note.ID); err != nil {
 		return err
 	}
@@ -108,3 +120,28 @@ func runNoteDelete(cmd *cobra.Command, args []string) error {
 	ui.Success(fmt.Sprintf("Deleted floating note %s", note.ID))
 	return nil
 }
+
+// confirmDeleteLinked warns about links to a note that is about to be
+// deleted and asks whether to go ahead, saying so if the answer is no.
+func confirmDeleteLinked(store *notes.Store, key string) bool {
+	if deleteForce {
+		return true
+	}
+	graph, err := store.LinkGraph()
+	if err != nil {
+		return true
+	}
+	links := graph.Backlinks(key)
+	if len(links) == 0 {
+		return true
+	}
+	ui.Warning(fmt.Sprintf("%d links to %s will break:", len(links), key))
+	for _, l := range links {
+		fmt.Printf("  %s\n", ui.DimStyle.Render(fmt.Sprintf("%s:%d", l.Source, l.Line)))
+	}
+	if !confirm("Delete anyway?") {
+		ui.PrintDim("Cancelled")
+		return false
+	}
+	return true
+}
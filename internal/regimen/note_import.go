@@ -0,0 +1,118 @@
+package regimen
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gitlab.com/caffeinatedjack/sleepless/pkg/notes"
+	"gitlab.com/caffeinatedjack/sleepless/pkg/ui"
+)
+
+var (
+	importFrom   string
+	importDryRun bool
+)
+
+var noteImportCmd = &cobra.Command{
+	Use:   "import --from <obsidian|logseq|dayone|dir> <path>",
+	Short: "Import notes from Obsidian, Logseq, Day One or a markdown folder",
+	Long: `Import notes from another tool into the wiki.
+
+Sources:
+    obsidian    An Obsidian vault
+    logseq      A Logseq graph (its journals/ and pages/ folders)
+    dayone      A Day One JSON export, as the .json file or the unzipped folder
+    dir         Any folder of markdown files
+
+Files named after a date (2026-01-20, or 2026_01_20 in Logseq journals)
+become daily notes; Day One entries are grouped into one daily note per day.
+Everything else becomes a floating note. Tags, aliases and created/updated
+times are taken from frontmatter or Logseq properties.
+
+[[Wiki links]] and markdown links between the imported files are pointed at
+the new notes. Images and other attachments they link to are copied into
+notes/attachments/. Links that match nothing are left as written and listed.
+
+A note whose content is already in the wiki is skipped as a duplicate, so
+the same folder can be imported again. An imported day that already has a
+daily note is added to it under an "## Imported from" heading.
+
+Examples:
+    regimen note import --from obsidian ~/Documents/Vault --dry-run
+    regimen note import --from logseq ~/logseq
+    regimen note import --from dayone ~/Downloads/Journal.json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		source, err := notes.ParseImportSource(importFrom)
+		if err != nil {
+			return err
+		}
+		store := notes.NewStore(getWikiDir())
+		plan, err := store.PlanImport(source, args[0])
+		if err != nil {
+			return err
+		}
+
+		printImportPlan(store, plan)
+		if importDryRun {
+			ui.Info("Dry run: nothing was written")
+			return nil
+		}
+		if plan.Count(notes.ImportCreate)+plan.Count(notes.ImportMerge) == 0 {
+			ui.Info("Nothing to import")
+			return nil
+		}
+		if err := store.ApplyImport(plan); err != nil {
+			return err
+		}
+		ui.Success(fmt.Sprintf("Imported %d notes from %s", len(plan.Changes), source))
+		return nil
+	},
+}
+
+func init() {
+	noteCmd.AddCommand(noteImportCmd)
+	noteImportCmd.Flags().StringVar(&importFrom, "from", "", "Source: obsidian, logseq, dayone or dir")
+	noteImportCmd.Flags().BoolVarP(&importDryRun, "dry-run", "n", false, "Show the import report without writing anything")
+	noteImportCmd.MarkFlagRequired("from")
+}
+
+// printImportPlan prints what happens to each source note, then totals and
+// any warnings.
+func printImportPlan(store *notes.Store, plan *notes.ImportPlan) {
+	links, unresolved := 0, 0
+	for _, it := range plan.Items {
+		source := strings.Join(it.Sources, ", ")
+		if len(it.Sources) > 3 {
+			source = fmt.Sprintf("%s and %d more", strings.Join(it.Sources[:2], ", "), len(it.Sources)-2)
+		}
+		switch it.Action {
+		case notes.ImportCreate:
+			fmt.Printf("%s %s  %s\n", ui.SuccessStyle.Render("+ "+it.Key), it.Title, ui.DimStyle.Render(source))
+		case notes.ImportMerge:
+			fmt.Printf("%s %s  %s\n", ui.WarningStyle.Render("~ "+it.Key), it.Title, ui.DimStyle.Render(source+" (added to existing note)"))
+		case notes.ImportDuplicate:
+			fmt.Printf("%s %s  %s\n", ui.DimStyle.Render("= "+it.Key), ui.DimStyle.Render(it.Title), ui.DimStyle.Render(source+" (duplicate, skipped)"))
+		}
+		for _, l := range it.Unresolved {
+			ui.PrintDim("    unresolved link " + l)
+		}
+		links += it.Links
+		unresolved += len(it.Unresolved)
+	}
+
+	fmt.Println()
+	fmt.Printf("%d new, %d merged, %d duplicates; %d links converted, %d unresolved; %d attachments\n",
+		plan.Count(notes.ImportCreate), plan.Count(notes.ImportMerge), plan.Count(notes.ImportDuplicate),
+		links, unresolved, len(plan.Attachments))
+	for _, a := range plan.Attachments {
+		if rel, err := filepath.Rel(store.WikiDir, a.To); err == nil {
+			ui.PrintDim("    " + rel)
+		}
+	}
+	for _, w := range plan.Warnings {
+		ui.Warning(w)
+	}
+}
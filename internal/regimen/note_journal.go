@@ -0,0 +1,277 @@
+package regimen
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gitlab.com/caffeinatedjack/sleepless/pkg/notes"
+	"gitlab.com/caffeinatedjack/sleepless/pkg/ui"
+)
+
+var (
+	logDate    string
+	trendsDays int
+	trendsCSV  string
+)
+
+var noteLogCmd = &cobra.Command{
+	Use:   "log [field=value...]",
+	Short: "Log mood, energy, sleep and habits in a daily note",
+	Long: `Log check-in fields in the frontmatter of a daily note.
+
+Fields:
+    mood=1-5          How the day felt
+    energy=1-5        Energy level
+    sleep=7.5         Hours slept the night before
+    habit.<name>=y/n  Whether a habit was done (habit.<name> alone means yes)
+
+An empty value clears a field. Without arguments, prompts for each field
+and for every habit logged in the last 30 days.
+
+Examples:
+    regimen note log mood=4 energy=3 sleep=7.5
+    regimen note log habit.run habit.read=false
+    regimen note log --date 2026-01-20 mood=2
+    regimen note log`,
+	RunE: runNoteLog,
+}
+
+var noteTrendsCmd = &cobra.Command{
+	Use:   "trends",
+	Short: "Show mood, energy, sleep and habit trends",
+	Long: `Show sparklines of mood, energy and sleep, and habit streaks, from the
+check-ins logged with "regimen note log".
+
+Examples:
+    regimen note trends
+    regimen note trends --days 30
+    regimen note trends --days 365 --csv checkins.csv`,
+	Args: cobra.NoArgs,
+	RunE: runNoteTrends,
+}
+
+func init() {
+	noteCmd.AddCommand(noteLogCmd)
+	noteCmd.AddCommand(noteTrendsCmd)
+
+	noteLogCmd.Flags().StringVar(&logDate, "date", "", "Date of the daily note (YYYY-MM-DD)")
+	noteTrendsCmd.Flags().IntVar(&trendsDays, "days", 90, "Number of days to show, ending today")
+	noteTrendsCmd.Flags().StringVar(&trendsCSV, "csv", "", "Export the check-ins as CSV to a file (- for stdout)")
+	noteTrendsCmd.Flags().BoolVar(&outputJSON, "json", false, "Output in JSON format")
+}
+
+func runNoteLog(cmd *cobra.Command, args []string) error {
+	store := notes.NewStore(getWikiDir())
+	date := logDate
+	if date == "" {
+		date = time.Now().Format("2006-01-02")
+	}
+	if _, err := store.DailyPath(date); err != nil {
+		return err
+	}
+	note, err := store.GetOrCreateDaily(date)
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		day, _ := time.ParseInLocation("2006-01-02", date, time.Local)
+		habits := notes.Habits(store.CheckIns(day, 30))
+		if err := promptCheckIn(bufio.NewReader(os.Stdin), os.Stderr, note, habits); err != nil {
+			return err
+		}
+	}
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok && strings.HasPrefix(arg, "habit.") {
+			value = "true"
+		} else if !ok {
+			return fmt.Errorf("expected field=value, got %q", arg)
+		}
+		if err := note.SetField(key, value); err != nil {
+			return err
+		}
+	}
+
+	if err := store.SaveDaily(note); err != nil {
+		return err
+	}
+	if summary := checkInSummary(note); summary != "" {
+		ui.Success(fmt.Sprintf("Logged %s: %s", date, summary))
+	} else {
+		ui.Success(fmt.Sprintf("Cleared check-in for %s", date))
+	}
+	return nil
+}
+
+// promptCheckIn asks for each check-in field in turn. An empty answer keeps
+// the current value.
+func promptCheckIn(reader *bufio.Reader, out io.Writer, note *notes.Note, habits []string) error {
+	type field struct{ key, question, current string }
+	fields := []field{
+		{"mood", "Mood (1-5)", intString(note.Mood)},
+		{"energy", "Energy (1-5)", intString(note.Energy)},
+		{"sleep", "Hours slept", ""},
+	}
+	if note.Sleep != nil {
+		fields[2].current = strconv.FormatFloat(*note.Sleep, 'f', -1, 64)
+	}
+	for _, name := range habits {
+		current := ""
+		if done, ok := note.Habits[name]; ok {
+			current = map[bool]string{true: "y", false: "n"}[done]
+		}
+		fields = append(fields, field{"habit." + name, name + "? (y/n)", current})
+	}
+
+	for _, f := range fields {
+		for {
+			prompt := f.question
+			if f.current != "" {
+				prompt += " [" + f.current + "]"
+			}
+			fmt.Fprint(out, prompt+": ")
+			line, err := reader.ReadString('\n')
+			answer := strings.TrimSpace(line)
+			if answer == "" {
+				if err != nil {
+					return nil
+				}
+				break
+			}
+			if err := note.SetField(f.key, answer); err != nil {
+				fmt.Fprintln(out, ui.WarningStyle.Render(err.Error()))
+				continue
+			}
+			break
+		}
+	}
+	return nil
+}
+
+func intString(v *int) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.Itoa(*v)
+}
+
+// checkInSummary formats a note's check-in fields on one line, or returns ""
+// if none are set.
+func checkInSummary(note *notes.Note) string {
+	var parts []string
+	if note.Mood != nil {
+		parts = append(parts, fmt.Sprintf("mood %d/5", *note.Mood))
+	}
+	if note.Energy != nil {
+		parts = append(parts, fmt.Sprintf("energy %d/5", *note.Energy))
+	}
+	if note.Sleep != nil {
+		parts = append(parts, fmt.Sprintf("sleep %gh", *note.Sleep))
+	}
+	for _, name := range notes.Habits([]notes.CheckIn{{Habits: note.Habits}}) {
+		mark := "✗"
+		if note.Habits[name] {
+			mark = "✓"
+		}
+		parts = append(parts, name+" "+mark)
+	}
+	return strings.Join(parts, " · ")
+}
+
+func runNoteTrends(cmd *cobra.Command, args []string) error {
+	if trendsDays < 1 {
+		return fmt.Errorf("--days must be at least 1")
+	}
+	store := notes.NewStore(getWikiDir())
+	checkIns := store.CheckIns(time.Now(), trendsDays)
+
+	if trendsCSV != "" {
+		if trendsCSV == "-" {
+			return notes.WriteCSV(os.Stdout, checkIns)
+		}
+		f, err := os.Create(trendsCSV)
+		if err != nil {
+			return err
+		}
+		if err := notes.WriteCSV(f, checkIns); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+		ui.Success(fmt.Sprintf("Exported %d days to %s", len(checkIns), trendsCSV))
+		return nil
+	}
+
+	var habits []notes.HabitStats
+	for _, name := range notes.Habits(checkIns) {
+		habits = append(habits, notes.Habit(checkIns, name))
+	}
+
+	if outputJSON {
+		data, err := json.MarshalIndent(struct {
+			CheckIns []notes.CheckIn    `json:"checkins"`
+			Habits   []notes.HabitStats `json:"habits"`
+		}{checkIns, habits}, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	logged := 0
+	for _, c := range checkIns {
+		if c.Logged() {
+			logged++
+		}
+	}
+	if logged == 0 {
+		ui.PrintDim(fmt.Sprintf("Nothing logged in the last %d days. Try: regimen note log mood=4", trendsDays))
+		return nil
+	}
+
+	fmt.Println()
+	fmt.Printf("%s %s\n", ui.BoldStyle.Render("Trends"),
+		ui.DimStyle.Render(fmt.Sprintf("%s to %s, %d of %d days logged",
+			checkIns[0].Date, checkIns[len(checkIns)-1].Date, logged, len(checkIns))))
+	fmt.Println()
+
+	width := len("energy")
+	for _, h := range habits {
+		width = max(width, len(h.Name))
+	}
+	for _, name := range []string{"mood", "energy", "sleep"} {
+		series := notes.Metric(checkIns, name)
+		if series.Count() == 0 {
+			continue
+		}
+		lo, hi := 1.0, 5.0
+		avg := fmt.Sprintf("avg %.1f", series.Average())
+		if name == "sleep" {
+			lo, hi = series.Range()
+			avg = fmt.Sprintf("avg %.1fh", series.Average())
+		}
+		fmt.Printf("  %-*s %s  %s\n", width, name, series.Sparkline(lo, hi), ui.DimStyle.Render(avg))
+	}
+
+	if len(habits) > 0 {
+		fmt.Println()
+		fmt.Println(ui.BoldStyle.Render("Habits"))
+		for _, h := range habits {
+			fmt.Printf("  %-*s %s  %s\n", width, h.Name, h.Marks,
+				ui.DimStyle.Render(fmt.Sprintf("%d/%d days, streak %d (best %d)", h.Done, h.Logged, h.Current, h.Longest)))
+		}
+	}
+	fmt.Println()
+	return nil
+}
@@ -0,0 +1,166 @@
+package regimen
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gitlab.com/caffeinatedjack/sleepless/pkg/notes"
+	"gitlab.com/caffeinatedjack/sleepless/pkg/ui"
+)
+
+var (
+	orphansDaily bool
+	graphFormat  string
+)
+
+var noteBacklinksCmd = &cobra.Command{
+	Use:   "backlinks <id|date>",
+	Short: "List the pages that link to a note",
+	Long: `List the pages in the wiki that link to a note.
+
+Links are [[id]], [[date]], [[page|text]], [text](path.md) and @note:<id>.
+Floating notes may be linked by a unique ID prefix.
+
+Examples:
+    regimen note backlinks a1b2c3d4
+    regimen note backlinks 2026-01-20`,
+	Args: cobra.ExactArgs(1),
+	RunE: runNoteBacklinks,
+}
+
+var noteOrphansCmd = &cobra.Command{
+	Use:   "orphans",
+	Short: "List notes with no links in or out",
+	Long: `List floating notes that no page links to and that link to nothing.
+
+Examples:
+    regimen note orphans
+    regimen note orphans --daily`,
+	Args: cobra.NoArgs,
+	RunE: runNoteOrphans,
+}
+
+var noteGraphCmd = &cobra.Command{
+	Use:   "graph",
+	Short: "Export the wiki link graph",
+	Long: `Export the link graph of the wiki as Graphviz dot or JSON.
+
+Examples:
+    regimen note graph | dot -Tsvg > wiki.svg
+    regimen note graph --format json`,
+	Args: cobra.NoArgs,
+	RunE: runNoteGraph,
+}
+
+func init() {
+	noteCmd.AddCommand(noteBacklinksCmd)
+	noteCmd.AddCommand(noteOrphansCmd)
+	noteCmd.AddCommand(noteGraphCmd)
+
+	noteBacklinksCmd.Flags().BoolVar(&outputJSON, "json", false, "Output in JSON format")
+	noteOrphansCmd.Flags().BoolVar(&orphansDaily, "daily", false, "Include daily notes")
+	noteGraphCmd.Flags().StringVar(&graphFormat, "format", "dot", "Output format (dot, json)")
+}
+
+func runNoteBacklinks(cmd *cobra.Command, args []string) error {
+	store := notes.NewStore(getWikiDir())
+	note, err := store.Load(args[0])
+	if err != nil {
+		return err
+	}
+	graph, err := store.LinkGraph()
+	if err != nil {
+		return err
+	}
+
+	links := graph.Backlinks(note.Key())
+	if outputJSON {
+		if links == nil {
+			links = []notes.Link{}
+		}
+		data, _ := json.MarshalIndent(links, "", "  ")
+		fmt.Println(string(data))
+		return nil
+	}
+	if len(links) == 0 {
+		ui.PrintDim(fmt.Sprintf("Nothing links to %s", note.Key()))
+		return nil
+	}
+	for _, l := range links {
+		fmt.Printf("%s  %s\n", ui.DimStyle.Render(fmt.Sprintf("%s:%d", l.Source, l.Line)), pageTitle(graph, l.Source))
+	}
+	return nil
+}
+
+func runNoteOrphans(cmd *cobra.Command, args []string) error {
+	store := notes.NewStore(getWikiDir())
+	graph, err := store.LinkGraph()
+	if err != nil {
+		return err
+	}
+	orphans := graph.Orphans(orphansDaily)
+	if len(orphans) == 0 {
+		ui.PrintDim("No orphaned notes")
+		return nil
+	}
+	for _, key := range orphans {
+		fmt.Printf("%s  %s\n", ui.DimStyle.Render(key), pageTitle(graph, key))
+	}
+	return nil
+}
+
+func runNoteGraph(cmd *cobra.Command, args []string) error {
+	store := notes.NewStore(getWikiDir())
+	graph, err := store.LinkGraph()
+	if err != nil {
+		return err
+	}
+	switch graphFormat {
+	case "dot":
+		fmt.Print(graph.Dot())
+	case "json":
+		data, _ := json.MarshalIndent(graph, "", "  ")
+		fmt.Println(string(data))
+	default:
+		return fmt.Errorf("unknown format %q (use dot or json)", graphFormat)
+	}
+	return nil
+}
+
+// pageTitle returns a page's title, or "" when it is the same as its key.
+func pageTitle(graph *notes.Graph, key string) string {
+	title := graph.Pages[key]
+	if title == key {
+		return ""
+	}
+	return title
+}
+
+// printLinkedFrom prints the pages linking to a note, as a footer.
+func printLinkedFrom(note *notes.Note) {
+	graph, err := notes.NewStore(getWikiDir()).LinkGraph()
+	if err != nil {
+		return
+	}
+	links := graph.Backlinks(note.Key())
+	if len(links) == 0 {
+		return
+	}
+	seen := make(map[string]bool)
+	var sources []string
+	for _, l := range links {
+		if seen[l.Source] {
+			continue
+		}
+		seen[l.Source] = true
+		entry := l.Source
+		if title := pageTitle(graph, l.Source); title != "" {
+			entry += " (" + title + ")"
+		}
+		sources = append(sources, entry)
+	}
+	fmt.Println()
+	fmt.Println(ui.DimStyle.Render("Linked from: " + strings.Join(sources, ", ")))
+}
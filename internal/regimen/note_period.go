@@ -0,0 +1,204 @@
+package regimen
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gitlab.com/caffeinatedjack/sleepless/pkg/notes"
+	"gitlab.com/caffeinatedjack/sleepless/pkg/storage"
+	"gitlab.com/caffeinatedjack/sleepless/pkg/task"
+	"gitlab.com/caffeinatedjack/sleepless/pkg/ui"
+)
+
+var (
+	periodDate     string
+	periodPrint    bool
+	periodNoRollup bool
+)
+
+func init() {
+	for _, name := range []string{"week", "month", "quarter", "year"} {
+		cmd := &cobra.Command{
+			Use:   name + " [period|date]",
+			Short: fmt.Sprintf("Open or create the %s's review note", name),
+			Long: fmt.Sprintf(`Open the review note for a %[1]s, creating it from the %[2]s template
+if it does not exist yet.
+
+The note's "Rollup" section is regenerated every time: links to each daily
+note in the %[1]s, the goals completed, the tags used and check-in
+averages. The rest of the note is yours to edit.
+
+The %[1]s defaults to the current one; pass a period key (2026-W03,
+2026-01, 2026-Q1 or 2026) or any date inside it to pick another.
+
+Examples:
+    regimen note review %[1]s
+    regimen note review %[1]s %[3]s
+    regimen note review %[1]s --print`, name, periodTemplate(name), periodExample(name)),
+			Args: cobra.MaximumNArgs(1),
+			RunE: runNotePeriod,
+		}
+		cmd.Flags().StringVar(&periodDate, "date", "", "A date in the period (YYYY-MM-DD)")
+		cmd.Flags().BoolVar(&periodPrint, "print", false, "Print the note instead of opening the editor")
+		cmd.Flags().BoolVar(&periodNoRollup, "no-rollup", false, "Leave the Rollup section unchanged")
+		noteReviewCmd.AddCommand(cmd)
+	}
+}
+
+func periodTemplate(name string) string {
+	t, _ := notes.ParsePeriodType(name)
+	return string(t)
+}
+
+func periodExample(name string) string {
+	switch name {
+	case "week":
+		return "2026-W03"
+	case "month":
+		return "2026-01"
+	case "quarter":
+		return "2026-Q1"
+	}
+	return "2025"
+}
+
+func runNotePeriod(cmd *cobra.Command, args []string) error {
+	kind, err := notes.ParsePeriodType(cmd.Name())
+	if err != nil {
+		return err
+	}
+
+	store := notes.NewStore(getWikiDir())
+	period, err := notes.PeriodOf(kind, time.Now())
+	if err != nil {
+		return err
+	}
+	ref := periodDate
+	if len(args) > 0 {
+		ref = args[0]
+	}
+	if ref != "" {
+		if p, ok := notes.ParsePeriod(ref); ok && p.Type == kind {
+			period = p
+		} else if day, err := time.ParseInLocation("2006-01-02", ref, time.Local); err == nil {
+			period, _ = notes.PeriodOf(kind, day)
+		} else {
+			return fmt.Errorf("%q is not a %s or a date (YYYY-MM-DD)", ref, cmd.Name())
+		}
+	}
+
+	path, err := store.PeriodPath(period.Key())
+	if err != nil {
+		return err
+	}
+	note, err := store.LoadPeriod(period.Key())
+	created := false
+	if err != nil {
+		if _, statErr := os.Stat(path); statErr == nil {
+			return err
+		}
+		if note, err = newPeriodNote(store, period); err != nil {
+			return err
+		}
+		created = true
+	}
+
+	if !periodNoRollup {
+		rollup := store.Rollup(period)
+		rollup.Goals = completedGoals(period)
+		notes.SetSection(note, "Rollup", rollup.Markdown())
+	}
+	if created || !periodNoRollup {
+		if err := store.SavePeriod(note); err != nil {
+			return err
+		}
+	}
+
+	if periodPrint {
+		displayNote(note)
+		return nil
+	}
+	if created {
+		ui.Success(fmt.Sprintf("Created %s note %s", note.Type, note.Period))
+	}
+	return openEditor(path)
+}
+
+// newPeriodNote renders the period's template into a new note.
+func newPeriodNote(store *notes.Store, period notes.Period) (*notes.Note, error) {
+	if err := store.EnsureBuiltInTemplates(); err != nil {
+		return nil, fmt.Errorf("failed to ensure templates: %w", err)
+	}
+	tmpl, err := store.GetTemplate(string(period.Type))
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := store.NewTemplateContext(period.Start)
+	ctx.Prompt = bufio.NewReader(os.Stdin)
+	for k, v := range period.TemplateVars() {
+		ctx.Vars[k] = v
+	}
+	body, err := notes.RenderTemplate(tmpl.Content, ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply template: %w", err)
+	}
+
+	note := notes.NewPeriodNote(period)
+	note.Body = body
+	return note, nil
+}
+
+// completedGoals returns the titles of the goals, archived or not, that were
+// completed during the period, oldest first. Candidates come from the goal
+// history (storage.GetHistory): completing a goal saves it, so it has an
+// entry in the period. The history records saves rather than completions,
+// so each candidate is kept only if it is now complete with a completion
+// time in the period. Goals removed since, or whose entries have aged out of
+// the history, are not listed.
+func completedGoals(period notes.Period) []string {
+	tasksPath := filepath.Join(getWikiDir(), "tasks")
+	if _, err := os.Stat(tasksPath); err != nil {
+		return nil
+	}
+	st := storage.New(tasksPath)
+	history, err := st.GetHistory(math.MaxInt, "")
+	if err != nil {
+		return nil
+	}
+	touched := make(map[string]bool)
+	for _, h := range history {
+		ts, err := time.Parse(time.RFC3339, h.Timestamp)
+		if err == nil && period.Contains(ts.In(time.Local)) {
+			touched[h.TaskID] = true
+		}
+	}
+	if len(touched) == 0 {
+		return nil
+	}
+
+	active, err := st.LoadTasks("")
+	if err != nil {
+		return nil
+	}
+	archived, _ := st.LoadTasks("archived")
+	var done []*task.Task
+	walkTasks(append(active, archived...), func(t *task.Task) {
+		if touched[t.ID] && t.IsComplete() && t.Completed != nil && period.Contains(t.Completed.In(time.Local)) {
+			done = append(done, t)
+		}
+	})
+	sort.SliceStable(done, func(i, j int) bool { return done[i].Completed.Before(*done[j].Completed) })
+
+	titles := make([]string, len(done))
+	for i, t := range done {
+		titles[i] = t.Title
+	}
+	return titles
+}
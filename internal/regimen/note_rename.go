@@ -0,0 +1,104 @@
+package regimen
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gitlab.com/caffeinatedjack/sleepless/pkg/notes"
+	"gitlab.com/caffeinatedjack/sleepless/pkg/ui"
+)
+
+var renameDryRun bool
+
+var noteRenameCmd = &cobra.Command{
+	Use:   "rename <id|date> <new-id|new-date>",
+	Short: "Rename a note and update links to it",
+	Long: `Rename a note and rewrite every link to it across the wiki: daily notes,
+floating notes, recipes and goals. A floating note takes a new 8-character
+hex ID; a daily note moves to another date. All files are written together,
+and restored if any write fails.
+
+Examples:
+    regimen note rename a1b2c3d4 cafef00d
+    regimen note rename 2026-01-20 2026-01-21 --dry-run`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store := notes.NewStore(getWikiDir())
+		plan, err := store.PlanRename(args[0], args[1])
+		if err != nil {
+			return err
+		}
+		return applyPlan(store, plan, fmt.Sprintf("Renamed %s to %s", args[0], args[1]))
+	},
+}
+
+var noteMergeCmd = &cobra.Command{
+	Use:   "merge <id|date> <into-id|into-date>",
+	Short: "Merge a note into another and update links",
+	Long: `Merge the first note into the second. Its body is appended under a
+"## Merged from" heading, its tags are added, links to it across the wiki
+are pointed at the second note, and it is deleted.
+
+Examples:
+    regimen note merge a1b2c3d4 cafef00d
+    regimen note merge a1b2c3d4 2026-01-20 --dry-run`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store := notes.NewStore(getWikiDir())
+		plan, err := store.PlanMerge(args[0], args[1])
+		if err != nil {
+			return err
+		}
+		return applyPlan(store, plan, fmt.Sprintf("Merged %s into %s", args[0], args[1]))
+	},
+}
+
+func init() {
+	noteCmd.AddCommand(noteRenameCmd)
+	noteCmd.AddCommand(noteMergeCmd)
+	noteRenameCmd.Flags().BoolVarP(&renameDryRun, "dry-run", "n", false, "Show the changes without writing them")
+	noteMergeCmd.Flags().BoolVarP(&renameDryRun, "dry-run", "n", false, "Show the changes without writing them")
+}
+
+// applyPlan prints a plan's changes, and writes them unless --dry-run.
+func applyPlan(store *notes.Store, plan *notes.Plan, done string) error {
+	rel := func(p string) string {
+		if r, err := filepath.Rel(store.WikiDir, p); err == nil {
+			return r
+		}
+		return p
+	}
+
+	for _, c := range plan.Changes {
+		switch {
+		case c.To == "":
+			fmt.Println(ui.ErrorStyle.Render("delete " + rel(c.From)))
+			continue
+		case c.From != c.To:
+			fmt.Println(ui.BoldStyle.Render(fmt.Sprintf("rename %s → %s", rel(c.From), rel(c.To))))
+		default:
+			fmt.Println(ui.BoldStyle.Render(rel(c.To)))
+		}
+		lines := c.Lines()
+		if lines == nil {
+			ui.PrintDim(fmt.Sprintf("  rewritten (%d → %d lines)", strings.Count(c.Before, "\n")+1, strings.Count(c.After, "\n")+1))
+			continue
+		}
+		for _, l := range lines {
+			fmt.Printf("  %s %s\n", ui.DimStyle.Render(fmt.Sprintf("%4d", l.Line)), ui.ErrorStyle.Render("- "+l.Old))
+			fmt.Printf("  %s %s\n", ui.DimStyle.Render(fmt.Sprintf("%4d", l.Line)), ui.SuccessStyle.Render("+ "+l.New))
+		}
+	}
+
+	if renameDryRun {
+		ui.Info(fmt.Sprintf("Dry run: %d links in %d files would change", plan.Links(), len(plan.Changes)))
+		return nil
+	}
+	if err := store.Apply(plan); err != nil {
+		return err
+	}
+	ui.Success(fmt.Sprintf("%s (%d links updated)", done, plan.Links()))
+	return nil
+}
@@ -0,0 +1,231 @@
+package regimen
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gitlab.com/caffeinatedjack/sleepless/pkg/cards"
+	"gitlab.com/caffeinatedjack/sleepless/pkg/notes"
+	"gitlab.com/caffeinatedjack/sleepless/pkg/ui"
+)
+
+var (
+	reviewDeck  string
+	reviewNew   int
+	reviewLimit int
+)
+
+var noteReviewCmd = &cobra.Command{
+	Use:   "review",
+	Short: "Review flashcards from your notes",
+	Long: `Review flashcards written in your notes, scheduled by spaced repetition.
+
+Cards are "Q:" lines followed by "A:" lines (the answer runs until a blank
+line), and cloze deletions such as "The {{c1::GOPATH}} predates
+{{c2::modules::build system}}", which make one card per number. Cards are
+scheduled with SM-2; review state is kept in notes/.flashcards.json.
+
+Each card shows its question; press Enter to see the answer, then grade it:
+1 again, 2 hard, 3 good, 4 easy. Cards answered "again" come back later in
+the session.
+
+"regimen note review week|month|quarter|year" opens the period's review
+note instead; see "regimen note review week --help".
+
+Examples:
+    regimen note review
+    regimen note review --deck golang --new 10
+    regimen note review stats
+    regimen note review week`,
+	Args: cobra.NoArgs,
+	RunE: runNoteReview,
+}
+
+var noteReviewStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show flashcard statistics",
+	Args:  cobra.NoArgs,
+	RunE:  runNoteReviewStats,
+}
+
+func init() {
+	noteCmd.AddCommand(noteReviewCmd)
+	noteReviewCmd.AddCommand(noteReviewStatsCmd)
+
+	noteReviewCmd.Flags().StringVar(&reviewDeck, "deck", "", "Only cards from notes with these tags (comma-separated)")
+	noteReviewStatsCmd.Flags().StringVar(&reviewDeck, "deck", "", "Only cards from notes with these tags (comma-separated)")
+	noteReviewCmd.Flags().IntVar(&reviewNew, "new", 20, "Maximum new cards to introduce")
+	noteReviewCmd.Flags().IntVar(&reviewLimit, "limit", 0, "Maximum cards to review (0 for all due)")
+	noteReviewStatsCmd.Flags().BoolVar(&outputJSON, "json", false, "Output in JSON format")
+}
+
+// loadDeck returns the cards in the selected deck and the review state.
+func loadDeck() (*notes.Store, []cards.Card, *cards.State, error) {
+	store := notes.NewStore(getWikiDir())
+	all, err := store.LoadAll()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var deck []*notes.Note
+	tags := strings.Split(reviewDeck, ",")
+	for _, n := range all {
+		if reviewDeck == "" {
+			deck = append(deck, n)
+			continue
+		}
+		for _, tag := range tags {
+			if n.HasTag(strings.TrimSpace(tag)) {
+				deck = append(deck, n)
+				break
+			}
+		}
+	}
+
+	state, err := cards.LoadState(filepath.Join(store.NotesDir, cards.StateFile))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return store, cards.FromNotes(deck), state, nil
+}
+
+func runNoteReview(cmd *cobra.Command, args []string) error {
+	_, deck, state, err := loadDeck()
+	if err != nil {
+		return err
+	}
+	if len(deck) == 0 {
+		ui.PrintDim("No flashcards found. Add \"Q:\"/\"A:\" lines or {{c1::cloze}} deletions to a note.")
+		return nil
+	}
+
+	queue := state.Due(deck, time.Now().Format("2006-01-02"), reviewNew)
+	if reviewLimit > 0 && len(queue) > reviewLimit {
+		queue = queue[:reviewLimit]
+	}
+	if len(queue) == 0 {
+		ui.Success("Nothing due. Come back tomorrow.")
+		return nil
+	}
+
+	reviewed, err := reviewSession(os.Stdin, os.Stdout, queue, state)
+	fmt.Println()
+	if reviewed > 0 {
+		ui.Success(fmt.Sprintf("Reviewed %d cards", reviewed))
+	}
+	return err
+}
+
+// reviewSession asks each card in turn, saving the state after every
+// answer. Cards answered Again are asked again at the end; only a card's
+// first answer changes its schedule. It returns the number of answers given.
+func reviewSession(in io.Reader, out io.Writer, queue []cards.Card, state *cards.State) (int, error) {
+	reader := bufio.NewReader(in)
+	answered := 0
+	total := len(queue)
+	asked := make(map[string]bool)
+	for len(queue) > 0 {
+		card := queue[0]
+		queue = queue[1:]
+
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, ui.DimStyle.Render(fmt.Sprintf("[%d/%d] %s:%d", answered+1, total, card.Note, card.Line)))
+		fmt.Fprintln(out, ui.BoldStyle.Render(card.Front))
+		fmt.Fprint(out, ui.DimStyle.Render("Enter to show the answer, q to quit: "))
+		line, err := reader.ReadString('\n')
+		if strings.TrimSpace(line) == "q" || (err != nil && line == "") {
+			return answered, nil
+		}
+
+		fmt.Fprintln(out, card.Back)
+		var grade cards.Grade
+		for {
+			fmt.Fprint(out, "Grade [1 again, 2 hard, 3 good, 4 easy]: ")
+			line, err := reader.ReadString('\n')
+			input := strings.ToLower(strings.TrimSpace(line))
+			if input == "q" || (err != nil && input == "") {
+				return answered, nil
+			}
+			if grade, err = cards.ParseGrade(input); err == nil {
+				break
+			}
+			fmt.Fprintln(out, ui.WarningStyle.Render(err.Error()))
+		}
+
+		var cs *cards.CardState
+		if asked[card.ID] {
+			cs = state.Repeat(card.ID, grade, time.Now())
+		} else {
+			cs = state.Answer(card.ID, grade, time.Now())
+		}
+		asked[card.ID] = true
+		answered++
+		if err := state.Save(); err != nil {
+			return answered, fmt.Errorf("failed to save review state: %w", err)
+		}
+		if grade == cards.Again {
+			queue = append(queue, card)
+			total++
+		} else {
+			fmt.Fprintln(out, ui.DimStyle.Render("Next review in "+days(cs.Interval)))
+		}
+	}
+	return answered, nil
+}
+
+func runNoteReviewStats(cmd *cobra.Command, args []string) error {
+	_, deck, state, err := loadDeck()
+	if err != nil {
+		return err
+	}
+	st := state.Stats(deck, time.Now())
+
+	if outputJSON {
+		data, _ := json.MarshalIndent(st, "", "  ")
+		fmt.Println(string(data))
+		return nil
+	}
+
+	fmt.Println()
+	fmt.Println(ui.BoldStyle.Render("Flashcards"))
+	fmt.Printf("  Cards        %d (%d new, %d mature)\n", st.Cards, st.New, st.Mature)
+	fmt.Printf("  Due today    %d\n", st.DueToday)
+	fmt.Printf("  Due in 7d    %d\n", st.DueWeek)
+	fmt.Printf("  Reviews 30d  %d", st.Reviews)
+	if st.Reviews > 0 {
+		fmt.Printf(" (%.0f%% retained)", st.Retention*100)
+	}
+	fmt.Println()
+	fmt.Printf("  Streak       %s\n", days(st.Streak))
+
+	peak := 0
+	for _, n := range st.Forecast {
+		peak = max(peak, n)
+	}
+	if peak > 0 {
+		fmt.Println()
+		fmt.Println(ui.BoldStyle.Render("Forecast"))
+		now := time.Now()
+		for d, n := range st.Forecast {
+			bar := strings.Repeat("█", (n*30+peak-1)/peak)
+			fmt.Printf("  %s %s %d\n", ui.DimStyle.Render(now.AddDate(0, 0, d).Format("Mon 02")), bar, n)
+		}
+	}
+	fmt.Println()
+	return nil
+}
+
+// days formats a number of days, e.g. "1 day" or "6 days".
+func days(n int) string {
+	if n == 1 {
+		return "1 day"
+	}
+	return fmt.Sprintf("%d days", n)
+}
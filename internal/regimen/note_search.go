@@ -111,6 +111,8 @@ func runNoteSearch(cmd *cobra.Command, args []string) error {
 		var id string
 		if result.Note.Type == notes.NoteTypeDaily {
 			id = result.Note.Date
+		} else if notes.IsPeriodType(result.Note.Type) {
+			id = result.Note.Period
 		} else {
 			id = result.Note.ID[:6] // Show short ID
 		}
@@ -174,6 +176,8 @@ func runNoteTags(cmd *cobra.Command, args []string) error {
 		var id string
 		if note.Type == notes.NoteTypeDaily {
 			id = note.Date
+		} else if notes.IsPeriodType(note.Type) {
+			id = note.Period
 		} else {
 			id = note.ID[:6]
 		}
@@ -187,6 +187,7 @@ func runNoteStats(cmd *cobra.Command, args []string) error {
 	totalNotes := 0
 	dailyCount := 0
 	floatingCount := 0
+	periodCount := 0
 	tagCounts := make(map[string]int)
 
 	for _, entry := range entries {
@@ -203,6 +204,8 @@ func runNoteStats(cmd *cobra.Command, args []string) error {
 		totalNotes++
 		if note.Type == notes.NoteTypeDaily {
 			dailyCount++
+		} else if notes.IsPeriodType(note.Type) {
+			periodCount++
 		} else {
 			floatingCount++
 		}
@@ -217,6 +220,9 @@ func runNoteStats(cmd *cobra.Command, args []string) error {
 	fmt.Printf("Total notes: %d\n", totalNotes)
 	fmt.Printf("Daily notes: %d\n", dailyCount)
 	fmt.Printf("Floating notes: %d\n", floatingCount)
+	if periodCount > 0 {
+		fmt.Printf("Review notes: %d\n", periodCount)
+	}
 	fmt.Printf("Unique tags: %d\n", len(tagCounts))
 
 	if len(tagCounts) > 0 {
@@ -16,10 +16,22 @@ var templateCmd = &cobra.Command{
 	Long: `Manage note templates for structured entries.
 
 Templates support placeholders:
-  {{DATE}}           - Current date (YYYY-MM-DD)
-  {{TIME}}           - Current time (HH:MM)
-  {{DATETIME}}       - Current datetime
-  {{PROMPT:question}} - Prompt user for input
+  {{date}} {{time}}            - Note date (YYYY-MM-DD) and time (HH:MM)
+  {{weekday}} {{iso_week}}     - e.g. Monday, 2026-W02
+  {{yesterday_link}}           - Link to the previous daily note
+  {{goals_due}}                - Open goals due by the note date
+  {{name}} {{name|default}}    - A --var value; kept as written if unset
+  {{VARS:name,other}}          - Declare variables to prompt for if unset
+  {{PROMPT:question|default}}  - Prompt user for input
+  {{PROMPT:name=question}}     - Prompt, answerable with --var name=...
+  {{CHOICE:question|a|b|c}}    - Prompt for one of several options
+  {{INCLUDE:name}}             - Insert another template
+  {{#if name}}...{{else}}...{{/if}} - Keep a block when a variable is set
+
+{{DATE}}, {{TIME}} and {{DATETIME}} are still supported. A prompt's answer
+is also a variable: "{{PROMPT:Meeting title}}" sets {{meeting_title}}, and
+"regimen note add --template meeting --var meeting_title=Sync" answers it
+without asking.
 
 Built-in templates: meeting, reflection, idea, report`,
 	PersistentPreRunE: noteCmd.PersistentPreRunE, // Inherit encryption check
@@ -37,10 +49,9 @@ var templateCreateCmd = &cobra.Command{
 	Short: "Create a new template",
 	Long: `Creates a new note template by opening an editor.
 
-The template can include placeholders that will be replaced when used:
-  {{DATE}}           - Current date
-  {{TIME}}           - Current time
-  {{PROMPT:question}} - Ask user for input`,
+The template can include placeholders that will be replaced when used,
+such as {{date}}, {{weekday}}, {{PROMPT:question|default}} and
+{{INCLUDE:name}}. See "regimen note template --help" for the full list.`,
 	Args: cobra.ExactArgs(1),
 	RunE: runTemplateCreate,
 }
@@ -111,10 +122,9 @@ func runTemplateCreate(cmd *cobra.Command, args []string) error {
 	initialContent := fmt.Sprintf(`# Template: %s
 
 Write your template here. You can use placeholders:
-  {{DATE}}           - Current date
-  {{TIME}}           - Current time
-  {{DATETIME}}       - Current datetime
-  {{PROMPT:question}} - Prompt user for input
+  {{date}} {{time}} {{weekday}} {{iso_week}} {{yesterday_link}} {{goals_due}}
+  {{PROMPT:question|default}} {{CHOICE:question|a|b}} {{INCLUDE:name}}
+  {{#if name}}...{{else}}...{{/if}}
 
 ---
 
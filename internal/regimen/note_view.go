@@ -38,10 +38,12 @@ var noteShowCmd = &cobra.Command{
 
 For floating notes: provide the ID or unique prefix.
 For daily notes: use --date flag.
+For review notes: provide the period, e.g. 2026-W03 or 2026-01.
 
 Examples:
     regimen note show abc123
-    regimen note show --date 2026-01-20`,
+    regimen note show --date 2026-01-20
+    regimen note show 2026-W03`,
 	RunE: runNoteShow,
 }
 
@@ -129,8 +131,7 @@ func runNoteShow(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("provide note ID or use --date flag")
 	}
 
-	idOrPrefix := args[0]
-	note, err := store.LoadFloating(idOrPrefix)
+	note, err := store.Load(args[0])
 	if err != nil {
 		return err
 	}
@@ -205,6 +206,8 @@ func runNoteMonth(cmd *cobra.Command, args []string) error {
 func displayNote(note *notes.Note) {
 	if note.Type == notes.NoteTypeDaily {
 		fmt.Printf("# Daily Note: %s\n\n", note.Date)
+	} else if notes.IsPeriodType(note.Type) {
+		fmt.Printf("# %s Note: %s\n\n", strings.ToUpper(string(note.Type[:1]))+string(note.Type[1:]), note.Period)
 	} else {
 		fmt.Printf("# Floating Note: %s\n\n", note.ID)
 	}
@@ -212,8 +215,12 @@ func displayNote(note *notes.Note) {
 	if len(note.Tags) > 0 {
 		fmt.Printf("Tags: %s\n\n", strings.Join(note.Tags, ", "))
 	}
+	if summary := checkInSummary(note); summary != "" {
+		fmt.Printf("Check-in: %s\n\n", summary)
+	}
 
 	fmt.Println(note.Body)
+	printLinkedFrom(note)
 }
 
 func displayFileSummary(path, filename string) {
@@ -31,6 +31,7 @@ Commands:
     remind    Show due date reminders
     archive   Move completed goals to archive
     history   Show goal change history
+    ics       Export and import goals as iCalendar
 
 Examples:
     regimen goals add "My goal" --topic work
@@ -24,9 +24,10 @@ var (
 
 // JSON output types
 type WhenZoneOutput struct {
-	Label string `json:"label"`
-	Zone  string `json:"zone"`
-	Time  string `json:"time"`
+	Label  string       `json:"label"`
+	Zone   string       `json:"zone"`
+	Time   string       `json:"time"`
+	Status *when.Status `json:"status,omitempty"`
 }
 
 type WhenOutput struct {
@@ -57,6 +58,12 @@ Commands:
     when diff <zone> <zone>    Show time difference between zones
     when until <time>          Show duration until a time
     when overlap <zone> <zone> Find overlapping work hours
+    when plan <zone...>        Rank meeting slots over the coming days
+    when hours [alias] [range] Show or set working hours
+    when people                List, add or remove people
+    when team                  List, add or remove teams
+    when holidays              List or import holiday calendars
+    when ics <file.ics>        Show calendar events in configured zones
 
 Time formats:
     now, 3pm, 3:30pm, 17:00, 05:30
@@ -66,6 +73,7 @@ Zone formats:
     - Abbreviations: UTC, PST, JST
     - Cities: London, Tokyo, NYC
     - Your aliases: work, home
+    - People: alice, bob
 
 Examples:
     regimen when
@@ -262,25 +270,28 @@ func outputDirectConversion(refTime time.Time, refLabel string, to *when.Resolve
 	fmt.Println()
 }
 
-func outputWorldClock(refTime time.Time, refLabel string, cfg *when.Config) {
-	// Build list of zones to display
+// configuredZones resolves the configured zones, skipping invalid ones, or
+// returns UTC if none are configured.
+func configuredZones(cfg *when.Config) []*when.ResolvedZone {
 	var zones []*when.ResolvedZone
-
-	// Always include UTC if nothing configured
 	if len(cfg.Configured) == 0 {
 		if resolved, err := when.ResolveZone("UTC", cfg); err == nil {
 			zones = append(zones, resolved)
 		}
-	} else {
-		for _, z := range cfg.Configured {
-			resolved, err := when.ResolveZone(z, cfg)
-			if err != nil {
-				// Skip invalid zones with a warning
-				continue
-			}
-			zones = append(zones, resolved)
+		return zones
+	}
+	for _, z := range cfg.Configured {
+		resolved, err := when.ResolveZone(z, cfg)
+		if err != nil {
+			continue
 		}
+		zones = append(zones, resolved)
 	}
+	return zones
+}
+
+func outputWorldClock(refTime time.Time, refLabel string, cfg *when.Config) {
+	zones := configuredZones(cfg)
 
 	if whenJSON {
 		output := WhenOutput{
@@ -294,9 +305,10 @@ func outputWorldClock(refTime time.Time, refLabel string, cfg *when.Config) {
 		for i, z := range zones {
 			t := refTime.In(z.Location)
 			output.Zones[i] = WhenZoneOutput{
-				Label: z.Label,
-				Zone:  z.Zone,
-				Time:  t.Format(time.RFC3339),
+				Label:  z.Label,
+				Zone:   z.Zone,
+				Time:   t.Format(time.RFC3339),
+				Status: zoneStatus(cfg, z, refTime),
 			}
 		}
 		data, _ := json.MarshalIndent(output, "", "  ")
@@ -322,9 +334,16 @@ func outputWorldClock(refTime time.Time, refLabel string, cfg *when.Config) {
 	fmt.Printf("  %s  %s\n", refStyle.Render(formatTime(refTime, refTime)), refLabel)
 	fmt.Println()
 
+	width := 0
+	for _, z := range zones {
+		if len(z.Label) > width {
+			width = len(z.Label)
+		}
+	}
 	for _, z := range zones {
 		t := refTime.In(z.Location)
-		fmt.Printf("  %s  %s\n", formatTime(t, refTime), labelStyle.Render(z.Label))
+		label := labelStyle.Render(z.Label + strings.Repeat(" ", width-len(z.Label)))
+		fmt.Printf("  %s  %s  %s\n", formatTime(t, refTime), label, renderStatus(zoneStatus(cfg, z, refTime)))
 	}
 	fmt.Println()
 }
@@ -455,11 +474,16 @@ var whenDiffCmd = &cobra.Command{
 		diffSeconds := offsetA - offsetB
 		diffHours := float64(diffSeconds) / 3600
 
+		statusA := zoneStatus(cfg, zoneA, now)
+		statusB := zoneStatus(cfg, zoneB, now)
+
 		if whenJSON {
 			output := map[string]interface{}{
 				"zone_a":           zoneA.Zone,
 				"zone_b":           zoneB.Zone,
 				"difference_hours": diffHours,
+				"status_a":         statusA,
+				"status_b":         statusB,
 			}
 			data, _ := json.MarshalIndent(output, "", "  ")
 			fmt.Println(string(data))
@@ -478,6 +502,7 @@ var whenDiffCmd = &cobra.Command{
 			relation = "behind"
 		} else {
 			fmt.Printf("%s and %s are in the same timezone offset\n", zoneA.Label, zoneB.Label)
+			printDiffStatus(zoneA, zoneB, statusA, statusB)
 			return
 		}
 
@@ -492,9 +517,15 @@ var whenDiffCmd = &cobra.Command{
 		} else {
 			fmt.Printf("%s is %.1f %s %s %s\n", zoneA.Label, absDiff, hourStr, relation, zoneB.Label)
 		}
+		printDiffStatus(zoneA, zoneB, statusA, statusB)
 	},
 }
 
+func printDiffStatus(zoneA, zoneB *when.ResolvedZone, statusA, statusB *when.Status) {
+	fmt.Printf("  %s: %s\n", zoneA.Label, renderStatus(statusA))
+	fmt.Printf("  %s: %s\n", zoneB.Label, renderStatus(statusB))
+}
+
 // whenUntilCmd shows duration until a time
 var whenUntilCmd = &cobra.Command{
 	Use:   "until <time-expr>",
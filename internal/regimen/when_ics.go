@@ -0,0 +1,141 @@
+package regimen
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"gitlab.com/caffeinatedjack/sleepless/pkg/ics"
+	"gitlab.com/caffeinatedjack/sleepless/pkg/ui"
+	"gitlab.com/caffeinatedjack/sleepless/pkg/when"
+)
+
+var whenICSDays int
+
+// WhenEventOutput is one event in 'when ics --json'.
+type WhenEventOutput struct {
+	Summary  string           `json:"summary"`
+	Location string           `json:"location,omitempty"`
+	Start    string           `json:"start"`
+	End      string           `json:"end"`
+	Recurs   bool             `json:"recurs,omitempty"`
+	Zones    []WhenZoneOutput `json:"zones"`
+}
+
+func init() {
+	whenCmd.AddCommand(whenICSCmd)
+	whenICSCmd.Flags().IntVar(&whenICSDays, "days", 7, "Number of days of events to show, starting today")
+}
+
+var whenICSCmd = &cobra.Command{
+	Use:   "ics <file.ics> [zone...]",
+	Short: "Show calendar events in configured zones",
+	Long: `Show the timed events in an iCalendar (.ics) file with their times converted
+into the configured zones, or the zones given, along with each zone's
+availability. All-day events are skipped. Recurring events are shown at
+their first occurrence only and marked with ↻.
+
+Examples:
+    regimen when ics invite.ics
+    regimen when ics team.ics --days 14
+    regimen when ics invite.ics alice Tokyo`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cal, err := ics.Open(args[0])
+		if err != nil {
+			ui.Error(err.Error())
+			os.Exit(1)
+		}
+		warnCalendar(cal)
+		cfg := loadWhenConfig()
+
+		zones := configuredZones(cfg)
+		if len(args) > 1 {
+			zones = nil
+			for _, name := range cfg.ExpandTeams(args[1:]) {
+				resolved, err := when.ResolveZone(name, cfg)
+				if err != nil {
+					ui.Error(err.Error())
+					os.Exit(1)
+				}
+				zones = append(zones, resolved)
+			}
+		}
+
+		now := time.Now()
+		from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+		until := from.AddDate(0, 0, whenICSDays)
+		var events []ics.Event
+		for _, ev := range cal.Events {
+			if ev.AllDay || ev.Start.Before(from) || !ev.Start.Before(until) {
+				continue
+			}
+			events = append(events, ev)
+		}
+		sort.SliceStable(events, func(i, j int) bool { return events[i].Start.Before(events[j].Start) })
+
+		if whenJSON {
+			output := make([]WhenEventOutput, len(events))
+			for i, ev := range events {
+				out := WhenEventOutput{
+					Summary:  ev.Summary,
+					Location: ev.Location,
+					Start:    ev.Start.Format(time.RFC3339),
+					End:      ev.End.Format(time.RFC3339),
+					Recurs:   ev.RRule != "",
+				}
+				for _, z := range zones {
+					out.Zones = append(out.Zones, WhenZoneOutput{
+						Label:  z.Label,
+						Zone:   z.Zone,
+						Time:   ev.Start.In(z.Location).Format(time.RFC3339),
+						Status: zoneStatus(cfg, z, ev.Start),
+					})
+				}
+				output[i] = out
+			}
+			data, _ := json.MarshalIndent(output, "", "  ")
+			fmt.Println(string(data))
+			return
+		}
+
+		if len(events) == 0 {
+			ui.Info(fmt.Sprintf("No timed events in the next %d days in %s", whenICSDays, args[0]))
+			return
+		}
+
+		endLayout := "15:04"
+		if when12h {
+			endLayout = "3:04 PM"
+		}
+		width := 0
+		for _, z := range zones {
+			width = max(width, len(z.Label))
+		}
+		for _, ev := range events {
+			local := ev.Start.Local()
+			title := ev.Summary
+			if ev.RRule != "" {
+				title += " ↻"
+			}
+			fmt.Println()
+			fmt.Printf("  %s  %s\n", ui.BoldStyle.Render(local.Format("Mon Jan 02 15:04")), title)
+			if ev.Location != "" {
+				ui.PrintDim("  " + ev.Location)
+			}
+			for _, z := range zones {
+				start := ev.Start.In(z.Location)
+				span := formatTime(start, local)
+				if ev.End.After(ev.Start) {
+					span += " - " + ev.End.In(z.Location).Format(endLayout)
+				}
+				fmt.Printf("    %-*s  %-24s %s\n", width, z.Label, span, renderStatus(zoneStatus(cfg, z, ev.Start)))
+			}
+		}
+		fmt.Println()
+	},
+}
@@ -0,0 +1,337 @@
+package regimen
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"gitlab.com/caffeinatedjack/sleepless/pkg/ics"
+	"gitlab.com/caffeinatedjack/sleepless/pkg/ui"
+	"gitlab.com/caffeinatedjack/sleepless/pkg/when"
+)
+
+var (
+	personHours    string
+	personWeekend  []string
+	personHolidays []string
+)
+
+func init() {
+	whenCmd.AddCommand(whenPeopleCmd)
+	whenCmd.AddCommand(whenTeamCmd)
+	whenCmd.AddCommand(whenHolidaysCmd)
+
+	whenPeopleCmd.AddCommand(whenPeopleAddCmd)
+	whenPeopleCmd.AddCommand(whenPeopleRemoveCmd)
+	whenTeamCmd.AddCommand(whenTeamAddCmd)
+	whenTeamCmd.AddCommand(whenTeamRemoveCmd)
+	whenHolidaysCmd.AddCommand(whenHolidaysImportCmd)
+	whenHolidaysCmd.AddCommand(whenHolidaysRemoveCmd)
+
+	whenPeopleAddCmd.Flags().StringVar(&personHours, "hours", "", "Working hours, e.g. 08:00-16:00 (default: the zone's hours)")
+	whenPeopleAddCmd.Flags().StringSliceVar(&personWeekend, "weekend", []string{"sat", "sun"}, "Days off")
+	whenPeopleAddCmd.Flags().StringSliceVar(&personHolidays, "holidays", nil, "Holiday calendars that apply")
+}
+
+// loadWhenConfig loads the when config or exits.
+func loadWhenConfig() *when.Config {
+	cfg, err := when.LoadConfig()
+	if err != nil {
+		ui.Error(fmt.Sprintf("Failed to load config: %v", err))
+		os.Exit(1)
+	}
+	return cfg
+}
+
+func saveWhenConfig(cfg *when.Config) {
+	if err := cfg.Save(); err != nil {
+		ui.Error(fmt.Sprintf("Failed to save config: %v", err))
+		os.Exit(1)
+	}
+}
+
+// zoneStatus returns the availability of a zone at t, or nil if its hours
+// are invalid.
+func zoneStatus(cfg *when.Config, z *when.ResolvedZone, t time.Time) *when.Status {
+	s, err := cfg.StatusAt(z, t)
+	if err != nil {
+		return nil
+	}
+	return &s
+}
+
+// renderStatus colours a status for the world clock.
+func renderStatus(s *when.Status) string {
+	if s == nil {
+		return ""
+	}
+	switch s.State {
+	case when.StatusAvailable:
+		return ui.SuccessStyle.Render(s.String())
+	case when.StatusHoliday:
+		return ui.WarningStyle.Render(s.String())
+	default:
+		return ui.DimStyle.Render(s.String())
+	}
+}
+
+var whenPeopleCmd = &cobra.Command{
+	Use:   "people",
+	Short: "List people and their zones",
+	Long: `List, add and remove people. A person's name works anywhere a zone does,
+and shows their availability in the world clock.
+
+Examples:
+    regimen when people add alice Europe/Paris
+    regimen when people add bob NYC --hours 08:00-16:00 --holidays us
+    regimen when people remove bob`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := loadWhenConfig()
+		names := make([]string, 0, len(cfg.People))
+		for name := range cfg.People {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		if whenJSON {
+			data, _ := json.MarshalIndent(cfg.People, "", "  ")
+			fmt.Println(string(data))
+			return
+		}
+		if len(names) == 0 {
+			ui.Info("No people configured. Add one with 'regimen when people add <name> <zone>'")
+			return
+		}
+		now := time.Now()
+		for _, name := range names {
+			resolved, err := when.ResolveZone(name, cfg)
+			if err != nil {
+				fmt.Printf("  %-16s %s\n", name, ui.WarningStyle.Render(err.Error()))
+				continue
+			}
+			fmt.Printf("  %-16s %-22s %-40s %s\n", name, resolved.Zone,
+				cfg.HoursForZone(resolved), renderStatus(zoneStatus(cfg, resolved, now)))
+		}
+	},
+}
+
+var whenPeopleAddCmd = &cobra.Command{
+	Use:   "add <name> <zone>",
+	Short: "Add or update a person",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := loadWhenConfig()
+		resolved, err := when.ResolveZone(args[1], cfg)
+		if err != nil {
+			ui.Error(err.Error())
+			os.Exit(1)
+		}
+
+		person := when.Person{Zone: resolved.Zone}
+		if personHours != "" || len(personHolidays) > 0 {
+			hoursRange := personHours
+			if hoursRange == "" {
+				h := cfg.HoursForZone(resolved)
+				hoursRange = h.Start + "-" + h.End
+			}
+			hours, err := parseHoursFlags(cfg, hoursRange, personWeekend, personHolidays)
+			if err != nil {
+				ui.Error(err.Error())
+				os.Exit(1)
+			}
+			person.Hours = &hours
+		}
+
+		cfg.AddPerson(args[0], person)
+		saveWhenConfig(cfg)
+		ui.Success(fmt.Sprintf("Added '%s' in %s", args[0], resolved.Zone))
+	},
+}
+
+var whenPeopleRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Remove a person",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := loadWhenConfig()
+		if !cfg.RemovePerson(args[0]) {
+			ui.Error(fmt.Sprintf("Person '%s' not found", args[0]))
+			os.Exit(1)
+		}
+		saveWhenConfig(cfg)
+		ui.Success(fmt.Sprintf("Removed '%s'", args[0]))
+	},
+}
+
+var whenTeamCmd = &cobra.Command{
+	Use:   "team",
+	Short: "List teams",
+	Long: `List, add and remove teams. A team name can be given to 'when plan' in
+place of its members.
+
+Examples:
+    regimen when team add platform alice bob Tokyo
+    regimen when plan platform --duration 45m`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := loadWhenConfig()
+		if whenJSON {
+			data, _ := json.MarshalIndent(cfg.Teams, "", "  ")
+			fmt.Println(string(data))
+			return
+		}
+		if len(cfg.Teams) == 0 {
+			ui.Info("No teams configured. Add one with 'regimen when team add <name> <members...>'")
+			return
+		}
+		names := make([]string, 0, len(cfg.Teams))
+		for name := range cfg.Teams {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Printf("  %-16s %s\n", name, strings.Join(cfg.Teams[name], ", "))
+		}
+	},
+}
+
+var whenTeamAddCmd = &cobra.Command{
+	Use:   "add <team> <member...>",
+	Short: "Create or replace a team",
+	Args:  cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := loadWhenConfig()
+		if err := cfg.SetTeam(args[0], args[1:]); err != nil {
+			ui.Error(err.Error())
+			os.Exit(1)
+		}
+		saveWhenConfig(cfg)
+		ui.Success(fmt.Sprintf("Team '%s': %s", args[0], strings.Join(args[1:], ", ")))
+	},
+}
+
+var whenTeamRemoveCmd = &cobra.Command{
+	Use:   "remove <team>",
+	Short: "Remove a team",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := loadWhenConfig()
+		if _, ok := cfg.Teams[args[0]]; !ok {
+			ui.Error(fmt.Sprintf("Team '%s' not found", args[0]))
+			os.Exit(1)
+		}
+		delete(cfg.Teams, args[0])
+		saveWhenConfig(cfg)
+		ui.Success(fmt.Sprintf("Removed team '%s'", args[0]))
+	},
+}
+
+var whenHolidaysCmd = &cobra.Command{
+	Use:   "holidays [calendar]",
+	Short: "List holiday calendars",
+	Long: `List, import and remove holiday calendars. Import all-day events from an
+iCalendar (.ics) file, then attach the calendar to hours or people with
+--holidays. The dates are copied into the config, so the file is not needed
+afterwards.
+
+Examples:
+    regimen when holidays import uk ~/Downloads/england-and-wales.ics
+    regimen when hours London 09:00-17:00 --holidays uk
+    regimen when holidays uk`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := loadWhenConfig()
+		if len(args) == 1 {
+			holidays, ok := cfg.Calendars[args[0]]
+			if !ok {
+				ui.Error(fmt.Sprintf("Holiday calendar '%s' not found", args[0]))
+				os.Exit(1)
+			}
+			if whenJSON {
+				data, _ := json.MarshalIndent(holidays, "", "  ")
+				fmt.Println(string(data))
+				return
+			}
+			today := time.Now().Format("2006-01-02")
+			for _, h := range holidays {
+				line := fmt.Sprintf("  %s  %s", h.Date, h.Name)
+				if h.Date < today {
+					line = ui.DimStyle.Render(line)
+				}
+				fmt.Println(line)
+			}
+			return
+		}
+
+		if whenJSON {
+			data, _ := json.MarshalIndent(cfg.Calendars, "", "  ")
+			fmt.Println(string(data))
+			return
+		}
+		if len(cfg.Calendars) == 0 {
+			ui.Info("No holiday calendars. Import one with 'regimen when holidays import <name> <file.ics>'")
+			return
+		}
+		names := make([]string, 0, len(cfg.Calendars))
+		for name := range cfg.Calendars {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			holidays := cfg.Calendars[name]
+			span := ""
+			if len(holidays) > 0 {
+				span = fmt.Sprintf("%s to %s", holidays[0].Date, holidays[len(holidays)-1].Date)
+			}
+			fmt.Printf("  %-12s %3d days  %s\n", name, len(holidays), ui.DimStyle.Render(span))
+		}
+	},
+}
+
+var whenHolidaysImportCmd = &cobra.Command{
+	Use:   "import <calendar> <file.ics>",
+	Short: "Import holidays from an iCalendar file",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		cal, err := ics.Open(args[1])
+		if err != nil {
+			ui.Error(err.Error())
+			os.Exit(1)
+		}
+		warnCalendar(cal)
+		holidays, skipped := when.HolidaysFromCalendar(cal)
+		if len(holidays) == 0 {
+			ui.Error(fmt.Sprintf("No all-day events in %s", args[1]))
+			os.Exit(1)
+		}
+
+		cfg := loadWhenConfig()
+		cfg.ImportHolidays(args[0], holidays)
+		saveWhenConfig(cfg)
+		ui.Success(fmt.Sprintf("Imported %d holiday dates into '%s'", len(holidays), args[0]))
+		if skipped > 0 {
+			ui.Warning(fmt.Sprintf("Skipped %d timed or recurring events", skipped))
+		}
+	},
+}
+
+var whenHolidaysRemoveCmd = &cobra.Command{
+	Use:   "remove <calendar>",
+	Short: "Remove a holiday calendar",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := loadWhenConfig()
+		if !cfg.RemoveCalendar(args[0]) {
+			ui.Error(fmt.Sprintf("Holiday calendar '%s' not found", args[0]))
+			os.Exit(1)
+		}
+		saveWhenConfig(cfg)
+		ui.Success(fmt.Sprintf("Removed holiday calendar '%s'", args[0]))
+	},
+}
@@ -0,0 +1,333 @@
+package regimen
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+
+	"gitlab.com/caffeinatedjack/sleepless/pkg/ui"
+	"gitlab.com/caffeinatedjack/sleepless/pkg/when"
+)
+
+var (
+	planDuration time.Duration
+	planDays     int
+	planStep     time.Duration
+	planTop      int
+	planFrom     string
+	planIn       string
+
+	hoursWeekend  []string
+	hoursHolidays []string
+)
+
+// WhenPlanOutput is the JSON output of when plan.
+type WhenPlanOutput struct {
+	Reference    string            `json:"reference"`
+	Duration     string            `json:"duration"`
+	Participants []WhenPlanPerson  `json:"participants"`
+	Slots        []when.Slot       `json:"slots"`
+	Days         []WhenPlanDayJSON `json:"days"`
+}
+
+// WhenPlanPerson describes one participant in JSON output.
+type WhenPlanPerson struct {
+	Label string `json:"label"`
+	Zone  string `json:"zone"`
+	Hours string `json:"hours"`
+}
+
+// WhenPlanDayJSON summarises one day of the heat map.
+type WhenPlanDayJSON struct {
+	Date      string `json:"date"`
+	Available []int  `json:"available"`
+}
+
+func init() {
+	whenCmd.AddCommand(whenPlanCmd)
+	whenCmd.AddCommand(whenHoursCmd)
+
+	whenPlanCmd.Flags().DurationVar(&planDuration, "duration", 30*time.Minute, "Meeting length")
+	whenPlanCmd.Flags().IntVar(&planDays, "days", 5, "Number of days to search, starting today")
+	whenPlanCmd.Flags().DurationVar(&planStep, "step", 30*time.Minute, "Spacing of candidate start times")
+	whenPlanCmd.Flags().IntVar(&planTop, "top", 5, "Number of best slots to list")
+	whenPlanCmd.Flags().StringVar(&planFrom, "from", "", "First day to search (YYYY-MM-DD)")
+	whenPlanCmd.Flags().StringVar(&planIn, "in", "", "Zone for the heat map (default local)")
+
+	whenHoursCmd.Flags().StringSliceVar(&hoursWeekend, "weekend", []string{"sat", "sun"}, "Days off")
+	whenHoursCmd.Flags().StringSliceVar(&hoursHolidays, "holidays", nil, "Holiday calendars that apply (see 'when holidays')")
+}
+
+var whenPlanCmd = &cobra.Command{
+	Use:   "plan <zone...>",
+	Short: "Find meeting times across several zones",
+	Long: `Find meeting times that suit people in several zones.
+
+Arguments can be zones, aliases, people or teams. Each uses the working
+hours set with 'when hours' or 'when people add', or 09:00-17:00 Monday to
+Friday, and its holiday calendars. Slots are ranked by how many people are
+inside working hours, then by the total hours spent outside them.
+
+The heat map has one row per day and one column per hour in the reference
+zone:
+    █ everyone available   ▓ most   ▒ half   ░ some   · nobody
+
+Examples:
+    regimen when plan London NYC Tokyo
+    regimen when plan platform --duration 45m --days 10
+    regimen when plan London NYC --from 2025-03-28 --in NYC --json`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := when.LoadConfig()
+		if err != nil {
+			ui.Error(fmt.Sprintf("Failed to load config: %v", err))
+			os.Exit(1)
+		}
+
+		var people []when.Participant
+		var zones []string
+		for _, arg := range cfg.ExpandTeams(args) {
+			resolved, err := when.ResolveZone(arg, cfg)
+			if err != nil {
+				ui.Error(err.Error())
+				os.Exit(1)
+			}
+			people = append(people, cfg.Participant(resolved))
+			zones = append(zones, resolved.Zone)
+		}
+
+		ref, refLabel := time.Local, "Local"
+		if planIn != "" {
+			resolved, err := when.ResolveZone(planIn, cfg)
+			if err != nil {
+				ui.Error(err.Error())
+				os.Exit(1)
+			}
+			ref, refLabel = resolved.Location, resolved.Label
+		}
+
+		from := time.Now()
+		if planFrom != "" {
+			from, err = time.ParseInLocation("2006-01-02", planFrom, ref)
+			if err != nil {
+				ui.Error("--from must be a date (YYYY-MM-DD)")
+				os.Exit(1)
+			}
+		}
+
+		days, err := when.Plan(people, when.PlanOptions{
+			From:      from,
+			Days:      planDays,
+			Duration:  planDuration,
+			Step:      planStep,
+			Reference: ref,
+		})
+		if err != nil {
+			ui.Error(err.Error())
+			os.Exit(1)
+		}
+		ranked := when.Rank(days)
+		if len(ranked) > planTop {
+			ranked = ranked[:planTop]
+		}
+
+		if whenJSON {
+			output := WhenPlanOutput{
+				Reference: ref.String(),
+				Duration:  planDuration.String(),
+				Slots:     ranked,
+			}
+			for i, p := range people {
+				output.Participants = append(output.Participants, WhenPlanPerson{Label: p.Label, Zone: zones[i], Hours: p.Hours.String()})
+			}
+			for _, d := range days {
+				day := WhenPlanDayJSON{Date: d.Date.Format("2006-01-02")}
+				for _, s := range d.Slots {
+					day.Available = append(day.Available, s.Available)
+				}
+				output.Days = append(output.Days, day)
+			}
+			data, _ := json.MarshalIndent(output, "", "  ")
+			fmt.Println(string(data))
+			return
+		}
+
+		printPlanHeatMap(days, len(people), refLabel)
+		printPlanSlots(ranked, len(people))
+	},
+}
+
+// heatCell picks the glyph for the share of people available.
+func heatCell(available, total int) string {
+	switch {
+	case available == total:
+		return lipgloss.NewStyle().Foreground(ui.Green).Render("█")
+	case available*4 >= total*3:
+		return lipgloss.NewStyle().Foreground(ui.Green).Render("▓")
+	case available*2 >= total:
+		return lipgloss.NewStyle().Foreground(ui.Yellow).Render("▒")
+	case available > 0:
+		return lipgloss.NewStyle().Foreground(ui.Yellow).Render("░")
+	default:
+		return lipgloss.NewStyle().Foreground(ui.DimColor).Render("·")
+	}
+}
+
+// printPlanHeatMap shows, for each hour of each day, the best slot starting
+// in that hour. Hours skipped by a DST change are left blank.
+func printPlanHeatMap(days []when.PlanDay, total int, refLabel string) {
+	fmt.Println()
+	header := "             "
+	for h := 0; h < 24; h++ {
+		if h%3 == 0 {
+			header += fmt.Sprintf("%-3d", h)
+		}
+	}
+	fmt.Println(header + ui.DimStyle.Render(refLabel))
+
+	for _, d := range days {
+		best := make(map[int]int)
+		for _, s := range d.Slots {
+			h := s.Start.Hour()
+			if v, ok := best[h]; !ok || s.Available > v {
+				best[h] = s.Available
+			}
+		}
+		row := ""
+		for h := 0; h < 24; h++ {
+			if v, ok := best[h]; ok {
+				row += heatCell(v, total)
+			} else {
+				row += " "
+			}
+		}
+		fmt.Printf("  %s  %s\n", d.Date.Format("Mon Jan 02"), row)
+	}
+	fmt.Println()
+}
+
+func printPlanSlots(slots []when.Slot, total int) {
+	if len(slots) == 0 {
+		ui.Warning("No candidate slots in range")
+		return
+	}
+	fmt.Println(ui.BoldStyle.Render("Best slots"))
+	for i, s := range slots {
+		fmt.Printf("  %d. %s  %d/%d available", i+1, s.Start.Format("Mon Jan 02 15:04"), s.Available, total)
+		if s.Pain > 0 {
+			fmt.Print(ui.DimStyle.Render(fmt.Sprintf(", %.1fh outside hours", s.Pain)))
+		}
+		fmt.Println()
+
+		var parts []string
+		for _, p := range s.People {
+			part := fmt.Sprintf("%s %s", p.Local.Format("Mon 15:04"), p.Label)
+			if !p.Available {
+				part = ui.WarningStyle.Render(part)
+			}
+			parts = append(parts, part)
+		}
+		fmt.Printf("     %s\n", strings.Join(parts, " | "))
+	}
+}
+
+var whenHoursCmd = &cobra.Command{
+	Use:   "hours [alias] [start-end]",
+	Short: "Show or set working hours for a zone",
+	Long: `Show or set the working hours used by 'when plan'. Zones without hours
+use 09:00-17:00, off on Saturday and Sunday. A range ending before it starts
+runs past midnight.
+
+Examples:
+    regimen when hours
+    regimen when hours work 08:30-17:00
+    regimen when hours Asia/Dubai 09:00-18:00 --weekend fri,sat
+    regimen when hours London 09:00-17:30 --holidays uk`,
+	Args: cobra.MaximumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := when.LoadConfig()
+		if err != nil {
+			ui.Error(fmt.Sprintf("Failed to load config: %v", err))
+			os.Exit(1)
+		}
+
+		if len(args) < 2 {
+			labels := args
+			if len(args) == 0 {
+				labels = append(labels, cfg.Configured...)
+				for l := range cfg.Hours {
+					if !containsString(cfg.Configured, l) {
+						labels = append(labels, l)
+					}
+				}
+				sort.Strings(labels[len(cfg.Configured):])
+			}
+			out := make(map[string]when.WorkHours)
+			for _, l := range labels {
+				resolved, err := when.ResolveZone(l, cfg)
+				if err != nil {
+					ui.Error(err.Error())
+					os.Exit(1)
+				}
+				out[l] = cfg.HoursForZone(resolved)
+			}
+			if whenJSON {
+				data, _ := json.MarshalIndent(out, "", "  ")
+				fmt.Println(string(data))
+				return
+			}
+			fmt.Printf("  %-20s %s\n", "default", ui.DimStyle.Render(when.DefaultWorkHours.String()))
+			for _, l := range labels {
+				fmt.Printf("  %-20s %s\n", l, out[l])
+			}
+			return
+		}
+
+		if _, err := when.ResolveZone(args[0], cfg); err != nil {
+			ui.Error(err.Error())
+			os.Exit(1)
+		}
+		hours, err := parseHoursFlags(cfg, args[1], hoursWeekend, hoursHolidays)
+		if err != nil {
+			ui.Error(err.Error())
+			os.Exit(1)
+		}
+		cfg.SetHours(args[0], hours)
+		if err := cfg.Save(); err != nil {
+			ui.Error(fmt.Sprintf("Failed to save config: %v", err))
+			os.Exit(1)
+		}
+		ui.Success(fmt.Sprintf("Working hours for '%s': %s", args[0], hours))
+	},
+}
+
+func containsString(items []string, s string) bool {
+	for _, item := range items {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// parseHoursFlags builds working hours, checking the holiday calendars
+// exist.
+func parseHoursFlags(cfg *when.Config, hoursRange string, weekend, holidays []string) (when.WorkHours, error) {
+	hours, err := when.ParseWorkHours(hoursRange, weekend)
+	if err != nil {
+		return hours, err
+	}
+	for _, name := range holidays {
+		if _, ok := cfg.Calendars[name]; !ok {
+			return hours, fmt.Errorf("unknown holiday calendar '%s' (import it with 'when holidays import')", name)
+		}
+	}
+	hours.Holidays = holidays
+	return hours, nil
+}
@@ -0,0 +1,168 @@
+// Package cards extracts flashcards from notes and schedules their review
+// with the SM-2 spaced-repetition algorithm.
+package cards
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gitlab.com/caffeinatedjack/sleepless/pkg/notes"
+)
+
+// Kind is how a card was written in its note.
+type Kind string
+
+const (
+	// KindQA is a "Q: ... / A: ..." pair.
+	KindQA Kind = "qa"
+	// KindCloze is one deletion from a line with {{c1::...}} markers.
+	KindCloze Kind = "cloze"
+)
+
+// Card is a question and answer extracted from a note.
+type Card struct {
+	// ID is derived from the card's text, so it survives edits elsewhere in
+	// the note and moving the card between notes.
+	ID    string   `json:"id"`
+	Kind  Kind     `json:"kind"`
+	Front string   `json:"front"`
+	Back  string   `json:"back"`
+	Note  string   `json:"note"`
+	Line  int      `json:"line"`
+	Tags  []string `json:"tags,omitempty"`
+}
+
+var (
+	// qaRe matches "Q: question" or "A: answer", optionally as a list item.
+	qaRe = regexp.MustCompile(`^\s*(?:[-*]\s+)?([QA]):\s*(.*)$`)
+	// clozeRe matches {{c1::text}} and {{c1::text::hint}}.
+	clozeRe = regexp.MustCompile(`\{\{c(\d+)::(.*?)(?:::(.*?))?\}\}`)
+)
+
+// Extract returns the cards in a note, in the order they appear. An answer
+// runs until a blank line or the next question; a line with cloze deletions
+// makes one card per deletion number.
+func Extract(note *notes.Note) []Card {
+	var cards []Card
+	add := func(kind Kind, front, back string, line int, key string) {
+		sum := sha256.Sum256([]byte(string(kind) + "\x00" + key))
+		cards = append(cards, Card{
+			ID:    hex.EncodeToString(sum[:])[:12],
+			Kind:  kind,
+			Front: front,
+			Back:  back,
+			Note:  note.Key(),
+			Line:  line,
+			Tags:  note.Tags,
+		})
+	}
+
+	var question, answer []string
+	qLine := 0
+	inAnswer := false
+	flush := func() {
+		if len(question) > 0 && len(answer) > 0 {
+			front := strings.Join(question, "\n")
+			add(KindQA, front, strings.Join(answer, "\n"), qLine, front)
+		}
+		question, answer, inAnswer = nil, nil, false
+	}
+
+	inFence := false
+	for i, line := range strings.Split(note.Body, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+			flush()
+			continue
+		}
+		if inFence {
+			continue
+		}
+
+		if m := qaRe.FindStringSubmatch(line); m != nil {
+			if m[1] == "Q" {
+				flush()
+				question, qLine = []string{m[2]}, i+1
+			} else if len(question) > 0 {
+				answer, inAnswer = append(answer, m[2]), true
+			}
+			continue
+		}
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			flush()
+		} else if inAnswer {
+			answer = append(answer, strings.TrimSpace(line))
+		} else if len(question) > 0 {
+			question = append(question, strings.TrimSpace(line))
+		}
+
+		for _, n := range clozeNumbers(line) {
+			text := strings.TrimSpace(line)
+			add(KindCloze, clozeFront(text, n), clozeBack(text, n), i+1, n+"\x00"+text)
+		}
+	}
+	flush()
+	return cards
+}
+
+// clozeNumbers returns the distinct deletion numbers on a line, in order.
+func clozeNumbers(line string) []string {
+	var nums []string
+	seen := make(map[string]bool)
+	for _, m := range clozeRe.FindAllStringSubmatch(line, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			nums = append(nums, m[1])
+		}
+	}
+	sort.SliceStable(nums, func(i, j int) bool {
+		a, _ := strconv.Atoi(nums[i])
+		b, _ := strconv.Atoi(nums[j])
+		return a < b
+	})
+	return nums
+}
+
+// clozeFront hides deletion n as [...] (or [hint]) and shows the others.
+func clozeFront(text, n string) string {
+	return clozeRe.ReplaceAllStringFunc(text, func(s string) string {
+		m := clozeRe.FindStringSubmatch(s)
+		if m[1] != n {
+			return m[2]
+		}
+		if m[3] != "" {
+			return "[" + m[3] + "]"
+		}
+		return "[...]"
+	})
+}
+
+// clozeBack shows every deletion, marking deletion n with brackets.
+func clozeBack(text, n string) string {
+	return clozeRe.ReplaceAllStringFunc(text, func(s string) string {
+		m := clozeRe.FindStringSubmatch(s)
+		if m[1] != n {
+			return m[2]
+		}
+		return "[" + m[2] + "]"
+	})
+}
+
+// FromNotes extracts the cards in notes, dropping duplicates by ID.
+func FromNotes(list []*notes.Note) []Card {
+	var cards []Card
+	seen := make(map[string]bool)
+	for _, n := range list {
+		for _, c := range Extract(n) {
+			if !seen[c.ID] {
+				seen[c.ID] = true
+				cards = append(cards, c)
+			}
+		}
+	}
+	return cards
+}
@@ -0,0 +1,139 @@
+package cards
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"gitlab.com/caffeinatedjack/sleepless/pkg/notes"
+)
+
+func TestExtract(t *testing.T) {
+	note := notes.NewFloatingNote("a1b2c3d4")
+	note.Tags = []string{"go"}
+	note.Body = "# Go\n\n" +
+		"Q: What does `defer` do?\n" +
+		"A: Runs a call when the function returns.\n" +
+		"Arguments are evaluated immediately.\n\n" +
+		"- Q: Zero value of a map?\n" +
+		"- A: nil\n\n" +
+		"Q: Unanswered question\n\n" +
+		"The {{c1::GOPATH}} predates {{c2::modules::build system}}.\n" +
+		"```\nQ: in code\nA: ignored\n```\n"
+
+	cards := Extract(note)
+	var got [][3]string
+	for _, c := range cards {
+		got = append(got, [3]string{string(c.Kind), c.Front, c.Back})
+	}
+	want := [][3]string{
+		{"qa", "What does `defer` do?", "Runs a call when the function returns.\nArguments are evaluated immediately."},
+		{"qa", "Zero value of a map?", "nil"},
+		{"cloze", "The [...] predates modules.", "The [GOPATH] predates modules."},
+		{"cloze", "The GOPATH predates [build system].", "The GOPATH predates [modules]."},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Extract:\n got %q\nwant %q", got, want)
+	}
+	if cards[0].Line != 3 || cards[0].Note != "a1b2c3d4" || cards[0].Tags[0] != "go" {
+		t.Errorf("unexpected card metadata %+v", cards[0])
+	}
+
+	// IDs depend only on the card text.
+	moved := notes.NewDailyNote("2026-01-20")
+	moved.Body = "Intro\n\nQ: Zero value of a map?\nA: nil\n"
+	if Extract(moved)[0].ID != cards[1].ID {
+		t.Error("card ID changed when the card moved")
+	}
+	if len(FromNotes([]*notes.Note{note, moved})) != 4 {
+		t.Error("expected duplicate cards to be dropped")
+	}
+}
+
+func TestAnswer_SM2(t *testing.T) {
+	s := &State{Cards: map[string]*CardState{}}
+	day := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	var intervals []int
+	for _, g := range []Grade{Good, Good, Good, Again, Good, Hard} {
+		cs := s.Answer("c", g, day)
+		intervals = append(intervals, cs.Interval)
+		day = day.AddDate(0, 0, cs.Interval)
+	}
+	// 1, 6, 6*2.5=15, lapse to 1, relearn at 1, then 6.
+	if want := []int{1, 6, 15, 1, 1, 6}; !reflect.DeepEqual(intervals, want) {
+		t.Errorf("intervals %v, want %v", intervals, want)
+	}
+	cs := s.Cards["c"]
+	if cs.Lapses != 1 || cs.Ease < 1.3 || cs.Ease >= 2.5 {
+		t.Errorf("unexpected state %+v", cs)
+	}
+	if cs.Due != day.Format("2006-01-02") || len(s.Reviews) != 6 {
+		t.Errorf("due %s, %d reviews", cs.Due, len(s.Reviews))
+	}
+
+	for i := 0; i < 20; i++ {
+		s.Answer("hard", Again, day)
+	}
+	if s.Cards["hard"].Ease != 1.3 {
+		t.Errorf("ease should bottom out at 1.3, got %v", s.Cards["hard"].Ease)
+	}
+}
+
+func TestRepeat_PenalisesOncePerSession(t *testing.T) {
+	s := &State{Cards: map[string]*CardState{}}
+	day := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	s.Answer("c", Good, day)
+	s.Answer("c", Good, day.AddDate(0, 0, 1))
+	day = day.AddDate(0, 0, 7)
+
+	once := *s.Answer("c", Again, day)
+	for _, g := range []Grade{Again, Again, Good} {
+		s.Repeat("c", g, day)
+	}
+	if cs := s.Cards["c"]; *cs != once || cs.Lapses != 1 || cs.Due != "2026-01-09" {
+		t.Errorf("repeats changed the schedule: %+v, want %+v", *cs, once)
+	}
+	if len(s.Reviews) != 6 {
+		t.Errorf("%d reviews logged, want 6", len(s.Reviews))
+	}
+
+	if cs := s.Repeat("new", Good, day); cs.Reps != 1 || cs.Interval != 1 {
+		t.Errorf("repeat of an unseen card should answer it: %+v", cs)
+	}
+}
+
+func TestDueAndStats(t *testing.T) {
+	dir := t.TempDir()
+	s, err := LoadState(dir + "/" + StateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cards := []Card{{ID: "a"}, {ID: "b"}, {ID: "c"}, {ID: "d"}}
+	now := time.Date(2026, 1, 10, 9, 0, 0, 0, time.UTC)
+	s.Answer("a", Good, now.AddDate(0, 0, -2)) // due on the 9th
+	s.Answer("b", Again, now)                  // due on the 11th
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+	s, err = LoadState(dir + "/" + StateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []string
+	for _, c := range s.Due(cards, "2026-01-10", 1) {
+		ids = append(ids, c.ID)
+	}
+	if !reflect.DeepEqual(ids, []string{"a", "c"}) {
+		t.Errorf("Due = %v, want the overdue card then one new card", ids)
+	}
+
+	st := s.Stats(cards, now)
+	if st.New != 2 || st.DueToday != 1 || st.DueWeek != 2 || st.Reviews != 2 || st.Retention != 0.5 {
+		t.Errorf("unexpected stats %+v", st)
+	}
+	if st.Streak != 1 || st.Forecast[0] != 1 || st.Forecast[1] != 1 {
+		t.Errorf("unexpected streak or forecast %+v", st)
+	}
+}
@@ -0,0 +1,272 @@
+package cards
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// StateFile is the review state file name, kept in the notes directory.
+const StateFile = ".flashcards.json"
+
+// maxReviews bounds the review log; the oldest entries are dropped first.
+const maxReviews = 10000
+
+// Grade is the answer to a card, from Again (forgotten) to Easy.
+type Grade int
+
+const (
+	Again Grade = 1
+	Hard  Grade = 2
+	Good  Grade = 3
+	Easy  Grade = 4
+)
+
+// ParseGrade accepts 1-4 or again, hard, good and easy.
+func ParseGrade(s string) (Grade, error) {
+	switch s {
+	case "1", "again", "a":
+		return Again, nil
+	case "2", "hard", "h":
+		return Hard, nil
+	case "3", "good", "g":
+		return Good, nil
+	case "4", "easy", "e":
+		return Easy, nil
+	}
+	return 0, fmt.Errorf("unknown grade %q (use 1-4: again, hard, good, easy)", s)
+}
+
+// quality maps a grade to SM-2's 0-5 response quality.
+func (g Grade) quality() float64 {
+	switch g {
+	case Again:
+		return 1
+	case Hard:
+		return 3
+	case Good:
+		return 4
+	default:
+		return 5
+	}
+}
+
+// CardState is a card's SM-2 schedule.
+type CardState struct {
+	Ease     float64 `json:"ease"`
+	Interval int     `json:"interval"`
+	Reps     int     `json:"reps"`
+	Lapses   int     `json:"lapses"`
+	// Due is the date the card is next shown, as YYYY-MM-DD.
+	Due      string `json:"due"`
+	Reviewed string `json:"reviewed"`
+}
+
+// Review is one answered card.
+type Review struct {
+	Card     string `json:"card"`
+	Time     string `json:"time"`
+	Grade    Grade  `json:"grade"`
+	Interval int    `json:"interval"`
+}
+
+// State is the persisted review state of every card that has been seen.
+type State struct {
+	Version int                   `json:"version"`
+	Cards   map[string]*CardState `json:"cards"`
+	Reviews []Review              `json:"reviews"`
+
+	path string
+}
+
+// LoadState reads the state at path. A missing file is an empty state.
+func LoadState(path string) (*State, error) {
+	s := &State{Version: 1, Cards: map[string]*CardState{}, path: path}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("failed to read review state: %w", err)
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("failed to parse review state %s: %w", path, err)
+	}
+	if s.Cards == nil {
+		s.Cards = map[string]*CardState{}
+	}
+	s.path = path
+	return s, nil
+}
+
+// Save writes the state back to the file it was loaded from, atomically.
+func (s *State) Save() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// Answer applies SM-2 to a card answered at now and logs the review.
+func (s *State) Answer(id string, g Grade, now time.Time) *CardState {
+	cs, ok := s.Cards[id]
+	if !ok {
+		cs = &CardState{Ease: 2.5}
+		s.Cards[id] = cs
+	}
+
+	q := g.quality()
+	if q < 3 {
+		cs.Reps = 0
+		cs.Interval = 1
+		cs.Lapses++
+	} else {
+		switch cs.Reps {
+		case 0:
+			cs.Interval = 1
+		case 1:
+			cs.Interval = 6
+		default:
+			cs.Interval = int(math.Round(float64(cs.Interval) * cs.Ease))
+		}
+		if g == Easy && cs.Reps < 2 {
+			cs.Interval *= 2
+		}
+		cs.Reps++
+	}
+	cs.Ease = math.Max(1.3, cs.Ease+0.1-(5-q)*(0.08+(5-q)*0.02))
+	cs.Due = now.AddDate(0, 0, cs.Interval).Format("2006-01-02")
+	cs.Reviewed = now.Format("2006-01-02")
+
+	s.log(id, g, now, cs.Interval)
+	return cs
+}
+
+// Repeat logs a card answered again in the session that first answered it.
+// As in SM-2, only the first answer in a session changes the schedule, so a
+// card forgotten and asked again until it is remembered is penalised once.
+func (s *State) Repeat(id string, g Grade, now time.Time) *CardState {
+	cs, ok := s.Cards[id]
+	if !ok {
+		return s.Answer(id, g, now)
+	}
+	s.log(id, g, now, cs.Interval)
+	return cs
+}
+
+func (s *State) log(id string, g Grade, now time.Time, interval int) {
+	s.Reviews = append(s.Reviews, Review{Card: id, Time: now.Format(time.RFC3339), Grade: g, Interval: interval})
+	if len(s.Reviews) > maxReviews {
+		s.Reviews = s.Reviews[len(s.Reviews)-maxReviews:]
+	}
+}
+
+// Due returns the cards to review on today (YYYY-MM-DD): seen cards due by
+// then, most overdue first, followed by up to newLimit unseen cards in note
+// order.
+func (s *State) Due(cards []Card, today string, newLimit int) []Card {
+	var due, unseen []Card
+	for _, c := range cards {
+		cs, ok := s.Cards[c.ID]
+		switch {
+		case !ok:
+			if len(unseen) < newLimit {
+				unseen = append(unseen, c)
+			}
+		case cs.Due <= today:
+			due = append(due, c)
+		}
+	}
+	sort.SliceStable(due, func(i, j int) bool { return s.Cards[due[i].ID].Due < s.Cards[due[j].ID].Due })
+	return append(due, unseen...)
+}
+
+// Stats summarises a deck's review state.
+type Stats struct {
+	Cards    int `json:"cards"`
+	New      int `json:"new"`
+	DueToday int `json:"due_today"`
+	DueWeek  int `json:"due_week"`
+	// Mature cards have an interval of 21 days or more.
+	Mature int `json:"mature"`
+	// Reviews and Retention cover the last 30 days; Retention is the share
+	// of reviews not answered Again.
+	Reviews   int     `json:"reviews_30d"`
+	Retention float64 `json:"retention_30d"`
+	// Streak is the number of consecutive days, up to today, with a review.
+	Streak int `json:"streak"`
+	// Forecast counts the cards due on each of the next 14 days, starting
+	// today; overdue cards count as due today.
+	Forecast []int `json:"forecast"`
+}
+
+// Stats summarises the review state of cards as of now.
+func (s *State) Stats(cards []Card, now time.Time) Stats {
+	today := now.Format("2006-01-02")
+	week := now.AddDate(0, 0, 7).Format("2006-01-02")
+	st := Stats{Cards: len(cards), Forecast: make([]int, 14)}
+	ids := make(map[string]bool)
+	for _, c := range cards {
+		ids[c.ID] = true
+		cs, ok := s.Cards[c.ID]
+		if !ok {
+			st.New++
+			continue
+		}
+		if cs.Due <= today {
+			st.DueToday++
+		}
+		if cs.Due <= week {
+			st.DueWeek++
+		}
+		if cs.Interval >= 21 {
+			st.Mature++
+		}
+		for d := range st.Forecast {
+			day := now.AddDate(0, 0, d).Format("2006-01-02")
+			if cs.Due == day || (d == 0 && cs.Due < day) {
+				st.Forecast[d]++
+			}
+		}
+	}
+
+	since := now.AddDate(0, 0, -30)
+	days := make(map[string]bool)
+	passed := 0
+	for _, r := range s.Reviews {
+		if !ids[r.Card] {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, r.Time)
+		if err != nil {
+			continue
+		}
+		days[t.In(now.Location()).Format("2006-01-02")] = true
+		if t.Before(since) {
+			continue
+		}
+		st.Reviews++
+		if r.Grade != Again {
+			passed++
+		}
+	}
+	if st.Reviews > 0 {
+		st.Retention = float64(passed) / float64(st.Reviews)
+	}
+	for d := now; days[d.Format("2006-01-02")]; d = d.AddDate(0, 0, -1) {
+		st.Streak++
+	}
+	return st
+}
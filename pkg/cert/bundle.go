@@ -0,0 +1,190 @@
+// Package cert provides offline X.509 certificate inspection for nightwatch.
+// It parses PEM and DER bundles, summarizes certificates and keys without
+// exposing key material, and checks expiry, hostnames and chains using
+// crypto/x509.
+package cert
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ErrNoBlocks is returned when input contains no certificates or keys.
+var ErrNoBlocks = errors.New("no certificates or keys found")
+
+// Bundle holds the certificates and keys parsed from one input.
+// Certificates keep their input order; by convention the leaf comes first.
+// Skipped lists the types of PEM blocks that are neither, such as
+// EC PARAMETERS written by openssl ecparam.
+type Bundle struct {
+	Certificates []*x509.Certificate
+	Keys         []KeyInfo
+	Skipped      []string
+}
+
+// KeyInfo summarizes a private or public key without its material.
+type KeyInfo struct {
+	PEMType   string `json:"pem_type,omitempty"`
+	Private   bool   `json:"private"`
+	Algorithm string `json:"algorithm"`
+	Size      int    `json:"size,omitempty"`
+}
+
+// LoadBundle reads and parses a PEM or DER file.
+func LoadBundle(path string) (*Bundle, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	bundle, err := ParseBundle(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return bundle, nil
+}
+
+// LoadCertificates reads one or more files and returns all certificates in order.
+// It is used for root and intermediate bundles where keys are not expected.
+func LoadCertificates(paths ...string) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for _, path := range paths {
+		bundle, err := LoadBundle(path)
+		if err != nil {
+			return nil, err
+		}
+		if len(bundle.Certificates) == 0 {
+			return nil, fmt.Errorf("%s: no certificates found", path)
+		}
+		certs = append(certs, bundle.Certificates...)
+	}
+	return certs, nil
+}
+
+// ParseBundle parses PEM data (any number of blocks) or a single DER
+// certificate, certificate sequence or key.
+func ParseBundle(data []byte) (*Bundle, error) {
+	if bytes.Contains(data, []byte("-----BEGIN ")) {
+		return parsePEM(data)
+	}
+	return parseDER(data)
+}
+
+func parsePEM(data []byte) (*Bundle, error) {
+	bundle := &Bundle{}
+	rest := data
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+
+		switch block.Type {
+		case "CERTIFICATE", "TRUSTED CERTIFICATE":
+			certs, err := x509.ParseCertificates(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("invalid certificate: %w", err)
+			}
+			bundle.Certificates = append(bundle.Certificates, certs...)
+		case "CERTIFICATE REQUEST", "NEW CERTIFICATE REQUEST", "X509 CRL":
+			// Parsed by dedicated commands; ignored when inspecting bundles.
+			continue
+		default:
+			if !strings.Contains(block.Type, "KEY") {
+				bundle.Skipped = append(bundle.Skipped, block.Type)
+				continue
+			}
+			if isEncryptedKey(block) {
+				// Its algorithm cannot be read without the passphrase.
+				bundle.Keys = append(bundle.Keys, KeyInfo{PEMType: block.Type, Private: true, Algorithm: "encrypted"})
+				continue
+			}
+			info, err := parseKey(block.Type, block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			bundle.Keys = append(bundle.Keys, info)
+		}
+	}
+
+	if len(bundle.Certificates) == 0 && len(bundle.Keys) == 0 {
+		return nil, ErrNoBlocks
+	}
+	return bundle, nil
+}
+
+// isEncryptedKey reports whether block is a PKCS#8 encrypted key or a
+// legacy key encrypted with a Proc-Type header.
+func isEncryptedKey(block *pem.Block) bool {
+	return block.Type == "ENCRYPTED PRIVATE KEY" || strings.Contains(block.Headers["Proc-Type"], "ENCRYPTED")
+}
+
+func parseDER(data []byte) (*Bundle, error) {
+	if len(data) == 0 {
+		return nil, ErrNoBlocks
+	}
+	if certs, err := x509.ParseCertificates(data); err == nil && len(certs) > 0 {
+		return &Bundle{Certificates: certs}, nil
+	}
+	if info, err := parseKey("", data); err == nil {
+		return &Bundle{Keys: []KeyInfo{info}}, nil
+	}
+	return nil, fmt.Errorf("input is neither PEM nor a DER certificate or key")
+}
+
+// parseKey identifies a DER key. pemType is used only to label the result.
+func parseKey(pemType string, der []byte) (KeyInfo, error) {
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return describeKey(pemType, true, key), nil
+	}
+	if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		return describeKey(pemType, true, key), nil
+	}
+	if key, err := x509.ParseECPrivateKey(der); err == nil {
+		return describeKey(pemType, true, key), nil
+	}
+	if key, err := x509.ParsePKIXPublicKey(der); err == nil {
+		return describeKey(pemType, false, key), nil
+	}
+	if key, err := x509.ParsePKCS1PublicKey(der); err == nil {
+		return describeKey(pemType, false, key), nil
+	}
+
+	if pemType != "" {
+		return KeyInfo{}, fmt.Errorf("unsupported or unparseable PEM block %q", pemType)
+	}
+	return KeyInfo{}, fmt.Errorf("unparseable key")
+}
+
+func describeKey(pemType string, private bool, key interface{}) KeyInfo {
+	alg, size := KeyAlgorithm(key)
+	return KeyInfo{PEMType: pemType, Private: private, Algorithm: alg, Size: size}
+}
+
+// KeyAlgorithm returns the algorithm name and size in bits for a public or
+// private key. Size is 0 for unknown key types.
+func KeyAlgorithm(key interface{}) (string, int) {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return "RSA", k.N.BitLen()
+	case *rsa.PrivateKey:
+		return "RSA", k.N.BitLen()
+	case *ecdsa.PublicKey:
+		return "ECDSA", k.Curve.Params().BitSize
+	case *ecdsa.PrivateKey:
+		return "ECDSA", k.Curve.Params().BitSize
+	case ed25519.PublicKey, ed25519.PrivateKey:
+		return "Ed25519", 256
+	default:
+		return "unknown", 0
+	}
+}
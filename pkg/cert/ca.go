@@ -0,0 +1,573 @@
+package cert
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// CA directory layout. The root key is written once at init and never read
+// again; day-to-day issuing and CRL signing use the intermediate.
+const (
+	rootCertFile         = "root.pem"
+	rootKeyFile          = "root.key"
+	intermediateCertFile = "intermediate.pem"
+	intermediateKeyFile  = "intermediate.key"
+	caStateFile          = "ca.json"
+)
+
+// Default validity periods for generated certificates.
+const (
+	DefaultRootValidity         = 10 * 365 * 24 * time.Hour
+	DefaultIntermediateValidity = 5 * 365 * 24 * time.Hour
+	DefaultLeafValidity         = 30 * 24 * time.Hour
+	DefaultCRLValidity          = 7 * 24 * time.Hour
+)
+
+// DefaultPermittedDNS restricts a new intermediate to reserved and
+// local-only names so a leaked dev CA cannot mint certificates for real sites.
+var DefaultPermittedDNS = []string{"localhost", "test", "example", "internal", "local"}
+
+// DefaultPermittedIPs restricts a new intermediate to loopback and private ranges.
+var DefaultPermittedIPs = []string{"127.0.0.0/8", "::1/128", "10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16"}
+
+// CAOptions configures InitCA.
+type CAOptions struct {
+	CommonName   string
+	Organization string
+	KeyType      KeyType
+	// PermittedDNS and PermittedIPs become name constraints on the
+	// intermediate. Both empty means the intermediate is unconstrained.
+	PermittedDNS         []string
+	PermittedIPs         []string
+	RootValidity         time.Duration
+	IntermediateValidity time.Duration
+}
+
+// IssueOptions configures leaf certificates issued by an Authority.
+type IssueOptions struct {
+	CommonName string
+	// SANs may mix DNS names, IP addresses, email addresses and URIs.
+	SANs     []string
+	Server   bool
+	Client   bool
+	Validity time.Duration
+	KeyType  KeyType
+}
+
+// IssuedRecord is the CA's log entry for an issued certificate.
+type IssuedRecord struct {
+	Serial   string    `json:"serial"`
+	Subject  string    `json:"subject"`
+	Names    []string  `json:"names,omitempty"`
+	Usage    []string  `json:"usage,omitempty"`
+	Issued   time.Time `json:"issued"`
+	NotAfter time.Time `json:"not_after"`
+}
+
+// RevokedRecord is the CA's log entry for a revoked certificate.
+type RevokedRecord struct {
+	Serial    string    `json:"serial"`
+	Subject   string    `json:"subject,omitempty"`
+	RevokedAt time.Time `json:"revoked_at"`
+	Reason    int       `json:"reason"`
+}
+
+// CAState is persisted in ca.json alongside the CA certificates.
+type CAState struct {
+	Version   int             `json:"version"`
+	Created   time.Time       `json:"created"`
+	CRLNumber int64           `json:"crl_number"`
+	Issued    []IssuedRecord  `json:"issued"`
+	Revoked   []RevokedRecord `json:"revoked"`
+}
+
+// Authority is a local two-tier CA loaded from a directory.
+type Authority struct {
+	Dir          string
+	Root         *x509.Certificate
+	Intermediate *x509.Certificate
+	State        *CAState
+	key          crypto.Signer
+}
+
+// DefaultCADir returns the local CA directory.
+// It checks NIGHTWATCH_CA_DIR, otherwise uses <user config dir>/nightwatch/ca.
+func DefaultCADir() (string, error) {
+	if dir := os.Getenv("NIGHTWATCH_CA_DIR"); dir != "" {
+		return dir, nil
+	}
+
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("unable to determine config directory: %w", err)
+	}
+	return filepath.Join(configDir, "nightwatch", "ca"), nil
+}
+
+// IntermediateKeyPath returns the path of the signing key used by the CA.
+func (a *Authority) IntermediateKeyPath() string {
+	return filepath.Join(a.Dir, intermediateKeyFile)
+}
+
+// RootPath returns the path of the root certificate to distribute to trust stores.
+func (a *Authority) RootPath() string {
+	return filepath.Join(a.Dir, rootCertFile)
+}
+
+// InitCA creates a root and a name-constrained intermediate in dir.
+// It fails if a CA already exists there.
+func InitCA(dir string, opts CAOptions) (*Authority, error) {
+	if _, err := os.Stat(filepath.Join(dir, rootCertFile)); err == nil {
+		return nil, fmt.Errorf("a CA already exists in %s", dir)
+	}
+	if opts.CommonName == "" {
+		opts.CommonName = "Nightwatch Development CA"
+	}
+	if opts.RootValidity <= 0 {
+		opts.RootValidity = DefaultRootValidity
+	}
+	if opts.IntermediateValidity <= 0 {
+		opts.IntermediateValidity = DefaultIntermediateValidity
+	}
+	if opts.IntermediateValidity > opts.RootValidity {
+		opts.IntermediateValidity = opts.RootValidity
+	}
+
+	permittedIPs, err := parseCIDRs(opts.PermittedIPs)
+	if err != nil {
+		return nil, err
+	}
+
+	rootKey, err := GenerateKey(opts.KeyType)
+	if err != nil {
+		return nil, err
+	}
+	interKey, err := GenerateKey(opts.KeyType)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Add(-5 * time.Minute)
+	subject := func(cn string) pkix.Name {
+		name := pkix.Name{CommonName: cn}
+		if opts.Organization != "" {
+			name.Organization = []string{opts.Organization}
+		}
+		return name
+	}
+
+	rootSerial, err := randomSerial()
+	if err != nil {
+		return nil, err
+	}
+	rootTmpl := &x509.Certificate{
+		SerialNumber:          rootSerial,
+		Subject:               subject(opts.CommonName + " Root"),
+		NotBefore:             now,
+		NotAfter:              now.Add(opts.RootValidity),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		MaxPathLen:            1,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	root, err := createCertificate(rootTmpl, rootTmpl, rootKey.Public(), rootKey)
+	if err != nil {
+		return nil, err
+	}
+
+	interSerial, err := randomSerial()
+	if err != nil {
+		return nil, err
+	}
+	interTmpl := &x509.Certificate{
+		SerialNumber:          interSerial,
+		Subject:               subject(opts.CommonName + " Intermediate"),
+		NotBefore:             now,
+		NotAfter:              now.Add(opts.IntermediateValidity),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		MaxPathLen:            0,
+		MaxPathLenZero:        true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		PermittedDNSDomains:   opts.PermittedDNS,
+		PermittedIPRanges:     permittedIPs,
+	}
+	interTmpl.PermittedDNSDomainsCritical = len(opts.PermittedDNS) > 0 || len(permittedIPs) > 0
+	inter, err := createCertificate(interTmpl, root, interKey.Public(), rootKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create CA directory: %w", err)
+	}
+	if err := WriteKey(filepath.Join(dir, rootKeyFile), rootKey); err != nil {
+		return nil, err
+	}
+	if err := WriteKey(filepath.Join(dir, intermediateKeyFile), interKey); err != nil {
+		return nil, err
+	}
+	if err := WriteCertificates(filepath.Join(dir, rootCertFile), root); err != nil {
+		return nil, err
+	}
+	if err := WriteCertificates(filepath.Join(dir, intermediateCertFile), inter); err != nil {
+		return nil, err
+	}
+
+	a := &Authority{
+		Dir:          dir,
+		Root:         root,
+		Intermediate: inter,
+		State:        &CAState{Version: 1, Created: time.Now().UTC(), Issued: []IssuedRecord{}, Revoked: []RevokedRecord{}},
+		key:          interKey,
+	}
+	if err := a.saveState(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// LoadCA opens an existing CA directory and its intermediate signing key.
+func LoadCA(dir string) (*Authority, error) {
+	if _, err := os.Stat(filepath.Join(dir, rootCertFile)); os.IsNotExist(err) {
+		return nil, fmt.Errorf("no CA found in %s (run 'nightwatch cert ca init')", dir)
+	}
+
+	roots, err := LoadCertificates(filepath.Join(dir, rootCertFile))
+	if err != nil {
+		return nil, err
+	}
+	inters, err := LoadCertificates(filepath.Join(dir, intermediateCertFile))
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Authority{Dir: dir, Root: roots[0], Intermediate: inters[0]}
+	if a.key, err = LoadSigner(a.IntermediateKeyPath()); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, caStateFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA state: %w", err)
+	}
+	var state CAState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse CA state: %w", err)
+	}
+	a.State = &state
+	return a, nil
+}
+
+// Chain returns the certificates a server should send after its leaf.
+func (a *Authority) Chain() []*x509.Certificate {
+	return []*x509.Certificate{a.Intermediate}
+}
+
+// Issue generates a key pair and a leaf certificate for it.
+func (a *Authority) Issue(opts IssueOptions) (*x509.Certificate, crypto.Signer, error) {
+	key, err := GenerateKey(opts.KeyType)
+	if err != nil {
+		return nil, nil, err
+	}
+	c, err := a.sign(key.Public(), opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c, key, nil
+}
+
+// SignCSR issues a leaf for a certificate request. The request's subject and
+// SANs are used unless opts supplies its own.
+func (a *Authority) SignCSR(csr *x509.CertificateRequest, opts IssueOptions) (*x509.Certificate, error) {
+	if err := csr.CheckSignature(); err != nil {
+		return nil, fmt.Errorf("certificate request signature is invalid: %w", err)
+	}
+	if opts.CommonName == "" {
+		opts.CommonName = csr.Subject.CommonName
+	}
+	if len(opts.SANs) == 0 {
+		opts.SANs = requestSANs(csr)
+	}
+	return a.sign(csr.PublicKey, opts)
+}
+
+// sign creates, verifies and records a leaf certificate.
+func (a *Authority) sign(pub crypto.PublicKey, opts IssueOptions) (*x509.Certificate, error) {
+	if len(opts.SANs) == 0 {
+		return nil, fmt.Errorf("at least one SAN is required")
+	}
+	if !opts.Server && !opts.Client {
+		opts.Server = true
+	}
+	if opts.Validity <= 0 {
+		opts.Validity = DefaultLeafValidity
+	}
+
+	tmpl := &x509.Certificate{
+		Subject:   pkix.Name{CommonName: opts.CommonName},
+		NotBefore: time.Now().Add(-5 * time.Minute),
+		KeyUsage:  x509.KeyUsageDigitalSignature,
+	}
+	tmpl.NotAfter = tmpl.NotBefore.Add(opts.Validity)
+	if tmpl.NotAfter.After(a.Intermediate.NotAfter) {
+		tmpl.NotAfter = a.Intermediate.NotAfter
+	}
+	if err := applySANs(tmpl, opts.SANs); err != nil {
+		return nil, err
+	}
+	if tmpl.Subject.CommonName == "" && len(tmpl.DNSNames) > 0 {
+		tmpl.Subject.CommonName = tmpl.DNSNames[0]
+	}
+	if _, isRSA := pub.(*rsa.PublicKey); isRSA {
+		tmpl.KeyUsage |= x509.KeyUsageKeyEncipherment
+	}
+
+	var usage []string
+	if opts.Server {
+		tmpl.ExtKeyUsage = append(tmpl.ExtKeyUsage, x509.ExtKeyUsageServerAuth)
+		usage = append(usage, "server")
+	}
+	if opts.Client {
+		tmpl.ExtKeyUsage = append(tmpl.ExtKeyUsage, x509.ExtKeyUsageClientAuth)
+		usage = append(usage, "client")
+	}
+
+	serial, err := randomSerial()
+	if err != nil {
+		return nil, err
+	}
+	tmpl.SerialNumber = serial
+
+	leaf, err := createCertificate(tmpl, a.Intermediate, pub, a.key)
+	if err != nil {
+		return nil, err
+	}
+
+	// Refuse to hand out certificates that violate the intermediate's name
+	// constraints; they would only fail later at handshake time.
+	check, err := VerifyChain([]*x509.Certificate{leaf, a.Intermediate}, ChainOptions{
+		Roots:     []*x509.Certificate{a.Root},
+		KeyUsages: tmpl.ExtKeyUsage,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !check.Valid {
+		return nil, fmt.Errorf("issued certificate does not verify against the CA: %s", check.Error)
+	}
+
+	a.State.Issued = append(a.State.Issued, IssuedRecord{
+		Serial:   SerialHex(leaf),
+		Subject:  leaf.Subject.String(),
+		Names:    opts.SANs,
+		Usage:    usage,
+		Issued:   time.Now().UTC(),
+		NotAfter: leaf.NotAfter.UTC(),
+	})
+	if err := a.saveState(); err != nil {
+		return nil, err
+	}
+	return leaf, nil
+}
+
+// Revoke records a certificate serial (hex, optionally colon-separated) as
+// revoked so it appears in the next CRL.
+func (a *Authority) Revoke(serial string, reason int) (*RevokedRecord, error) {
+	serial = normalizeSerial(serial)
+	if _, err := hex.DecodeString(serial); err != nil || serial == "" {
+		return nil, fmt.Errorf("invalid serial number: %s", serial)
+	}
+	for _, r := range a.State.Revoked {
+		if r.Serial == serial {
+			return nil, fmt.Errorf("certificate %s is already revoked", serial)
+		}
+	}
+
+	record := RevokedRecord{Serial: serial, RevokedAt: time.Now().UTC(), Reason: reason}
+	found := false
+	for _, issued := range a.State.Issued {
+		if issued.Serial == serial {
+			record.Subject = issued.Subject
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("serial %s was not issued by this CA", serial)
+	}
+
+	a.State.Revoked = append(a.State.Revoked, record)
+	if err := a.saveState(); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// CRL signs a new certificate revocation list with the intermediate and
+// returns it as PEM. Each call increments the CRL number.
+func (a *Authority) CRL(validity time.Duration) (*x509.RevocationList, []byte, error) {
+	if validity <= 0 {
+		validity = DefaultCRLValidity
+	}
+
+	entries := make([]x509.RevocationListEntry, 0, len(a.State.Revoked))
+	for _, r := range a.State.Revoked {
+		serial, ok := new(big.Int).SetString(r.Serial, 16)
+		if !ok {
+			return nil, nil, fmt.Errorf("invalid serial in CA state: %s", r.Serial)
+		}
+		entries = append(entries, x509.RevocationListEntry{
+			SerialNumber:   serial,
+			RevocationTime: r.RevokedAt,
+			ReasonCode:     r.Reason,
+		})
+	}
+
+	a.State.CRLNumber++
+	now := time.Now().UTC()
+	der, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:                    big.NewInt(a.State.CRLNumber),
+		ThisUpdate:                now,
+		NextUpdate:                now.Add(validity),
+		RevokedCertificateEntries: entries,
+	}, a.Intermediate, a.key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create CRL: %w", err)
+	}
+	if err := a.saveState(); err != nil {
+		return nil, nil, err
+	}
+
+	crl, err := x509.ParseRevocationList(der)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse generated CRL: %w", err)
+	}
+	return crl, pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der}), nil
+}
+
+// saveState writes ca.json with user-only permissions.
+func (a *Authority) saveState() error {
+	data, err := json.MarshalIndent(a.State, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal CA state: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(a.Dir, caStateFile), data, 0600); err != nil {
+		return fmt.Errorf("failed to write CA state: %w", err)
+	}
+	return nil
+}
+
+// RevocationReasons maps RFC 5280 reason names to codes.
+var RevocationReasons = map[string]int{
+	"unspecified":          0,
+	"keyCompromise":        1,
+	"caCompromise":         2,
+	"affiliationChanged":   3,
+	"superseded":           4,
+	"cessationOfOperation": 5,
+}
+
+// ParseRevocationReason converts a reason name (case-insensitive) to its code.
+func ParseRevocationReason(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	for name, code := range RevocationReasons {
+		if strings.EqualFold(name, s) {
+			return code, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown revocation reason: %s", s)
+}
+
+// SerialHex returns a certificate serial as lowercase hex without separators.
+func SerialHex(c *x509.Certificate) string {
+	return hex.EncodeToString(c.SerialNumber.Bytes())
+}
+
+func normalizeSerial(s string) string {
+	s = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(s), ":", ""))
+	return strings.TrimPrefix(s, "0x")
+}
+
+// randomSerial returns a positive 128-bit serial number (RFC 5280 allows up to 20 octets).
+func randomSerial() (*big.Int, error) {
+	limit := new(big.Int).Lsh(big.NewInt(1), 127)
+	serial, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate serial number: %w", err)
+	}
+	return serial.Add(serial, big.NewInt(1)), nil
+}
+
+func createCertificate(tmpl, parent *x509.Certificate, pub crypto.PublicKey, signer crypto.Signer) (*x509.Certificate, error) {
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, pub, signer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create certificate: %w", err)
+	}
+	return x509.ParseCertificate(der)
+}
+
+func parseCIDRs(ranges []string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, r := range ranges {
+		_, n, err := net.ParseCIDR(strings.TrimSpace(r))
+		if err != nil {
+			return nil, fmt.Errorf("invalid IP range %q: %w", r, err)
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+// applySANs sorts mixed SAN strings into the template's typed fields.
+func applySANs(tmpl *x509.Certificate, sans []string) error {
+	for _, raw := range sans {
+		san := strings.TrimSpace(raw)
+		switch {
+		case san == "":
+			continue
+		case net.ParseIP(san) != nil:
+			tmpl.IPAddresses = append(tmpl.IPAddresses, net.ParseIP(san))
+		case strings.Contains(san, "://"):
+			u, err := url.Parse(san)
+			if err != nil {
+				return fmt.Errorf("invalid URI SAN %q: %w", san, err)
+			}
+			tmpl.URIs = append(tmpl.URIs, u)
+		case strings.Contains(san, "@"):
+			tmpl.EmailAddresses = append(tmpl.EmailAddresses, san)
+		default:
+			tmpl.DNSNames = append(tmpl.DNSNames, strings.ToLower(san))
+		}
+	}
+	return nil
+}
+
+func requestSANs(csr *x509.CertificateRequest) []string {
+	var sans []string
+	sans = append(sans, csr.DNSNames...)
+	for _, ip := range csr.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	sans = append(sans, csr.EmailAddresses...)
+	for _, u := range csr.URIs {
+		sans = append(sans, u.String())
+	}
+	return sans
+}
@@ -0,0 +1,250 @@
+package cert
+
+import (
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestCA(t *testing.T) *Authority {
+	t.Helper()
+	ca, err := InitCA(filepath.Join(t.TempDir(), "ca"), CAOptions{
+		CommonName:   "Test",
+		PermittedDNS: DefaultPermittedDNS,
+		PermittedIPs: DefaultPermittedIPs,
+	})
+	if err != nil {
+		t.Fatalf("InitCA failed: %v", err)
+	}
+	return ca
+}
+
+// TestInitCA verifies the CA layout, key permissions and name constraints.
+func TestInitCA(t *testing.T) {
+	ca := newTestCA(t)
+
+	for _, name := range []string{rootKeyFile, intermediateKeyFile} {
+		info, err := os.Stat(filepath.Join(ca.Dir, name))
+		if err != nil {
+			t.Fatalf("missing %s: %v", name, err)
+		}
+		if perm := info.Mode().Perm(); perm != 0600 {
+			t.Errorf("%s permissions = %o, want 600", name, perm)
+		}
+	}
+
+	if !ca.Root.IsCA || !IsSelfSigned(ca.Root) {
+		t.Error("root should be a self-signed CA")
+	}
+	if !ca.Intermediate.IsCA || !ca.Intermediate.MaxPathLenZero {
+		t.Error("intermediate should be a CA with path length 0")
+	}
+	if len(ca.Intermediate.PermittedDNSDomains) != len(DefaultPermittedDNS) {
+		t.Errorf("unexpected DNS constraints: %v", ca.Intermediate.PermittedDNSDomains)
+	}
+
+	if _, err := InitCA(ca.Dir, CAOptions{}); err == nil {
+		t.Error("expected error when initialising over an existing CA")
+	}
+}
+
+// TestIssue verifies server and client leaves chain to the root.
+func TestIssue(t *testing.T) {
+	ca := newTestCA(t)
+
+	leaf, key, err := ca.Issue(IssueOptions{
+		SANs:   []string{"api.test", "127.0.0.1", "dev@example.test"},
+		Server: true,
+		Client: true,
+	})
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+	if key == nil {
+		t.Fatal("expected a private key")
+	}
+	if leaf.Subject.CommonName != "api.test" {
+		t.Errorf("CommonName = %q, want first DNS SAN", leaf.Subject.CommonName)
+	}
+	if len(leaf.IPAddresses) != 1 || len(leaf.EmailAddresses) != 1 {
+		t.Errorf("SANs not sorted by type: %v %v", leaf.IPAddresses, leaf.EmailAddresses)
+	}
+	if len(leaf.ExtKeyUsage) != 2 {
+		t.Errorf("expected server and client usages, got %v", leaf.ExtKeyUsage)
+	}
+	if d := time.Until(leaf.NotAfter); d > DefaultLeafValidity || d < DefaultLeafValidity-time.Hour {
+		t.Errorf("unexpected leaf validity: %s", d)
+	}
+
+	result, err := VerifyChain([]*x509.Certificate{leaf, ca.Intermediate}, ChainOptions{
+		Roots:     []*x509.Certificate{ca.Root},
+		DNSName:   "api.test",
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	if err != nil || !result.Valid {
+		t.Fatalf("issued leaf does not verify: %v %+v", err, result)
+	}
+
+	if len(ca.State.Issued) != 1 || ca.State.Issued[0].Serial != SerialHex(leaf) {
+		t.Errorf("issued certificate not recorded: %+v", ca.State.Issued)
+	}
+}
+
+// TestIssue_NameConstraintViolation verifies out-of-scope names are refused.
+func TestIssue_NameConstraintViolation(t *testing.T) {
+	ca := newTestCA(t)
+
+	tests := [][]string{
+		{"www.google.com"},
+		{"api.test", "8.8.8.8"},
+	}
+	for _, sans := range tests {
+		if _, _, err := ca.Issue(IssueOptions{SANs: sans}); err == nil {
+			t.Errorf("expected name constraint error for %v", sans)
+		}
+	}
+	if len(ca.State.Issued) != 0 {
+		t.Error("refused certificates must not be recorded")
+	}
+
+	if _, _, err := ca.Issue(IssueOptions{}); err == nil {
+		t.Error("expected error when no SANs are given")
+	}
+}
+
+// TestLoadCA verifies a CA can be reopened and keeps issuing.
+func TestLoadCA(t *testing.T) {
+	ca := newTestCA(t)
+	if _, _, err := ca.Issue(IssueOptions{SANs: []string{"one.test"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadCA(ca.Dir)
+	if err != nil {
+		t.Fatalf("LoadCA failed: %v", err)
+	}
+	if !loaded.Root.Equal(ca.Root) || !loaded.Intermediate.Equal(ca.Intermediate) {
+		t.Error("loaded CA certificates differ")
+	}
+	if len(loaded.State.Issued) != 1 {
+		t.Errorf("expected issued log to persist, got %d entries", len(loaded.State.Issued))
+	}
+	if _, _, err := loaded.Issue(IssueOptions{SANs: []string{"two.test"}}); err != nil {
+		t.Errorf("Issue after load failed: %v", err)
+	}
+
+	if _, err := LoadCA(t.TempDir()); err == nil {
+		t.Error("expected error for a directory without a CA")
+	}
+}
+
+// TestCSRRoundTrip verifies CSRs can be created, parsed and signed.
+func TestCSRRoundTrip(t *testing.T) {
+	ca := newTestCA(t)
+
+	key, err := GenerateKey(KeyRSA2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	csr, err := CreateCSR(key, "", []string{"svc.internal", "10.1.2.3"})
+	if err != nil {
+		t.Fatalf("CreateCSR failed: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "svc.csr")
+	if err := WriteCSR(path, csr); err != nil {
+		t.Fatalf("WriteCSR failed: %v", err)
+	}
+	parsed, err := LoadCSR(path)
+	if err != nil {
+		t.Fatalf("LoadCSR failed: %v", err)
+	}
+
+	summary := SummarizeCSR(parsed)
+	if !summary.SignatureValid || summary.KeyAlgorithm != "RSA" || summary.KeySize != 2048 {
+		t.Errorf("unexpected CSR summary: %+v", summary)
+	}
+	if !strings.Contains(summary.Subject, "svc.internal") {
+		t.Errorf("CommonName should default to the first DNS SAN, got %s", summary.Subject)
+	}
+
+	leaf, err := ca.SignCSR(parsed, IssueOptions{Client: true})
+	if err != nil {
+		t.Fatalf("SignCSR failed: %v", err)
+	}
+	if len(leaf.DNSNames) != 1 || len(leaf.IPAddresses) != 1 {
+		t.Errorf("CSR SANs not carried over: %v %v", leaf.DNSNames, leaf.IPAddresses)
+	}
+	if leaf.KeyUsage&x509.KeyUsageKeyEncipherment == 0 {
+		t.Error("RSA leaves should allow key encipherment")
+	}
+}
+
+// TestRevokeAndCRL verifies revocation entries appear in signed CRLs.
+func TestRevokeAndCRL(t *testing.T) {
+	ca := newTestCA(t)
+	leaf, _, err := ca.Issue(IssueOptions{SANs: []string{"gone.test"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reason, err := ParseRevocationReason("keycompromise")
+	if err != nil || reason != 1 {
+		t.Fatalf("ParseRevocationReason = %d, %v", reason, err)
+	}
+	if _, err := ca.Revoke(Fingerprint(leaf, "sha256"), reason); err == nil {
+		t.Error("expected error for unknown serial")
+	}
+	if _, err := ca.Revoke(formatSerial(leaf), reason); err != nil {
+		t.Fatalf("Revoke with colon serial failed: %v", err)
+	}
+	if _, err := ca.Revoke(SerialHex(leaf), reason); err == nil {
+		t.Error("expected error when revoking twice")
+	}
+
+	crl, pemData, err := ca.CRL(0)
+	if err != nil {
+		t.Fatalf("CRL failed: %v", err)
+	}
+	if !strings.Contains(string(pemData), "BEGIN X509 CRL") {
+		t.Error("expected PEM-encoded CRL")
+	}
+	if err := crl.CheckSignatureFrom(ca.Intermediate); err != nil {
+		t.Errorf("CRL signature invalid: %v", err)
+	}
+	if len(crl.RevokedCertificateEntries) != 1 || crl.RevokedCertificateEntries[0].SerialNumber.Cmp(leaf.SerialNumber) != 0 {
+		t.Errorf("unexpected CRL entries: %+v", crl.RevokedCertificateEntries)
+	}
+	if crl.RevokedCertificateEntries[0].ReasonCode != 1 {
+		t.Errorf("reason code = %d, want 1", crl.RevokedCertificateEntries[0].ReasonCode)
+	}
+
+	second, _, err := ca.CRL(time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Number.Int64() != crl.Number.Int64()+1 {
+		t.Errorf("CRL number should increment, got %d then %d", crl.Number, second.Number)
+	}
+}
+
+// TestWriteKey_NoOverwrite verifies existing files are never replaced.
+func TestWriteKey_NoOverwrite(t *testing.T) {
+	key, err := GenerateKey(KeyEd25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "k.pem")
+	if err := WriteKey(path, key); err != nil {
+		t.Fatalf("WriteKey failed: %v", err)
+	}
+	if err := WriteKey(path, key); err == nil {
+		t.Error("expected error when overwriting a key")
+	}
+	if _, err := LoadSigner(path); err != nil {
+		t.Errorf("LoadSigner failed: %v", err)
+	}
+}
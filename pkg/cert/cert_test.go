@@ -0,0 +1,376 @@
+package cert
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testPKI struct {
+	root, intermediate, leaf *x509.Certificate
+	leafKey                  *ecdsa.PrivateKey
+}
+
+// issue creates a certificate signed by parent (self-signed when parent is nil).
+func issue(t *testing.T, tmpl *x509.Certificate, parent *x509.Certificate, parentKey interface{}) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	if parent == nil {
+		parent, parentKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	c, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %v", err)
+	}
+	return c, key
+}
+
+func newTestPKI(t *testing.T, leafNotAfter time.Time) *testPKI {
+	t.Helper()
+	now := time.Now()
+
+	root, rootKey := issue(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test Root"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(10 * 365 * 24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}, nil, nil)
+
+	inter, interKey := issue(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: "Test Intermediate"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(5 * 365 * 24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}, root, rootKey)
+
+	leaf, leafKey := issue(t, &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "api.example.com"},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     leafNotAfter,
+		DNSNames:     []string{"api.example.com", "*.svc.example.com"},
+		IPAddresses:  []net.IP{net.ParseIP("10.0.0.1")},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, inter, interKey)
+
+	return &testPKI{root: root, intermediate: inter, leaf: leaf, leafKey: leafKey}
+}
+
+func pemEncode(certs ...*x509.Certificate) []byte {
+	var sb strings.Builder
+	for _, c := range certs {
+		sb.Write(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Raw}))
+	}
+	return []byte(sb.String())
+}
+
+// TestParseBundle_PEMChainAndKey verifies certificates and keys are separated.
+func TestParseBundle_PEMChainAndKey(t *testing.T) {
+	pki := newTestPKI(t, time.Now().Add(90*24*time.Hour))
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(pki.leafKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := append(pemEncode(pki.leaf, pki.intermediate),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})...)
+
+	bundle, err := ParseBundle(data)
+	if err != nil {
+		t.Fatalf("ParseBundle failed: %v", err)
+	}
+	if len(bundle.Certificates) != 2 {
+		t.Errorf("expected 2 certificates, got %d", len(bundle.Certificates))
+	}
+	if len(bundle.Keys) != 1 {
+		t.Fatalf("expected 1 key, got %d", len(bundle.Keys))
+	}
+	key := bundle.Keys[0]
+	if !key.Private || key.Algorithm != "ECDSA" || key.Size != 256 {
+		t.Errorf("unexpected key summary: %+v", key)
+	}
+}
+
+// TestParseBundle_SkipsParameters verifies non-key blocks such as
+// EC PARAMETERS are noted rather than failing the bundle.
+func TestParseBundle_SkipsParameters(t *testing.T) {
+	pki := newTestPKI(t, time.Now().Add(90*24*time.Hour))
+
+	keyDER, err := x509.MarshalECPrivateKey(pki.leafKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	params := "-----BEGIN EC PARAMETERS-----\nBggqhkjOPQMBBw==\n-----END EC PARAMETERS-----\n"
+	data := append([]byte(params), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})...)
+	data = append(data, pemEncode(pki.leaf)...)
+
+	bundle, err := ParseBundle(data)
+	if err != nil {
+		t.Fatalf("ParseBundle failed: %v", err)
+	}
+	if len(bundle.Certificates) != 1 || len(bundle.Keys) != 1 {
+		t.Errorf("expected 1 certificate and 1 key, got %d and %d", len(bundle.Certificates), len(bundle.Keys))
+	}
+	if len(bundle.Skipped) != 1 || bundle.Skipped[0] != "EC PARAMETERS" {
+		t.Errorf("Skipped = %v", bundle.Skipped)
+	}
+}
+
+// TestParseBundle_EncryptedKeys verifies encrypted keys are listed without
+// being read, so a certificate and its key can still be inspected.
+func TestParseBundle_EncryptedKeys(t *testing.T) {
+	pki := newTestPKI(t, time.Now().Add(90*24*time.Hour))
+
+	data := pemEncode(pki.leaf)
+	data = append(data, pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: []byte("opaque")})...)
+	data = append(data, pem.EncodeToMemory(&pem.Block{
+		Type:    "RSA PRIVATE KEY",
+		Headers: map[string]string{"Proc-Type": "4,ENCRYPTED", "DEK-Info": "AES-128-CBC,00112233445566778899AABBCCDDEEFF"},
+		Bytes:   []byte("opaque"),
+	})...)
+
+	bundle, err := ParseBundle(data)
+	if err != nil {
+		t.Fatalf("ParseBundle failed: %v", err)
+	}
+	if len(bundle.Certificates) != 1 || len(bundle.Keys) != 2 {
+		t.Fatalf("expected 1 certificate and 2 keys, got %d and %d", len(bundle.Certificates), len(bundle.Keys))
+	}
+	for _, k := range bundle.Keys {
+		if !k.Private || k.Algorithm != "encrypted" {
+			t.Errorf("unexpected key summary: %+v", k)
+		}
+	}
+}
+
+// TestParseBundle_DER verifies raw DER certificates are accepted.
+func TestParseBundle_DER(t *testing.T) {
+	pki := newTestPKI(t, time.Now().Add(90*24*time.Hour))
+
+	bundle, err := ParseBundle(pki.leaf.Raw)
+	if err != nil {
+		t.Fatalf("ParseBundle failed: %v", err)
+	}
+	if len(bundle.Certificates) != 1 || bundle.Certificates[0].Subject.CommonName != "api.example.com" {
+		t.Errorf("unexpected DER parse result: %+v", bundle.Certificates)
+	}
+}
+
+// TestParseBundle_Errors verifies empty and malformed input is rejected.
+func TestParseBundle_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"empty", ""},
+		{"garbage", "not a certificate"},
+		{"bad pem body", "-----BEGIN CERTIFICATE-----\nAAAA\n-----END CERTIFICATE-----\n"},
+		{"unknown block", "-----BEGIN SOMETHING-----\nAAAA\n-----END SOMETHING-----\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseBundle([]byte(tt.data)); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
+
+// TestSummarize verifies summary fields and weak key warnings.
+func TestSummarize(t *testing.T) {
+	pki := newTestPKI(t, time.Now().Add(90*24*time.Hour))
+
+	s := Summarize(pki.leaf)
+	if s.KeyAlgorithm != "ECDSA" || s.KeySize != 256 {
+		t.Errorf("unexpected key algorithm: %s/%d", s.KeyAlgorithm, s.KeySize)
+	}
+	if len(s.DNSNames) != 2 || len(s.IPAddresses) != 1 || s.IPAddresses[0] != "10.0.0.1" {
+		t.Errorf("unexpected SANs: %v %v", s.DNSNames, s.IPAddresses)
+	}
+	if s.IsCA || s.SelfSigned {
+		t.Error("leaf should not be CA or self-signed")
+	}
+	if len(s.ExtKeyUsage) != 1 || s.ExtKeyUsage[0] != "server_auth" {
+		t.Errorf("unexpected ext key usage: %v", s.ExtKeyUsage)
+	}
+	if len(s.Warnings) != 0 {
+		t.Errorf("unexpected warnings: %v", s.Warnings)
+	}
+	if !Summarize(pki.root).SelfSigned {
+		t.Error("root should be self-signed")
+	}
+
+	weakKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(9),
+		Subject:      pkix.Name{CommonName: "weak"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{"weak.example.com"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &weakKey.PublicKey, weakKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	weak, _ := x509.ParseCertificate(der)
+	if ws := Summarize(weak).Warnings; len(ws) == 0 || !strings.Contains(ws[0], "weak RSA key") {
+		t.Errorf("expected weak RSA warning, got %v", ws)
+	}
+}
+
+// TestFingerprint verifies digest formatting.
+func TestFingerprint(t *testing.T) {
+	pki := newTestPKI(t, time.Now().Add(time.Hour))
+
+	sha256fp := Fingerprint(pki.leaf, "sha256")
+	if len(sha256fp) != 32*3-1 {
+		t.Errorf("unexpected SHA-256 fingerprint length: %d", len(sha256fp))
+	}
+	if sha256fp != strings.ToUpper(sha256fp) {
+		t.Error("fingerprint should be uppercase")
+	}
+	if fp := Fingerprint(pki.leaf, "sha1"); len(fp) != 20*3-1 {
+		t.Errorf("unexpected SHA-1 fingerprint length: %d", len(fp))
+	}
+}
+
+// TestCheckExpiry verifies status thresholds against the earliest expiry.
+func TestCheckExpiry(t *testing.T) {
+	now := time.Now()
+	pki := newTestPKI(t, now.Add(10*24*time.Hour))
+	certs := []*x509.Certificate{pki.leaf, pki.intermediate, pki.root}
+
+	tests := []struct {
+		name string
+		at   time.Time
+		warn time.Duration
+		want ExpiryStatus
+	}{
+		{"valid outside window", now, 5 * 24 * time.Hour, StatusValid},
+		{"expiring inside window", now, 30 * 24 * time.Hour, StatusExpiring},
+		{"expired", now.Add(11 * 24 * time.Hour), 0, StatusExpired},
+		{"not yet valid", now.Add(-2 * time.Hour), 0, StatusNotYetValid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := CheckExpiry(certs, tt.at, tt.warn)
+			if err != nil {
+				t.Fatalf("CheckExpiry failed: %v", err)
+			}
+			if result.Status != tt.want {
+				t.Errorf("status = %s, want %s", result.Status, tt.want)
+			}
+			if result.Subject != pki.leaf.Subject.String() {
+				t.Errorf("expected earliest expiry to be the leaf, got %s", result.Subject)
+			}
+		})
+	}
+
+	if _, err := CheckExpiry(nil, now, 0); err == nil {
+		t.Error("expected error for empty bundle")
+	}
+}
+
+// TestVerifyHost verifies SAN matching for DNS names, wildcards and IPs.
+func TestVerifyHost(t *testing.T) {
+	pki := newTestPKI(t, time.Now().Add(time.Hour))
+	certs := []*x509.Certificate{pki.leaf, pki.intermediate}
+
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{"api.example.com", true},
+		{"orders.svc.example.com", true},
+		{"10.0.0.1", true},
+		{"deep.orders.svc.example.com", false},
+		{"example.com", false},
+		{"10.0.0.2", false},
+	}
+	for _, tt := range tests {
+		result := VerifyHost(certs, tt.host)
+		if result.Valid != tt.want {
+			t.Errorf("VerifyHost(%q) = %v, want %v (errors: %v)", tt.host, result.Valid, tt.want, result.Errors)
+		}
+	}
+}
+
+// TestVerifyChain verifies chain building against a supplied root bundle.
+func TestVerifyChain(t *testing.T) {
+	pki := newTestPKI(t, time.Now().Add(time.Hour))
+
+	result, err := VerifyChain([]*x509.Certificate{pki.leaf, pki.intermediate}, ChainOptions{
+		Roots:   []*x509.Certificate{pki.root},
+		DNSName: "api.example.com",
+	})
+	if err != nil {
+		t.Fatalf("VerifyChain failed: %v", err)
+	}
+	if !result.Valid {
+		t.Fatalf("expected valid chain, got error: %s", result.Error)
+	}
+	if len(result.Chains) != 1 || len(result.Chains[0]) != 3 {
+		t.Fatalf("expected one chain of length 3, got %v", result.Chains)
+	}
+	if result.Chains[0][2].Subject != pki.root.Subject.String() {
+		t.Errorf("chain should end at the root, got %s", result.Chains[0][2].Subject)
+	}
+
+	// Missing intermediate
+	result, err = VerifyChain([]*x509.Certificate{pki.leaf}, ChainOptions{Roots: []*x509.Certificate{pki.root}})
+	if err != nil {
+		t.Fatalf("VerifyChain failed: %v", err)
+	}
+	if result.Valid {
+		t.Error("expected failure without the intermediate")
+	}
+
+	// Untrusted root
+	other := newTestPKI(t, time.Now().Add(time.Hour))
+	result, _ = VerifyChain([]*x509.Certificate{pki.leaf, pki.intermediate}, ChainOptions{Roots: []*x509.Certificate{other.root}})
+	if result.Valid {
+		t.Error("expected failure against an unrelated root")
+	}
+
+	if _, err := VerifyChain([]*x509.Certificate{pki.leaf}, ChainOptions{}); err == nil {
+		t.Error("expected error when no roots are supplied")
+	}
+}
+
+// TestOrderWarnings verifies misordered bundles are reported.
+func TestOrderWarnings(t *testing.T) {
+	pki := newTestPKI(t, time.Now().Add(time.Hour))
+
+	if ws := OrderWarnings([]*x509.Certificate{pki.leaf, pki.intermediate}); len(ws) != 0 {
+		t.Errorf("expected no warnings for ordered chain, got %v", ws)
+	}
+	if ws := OrderWarnings([]*x509.Certificate{pki.intermediate, pki.leaf}); len(ws) == 0 {
+		t.Error("expected warning for reversed chain")
+	}
+}
@@ -0,0 +1,103 @@
+package cert
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"os"
+)
+
+// CSRSummary is a safe-to-share description of a certificate request.
+type CSRSummary struct {
+	Subject            string   `json:"subject"`
+	DNSNames           []string `json:"dns_names,omitempty"`
+	IPAddresses        []string `json:"ip_addresses,omitempty"`
+	EmailAddresses     []string `json:"email_addresses,omitempty"`
+	URIs               []string `json:"uris,omitempty"`
+	KeyAlgorithm       string   `json:"key_algorithm"`
+	KeySize            int      `json:"key_size,omitempty"`
+	SignatureAlgorithm string   `json:"signature_algorithm"`
+	SignatureValid     bool     `json:"signature_valid"`
+}
+
+// CreateCSR builds a PKCS#10 certificate request signed by key.
+func CreateCSR(key crypto.Signer, commonName string, sans []string) (*x509.CertificateRequest, error) {
+	holder := &x509.Certificate{}
+	if err := applySANs(holder, sans); err != nil {
+		return nil, err
+	}
+	if commonName == "" && len(holder.DNSNames) > 0 {
+		commonName = holder.DNSNames[0]
+	}
+
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:        pkix.Name{CommonName: commonName},
+		DNSNames:       holder.DNSNames,
+		IPAddresses:    holder.IPAddresses,
+		EmailAddresses: holder.EmailAddresses,
+		URIs:           holder.URIs,
+	}, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create certificate request: %w", err)
+	}
+	return x509.ParseCertificateRequest(der)
+}
+
+// WriteCSR writes a certificate request as PEM. Existing files are never overwritten.
+func WriteCSR(path string, csr *x509.CertificateRequest) error {
+	return writePEM(path, 0644, &pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csr.Raw})
+}
+
+// LoadCSR reads a PEM or DER certificate request.
+func LoadCSR(path string) (*x509.CertificateRequest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	csr, err := ParseCSR(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return csr, nil
+}
+
+// ParseCSR parses a PEM or DER certificate request.
+func ParseCSR(data []byte) (*x509.CertificateRequest, error) {
+	der := data
+	if block, _ := pem.Decode(data); block != nil {
+		if block.Type != "CERTIFICATE REQUEST" && block.Type != "NEW CERTIFICATE REQUEST" {
+			return nil, fmt.Errorf("expected a CERTIFICATE REQUEST PEM block, got %q", block.Type)
+		}
+		der = block.Bytes
+	}
+
+	csr, err := x509.ParseCertificateRequest(der)
+	if err != nil {
+		return nil, fmt.Errorf("invalid certificate request: %w", err)
+	}
+	return csr, nil
+}
+
+// SummarizeCSR builds a CSRSummary, checking the request's self-signature.
+func SummarizeCSR(csr *x509.CertificateRequest) CSRSummary {
+	alg, size := KeyAlgorithm(csr.PublicKey)
+	s := CSRSummary{
+		Subject:            csr.Subject.String(),
+		DNSNames:           csr.DNSNames,
+		EmailAddresses:     csr.EmailAddresses,
+		KeyAlgorithm:       alg,
+		KeySize:            size,
+		SignatureAlgorithm: csr.SignatureAlgorithm.String(),
+		SignatureValid:     csr.CheckSignature() == nil,
+	}
+	for _, ip := range csr.IPAddresses {
+		s.IPAddresses = append(s.IPAddresses, ip.String())
+	}
+	for _, u := range csr.URIs {
+		s.URIs = append(s.URIs, u.String())
+	}
+	return s
+}
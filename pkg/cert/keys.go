@@ -0,0 +1,137 @@
+package cert
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// KeyType names a key algorithm accepted by GenerateKey.
+type KeyType string
+
+const (
+	KeyECDSAP256 KeyType = "ecdsa"
+	KeyECDSAP384 KeyType = "ecdsa-p384"
+	KeyRSA2048   KeyType = "rsa"
+	KeyRSA4096   KeyType = "rsa-4096"
+	KeyEd25519   KeyType = "ed25519"
+)
+
+// ParseKeyType converts a flag value into a KeyType. Empty selects ECDSA P-256.
+func ParseKeyType(s string) (KeyType, error) {
+	switch KeyType(strings.ToLower(strings.TrimSpace(s))) {
+	case "", KeyECDSAP256, "ecdsa-p256", "ec":
+		return KeyECDSAP256, nil
+	case KeyECDSAP384:
+		return KeyECDSAP384, nil
+	case KeyRSA2048, "rsa-2048":
+		return KeyRSA2048, nil
+	case KeyRSA4096:
+		return KeyRSA4096, nil
+	case KeyEd25519:
+		return KeyEd25519, nil
+	default:
+		return "", fmt.Errorf("unsupported key type: %s (use ecdsa, ecdsa-p384, rsa, rsa-4096 or ed25519)", s)
+	}
+}
+
+// GenerateKey creates a new private key of the given type.
+func GenerateKey(kt KeyType) (crypto.Signer, error) {
+	switch kt {
+	case KeyECDSAP256, "":
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case KeyECDSAP384:
+		return ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case KeyRSA2048:
+		return rsa.GenerateKey(rand.Reader, 2048)
+	case KeyRSA4096:
+		return rsa.GenerateKey(rand.Reader, 4096)
+	case KeyEd25519:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+	default:
+		return nil, fmt.Errorf("unsupported key type: %s", kt)
+	}
+}
+
+// WriteKey writes a private key as PKCS#8 PEM with 0600 permissions.
+// Existing files are never overwritten.
+func WriteKey(path string, key crypto.Signer) error {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return fmt.Errorf("failed to encode private key: %w", err)
+	}
+	return writePEM(path, 0600, &pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+// WriteCertificates writes certificates as a PEM bundle in the given order.
+// Existing files are never overwritten.
+func WriteCertificates(path string, certs ...*x509.Certificate) error {
+	blocks := make([]*pem.Block, len(certs))
+	for i, c := range certs {
+		blocks[i] = &pem.Block{Type: "CERTIFICATE", Bytes: c.Raw}
+	}
+	return writePEM(path, 0644, blocks...)
+}
+
+// writePEM creates path exclusively so keys and certificates are never
+// silently replaced, and creates the parent directory with 0700.
+func writePEM(path string, perm os.FileMode, blocks ...*pem.Block) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", path, err)
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		if os.IsExist(err) {
+			return fmt.Errorf("%s already exists", path)
+		}
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	for _, b := range blocks {
+		if err := pem.Encode(f, b); err != nil {
+			f.Close()
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+	return f.Close()
+}
+
+// LoadSigner reads a PEM private key (PKCS#1, PKCS#8 or SEC 1) usable for signing.
+func LoadSigner(path string) (crypto.Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file %s: %w", path, err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: invalid PEM format", path)
+	}
+
+	var key interface{}
+	if k, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		key = k
+	} else if k, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		key = k
+	} else if k, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		key = k
+	} else {
+		return nil, fmt.Errorf("%s: unable to parse private key (supported formats: PKCS1, PKCS8, EC)", path)
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("%s: key type %T cannot sign", path, key)
+	}
+	return signer, nil
+}
@@ -0,0 +1,166 @@
+package cert
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Summary is a safe-to-share description of a certificate. It omits
+// extension payloads other than SANs, usages and basic constraints.
+type Summary struct {
+	Subject            string    `json:"subject"`
+	Issuer             string    `json:"issuer"`
+	Serial             string    `json:"serial"`
+	NotBefore          time.Time `json:"not_before"`
+	NotAfter           time.Time `json:"not_after"`
+	DNSNames           []string  `json:"dns_names,omitempty"`
+	IPAddresses        []string  `json:"ip_addresses,omitempty"`
+	EmailAddresses     []string  `json:"email_addresses,omitempty"`
+	URIs               []string  `json:"uris,omitempty"`
+	KeyAlgorithm       string    `json:"key_algorithm"`
+	KeySize            int       `json:"key_size,omitempty"`
+	SignatureAlgorithm string    `json:"signature_algorithm"`
+	IsCA               bool      `json:"is_ca"`
+	SelfSigned         bool      `json:"self_signed"`
+	KeyUsage           []string  `json:"key_usage,omitempty"`
+	ExtKeyUsage        []string  `json:"ext_key_usage,omitempty"`
+	SHA256             string    `json:"sha256"`
+	Warnings           []string  `json:"warnings,omitempty"`
+}
+
+// Summarize builds a Summary for a certificate, including weak-key and
+// deprecated-algorithm warnings.
+func Summarize(c *x509.Certificate) Summary {
+	alg, size := KeyAlgorithm(c.PublicKey)
+	s := Summary{
+		Subject:            c.Subject.String(),
+		Issuer:             c.Issuer.String(),
+		Serial:             formatSerial(c),
+		NotBefore:          c.NotBefore.UTC(),
+		NotAfter:           c.NotAfter.UTC(),
+		DNSNames:           c.DNSNames,
+		EmailAddresses:     c.EmailAddresses,
+		KeyAlgorithm:       alg,
+		KeySize:            size,
+		SignatureAlgorithm: c.SignatureAlgorithm.String(),
+		IsCA:               c.IsCA,
+		SelfSigned:         IsSelfSigned(c),
+		KeyUsage:           keyUsageNames(c.KeyUsage),
+		ExtKeyUsage:        extKeyUsageNames(c.ExtKeyUsage),
+		SHA256:             Fingerprint(c, "sha256"),
+	}
+	for _, ip := range c.IPAddresses {
+		s.IPAddresses = append(s.IPAddresses, ip.String())
+	}
+	for _, u := range c.URIs {
+		s.URIs = append(s.URIs, u.String())
+	}
+
+	switch {
+	case alg == "RSA" && size < 2048:
+		s.Warnings = append(s.Warnings, fmt.Sprintf("weak RSA key (%d bits, minimum 2048)", size))
+	case alg == "ECDSA" && size < 256:
+		s.Warnings = append(s.Warnings, fmt.Sprintf("weak ECDSA key (%d bits, minimum 256)", size))
+	}
+	switch c.SignatureAlgorithm {
+	case x509.MD5WithRSA, x509.SHA1WithRSA, x509.ECDSAWithSHA1, x509.DSAWithSHA1:
+		s.Warnings = append(s.Warnings, fmt.Sprintf("deprecated signature algorithm %s", c.SignatureAlgorithm))
+	}
+	if !c.IsCA && len(c.DNSNames) == 0 && len(c.IPAddresses) == 0 && c.Subject.CommonName != "" {
+		s.Warnings = append(s.Warnings, "no SANs; hostname verification ignores the common name")
+	}
+	return s
+}
+
+// IsSelfSigned reports whether a certificate's issuer matches its subject
+// and its signature verifies with its own key.
+func IsSelfSigned(c *x509.Certificate) bool {
+	if !bytes.Equal(c.RawIssuer, c.RawSubject) {
+		return false
+	}
+	return c.CheckSignatureFrom(c) == nil
+}
+
+// Fingerprint returns the colon-separated uppercase hex digest of a
+// certificate's DER encoding. Supported algorithms are "sha256" and "sha1".
+func Fingerprint(c *x509.Certificate, alg string) string {
+	var sum []byte
+	switch strings.ToLower(alg) {
+	case "sha1":
+		d := sha1.Sum(c.Raw)
+		sum = d[:]
+	default:
+		d := sha256.Sum256(c.Raw)
+		sum = d[:]
+	}
+	return colonHex(sum)
+}
+
+func colonHex(b []byte) string {
+	parts := make([]string, len(b))
+	for i, v := range b {
+		parts[i] = fmt.Sprintf("%02X", v)
+	}
+	return strings.Join(parts, ":")
+}
+
+func formatSerial(c *x509.Certificate) string {
+	if c.SerialNumber == nil {
+		return ""
+	}
+	return colonHex(c.SerialNumber.Bytes())
+}
+
+func keyUsageNames(ku x509.KeyUsage) []string {
+	names := []struct {
+		bit  x509.KeyUsage
+		name string
+	}{
+		{x509.KeyUsageDigitalSignature, "digital_signature"},
+		{x509.KeyUsageContentCommitment, "content_commitment"},
+		{x509.KeyUsageKeyEncipherment, "key_encipherment"},
+		{x509.KeyUsageDataEncipherment, "data_encipherment"},
+		{x509.KeyUsageKeyAgreement, "key_agreement"},
+		{x509.KeyUsageCertSign, "cert_sign"},
+		{x509.KeyUsageCRLSign, "crl_sign"},
+		{x509.KeyUsageEncipherOnly, "encipher_only"},
+		{x509.KeyUsageDecipherOnly, "decipher_only"},
+	}
+	var out []string
+	for _, n := range names {
+		if ku&n.bit != 0 {
+			out = append(out, n.name)
+		}
+	}
+	return out
+}
+
+func extKeyUsageNames(usages []x509.ExtKeyUsage) []string {
+	var out []string
+	for _, u := range usages {
+		switch u {
+		case x509.ExtKeyUsageAny:
+			out = append(out, "any")
+		case x509.ExtKeyUsageServerAuth:
+			out = append(out, "server_auth")
+		case x509.ExtKeyUsageClientAuth:
+			out = append(out, "client_auth")
+		case x509.ExtKeyUsageCodeSigning:
+			out = append(out, "code_signing")
+		case x509.ExtKeyUsageEmailProtection:
+			out = append(out, "email_protection")
+		case x509.ExtKeyUsageTimeStamping:
+			out = append(out, "time_stamping")
+		case x509.ExtKeyUsageOCSPSigning:
+			out = append(out, "ocsp_signing")
+		default:
+			out = append(out, fmt.Sprintf("unknown(%d)", u))
+		}
+	}
+	return out
+}
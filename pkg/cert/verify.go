@@ -0,0 +1,232 @@
+package cert
+
+import (
+	"bytes"
+	"crypto/x509"
+	"fmt"
+	"math"
+	"time"
+)
+
+// ExpiryStatus describes where a bundle sits in its validity window.
+type ExpiryStatus string
+
+const (
+	StatusValid       ExpiryStatus = "valid"
+	StatusExpiring    ExpiryStatus = "expiring"
+	StatusExpired     ExpiryStatus = "expired"
+	StatusNotYetValid ExpiryStatus = "not_yet_valid"
+)
+
+// ExpiryResult reports the earliest expiry in a bundle.
+type ExpiryResult struct {
+	Status        ExpiryStatus `json:"status"`
+	Subject       string       `json:"subject"`
+	NotBefore     time.Time    `json:"not_before"`
+	NotAfter      time.Time    `json:"not_after"`
+	DaysRemaining int          `json:"days_remaining"`
+	WarnDays      int          `json:"warn_days"`
+	Checked       time.Time    `json:"checked"`
+}
+
+// CheckExpiry finds the certificate with the earliest NotAfter and classifies
+// the bundle at time now. A bundle is "expiring" when the earliest expiry is
+// within warn of now, and "not_yet_valid" when any certificate starts later.
+func CheckExpiry(certs []*x509.Certificate, now time.Time, warn time.Duration) (*ExpiryResult, error) {
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificates to check")
+	}
+
+	earliest := certs[0]
+	notYetValid := false
+	for _, c := range certs {
+		if c.NotAfter.Before(earliest.NotAfter) {
+			earliest = c
+		}
+		if now.Before(c.NotBefore) {
+			notYetValid = true
+		}
+	}
+
+	remaining := earliest.NotAfter.Sub(now)
+	result := &ExpiryResult{
+		Status:        StatusValid,
+		Subject:       earliest.Subject.String(),
+		NotBefore:     earliest.NotBefore.UTC(),
+		NotAfter:      earliest.NotAfter.UTC(),
+		DaysRemaining: int(math.Floor(remaining.Hours() / 24)),
+		WarnDays:      int(warn.Hours() / 24),
+		Checked:       now.UTC(),
+	}
+
+	switch {
+	case remaining <= 0:
+		result.Status = StatusExpired
+	case notYetValid:
+		result.Status = StatusNotYetValid
+	case remaining <= warn:
+		result.Status = StatusExpiring
+	}
+	return result, nil
+}
+
+// HostResult reports whether any certificate in a bundle covers a hostname.
+type HostResult struct {
+	Host    string   `json:"host"`
+	Valid   bool     `json:"valid"`
+	Subject string   `json:"subject,omitempty"`
+	Names   []string `json:"names,omitempty"`
+	Errors  []string `json:"errors,omitempty"`
+}
+
+// VerifyHost checks each non-CA certificate (or every certificate when all
+// are CAs) against host using crypto/x509 hostname rules. Host may be a DNS
+// name or an IP address.
+func VerifyHost(certs []*x509.Certificate, host string) *HostResult {
+	result := &HostResult{Host: host}
+
+	candidates := make([]*x509.Certificate, 0, len(certs))
+	for _, c := range certs {
+		if !c.IsCA {
+			candidates = append(candidates, c)
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = certs
+	}
+
+	for _, c := range candidates {
+		if err := c.VerifyHostname(host); err != nil {
+			result.Errors = append(result.Errors, err.Error())
+			continue
+		}
+		result.Valid = true
+		result.Subject = c.Subject.String()
+		result.Names = c.DNSNames
+		for _, ip := range c.IPAddresses {
+			result.Names = append(result.Names, ip.String())
+		}
+		result.Errors = nil
+		break
+	}
+	return result
+}
+
+// ChainOptions controls chain verification.
+type ChainOptions struct {
+	// Roots are trusted anchors. When empty, SystemRoots must be set.
+	Roots []*x509.Certificate
+	// Intermediates are extra untrusted certificates used for path building.
+	Intermediates []*x509.Certificate
+	// SystemRoots adds the platform trust store to Roots.
+	SystemRoots bool
+	// DNSName, when set, is also verified against the leaf.
+	DNSName string
+	// At is the verification time; zero means now.
+	At time.Time
+	// KeyUsages restricts acceptable extended key usages; empty accepts any.
+	KeyUsages []x509.ExtKeyUsage
+}
+
+// ChainLink is one certificate in a verified chain.
+type ChainLink struct {
+	Subject  string    `json:"subject"`
+	Issuer   string    `json:"issuer"`
+	NotAfter time.Time `json:"not_after"`
+	IsCA     bool      `json:"is_ca"`
+	SHA256   string    `json:"sha256"`
+}
+
+// ChainResult reports the outcome of chain building.
+type ChainResult struct {
+	Valid    bool          `json:"valid"`
+	Leaf     string        `json:"leaf"`
+	Chains   [][]ChainLink `json:"chains,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Warnings []string      `json:"warnings,omitempty"`
+}
+
+// VerifyChain treats the first certificate in certs as the leaf and the rest
+// as intermediates, then builds chains to the supplied roots.
+func VerifyChain(certs []*x509.Certificate, opts ChainOptions) (*ChainResult, error) {
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificates to verify")
+	}
+
+	var roots *x509.CertPool
+	if opts.SystemRoots {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load system roots: %w", err)
+		}
+		roots = pool
+	} else {
+		if len(opts.Roots) == 0 {
+			return nil, fmt.Errorf("a root bundle is required (or enable system roots)")
+		}
+		roots = x509.NewCertPool()
+	}
+	for _, r := range opts.Roots {
+		roots.AddCert(r)
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, c := range certs[1:] {
+		intermediates.AddCert(c)
+	}
+	for _, c := range opts.Intermediates {
+		intermediates.AddCert(c)
+	}
+
+	usages := opts.KeyUsages
+	if len(usages) == 0 {
+		usages = []x509.ExtKeyUsage{x509.ExtKeyUsageAny}
+	}
+
+	leaf := certs[0]
+	result := &ChainResult{Leaf: leaf.Subject.String(), Warnings: OrderWarnings(certs)}
+
+	chains, err := leaf.Verify(x509.VerifyOptions{
+		DNSName:       opts.DNSName,
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   opts.At,
+		KeyUsages:     usages,
+	})
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+
+	result.Valid = true
+	for _, chain := range chains {
+		links := make([]ChainLink, len(chain))
+		for i, c := range chain {
+			links[i] = ChainLink{
+				Subject:  c.Subject.String(),
+				Issuer:   c.Issuer.String(),
+				NotAfter: c.NotAfter.UTC(),
+				IsCA:     c.IsCA,
+				SHA256:   Fingerprint(c, "sha256"),
+			}
+		}
+		result.Chains = append(result.Chains, links)
+	}
+	return result, nil
+}
+
+// OrderWarnings reports places where a bundle is not ordered leaf-first with
+// each certificate issued by the next, which some TLS stacks reject.
+func OrderWarnings(certs []*x509.Certificate) []string {
+	var warnings []string
+	for i := 0; i+1 < len(certs); i++ {
+		if !bytes.Equal(certs[i].RawIssuer, certs[i+1].RawSubject) {
+			warnings = append(warnings, fmt.Sprintf("certificate %d (%s) is not issued by certificate %d (%s)",
+				i+1, certs[i].Subject.CommonName, i+2, certs[i+1].Subject.CommonName))
+		}
+	}
+	if len(certs) > 1 && IsSelfSigned(certs[len(certs)-1]) {
+		warnings = append(warnings, "bundle includes a self-signed root; servers usually omit it")
+	}
+	return warnings
+}
@@ -0,0 +1,794 @@
+package decide
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Limits that keep a typo from rolling (or enumerating) forever.
+const (
+	maxDice       = 1000
+	maxSides      = 1000000
+	maxExplosions = 100
+	maxRerolls    = 100
+	// maxOutcomes bounds the support of an exact distribution.
+	maxOutcomes = 100000
+	// maxKeepWork bounds the dynamic programme behind keep/drop stats.
+	maxKeepWork = 50000000
+)
+
+// Die is one die in a roll. An exploding die adds every extra roll to its
+// value, so it stays a single die for keep and drop.
+type Die struct {
+	Value int `json:"value"`
+	// Rolls holds the individual rolls of an exploded die.
+	Rolls []int `json:"rolls,omitempty"`
+	// Rerolled holds the values that were discarded by rerolls.
+	Rerolled []int `json:"rerolled,omitempty"`
+	Dropped  bool  `json:"dropped,omitempty"`
+}
+
+// DiceTerm is the outcome of one dice group, e.g. the 4d6kh3 in 4d6kh3+2.
+type DiceTerm struct {
+	Notation string `json:"notation"`
+	Dice     []Die  `json:"dice"`
+	Total    int    `json:"total"`
+}
+
+// DiceRoll is the outcome of rolling a whole expression.
+type DiceRoll struct {
+	Expression string     `json:"expression"`
+	Terms      []DiceTerm `json:"terms"`
+	// Detail shows the expression with each dice group replaced by its dice,
+	// e.g. "[6, 5, 3, ~1~] + 2".
+	Detail string `json:"detail"`
+	Total  int    `json:"total"`
+}
+
+// Outcome is one possible total with its probability.
+type Outcome struct {
+	Total       int     `json:"total"`
+	Probability float64 `json:"probability"`
+	// AtLeast is the probability of rolling this total or more.
+	AtLeast float64 `json:"at_least"`
+}
+
+// DiceStats is the exact distribution of an expression.
+type DiceStats struct {
+	Expression   string    `json:"expression"`
+	Min          int       `json:"min"`
+	Max          int       `json:"max"`
+	Mean         float64   `json:"mean"`
+	StdDev       float64   `json:"std_dev"`
+	Distribution []Outcome `json:"distribution"`
+}
+
+// DiceExpr is a parsed dice expression.
+//
+// Grammar (case-insensitive, spaces ignored):
+//
+//	expr     = term { ("+" | "-") term }
+//	term     = unary { ("*" | "/") unary }
+//	unary    = "-" unary | atom
+//	atom     = number | dice | "(" expr ")"
+//	dice     = [count] "d" (sides | "%" | "F") { modifier }
+//	modifier = "!"                        explode on the highest face
+//	         | "r" [ "o" ] [ "<" | ">" | "=" ] n   reroll (once with "o")
+//	         | "kh" [n] | "kl" [n] | "k" [n]       keep highest/lowest
+//	         | "dh" [n] | "dl" [n]                 drop highest/lowest
+//
+// Division is integer division rounding toward zero.
+type DiceExpr struct {
+	source string
+	root   diceNode
+}
+
+// ParseDice parses a dice expression such as "4d6kh3+2" or "2d20kl1".
+func ParseDice(expr string) (*DiceExpr, error) {
+	src := strings.ToLower(strings.Join(strings.Fields(expr), ""))
+	if src == "" {
+		return nil, fmt.Errorf("empty dice expression")
+	}
+	p := &diceParser{src: src}
+	root, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.src) {
+		return nil, p.errorf("unexpected %q", p.src[p.pos])
+	}
+	return &DiceExpr{source: src, root: root}, nil
+}
+
+// String returns the normalised expression.
+func (e *DiceExpr) String() string {
+	return e.source
+}
+
+// Roll rolls the expression.
+func (e *DiceExpr) Roll(rng RNG) (*DiceRoll, error) {
+	r := &DiceRoll{Expression: e.source}
+	total, detail, err := e.root.roll(rng, r)
+	if err != nil {
+		return nil, err
+	}
+	r.Total = total
+	r.Detail = detail
+	return r, nil
+}
+
+// Stats computes the exact distribution of the expression by convolution.
+func (e *DiceExpr) Stats() (*DiceStats, error) {
+	d, err := e.root.dist()
+	if err != nil {
+		return nil, err
+	}
+	totals := make([]int, 0, len(d))
+	for v := range d {
+		totals = append(totals, v)
+	}
+	sort.Ints(totals)
+
+	s := &DiceStats{Expression: e.source, Min: totals[0], Max: totals[len(totals)-1]}
+	for _, v := range totals {
+		s.Mean += float64(v) * d[v]
+	}
+	for _, v := range totals {
+		diff := float64(v) - s.Mean
+		s.StdDev += diff * diff * d[v]
+	}
+	s.StdDev = math.Sqrt(s.StdDev)
+
+	s.Distribution = make([]Outcome, len(totals))
+	atLeast := 1.0
+	for i, v := range totals {
+		s.Distribution[i] = Outcome{Total: v, Probability: d[v], AtLeast: math.Max(atLeast, 0)}
+		atLeast -= d[v]
+	}
+	return s, nil
+}
+
+// dist maps each total to its probability.
+type dist map[int]float64
+
+type diceNode interface {
+	roll(rng RNG, r *DiceRoll) (int, string, error)
+	dist() (dist, error)
+}
+
+type numNode struct{ v int }
+
+func (n numNode) roll(RNG, *DiceRoll) (int, string, error) { return n.v, strconv.Itoa(n.v), nil }
+func (n numNode) dist() (dist, error)                      { return dist{n.v: 1}, nil }
+
+type negNode struct{ x diceNode }
+
+func (n negNode) roll(rng RNG, r *DiceRoll) (int, string, error) {
+	v, s, err := n.x.roll(rng, r)
+	return -v, "-" + s, err
+}
+
+func (n negNode) dist() (dist, error) {
+	d, err := n.x.dist()
+	if err != nil {
+		return nil, err
+	}
+	out := make(dist, len(d))
+	for v, p := range d {
+		out[-v] = p
+	}
+	return out, nil
+}
+
+type parenNode struct{ x diceNode }
+
+func (n parenNode) roll(rng RNG, r *DiceRoll) (int, string, error) {
+	v, s, err := n.x.roll(rng, r)
+	return v, "(" + s + ")", err
+}
+
+func (n parenNode) dist() (dist, error) { return n.x.dist() }
+
+type binNode struct {
+	op   byte
+	l, r diceNode
+}
+
+func applyOp(op byte, a, b int) (int, error) {
+	switch op {
+	case '+':
+		return a + b, nil
+	case '-':
+		return a - b, nil
+	case '*':
+		return a * b, nil
+	default:
+		if b == 0 {
+			return 0, fmt.Errorf("division by zero")
+		}
+		return a / b, nil
+	}
+}
+
+func (n binNode) roll(rng RNG, r *DiceRoll) (int, string, error) {
+	a, as, err := n.l.roll(rng, r)
+	if err != nil {
+		return 0, "", err
+	}
+	b, bs, err := n.r.roll(rng, r)
+	if err != nil {
+		return 0, "", err
+	}
+	v, err := applyOp(n.op, a, b)
+	return v, as + " " + string(n.op) + " " + bs, err
+}
+
+func (n binNode) dist() (dist, error) {
+	a, err := n.l.dist()
+	if err != nil {
+		return nil, err
+	}
+	b, err := n.r.dist()
+	if err != nil {
+		return nil, err
+	}
+	if n.op == '/' && b[0] > 0 {
+		return nil, fmt.Errorf("division by zero is possible")
+	}
+	out := make(dist)
+	for x, px := range a {
+		for y, py := range b {
+			v, _ := applyOp(n.op, x, y)
+			out[v] += px * py
+		}
+		if len(out) > maxOutcomes {
+			return nil, fmt.Errorf("distribution has more than %d outcomes", maxOutcomes)
+		}
+	}
+	return out, nil
+}
+
+// compare matches face values for rerolls.
+type compare struct {
+	op byte
+	v  int
+}
+
+func (c compare) match(x int) bool {
+	switch c.op {
+	case '<':
+		return x <= c.v
+	case '>':
+		return x >= c.v
+	default:
+		return x == c.v
+	}
+}
+
+type rollNode struct {
+	notation   string
+	count      int
+	sides      int
+	fate       bool
+	explode    bool
+	reroll     *compare
+	rerollOnce bool
+	// keep is the number of dice kept; zero keeps them all.
+	keep        int
+	keepHighest bool
+}
+
+func (n *rollNode) faces() []int {
+	if n.fate {
+		return []int{-1, 0, 1}
+	}
+	faces := make([]int, n.sides)
+	for i := range faces {
+		faces[i] = i + 1
+	}
+	return faces
+}
+
+func (n *rollNode) face(rng RNG) (int, error) {
+	if n.fate {
+		v, err := rng.Intn(3)
+		return v - 1, err
+	}
+	v, err := rng.Intn(n.sides)
+	return v + 1, err
+}
+
+// rollOnce rolls one face, applying rerolls.
+func (n *rollNode) rollOnce(rng RNG, die *Die) (int, error) {
+	v, err := n.face(rng)
+	if err != nil {
+		return 0, err
+	}
+	for i := 0; n.reroll != nil && n.reroll.match(v) && i < maxRerolls; i++ {
+		die.Rerolled = append(die.Rerolled, v)
+		if v, err = n.face(rng); err != nil {
+			return 0, err
+		}
+		if n.rerollOnce {
+			break
+		}
+	}
+	return v, nil
+}
+
+func (n *rollNode) rollDie(rng RNG) (Die, error) {
+	var die Die
+	top := n.faces()[len(n.faces())-1]
+	for i := 0; ; i++ {
+		v, err := n.rollOnce(rng, &die)
+		if err != nil {
+			return die, err
+		}
+		die.Value += v
+		die.Rolls = append(die.Rolls, v)
+		if !n.explode || v != top || i == maxExplosions {
+			break
+		}
+	}
+	if len(die.Rolls) == 1 {
+		die.Rolls = nil
+	}
+	return die, nil
+}
+
+func (n *rollNode) roll(rng RNG, r *DiceRoll) (int, string, error) {
+	term := DiceTerm{Notation: n.notation, Dice: make([]Die, n.count)}
+	for i := range term.Dice {
+		die, err := n.rollDie(rng)
+		if err != nil {
+			return 0, "", err
+		}
+		term.Dice[i] = die
+	}
+
+	if n.keep > 0 {
+		order := make([]int, n.count)
+		for i := range order {
+			order[i] = i
+		}
+		sort.SliceStable(order, func(a, b int) bool {
+			if n.keepHighest {
+				return term.Dice[order[a]].Value > term.Dice[order[b]].Value
+			}
+			return term.Dice[order[a]].Value < term.Dice[order[b]].Value
+		})
+		for _, i := range order[n.keep:] {
+			term.Dice[i].Dropped = true
+		}
+	}
+
+	parts := make([]string, len(term.Dice))
+	for i, d := range term.Dice {
+		if !d.Dropped {
+			term.Total += d.Value
+		}
+		parts[i] = formatDie(d, n.fate)
+	}
+	r.Terms = append(r.Terms, term)
+	return term.Total, "[" + strings.Join(parts, ", ") + "]", nil
+}
+
+// formatDie renders a die for the detail line: "1→4" for a reroll, "6!+3"
+// for an explosion and "~2~" when dropped.
+func formatDie(d Die, fate bool) string {
+	show := strconv.Itoa
+	if fate {
+		show = func(v int) string {
+			if v > 0 {
+				return "+" + strconv.Itoa(v)
+			}
+			return strconv.Itoa(v)
+		}
+	}
+	var s string
+	for _, v := range d.Rerolled {
+		s += show(v) + "→"
+	}
+	if len(d.Rolls) > 0 {
+		rolls := make([]string, len(d.Rolls))
+		for i, v := range d.Rolls {
+			rolls[i] = show(v)
+		}
+		s += strings.Join(rolls, "!+")
+	} else {
+		s += show(d.Value)
+	}
+	if d.Dropped {
+		return "~" + s + "~"
+	}
+	return s
+}
+
+// faceDist is the distribution of a single roll after rerolls.
+func (n *rollNode) faceDist() dist {
+	faces := n.faces()
+	p := 1 / float64(len(faces))
+	out := make(dist, len(faces))
+	if n.reroll == nil {
+		for _, f := range faces {
+			out[f] = p
+		}
+		return out
+	}
+
+	var kept []int
+	for _, f := range faces {
+		if !n.reroll.match(f) {
+			kept = append(kept, f)
+		}
+	}
+	rerolled := p * float64(len(faces)-len(kept))
+	for _, f := range faces {
+		switch {
+		case n.rerollOnce:
+			// Kept first time, or rerolled into this face.
+			if !n.reroll.match(f) {
+				out[f] += p
+			}
+			out[f] += rerolled * p
+		case !n.reroll.match(f):
+			// Rerolling until no match is the same as never rolling a match.
+			out[f] = 1 / float64(len(kept))
+		}
+	}
+	return out
+}
+
+// dieDist is the distribution of one die, including explosions. Chains so
+// unlikely that their probability is below 1e-15 are left out.
+func (n *rollNode) dieDist() dist {
+	q := n.faceDist()
+	if !n.explode {
+		return q
+	}
+	faces := n.faces()
+	top := faces[len(faces)-1]
+	pTop := q[top]
+
+	out := make(dist)
+	weight := 1.0
+	for k := 0; k <= maxExplosions && weight > 1e-15; k++ {
+		for v, p := range q {
+			if v != top || k == maxExplosions {
+				out[v+k*top] += weight * p
+			}
+		}
+		weight *= pTop
+	}
+	return out
+}
+
+func (n *rollNode) dist() (dist, error) {
+	die := n.dieDist()
+	if n.keep == 0 {
+		out := dist{0: 1}
+		for i := 0; i < n.count; i++ {
+			out = convolve(out, die)
+			if len(out) > maxOutcomes {
+				return nil, fmt.Errorf("distribution has more than %d outcomes", maxOutcomes)
+			}
+		}
+		return out, nil
+	}
+	return n.keepDist(die)
+}
+
+// keepDist walks the face values from best to worst, choosing how many of
+// the remaining dice show each value. The first keep dice seen are the
+// kept ones, so the sum of kept dice is exact without enumerating every
+// combination of rolls.
+func (n *rollNode) keepDist(die dist) (dist, error) {
+	values := make([]int, 0, len(die))
+	for v, p := range die {
+		if p > 0 {
+			values = append(values, v)
+		}
+	}
+	sort.Ints(values)
+	if n.keepHighest {
+		for i, j := 0, len(values)-1; i < j; i, j = i+1, j-1 {
+			values[i], values[j] = values[j], values[i]
+		}
+	}
+
+	span := values[0] - values[len(values)-1]
+	if span < 0 {
+		span = -span
+	}
+	if work := len(values) * n.count * n.count * (n.keep*span + 1); work > maxKeepWork {
+		return nil, fmt.Errorf("too many dice to compute %s exactly", n.notation)
+	}
+
+	// dp[m] is the distribution of the kept sum after m dice are placed.
+	dp := make([]dist, n.count+1)
+	dp[0] = dist{0: 1}
+	for _, v := range values {
+		p := die[v]
+		next := make([]dist, n.count+1)
+		for m, sums := range dp {
+			if sums == nil {
+				continue
+			}
+			left := n.count - m
+			for j := 0; j <= left; j++ {
+				w := binomial(left, j) * math.Pow(p, float64(j))
+				if w == 0 {
+					continue
+				}
+				kept := n.keep - m
+				if kept < 0 {
+					kept = 0
+				}
+				if kept > j {
+					kept = j
+				}
+				if next[m+j] == nil {
+					next[m+j] = make(dist)
+				}
+				for s, ps := range sums {
+					next[m+j][s+kept*v] += ps * w
+				}
+			}
+		}
+		dp = next
+	}
+	return dp[n.count], nil
+}
+
+func convolve(a, b dist) dist {
+	out := make(dist, len(a)+len(b))
+	for x, px := range a {
+		for y, py := range b {
+			out[x+y] += px * py
+		}
+	}
+	return out
+}
+
+func binomial(n, k int) float64 {
+	r := 1.0
+	for i := 1; i <= k; i++ {
+		r = r * float64(n-k+i) / float64(i)
+	}
+	return r
+}
+
+type diceParser struct {
+	src string
+	pos int
+}
+
+func (p *diceParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid dice expression %q at offset %d: %s", p.src, p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *diceParser) peek() byte {
+	if p.pos < len(p.src) {
+		return p.src[p.pos]
+	}
+	return 0
+}
+
+func (p *diceParser) parseExpr() (diceNode, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for c := p.peek(); c == '+' || c == '-'; c = p.peek() {
+		p.pos++
+		right, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		left = binNode{op: c, l: left, r: right}
+	}
+	return left, nil
+}
+
+func (p *diceParser) parseTerm() (diceNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for c := p.peek(); c == '*' || c == '/'; c = p.peek() {
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = binNode{op: c, l: left, r: right}
+	}
+	return left, nil
+}
+
+func (p *diceParser) parseUnary() (diceNode, error) {
+	if p.peek() == '-' {
+		p.pos++
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return negNode{x: x}, nil
+	}
+	return p.parseAtom()
+}
+
+func (p *diceParser) parseAtom() (diceNode, error) {
+	switch c := p.peek(); {
+	case c == '(':
+		p.pos++
+		x, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ')' {
+			return nil, p.errorf("missing )")
+		}
+		p.pos++
+		return parenNode{x: x}, nil
+	case c == 'd':
+		return p.parseDice(p.pos, 1)
+	case c >= '0' && c <= '9':
+		start := p.pos
+		n, err := p.number()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() == 'd' {
+			return p.parseDice(start, n)
+		}
+		return numNode{v: n}, nil
+	case c == 0:
+		return nil, p.errorf("unexpected end")
+	default:
+		return nil, p.errorf("unexpected %q", c)
+	}
+}
+
+func (p *diceParser) number() (int, error) {
+	start := p.pos
+	for c := p.peek(); c >= '0' && c <= '9'; c = p.peek() {
+		p.pos++
+	}
+	if start == p.pos {
+		return 0, p.errorf("expected a number")
+	}
+	n, err := strconv.Atoi(p.src[start:p.pos])
+	if err != nil || n > 1000000000 {
+		return 0, p.errorf("number %s is too large", p.src[start:p.pos])
+	}
+	return n, nil
+}
+
+// optionalNumber reads a number if one follows, else returns def.
+func (p *diceParser) optionalNumber(def int) (int, error) {
+	if c := p.peek(); c >= '0' && c <= '9' {
+		return p.number()
+	}
+	return def, nil
+}
+
+func (p *diceParser) parseDice(start, count int) (diceNode, error) {
+	p.pos++ // the "d"
+	n := &rollNode{count: count}
+	switch c := p.peek(); {
+	case c == '%':
+		p.pos++
+		n.sides = 100
+	case c == 'f':
+		p.pos++
+		n.fate = true
+	default:
+		sides, err := p.number()
+		if err != nil {
+			return nil, p.errorf("expected sides, %%, or F after d")
+		}
+		n.sides = sides
+	}
+	if count < 1 || count > maxDice {
+		return nil, p.errorf("number of dice must be between 1 and %d", maxDice)
+	}
+	if !n.fate && (n.sides < 1 || n.sides > maxSides) {
+		return nil, p.errorf("number of sides must be between 1 and %d", maxSides)
+	}
+
+	keepSet := false
+	for {
+		switch p.peek() {
+		case '!':
+			if n.explode {
+				return nil, p.errorf("duplicate !")
+			}
+			p.pos++
+			n.explode = true
+		case 'r':
+			if n.reroll != nil {
+				return nil, p.errorf("only one reroll is allowed")
+			}
+			p.pos++
+			if p.peek() == 'o' {
+				p.pos++
+				n.rerollOnce = true
+			}
+			c := compare{op: '='}
+			if op := p.peek(); op == '<' || op == '>' || op == '=' {
+				p.pos++
+				c.op = op
+			}
+			v, err := p.number()
+			if err != nil {
+				return nil, err
+			}
+			c.v = v
+			n.reroll = &c
+		case 'k', 'd':
+			if keepSet {
+				return nil, p.errorf("only one keep or drop is allowed")
+			}
+			drop := p.peek() == 'd'
+			p.pos++
+			high := true
+			switch p.peek() {
+			case 'h':
+				p.pos++
+			case 'l':
+				p.pos++
+				high = false
+			default:
+				if drop {
+					return nil, p.errorf("use dh or dl to drop dice")
+				}
+			}
+			k, err := p.optionalNumber(1)
+			if err != nil {
+				return nil, err
+			}
+			if k > count || (!drop && k < 1) {
+				return nil, p.errorf("cannot keep or drop %d of %d dice", k, count)
+			}
+			keepSet = true
+			if drop {
+				// Dropping the highest keeps the lowest, and vice versa.
+				k, high = count-k, !high
+			}
+			n.keep, n.keepHighest = k, high
+			if k == count {
+				n.keep = 0
+			}
+			if k == 0 {
+				return nil, p.errorf("cannot drop every die")
+			}
+		default:
+			n.notation = p.src[start:p.pos]
+			return n, n.validate(p)
+		}
+	}
+}
+
+// validate rejects modifiers that could never finish or never apply.
+func (n *rollNode) validate(p *diceParser) error {
+	faces := n.faces()
+	if n.reroll != nil && !n.rerollOnce {
+		left := 0
+		for _, f := range faces {
+			if !n.reroll.match(f) {
+				left++
+			}
+		}
+		if left == 0 {
+			return p.errorf("%s rerolls every face", n.notation)
+		}
+	}
+	if n.explode && n.faceDist()[faces[len(faces)-1]] >= 1 {
+		return p.errorf("%s would explode forever", n.notation)
+	}
+	return nil
+}
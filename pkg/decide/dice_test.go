@@ -0,0 +1,195 @@
+package decide
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func mustParseDice(t *testing.T, expr string) *DiceExpr {
+	t.Helper()
+	e, err := ParseDice(expr)
+	if err != nil {
+		t.Fatalf("ParseDice(%q): %v", expr, err)
+	}
+	return e
+}
+
+func TestParseDice_Errors(t *testing.T) {
+	for expr, want := range map[string]string{
+		"":        "empty",
+		"2d":      "expected sides",
+		"4d6kh5":  "cannot keep or drop 5 of 4",
+		"4d6dl4":  "cannot drop every die",
+		"d1!":     "explode forever",
+		"d6r<6":   "rerolls every face",
+		"2d6d":    "use dh or dl",
+		"(1+2":    "missing )",
+		"2d6!!":   "duplicate !",
+		"0d6":     "number of dice",
+		"d0":      "number of sides",
+		"3d6 x":   "unexpected 'x'",
+		"4d6khkl": "only one keep or drop",
+	} {
+		if _, err := ParseDice(expr); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ParseDice(%q) = %v, want error containing %q", expr, err, want)
+		}
+	}
+}
+
+func TestDiceRoll_PlainMatchesRNG(t *testing.T) {
+	// Plain NdM must draw exactly one Intn per die so seeded rolls stay stable.
+	roll, err := mustParseDice(t, "3D6").Roll(NewSeededRNG(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rng := NewSeededRNG(5)
+	total := 0
+	for i, d := range roll.Terms[0].Dice {
+		v, _ := rng.Intn(6)
+		if d.Value != v+1 {
+			t.Fatalf("die %d = %d, want %d", i, d.Value, v+1)
+		}
+		total += v + 1
+	}
+	if roll.Total != total || roll.Expression != "3d6" {
+		t.Errorf("unexpected roll %+v", roll)
+	}
+}
+
+func TestDiceRoll_Modifiers(t *testing.T) {
+	for seed := int64(0); seed < 200; seed++ {
+		rng := NewSeededRNG(seed)
+
+		roll, _ := mustParseDice(t, "4d6kh3 + 2").Roll(rng)
+		dice := roll.Terms[0].Dice
+		dropped, lowest := 0, 7
+		for _, d := range dice {
+			if d.Value < lowest {
+				lowest = d.Value
+			}
+			if d.Dropped {
+				dropped++
+				if d.Value > lowest {
+					t.Fatalf("seed %d: dropped %d but %d was lower", seed, d.Value, lowest)
+				}
+			}
+		}
+		if dropped != 1 || roll.Total != roll.Terms[0].Total+2 {
+			t.Fatalf("seed %d: unexpected keep result %+v", seed, roll)
+		}
+
+		roll, _ = mustParseDice(t, "3d6!").Roll(rng)
+		for _, d := range roll.Terms[0].Dice {
+			for i, v := range d.Rolls {
+				if (i < len(d.Rolls)-1) != (v == 6) {
+					t.Fatalf("seed %d: bad explosion chain %v", seed, d.Rolls)
+				}
+			}
+		}
+
+		roll, _ = mustParseDice(t, "5d4r<2").Roll(rng)
+		for _, d := range roll.Terms[0].Dice {
+			if d.Value <= 2 {
+				t.Fatalf("seed %d: rerolled value %d kept", seed, d.Value)
+			}
+		}
+
+		roll, _ = mustParseDice(t, "4dF").Roll(rng)
+		if roll.Total < -4 || roll.Total > 4 {
+			t.Fatalf("seed %d: fate total %d out of range", seed, roll.Total)
+		}
+	}
+}
+
+func TestDiceRoll_Detail(t *testing.T) {
+	rng := &fixedRNG{values: []int{5, 0, 3, 2, 0, 4}}
+	roll, err := mustParseDice(t, "(4d6dl1 + d6ro1) * 2").Roll(rng)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if roll.Detail != "([6, ~1~, 4, 3] + [1→5]) * 2" || roll.Total != 36 {
+		t.Errorf("got %q = %d", roll.Detail, roll.Total)
+	}
+}
+
+// fixedRNG returns a fixed sequence of values.
+type fixedRNG struct {
+	values []int
+}
+
+func (r *fixedRNG) Intn(n int) (int, error) {
+	v := r.values[0] % n
+	r.values = r.values[1:]
+	return v, nil
+}
+
+func TestDiceStats_Exact(t *testing.T) {
+	stats, err := mustParseDice(t, "2d6").Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Min != 2 || stats.Max != 12 || !approx(stats.Mean, 7) {
+		t.Errorf("unexpected 2d6 stats %+v", stats)
+	}
+	if o := stats.Distribution[5]; o.Total != 7 || !approx(o.Probability, 6.0/36) || !approx(o.AtLeast, 21.0/36) {
+		t.Errorf("unexpected P(7) %+v", o)
+	}
+
+	for expr, mean := range map[string]float64{
+		"4d6kh3":    12.2445987654321,
+		"4d6dl1":    12.2445987654321,
+		"2d20kh1":   13.825,
+		"2d20kl1":   7.175,
+		"d6!":       4.2,
+		"4dF":       0,
+		"d%":        50.5,
+		"d6r1":      4,
+		"d6ro1":     3.5 + 2.5/6,
+		"(d6+1)*2":  9,
+		"d6-d6":     0,
+		"10/(d2+1)": 4,
+	} {
+		stats, err := mustParseDice(t, expr).Stats()
+		if err != nil {
+			t.Fatalf("%s: %v", expr, err)
+		}
+		if math.Abs(stats.Mean-mean) > 1e-6 {
+			t.Errorf("%s: mean %f, want %f", expr, stats.Mean, mean)
+		}
+		sum := 0.0
+		for _, o := range stats.Distribution {
+			sum += o.Probability
+		}
+		if math.Abs(sum-1) > 1e-9 {
+			t.Errorf("%s: probabilities sum to %f", expr, sum)
+		}
+	}
+
+	if _, err := mustParseDice(t, "10/d2-1").Stats(); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if _, err := mustParseDice(t, "10/(d3-1)").Stats(); err == nil {
+		t.Error("expected error for a possible division by zero")
+	}
+}
+
+func TestDiceStats_MatchesRolls(t *testing.T) {
+	// Keep with rerolls and explosions is the hardest case for the exact
+	// calculation, so compare it with a large sample.
+	e := mustParseDice(t, "5d6r1!kl3")
+	stats, err := e.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rng := NewSeededRNG(1)
+	const n = 200000
+	sum := 0
+	for i := 0; i < n; i++ {
+		roll, _ := e.Roll(rng)
+		sum += roll.Total
+	}
+	if sample := float64(sum) / n; math.Abs(sample-stats.Mean) > 0.03 {
+		t.Errorf("sample mean %f, exact mean %f", sample, stats.Mean)
+	}
+}
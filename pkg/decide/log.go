@@ -0,0 +1,415 @@
+package decide
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LogFile is the decisions log file name, kept in the goals directory next to
+// the task metadata.
+const LogFile = ".decisions.json"
+
+// maxLogRecords bounds the log; the oldest records are dropped first.
+const maxLogRecords = 1000
+
+// Record is one logged decision. A decision made with a seed can be replayed
+// with the same mode and options to check the result; a rotation also needs
+// the cycle it started from, see ReplayRotation.
+type Record struct {
+	Timestamp string   `json:"timestamp"`
+	Mode      string   `json:"mode"`
+	Label     string   `json:"label,omitempty"`
+	Group     string   `json:"group,omitempty"`
+	Options   []string `json:"options,omitempty"`
+	// Picked and Last are a rotation's cycle before the draw, with Options
+	// as the members.
+	Picked     []string `json:"picked,omitempty"`
+	Last       string   `json:"last,omitempty"`
+	Seed       *int64   `json:"seed,omitempty"`
+	Commitment string   `json:"commitment,omitempty"`
+	Nonce      string   `json:"nonce,omitempty"`
+	Result     []string `json:"result"`
+}
+
+// ReplayRotation draws a logged rotation again from its seed and the cycle
+// it started from, returning the picks to compare with Result.
+func (r Record) ReplayRotation() ([]string, error) {
+	if r.Mode != "rotate" || r.Seed == nil {
+		return nil, fmt.Errorf("not a seeded rotation")
+	}
+	g := &Group{Members: r.Options, Picked: append([]string(nil), r.Picked...), Last: r.Last}
+	rng := NewSeededRNG(*r.Seed)
+	picks := make([]string, len(r.Result))
+	for i := range picks {
+		pick, err := g.Next(rng)
+		if err != nil {
+			return nil, err
+		}
+		picks[i] = pick
+	}
+	return picks, nil
+}
+
+// Group is a rotation: members are picked round-robin in a random order, and
+// nobody is picked twice until everyone has been picked once.
+type Group struct {
+	Members []string `json:"members"`
+	// Picked holds the members already picked in the current cycle.
+	Picked []string `json:"picked"`
+	// Last is the most recent pick, kept so a new cycle does not start with
+	// the person who ended the previous one.
+	Last string `json:"last,omitempty"`
+}
+
+// Commitment is a published hash binding a decision's label and options to
+// a seed that has not been revealed yet. The seed and nonce are not written
+// to the log: they are kept in the secrets file until the reveal, so reading
+// the log does not give the result away.
+type Commitment struct {
+	Hash      string   `json:"hash"`
+	Label     string   `json:"label,omitempty"`
+	Options   []string `json:"options,omitempty"`
+	Timestamp string   `json:"timestamp"`
+	Seed      int64    `json:"-"`
+	Nonce     string   `json:"-"`
+}
+
+// commitSecret is the part of a commitment kept out of the log.
+type commitSecret struct {
+	Seed  int64  `json:"seed"`
+	Nonce string `json:"nonce"`
+}
+
+// Log is the persisted decisions log.
+type Log struct {
+	Version     int               `json:"version"`
+	Records     []Record          `json:"records"`
+	Groups      map[string]*Group `json:"groups"`
+	Commitments []Commitment      `json:"commitments"`
+
+	// SecretsPath is the file holding the seeds of pending commitments,
+	// which should not be readable by whoever can read the log.
+	SecretsPath string `json:"-"`
+
+	path    string
+	secrets map[string]commitSecret
+}
+
+// DefaultSecretsPath returns the secrets file in the user's config
+// directory, outside the wiki.
+func DefaultSecretsPath() (string, error) {
+	configDir := os.Getenv("XDG_CONFIG_HOME")
+	if configDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get home directory: %w", err)
+		}
+		configDir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(configDir, "regimen", "decide-secrets.json"), nil
+}
+
+// LoadLog reads the log at path. A missing file is an empty log.
+func LoadLog(path string) (*Log, error) {
+	l := &Log{Version: 1, Groups: map[string]*Group{}, path: path}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return l, nil
+		}
+		return nil, fmt.Errorf("failed to read decisions log: %w", err)
+	}
+	if err := json.Unmarshal(data, l); err != nil {
+		return nil, fmt.Errorf("failed to parse decisions log %s: %w", path, err)
+	}
+	if l.Groups == nil {
+		l.Groups = map[string]*Group{}
+	}
+	l.path = path
+	return l, nil
+}
+
+// Save writes the log back to the file it was loaded from. The file is
+// replaced atomically so an interrupted write cannot lose history.
+func (l *Log) Save() error {
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := l.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, l.path); err != nil {
+		return err
+	}
+	return l.saveSecrets()
+}
+
+// loadSecrets reads the secrets file once. A missing file has no secrets.
+func (l *Log) loadSecrets() error {
+	if l.secrets != nil {
+		return nil
+	}
+	if l.SecretsPath == "" {
+		return fmt.Errorf("no secrets file set for commitments")
+	}
+	l.secrets = map[string]commitSecret{}
+	data, err := os.ReadFile(l.SecretsPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read commitment secrets: %w", err)
+	}
+	if err := json.Unmarshal(data, &l.secrets); err != nil {
+		return fmt.Errorf("failed to parse commitment secrets %s: %w", l.SecretsPath, err)
+	}
+	return nil
+}
+
+// saveSecrets writes the secrets file, readable only by the user, if it was
+// loaded.
+func (l *Log) saveSecrets() error {
+	if l.secrets == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(l.SecretsPath), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(l.secrets, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := l.SecretsPath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, l.SecretsPath)
+}
+
+// Add appends a record, stamping it with the current time.
+func (l *Log) Add(r Record) {
+	if r.Timestamp == "" {
+		r.Timestamp = time.Now().Format(time.RFC3339)
+	}
+	l.Records = append(l.Records, r)
+	if len(l.Records) > maxLogRecords {
+		l.Records = l.Records[len(l.Records)-maxLogRecords:]
+	}
+}
+
+// Recent returns up to limit records, newest first, optionally only those
+// for group.
+func (l *Log) Recent(limit int, group string) []Record {
+	var out []Record
+	for i := len(l.Records) - 1; i >= 0 && len(out) < limit; i-- {
+		if group == "" || l.Records[i].Group == group {
+			out = append(out, l.Records[i])
+		}
+	}
+	return out
+}
+
+// SetMembers creates the group or updates its members. Members added to an
+// existing group join the current cycle; removed members leave it.
+func (l *Log) SetMembers(name string, members []string) (*Group, error) {
+	seen := make(map[string]bool)
+	var unique []string
+	for _, m := range members {
+		m = strings.TrimSpace(m)
+		if m == "" || seen[m] {
+			continue
+		}
+		seen[m] = true
+		unique = append(unique, m)
+	}
+	if len(unique) == 0 {
+		return nil, fmt.Errorf("group %q needs at least one member", name)
+	}
+
+	g, ok := l.Groups[name]
+	if !ok {
+		g = &Group{}
+		l.Groups[name] = g
+	}
+	g.Members = unique
+	var picked []string
+	for _, p := range g.Picked {
+		if seen[p] {
+			picked = append(picked, p)
+		}
+	}
+	g.Picked = picked
+	return g, nil
+}
+
+// Remaining returns the members not yet picked in the current cycle.
+func (g *Group) Remaining() []string {
+	picked := make(map[string]bool, len(g.Picked))
+	for _, p := range g.Picked {
+		picked[p] = true
+	}
+	var out []string
+	for _, m := range g.Members {
+		if !picked[m] {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// Next picks the next member. When everyone has been picked a new cycle
+// starts; with more than one member, it never starts with the previous pick.
+func (g *Group) Next(rng RNG) (string, error) {
+	if len(g.Members) == 0 {
+		return "", fmt.Errorf("group has no members")
+	}
+	remaining := g.Remaining()
+	if len(remaining) == 0 {
+		g.Picked = nil
+		remaining = g.Remaining()
+		if len(remaining) > 1 && g.Last != "" {
+			for i, m := range remaining {
+				if m == g.Last {
+					remaining = append(remaining[:i:i], remaining[i+1:]...)
+					break
+				}
+			}
+		}
+	}
+	idx, err := rng.Intn(len(remaining))
+	if err != nil {
+		return "", err
+	}
+	pick := remaining[idx]
+	g.Picked = append(g.Picked, pick)
+	g.Last = pick
+	return pick, nil
+}
+
+// Commit draws a random seed and nonce and stores a pending commitment to
+// them, the label and the options in order. Only the hash should be shared
+// until the reveal; the seed and nonce go to the secrets file.
+func (l *Log) Commit(label string, options []string) (Commitment, error) {
+	if err := l.loadSecrets(); err != nil {
+		return Commitment{}, err
+	}
+	seed, err := RandomSeed()
+	if err != nil {
+		return Commitment{}, err
+	}
+	nonceSeed, err := RandomSeed()
+	if err != nil {
+		return Commitment{}, err
+	}
+	nonce := strconv.FormatUint(uint64(nonceSeed), 16)
+	c := Commitment{
+		Hash:      CommitmentHash(label, options, seed, nonce),
+		Label:     label,
+		Options:   options,
+		Timestamp: time.Now().Format(time.RFC3339),
+		Seed:      seed,
+		Nonce:     nonce,
+	}
+	l.Commitments = append(l.Commitments, c)
+	l.secrets[c.Hash] = commitSecret{Seed: seed, Nonce: nonce}
+	return c, nil
+}
+
+// Reveal removes and returns the pending commitment whose hash starts with
+// prefix, with its seed and nonce. The options must be the committed ones in
+// the same order, and label, if given, the committed label. A commitment
+// can be revealed only once.
+func (l *Log) Reveal(prefix, label string, options []string) (Commitment, error) {
+	prefix = strings.ToLower(strings.TrimSpace(prefix))
+	if len(prefix) < 6 {
+		return Commitment{}, fmt.Errorf("commitment prefix must be at least 6 characters")
+	}
+	match := -1
+	for i, c := range l.Commitments {
+		if strings.HasPrefix(c.Hash, prefix) {
+			if match >= 0 {
+				return Commitment{}, fmt.Errorf("commitment prefix %q is ambiguous", prefix)
+			}
+			match = i
+		}
+	}
+	if match < 0 {
+		return Commitment{}, fmt.Errorf("no pending commitment %q (it may already have been revealed)", prefix)
+	}
+	c := l.Commitments[match]
+	if !sameOptions(c.Options, options) {
+		return Commitment{}, fmt.Errorf("options do not match the commitment, which was made for: %s", strings.Join(c.Options, ", "))
+	}
+	if label != "" && label != c.Label {
+		return Commitment{}, fmt.Errorf("label %q does not match the committed label %q", label, c.Label)
+	}
+	if err := l.loadSecrets(); err != nil {
+		return Commitment{}, err
+	}
+	secret, ok := l.secrets[c.Hash]
+	if !ok {
+		return Commitment{}, fmt.Errorf("the seed for commitment %s is not in %s; reveal it where it was made", ShortHash(c.Hash), l.SecretsPath)
+	}
+	c.Seed, c.Nonce = secret.Seed, secret.Nonce
+	l.Commitments = append(l.Commitments[:match], l.Commitments[match+1:]...)
+	delete(l.secrets, c.Hash)
+	return c, nil
+}
+
+func sameOptions(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// commitmentInput is what a commitment hashes, as JSON.
+type commitmentInput struct {
+	Label   string   `json:"label"`
+	Options []string `json:"options"`
+	Seed    int64    `json:"seed"`
+	Nonce   string   `json:"nonce"`
+}
+
+// CommitmentHash is the SHA-256 of the JSON object
+// {"label":…,"options":[…],"seed":…,"nonce":…}, hex encoded.
+func CommitmentHash(label string, options []string, seed int64, nonce string) string {
+	if options == nil {
+		options = []string{}
+	}
+	data, _ := json.Marshal(commitmentInput{Label: label, Options: options, Seed: seed, Nonce: nonce})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifyCommitment reports whether the label, options, seed and nonce hash
+// to commitment.
+func VerifyCommitment(commitment, label string, options []string, seed int64, nonce string) bool {
+	return strings.EqualFold(strings.TrimSpace(commitment), CommitmentHash(label, options, seed, nonce))
+}
+
+// ShortHash returns the first 12 characters of a hash, or all of a shorter
+// one.
+func ShortHash(h string) string {
+	if len(h) > 12 {
+		return h[:12]
+	}
+	return h
+}
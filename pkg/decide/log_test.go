@@ -0,0 +1,196 @@
+package decide
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestGroup_RoundRobin(t *testing.T) {
+	l, err := LoadLog(filepath.Join(t.TempDir(), LogFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := l.SetMembers("standup", []string{"alice", "bob", "carol", "dave", "bob", " "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Members) != 4 {
+		t.Fatalf("expected duplicates and blanks dropped, got %v", g.Members)
+	}
+
+	rng := NewSeededRNG(1)
+	prev := ""
+	for cycle := 0; cycle < 50; cycle++ {
+		seen := map[string]bool{}
+		for i := 0; i < 4; i++ {
+			pick, err := g.Next(rng)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if seen[pick] {
+				t.Fatalf("cycle %d picked %s twice", cycle, pick)
+			}
+			if i == 0 && pick == prev {
+				t.Fatalf("cycle %d started with the previous pick %s", cycle, pick)
+			}
+			seen[pick] = true
+			prev = pick
+		}
+	}
+}
+
+func TestRecord_ReplayRotation(t *testing.T) {
+	g := &Group{Members: []string{"alice", "bob", "carol"}, Picked: []string{"alice"}, Last: "alice"}
+	seed := int64(42)
+	r := Record{Mode: "rotate", Options: g.Members, Picked: append([]string(nil), g.Picked...), Last: g.Last, Seed: &seed}
+
+	// Five picks finish this cycle, run a whole one and start a third.
+	rng := NewSeededRNG(seed)
+	for i := 0; i < 5; i++ {
+		pick, err := g.Next(rng)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.Result = append(r.Result, pick)
+	}
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var logged Record
+	if err := json.Unmarshal(data, &logged); err != nil {
+		t.Fatal(err)
+	}
+	got, err := logged.ReplayRotation()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(got, ",") != strings.Join(r.Result, ",") {
+		t.Errorf("replay = %v, logged %v", got, r.Result)
+	}
+}
+
+func TestGroup_SingleMember(t *testing.T) {
+	g := &Group{Members: []string{"solo"}}
+	for i := 0; i < 3; i++ {
+		if pick, err := g.Next(NewSeededRNG(1)); err != nil || pick != "solo" {
+			t.Fatalf("got %q, %v", pick, err)
+		}
+	}
+}
+
+func TestSetMembers_KeepsCycle(t *testing.T) {
+	l, _ := LoadLog(filepath.Join(t.TempDir(), LogFile))
+	g, _ := l.SetMembers("g", []string{"a", "b", "c"})
+	g.Picked = []string{"a", "b"}
+
+	g, _ = l.SetMembers("g", []string{"b", "c", "d"})
+	if len(g.Picked) != 1 || g.Picked[0] != "b" {
+		t.Errorf("expected removed members to leave the cycle, got %v", g.Picked)
+	}
+	remaining := g.Remaining()
+	if len(remaining) != 2 || remaining[0] != "c" || remaining[1] != "d" {
+		t.Errorf("expected new members to join the cycle, got %v", remaining)
+	}
+	if _, err := l.SetMembers("empty", nil); err == nil {
+		t.Error("expected error for a group without members")
+	}
+}
+
+func TestLog_SaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks", LogFile)
+	l, _ := LoadLog(path)
+	seed := int64(42)
+	l.Add(Record{Mode: "pickOne", Options: []string{"a", "b"}, Seed: &seed, Result: []string{"a"}})
+	l.Add(Record{Mode: "rotate", Group: "g", Result: []string{"x"}})
+	g, _ := l.SetMembers("g", []string{"x", "y"})
+	g.Picked = []string{"x"}
+	if err := l.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Records) != 2 || *loaded.Records[0].Seed != 42 || loaded.Records[0].Timestamp == "" {
+		t.Errorf("unexpected records %+v", loaded.Records)
+	}
+	if got := loaded.Groups["g"].Remaining(); len(got) != 1 || got[0] != "y" {
+		t.Errorf("group state not restored: %v", got)
+	}
+	if recent := loaded.Recent(10, "g"); len(recent) != 1 || recent[0].Mode != "rotate" {
+		t.Errorf("unexpected group history %+v", recent)
+	}
+	if recent := loaded.Recent(1, ""); len(recent) != 1 || recent[0].Mode != "rotate" {
+		t.Errorf("expected newest record first, got %+v", recent)
+	}
+}
+
+func TestCommitReveal(t *testing.T) {
+	dir := t.TempDir()
+	l, _ := LoadLog(filepath.Join(dir, LogFile))
+	l.SecretsPath = filepath.Join(dir, "secrets", "decide-secrets.json")
+	options := []string{"alice", "bob", "carol"}
+	c, err := l.Commit("order", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Hash) != 64 || !VerifyCommitment(c.Hash, "order", options, c.Seed, c.Nonce) {
+		t.Fatalf("commitment does not verify: %+v", c)
+	}
+	if VerifyCommitment(c.Hash, "order", options, c.Seed+1, c.Nonce) || VerifyCommitment(c.Hash, "order", options, c.Seed, c.Nonce+"0") ||
+		VerifyCommitment(c.Hash, "other", options, c.Seed, c.Nonce) || VerifyCommitment(c.Hash, "order", []string{"bob", "alice", "carol"}, c.Seed, c.Nonce) {
+		t.Error("commitment verified with the wrong seed, nonce, label or options")
+	}
+	if err := l.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The seed stays out of the log until the reveal.
+	data, err := os.ReadFile(filepath.Join(dir, LogFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), c.Nonce) || strings.Contains(string(data), strconv.FormatInt(c.Seed, 10)) {
+		t.Errorf("log holds the seed or nonce:\n%s", data)
+	}
+	if info, err := os.Stat(l.SecretsPath); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("secrets file: %v, %v", info, err)
+	}
+
+	l, _ = LoadLog(filepath.Join(dir, LogFile))
+	l.SecretsPath = filepath.Join(dir, "secrets", "decide-secrets.json")
+	if _, err := l.Reveal(c.Hash[:4], "", options); err == nil {
+		t.Error("expected error for a too-short prefix")
+	}
+	if _, err := l.Reveal(c.Hash[:8], "", []string{"carol", "bob", "alice"}); err == nil {
+		t.Error("expected error for reordered options")
+	}
+	if _, err := l.Reveal(c.Hash[:8], "another", options); err == nil {
+		t.Error("expected error for a different label")
+	}
+	revealed, err := l.Reveal(c.Hash[:8], "", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if revealed.Seed != c.Seed || revealed.Nonce != c.Nonce || revealed.Label != "order" {
+		t.Errorf("revealed the wrong commitment: %+v", revealed)
+	}
+	if _, err := l.Reveal(c.Hash, "", options); err == nil {
+		t.Error("expected a commitment to be revealed only once")
+	}
+
+	// Known vector: SHA-256 of {"label":"x","options":["a","b"],"seed":1,"nonce":"ab"}.
+	if got := CommitmentHash("x", []string{"a", "b"}, 1, "ab"); got != "cbaa30434cf602af7bfe31d4f704ec5c1c603ce8d2a870da81c1cfdfe8c532cb" {
+		t.Errorf("unexpected hash %s", got)
+	}
+	if got := ShortHash("abc"); got != "abc" {
+		t.Errorf("ShortHash = %q", got)
+	}
+}
@@ -0,0 +1,516 @@
+package decide
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Criterion directions.
+const (
+	DirectionMax = "max" // higher scores are better (default)
+	DirectionMin = "min" // lower scores are better, e.g. cost
+)
+
+// Criterion is one weighted column of a decision matrix.
+type Criterion struct {
+	Name      string  `yaml:"name" json:"name"`
+	Weight    float64 `yaml:"weight" json:"weight"`
+	Direction string  `yaml:"direction,omitempty" json:"direction,omitempty"`
+}
+
+// MatrixOption is one alternative with a score per criterion.
+type MatrixOption struct {
+	Name   string             `yaml:"name" json:"name"`
+	Scores map[string]float64 `yaml:"scores" json:"scores"`
+}
+
+// Matrix scores options against weighted criteria.
+//
+// Pairwise optionally replaces the weights with AHP judgements: the key
+// "a/b" with value 3 means criterion a matters three times as much as b.
+type Matrix struct {
+	Title    string             `yaml:"title,omitempty" json:"title,omitempty"`
+	Criteria []Criterion        `yaml:"criteria" json:"criteria"`
+	Options  []MatrixOption     `yaml:"options" json:"options"`
+	Pairwise map[string]float64 `yaml:"pairwise,omitempty" json:"pairwise,omitempty"`
+}
+
+// LoadMatrix reads a matrix from a .csv file or from YAML (which includes
+// JSON).
+//
+// A CSV file has a header row of "option" and the criterion names, an
+// optional "weight" row, an optional "direction" row of max/min, and one row
+// per option. Criteria without a weight row weigh 1.
+func LoadMatrix(path string) (*Matrix, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read matrix: %w", err)
+	}
+
+	var m *Matrix
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		m, err = ParseMatrixCSV(strings.NewReader(string(data)))
+	} else {
+		m = &Matrix{}
+		err = yaml.Unmarshal(data, m)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse matrix %s: %w", path, err)
+	}
+	if m.Title == "" {
+		m.Title = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ParseMatrixCSV parses the CSV layout described in LoadMatrix.
+func ParseMatrixCSV(r io.Reader) (*Matrix, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) < 2 || len(rows[0]) < 2 {
+		return nil, fmt.Errorf("csv needs a header row and at least one option and criterion")
+	}
+
+	m := &Matrix{}
+	for _, name := range rows[0][1:] {
+		m.Criteria = append(m.Criteria, Criterion{Name: strings.TrimSpace(name), Weight: 1})
+	}
+	for n, row := range rows[1:] {
+		line := n + 2
+		label := strings.TrimSpace(row[0])
+		switch strings.ToLower(label) {
+		case "weight", "weights":
+			for i, cell := range row[1:] {
+				w, err := strconv.ParseFloat(strings.TrimSpace(cell), 64)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: invalid weight %q", line, cell)
+				}
+				m.Criteria[i].Weight = w
+			}
+		case "direction", "directions":
+			for i, cell := range row[1:] {
+				m.Criteria[i].Direction = strings.ToLower(strings.TrimSpace(cell))
+			}
+		default:
+			opt := MatrixOption{Name: label, Scores: map[string]float64{}}
+			for i, cell := range row[1:] {
+				v, err := strconv.ParseFloat(strings.TrimSpace(cell), 64)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: invalid score %q for %s", line, cell, m.Criteria[i].Name)
+				}
+				opt.Scores[m.Criteria[i].Name] = v
+			}
+			m.Options = append(m.Options, opt)
+		}
+	}
+	return m, nil
+}
+
+// Validate checks that every option scores every criterion and that the
+// weights are usable.
+func (m *Matrix) Validate() error {
+	if len(m.Criteria) == 0 {
+		return fmt.Errorf("matrix has no criteria")
+	}
+	if len(m.Options) < 2 {
+		return fmt.Errorf("matrix needs at least two options")
+	}
+	seen := make(map[string]bool)
+	total := 0.0
+	for i, c := range m.Criteria {
+		if c.Name == "" {
+			return fmt.Errorf("criterion %d has no name", i+1)
+		}
+		if seen[c.Name] {
+			return fmt.Errorf("duplicate criterion %q", c.Name)
+		}
+		seen[c.Name] = true
+		if c.Weight < 0 {
+			return fmt.Errorf("criterion %q has a negative weight", c.Name)
+		}
+		if c.Direction != "" && c.Direction != DirectionMax && c.Direction != DirectionMin {
+			return fmt.Errorf("criterion %q: direction must be max or min", c.Name)
+		}
+		total += c.Weight
+	}
+	if total == 0 && len(m.Pairwise) == 0 {
+		return fmt.Errorf("criteria weights sum to zero")
+	}
+	names := make(map[string]bool)
+	for i, o := range m.Options {
+		if o.Name == "" {
+			return fmt.Errorf("option %d has no name", i+1)
+		}
+		if names[o.Name] {
+			return fmt.Errorf("duplicate option %q", o.Name)
+		}
+		names[o.Name] = true
+		for _, c := range m.Criteria {
+			if _, ok := o.Scores[c.Name]; !ok {
+				return fmt.Errorf("option %q has no score for %q", o.Name, c.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// CriteriaNames returns the criterion names in order.
+func (m *Matrix) CriteriaNames() []string {
+	names := make([]string, len(m.Criteria))
+	for i, c := range m.Criteria {
+		names[i] = c.Name
+	}
+	return names
+}
+
+// OptionResult is one option's scores under each method. Scores are in
+// [0, 1]; rank 1 is best and ties share a rank.
+type OptionResult struct {
+	Name          string  `json:"name"`
+	WeightedScore float64 `json:"weighted_score"`
+	WeightedRank  int     `json:"weighted_rank"`
+	RankScore     float64 `json:"rank_score"`
+	RankRank      int     `json:"rank_rank"`
+}
+
+// Sensitivity reports how far a criterion's weight must move, with the other
+// weights keeping their proportions, before the weighted-sum winner changes.
+type Sensitivity struct {
+	Criterion string   `json:"criterion"`
+	Weight    float64  `json:"weight"`
+	FlipAt    *float64 `json:"flip_at"`
+	FlipTo    string   `json:"flip_to,omitempty"`
+}
+
+// MatrixResult is the evaluation of a matrix.
+type MatrixResult struct {
+	Title          string         `json:"title,omitempty"`
+	Criteria       []string       `json:"criteria"`
+	Weights        []float64      `json:"weights"`
+	Consistency    *float64       `json:"consistency_ratio,omitempty"`
+	Options        []OptionResult `json:"options"`
+	WeightedWinner string         `json:"weighted_winner"`
+	RankWinner     string         `json:"rank_winner"`
+	Sensitivity    []Sensitivity  `json:"sensitivity"`
+}
+
+// Evaluate scores every option by weighted sum and by weighted rank.
+//
+// The weighted sum rescales each criterion to [0, 1] across the options
+// (inverted for "min" criteria) before weighting. The rank-based method
+// replaces each score with its Borda points, so only the order of options
+// on a criterion matters, not the size of the gaps.
+func (m *Matrix) Evaluate() (*MatrixResult, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	res := &MatrixResult{Title: m.Title, Criteria: m.CriteriaNames()}
+
+	weights := make([]float64, len(m.Criteria))
+	if len(m.Pairwise) > 0 {
+		cmp, err := PairwiseMatrix(res.Criteria, m.Pairwise)
+		if err != nil {
+			return nil, err
+		}
+		w, cr, err := AHPWeights(cmp)
+		if err != nil {
+			return nil, err
+		}
+		weights = w
+		res.Consistency = &cr
+	} else {
+		total := 0.0
+		for _, c := range m.Criteria {
+			total += c.Weight
+		}
+		for i, c := range m.Criteria {
+			weights[i] = c.Weight / total
+		}
+	}
+	res.Weights = weights
+
+	norm := m.normalized()
+	points := m.bordaPoints()
+	weighted := make([]float64, len(m.Options))
+	ranked := make([]float64, len(m.Options))
+	for i, o := range m.Options {
+		for j := range m.Criteria {
+			weighted[i] += weights[j] * norm[i][j]
+			ranked[i] += weights[j] * points[i][j]
+		}
+		res.Options = append(res.Options, OptionResult{Name: o.Name, WeightedScore: weighted[i], RankScore: ranked[i]})
+	}
+	wRanks, rRanks := competitionRanks(weighted), competitionRanks(ranked)
+	for i := range res.Options {
+		res.Options[i].WeightedRank = wRanks[i]
+		res.Options[i].RankRank = rRanks[i]
+	}
+	winner := argmax(weighted)
+	res.WeightedWinner = m.Options[winner].Name
+	res.RankWinner = m.Options[argmax(ranked)].Name
+	res.Sensitivity = m.sensitivity(weights, norm, winner)
+	return res, nil
+}
+
+// normalized rescales each criterion to [0, 1]; 1 is best.
+func (m *Matrix) normalized() [][]float64 {
+	out := make([][]float64, len(m.Options))
+	for i := range out {
+		out[i] = make([]float64, len(m.Criteria))
+	}
+	for j, c := range m.Criteria {
+		lo, hi := math.Inf(1), math.Inf(-1)
+		for _, o := range m.Options {
+			lo = math.Min(lo, o.Scores[c.Name])
+			hi = math.Max(hi, o.Scores[c.Name])
+		}
+		for i, o := range m.Options {
+			v := 1.0
+			if hi > lo {
+				v = (o.Scores[c.Name] - lo) / (hi - lo)
+			}
+			if c.Direction == DirectionMin && hi > lo {
+				v = 1 - v
+			}
+			out[i][j] = v
+		}
+	}
+	return out
+}
+
+// bordaPoints scores each option per criterion by how many options it beats,
+// scaled to [0, 1]; ties split the points.
+func (m *Matrix) bordaPoints() [][]float64 {
+	n := len(m.Options)
+	out := make([][]float64, n)
+	for i := range out {
+		out[i] = make([]float64, len(m.Criteria))
+	}
+	for j, c := range m.Criteria {
+		for i, o := range m.Options {
+			beats := 0.0
+			for k, other := range m.Options {
+				if k == i {
+					continue
+				}
+				a, b := o.Scores[c.Name], other.Scores[c.Name]
+				if c.Direction == DirectionMin {
+					a, b = -a, -b
+				}
+				switch {
+				case a > b:
+					beats++
+				case a == b:
+					beats += 0.5
+				}
+			}
+			out[i][j] = beats / float64(n-1)
+		}
+	}
+	return out
+}
+
+// sensitivity finds, per criterion, the nearest weight at which another
+// option overtakes the winner.
+func (m *Matrix) sensitivity(weights []float64, norm [][]float64, winner int) []Sensitivity {
+	var out []Sensitivity
+	for k, c := range m.Criteria {
+		s := Sensitivity{Criterion: c.Name, Weight: weights[k]}
+		if weights[k] < 1 {
+			// With weight t on k and the rest scaled by (1-t)/(1-w_k), each
+			// score is linear in t: t*a + (1-t)*b.
+			score := func(i int) (a, b float64) {
+				a = norm[i][k]
+				for j := range m.Criteria {
+					if j != k {
+						b += weights[j] * norm[i][j]
+					}
+				}
+				return a, b / (1 - weights[k])
+			}
+			aw, bw := score(winner)
+			best := math.Inf(1)
+			for i := range m.Options {
+				if i == winner {
+					continue
+				}
+				ai, bi := score(i)
+				da, db := aw-ai, bw-bi
+				if da == db {
+					continue
+				}
+				t := db / (db - da)
+				if t < 0 || t > 1 || math.Abs(t-weights[k]) < 1e-12 {
+					continue
+				}
+				if d := math.Abs(t - weights[k]); d < best {
+					best = d
+					flip := t
+					s.FlipAt, s.FlipTo = &flip, m.Options[i].Name
+				}
+			}
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+// competitionRanks ranks scores descending, "1224" style.
+func competitionRanks(scores []float64) []int {
+	idx := make([]int, len(scores))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(a, b int) bool { return scores[idx[a]] > scores[idx[b]] })
+	ranks := make([]int, len(scores))
+	for pos, i := range idx {
+		if pos > 0 && nearlyEqual(scores[i], scores[idx[pos-1]]) {
+			ranks[i] = ranks[idx[pos-1]]
+		} else {
+			ranks[i] = pos + 1
+		}
+	}
+	return ranks
+}
+
+func argmax(scores []float64) int {
+	best := 0
+	for i, s := range scores {
+		if s > scores[best] && !nearlyEqual(s, scores[best]) {
+			best = i
+		}
+	}
+	return best
+}
+
+func nearlyEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+// ahpRandomIndex is Saaty's random consistency index by matrix size.
+var ahpRandomIndex = []float64{0, 0, 0, 0.58, 0.90, 1.12, 1.24, 1.32, 1.41, 1.45, 1.49}
+
+// PairwiseMatrix builds a reciprocal comparison matrix from judgements keyed
+// "a/b" (a matters that many times as much as b). Every pair must be judged
+// once, in either order.
+func PairwiseMatrix(criteria []string, judgements map[string]float64) ([][]float64, error) {
+	index := make(map[string]int, len(criteria))
+	n := len(criteria)
+	cmp := make([][]float64, n)
+	for i, c := range criteria {
+		index[c] = i
+		cmp[i] = make([]float64, n)
+		cmp[i][i] = 1
+	}
+	for key, v := range judgements {
+		a, b, ok := strings.Cut(key, "/")
+		i, okA := index[strings.TrimSpace(a)]
+		j, okB := index[strings.TrimSpace(b)]
+		if !ok || !okA || !okB || i == j {
+			return nil, fmt.Errorf("invalid pairwise key %q (use criterion/criterion)", key)
+		}
+		if v <= 0 {
+			return nil, fmt.Errorf("pairwise judgement %q must be positive", key)
+		}
+		if cmp[i][j] != 0 {
+			return nil, fmt.Errorf("pair %s and %s is judged twice", criteria[i], criteria[j])
+		}
+		cmp[i][j], cmp[j][i] = v, 1/v
+	}
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			if cmp[i][j] == 0 {
+				return nil, fmt.Errorf("missing pairwise judgement %s/%s", criteria[i], criteria[j])
+			}
+		}
+	}
+	return cmp, nil
+}
+
+// AHPWeights derives criterion weights from a reciprocal pairwise matrix by
+// the geometric-mean method and returns Saaty's consistency ratio; above 0.1
+// the judgements contradict each other noticeably.
+func AHPWeights(cmp [][]float64) ([]float64, float64, error) {
+	n := len(cmp)
+	if n == 0 {
+		return nil, 0, fmt.Errorf("empty comparison matrix")
+	}
+	weights := make([]float64, n)
+	total := 0.0
+	for i, row := range cmp {
+		if len(row) != n {
+			return nil, 0, fmt.Errorf("comparison matrix is not square")
+		}
+		prod := 1.0
+		for _, v := range row {
+			if v <= 0 {
+				return nil, 0, fmt.Errorf("comparison values must be positive")
+			}
+			prod *= v
+		}
+		weights[i] = math.Pow(prod, 1/float64(n))
+		total += weights[i]
+	}
+	for i := range weights {
+		weights[i] /= total
+	}
+	if n < 3 {
+		return weights, 0, nil
+	}
+
+	lambda := 0.0
+	for i, row := range cmp {
+		sum := 0.0
+		for j, v := range row {
+			sum += v * weights[j]
+		}
+		lambda += sum / weights[i]
+	}
+	lambda /= float64(n)
+	ri := ahpRandomIndex[len(ahpRandomIndex)-1]
+	if n < len(ahpRandomIndex) {
+		ri = ahpRandomIndex[n]
+	}
+	ci := (lambda - float64(n)) / float64(n-1)
+	return weights, math.Max(0, ci/ri), nil
+}
+
+// ParseJudgement parses a pairwise answer such as "3", "1/5" or "0.2".
+func ParseJudgement(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	var v float64
+	var err error
+	if num, den, ok := strings.Cut(s, "/"); ok {
+		var a, b float64
+		a, err = strconv.ParseFloat(strings.TrimSpace(num), 64)
+		if err == nil {
+			b, err = strconv.ParseFloat(strings.TrimSpace(den), 64)
+		}
+		if err == nil && b != 0 {
+			v = a / b
+		}
+	} else {
+		v, err = strconv.ParseFloat(s, 64)
+	}
+	if err != nil || v < 1.0/9-1e-9 || v > 9+1e-9 {
+		return 0, fmt.Errorf("invalid judgement %q (use 1/9 to 9)", s)
+	}
+	return v, nil
+}
@@ -0,0 +1,159 @@
+package decide
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func laptopMatrix() *Matrix {
+	return &Matrix{
+		Criteria: []Criterion{
+			{Name: "price", Weight: 3, Direction: DirectionMin},
+			{Name: "battery", Weight: 2},
+			{Name: "screen", Weight: 1},
+		},
+		Options: []MatrixOption{
+			{Name: "Alpha", Scores: map[string]float64{"price": 1200, "battery": 14, "screen": 8}},
+			{Name: "Beta", Scores: map[string]float64{"price": 900, "battery": 9, "screen": 7}},
+			{Name: "Gamma", Scores: map[string]float64{"price": 1000, "battery": 12, "screen": 9}},
+		},
+	}
+}
+
+func approx(a, b float64) bool { return math.Abs(a-b) < 1e-6 }
+
+func TestMatrix_WeightedAndRank(t *testing.T) {
+	res, err := laptopMatrix().Evaluate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Gamma: price (1200-1000)/300 = 2/3, battery 3/5, screen 1.
+	want := 0.5*2.0/3 + (1.0/3)*0.6 + (1.0/6)*1
+	if !approx(res.Options[2].WeightedScore, want) {
+		t.Errorf("Gamma weighted score = %f, want %f", res.Options[2].WeightedScore, want)
+	}
+	// Gamma is middle on price and battery and best on screen.
+	if !approx(res.Options[2].RankScore, 0.5*0.5+(1.0/3)*0.5+(1.0/6)*1) {
+		t.Errorf("unexpected Gamma rank score %f", res.Options[2].RankScore)
+	}
+	if res.WeightedWinner != "Gamma" || res.RankWinner != "Gamma" || res.Options[2].WeightedRank != 1 {
+		t.Errorf("unexpected winners %s / %s", res.WeightedWinner, res.RankWinner)
+	}
+}
+
+func TestMatrix_Sensitivity(t *testing.T) {
+	res, err := laptopMatrix().Evaluate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	price := res.Sensitivity[0]
+	if price.FlipAt == nil || price.FlipTo != "Beta" {
+		t.Fatalf("expected Beta to overtake on price, got %+v", price)
+	}
+
+	// Re-evaluating just past the flip point must change the winner.
+	m := laptopMatrix()
+	flip := *price.FlipAt + 0.001
+	rest := (1 - flip) / (1 - price.Weight)
+	m.Criteria[0].Weight = flip
+	m.Criteria[1].Weight = res.Weights[1] * rest
+	m.Criteria[2].Weight = res.Weights[2] * rest
+	flipped, _ := m.Evaluate()
+	if flipped.WeightedWinner != "Beta" {
+		t.Errorf("expected Beta to win at price weight %.3f, got %s", flip, flipped.WeightedWinner)
+	}
+
+	if res.Sensitivity[2].FlipAt != nil {
+		t.Errorf("expected no flip on screen, where Gamma is best, got %+v", res.Sensitivity[2])
+	}
+}
+
+func TestAHPWeights(t *testing.T) {
+	// A perfectly consistent matrix: weights 4:2:1.
+	cmp := [][]float64{{1, 2, 4}, {0.5, 1, 2}, {0.25, 0.5, 1}}
+	w, cr, err := AHPWeights(cmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !approx(w[0], 4.0/7) || !approx(w[1], 2.0/7) || !approx(w[2], 1.0/7) || !approx(cr, 0) {
+		t.Errorf("unexpected weights %v cr %f", w, cr)
+	}
+
+	// Contradictory judgements: a > b > c but c > a.
+	cmp = [][]float64{{1, 5, 1.0 / 5}, {1.0 / 5, 1, 5}, {5, 1.0 / 5, 1}}
+	if _, cr, _ = AHPWeights(cmp); cr <= 0.1 {
+		t.Errorf("expected an inconsistent ratio, got %f", cr)
+	}
+}
+
+func TestPairwiseMatrix(t *testing.T) {
+	m := laptopMatrix()
+	m.Pairwise = map[string]float64{"price/battery": 2, "screen/price": 0.25, "battery/screen": 2}
+	res, err := m.Evaluate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !approx(res.Weights[0], 4.0/7) || res.Consistency == nil {
+		t.Errorf("expected AHP weights, got %v", res.Weights)
+	}
+
+	if _, err := PairwiseMatrix([]string{"a", "b", "c"}, map[string]float64{"a/b": 2}); err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("expected missing judgement error, got %v", err)
+	}
+	if _, err := PairwiseMatrix([]string{"a", "b"}, map[string]float64{"a/b": 2, "b/a": 0.5}); err == nil {
+		t.Error("expected error for a pair judged twice")
+	}
+}
+
+func TestParseJudgement(t *testing.T) {
+	for in, want := range map[string]float64{"3": 3, " 1/5 ": 0.2, "0.5": 0.5, "9": 9} {
+		if got, err := ParseJudgement(in); err != nil || !approx(got, want) {
+			t.Errorf("ParseJudgement(%q) = %v, %v", in, got, err)
+		}
+	}
+	for _, in := range []string{"10", "0", "x", "1/0", "1/10"} {
+		if _, err := ParseJudgement(in); err == nil {
+			t.Errorf("expected error for %q", in)
+		}
+	}
+}
+
+func TestLoadMatrix_CSV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "laptop.csv")
+	csv := "option,price,battery,screen\nweight,3,2,1\ndirection,min,max,max\nAlpha,1200,14,8\nBeta,900,9,7\nGamma,1000,12,9\n"
+	if err := os.WriteFile(path, []byte(csv), 0600); err != nil {
+		t.Fatal(err)
+	}
+	m, err := LoadMatrix(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Title != "laptop" || len(m.Options) != 3 || m.Criteria[0].Direction != DirectionMin || m.Criteria[0].Weight != 3 {
+		t.Errorf("unexpected matrix %+v", m)
+	}
+	res, _ := m.Evaluate()
+	if res.WeightedWinner != "Gamma" {
+		t.Errorf("expected the same result as YAML, got %s", res.WeightedWinner)
+	}
+}
+
+func TestMatrix_Validate(t *testing.T) {
+	tests := map[string]func(m *Matrix){
+		"has no score":     func(m *Matrix) { delete(m.Options[0].Scores, "screen") },
+		"duplicate option": func(m *Matrix) { m.Options[1].Name = "Alpha" },
+		"direction":        func(m *Matrix) { m.Criteria[0].Direction = "down" },
+		"sum to zero":      func(m *Matrix) { m.Criteria[0].Weight, m.Criteria[1].Weight, m.Criteria[2].Weight = 0, 0, 0 },
+		"at least two":     func(m *Matrix) { m.Options = m.Options[:1] },
+		"negative weight":  func(m *Matrix) { m.Criteria[1].Weight = -1 },
+	}
+	for want, mutate := range tests {
+		m := laptopMatrix()
+		mutate(m)
+		if err := m.Validate(); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error containing %q, got %v", want, err)
+		}
+	}
+}
@@ -0,0 +1,483 @@
+// Package ics reads and writes iCalendar (RFC 5545) files.
+package ics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Property is one content line, e.g. DTSTART;TZID=Europe/London:20250101T090000.
+type Property struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+// Component is a BEGIN/END block with its properties and nested blocks.
+type Component struct {
+	Name       string
+	Properties []Property
+	Children   []*Component
+}
+
+// Get returns the first property called name.
+func (c *Component) Get(name string) (Property, bool) {
+	for _, p := range c.Properties {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Property{}, false
+}
+
+// Text returns the unescaped value of a text property, or "".
+func (c *Component) Text(name string) string {
+	p, ok := c.Get(name)
+	if !ok {
+		return ""
+	}
+	return Unescape(p.Value)
+}
+
+// Event is a VEVENT. All-day events have midnight UTC times and an exclusive
+// End, as in the file.
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	Location    string
+	Start       time.Time
+	End         time.Time
+	AllDay      bool
+	// RRule is the raw recurrence rule; recurrences are not expanded.
+	RRule string
+}
+
+// Todo is a VTODO. Due dates without a time have DueDate set.
+type Todo struct {
+	UID         string
+	Summary     string
+	Description string
+	Due         *time.Time
+	DueDate     bool
+	// Status is NEEDS-ACTION, IN-PROCESS, COMPLETED or CANCELLED.
+	Status    string
+	Completed *time.Time
+	// Priority runs from 1 (highest) to 9 (lowest); 0 is undefined.
+	Priority   int
+	Categories []string
+}
+
+// Calendar is a parsed VCALENDAR.
+type Calendar struct {
+	// Root is the parsed component tree; it is nil for calendars built in
+	// code.
+	Root   *Component
+	Events []Event
+	Todos  []Todo
+	// Warnings lists events and to-dos read in local time because their
+	// TZID could not be resolved.
+	Warnings []string
+}
+
+// Open parses the calendar at path.
+func Open(path string) (*Calendar, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open calendar: %w", err)
+	}
+	defer f.Close()
+	cal, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cal, nil
+}
+
+// Parse reads a calendar. Unknown components and properties are kept in
+// Root but otherwise ignored.
+func Parse(r io.Reader) (*Calendar, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var stack []*Component
+	var root *Component
+	for i, line := range lines {
+		if line == "" {
+			continue
+		}
+		p, err := parseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		switch p.Name {
+		case "BEGIN":
+			c := &Component{Name: strings.ToUpper(p.Value)}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.Children = append(parent.Children, c)
+			} else if root != nil {
+				return nil, fmt.Errorf("line %d: more than one top-level component", i+1)
+			} else {
+				root = c
+			}
+			stack = append(stack, c)
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1].Name != strings.ToUpper(p.Value) {
+				return nil, fmt.Errorf("line %d: unexpected END:%s", i+1, p.Value)
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("line %d: property outside a component", i+1)
+			}
+			c := stack[len(stack)-1]
+			c.Properties = append(c.Properties, p)
+		}
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("missing END:%s", stack[len(stack)-1].Name)
+	}
+	if root == nil || root.Name != "VCALENDAR" {
+		return nil, fmt.Errorf("not an iCalendar file (no VCALENDAR)")
+	}
+
+	cal := &Calendar{Root: root}
+	tr := &timeReader{zones: parseTimezones(root)}
+	for _, c := range root.Children {
+		switch c.Name {
+		case "VEVENT":
+			ev, err := parseEvent(c, tr)
+			if err != nil {
+				return nil, err
+			}
+			cal.Events = append(cal.Events, ev)
+		case "VTODO":
+			todo, err := parseTodo(c, tr)
+			if err != nil {
+				return nil, err
+			}
+			cal.Todos = append(cal.Todos, todo)
+		}
+	}
+	cal.Warnings = tr.warnings
+	return cal, nil
+}
+
+func parseTodo(c *Component, tr *timeReader) (Todo, error) {
+	todo := Todo{
+		UID:         c.Text("UID"),
+		Summary:     c.Text("SUMMARY"),
+		Description: c.Text("DESCRIPTION"),
+		Status:      strings.ToUpper(c.Text("STATUS")),
+	}
+	if p, ok := c.Get("DUE"); ok {
+		due, date, err := tr.parse(p, fmt.Sprintf("todo %q", todo.Summary))
+		if err != nil {
+			return todo, fmt.Errorf("todo %q: %w", todo.Summary, err)
+		}
+		todo.Due, todo.DueDate = &due, date
+	}
+	if p, ok := c.Get("COMPLETED"); ok {
+		done, _, err := tr.parse(p, fmt.Sprintf("todo %q", todo.Summary))
+		if err != nil {
+			return todo, fmt.Errorf("todo %q: %w", todo.Summary, err)
+		}
+		todo.Completed = &done
+	}
+	if p, ok := c.Get("PRIORITY"); ok {
+		n, err := strconv.Atoi(strings.TrimSpace(p.Value))
+		if err != nil || n < 0 || n > 9 {
+			return todo, fmt.Errorf("todo %q: invalid PRIORITY %q", todo.Summary, p.Value)
+		}
+		todo.Priority = n
+	}
+	for _, p := range c.Properties {
+		if p.Name == "CATEGORIES" {
+			for _, cat := range splitText(p.Value) {
+				if cat = strings.TrimSpace(cat); cat != "" {
+					todo.Categories = append(todo.Categories, cat)
+				}
+			}
+		}
+	}
+	return todo, nil
+}
+
+// splitText splits a multi-valued TEXT property on unescaped commas.
+func splitText(s string) []string {
+	var parts []string
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case ',':
+			parts = append(parts, Unescape(s[start:i]))
+			start = i + 1
+		}
+	}
+	return append(parts, Unescape(s[start:]))
+}
+
+func parseEvent(c *Component, tr *timeReader) (Event, error) {
+	ev := Event{
+		UID:         c.Text("UID"),
+		Summary:     c.Text("SUMMARY"),
+		Description: c.Text("DESCRIPTION"),
+		Location:    c.Text("LOCATION"),
+	}
+	if p, ok := c.Get("RRULE"); ok {
+		ev.RRule = p.Value
+	}
+	start, ok := c.Get("DTSTART")
+	if !ok {
+		return ev, fmt.Errorf("event %q has no DTSTART", ev.Summary)
+	}
+	what := fmt.Sprintf("event %q", ev.Summary)
+	var err error
+	if ev.Start, ev.AllDay, err = tr.parse(start, what); err != nil {
+		return ev, fmt.Errorf("%s: %w", what, err)
+	}
+
+	if end, ok := c.Get("DTEND"); ok {
+		if ev.End, _, err = tr.parse(end, what); err != nil {
+			return ev, fmt.Errorf("event %q: %w", ev.Summary, err)
+		}
+	} else if d, ok := c.Get("DURATION"); ok {
+		dur, err := ParseDuration(d.Value)
+		if err != nil {
+			return ev, fmt.Errorf("event %q: %w", ev.Summary, err)
+		}
+		ev.End = ev.Start.Add(dur)
+	} else if ev.AllDay {
+		ev.End = ev.Start.AddDate(0, 0, 1)
+	} else {
+		ev.End = ev.Start
+	}
+	return ev, nil
+}
+
+// Days returns the dates an all-day event covers, as YYYY-MM-DD.
+func (e Event) Days() []string {
+	days := []string{e.Start.Format("2006-01-02")}
+	for d := e.Start.AddDate(0, 0, 1); d.Before(e.End); d = d.AddDate(0, 0, 1) {
+		days = append(days, d.Format("2006-01-02"))
+	}
+	return days
+}
+
+// On returns the events on a date (YYYY-MM-DD) in loc: all-day events
+// covering it first, then timed events starting that day, by start time.
+// Recurring events only match their first occurrence.
+func (c *Calendar) On(date string, loc *time.Location) []Event {
+	var allDay, timed []Event
+	for _, ev := range c.Events {
+		if ev.AllDay {
+			for _, d := range ev.Days() {
+				if d == date {
+					allDay = append(allDay, ev)
+					break
+				}
+			}
+		} else if ev.Start.In(loc).Format("2006-01-02") == date {
+			timed = append(timed, ev)
+		}
+	}
+	sort.SliceStable(timed, func(i, j int) bool { return timed[i].Start.Before(timed[j].Start) })
+	return append(allDay, timed...)
+}
+
+// unfold joins continuation lines (starting with a space or tab).
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read calendar: %w", err)
+	}
+	return lines, nil
+}
+
+// parseLine splits NAME;PARAM=VALUE;...:VALUE, honouring quoted parameters.
+func parseLine(line string) (Property, error) {
+	p := Property{Params: map[string]string{}}
+	inQuote := false
+	colon := -1
+	for i, r := range line {
+		if r == '"' {
+			inQuote = !inQuote
+		} else if r == ':' && !inQuote {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return p, fmt.Errorf("missing ':' in %q", line)
+	}
+	p.Value = line[colon+1:]
+
+	parts := splitUnquoted(line[:colon], ';')
+	p.Name = strings.ToUpper(parts[0])
+	if p.Name == "" {
+		return p, fmt.Errorf("missing property name in %q", line)
+	}
+	for _, param := range parts[1:] {
+		k, v, _ := strings.Cut(param, "=")
+		p.Params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+	}
+	return p, nil
+}
+
+func splitUnquoted(s string, sep rune) []string {
+	var parts []string
+	inQuote := false
+	start := 0
+	for i, r := range s {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+		case r == sep && !inQuote:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// ParseTime parses a DATE or DATE-TIME property. Times with TZID are read in
+// that zone, times ending in Z in UTC and floating times in local time.
+// Dates are midnight UTC and reported as all-day.
+func ParseTime(p Property) (time.Time, bool, error) {
+	return parseTime(p, func(tz, value string) (*time.Location, error) {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			return nil, fmt.Errorf("unknown TZID %q", tz)
+		}
+		return loc, nil
+	})
+}
+
+// parseTime is ParseTime with zone resolving a TZID for a date-time value.
+func parseTime(p Property, zone func(tzid, value string) (*time.Location, error)) (time.Time, bool, error) {
+	v := strings.TrimSpace(p.Value)
+	if p.Params["VALUE"] == "DATE" || len(v) == 8 {
+		t, err := time.Parse("20060102", v)
+		if err != nil {
+			return t, true, fmt.Errorf("invalid date %q", v)
+		}
+		return t, true, nil
+	}
+
+	loc := time.Local
+	if strings.HasSuffix(v, "Z") {
+		v = strings.TrimSuffix(v, "Z")
+		loc = time.UTC
+	} else if tz := p.Params["TZID"]; tz != "" {
+		l, err := zone(tz, v)
+		if err != nil {
+			return time.Time{}, false, err
+		}
+		loc = l
+	}
+	t, err := time.ParseInLocation("20060102T150405", v, loc)
+	if err != nil {
+		return t, false, fmt.Errorf("invalid date-time %q", p.Value)
+	}
+	return t, false, nil
+}
+
+// ParseDuration parses an iCalendar duration such as PT1H30M or P1D.
+func ParseDuration(s string) (time.Duration, error) {
+	orig := s
+	neg := false
+	switch {
+	case strings.HasPrefix(s, "-"):
+		neg = true
+		s = s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+	if !strings.HasPrefix(s, "P") || len(s) < 3 {
+		return 0, fmt.Errorf("invalid duration %q", orig)
+	}
+	s = s[1:]
+
+	var d time.Duration
+	inTime := false
+	n := 0
+	digits := false
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			n = n*10 + int(r-'0')
+			digits = true
+			continue
+		case r == 'T':
+			inTime = true
+			continue
+		}
+		if !digits {
+			return 0, fmt.Errorf("invalid duration %q", orig)
+		}
+		var unit time.Duration
+		switch {
+		case r == 'W' && !inTime:
+			unit = 7 * 24 * time.Hour
+		case r == 'D' && !inTime:
+			unit = 24 * time.Hour
+		case r == 'H' && inTime:
+			unit = time.Hour
+		case r == 'M' && inTime:
+			unit = time.Minute
+		case r == 'S' && inTime:
+			unit = time.Second
+		default:
+			return 0, fmt.Errorf("invalid duration %q", orig)
+		}
+		d += time.Duration(n) * unit
+		n, digits = 0, false
+	}
+	if digits {
+		return 0, fmt.Errorf("invalid duration %q", orig)
+	}
+	if neg {
+		d = -d
+	}
+	return d, nil
+}
+
+// Unescape decodes a TEXT value.
+func Unescape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+			switch s[i] {
+			case 'n', 'N':
+				b.WriteByte('\n')
+			default:
+				b.WriteByte(s[i])
+			}
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
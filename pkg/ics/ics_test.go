@@ -0,0 +1,177 @@
+package ics
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const holidaysICS = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"PRODID:-//Test//Holidays//EN\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:xmas@test\r\n" +
+	"DTSTART;VALUE=DATE:20251225\r\n" +
+	"DTEND;VALUE=DATE:20251227\r\n" +
+	"SUMMARY:Christmas\\, Boxing Day\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:standup@test\r\n" +
+	"DTSTART;TZID=America/New_York:20250310T093000\r\n" +
+	"DURATION:PT15M\r\n" +
+	"SUMMARY:Standup with a very long title that has been folded across\r\n" +
+	"  two lines\r\n" +
+	"DESCRIPTION:Line one\\nLine two\r\n" +
+	"RRULE:FREQ=WEEKLY;BYDAY=MO\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"DTSTART:20250311T140000Z\r\n" +
+	"DTEND:20250311T150000Z\r\n" +
+	"LOCATION;ALTREP=\"http://example.com/a:b\":Room 1\r\n" +
+	"SUMMARY:Review\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestParse(t *testing.T) {
+	cal, err := Parse(strings.NewReader(holidaysICS))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cal.Events) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(cal.Events))
+	}
+
+	xmas := cal.Events[0]
+	if !xmas.AllDay || xmas.Summary != "Christmas, Boxing Day" {
+		t.Errorf("unexpected all-day event %+v", xmas)
+	}
+	if days := xmas.Days(); len(days) != 2 || days[0] != "2025-12-25" || days[1] != "2025-12-26" {
+		t.Errorf("expected two holiday dates, got %v", days)
+	}
+
+	standup := cal.Events[1]
+	if standup.Summary != "Standup with a very long title that has been folded across two lines" {
+		t.Errorf("folded line not joined: %q", standup.Summary)
+	}
+	if standup.Description != "Line one\nLine two" || standup.RRule != "FREQ=WEEKLY;BYDAY=MO" {
+		t.Errorf("unexpected standup %+v", standup)
+	}
+	// 09:30 EDT is 13:30 UTC (DST started on 9 March 2025).
+	if got := standup.Start.UTC(); got != time.Date(2025, 3, 10, 13, 30, 0, 0, time.UTC) {
+		t.Errorf("unexpected start %v", got)
+	}
+	if standup.End.Sub(standup.Start) != 15*time.Minute {
+		t.Errorf("unexpected duration %v", standup.End.Sub(standup.Start))
+	}
+
+	review := cal.Events[2]
+	if review.Location != "Room 1" || review.Start.Location() != time.UTC {
+		t.Errorf("unexpected review %+v", review)
+	}
+}
+
+func TestParse_WindowsTimezones(t *testing.T) {
+	cal, err := Parse(strings.NewReader("BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VTIMEZONE\r\n" +
+		"TZID:W. Europe Standard Time\r\n" +
+		"BEGIN:STANDARD\r\n" +
+		"DTSTART:16010101T030000\r\n" +
+		"TZOFFSETFROM:+0200\r\n" +
+		"TZOFFSETTO:+0100\r\n" +
+		"RRULE:FREQ=YEARLY;BYDAY=-1SU;BYMONTH=10\r\n" +
+		"END:STANDARD\r\n" +
+		"BEGIN:DAYLIGHT\r\n" +
+		"DTSTART:16010101T020000\r\n" +
+		"TZOFFSETFROM:+0100\r\n" +
+		"TZOFFSETTO:+0200\r\n" +
+		"RRULE:FREQ=YEARLY;BYDAY=-1SU;BYMONTH=3\r\n" +
+		"END:DAYLIGHT\r\n" +
+		"END:VTIMEZONE\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"DTSTART;TZID=W. Europe Standard Time:20250115T090000\r\n" +
+		"DTEND;TZID=W. Europe Standard Time:20250115T100000\r\n" +
+		"SUMMARY:Winter\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"DTSTART;TZID=\"W. Europe Standard Time\":20250330T090000\r\n" +
+		"SUMMARY:First day of summer time\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"DTSTART;TZID=Mars Standard Time:20250715T090000\r\n" +
+		"DTEND;TZID=Mars Standard Time:20250715T100000\r\n" +
+		"SUMMARY:Elsewhere\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cal.Events) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(cal.Events))
+	}
+	if got := cal.Events[0].Start.UTC(); got != time.Date(2025, 1, 15, 8, 0, 0, 0, time.UTC) {
+		t.Errorf("winter start = %v", got)
+	}
+	if got := cal.Events[1].Start.UTC(); got != time.Date(2025, 3, 30, 7, 0, 0, 0, time.UTC) {
+		t.Errorf("summer start = %v", got)
+	}
+	if got := cal.Events[2].Start; got != time.Date(2025, 7, 15, 9, 0, 0, 0, time.Local) {
+		t.Errorf("unresolved zone start = %v, want local time", got)
+	}
+	if len(cal.Warnings) != 1 || !strings.Contains(cal.Warnings[0], `event "Elsewhere": unknown TZID "Mars Standard Time"`) {
+		t.Errorf("warnings = %v", cal.Warnings)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	for input, want := range map[string]string{
+		"BEGIN:VEVENT\nEND:VEVENT\n":                                 "no VCALENDAR",
+		"BEGIN:VCALENDAR\nBEGIN:VEVENT\nEND:VCALENDAR\n":             "unexpected END",
+		"BEGIN:VCALENDAR\nSUMMARY\nEND:VCALENDAR\n":                  "missing ':'",
+		"BEGIN:VCALENDAR\n":                                          "missing END:VCALENDAR",
+		"BEGIN:VCALENDAR\nBEGIN:VEVENT\nEND:VEVENT\nEND:VCALENDAR\n": "no DTSTART",
+	} {
+		if _, err := Parse(strings.NewReader(input)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Parse(%q) = %v, want %q", input, err, want)
+		}
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	for in, want := range map[string]time.Duration{
+		"PT1H30M": 90 * time.Minute,
+		"P1D":     24 * time.Hour,
+		"P1W":     7 * 24 * time.Hour,
+		"-PT15M":  -15 * time.Minute,
+		"P1DT2H":  26 * time.Hour,
+	} {
+		if got, err := ParseDuration(in); err != nil || got != want {
+			t.Errorf("ParseDuration(%q) = %v, %v", in, got, err)
+		}
+	}
+	for _, bad := range []string{"1H", "PT", "P1H", "PTH", "PT5"} {
+		if _, err := ParseDuration(bad); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestCalendar_On(t *testing.T) {
+	cal, err := Parse(strings.NewReader(holidaysICS))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cal.On("2025-12-26", time.UTC); len(got) != 1 || got[0].UID != "xmas@test" {
+		t.Errorf("expected the two-day holiday on its second day, got %v", got)
+	}
+	// The 14:00Z review is on the 12th in Auckland, the standup on the 11th.
+	auckland, err := time.LoadLocation("Pacific/Auckland")
+	if err != nil {
+		t.Skip("no zoneinfo")
+	}
+	if got := cal.On("2025-03-12", auckland); len(got) != 1 || got[0].Summary != "Review" {
+		t.Errorf("expected only the review on 12 March in Auckland, got %v", got)
+	}
+	if got := cal.On("2025-03-11", time.UTC); len(got) != 1 || got[0].Summary != "Review" {
+		t.Errorf("expected only the review on 11 March UTC, got %v", got)
+	}
+}
@@ -0,0 +1,149 @@
+package ics
+
+import (
+	"strings"
+	"time"
+
+	"gitlab.com/caffeinatedjack/sleepless/pkg/task"
+)
+
+// uidSuffix marks UIDs of goals exported by regimen, so a re-import can
+// recognise them.
+const uidSuffix = "@regimen"
+
+// TaskUID returns the UID a goal is exported under.
+func TaskUID(t *task.Task) string {
+	return t.ID + uidSuffix
+}
+
+// TaskIDFromUID returns the goal ID for a UID written by TaskUID, or "".
+func TaskIDFromUID(uid string) string {
+	id, ok := strings.CutSuffix(uid, uidSuffix)
+	if !ok {
+		return ""
+	}
+	// Due-date events carry a suffix after the ID.
+	id, _, _ = strings.Cut(id, "-")
+	return id
+}
+
+// TodoFromTask converts a goal to a VTODO. The topic becomes the first
+// category, followed by the tags; notes become the description.
+func TodoFromTask(t *task.Task) Todo {
+	todo := Todo{
+		UID:         TaskUID(t),
+		Summary:     t.Title,
+		Description: strings.Join(t.Notes, "\n"),
+		Status:      "NEEDS-ACTION",
+		Priority:    priorityToICS(t.Priority),
+	}
+	if t.Topic != "" {
+		todo.Categories = append(todo.Categories, t.Topic)
+	}
+	todo.Categories = append(todo.Categories, t.Tags...)
+	if t.Due != nil {
+		due := dateOnly(*t.Due)
+		todo.Due, todo.DueDate = &due, true
+	}
+	if t.IsComplete() {
+		todo.Status = "COMPLETED"
+		todo.Completed = t.Completed
+	}
+	return todo
+}
+
+// TaskFromTodo converts a VTODO to a new goal. The first category is used as
+// the topic when it is a valid topic name; the rest become tags.
+func TaskFromTodo(todo Todo) *task.Task {
+	title := todo.Summary
+	if title == "" {
+		title = "(untitled)"
+	}
+	t := task.New(title)
+	t.Priority = priorityFromICS(todo.Priority)
+	if todo.Description != "" {
+		t.Notes = strings.Split(todo.Description, "\n")
+	}
+	cats := todo.Categories
+	if len(cats) > 0 && isTopicName(cats[0]) {
+		t.Topic = cats[0]
+		cats = cats[1:]
+	}
+	for _, c := range cats {
+		t.Tags = append(t.Tags, strings.ToLower(c))
+	}
+	if todo.Due != nil {
+		due := dateOnly(*todo.Due)
+		if !todo.DueDate {
+			local := todo.Due.Local()
+			due = time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+		}
+		t.Due = &due
+	}
+	if todo.Status == "COMPLETED" || todo.Completed != nil {
+		t.Status = task.StatusComplete
+		t.Completed = todo.Completed
+		if t.Completed == nil {
+			now := time.Now()
+			t.Completed = &now
+		}
+	}
+	return t
+}
+
+// DueEvent converts a goal's due date to an all-day VEVENT, as shown by
+// 'goals view calendar'.
+func DueEvent(t *task.Task) Event {
+	due := dateOnly(*t.Due)
+	desc := "Goal " + t.ShortID()
+	if t.Topic != "" {
+		desc += " in " + t.Topic
+	}
+	return Event{
+		UID:         t.ID + "-due" + uidSuffix,
+		Summary:     t.Title,
+		Description: desc,
+		Start:       due,
+		End:         due.AddDate(0, 0, 1),
+		AllDay:      true,
+	}
+}
+
+func dateOnly(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func priorityToICS(p task.Priority) int {
+	switch p {
+	case task.PriorityHigh:
+		return 1
+	case task.PriorityLow:
+		return 9
+	default:
+		return 5
+	}
+}
+
+func priorityFromICS(n int) task.Priority {
+	switch {
+	case n >= 1 && n <= 4:
+		return task.PriorityHigh
+	case n >= 6:
+		return task.PriorityLow
+	default:
+		return task.PriorityMedium
+	}
+}
+
+// isTopicName reports whether s can be used as a topic file name.
+func isTopicName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
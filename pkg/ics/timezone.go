@@ -0,0 +1,192 @@
+package ics
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// vtimezone is a VTIMEZONE definition, used for TZIDs that are not IANA
+// names, such as Outlook's "W. Europe Standard Time".
+type vtimezone struct {
+	id          string
+	observances []observance
+}
+
+// observance is a STANDARD or DAYLIGHT part of a VTIMEZONE. Yearly rules of
+// the form BYMONTH=3;BYDAY=-1SU are followed; any other rule is read as a
+// single change at start.
+type observance struct {
+	// start is the DTSTART wall-clock time, held in UTC.
+	start time.Time
+	// offset is TZOFFSETTO in seconds east of UTC.
+	offset  int
+	month   time.Month
+	week    int
+	weekday time.Weekday
+}
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// parseTimezones reads the calendar's VTIMEZONE components by TZID. Ones
+// that cannot be read are left out.
+func parseTimezones(root *Component) map[string]*vtimezone {
+	zones := make(map[string]*vtimezone)
+	for _, c := range root.Children {
+		if c.Name != "VTIMEZONE" {
+			continue
+		}
+		z := &vtimezone{id: c.Text("TZID")}
+		for _, part := range c.Children {
+			if part.Name != "STANDARD" && part.Name != "DAYLIGHT" {
+				continue
+			}
+			if o, ok := parseObservance(part); ok {
+				z.observances = append(z.observances, o)
+			}
+		}
+		if z.id != "" && len(z.observances) > 0 {
+			zones[z.id] = z
+		}
+	}
+	return zones
+}
+
+func parseObservance(c *Component) (observance, bool) {
+	var o observance
+	start, ok := c.Get("DTSTART")
+	if !ok {
+		return o, false
+	}
+	t, err := time.Parse("20060102T150405", strings.TrimSpace(start.Value))
+	if err != nil {
+		return o, false
+	}
+	o.start = t
+	to, ok := c.Get("TZOFFSETTO")
+	if !ok {
+		return o, false
+	}
+	if o.offset, err = parseOffset(to.Value); err != nil {
+		return o, false
+	}
+
+	if rule, ok := c.Get("RRULE"); ok {
+		parts := make(map[string]string)
+		for _, kv := range strings.Split(rule.Value, ";") {
+			k, v, _ := strings.Cut(kv, "=")
+			parts[strings.ToUpper(k)] = strings.ToUpper(v)
+		}
+		month, err := strconv.Atoi(parts["BYMONTH"])
+		day := parts["BYDAY"]
+		if parts["FREQ"] == "YEARLY" && err == nil && month >= 1 && month <= 12 && len(day) >= 3 {
+			wd, ok := weekdays[day[len(day)-2:]]
+			week, err := strconv.Atoi(day[:len(day)-2])
+			if ok && err == nil && week != 0 && week >= -5 && week <= 5 {
+				o.month, o.week, o.weekday = time.Month(month), week, wd
+			}
+		}
+	}
+	return o, true
+}
+
+// parseOffset parses a UTC offset such as +0100, -0500 or +053000.
+func parseOffset(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if len(s) != 5 && len(s) != 7 || s[0] != '+' && s[0] != '-' {
+		return 0, fmt.Errorf("invalid UTC offset %q", s)
+	}
+	n := []int{0, 0, 0}
+	for i := 0; i*2+1 < len(s); i++ {
+		v, err := strconv.Atoi(s[i*2+1 : i*2+3])
+		if err != nil {
+			return 0, fmt.Errorf("invalid UTC offset %q", s)
+		}
+		n[i] = v
+	}
+	secs := n[0]*3600 + n[1]*60 + n[2]
+	if s[0] == '-' {
+		secs = -secs
+	}
+	return secs, nil
+}
+
+// onset returns when the observance starts in year, as a wall-clock time
+// held in UTC, or false if it does not start that year.
+func (o observance) onset(year int) (time.Time, bool) {
+	if o.month == 0 {
+		return o.start, o.start.Year() == year
+	}
+	if year < o.start.Year() {
+		return time.Time{}, false
+	}
+	hh, mm, ss := o.start.Clock()
+	var d time.Time
+	if o.week > 0 {
+		d = time.Date(year, o.month, 1, hh, mm, ss, 0, time.UTC)
+		d = d.AddDate(0, 0, (int(o.weekday)-int(d.Weekday())+7)%7+(o.week-1)*7)
+	} else {
+		d = time.Date(year, o.month+1, 0, hh, mm, ss, 0, time.UTC)
+		d = d.AddDate(0, 0, -((int(d.Weekday())-int(o.weekday)+7)%7)+(o.week+1)*7)
+	}
+	return d, d.Month() == o.month && !d.Before(o.start)
+}
+
+// location returns a fixed zone with the offset in force at the wall-clock
+// time wall, held in UTC.
+func (z *vtimezone) location(wall time.Time) *time.Location {
+	var latest time.Time
+	offset, found := 0, false
+	for _, o := range z.observances {
+		for _, year := range []int{wall.Year() - 1, wall.Year()} {
+			if t, ok := o.onset(year); ok && !t.After(wall) && (!found || t.After(latest)) {
+				latest, offset, found = t, o.offset, true
+			}
+		}
+	}
+	if !found {
+		// Before every change: use the earliest observance.
+		first := z.observances[0]
+		for _, o := range z.observances[1:] {
+			if o.start.Before(first.start) {
+				first = o
+			}
+		}
+		offset = first.offset
+	}
+	return time.FixedZone(z.id, offset)
+}
+
+// timeReader reads DATE-TIME values with the calendar's time zones. A TZID
+// that is neither an IANA name nor defined by a VTIMEZONE is read in local
+// time, with a warning, so one odd event does not stop the whole calendar.
+type timeReader struct {
+	zones    map[string]*vtimezone
+	warnings []string
+	warned   map[string]bool
+}
+
+func (r *timeReader) parse(p Property, what string) (time.Time, bool, error) {
+	return parseTime(p, func(tz, value string) (*time.Location, error) {
+		if loc, err := time.LoadLocation(tz); err == nil {
+			return loc, nil
+		}
+		if z := r.zones[tz]; z != nil {
+			if wall, err := time.Parse("20060102T150405", value); err == nil {
+				return z.location(wall), nil
+			}
+		}
+		if key := what + "\x00" + tz; !r.warned[key] {
+			if r.warned == nil {
+				r.warned = make(map[string]bool)
+			}
+			r.warned[key] = true
+			r.warnings = append(r.warnings, fmt.Sprintf("%s: unknown TZID %q, read in local time", what, tz))
+		}
+		return time.Local, nil
+	})
+}
@@ -0,0 +1,140 @@
+package ics
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ProdID identifies calendars written by this package.
+const ProdID = "-//sleepless//regimen//EN"
+
+// maxLineOctets is the longest content line before folding (RFC 5545 3.1).
+const maxLineOctets = 75
+
+// Encode writes the calendar's events and todos. stamp is used for DTSTAMP;
+// a zero stamp means now.
+func Encode(w io.Writer, cal *Calendar, stamp time.Time) error {
+	if stamp.IsZero() {
+		stamp = time.Now()
+	}
+	e := &encoder{w: bufio.NewWriter(w), stamp: formatUTC(stamp)}
+	e.line("BEGIN:VCALENDAR")
+	e.line("VERSION:2.0")
+	e.line("PRODID:" + ProdID)
+	e.line("CALSCALE:GREGORIAN")
+	for _, ev := range cal.Events {
+		e.event(ev)
+	}
+	for _, todo := range cal.Todos {
+		e.todo(todo)
+	}
+	e.line("END:VCALENDAR")
+	if e.err != nil {
+		return e.err
+	}
+	return e.w.Flush()
+}
+
+type encoder struct {
+	w     *bufio.Writer
+	stamp string
+	err   error
+}
+
+func (e *encoder) event(ev Event) {
+	e.line("BEGIN:VEVENT")
+	e.line("UID:" + Escape(ev.UID))
+	e.line("DTSTAMP:" + e.stamp)
+	if ev.AllDay {
+		e.line("DTSTART;VALUE=DATE:" + ev.Start.Format("20060102"))
+		if !ev.End.IsZero() {
+			e.line("DTEND;VALUE=DATE:" + ev.End.Format("20060102"))
+		}
+	} else {
+		e.line("DTSTART:" + formatUTC(ev.Start))
+		if !ev.End.IsZero() {
+			e.line("DTEND:" + formatUTC(ev.End))
+		}
+	}
+	e.text("SUMMARY", ev.Summary)
+	e.text("DESCRIPTION", ev.Description)
+	e.text("LOCATION", ev.Location)
+	if ev.RRule != "" {
+		e.line("RRULE:" + ev.RRule)
+	}
+	e.line("END:VEVENT")
+}
+
+func (e *encoder) todo(t Todo) {
+	e.line("BEGIN:VTODO")
+	e.line("UID:" + Escape(t.UID))
+	e.line("DTSTAMP:" + e.stamp)
+	e.text("SUMMARY", t.Summary)
+	e.text("DESCRIPTION", t.Description)
+	if t.Due != nil {
+		if t.DueDate {
+			e.line("DUE;VALUE=DATE:" + t.Due.Format("20060102"))
+		} else {
+			e.line("DUE:" + formatUTC(*t.Due))
+		}
+	}
+	if t.Status != "" {
+		e.line("STATUS:" + t.Status)
+	}
+	if t.Completed != nil {
+		e.line("COMPLETED:" + formatUTC(*t.Completed))
+	}
+	if t.Priority > 0 {
+		e.line("PRIORITY:" + strconv.Itoa(t.Priority))
+	}
+	if len(t.Categories) > 0 {
+		cats := make([]string, len(t.Categories))
+		for i, c := range t.Categories {
+			cats[i] = Escape(c)
+		}
+		e.line("CATEGORIES:" + strings.Join(cats, ","))
+	}
+	e.line("END:VTODO")
+}
+
+func (e *encoder) text(name, value string) {
+	if value != "" {
+		e.line(name + ":" + Escape(value))
+	}
+}
+
+// line writes a content line, folding it at 75 octets without splitting a
+// UTF-8 character.
+func (e *encoder) line(s string) {
+	if e.err != nil {
+		return
+	}
+	limit := maxLineOctets
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		_, e.err = e.w.WriteString(s[:cut] + "\r\n ")
+		s = s[cut:]
+		// Continuation lines start with a space, which counts.
+		limit = maxLineOctets - 1
+	}
+	if e.err == nil {
+		_, e.err = e.w.WriteString(s + "\r\n")
+	}
+}
+
+func formatUTC(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// Escape encodes a TEXT value.
+func Escape(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	return r.Replace(s)
+}
@@ -0,0 +1,135 @@
+package ics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"gitlab.com/caffeinatedjack/sleepless/pkg/task"
+)
+
+var stamp = time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func TestEncode_RoundTrip(t *testing.T) {
+	src, err := Parse(strings.NewReader(holidaysICS))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := Encode(&buf, src, stamp); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Parse(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Events) != len(src.Events) {
+		t.Fatalf("expected %d events, got %d", len(src.Events), len(got.Events))
+	}
+	for i, want := range src.Events {
+		ev := got.Events[i]
+		if ev.Summary != want.Summary || ev.Description != want.Description ||
+			ev.Location != want.Location || ev.RRule != want.RRule || ev.AllDay != want.AllDay {
+			t.Errorf("event %d: got %+v, want %+v", i, ev, want)
+		}
+		if !ev.Start.Equal(want.Start) || !ev.End.Equal(want.End) {
+			t.Errorf("event %d: times %v-%v, want %v-%v", i, ev.Start, ev.End, want.Start, want.End)
+		}
+	}
+}
+
+func TestEncode_FoldAndEscape(t *testing.T) {
+	summary := strings.Repeat("héllo, wörld; ", 12) + "back\\slash\nnew line"
+	cal := &Calendar{Events: []Event{{
+		UID:     "fold@test",
+		Summary: summary,
+		Start:   time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC),
+	}}}
+	var buf bytes.Buffer
+	if err := Encode(&buf, cal, stamp); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line longer than 75 octets: %q", line)
+		}
+		if !utf8.ValidString(line) {
+			t.Errorf("fold split a character: %q", line)
+		}
+	}
+	got, err := Parse(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Events[0].Summary != summary {
+		t.Errorf("summary changed in round trip:\n got %q\nwant %q", got.Events[0].Summary, summary)
+	}
+}
+
+func TestTodo_RoundTrip(t *testing.T) {
+	due := time.Date(2025, 4, 2, 0, 0, 0, 0, time.UTC)
+	goal := task.New("Renew passport")
+	goal.Topic = "admin"
+	goal.Priority = task.PriorityHigh
+	goal.Tags = []string{"travel", "urgent"}
+	goal.Notes = []string{"Photos first", "Form HM-1, signed"}
+	goal.Due = &due
+
+	done := task.New("Book flights")
+	done.Due = &due
+	done.Priority = task.PriorityLow
+	done.Complete()
+
+	cal := &Calendar{Todos: []Todo{TodoFromTask(goal), TodoFromTask(done)}}
+	var buf bytes.Buffer
+	if err := Encode(&buf, cal, stamp); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "DUE;VALUE=DATE:20250402\r\n") {
+		t.Errorf("expected a date-only DUE, got:\n%s", buf.String())
+	}
+	parsed, err := Parse(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed.Todos) != 2 {
+		t.Fatalf("expected 2 todos, got %d", len(parsed.Todos))
+	}
+
+	back := TaskFromTodo(parsed.Todos[0])
+	if back.Title != goal.Title || back.Topic != "admin" || back.Priority != task.PriorityHigh {
+		t.Errorf("unexpected task %+v", back)
+	}
+	if strings.Join(back.Tags, ",") != "travel,urgent" || strings.Join(back.Notes, "|") != "Photos first|Form HM-1, signed" {
+		t.Errorf("tags or notes lost: %v %v", back.Tags, back.Notes)
+	}
+	if back.Due == nil || !back.Due.Equal(due) {
+		t.Errorf("due date %v, want %v", back.Due, due)
+	}
+	if TaskIDFromUID(parsed.Todos[0].UID) != goal.ID {
+		t.Errorf("UID %q does not map back to %s", parsed.Todos[0].UID, goal.ID)
+	}
+
+	back = TaskFromTodo(parsed.Todos[1])
+	if !back.IsComplete() || back.Priority != task.PriorityLow || back.Topic != "inbox" {
+		t.Errorf("unexpected completed task %+v", back)
+	}
+}
+
+func TestDueEvent(t *testing.T) {
+	due := time.Date(2025, 4, 2, 0, 0, 0, 0, time.UTC)
+	goal := task.New("Renew passport")
+	goal.Due = &due
+	ev := DueEvent(goal)
+	if !ev.AllDay || strings.Join(ev.Days(), ",") != "2025-04-02" {
+		t.Errorf("unexpected event %+v", ev)
+	}
+	if TaskIDFromUID(ev.UID) != goal.ID {
+		t.Errorf("UID %q does not map back to %s", ev.UID, goal.ID)
+	}
+	if TaskIDFromUID("someone-else@example.com") != "" {
+		t.Error("expected a foreign UID to map to no goal")
+	}
+}
@@ -87,14 +87,8 @@ func ExtractInlineTags(content string) []string {
 	words := strings.Fields(content)
 
 	for _, word := range words {
-		if strings.HasPrefix(word, "#") {
-			tag := strings.TrimPrefix(word, "#")
-			tag = strings.ToLower(tag)
-			// Remove trailing punctuation
-			tag = strings.TrimRight(tag, ".,;:!?")
-			if tag != "" {
-				tagMap[tag] = true
-			}
+		if tag := InlineTag(word); tag != "" {
+			tagMap[tag] = true
 		}
 	}
 
@@ -105,3 +99,20 @@ func ExtractInlineTags(content string) []string {
 
 	return tags
 }
+
+// InlineTag returns the tag a whitespace-delimited word stands for, in
+// lowercase without the leading # or trailing punctuation, or "" if the word
+// is not a tag. Heading markers such as "##" are not tags.
+func InlineTag(word string) string {
+	if !strings.HasPrefix(word, "#") {
+		return ""
+	}
+	tag := strings.TrimPrefix(word, "#")
+	tag = strings.ToLower(tag)
+	// Remove trailing punctuation
+	tag = strings.TrimRight(tag, ".,;:!?")
+	if strings.Trim(tag, "#") == "" {
+		return ""
+	}
+	return tag
+}
@@ -90,6 +90,24 @@ func TestExtractInlineTags(t *testing.T) {
 	}
 }
 
+func TestInlineTag(t *testing.T) {
+	for word, want := range map[string]string{
+		"#Work":  "work",
+		"#c++,":  "c++",
+		"#a/b!":  "a/b",
+		"##":     "",
+		"#":      "",
+		"#.":     "",
+		"word":   "",
+		"x#y":    "",
+		"#v1.2.": "v1.2",
+	} {
+		if got := InlineTag(word); got != want {
+			t.Errorf("InlineTag(%q) = %q, want %q", word, got, want)
+		}
+	}
+}
+
 func TestExtractInlineTagsNoTags(t *testing.T) {
 	content := "This note has no tags."
 
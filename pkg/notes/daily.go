@@ -54,3 +54,48 @@ func (s *Store) GetOrCreateDaily(date string) (*Note, error) {
 	}
 	return note, nil
 }
+
+// SetSection replaces the body of the "## <heading>" section in a note, or
+// appends the section if it is missing. The section runs until the next
+// heading of level two or higher.
+func SetSection(note *Note, heading, content string) {
+	title := "## " + heading
+	lines := strings.Split(note.Body, "\n")
+	start := -1
+	for i, line := range lines {
+		if strings.TrimSpace(line) == title {
+			start = i
+			break
+		}
+	}
+	section := title + "\n\n" + strings.TrimRight(content, "\n") + "\n"
+
+	if start < 0 {
+		body := strings.TrimRight(note.Body, "\n")
+		if body == "" && note.Date != "" {
+			body = fmt.Sprintf("# %s", note.Date)
+		}
+		if body != "" {
+			body += "\n\n"
+		}
+		note.Body = body + section
+		return
+	}
+
+	end := len(lines)
+	for i := start + 1; i < len(lines); i++ {
+		if strings.HasPrefix(lines[i], "# ") || strings.HasPrefix(lines[i], "## ") {
+			end = i
+			break
+		}
+	}
+	before := strings.Join(lines[:start], "\n")
+	if start > 0 {
+		before += "\n"
+	}
+	after := strings.Join(lines[end:], "\n")
+	note.Body = before + section
+	if after != "" {
+		note.Body += "\n" + after
+	}
+}
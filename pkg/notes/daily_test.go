@@ -0,0 +1,19 @@
+package notes
+
+import "testing"
+
+func TestSetSection(t *testing.T) {
+	note := NewDailyNote("2025-03-10")
+	SetSection(note, "Agenda", "- 09:30-09:45 Standup")
+	want := "# 2025-03-10\n\n## Agenda\n\n- 09:30-09:45 Standup\n"
+	if note.Body != want {
+		t.Fatalf("new section:\n got %q\nwant %q", note.Body, want)
+	}
+
+	note.Body += "\n## 10:02\n\nShipped the fix\n"
+	SetSection(note, "Agenda", "- 14:00-15:00 Review\n")
+	want = "# 2025-03-10\n\n## Agenda\n\n- 14:00-15:00 Review\n\n## 10:02\n\nShipped the fix\n"
+	if note.Body != want {
+		t.Errorf("replaced section:\n got %q\nwant %q", note.Body, want)
+	}
+}
@@ -28,6 +28,10 @@ type Note struct {
 	// Date is the date for daily notes (YYYY-MM-DD format).
 	Date string `yaml:"date,omitempty"`
 
+	// Period is the key of weekly, monthly, quarterly and yearly notes
+	// (2026-W03, 2026-01, 2026-Q1 or 2026).
+	Period string `yaml:"period,omitempty"`
+
 	// Created is the creation timestamp.
 	Created time.Time `yaml:"created"`
 
@@ -37,6 +41,18 @@ type Note struct {
 	// Tags are labels for organization and filtering.
 	Tags []string `yaml:"tags,omitempty"`
 
+	// Mood is the day's mood, from 1 (low) to 5 (high).
+	Mood *int `yaml:"mood,omitempty"`
+
+	// Energy is the day's energy level, from 1 (low) to 5 (high).
+	Energy *int `yaml:"energy,omitempty"`
+
+	// Sleep is the hours slept the night before.
+	Sleep *float64 `yaml:"sleep,omitempty"`
+
+	// Habits records whether each tracked habit was done that day.
+	Habits map[string]bool `yaml:"habits,omitempty"`
+
 	// Body is the markdown content (without frontmatter).
 	Body string `yaml:"-"`
 }
@@ -0,0 +1,676 @@
+package notes
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gitlab.com/caffeinatedjack/sleepless/pkg/markdown"
+)
+
+// ImportSource is a tool or layout that notes can be imported from.
+type ImportSource string
+
+const (
+	// ImportObsidian is an Obsidian vault.
+	ImportObsidian ImportSource = "obsidian"
+	// ImportLogseq is a Logseq graph, with journals/ and pages/.
+	ImportLogseq ImportSource = "logseq"
+	// ImportDayOne is a Day One JSON export.
+	ImportDayOne ImportSource = "dayone"
+	// ImportDir is a plain folder of markdown files.
+	ImportDir ImportSource = "dir"
+)
+
+// ParseImportSource parses an import source name.
+func ParseImportSource(s string) (ImportSource, error) {
+	switch src := ImportSource(strings.ToLower(s)); src {
+	case ImportObsidian, ImportLogseq, ImportDayOne, ImportDir:
+		return src, nil
+	}
+	return "", fmt.Errorf("unknown import source %q (use obsidian, logseq, dayone or dir)", s)
+}
+
+// AttachmentsDir is the directory, in the notes directory, that imported
+// attachments are copied to.
+const AttachmentsDir = "attachments"
+
+// What happens to each imported note.
+const (
+	ImportCreate    = "create"
+	ImportMerge     = "merge"
+	ImportDuplicate = "duplicate"
+)
+
+// ImportItem is one note in an import.
+type ImportItem struct {
+	// Sources are the files the note comes from, relative to the import
+	// root. A Day One day can come from several entries.
+	Sources []string `json:"sources"`
+	Key     string   `json:"key"`
+	Title   string   `json:"title,omitempty"`
+	// Action is ImportCreate, ImportMerge (into an existing daily note) or
+	// ImportDuplicate, in which case the note is skipped.
+	Action      string `json:"action"`
+	DuplicateOf string `json:"duplicate_of,omitempty"`
+	// Links counts the converted links; Unresolved lists the link targets
+	// that matched no imported note or file and were left as written.
+	Links      int      `json:"links"`
+	Unresolved []string `json:"unresolved,omitempty"`
+}
+
+// Attachment is a file copied alongside the imported notes.
+type Attachment struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// ImportPlan is a prepared import: the note files to write, the
+// attachments to copy and a report of what happens to each source note.
+type ImportPlan struct {
+	Plan
+	Source      ImportSource `json:"source"`
+	Root        string       `json:"root"`
+	Items       []ImportItem `json:"items"`
+	Attachments []Attachment `json:"attachments"`
+	Warnings    []string     `json:"warnings,omitempty"`
+}
+
+// Count returns the number of items with the action.
+func (p *ImportPlan) Count(action string) int {
+	n := 0
+	for _, it := range p.Items {
+		if it.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+// sourceNote is a note read from another tool, before its links are
+// converted.
+type sourceNote struct {
+	sources []string
+	// dir is the directory of the source file, for relative links.
+	dir string
+	// names are the names links may use for the note: its title, file name,
+	// path and aliases.
+	names []string
+	title string
+	date  string
+	body  string
+	tags  []string
+	// photos maps Day One photo identifiers to files.
+	photos           map[string]string
+	created, updated time.Time
+
+	key  string
+	hash string
+}
+
+var (
+	// importWikiRe matches [[target]], [[target#anchor|text]] and ![[embeds]].
+	importWikiRe = regexp.MustCompile(`(!?)\[\[([^\[\]|#]*)(#[^\[\]|]*)?(?:\|([^\[\]]*))?\]\]`)
+	// importMdRe matches [text](target) and ![alt](target).
+	importMdRe = regexp.MustCompile(`(!?)\[([^\[\]]*)\]\((<[^<>]+>|[^()\s]+)\)`)
+)
+
+// importer holds the state of one import while it is planned.
+type importer struct {
+	store  *Store
+	plan   *ImportPlan
+	notes  []*sourceNote
+	byName map[string]*sourceNote
+	// files indexes the other files under the root by lowercased base name,
+	// for links that give only a file name.
+	files map[string]string
+	// copies maps attachments to their name in the attachments directory.
+	copies map[string]string
+	taken  map[string]string
+}
+
+// PlanImport reads the notes at root and prepares importing them. Nothing
+// is written until the plan is applied.
+func (s *Store) PlanImport(source ImportSource, root string) (*ImportPlan, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	im := &importer{
+		store:  s,
+		plan:   &ImportPlan{Source: source, Root: root},
+		byName: make(map[string]*sourceNote),
+		files:  make(map[string]string),
+		copies: make(map[string]string),
+		taken:  make(map[string]string),
+	}
+
+	if source == ImportDayOne {
+		im.notes, err = readDayOne(root)
+	} else {
+		im.notes, err = im.readTree(source, root)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(im.notes) == 0 {
+		return nil, fmt.Errorf("no notes found in %s", root)
+	}
+	if err := im.assignKeys(); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.LoadAll()
+	if err != nil {
+		return nil, err
+	}
+	byHash := make(map[string]string)
+	byKey := make(map[string]*Note)
+	for _, n := range existing {
+		byHash[contentHash(stripTitle(n.Body))] = n.Key()
+		byKey[n.Key()] = n
+	}
+
+	// Find duplicates first, then convert links again so that links to a
+	// duplicate point at the note it duplicates.
+	dups := make(map[*sourceNote]string)
+	seen := make(map[string]*sourceNote)
+	for _, n := range im.notes {
+		body, _, _ := im.convert(n, false)
+		n.hash = contentHash(stripTitle(body))
+		if key, ok := byHash[n.hash]; ok {
+			dups[n] = key
+		} else if first, ok := seen[n.hash]; ok {
+			dups[n] = first.key
+		} else if old, ok := byKey[n.key]; ok && n.date != "" && strings.Contains(normalizeContent(old.Body), normalizeContent(stripTitle(body))) {
+			dups[n] = n.key
+		} else {
+			seen[n.hash] = n
+		}
+	}
+	for n, key := range dups {
+		n.key = key
+	}
+	if err := im.rekeyChanged(dups); err != nil {
+		return nil, err
+	}
+
+	for _, n := range im.notes {
+		item := ImportItem{Sources: n.sources, Key: n.key, Title: n.title}
+		if key, ok := dups[n]; ok {
+			item.Action, item.DuplicateOf = ImportDuplicate, key
+			im.plan.Items = append(im.plan.Items, item)
+			continue
+		}
+
+		body, links, unresolved := im.convert(n, true)
+		item.Links, item.Unresolved = links, unresolved
+		change, err := im.change(n, body, byKey[n.key])
+		if err != nil {
+			return nil, err
+		}
+		item.Action = ImportCreate
+		if change.Before != "" {
+			item.Action = ImportMerge
+		}
+		im.plan.Changes = append(im.plan.Changes, change)
+		im.plan.Items = append(im.plan.Items, item)
+	}
+
+	for from, name := range im.copies {
+		// A file of the same name is only reused when its content matches.
+		if to := filepath.Join(s.NotesDir, AttachmentsDir, name); !fileExists(to) {
+			im.plan.Attachments = append(im.plan.Attachments, Attachment{From: from, To: to})
+		}
+	}
+	sort.Slice(im.plan.Attachments, func(i, j int) bool { return im.plan.Attachments[i].To < im.plan.Attachments[j].To })
+	return im.plan, nil
+}
+
+// assignKeys gives each note its date or a floating ID, and indexes the
+// notes by the names links may use. Floating IDs are derived from the source
+// path so that importing the same files again, from any source, converts
+// their links as before and finds them as duplicates. A derived ID that is
+// taken but not by a duplicate is replaced later, by rekeyChanged.
+func (im *importer) assignKeys() error {
+	used := make(map[string]bool)
+	byDate := make(map[string]*sourceNote)
+	var merged []*sourceNote
+	for _, n := range im.notes {
+		if n.date == "" {
+			merged = append(merged, n)
+			continue
+		}
+		// Two files for the same day, such as a journal and a page named
+		// after the date, become one daily note.
+		if first, ok := byDate[n.date]; ok {
+			first.sources = append(first.sources, n.sources...)
+			first.body = strings.TrimRight(first.body, "\n") + "\n\n" + stripTitle(n.body)
+			first.tags = append(first.tags, n.tags...)
+			first.names = append(first.names, n.names...)
+			continue
+		}
+		n.key = n.date
+		byDate[n.date] = n
+		merged = append(merged, n)
+	}
+	im.notes = merged
+
+	for _, n := range im.notes {
+		if n.key == "" {
+			sum := sha256.Sum256([]byte(strings.Join(n.sources, ",")))
+			id := hex.EncodeToString(sum[:4])
+			if used[id] {
+				var err error
+				if id, err = im.freeID(used); err != nil {
+					return err
+				}
+			}
+			n.key = id
+		}
+		used[n.key] = true
+		for _, name := range n.names {
+			name = strings.ToLower(name)
+			if _, ok := im.byName[name]; !ok {
+				im.byName[name] = n
+			}
+		}
+	}
+	sort.SliceStable(im.notes, func(i, j int) bool { return im.notes[i].sources[0] < im.notes[j].sources[0] })
+	return nil
+}
+
+// rekeyChanged gives a new ID to each floating note, other than a
+// duplicate, whose ID is already a note: one changed since an earlier
+// import, on either side. An import never writes over a floating note.
+func (im *importer) rekeyChanged(dups map[*sourceNote]string) error {
+	used := make(map[string]bool)
+	for _, n := range im.notes {
+		used[n.key] = true
+	}
+	for _, n := range im.notes {
+		if _, ok := dups[n]; ok || n.date != "" {
+			continue
+		}
+		if path, _ := im.store.FloatingPath(n.key); !fileExists(path) {
+			continue
+		}
+		id, err := im.freeID(used)
+		if err != nil {
+			return err
+		}
+		im.plan.Warnings = append(im.plan.Warnings, fmt.Sprintf("%s: note %s already exists and differs, imported as %s", n.sources[0], n.key, id))
+		n.key = id
+		used[id] = true
+	}
+	return nil
+}
+
+// freeID returns a random floating ID that is neither in used nor a note.
+func (im *importer) freeID(used map[string]bool) (string, error) {
+	for {
+		id, err := GenerateID()
+		if err != nil {
+			return "", fmt.Errorf("failed to generate ID: %w", err)
+		}
+		if path, _ := im.store.FloatingPath(id); !used[id] && !fileExists(path) {
+			return id, nil
+		}
+	}
+}
+
+// change prepares the file write for an imported note, merging it into an
+// existing daily note.
+func (im *importer) change(n *sourceNote, body string, old *Note) (FileChange, error) {
+	tags := normalizeTags(n.tags)
+	var note *Note
+	var path string
+	var before string
+
+	if n.date != "" {
+		path, _ = im.store.DailyPath(n.date)
+		if old != nil {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return FileChange{}, err
+			}
+			before = string(content)
+			merged := *old
+			merged.Body = strings.TrimRight(old.Body, "\n") + "\n\n## Imported from " + n.sources[0] + "\n\n" + stripTitle(body)
+			merged.AddTags(tags...)
+			merged.Updated = time.Now()
+			note = &merged
+		} else {
+			note = NewDailyNote(n.date)
+			note.Body = body
+		}
+	} else {
+		path, _ = im.store.FloatingPath(n.key)
+		note = NewFloatingNote(n.key)
+		note.Body = body
+	}
+
+	if old == nil {
+		note.AddTags(tags...)
+		if !n.created.IsZero() {
+			note.Created = n.created
+		}
+		note.Updated = n.updated
+		if note.Updated.Before(note.Created) {
+			note.Updated = note.Created
+		}
+		if note.Body != "" && !strings.HasPrefix(note.Body, "# ") {
+			title := n.title
+			if n.date != "" {
+				title = n.date
+			}
+			note.Body = "# " + title + "\n\n" + note.Body
+		}
+	}
+	note.Body = strings.TrimRight(note.Body, "\n") + "\n"
+
+	after, err := markdown.SerializeFrontmatter(note, note.Body)
+	if err != nil {
+		return FileChange{}, fmt.Errorf("failed to serialize note: %w", err)
+	}
+	change := FileChange{To: path, Before: before, After: after}
+	if before != "" {
+		change.From = path
+	}
+	return change, nil
+}
+
+// convert rewrites a note's links to point at the imported notes and copied
+// attachments. When record is false, attachments are only looked up, not
+// claimed. Links that match nothing are left as written.
+func (im *importer) convert(n *sourceNote, record bool) (string, int, []string) {
+	links := 0
+	var unresolved []string
+	lines := strings.Split(n.body, "\n")
+	inFence := false
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+
+		line = replaceOutsideCode(line, importWikiRe, func(m []string) (string, bool) {
+			embed, target, anchor, text := m[1], strings.TrimSpace(m[2]), m[3], m[4]
+			if ext := filepath.Ext(target); ext != "" && !strings.EqualFold(ext, ".md") {
+				file := im.findFile(n, target)
+				if file == "" {
+					unresolved = append(unresolved, m[0])
+					return "", false
+				}
+				links++
+				if text == "" {
+					text = filepath.Base(target)
+				}
+				return fmt.Sprintf("%s[%s](%s)", embed, text, im.attach(file, record)), true
+			}
+			key := im.lookup(n, target)
+			if key == "" {
+				unresolved = append(unresolved, m[0])
+				return "", false
+			}
+			links++
+			if text == "" && !strings.EqualFold(target, key) {
+				text = target
+			}
+			if text != "" {
+				return "[[" + key + anchor + "|" + text + "]]", true
+			}
+			return "[[" + key + anchor + "]]", true
+		})
+
+		line = replaceOutsideCode(line, importMdRe, func(m []string) (string, bool) {
+			embed, text, target := m[1], m[2], strings.Trim(m[3], "<>")
+			if id, ok := strings.CutPrefix(target, "dayone-moment://"); ok {
+				if file := n.photos[id]; file != "" {
+					links++
+					return fmt.Sprintf("%s[%s](%s)", embed, text, im.attach(file, record)), true
+				}
+				unresolved = append(unresolved, m[0])
+				return "", false
+			}
+			if schemeRe.MatchString(target) || strings.HasPrefix(target, "#") {
+				return "", false
+			}
+			if decoded, err := url.PathUnescape(target); err == nil {
+				target = decoded
+			}
+			target, anchor, _ := strings.Cut(target, "#")
+			if anchor != "" {
+				anchor = "#" + anchor
+			}
+
+			if strings.EqualFold(filepath.Ext(target), ".md") {
+				key := im.lookup(n, target)
+				if key == "" {
+					unresolved = append(unresolved, m[0])
+					return "", false
+				}
+				links++
+				return fmt.Sprintf("[%s](%s.md%s)", text, key, anchor), true
+			}
+			file := im.findFile(n, target)
+			if file == "" {
+				return "", false
+			}
+			links++
+			return fmt.Sprintf("%s[%s](%s)", embed, text, im.attach(file, record)), true
+		})
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n"), links, unresolved
+}
+
+// replaceOutsideCode replaces the matches of re in line, except inside
+// inline code. fn returns the replacement, or false to keep the match.
+func replaceOutsideCode(line string, re *regexp.Regexp, fn func(m []string) (string, bool)) string {
+	code := inlineCodeRe.FindAllStringIndex(line, -1)
+	var b strings.Builder
+	last := 0
+	for _, loc := range re.FindAllStringSubmatchIndex(line, -1) {
+		inCode := false
+		for _, c := range code {
+			if loc[0] >= c[0] && loc[0] < c[1] {
+				inCode = true
+			}
+		}
+		if inCode {
+			continue
+		}
+		m := make([]string, len(loc)/2)
+		for g := range m {
+			if loc[2*g] >= 0 {
+				m[g] = line[loc[2*g]:loc[2*g+1]]
+			}
+		}
+		repl, ok := fn(m)
+		if !ok {
+			continue
+		}
+		b.WriteString(line[last:loc[0]])
+		b.WriteString(repl)
+		last = loc[1]
+	}
+	b.WriteString(line[last:])
+	return b.String()
+}
+
+// lookup returns the key of the note a link target names: an imported
+// note's title, alias, file name or path, or a date.
+func (im *importer) lookup(from *sourceNote, target string) string {
+	name := strings.ToLower(strings.TrimSuffix(strings.TrimSuffix(target, ".md"), ".MD"))
+	if name == "" {
+		return from.key
+	}
+	candidates := []string{name, strings.ToLower(filepath.ToSlash(filepath.Join(relDir(im.plan.Root, from.dir), name))), lastElem(name)}
+	for _, c := range candidates {
+		if n, ok := im.byName[c]; ok {
+			return n.key
+		}
+	}
+	if date := parseJournalDate(target); date != "" {
+		return date
+	}
+	return ""
+}
+
+// lastElem returns the last element of a slash-separated path.
+func lastElem(p string) string {
+	if i := strings.LastIndex(p, "/"); i >= 0 {
+		return p[i+1:]
+	}
+	return p
+}
+
+// relDir returns dir relative to root, with forward slashes.
+func relDir(root, dir string) string {
+	rel, err := filepath.Rel(root, dir)
+	if err != nil {
+		return ""
+	}
+	return filepath.ToSlash(rel)
+}
+
+// findFile returns the attachment a link target names, relative to the
+// note, to the import root or by file name.
+func (im *importer) findFile(from *sourceNote, target string) string {
+	for _, p := range []string{filepath.Join(from.dir, target), filepath.Join(im.plan.Root, target)} {
+		if info, err := os.Stat(p); err == nil && !info.IsDir() && strings.HasPrefix(p, im.plan.Root) {
+			return p
+		}
+	}
+	return im.files[strings.ToLower(filepath.Base(target))]
+}
+
+// attach returns the path, relative to the notes directory, that the
+// attachment file is copied to. Files with the same name but different
+// content get the start of their hash appended.
+func (im *importer) attach(file string, record bool) string {
+	if name, ok := im.copies[file]; ok {
+		return AttachmentsDir + "/" + name
+	}
+	name := filepath.Base(file)
+	hash := fileHash(file)
+	dest := filepath.Join(im.store.NotesDir, AttachmentsDir, name)
+	if other, ok := im.taken[name]; (ok && other != hash) || (!ok && fileExists(dest) && fileHash(dest) != hash) {
+		ext := filepath.Ext(name)
+		name = strings.TrimSuffix(name, ext) + "-" + hash[:6] + ext
+	}
+	if record {
+		im.copies[file] = name
+		im.taken[name] = hash
+	}
+	return AttachmentsDir + "/" + strings.ReplaceAll(name, " ", "%20")
+}
+
+// ApplyImport copies the plan's attachments and writes its notes. Files
+// already written are removed or restored if a later write fails.
+func (s *Store) ApplyImport(p *ImportPlan) error {
+	if err := s.EnsureStructure(); err != nil {
+		return err
+	}
+	var copied []string
+	for _, a := range p.Attachments {
+		if fileExists(a.To) {
+			continue
+		}
+		if err := copyFile(a.From, a.To); err != nil {
+			for _, c := range copied {
+				os.Remove(c)
+			}
+			return fmt.Errorf("failed to copy %s: %w", a.From, err)
+		}
+		copied = append(copied, a.To)
+	}
+	if err := s.Apply(&p.Plan); err != nil {
+		for _, c := range copied {
+			os.Remove(c)
+		}
+		return err
+	}
+	return nil
+}
+
+func copyFile(from, to string) error {
+	if err := os.MkdirAll(filepath.Dir(to), 0755); err != nil {
+		return err
+	}
+	in, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(to)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(to)
+		return err
+	}
+	return out.Close()
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func fileHash(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	h := sha256.New()
+	io.Copy(h, f)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// normalizeContent trims trailing spaces and blank lines, so that content
+// compares equal whatever its line endings.
+func normalizeContent(s string) string {
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// contentHash identifies a note body for duplicate detection.
+func contentHash(body string) string {
+	sum := sha256.Sum256([]byte(normalizeContent(body)))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeTags lowercases tags, drops a leading "#" and joins words with
+// dashes.
+func normalizeTags(tags []string) []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(tag), "#")))
+		tag = strings.Join(strings.Fields(tag), "-")
+		if tag != "" && !seen[tag] {
+			seen[tag] = true
+			out = append(out, tag)
+		}
+	}
+	return out
+}
@@ -0,0 +1,354 @@
+package notes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gitlab.com/caffeinatedjack/sleepless/pkg/markdown"
+)
+
+var (
+	// logseqPropRe matches a Logseq page property line, "key:: value".
+	logseqPropRe = regexp.MustCompile(`^([A-Za-z][\w-]*):: ?(.*)$`)
+	// logseqTagRe matches a multi-word Logseq tag, #[[multi word]].
+	logseqTagRe = regexp.MustCompile(`#\[\[([^\[\]]+)\]\]`)
+	// ordinalRe matches the suffix of 1st, 2nd, 3rd and 4th in journal titles.
+	ordinalRe = regexp.MustCompile(`(\d)(st|nd|rd|th)\b`)
+	// dayOneEscapeRe matches the punctuation Day One escapes in its markdown.
+	dayOneEscapeRe = regexp.MustCompile(`\\([.\-!()\[\]#*_+>{}~|])`)
+)
+
+// readTree reads the markdown files of an Obsidian vault, Logseq graph or
+// plain folder, and indexes the other files there as attachments.
+func (im *importer) readTree(source ImportSource, root string) ([]*sourceNote, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", root)
+	}
+
+	var found []*sourceNote
+	err = filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := d.Name()
+		if d.IsDir() {
+			// .obsidian, .trash and Logseq's own logseq/ folder hold settings
+			// and backups, not notes.
+			if path != root && (strings.HasPrefix(name, ".") || (source == ImportLogseq && name == "logseq" && filepath.Dir(path) == root)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasPrefix(name, ".") {
+			return nil
+		}
+		if !strings.EqualFold(filepath.Ext(name), ".md") {
+			if _, ok := im.files[strings.ToLower(name)]; !ok {
+				im.files[strings.ToLower(name)] = path
+			}
+			return nil
+		}
+
+		rel, _ := filepath.Rel(root, path)
+		rel = filepath.ToSlash(rel)
+		if source == ImportLogseq && !strings.HasPrefix(rel, "journals/") && !strings.HasPrefix(rel, "pages/") {
+			return nil
+		}
+		n, err := im.readMarkdown(source, path, rel)
+		if err != nil {
+			return err
+		}
+		found = append(found, n)
+		return nil
+	})
+	return found, err
+}
+
+// readMarkdown reads one markdown file, taking its title, tags, aliases and
+// times from its frontmatter or Logseq properties.
+func (im *importer) readMarkdown(source ImportSource, path, rel string) (*sourceNote, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	text := strings.ReplaceAll(string(content), "\r\n", "\n")
+	fm, body, err := markdown.ParseFrontmatter(text)
+	if err != nil {
+		if !errors.Is(err, markdown.ErrNoFrontmatter) {
+			im.plan.Warnings = append(im.plan.Warnings, fmt.Sprintf("%s: ignored invalid frontmatter: %v", rel, err))
+		}
+		fm, body = map[string]interface{}{}, text
+	}
+
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	n := &sourceNote{
+		sources: []string{rel},
+		dir:     filepath.Dir(path),
+		updated: info.ModTime(),
+	}
+	name := base
+	if source == ImportLogseq {
+		name = logseqPageName(base)
+		var props map[string]string
+		props, body = logseqProperties(body)
+		for k, v := range props {
+			switch k {
+			case "tags", "alias", "title":
+				fm[k] = v
+			}
+		}
+		body = logseqTagRe.ReplaceAllStringFunc(body, func(m string) string {
+			return "#" + strings.Join(strings.Fields(logseqTagRe.FindStringSubmatch(m)[1]), "-")
+		})
+		if strings.HasPrefix(rel, "journals/") {
+			if t, err := time.Parse("2006_01_02", base); err == nil {
+				n.date = t.Format("2006-01-02")
+			}
+		}
+	} else if _, err := time.Parse("2006-01-02", base); err == nil {
+		n.date = base
+	}
+
+	n.title = stringValue(fm["title"])
+	if n.title == "" {
+		if line := strings.TrimSpace(strings.SplitN(strings.TrimSpace(body), "\n", 2)[0]); strings.HasPrefix(line, "# ") {
+			n.title = strings.TrimSpace(line[2:])
+		}
+	}
+	if n.title == "" && n.date != "" {
+		n.title = n.date
+	} else if n.title == "" {
+		n.title = name
+	}
+	n.names = append([]string{name, strings.TrimSuffix(rel, filepath.Ext(rel)), n.title}, listValue(fm["aliases"], false)...)
+	n.names = append(n.names, listValue(fm["alias"], false)...)
+	if n.date != "" {
+		n.names = append(n.names, n.date)
+	}
+
+	n.tags = append(listValue(fm["tags"], true), listValue(fm["tag"], true)...)
+	if t, ok := timeValue(fm["created"]); ok {
+		n.created = t
+	} else if t, ok := timeValue(fm["date"]); ok {
+		n.created = t
+	}
+	if t, ok := timeValue(fm["updated"]); ok {
+		n.updated = t
+	} else if t, ok := timeValue(fm["modified"]); ok {
+		n.updated = t
+	}
+	if n.created.IsZero() {
+		n.created = n.updated
+	}
+	n.body = strings.TrimSpace(body)
+	return n, nil
+}
+
+// logseqPageName decodes a Logseq page file name: "___" separates
+// namespaces and other characters are URL-encoded.
+func logseqPageName(base string) string {
+	name := strings.ReplaceAll(base, "___", "/")
+	if decoded, err := url.PathUnescape(name); err == nil {
+		name = decoded
+	}
+	return name
+}
+
+// logseqProperties removes the "key:: value" property lines from the start
+// of a Logseq page and returns them.
+func logseqProperties(body string) (map[string]string, string) {
+	props := make(map[string]string)
+	lines := strings.Split(strings.TrimLeft(body, "\n"), "\n")
+	i := 0
+	for ; i < len(lines); i++ {
+		m := logseqPropRe.FindStringSubmatch(strings.TrimSpace(strings.TrimPrefix(lines[i], "- ")))
+		if m == nil {
+			break
+		}
+		props[strings.ToLower(m[1])] = strings.TrimSpace(m[2])
+	}
+	return props, strings.Join(lines[i:], "\n")
+}
+
+// parseJournalDate parses the ways a journal page may be named, such as
+// 2026-01-02, 2026_01_02 and "Jan 2nd, 2026", returning YYYY-MM-DD or "".
+func parseJournalDate(s string) string {
+	s = ordinalRe.ReplaceAllString(strings.TrimSpace(s), "$1")
+	for _, layout := range []string{"2006-01-02", "2006_01_02", "Jan 2, 2006", "January 2, 2006", "2 Jan 2006", "2 January 2006"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.Format("2006-01-02")
+		}
+	}
+	return ""
+}
+
+// stringValue returns a frontmatter value as a string.
+func stringValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case nil:
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+// listValue returns a frontmatter value that may be a list or a
+// comma-separated string, such as aliases. With words, a string without
+// commas is split on spaces, as Obsidian allows for tags.
+func listValue(v interface{}, words bool) []string {
+	var out []string
+	switch v := v.(type) {
+	case []interface{}:
+		for _, item := range v {
+			if s := stringValue(item); s != "" {
+				out = append(out, s)
+			}
+		}
+	case string:
+		parts := strings.Split(v, ",")
+		// Logseq writes [[links]], which may hold spaces, in its properties.
+		if words && !strings.Contains(v, ",") && !strings.Contains(v, "[[") {
+			parts = strings.Fields(v)
+		}
+		for _, s := range parts {
+			if s = strings.Trim(strings.TrimSpace(s), "[]"); s != "" {
+				out = append(out, s)
+			}
+		}
+	}
+	return out
+}
+
+// timeValue returns a frontmatter value as a time. YAML decodes unquoted
+// timestamps itself; other values are parsed as RFC 3339 or a date.
+func timeValue(v interface{}) (time.Time, bool) {
+	switch v := v.(type) {
+	case time.Time:
+		return v, true
+	case string:
+		for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"} {
+			if t, err := time.ParseInLocation(layout, strings.TrimSpace(v), time.Local); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// dayOneExport is the part of a Day One JSON export that is imported.
+type dayOneExport struct {
+	Entries []struct {
+		UUID         string    `json:"uuid"`
+		CreationDate time.Time `json:"creationDate"`
+		ModifiedDate time.Time `json:"modifiedDate"`
+		TimeZone     string    `json:"timeZone"`
+		Text         string    `json:"text"`
+		Tags         []string  `json:"tags"`
+		Photos       []struct {
+			Identifier string `json:"identifier"`
+			MD5        string `json:"md5"`
+			Type       string `json:"type"`
+		} `json:"photos"`
+	} `json:"entries"`
+}
+
+// readDayOne reads a Day One JSON export, given as the JSON file or the
+// folder it was unzipped to. Entries are grouped into one daily note per
+// day, each under a "## HH:MM" heading like the entries of `note add`.
+func readDayOne(root string) ([]*sourceNote, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	dir, files := root, []string{root}
+	if info.IsDir() {
+		if files, err = filepath.Glob(filepath.Join(root, "*.json")); err != nil {
+			return nil, err
+		}
+	} else {
+		dir = filepath.Dir(root)
+	}
+
+	byDate := make(map[string]*sourceNote)
+	type entry struct {
+		at   time.Time
+		text string
+	}
+	entries := make(map[string][]entry)
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var export dayOneExport
+		if err := json.Unmarshal(content, &export); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", filepath.Base(file), err)
+		}
+		for _, e := range export.Entries {
+			loc := time.Local
+			if l, err := time.LoadLocation(e.TimeZone); err == nil && e.TimeZone != "" {
+				loc = l
+			}
+			at := e.CreationDate.In(loc)
+			date := at.Format("2006-01-02")
+			n, ok := byDate[date]
+			if !ok {
+				n = &sourceNote{dir: dir, date: date, title: date, names: []string{date}, photos: make(map[string]string), created: at}
+				byDate[date] = n
+			}
+			n.sources = append(n.sources, filepath.Base(file)+"#"+e.UUID)
+			n.tags = append(n.tags, e.Tags...)
+			if at.Before(n.created) {
+				n.created = at
+			}
+			if e.ModifiedDate.After(n.updated) {
+				n.updated = e.ModifiedDate
+			}
+			for _, p := range e.Photos {
+				n.photos[p.Identifier] = filepath.Join(dir, "photos", p.MD5+"."+p.Type)
+			}
+			entries[date] = append(entries[date], entry{at, dayOneEscapeRe.ReplaceAllString(e.Text, "$1")})
+		}
+	}
+
+	var notes []*sourceNote
+	for date, n := range byDate {
+		es := entries[date]
+		sort.SliceStable(es, func(i, j int) bool { return es[i].at.Before(es[j].at) })
+		var b strings.Builder
+		for _, e := range es {
+			heading := e.at.Format("15:04")
+			text := strings.TrimSpace(e.text)
+			// Day One makes an entry's first line its title.
+			if line, rest, _ := strings.Cut(text, "\n"); strings.HasPrefix(line, "# ") {
+				heading += " " + strings.TrimSpace(line[2:])
+				text = strings.TrimSpace(rest)
+			}
+			if b.Len() > 0 {
+				b.WriteString("\n\n")
+			}
+			b.WriteString("## " + heading + "\n\n" + text)
+		}
+		n.body = b.String()
+		notes = append(notes, n)
+	}
+	return notes, nil
+}
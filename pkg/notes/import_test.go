@@ -0,0 +1,179 @@
+package notes
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestImportObsidian(t *testing.T) {
+	vault := writeWiki(t, map[string]string{
+		"Caching.md": "---\ntags: [work, Big Idea]\naliases: [cache]\ncreated: 2025-05-01T10:00:00Z\n---\n" +
+			"See [[Projects/Roadmap#Q3|the roadmap]] and [[2025-05-02]].\n\n![[diagram.png]]\n\n`[[code]]` [[Nowhere]]\n",
+		"Projects/Roadmap.md": "# Roadmap\n\nBack to [[cache]] and [link](../Caching.md).\n",
+		"2025-05-02.md":       "Worked on [[Caching]]\n",
+		"img/diagram.png":     "PNG",
+		".obsidian/notes.md":  "settings, not a note",
+		".obsidian/workspace": "{}",
+	}).WikiDir
+	store := writeWiki(t, map[string]string{
+		"notes/2025-05-02.md": "---\ntype: daily\ndate: \"2025-05-02\"\n---\n# 2025-05-02\n\nAlready here\n",
+	})
+
+	plan, err := store.PlanImport(ImportObsidian, vault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Items) != 3 || plan.Count(ImportCreate) != 2 || plan.Count(ImportMerge) != 1 {
+		t.Fatalf("items = %+v", plan.Items)
+	}
+	keys := make(map[string]ImportItem)
+	for _, it := range plan.Items {
+		keys[it.Sources[0]] = it
+	}
+	caching, roadmap := keys["Caching.md"].Key, keys["Projects/Roadmap.md"].Key
+	if got := keys["Caching.md"].Unresolved; len(got) != 1 || got[0] != "[[Nowhere]]" {
+		t.Errorf("unresolved = %v", got)
+	}
+	if len(plan.Attachments) != 1 || !strings.HasSuffix(plan.Attachments[0].To, "notes/attachments/diagram.png") {
+		t.Errorf("attachments = %+v", plan.Attachments)
+	}
+
+	if err := store.ApplyImport(plan); err != nil {
+		t.Fatal(err)
+	}
+	note, err := store.LoadFloating(caching)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# Caching\n\nSee [[" + roadmap + "#Q3|the roadmap]] and [[2025-05-02]].\n\n" +
+		"![diagram.png](attachments/diagram.png)\n\n`[[code]]` [[Nowhere]]"
+	if note.Body != want {
+		t.Errorf("body:\n got %q\nwant %q", note.Body, want)
+	}
+	if !note.HasTag("big-idea") || note.Created.Year() != 2025 {
+		t.Errorf("tags %v, created %v", note.Tags, note.Created)
+	}
+	if got := readFile(t, store, "notes/"+roadmap+".md"); !strings.Contains(got, "[["+caching+"|cache]] and [link]("+caching+".md)") {
+		t.Errorf("roadmap links not converted:\n%s", got)
+	}
+	if got := readFile(t, store, "notes/2025-05-02.md"); !strings.Contains(got, "Already here\n\n## Imported from 2025-05-02.md\n\nWorked on [["+caching+"|Caching]]") {
+		t.Errorf("daily note not merged:\n%s", got)
+	}
+	if readFile(t, store, "notes/attachments/diagram.png") != "PNG" {
+		t.Error("attachment not copied")
+	}
+
+	again, err := store.PlanImport(ImportDir, vault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Count(ImportDuplicate) != 3 || len(again.Changes) != 0 || len(again.Attachments) != 0 {
+		t.Errorf("re-import should find only duplicates: %+v", again.Items)
+	}
+}
+
+func TestImportKeepsEditedNotes(t *testing.T) {
+	vault := writeWiki(t, map[string]string{"Ideas.md": "# Ideas\n\nFirst thought\n"}).WikiDir
+	store := writeWiki(t, map[string]string{"notes/2025-05-02.md": "# 2025-05-02\n"})
+
+	plan, err := store.PlanImport(ImportObsidian, vault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.ApplyImport(plan); err != nil {
+		t.Fatal(err)
+	}
+	key := plan.Items[0].Key
+	rel := "notes/" + key + ".md"
+	edited := readFile(t, store, rel) + "\nMy edit\n"
+	if !strings.Contains(edited, "\nupdated: ") {
+		t.Errorf("imported note has no updated time:\n%s", edited)
+	}
+	if err := os.WriteFile(filepath.Join(store.WikiDir, rel), []byte(edited), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(vault, "Ideas.md"), []byte("# Ideas\n\nFirst thought, revised\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	again, err := store.PlanImport(ImportObsidian, vault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Count(ImportCreate) != 1 || again.Items[0].Key == key || len(again.Warnings) != 1 {
+		t.Fatalf("re-import items = %+v, warnings = %v", again.Items, again.Warnings)
+	}
+	for _, c := range again.Changes {
+		if c.From != "" || c.Before != "" || fileExists(c.To) {
+			t.Errorf("re-import writes over %s", c.To)
+		}
+	}
+	if err := store.ApplyImport(again); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, store, rel); got != edited {
+		t.Errorf("edited note changed:\n%s", got)
+	}
+	if got := readFile(t, store, "notes/"+again.Items[0].Key+".md"); !strings.Contains(got, "revised") {
+		t.Errorf("revised note not imported:\n%s", got)
+	}
+}
+
+func TestImportLogseqAndDayOne(t *testing.T) {
+	graph := writeWiki(t, map[string]string{
+		"pages/Home___Server%3F.md": "tags:: project, [[Home Lab]]\nalias:: homelab\n\n- Racked on [[Jan 3rd, 2026]] #[[home lab]]\n",
+		"journals/2026_01_03.md":    "- Set up [[homelab]]\n",
+		"logseq/config.md":          "not a page",
+	}).WikiDir
+	store := writeWiki(t, nil)
+	plan, err := store.PlanImport(ImportLogseq, graph)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Items) != 2 || plan.Items[0].Key != "2026-01-03" || plan.Items[1].Title != "Home/Server?" {
+		t.Fatalf("items = %+v", plan.Items)
+	}
+	page := plan.Items[1].Key
+	if got := plan.Changes[0].After; !strings.Contains(got, "- Set up [["+page+"|homelab]]") {
+		t.Errorf("journal:\n%s", got)
+	}
+	if got := plan.Changes[1].After; !strings.Contains(got, "- home-lab") || !strings.Contains(got, "[[2026-01-03|Jan 3rd, 2026]] #home-lab") || strings.Contains(got, "alias::") {
+		t.Errorf("page:\n%s", got)
+	}
+
+	export := writeWiki(t, map[string]string{
+		"Journal.json": `{"entries": [
+			{"uuid": "B", "creationDate": "2026-02-10T21:30:00Z", "timeZone": "UTC", "text": "Evening\\."},
+			{"uuid": "A", "creationDate": "2026-02-10T08:15:00Z", "timeZone": "UTC", "text": "# Run\nFelt great\\!\n![](dayone-moment://P1)",
+			 "tags": ["running"], "photos": [{"identifier": "P1", "md5": "abc", "type": "jpeg"}]}
+		]}`,
+		"photos/abc.jpeg": "JPEG",
+	}).WikiDir
+	plan, err = store.PlanImport(ImportDayOne, export)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Items) != 1 || len(plan.Items[0].Sources) != 2 || len(plan.Attachments) != 1 {
+		t.Fatalf("items = %+v, attachments = %+v", plan.Items, plan.Attachments)
+	}
+	want := "# 2026-02-10\n\n## 08:15 Run\n\nFelt great!\n![](attachments/abc.jpeg)\n\n## 21:30\n\nEvening.\n"
+	if got := plan.Changes[0].After; !strings.HasSuffix(got, want) || !strings.Contains(got, "- running") {
+		t.Errorf("day:\n got %q\nwant suffix %q", got, want)
+	}
+}
+
+func TestParseJournalDate(t *testing.T) {
+	for in, want := range map[string]string{
+		"2026-01-02":       "2026-01-02",
+		"2026_01_02":       "2026-01-02",
+		"Jan 2nd, 2026":    "2026-01-02",
+		"March 21st, 2026": "2026-03-21",
+		"Roadmap":          "",
+	} {
+		if got := parseJournalDate(in); got != want {
+			t.Errorf("parseJournalDate(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package notes
 import (
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 )
 
@@ -156,3 +157,29 @@ func (s *Store) FindByTag(tag string) ([]*Note, error) {
 
 	return notes, nil
 }
+
+// LoadAll returns every daily and floating note, sorted by key. Notes that
+// fail to parse are skipped.
+func (s *Store) LoadAll() ([]*Note, error) {
+	entries, err := os.ReadDir(s.NotesDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var all []*Note
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".md") {
+			continue
+		}
+		note, err := s.loadNote(filepath.Join(s.NotesDir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		all = append(all, note)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Key() < all[j].Key() })
+	return all, nil
+}
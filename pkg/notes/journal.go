@@ -0,0 +1,317 @@
+package notes
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// habitNameRe matches a habit name as used in "habit.<name>".
+var habitNameRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// SetField sets a daily check-in field from its "key=value" form: mood and
+// energy take 1-5, sleep takes hours, and habit.<name> takes a boolean. An
+// empty value clears the field.
+func (n *Note) SetField(key, value string) error {
+	key = strings.ToLower(strings.TrimSpace(key))
+	value = strings.TrimSpace(value)
+
+	switch key {
+	case "mood", "energy":
+		field := &n.Mood
+		if key == "energy" {
+			field = &n.Energy
+		}
+		if value == "" {
+			*field = nil
+			return nil
+		}
+		v, err := strconv.Atoi(value)
+		if err != nil || v < 1 || v > 5 {
+			return fmt.Errorf("%s must be a whole number from 1 to 5, got %q", key, value)
+		}
+		*field = &v
+		return nil
+
+	case "sleep":
+		if value == "" {
+			n.Sleep = nil
+			return nil
+		}
+		v, err := strconv.ParseFloat(strings.TrimSuffix(value, "h"), 64)
+		if err != nil || math.IsNaN(v) || math.IsInf(v, 0) || v < 0 || v > 24 {
+			return fmt.Errorf("sleep must be hours from 0 to 24, got %q", value)
+		}
+		n.Sleep = &v
+		return nil
+	}
+
+	name, ok := strings.CutPrefix(key, "habit.")
+	if !ok {
+		return fmt.Errorf("unknown field %q (use mood, energy, sleep or habit.<name>)", key)
+	}
+	if !habitNameRe.MatchString(name) {
+		return fmt.Errorf("invalid habit name %q", name)
+	}
+	if value == "" {
+		delete(n.Habits, name)
+		return nil
+	}
+	done, err := parseBool(value)
+	if err != nil {
+		return fmt.Errorf("habit.%s: %w", name, err)
+	}
+	if n.Habits == nil {
+		n.Habits = make(map[string]bool)
+	}
+	n.Habits[name] = done
+	return nil
+}
+
+// parseBool accepts true/false, yes/no, y/n, on/off and 1/0.
+func parseBool(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "true", "yes", "y", "on", "1", "done":
+		return true, nil
+	case "false", "no", "n", "off", "0":
+		return false, nil
+	}
+	return false, fmt.Errorf("expected true or false, got %q", s)
+}
+
+// HasCheckIn returns true if any check-in field is set.
+func (n *Note) HasCheckIn() bool {
+	return n.Mood != nil || n.Energy != nil || n.Sleep != nil || len(n.Habits) > 0
+}
+
+// CheckIn is one day's check-in fields. Days without a daily note, or with
+// nothing logged, have every field unset.
+type CheckIn struct {
+	Date   string          `json:"date"`
+	Mood   *int            `json:"mood,omitempty"`
+	Energy *int            `json:"energy,omitempty"`
+	Sleep  *float64        `json:"sleep,omitempty"`
+	Habits map[string]bool `json:"habits,omitempty"`
+}
+
+// Logged returns true if anything was logged that day.
+func (c CheckIn) Logged() bool {
+	return c.Mood != nil || c.Energy != nil || c.Sleep != nil || len(c.Habits) > 0
+}
+
+// CheckIns returns one check-in per day for the days ending on end,
+// oldest first.
+func (s *Store) CheckIns(end time.Time, days int) []CheckIn {
+	list := make([]CheckIn, 0, days)
+	for d := days - 1; d >= 0; d-- {
+		date := end.AddDate(0, 0, -d).Format("2006-01-02")
+		c := CheckIn{Date: date}
+		if note, err := s.LoadDaily(date); err == nil {
+			c.Mood, c.Energy, c.Sleep, c.Habits = note.Mood, note.Energy, note.Sleep, note.Habits
+		}
+		list = append(list, c)
+	}
+	return list
+}
+
+// Series is one numeric check-in field over a run of days.
+type Series struct {
+	Name    string
+	Values  []float64
+	Present []bool
+}
+
+// Metric returns the mood, energy or sleep series of check-ins.
+func Metric(checkIns []CheckIn, name string) Series {
+	s := Series{Name: name, Values: make([]float64, len(checkIns)), Present: make([]bool, len(checkIns))}
+	for i, c := range checkIns {
+		switch {
+		case name == "mood" && c.Mood != nil:
+			s.Values[i], s.Present[i] = float64(*c.Mood), true
+		case name == "energy" && c.Energy != nil:
+			s.Values[i], s.Present[i] = float64(*c.Energy), true
+		case name == "sleep" && c.Sleep != nil:
+			s.Values[i], s.Present[i] = *c.Sleep, true
+		}
+	}
+	return s
+}
+
+// Count returns the number of days with a value.
+func (s Series) Count() int {
+	n := 0
+	for _, ok := range s.Present {
+		if ok {
+			n++
+		}
+	}
+	return n
+}
+
+// Average returns the mean of the days with a value, or 0 if there are none.
+func (s Series) Average() float64 {
+	sum, n := 0.0, 0
+	for i, v := range s.Values {
+		if s.Present[i] {
+			sum += v
+			n++
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	return sum / float64(n)
+}
+
+// sparkBlocks are the sparkline levels, lowest first.
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline renders the series as one block per day, scaled between lo and
+// hi. Days without a value are spaces.
+func (s Series) Sparkline(lo, hi float64) string {
+	var b strings.Builder
+	for i, v := range s.Values {
+		if !s.Present[i] {
+			b.WriteRune(' ')
+			continue
+		}
+		level := 0
+		if hi > lo {
+			level = int(math.Round((v - lo) / (hi - lo) * float64(len(sparkBlocks)-1)))
+		}
+		level = max(0, min(level, len(sparkBlocks)-1))
+		b.WriteRune(sparkBlocks[level])
+	}
+	return b.String()
+}
+
+// Range returns the smallest and largest values, or 0, 0 if there are none.
+func (s Series) Range() (lo, hi float64) {
+	first := true
+	for i, v := range s.Values {
+		if !s.Present[i] {
+			continue
+		}
+		if first || v < lo {
+			lo = v
+		}
+		if first || v > hi {
+			hi = v
+		}
+		first = false
+	}
+	return lo, hi
+}
+
+// Habits returns the names of the habits logged in check-ins, sorted.
+func Habits(checkIns []CheckIn) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, c := range checkIns {
+		for name := range c.Habits {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// HabitStats summarises one habit over a run of check-ins.
+type HabitStats struct {
+	Name string `json:"name"`
+	// Done counts the days the habit was done; Logged the days it was
+	// recorded either way.
+	Done   int `json:"done"`
+	Logged int `json:"logged"`
+	// Current is the run of consecutive done days ending on the last day,
+	// or the day before when the last day has not been logged yet.
+	Current int `json:"current_streak"`
+	Longest int `json:"longest_streak"`
+	// Marks has one rune per day: █ done, · not done, space not logged.
+	Marks string `json:"marks"`
+}
+
+// Habit summarises the habit name over check-ins.
+func Habit(checkIns []CheckIn, name string) HabitStats {
+	h := HabitStats{Name: name}
+	var marks strings.Builder
+	run := 0
+	for _, c := range checkIns {
+		done, ok := c.Habits[name]
+		switch {
+		case !ok:
+			marks.WriteRune(' ')
+			run = 0
+		case done:
+			marks.WriteRune('█')
+			h.Done++
+			h.Logged++
+			run++
+		default:
+			marks.WriteRune('·')
+			h.Logged++
+			run = 0
+		}
+		h.Longest = max(h.Longest, run)
+	}
+	h.Marks = marks.String()
+
+	end := len(checkIns) - 1
+	if end >= 0 {
+		if _, ok := checkIns[end].Habits[name]; !ok {
+			end--
+		}
+	}
+	for i := end; i >= 0 && checkIns[i].Habits[name]; i-- {
+		h.Current++
+	}
+	return h
+}
+
+// WriteCSV writes check-ins as CSV with a date, mood, energy and sleep
+// column and one habit.<name> column per habit. Unset fields are empty.
+func WriteCSV(w io.Writer, checkIns []CheckIn) error {
+	habits := Habits(checkIns)
+	header := []string{"date", "mood", "energy", "sleep"}
+	for _, name := range habits {
+		header = append(header, "habit."+name)
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, c := range checkIns {
+		row := []string{c.Date, "", "", ""}
+		if c.Mood != nil {
+			row[1] = strconv.Itoa(*c.Mood)
+		}
+		if c.Energy != nil {
+			row[2] = strconv.Itoa(*c.Energy)
+		}
+		if c.Sleep != nil {
+			row[3] = strconv.FormatFloat(*c.Sleep, 'f', -1, 64)
+		}
+		for _, name := range habits {
+			if done, ok := c.Habits[name]; ok {
+				row = append(row, strconv.FormatBool(done))
+			} else {
+				row = append(row, "")
+			}
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,86 @@
+package notes
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSetField(t *testing.T) {
+	note := NewDailyNote("2026-03-02")
+	for _, kv := range [][2]string{{"mood", "4"}, {"energy", "2"}, {"sleep", "7.5h"}, {"habit.run", "yes"}, {"habit.read", "false"}} {
+		if err := note.SetField(kv[0], kv[1]); err != nil {
+			t.Fatalf("SetField(%s=%s): %v", kv[0], kv[1], err)
+		}
+	}
+	if *note.Mood != 4 || *note.Energy != 2 || *note.Sleep != 7.5 {
+		t.Errorf("got mood %d energy %d sleep %v", *note.Mood, *note.Energy, *note.Sleep)
+	}
+	if !note.Habits["run"] || note.Habits["read"] || len(note.Habits) != 2 {
+		t.Errorf("habits = %v", note.Habits)
+	}
+
+	if err := note.SetField("mood", ""); err != nil || note.Mood != nil {
+		t.Errorf("clearing mood: %v, %v", err, note.Mood)
+	}
+	for _, kv := range [][2]string{{"mood", "6"}, {"energy", "3.5"}, {"sleep", "30"}, {"sleep", "NaN"}, {"sleep", "-Inf"}, {"habit.run", "maybe"}, {"habit.Bad Name", "true"}, {"weight", "80"}} {
+		if err := note.SetField(kv[0], kv[1]); err == nil {
+			t.Errorf("SetField(%s=%s): expected an error", kv[0], kv[1])
+		}
+	}
+}
+
+func TestCheckInsAndTrends(t *testing.T) {
+	store := NewStore(t.TempDir())
+	end := time.Date(2026, 3, 5, 12, 0, 0, 0, time.Local)
+	logs := map[string][]string{
+		"2026-03-01": {"mood=2", "sleep=6", "habit.run=true"},
+		"2026-03-02": {"mood=3", "habit.run=true"},
+		"2026-03-03": {"mood=5", "sleep=8", "habit.run=false"},
+		"2026-03-04": {"habit.run=true"},
+	}
+	for date, fields := range logs {
+		note := NewDailyNote(date)
+		for _, f := range fields {
+			k, v, _ := strings.Cut(f, "=")
+			if err := note.SetField(k, v); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := store.SaveDaily(note); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	checkIns := store.CheckIns(end, 5)
+	if len(checkIns) != 5 || checkIns[0].Date != "2026-03-01" || checkIns[4].Logged() {
+		t.Fatalf("check-ins = %+v", checkIns)
+	}
+
+	mood := Metric(checkIns, "mood")
+	if mood.Count() != 3 || mood.Average() != 10.0/3 {
+		t.Errorf("mood count %d average %v", mood.Count(), mood.Average())
+	}
+	if got := mood.Sparkline(1, 5); got != "▃▅█  " {
+		t.Errorf("mood sparkline = %q", got)
+	}
+	if lo, hi := Metric(checkIns, "sleep").Range(); lo != 6 || hi != 8 {
+		t.Errorf("sleep range = %v, %v", lo, hi)
+	}
+
+	// Today (03-05) is not logged yet, so the current streak ends on 03-04.
+	run := Habit(checkIns, "run")
+	want := HabitStats{Name: "run", Done: 3, Logged: 4, Current: 1, Longest: 2, Marks: "██·█ "}
+	if run != want {
+		t.Errorf("Habit = %+v, want %+v", run, want)
+	}
+
+	var csv strings.Builder
+	if err := WriteCSV(&csv, checkIns[:2]); err != nil {
+		t.Fatal(err)
+	}
+	wantCSV := "date,mood,energy,sleep,habit.run\n2026-03-01,2,,6,true\n2026-03-02,3,,,true\n"
+	if csv.String() != wantCSV {
+		t.Errorf("csv:\n got %q\nwant %q", csv.String(), wantCSV)
+	}
+}
@@ -0,0 +1,398 @@
+package notes
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gitlab.com/caffeinatedjack/sleepless/pkg/markdown"
+)
+
+// Link is a reference from one wiki page to another. Notes are identified by
+// their key (date or floating ID); other pages by their path relative to the
+// wiki, e.g. "recipes/pasta.md".
+type Link struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Text   string `json:"text,omitempty"`
+	// Line is the 1-based line in the page body, after any frontmatter.
+	Line int `json:"line"`
+}
+
+var (
+	// wikiLinkRe matches [[target]], [[target|text]] and [[target#anchor]].
+	wikiLinkRe = regexp.MustCompile(`\[\[([^\]|#]+)(?:#[^\]|]*)?(?:\|([^\]]*))?\]\]`)
+	// mdLinkRe matches [text](target) and [text](target "title").
+	mdLinkRe = regexp.MustCompile(`\[([^\]]*)\]\(([^)\s]+)(?:\s+"[^"]*")?\)`)
+	// noteRefRe matches @note:<id> and @note:<date>, like @task:<id>.
+	noteRefRe = regexp.MustCompile(`@note:([a-f0-9]{6,8}|\d{4}-\d{2}-\d{2})\b`)
+	// inlineCodeRe matches `code` spans, whose contents are not links.
+	inlineCodeRe = regexp.MustCompile("`[^`]*`")
+	// schemeRe matches URLs such as https: and mailto:.
+	schemeRe = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*:`)
+)
+
+// Key returns the note's identifier: its date or floating ID.
+func (n *Note) Key() string {
+	switch {
+	case n.Type == NoteTypeDaily:
+		return n.Date
+	case IsPeriodType(n.Type):
+		return n.Period
+	}
+	return n.ID
+}
+
+// IsNoteKey reports whether s looks like a daily note date, a period note
+// key or a floating note ID (or ID prefix).
+func IsNoteKey(s string) bool {
+	if _, err := time.Parse("2006-01-02", s); err == nil {
+		return true
+	}
+	if _, ok := ParsePeriod(s); ok {
+		return true
+	}
+	return len(s) >= 6 && len(s) <= 8 && isHexString(s)
+}
+
+// ParseLinks extracts the links in content. dir is the directory of the page,
+// relative to the wiki ("notes" for notes); relative targets are resolved
+// against it, and targets starting with "/" against the wiki root. Links in
+// code, to URLs and to pages outside the wiki are ignored.
+func ParseLinks(content, dir string) []Link {
+	var links []Link
+	inFence := false
+	for i, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		line = inlineCodeRe.ReplaceAllString(line, "")
+
+		for _, m := range wikiLinkRe.FindAllStringSubmatch(line, -1) {
+			if target := resolveTarget(strings.TrimSpace(m[1]), dir, true); target != "" {
+				links = append(links, Link{Target: target, Text: strings.TrimSpace(m[2]), Line: i + 1})
+			}
+		}
+		// Blank out wiki links so [[a]](b) is not read twice.
+		line = wikiLinkRe.ReplaceAllString(line, "")
+		for _, m := range mdLinkRe.FindAllStringSubmatch(line, -1) {
+			if target := resolveTarget(m[2], dir, false); target != "" {
+				links = append(links, Link{Target: target, Text: m[1], Line: i + 1})
+			}
+		}
+		for _, m := range noteRefRe.FindAllStringSubmatch(line, -1) {
+			links = append(links, Link{Target: m[1], Line: i + 1})
+		}
+	}
+	return links
+}
+
+// resolveTarget normalises a link target to a note key or wiki-relative
+// path, or returns "" if it is not a wiki page. Wiki links may omit ".md".
+func resolveTarget(target, dir string, wiki bool) string {
+	if target == "" || strings.HasPrefix(target, "#") || schemeRe.MatchString(target) {
+		return ""
+	}
+	if i := strings.IndexByte(target, '#'); i >= 0 {
+		target = target[:i]
+	}
+	target = strings.ReplaceAll(target, "%20", " ")
+	// [[2026-01-20]] and [[a1b2c3d4]] name notes from anywhere in the wiki.
+	if key := strings.TrimSuffix(target, ".md"); wiki && IsNoteKey(key) {
+		return key
+	}
+
+	var p string
+	if strings.HasPrefix(target, "/") {
+		p = path.Clean(strings.TrimPrefix(target, "/"))
+	} else {
+		p = path.Clean(path.Join(dir, target))
+	}
+	if p == "." || p == ".." || strings.HasPrefix(p, "../") {
+		return ""
+	}
+
+	ext := path.Ext(p)
+	if ext == "" && wiki {
+		p += ".md"
+	} else if ext != ".md" {
+		return ""
+	}
+	if d, base := path.Split(p); d == "notes/" && IsNoteKey(strings.TrimSuffix(base, ".md")) {
+		return strings.TrimSuffix(base, ".md")
+	}
+	return p
+}
+
+// Graph is the link graph of the wiki.
+type Graph struct {
+	// Pages maps every page's key or path to its title.
+	Pages map[string]string `json:"pages"`
+	// Links holds every link, with note ID prefixes resolved.
+	Links []Link `json:"links"`
+}
+
+// IsNote reports whether key names a note (rather than another wiki page).
+func (g *Graph) IsNote(key string) bool {
+	_, ok := g.Pages[key]
+	return ok && !strings.HasSuffix(key, ".md")
+}
+
+// Backlinks returns the links to key from other pages.
+func (g *Graph) Backlinks(key string) []Link {
+	var links []Link
+	for _, l := range g.Links {
+		if l.Target == key && l.Source != key {
+			links = append(links, l)
+		}
+	}
+	return links
+}
+
+// Outlinks returns the links from key to other pages.
+func (g *Graph) Outlinks(key string) []Link {
+	var links []Link
+	for _, l := range g.Links {
+		if l.Source == key && l.Target != key {
+			links = append(links, l)
+		}
+	}
+	return links
+}
+
+// Orphans returns the notes that nothing links to and that link to nothing,
+// sorted. Daily notes are only included if daily is set, since they can
+// always be found by date.
+func (g *Graph) Orphans(daily bool) []string {
+	linked := make(map[string]bool)
+	for _, l := range g.Links {
+		if l.Source != l.Target {
+			linked[l.Source] = true
+			linked[l.Target] = true
+		}
+	}
+	var orphans []string
+	for key := range g.Pages {
+		if !g.IsNote(key) || linked[key] {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", key); err == nil && !daily {
+			continue
+		}
+		orphans = append(orphans, key)
+	}
+	sort.Strings(orphans)
+	return orphans
+}
+
+// Dot renders the graph in Graphviz format. Notes are boxes; other pages
+// are ellipses. Links to missing pages are drawn dashed.
+func (g *Graph) Dot() string {
+	var b strings.Builder
+	b.WriteString("digraph wiki {\n")
+	b.WriteString("  rankdir=LR;\n")
+	keys := make([]string, 0, len(g.Pages))
+	for key := range g.Pages {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		shape := "ellipse"
+		if g.IsNote(key) {
+			shape = "box"
+		}
+		label := key
+		if title := g.Pages[key]; title != "" && title != key {
+			label = key + "\\n" + title
+		}
+		fmt.Fprintf(&b, "  %q [shape=%s, label=\"%s\"];\n", key, shape, strings.ReplaceAll(label, `"`, `\"`))
+	}
+	for _, l := range g.Links {
+		if l.Source == l.Target {
+			continue
+		}
+		style := ""
+		if _, ok := g.Pages[l.Target]; !ok {
+			style = " [style=dashed]"
+		}
+		fmt.Fprintf(&b, "  %q -> %q%s;\n", l.Source, l.Target, style)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// linkCacheFile holds parsed links per page, keyed by wiki-relative path.
+const linkCacheFile = ".links-cache.json"
+
+type cachedPage struct {
+	ModTime time.Time `json:"mtime"`
+	Size    int64     `json:"size"`
+	Title   string    `json:"title,omitempty"`
+	Links   []Link    `json:"links,omitempty"`
+}
+
+// LinkGraph builds the link graph of the notes and the other Markdown pages
+// in the wiki. Parsed pages are cached in the notes directory and reparsed
+// only when their modification time or size changes.
+func (s *Store) LinkGraph() (*Graph, error) {
+	cachePath := filepath.Join(s.NotesDir, linkCacheFile)
+	cache := make(map[string]cachedPage)
+	if data, err := os.ReadFile(cachePath); err == nil {
+		// A corrupt cache is rebuilt.
+		_ = json.Unmarshal(data, &cache)
+	}
+
+	fresh := make(map[string]cachedPage)
+	changed := false
+	err := filepath.WalkDir(s.WikiDir, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if p != s.WikiDir && strings.HasPrefix(name, ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(name, ".md") || !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		rel, err := filepath.Rel(s.WikiDir, p)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+
+		page, ok := cache[rel]
+		if !ok || !page.ModTime.Equal(info.ModTime()) || page.Size != info.Size() {
+			page, err = parsePage(p, rel, info)
+			if err != nil {
+				return nil
+			}
+			changed = true
+		}
+		fresh[rel] = page
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan wiki: %w", err)
+	}
+
+	if changed || len(fresh) != len(cache) {
+		if data, err := json.Marshal(fresh); err == nil {
+			// The cache is only an optimisation; failing to write it is harmless.
+			_ = os.WriteFile(cachePath, data, 0644)
+		}
+	}
+
+	g := &Graph{Pages: make(map[string]string)}
+	for rel, page := range fresh {
+		g.Pages[pageKey(rel)] = page.Title
+	}
+	ids := g.floatingIDs()
+	sources := make([]string, 0, len(fresh))
+	for rel := range fresh {
+		sources = append(sources, rel)
+	}
+	sort.Strings(sources)
+	for _, rel := range sources {
+		for _, l := range fresh[rel].Links {
+			l.Source = pageKey(rel)
+			l.Target = resolvePrefix(l.Target, ids)
+			g.Links = append(g.Links, l)
+		}
+	}
+	return g, nil
+}
+
+// Resolve returns the note key or wiki-relative path a link target names, as
+// ParseLinks would record it for a page in dir, or "" if the target is not a
+// wiki page. wiki is set for [[wiki links]], which may omit ".md".
+func (g *Graph) Resolve(target, dir string, wiki bool) string {
+	if t := resolveTarget(target, dir, wiki); t != "" {
+		return resolvePrefix(t, g.floatingIDs())
+	}
+	return ""
+}
+
+// floatingIDs returns the IDs of the floating notes in the graph.
+func (g *Graph) floatingIDs() []string {
+	var ids []string
+	for key := range g.Pages {
+		if g.IsNote(key) && !strings.Contains(key, "-") {
+			ids = append(ids, key)
+		}
+	}
+	return ids
+}
+
+// pageKey returns the graph key for a wiki-relative path: the note key for
+// notes, otherwise the path itself.
+func pageKey(rel string) string {
+	if d, base := path.Split(rel); d == "notes/" {
+		if key := strings.TrimSuffix(base, ".md"); IsNoteKey(key) {
+			return key
+		}
+	}
+	return rel
+}
+
+// resolvePrefix expands a floating note ID prefix to the full ID when it is
+// unambiguous.
+func resolvePrefix(target string, ids []string) string {
+	if len(target) >= 8 || !IsNoteKey(target) || strings.Contains(target, "-") {
+		return target
+	}
+	if _, ok := ParsePeriod(target); ok {
+		return target
+	}
+	match := ""
+	for _, id := range ids {
+		if strings.HasPrefix(id, target) {
+			if match != "" {
+				return target
+			}
+			match = id
+		}
+	}
+	if match == "" {
+		return target
+	}
+	return match
+}
+
+// parsePage reads a page's title and links.
+func parsePage(p, rel string, info os.FileInfo) (cachedPage, error) {
+	content, err := os.ReadFile(p)
+	if err != nil {
+		return cachedPage{}, err
+	}
+	body := string(content)
+	if _, b, err := markdown.ParseFrontmatter(body); err == nil {
+		body = b
+	}
+	page := cachedPage{ModTime: info.ModTime(), Size: info.Size()}
+	for _, line := range strings.Split(body, "\n") {
+		if strings.HasPrefix(line, "# ") {
+			page.Title = strings.TrimSpace(strings.TrimPrefix(line, "# "))
+			break
+		}
+	}
+	page.Links = ParseLinks(body, path.Dir(rel))
+	return page, nil
+}
@@ -0,0 +1,105 @@
+package notes
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseLinks(t *testing.T) {
+	content := "See [[a1b2c3d4|the plan]] and [[2026-01-20#standup]].\n" +
+		"Recipe: [pasta](../recipes/pasta.md), [site](https://example.com), [img](pic.png)\n" +
+		"```\n[[ignored]]\n```\n" +
+		"`[[also ignored]]` but @note:cafe12 and [[/tasks/work]] count. [[Some Page]]\n"
+
+	var got []string
+	for _, l := range ParseLinks(content, "notes") {
+		got = append(got, l.Target+"|"+l.Text)
+	}
+	want := []string{
+		"a1b2c3d4|the plan",
+		"2026-01-20|",
+		"recipes/pasta.md|pasta",
+		"tasks/work.md|",
+		"notes/Some Page.md|",
+		"cafe12|",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseLinks:\n got %v\nwant %v", got, want)
+	}
+
+	if links := ParseLinks("[up](../../etc/passwd.md) [x](a1b2c3d4.md)", "recipes"); len(links) != 1 || links[0].Target != "recipes/a1b2c3d4.md" {
+		t.Errorf("expected only the in-wiki recipe link, got %v", links)
+	}
+}
+
+func writeWiki(t *testing.T, files map[string]string) *Store {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return NewStore(dir)
+}
+
+func TestLinkGraph(t *testing.T) {
+	store := writeWiki(t, map[string]string{
+		"notes/2026-01-20.md": "---\ntype: daily\n---\n# 2026-01-20\n\nWorked on [[a1b2c3]].\n",
+		"notes/a1b2c3d4.md":   "---\ntype: floating\n---\n# Caching plan\n\nSee [pasta](../recipes/pasta.md).\n",
+		"notes/deadbeef.md":   "# Lonely\n",
+		"notes/2026-01-21.md": "# 2026-01-21\n",
+		"recipes/pasta.md":    "# Pasta\n\nFrom @note:2026-01-20\n",
+		".templates/idea.md":  "[[a1b2c3d4]]\n",
+	})
+
+	g, err := store.LinkGraph()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Pages["a1b2c3d4"] != "Caching plan" || !g.IsNote("a1b2c3d4") || g.IsNote("recipes/pasta.md") {
+		t.Errorf("unexpected pages %v", g.Pages)
+	}
+	back := g.Backlinks("a1b2c3d4")
+	if len(back) != 1 || back[0].Source != "2026-01-20" || back[0].Line != 3 {
+		t.Errorf("expected one backlink from the daily note with the prefix resolved, got %v", back)
+	}
+	if back := g.Backlinks("2026-01-20"); len(back) != 1 || back[0].Source != "recipes/pasta.md" {
+		t.Errorf("expected a backlink from the recipe, got %v", back)
+	}
+	if got := g.Orphans(false); !reflect.DeepEqual(got, []string{"deadbeef"}) {
+		t.Errorf("Orphans(false) = %v", got)
+	}
+	if got := g.Orphans(true); !reflect.DeepEqual(got, []string{"2026-01-21", "deadbeef"}) {
+		t.Errorf("Orphans(true) = %v", got)
+	}
+	if dot := g.Dot(); !strings.Contains(dot, `"2026-01-20" -> "a1b2c3d4";`) {
+		t.Errorf("missing edge in dot output:\n%s", dot)
+	}
+
+	// Pages are reparsed when they change, and read from the cache otherwise.
+	if _, err := os.Stat(filepath.Join(store.NotesDir, linkCacheFile)); err != nil {
+		t.Fatalf("cache not written: %v", err)
+	}
+	lonely := filepath.Join(store.NotesDir, "deadbeef.md")
+	if err := os.WriteFile(lonely, []byte("# Lonely\n\nNow links [[a1b2c3d4]]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(lonely, later, later)
+	g, err = store.LinkGraph()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Backlinks("a1b2c3d4")) != 2 || len(g.Orphans(false)) != 0 {
+		t.Errorf("changed page not reparsed: %v", g.Links)
+	}
+}
@@ -0,0 +1,358 @@
+package notes
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// NoteTypeWeekly represents a weekly review note (YYYY-Www).
+	NoteTypeWeekly NoteType = "weekly"
+	// NoteTypeMonthly represents a monthly review note (YYYY-MM).
+	NoteTypeMonthly NoteType = "monthly"
+	// NoteTypeQuarterly represents a quarterly review note (YYYY-Qn).
+	NoteTypeQuarterly NoteType = "quarterly"
+	// NoteTypeYearly represents a yearly review note (YYYY).
+	NoteTypeYearly NoteType = "yearly"
+)
+
+// periodKeyRe matches the key of a period note.
+var periodKeyRe = regexp.MustCompile(`^(\d{4})(?:-W(\d{2})|-(\d{2})|-Q([1-4]))?$`)
+
+// Period is a calendar week (starting Monday), month, quarter or year.
+type Period struct {
+	Type NoteType
+	// Start is the first day of the period; End is the day after the last.
+	Start, End time.Time
+}
+
+// ParsePeriodType accepts week, month, quarter and year, or the matching
+// note types.
+func ParsePeriodType(s string) (NoteType, error) {
+	switch strings.ToLower(s) {
+	case "week", "weekly":
+		return NoteTypeWeekly, nil
+	case "month", "monthly":
+		return NoteTypeMonthly, nil
+	case "quarter", "quarterly":
+		return NoteTypeQuarterly, nil
+	case "year", "yearly":
+		return NoteTypeYearly, nil
+	}
+	return "", fmt.Errorf("unknown period %q (use week, month, quarter or year)", s)
+}
+
+// PeriodOf returns the period of type t that contains day.
+func PeriodOf(t NoteType, day time.Time) (Period, error) {
+	y, m, d := day.Date()
+	day = time.Date(y, m, d, 0, 0, 0, 0, day.Location())
+	p := Period{Type: t}
+	switch t {
+	case NoteTypeWeekly:
+		offset := (int(day.Weekday()) + 6) % 7
+		p.Start = day.AddDate(0, 0, -offset)
+		p.End = p.Start.AddDate(0, 0, 7)
+	case NoteTypeMonthly:
+		p.Start = time.Date(y, m, 1, 0, 0, 0, 0, day.Location())
+		p.End = p.Start.AddDate(0, 1, 0)
+	case NoteTypeQuarterly:
+		p.Start = time.Date(y, (m-1)/3*3+1, 1, 0, 0, 0, 0, day.Location())
+		p.End = p.Start.AddDate(0, 3, 0)
+	case NoteTypeYearly:
+		p.Start = time.Date(y, 1, 1, 0, 0, 0, 0, day.Location())
+		p.End = p.Start.AddDate(1, 0, 0)
+	default:
+		return Period{}, fmt.Errorf("%s is not a period note type", t)
+	}
+	return p, nil
+}
+
+// ParsePeriod parses a period note key: 2026-W03, 2026-01, 2026-Q1 or 2026.
+func ParsePeriod(key string) (Period, bool) {
+	m := periodKeyRe.FindStringSubmatch(key)
+	if m == nil {
+		return Period{}, false
+	}
+	year, _ := strconv.Atoi(m[1])
+	var (
+		t   NoteType
+		day time.Time
+	)
+	switch {
+	case m[2] != "":
+		week, _ := strconv.Atoi(m[2])
+		// January 4th is always in ISO week 1.
+		jan4 := time.Date(year, 1, 4, 0, 0, 0, 0, time.Local)
+		day = jan4.AddDate(0, 0, (week-1)*7)
+		if week < 1 || week > 53 {
+			return Period{}, false
+		}
+		if _, w := day.ISOWeek(); w != week {
+			return Period{}, false
+		}
+		t = NoteTypeWeekly
+	case m[3] != "":
+		month, _ := strconv.Atoi(m[3])
+		if month < 1 || month > 12 {
+			return Period{}, false
+		}
+		t, day = NoteTypeMonthly, time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.Local)
+	case m[4] != "":
+		q, _ := strconv.Atoi(m[4])
+		t, day = NoteTypeQuarterly, time.Date(year, time.Month(q*3-2), 1, 0, 0, 0, 0, time.Local)
+	default:
+		t, day = NoteTypeYearly, time.Date(year, 1, 1, 0, 0, 0, 0, time.Local)
+	}
+	p, _ := PeriodOf(t, day)
+	return p, true
+}
+
+// IsPeriodType returns true for the weekly, monthly, quarterly and yearly
+// note types.
+func IsPeriodType(t NoteType) bool {
+	switch t {
+	case NoteTypeWeekly, NoteTypeMonthly, NoteTypeQuarterly, NoteTypeYearly:
+		return true
+	}
+	return false
+}
+
+// Key returns the period's note key, e.g. 2026-W03.
+func (p Period) Key() string {
+	switch p.Type {
+	case NoteTypeWeekly:
+		year, week := p.Start.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case NoteTypeMonthly:
+		return p.Start.Format("2006-01")
+	case NoteTypeQuarterly:
+		return fmt.Sprintf("%d-Q%d", p.Start.Year(), (int(p.Start.Month())+2)/3)
+	}
+	return p.Start.Format("2006")
+}
+
+// Title returns a heading for the period, e.g. "Week 3, 2026".
+func (p Period) Title() string {
+	switch p.Type {
+	case NoteTypeWeekly:
+		year, week := p.Start.ISOWeek()
+		return fmt.Sprintf("Week %d, %d", week, year)
+	case NoteTypeMonthly:
+		return p.Start.Format("January 2006")
+	case NoteTypeQuarterly:
+		return fmt.Sprintf("Q%d %d", (int(p.Start.Month())+2)/3, p.Start.Year())
+	}
+	return p.Start.Format("2006")
+}
+
+// Last returns the last day of the period.
+func (p Period) Last() time.Time {
+	return p.End.AddDate(0, 0, -1)
+}
+
+// Prev returns the period before p.
+func (p Period) Prev() Period {
+	prev, _ := PeriodOf(p.Type, p.Start.AddDate(0, 0, -1))
+	return prev
+}
+
+// Next returns the period after p.
+func (p Period) Next() Period {
+	next, _ := PeriodOf(p.Type, p.End)
+	return next
+}
+
+// Contains returns true if t falls within the period.
+func (p Period) Contains(t time.Time) bool {
+	return !t.Before(p.Start) && t.Before(p.End)
+}
+
+// Dates returns each date in the period as YYYY-MM-DD.
+func (p Period) Dates() []string {
+	var dates []string
+	for d := p.Start; d.Before(p.End); d = d.AddDate(0, 0, 1) {
+		dates = append(dates, d.Format("2006-01-02"))
+	}
+	return dates
+}
+
+// TemplateVars returns the template variables for a period note:
+// period, period_title, period_start, period_end (the last day),
+// previous_link and next_link.
+func (p Period) TemplateVars() map[string]string {
+	return map[string]string{
+		"period":        p.Key(),
+		"period_title":  p.Title(),
+		"period_start":  p.Start.Format("2006-01-02"),
+		"period_end":    p.Last().Format("2006-01-02"),
+		"previous_link": "[[" + p.Prev().Key() + "]]",
+		"next_link":     "[[" + p.Next().Key() + "]]",
+	}
+}
+
+// NewPeriodNote creates a new note for a period.
+func NewPeriodNote(p Period) *Note {
+	return &Note{
+		Type:    p.Type,
+		Period:  p.Key(),
+		Created: time.Now(),
+		Tags:    []string{},
+	}
+}
+
+// PeriodPath returns the file path for a period note.
+func (s *Store) PeriodPath(key string) (string, error) {
+	if _, ok := ParsePeriod(key); !ok {
+		return "", fmt.Errorf("invalid period %q (use 2026-W03, 2026-01, 2026-Q1 or 2026)", key)
+	}
+	return filepath.Join(s.NotesDir, key+".md"), nil
+}
+
+// LoadPeriod loads a period note by key.
+func (s *Store) LoadPeriod(key string) (*Note, error) {
+	path, err := s.PeriodPath(key)
+	if err != nil {
+		return nil, err
+	}
+	return s.loadNote(path)
+}
+
+// SavePeriod saves a period note.
+func (s *Store) SavePeriod(note *Note) error {
+	if !IsPeriodType(note.Type) {
+		return fmt.Errorf("cannot save %s note as a period note", note.Type)
+	}
+	path, err := s.PeriodPath(note.Period)
+	if err != nil {
+		return err
+	}
+	return s.saveNote(path, note)
+}
+
+// Rollup summarises the daily notes of a period.
+type Rollup struct {
+	Period Period
+	// Daily are the period's daily notes, oldest first.
+	Daily []*Note
+	// Tags counts the daily notes using each tag.
+	Tags map[string]int
+	// Goals are the goals completed in the period. The store does not know
+	// about goals, so the caller fills them in.
+	Goals []string
+}
+
+// Rollup collects the daily notes and tags of a period.
+func (s *Store) Rollup(p Period) *Rollup {
+	r := &Rollup{Period: p, Tags: make(map[string]int)}
+	for _, date := range p.Dates() {
+		note, err := s.LoadDaily(date)
+		if err != nil {
+			continue
+		}
+		r.Daily = append(r.Daily, note)
+		for _, tag := range note.Tags {
+			// Heading markers can be picked up as inline tags; skip them.
+			if strings.Trim(tag, "#") != "" {
+				r.Tags[tag]++
+			}
+		}
+	}
+	return r
+}
+
+// Markdown renders the rollup as the body of a "## Rollup" section.
+func (r *Rollup) Markdown() string {
+	var b strings.Builder
+
+	b.WriteString("### Daily notes\n\n")
+	if len(r.Daily) == 0 {
+		b.WriteString("No daily notes.\n")
+	}
+	for _, note := range r.Daily {
+		day, _ := time.Parse("2006-01-02", note.Date)
+		fmt.Fprintf(&b, "- [[%s]] %s", note.Date, day.Format("Mon"))
+		if line := firstLine(note.Body, note.Date); line != "" {
+			b.WriteString(" - " + line)
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n### Goals completed\n\n")
+	if len(r.Goals) == 0 {
+		b.WriteString("None.\n")
+	}
+	for _, g := range r.Goals {
+		b.WriteString("- [x] " + g + "\n")
+	}
+
+	if len(r.Tags) > 0 {
+		tags := make([]string, 0, len(r.Tags))
+		for tag := range r.Tags {
+			tags = append(tags, tag)
+		}
+		sort.Slice(tags, func(i, j int) bool {
+			if r.Tags[tags[i]] != r.Tags[tags[j]] {
+				return r.Tags[tags[i]] > r.Tags[tags[j]]
+			}
+			return tags[i] < tags[j]
+		})
+		b.WriteString("\n### Tags\n\n")
+		for i, tag := range tags {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			// Written without "#" so the review note doesn't take the tags.
+			fmt.Fprintf(&b, "%s (%d)", tag, r.Tags[tag])
+		}
+		b.WriteString("\n")
+	}
+
+	var checkIns []CheckIn
+	for _, note := range r.Daily {
+		checkIns = append(checkIns, CheckIn{Date: note.Date, Mood: note.Mood, Energy: note.Energy, Sleep: note.Sleep, Habits: note.Habits})
+	}
+	var avgs []string
+	for _, name := range []string{"mood", "energy", "sleep"} {
+		if series := Metric(checkIns, name); series.Count() > 0 {
+			avgs = append(avgs, fmt.Sprintf("%s %.1f", name, series.Average()))
+		}
+	}
+	for _, name := range Habits(checkIns) {
+		h := Habit(checkIns, name)
+		avgs = append(avgs, fmt.Sprintf("%s %d/%d days", name, h.Done, h.Logged))
+	}
+	if len(avgs) > 0 {
+		b.WriteString("\n### Check-ins\n\n")
+		b.WriteString(strings.Join(avgs, " · ") + "\n")
+	}
+	return b.String()
+}
+
+// firstLine returns the first line of body that is not blank, a tag line,
+// a timestamp heading or the note's title, shortened to 60 characters.
+func firstLine(body, date string) string {
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		text := strings.TrimLeft(line, "#")
+		if text != line && !strings.HasPrefix(text, " ") {
+			continue // #tags
+		}
+		text = strings.TrimSpace(text)
+		if text == "" || text == date {
+			continue
+		}
+		if _, err := time.Parse("15:04", text); err == nil {
+			continue
+		}
+		if r := []rune(text); len(r) > 60 {
+			text = string(r[:57]) + "..."
+		}
+		return text
+	}
+	return ""
+}
@@ -0,0 +1,89 @@
+package notes
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPeriods(t *testing.T) {
+	day := time.Date(2026, 1, 1, 15, 0, 0, 0, time.Local) // a Thursday
+	tests := []struct {
+		kind       NoteType
+		key, title string
+		start, end string
+		prev, next string
+	}{
+		{NoteTypeWeekly, "2026-W01", "Week 1, 2026", "2025-12-29", "2026-01-04", "2025-W52", "2026-W02"},
+		{NoteTypeMonthly, "2026-01", "January 2026", "2026-01-01", "2026-01-31", "2025-12", "2026-02"},
+		{NoteTypeQuarterly, "2026-Q1", "Q1 2026", "2026-01-01", "2026-03-31", "2025-Q4", "2026-Q2"},
+		{NoteTypeYearly, "2026", "2026", "2026-01-01", "2026-12-31", "2025", "2027"},
+	}
+	for _, tt := range tests {
+		p, err := PeriodOf(tt.kind, day)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := []string{p.Key(), p.Title(), p.Start.Format("2006-01-02"), p.Last().Format("2006-01-02"), p.Prev().Key(), p.Next().Key()}
+		want := []string{tt.key, tt.title, tt.start, tt.end, tt.prev, tt.next}
+		if strings.Join(got, "|") != strings.Join(want, "|") {
+			t.Errorf("%s:\n got %v\nwant %v", tt.kind, got, want)
+		}
+
+		parsed, ok := ParsePeriod(tt.key)
+		if !ok || parsed.Type != tt.kind || !parsed.Start.Equal(p.Start) || !parsed.End.Equal(p.End) {
+			t.Errorf("ParsePeriod(%q) = %+v, %v", tt.key, parsed, ok)
+		}
+	}
+
+	for _, bad := range []string{"2026-W00", "2025-W53", "2026-13", "2026-Q5", "26", "2026-01-01", "a1b2c3d4"} {
+		if _, ok := ParsePeriod(bad); ok {
+			t.Errorf("ParsePeriod(%q): expected no match", bad)
+		}
+	}
+	if !IsNoteKey("2026-W03") || !IsNoteKey("2026") {
+		t.Error("period keys should be note keys")
+	}
+}
+
+func TestRollup(t *testing.T) {
+	store := NewStore(t.TempDir())
+	for date, body := range map[string]string{
+		"2026-03-02": "# 2026-03-02\n\n## 09:00\n\nKicked off the migration #work",
+		"2026-03-04": "# 2026-03-04\n\n## 17:30\n\nShipped it #work #infra",
+		"2026-03-09": "# 2026-03-09\n\nNext week",
+	} {
+		note := NewDailyNote(date)
+		note.Body = body
+		if err := store.SaveDaily(note); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p, _ := ParsePeriod("2026-W10")
+	r := store.Rollup(p)
+	r.Goals = []string{"Migrate the database"}
+	got := r.Markdown()
+	want := "### Daily notes\n\n" +
+		"- [[2026-03-02]] Mon - Kicked off the migration #work\n" +
+		"- [[2026-03-04]] Wed - Shipped it #work #infra\n" +
+		"\n### Goals completed\n\n- [x] Migrate the database\n" +
+		"\n### Tags\n\nwork (2), infra (1)\n"
+	if got != want {
+		t.Errorf("rollup:\n got %q\nwant %q", got, want)
+	}
+
+	note := NewPeriodNote(p)
+	note.Body = "# Week 10, 2026"
+	SetSection(note, "Rollup", got)
+	if err := store.SavePeriod(note); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := store.Load("2026-W10")
+	if err != nil || loaded.Key() != "2026-W10" || loaded.Type != NoteTypeWeekly {
+		t.Fatalf("Load(2026-W10) = %+v, %v", loaded, err)
+	}
+	if _, err := store.LoadFloating("2026"); err == nil {
+		t.Error("a period note should not match a floating ID prefix")
+	}
+}
@@ -0,0 +1,416 @@
+package notes
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gitlab.com/caffeinatedjack/sleepless/pkg/markdown"
+)
+
+// FileChange is one file written or removed by a Plan. From is empty for a
+// new file and To is empty for a removed one; both are set, and differ, for
+// a renamed file.
+type FileChange struct {
+	From   string
+	To     string
+	Before string
+	After  string
+	// Links is the number of links rewritten in the file.
+	Links int
+}
+
+// LineChange is a changed line in a FileChange.
+type LineChange struct {
+	Line int
+	Old  string
+	New  string
+}
+
+// Lines returns the lines that differ between Before and After. It returns
+// nil when the line count changes; the file is then best shown whole.
+func (c FileChange) Lines() []LineChange {
+	before := strings.Split(c.Before, "\n")
+	after := strings.Split(c.After, "\n")
+	if c.From == "" || c.To == "" || len(before) != len(after) {
+		return nil
+	}
+	var lines []LineChange
+	for i := range before {
+		if before[i] != after[i] {
+			lines = append(lines, LineChange{Line: i + 1, Old: before[i], New: after[i]})
+		}
+	}
+	return lines
+}
+
+// Plan is a set of file changes applied together by Store.Apply.
+type Plan struct {
+	Changes []FileChange
+}
+
+// Links returns the total number of links the plan rewrites.
+func (p *Plan) Links() int {
+	n := 0
+	for _, c := range p.Changes {
+		n += c.Links
+	}
+	return n
+}
+
+// PlanRename prepares renaming a note to a new date or floating ID and
+// rewriting every link to it across the wiki.
+func (s *Store) PlanRename(from, to string) (*Plan, error) {
+	note, err := s.Load(from)
+	if err != nil {
+		return nil, err
+	}
+	oldPath, err := s.notePath(note)
+	if err != nil {
+		return nil, err
+	}
+
+	renamed := *note
+	if IsPeriodType(note.Type) {
+		return nil, fmt.Errorf("%s notes are named after their period and cannot be renamed", note.Type)
+	}
+	if note.Type == NoteTypeDaily {
+		if _, err := time.Parse("2006-01-02", to); err != nil {
+			return nil, fmt.Errorf("daily notes can only be renamed to another date (YYYY-MM-DD): %q", to)
+		}
+		renamed.Date = to
+	} else {
+		if len(to) != 8 || !isHexString(to) {
+			return nil, fmt.Errorf("floating note IDs are 8 lowercase hex characters: %q", to)
+		}
+		renamed.ID = to
+	}
+	if renamed.Key() == note.Key() {
+		return nil, fmt.Errorf("note is already called %s", to)
+	}
+	newPath, err := s.notePath(&renamed)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(newPath); err == nil {
+		return nil, fmt.Errorf("a note called %s already exists; use merge to combine them", to)
+	}
+
+	plan, ids, err := s.planLinkRewrites(note.Key(), renamed.Key(), oldPath)
+	if err != nil {
+		return nil, err
+	}
+
+	before, err := os.ReadFile(oldPath)
+	if err != nil {
+		return nil, err
+	}
+	body, n := rewriteLinks(note.Body, "notes", note.Key(), renamed.Key(), ids)
+	after, err := markdown.SerializeFrontmatter(&renamed, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize note: %w", err)
+	}
+	plan.Changes = append([]FileChange{{From: oldPath, To: newPath, Before: string(before), After: after, Links: n}}, plan.Changes...)
+	return plan, nil
+}
+
+// PlanMerge prepares merging note src into dst: src's body is appended to
+// dst under a heading, its tags are added to dst, links to src are pointed
+// at dst and src is removed.
+func (s *Store) PlanMerge(src, dst string) (*Plan, error) {
+	from, err := s.Load(src)
+	if err != nil {
+		return nil, err
+	}
+	into, err := s.Load(dst)
+	if err != nil {
+		return nil, err
+	}
+	if from.Key() == into.Key() {
+		return nil, fmt.Errorf("cannot merge a note into itself")
+	}
+	fromPath, err := s.notePath(from)
+	if err != nil {
+		return nil, err
+	}
+	intoPath, err := s.notePath(into)
+	if err != nil {
+		return nil, err
+	}
+
+	plan, ids, err := s.planLinkRewrites(from.Key(), into.Key(), fromPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// dst may itself link to src; rewrite it before appending.
+	merged := *into
+	var n int
+	for i, c := range plan.Changes {
+		if c.To == intoPath {
+			plan.Changes = append(plan.Changes[:i], plan.Changes[i+1:]...)
+			break
+		}
+	}
+	merged.Body, n = rewriteLinks(into.Body, "notes", from.Key(), into.Key(), ids)
+	srcBody, m := rewriteLinks(from.Body, "notes", from.Key(), into.Key(), ids)
+	merged.Body = strings.TrimRight(merged.Body, "\n") + "\n\n## Merged from " + from.Key() + "\n\n" + stripTitle(srcBody) + "\n"
+	merged.Tags = append([]string(nil), into.Tags...)
+	merged.AddTags(from.Tags...)
+	merged.Touch()
+
+	intoBefore, err := os.ReadFile(intoPath)
+	if err != nil {
+		return nil, err
+	}
+	fromBefore, err := os.ReadFile(fromPath)
+	if err != nil {
+		return nil, err
+	}
+	after, err := markdown.SerializeFrontmatter(&merged, merged.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize note: %w", err)
+	}
+	plan.Changes = append([]FileChange{
+		{From: intoPath, To: intoPath, Before: string(intoBefore), After: after, Links: n + m},
+		{From: fromPath, Before: string(fromBefore)},
+	}, plan.Changes...)
+	return plan, nil
+}
+
+// stripTitle removes a leading "# " heading, which a merged note does not need.
+func stripTitle(body string) string {
+	body = strings.TrimSpace(body)
+	if strings.HasPrefix(body, "# ") {
+		if i := strings.IndexByte(body, '\n'); i >= 0 {
+			return strings.TrimSpace(body[i+1:])
+		}
+		return ""
+	}
+	return body
+}
+
+// notePath returns the file path of a loaded note.
+func (s *Store) notePath(note *Note) (string, error) {
+	if note.Type == NoteTypeDaily {
+		return s.DailyPath(note.Date)
+	}
+	if IsPeriodType(note.Type) {
+		return s.PeriodPath(note.Period)
+	}
+	return s.FloatingPath(note.ID)
+}
+
+// planLinkRewrites prepares rewriting links to from as links to to in every
+// page that links to from, except the page at skip. It also returns the
+// floating note IDs, for resolving prefixes.
+func (s *Store) planLinkRewrites(from, to, skip string) (*Plan, []string, error) {
+	graph, err := s.LinkGraph()
+	if err != nil {
+		return nil, nil, err
+	}
+	ids := graph.floatingIDs()
+
+	sources := make(map[string]bool)
+	for _, l := range graph.Backlinks(from) {
+		sources[l.Source] = true
+	}
+	keys := make([]string, 0, len(sources))
+	for key := range sources {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	plan := &Plan{}
+	for _, key := range keys {
+		rel := key
+		if graph.IsNote(key) {
+			rel = "notes/" + key + ".md"
+		}
+		p := filepath.Join(s.WikiDir, filepath.FromSlash(rel))
+		if p == skip {
+			continue
+		}
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return nil, nil, err
+		}
+		fm, body := splitFrontmatter(string(content))
+		body, n := rewriteLinks(body, filepath.ToSlash(filepath.Dir(rel)), from, to, ids)
+		if n == 0 {
+			continue
+		}
+		plan.Changes = append(plan.Changes, FileChange{From: p, To: p, Before: string(content), After: fm + body, Links: n})
+	}
+	return plan, ids, nil
+}
+
+// splitFrontmatter splits raw content into its frontmatter block (with
+// delimiters) and body, without reformatting either.
+func splitFrontmatter(content string) (string, string) {
+	if !strings.HasPrefix(content, "---\n") {
+		return "", content
+	}
+	end := strings.Index(content[4:], "\n---\n")
+	if end < 0 {
+		return "", content
+	}
+	cut := 4 + end + len("\n---\n")
+	return content[:cut], content[cut:]
+}
+
+// rewriteLinks replaces links to from with links to to, keeping each link's
+// form, text and anchor. Link targets are resolved as by ParseLinks, with ID
+// prefixes expanded using ids. It returns the new body and the number of
+// links changed.
+func rewriteLinks(body, dir, from, to string, ids []string) (string, int) {
+	matches := func(target string, wiki bool) bool {
+		resolved := resolveTarget(target, dir, wiki)
+		return resolved != "" && resolvePrefix(resolved, ids) == from
+	}
+
+	count := 0
+	lines := strings.Split(body, "\n")
+	inFence := false
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		line = replaceTargets(line, wikiLinkRe, func(target string) (string, bool) {
+			if !matches(strings.TrimSpace(target), true) {
+				return "", false
+			}
+			return replaceKey(target, to), true
+		}, &count)
+		line = replaceTargets(line, mdLinkRe, func(target string) (string, bool) {
+			if !matches(target, false) {
+				return "", false
+			}
+			return replaceKey(target, to), true
+		}, &count)
+		line = replaceTargets(line, noteRefRe, func(target string) (string, bool) {
+			if resolvePrefix(target, ids) != from {
+				return "", false
+			}
+			return to, true
+		}, &count)
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n"), count
+}
+
+// replaceTargets rewrites the last capture group holding a link target in
+// each match of re, skipping matches inside inline code.
+func replaceTargets(line string, re *regexp.Regexp, replace func(string) (string, bool), count *int) string {
+	code := inlineCodeRe.FindAllStringIndex(line, -1)
+	inCode := func(pos int) bool {
+		for _, c := range code {
+			if pos >= c[0] && pos < c[1] {
+				return true
+			}
+		}
+		return false
+	}
+
+	// The target is group 1 for wiki links and @note refs, group 2 for
+	// Markdown links.
+	group := 1
+	if re == mdLinkRe {
+		group = 2
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range re.FindAllStringSubmatchIndex(line, -1) {
+		start, end := m[2*group], m[2*group+1]
+		if start < 0 || inCode(m[0]) {
+			continue
+		}
+		repl, ok := replace(line[start:end])
+		if !ok {
+			continue
+		}
+		b.WriteString(line[last:start])
+		b.WriteString(repl)
+		last = end
+		*count++
+	}
+	b.WriteString(line[last:])
+	return b.String()
+}
+
+// replaceKey swaps the note key in a link target for key, keeping any
+// directory, ".md" extension, anchor and surrounding space.
+func replaceKey(target, key string) string {
+	trimmed := strings.TrimSpace(target)
+	lead := target[:strings.Index(target, trimmed)]
+	trail := target[len(lead)+len(trimmed):]
+	if i := strings.IndexByte(trimmed, '#'); i >= 0 {
+		trimmed, trail = trimmed[:i], trimmed[i:]+trail
+	}
+
+	dir := ""
+	if i := strings.LastIndex(trimmed, "/"); i >= 0 {
+		dir, trimmed = trimmed[:i+1], trimmed[i+1:]
+	}
+	ext := ""
+	if strings.HasSuffix(trimmed, ".md") {
+		ext = ".md"
+	}
+	return lead + dir + key + ext + trail
+}
+
+// Apply writes a plan's changes. Every file is written before any is
+// removed, and if a write fails the files already written are restored, so
+// the wiki is left either fully changed or unchanged.
+func (s *Store) Apply(p *Plan) error {
+	var written []FileChange
+	rollback := func() {
+		for _, c := range written {
+			if c.From == c.To {
+				os.WriteFile(c.To, []byte(c.Before), 0644)
+			} else {
+				os.Remove(c.To)
+			}
+		}
+	}
+
+	for _, c := range p.Changes {
+		if c.To == "" {
+			continue
+		}
+		tmp := c.To + ".tmp"
+		if err := os.WriteFile(tmp, []byte(c.After), 0644); err != nil {
+			os.Remove(tmp)
+			rollback()
+			return fmt.Errorf("failed to write %s: %w", c.To, err)
+		}
+		if err := os.Rename(tmp, c.To); err != nil {
+			os.Remove(tmp)
+			rollback()
+			return fmt.Errorf("failed to write %s: %w", c.To, err)
+		}
+		written = append(written, c)
+	}
+
+	// Rewritten links often keep a file's size, and its mtime may not have
+	// moved on yet, so drop the link cache rather than trust it.
+	os.Remove(filepath.Join(s.NotesDir, linkCacheFile))
+
+	for _, c := range p.Changes {
+		if c.From != "" && c.From != c.To {
+			if err := os.Remove(c.From); err != nil {
+				return fmt.Errorf("failed to remove %s: %w", c.From, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package notes
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func renameWiki(t *testing.T) *Store {
+	t.Helper()
+	return writeWiki(t, map[string]string{
+		"notes/a1b2c3d4.md": "---\ntype: floating\nid: a1b2c3d4\ntags: [plan]\n---\n# Caching plan\n\nSelf [[a1b2c3d4]]\n",
+		"notes/2026-01-20.md": "---\ntype: daily\ndate: \"2026-01-20\"\n---\n# 2026-01-20\n\n" +
+			"Worked on [[a1b2c3|the plan]] and [again](a1b2c3d4.md#top).\n" +
+			"Not `[[a1b2c3d4]]` in code. @note:a1b2c3d4\n",
+		"notes/bbbbbbbb.md":  "---\ntype: floating\nid: bbbbbbbb\ntags: [infra]\n---\n# Other\n\nSee [[a1b2c3d4]]\n",
+		"recipes/pasta.md":   "# Pasta\n\nPlan: [plan](../notes/a1b2c3d4.md)\n",
+		"tasks/work.md":      "# Work\n\n- [ ] Ship it\n  - See [[/notes/a1b2c3d4]]\n",
+		"notes/unrelated.md": "# Unrelated\n\n[[bbbbbbbb]]\n",
+	})
+}
+
+func readFile(t *testing.T, store *Store, rel string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(store.WikiDir, rel))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestRename(t *testing.T) {
+	store := renameWiki(t)
+	plan, err := store.PlanRename("a1b2", "cafef00d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Self link, 3 in the daily note, 1 each in bbbbbbbb, pasta and work.
+	if plan.Links() != 7 || len(plan.Changes) != 5 {
+		t.Fatalf("expected 7 links in 5 files, got %d in %d", plan.Links(), len(plan.Changes))
+	}
+	daily := plan.Changes[1]
+	if lines := daily.Lines(); len(lines) != 2 || lines[0].Line != 7 {
+		t.Errorf("unexpected diff %+v", lines)
+	}
+	if err := store.Apply(plan); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(store.NotesDir, "a1b2c3d4.md")); !os.IsNotExist(err) {
+		t.Error("old note not removed")
+	}
+	note, err := store.LoadFloating("cafef00d")
+	if err != nil || note.ID != "cafef00d" || !strings.Contains(note.Body, "Self [[cafef00d]]") {
+		t.Errorf("renamed note: %+v, %v", note, err)
+	}
+	for rel, want := range map[string]string{
+		"notes/2026-01-20.md": "Worked on [[cafef00d|the plan]] and [again](cafef00d.md#top).\n" +
+			"Not `[[a1b2c3d4]]` in code. @note:cafef00d\n",
+		"recipes/pasta.md": "[plan](../notes/cafef00d.md)",
+		"tasks/work.md":    "[[/notes/cafef00d]]",
+	} {
+		if got := readFile(t, store, rel); !strings.Contains(got, want) {
+			t.Errorf("%s not rewritten:\n%s", rel, got)
+		}
+	}
+	if !strings.HasPrefix(readFile(t, store, "notes/2026-01-20.md"), "---\ntype: daily\ndate: \"2026-01-20\"\n---\n") {
+		t.Error("frontmatter of a linking note was reformatted")
+	}
+
+	g, err := store.LinkGraph()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Backlinks("a1b2c3d4")) != 0 || len(g.Backlinks("cafef00d")) != 6 {
+		t.Errorf("graph not updated: %v", g.Links)
+	}
+
+	if _, err := store.PlanRename("cafef00d", "bbbbbbbb"); err == nil {
+		t.Error("expected an error renaming onto an existing note")
+	}
+	if _, err := store.PlanRename("2026-01-20", "cafe"); err == nil {
+		t.Error("expected an error renaming a daily note to an ID")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	store := renameWiki(t)
+	plan, err := store.PlanMerge("a1b2c3d4", "bbbbbbbb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Apply(plan); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(store.NotesDir, "a1b2c3d4.md")); !os.IsNotExist(err) {
+		t.Error("merged note not removed")
+	}
+	into, err := store.LoadFloating("bbbbbbbb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(into.Body, "See [[bbbbbbbb]]\n\n## Merged from a1b2c3d4\n\nSelf [[bbbbbbbb]]") {
+		t.Errorf("unexpected merged body:\n%s", into.Body)
+	}
+	if !into.HasTag("plan") || !into.HasTag("infra") {
+		t.Errorf("tags not merged: %v", into.Tags)
+	}
+	if got := readFile(t, store, "recipes/pasta.md"); !strings.Contains(got, "../notes/bbbbbbbb.md") {
+		t.Errorf("recipe not rewritten:\n%s", got)
+	}
+}
+
+func TestApply_Rollback(t *testing.T) {
+	store := renameWiki(t)
+	plan, err := store.PlanRename("a1b2c3d4", "cafef00d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Make a final write fail: its directory does not exist.
+	last := plan.Changes[len(plan.Changes)-1]
+	plan.Changes = append(plan.Changes, FileChange{From: "", To: filepath.Join(store.WikiDir, "missing", "x.md"), After: "x"})
+	if err := store.Apply(plan); err == nil {
+		t.Fatal("expected the write to fail")
+	}
+	if got := readFile(t, store, strings.TrimPrefix(last.To, store.WikiDir+"/")); got != last.Before {
+		t.Errorf("%s not restored:\n%s", last.To, got)
+	}
+	if _, err := os.Stat(filepath.Join(store.NotesDir, "cafef00d.md")); !os.IsNotExist(err) {
+		t.Error("renamed note left behind after rollback")
+	}
+	if _, err := os.Stat(filepath.Join(store.NotesDir, "a1b2c3d4.md")); err != nil {
+		t.Error("original note removed despite rollback")
+	}
+}
@@ -0,0 +1,468 @@
+package notes
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxIncludeDepth bounds nested {{INCLUDE:name}} directives.
+const maxIncludeDepth = 8
+
+// TemplateContext supplies the values and input used to render a template.
+type TemplateContext struct {
+	// Now is the date and time the template is rendered for; for a daily
+	// note it is the note's date.
+	Now time.Time
+
+	// Vars are template variables, such as values given with --var. They
+	// override the built-in variables and answer prompts with the same
+	// name. Prompt answers are added as they are given.
+	Vars map[string]string
+
+	// Prompt reads answers to prompts. When nil, prompts take their default
+	// value and unset variables are an error.
+	Prompt *bufio.Reader
+
+	// Out receives prompt questions; it defaults to os.Stderr.
+	Out io.Writer
+
+	// Include returns the content of another template by name.
+	Include func(name string) (string, error)
+
+	// declared holds the variables named by {{VARS:...}}, which are
+	// prompted for when unset.
+	declared map[string]bool
+}
+
+// NewTemplateContext returns a context for rendering at now that includes
+// templates from the store.
+func (s *Store) NewTemplateContext(now time.Time) *TemplateContext {
+	return &TemplateContext{
+		Now:  now,
+		Vars: make(map[string]string),
+		Include: func(name string) (string, error) {
+			t, err := s.GetTemplate(name)
+			if err != nil {
+				return "", err
+			}
+			return t.Content, nil
+		},
+	}
+}
+
+var (
+	// identRe matches a template variable name.
+	identRe = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+	// slugRe matches the runs of characters dropped when a prompt question
+	// is turned into a variable name.
+	slugRe = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// tmplNode is a literal, a {{tag}} or an {{#if}} block of a parsed template.
+type tmplNode struct {
+	text string
+	tag  string
+	// raw is the tag as written, for tags that are kept as text.
+	raw string
+	// cond is the variable an {{#if}} block tests; body and alt are its
+	// branches.
+	cond      string
+	body, alt []tmplNode
+}
+
+// parseTemplate splits a template into literals, tags and {{#if}} blocks.
+// {{#if}}, {{else}} and {{/if}} are paired like brackets; any that do not
+// pair up, and a {{ that is never closed, are kept as text, as templates
+// written before blocks existed expect.
+func parseTemplate(src string) ([]tmplNode, error) {
+	toks, err := splitTemplate(src)
+	if err != nil {
+		return nil, err
+	}
+	ends := make(map[int]int)  // {{#if}} -> its {{/if}}
+	elses := make(map[int]int) // {{#if}} -> its {{else}}
+	var open []int
+	for i, t := range toks {
+		switch {
+		case ifCond(t.tag) != "":
+			open = append(open, i)
+		case t.tag == "else" && len(open) > 0:
+			top := open[len(open)-1]
+			if _, ok := elses[top]; !ok {
+				elses[top] = i
+			}
+		case t.tag == "/if" && len(open) > 0:
+			ends[open[len(open)-1]] = i
+			open = open[:len(open)-1]
+		}
+	}
+	return buildNodes(toks, 0, len(toks), ends, elses), nil
+}
+
+// splitTemplate splits a template into literals and tags.
+func splitTemplate(src string) ([]tmplNode, error) {
+	var toks []tmplNode
+	pos := 0
+	for {
+		start := strings.Index(src[pos:], "{{")
+		if start == -1 {
+			break
+		}
+		start += pos
+		stop := strings.Index(src[start:], "}}")
+		if stop == -1 {
+			if strings.HasPrefix(src[start:], "{{PROMPT:") {
+				return nil, fmt.Errorf("unclosed PROMPT placeholder at position %d", start)
+			}
+			break
+		}
+		stop += start + 2
+		if start > pos {
+			toks = append(toks, tmplNode{text: src[pos:start]})
+		}
+		tag := strings.TrimSpace(src[start+2 : stop-2])
+		toks = append(toks, tmplNode{tag: tag, raw: src[start:stop]})
+		pos = stop
+
+		// A declaration on a line of its own leaves no blank line.
+		if strings.HasPrefix(tag, "VARS:") && strings.HasPrefix(src[pos:], "\n") &&
+			(start == 0 || src[start-1] == '\n') {
+			pos++
+		}
+	}
+	if pos < len(src) {
+		toks = append(toks, tmplNode{text: src[pos:]})
+	}
+	return toks, nil
+}
+
+// buildNodes turns toks[lo:hi] into nodes, nesting the paired blocks and
+// turning unpaired block tags into text.
+func buildNodes(toks []tmplNode, lo, hi int, ends, elses map[int]int) []tmplNode {
+	var nodes []tmplNode
+	for i := lo; i < hi; i++ {
+		t := toks[i]
+		end, paired := ends[i]
+		switch {
+		case paired:
+			node := tmplNode{cond: ifCond(t.tag)}
+			if e, ok := elses[i]; ok {
+				node.body = buildNodes(toks, i+1, e, ends, elses)
+				node.alt = buildNodes(toks, e+1, end, ends, elses)
+			} else {
+				node.body = buildNodes(toks, i+1, end, ends, elses)
+			}
+			nodes = append(nodes, node)
+			i = end
+		case ifCond(t.tag) != "" || t.tag == "else" || t.tag == "/if":
+			nodes = append(nodes, tmplNode{text: t.raw})
+		default:
+			nodes = append(nodes, t)
+		}
+	}
+	return nodes
+}
+
+// ifCond returns the variable an {{#if name}} tag tests, or "" if tag is
+// not one.
+func ifCond(tag string) string {
+	cond, ok := strings.CutPrefix(tag, "#if ")
+	if cond = strings.TrimSpace(cond); !ok || !identRe.MatchString(cond) {
+		return ""
+	}
+	return cond
+}
+
+// RenderTemplate renders a template. Besides the legacy {{DATE}}, {{TIME}},
+// {{DATETIME}} and {{PROMPT:question}} placeholders it supports:
+//
+//   - {{name}} and {{name|default}}: a variable. The built-in variables are
+//     date, time, weekday, iso_week, yesterday, tomorrow, yesterday_link and
+//     tomorrow_link; goals_due is set by the caller. An unset variable
+//     without a default is kept as written, as templates written before
+//     variables existed expect, unless it is declared.
+//   - {{VARS:name,other}}: declares variables to prompt for, or to require
+//     with --var, when unset. It renders as nothing.
+//   - {{PROMPT:question|default}} and {{PROMPT:name=question}}: a prompt
+//     whose answer is stored in the variable name, or in the question
+//     turned into a name ("Meeting title" becomes meeting_title).
+//   - {{CHOICE:question|first|second}}: a prompt for one of the options,
+//     defaulting to the first.
+//   - {{INCLUDE:name}}: another template, rendered in place.
+//   - {{#if name}}...{{else}}...{{/if}}: a block kept when the variable is
+//     set and not empty.
+//
+// Any other {{...}} text, such as a cloze deletion, is kept as written.
+func RenderTemplate(template string, ctx *TemplateContext) (string, error) {
+	if ctx.Now.IsZero() {
+		ctx.Now = time.Now()
+	}
+	if ctx.Vars == nil {
+		ctx.Vars = make(map[string]string)
+	}
+	if ctx.Out == nil {
+		ctx.Out = os.Stderr
+	}
+	var b strings.Builder
+	if err := ctx.render(&b, template, 0); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func (ctx *TemplateContext) render(b *strings.Builder, template string, depth int) error {
+	nodes, err := parseTemplate(template)
+	if err != nil {
+		return err
+	}
+	ctx.declare(nodes)
+	return ctx.renderNodes(b, nodes, depth)
+}
+
+// declare records the variables named by the {{VARS:...}} tags in nodes,
+// wherever they appear, so a declaration at the end still applies.
+func (ctx *TemplateContext) declare(nodes []tmplNode) {
+	for _, n := range nodes {
+		if names, ok := strings.CutPrefix(n.tag, "VARS:"); ok {
+			if ctx.declared == nil {
+				ctx.declared = make(map[string]bool)
+			}
+			for _, name := range strings.Split(names, ",") {
+				if name = strings.TrimSpace(name); name != "" {
+					ctx.declared[name] = true
+				}
+			}
+		}
+		ctx.declare(n.body)
+		ctx.declare(n.alt)
+	}
+}
+
+func (ctx *TemplateContext) renderNodes(b *strings.Builder, nodes []tmplNode, depth int) error {
+	for _, n := range nodes {
+		switch {
+		case n.cond != "":
+			branch := n.alt
+			if v, _ := ctx.lookup(n.cond); v != "" {
+				branch = n.body
+			}
+			if err := ctx.renderNodes(b, branch, depth); err != nil {
+				return err
+			}
+		case n.tag != "":
+			if err := ctx.renderTag(b, n.tag, n.raw, depth); err != nil {
+				return err
+			}
+		default:
+			b.WriteString(n.text)
+		}
+	}
+	return nil
+}
+
+func (ctx *TemplateContext) renderTag(b *strings.Builder, tag, raw string, depth int) error {
+	switch tag {
+	case "DATE":
+		b.WriteString(ctx.Now.Format("2006-01-02"))
+		return nil
+	case "TIME":
+		b.WriteString(ctx.Now.Format("15:04"))
+		return nil
+	case "DATETIME":
+		b.WriteString(ctx.Now.Format("2006-01-02 15:04"))
+		return nil
+	}
+
+	if strings.HasPrefix(tag, "VARS:") {
+		return nil
+	}
+	if q, ok := strings.CutPrefix(tag, "PROMPT:"); ok {
+		answer, err := ctx.prompt(q)
+		b.WriteString(answer)
+		return err
+	}
+	if q, ok := strings.CutPrefix(tag, "CHOICE:"); ok {
+		answer, err := ctx.choice(q)
+		b.WriteString(answer)
+		return err
+	}
+	if name, ok := strings.CutPrefix(tag, "INCLUDE:"); ok {
+		name = strings.TrimSpace(name)
+		if depth >= maxIncludeDepth {
+			return fmt.Errorf("template includes nested too deeply at %q", name)
+		}
+		if ctx.Include == nil {
+			return fmt.Errorf("cannot include template %q", name)
+		}
+		content, err := ctx.Include(name)
+		if err != nil {
+			return fmt.Errorf("failed to include template %q: %w", name, err)
+		}
+		return ctx.render(b, strings.TrimRight(content, "\n"), depth+1)
+	}
+
+	name, def, hasDefault := strings.Cut(tag, "|")
+	name = strings.TrimSpace(name)
+	if !identRe.MatchString(name) {
+		b.WriteString(raw)
+		return nil
+	}
+	if v, ok := ctx.lookup(name); ok {
+		b.WriteString(v)
+		return nil
+	}
+	if hasDefault {
+		b.WriteString(strings.TrimSpace(def))
+		return nil
+	}
+	if !ctx.declared[name] {
+		b.WriteString(raw)
+		return nil
+	}
+	if ctx.Prompt == nil {
+		return fmt.Errorf("template variable %q is not set (use --var %s=value)", name, name)
+	}
+	answer, err := ctx.ask(name, "")
+	if err != nil {
+		return err
+	}
+	ctx.Vars[name] = answer
+	b.WriteString(answer)
+	return nil
+}
+
+// lookup returns a variable, falling back to the built-in variables.
+func (ctx *TemplateContext) lookup(name string) (string, bool) {
+	if v, ok := ctx.Vars[name]; ok {
+		return v, true
+	}
+	day := func(d int) string { return ctx.Now.AddDate(0, 0, d).Format("2006-01-02") }
+	switch name {
+	case "date":
+		return day(0), true
+	case "time":
+		return ctx.Now.Format("15:04"), true
+	case "weekday":
+		return ctx.Now.Weekday().String(), true
+	case "iso_week":
+		year, week := ctx.Now.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week), true
+	case "yesterday":
+		return day(-1), true
+	case "tomorrow":
+		return day(1), true
+	case "yesterday_link":
+		return "[[" + day(-1) + "]]", true
+	case "tomorrow_link":
+		return "[[" + day(1) + "]]", true
+	}
+	return "", false
+}
+
+// prompt answers a {{PROMPT:...}} tag.
+func (ctx *TemplateContext) prompt(spec string) (string, error) {
+	question, def, _ := strings.Cut(spec, "|")
+	name, question := promptName(strings.TrimSpace(question))
+	def = strings.TrimSpace(def)
+
+	if v, ok := ctx.Vars[name]; ok {
+		return v, nil
+	}
+	answer := def
+	if ctx.Prompt != nil {
+		a, err := ctx.ask(question, def)
+		if err != nil {
+			return "", err
+		}
+		answer = a
+	}
+	ctx.Vars[name] = answer
+	return answer, nil
+}
+
+// choice answers a {{CHOICE:question|option|...}} tag.
+func (ctx *TemplateContext) choice(spec string) (string, error) {
+	parts := strings.Split(spec, "|")
+	name, question := promptName(strings.TrimSpace(parts[0]))
+	var options []string
+	for _, o := range parts[1:] {
+		if o = strings.TrimSpace(o); o != "" {
+			options = append(options, o)
+		}
+	}
+	if len(options) == 0 {
+		return "", fmt.Errorf("choice %q has no options", question)
+	}
+	pick := func(answer string) (string, bool) {
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(options) {
+			return options[n-1], true
+		}
+		for _, o := range options {
+			if strings.EqualFold(o, answer) {
+				return o, true
+			}
+		}
+		return "", false
+	}
+
+	if v, ok := ctx.Vars[name]; ok {
+		o, ok := pick(v)
+		if !ok {
+			return "", fmt.Errorf("%s must be one of %s, got %q", name, strings.Join(options, ", "), v)
+		}
+		return o, nil
+	}
+	answer := options[0]
+	if ctx.Prompt != nil {
+		fmt.Fprintln(ctx.Out, question)
+		for i, o := range options {
+			fmt.Fprintf(ctx.Out, "  %d) %s\n", i+1, o)
+		}
+		for {
+			a, err := ctx.ask("Choose", "1")
+			if err != nil {
+				return "", err
+			}
+			if o, ok := pick(a); ok {
+				answer = o
+				break
+			}
+			fmt.Fprintf(ctx.Out, "Enter 1-%d or an option\n", len(options))
+		}
+	}
+	ctx.Vars[name] = answer
+	return answer, nil
+}
+
+// ask prints a question and reads one line. An empty answer, or the end of
+// input, gives def.
+func (ctx *TemplateContext) ask(question, def string) (string, error) {
+	if def != "" {
+		fmt.Fprintf(ctx.Out, "%s [%s]: ", question, def)
+	} else {
+		fmt.Fprintf(ctx.Out, "%s: ", question)
+	}
+	line, err := ctx.Prompt.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("failed to read prompt answer: %w", err)
+	}
+	if answer := strings.TrimSpace(line); answer != "" {
+		return answer, nil
+	}
+	return def, nil
+}
+
+// promptName splits "name=question" into its variable name and question.
+// Without an explicit name, the name is the question in snake case.
+func promptName(spec string) (name, question string) {
+	if n, q, ok := strings.Cut(spec, "="); ok && identRe.MatchString(strings.TrimSpace(n)) {
+		return strings.TrimSpace(n), strings.TrimSpace(q)
+	}
+	return strings.Trim(slugRe.ReplaceAllString(strings.ToLower(spec), "_"), "_"), spec
+}
@@ -234,12 +234,15 @@ func (s *Store) findFloatingByPrefix(prefix string) ([]string, error) {
 			continue
 		}
 
-		// Check if it's a floating note (not a date)
+		// Check if it's a floating note (not a date or period)
 		base := strings.TrimSuffix(name, ".md")
 		if _, err := time.Parse("2006-01-02", base); err == nil {
 			// It's a date, skip
 			continue
 		}
+		if _, ok := ParsePeriod(base); ok {
+			continue
+		}
 
 		// Check if it matches the prefix
 		if strings.HasPrefix(base, prefix) {
@@ -264,3 +267,15 @@ func isHexString(s string) bool {
 func (s *Store) LoadNote(path string) (*Note, error) {
 	return s.loadNote(path)
 }
+
+// Load loads a daily note by date, a period note by key or a floating note
+// by ID or prefix.
+func (s *Store) Load(dateOrID string) (*Note, error) {
+	if _, err := time.Parse("2006-01-02", dateOrID); err == nil {
+		return s.LoadDaily(dateOrID)
+	}
+	if _, ok := ParsePeriod(dateOrID); ok {
+		return s.LoadPeriod(dateOrID)
+	}
+	return s.LoadFloating(dateOrID)
+}
@@ -105,50 +105,11 @@ func (s *Store) DeleteTemplate(name string) error {
 // - {{TIME}} - current time in HH:MM format
 // - {{DATETIME}} - current datetime
 // - {{PROMPT:question}} - prompts user for input
+//
+// It is RenderTemplate with no variables or includes; see RenderTemplate
+// for the full syntax.
 func ApplyTemplate(template string, promptReader *bufio.Reader) (string, error) {
-	result := template
-	now := time.Now()
-
-	// Replace simple placeholders
-	result = strings.ReplaceAll(result, "{{DATE}}", now.Format("2006-01-02"))
-	result = strings.ReplaceAll(result, "{{TIME}}", now.Format("15:04"))
-	result = strings.ReplaceAll(result, "{{DATETIME}}", now.Format("2006-01-02 15:04"))
-
-	// Process PROMPT placeholders
-	for {
-		start := strings.Index(result, "{{PROMPT:")
-		if start == -1 {
-			break
-		}
-
-		end := strings.Index(result[start:], "}}")
-		if end == -1 {
-			return "", fmt.Errorf("unclosed PROMPT placeholder at position %d", start)
-		}
-		end += start
-
-		// Extract prompt question
-		placeholder := result[start : end+2]
-		question := strings.TrimSpace(result[start+9 : end])
-
-		// Get user input
-		var answer string
-		if promptReader != nil {
-			fmt.Fprintf(os.Stderr, "%s: ", question)
-			line, err := promptReader.ReadString('\n')
-			if err != nil {
-				return "", fmt.Errorf("failed to read prompt answer: %w", err)
-			}
-			answer = strings.TrimSpace(line)
-		} else {
-			answer = "" // No prompt reader, use empty string
-		}
-
-		// Replace placeholder with answer
-		result = strings.Replace(result, placeholder, answer, 1)
-	}
-
-	return result, nil
+	return RenderTemplate(template, &TemplateContext{Now: time.Now(), Prompt: promptReader})
 }
 
 // Built-in templates
@@ -171,9 +132,14 @@ var BuiltInTemplates = map[string]string{
 ## Next Steps
 `,
 
-	"reflection": `# Reflection: {{DATE}}
+	"reflection": `# Reflection: {{date}} ({{weekday}})
+
+Previous: {{yesterday_link}}
 
-## What went well today?
+{{#if goals_due}}## Goals due
+{{goals_due}}
+
+{{/if}}## What went well today?
 {{PROMPT:What went well}}
 
 ## What could be improved?
@@ -183,6 +149,73 @@ var BuiltInTemplates = map[string]string{
 
 
 ## Tomorrow's focus
+`,
+
+	"weekly": `# {{period_title}}
+
+{{period_start}} to {{period_end}} · Previous: {{previous_link}} · Next: {{next_link}}
+
+## Highlights
+
+
+## Challenges
+
+
+## Lessons
+
+
+## Focus for next week
+- [ ] 
+`,
+
+	"monthly": `# {{period_title}}
+
+Previous: {{previous_link}} · Next: {{next_link}}
+
+## Highlights
+
+
+## Progress on goals
+
+
+## What to change
+
+
+## Focus for next month
+- [ ] 
+`,
+
+	"quarterly": `# {{period_title}}
+
+Previous: {{previous_link}} · Next: {{next_link}}
+
+## Wins
+
+
+## Misses
+
+
+## Themes
+
+
+## Goals for next quarter
+- [ ] 
+`,
+
+	"yearly": `# {{period_title}}
+
+Previous: {{previous_link}} · Next: {{next_link}}
+
+## The year in a sentence
+
+
+## Best moments
+
+
+## What I learned
+
+
+## Intentions for next year
 `,
 
 	"idea": `# Idea: {{PROMPT:Idea title}}
@@ -3,8 +3,10 @@ package notes
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestApplyTemplate(t *testing.T) {
@@ -305,6 +307,159 @@ func TestDeleteTemplateNotFound(t *testing.T) {
 	}
 }
 
+func TestRenderTemplate(t *testing.T) {
+	store := NewStore(t.TempDir())
+	if err := store.CreateTemplate("footer", "Filed {{weekday}} of {{iso_week}}"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.CreateTemplate("loop", "{{INCLUDE:loop}}"); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 1, 5, 9, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		template string
+		vars     map[string]string
+		input    string
+		want     string
+	}{
+		{
+			name:     "built-in variables",
+			template: "{{date}} {{DATE}} {{time}} after {{yesterday_link}}",
+			want:     "2026-01-05 2026-01-05 09:30 after [[2026-01-04]]",
+		},
+		{
+			name:     "vars answer prompts by name",
+			template: "# {{PROMPT:Meeting title}} with {{PROMPT:who=Attendees}} ({{meeting_title}})",
+			vars:     map[string]string{"meeting_title": "Sync", "who": "Ann"},
+			want:     "# Sync with Ann (Sync)",
+		},
+		{
+			name:     "defaults",
+			template: "{{PROMPT:Title|Untitled}} by {{author|me}}",
+			input:    "\n",
+			want:     "Untitled by me",
+		},
+		{
+			name:     "choice",
+			template: "Mood: {{CHOICE:How was it?|good|okay|bad}}",
+			input:    "7\nBAD\n",
+			want:     "Mood: bad",
+		},
+		{
+			name:     "conditionals",
+			template: "{{#if goals_due}}Due:\n{{goals_due}}{{else}}Nothing due{{/if}}.{{#if missing}} no{{/if}}",
+			vars:     map[string]string{"goals_due": "- [ ] Ship"},
+			want:     "Due:\n- [ ] Ship.",
+		},
+		{
+			name:     "include",
+			template: "Done. {{INCLUDE:footer}}",
+			want:     "Done. Filed Monday of 2026-W02",
+		},
+		{
+			name:     "declared variables are prompted for",
+			template: "{{VARS: client, project}}\nFor {{client}} on {{project|misc}}, {{client}} again",
+			input:    "Acme\n",
+			want:     "For Acme on misc, Acme again",
+		},
+		{
+			name:     "other braces kept",
+			template: "The {{c1::GOPATH}} predates modules",
+			want:     "The {{c1::GOPATH}} predates modules",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := store.NewTemplateContext(now)
+			ctx.Out = io.Discard
+			for k, v := range tt.vars {
+				ctx.Vars[k] = v
+			}
+			if tt.input != "" {
+				ctx.Prompt = bufio.NewReader(strings.NewReader(tt.input))
+			}
+			got, err := RenderTemplate(tt.template, ctx)
+			if err != nil {
+				t.Fatalf("RenderTemplate failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	for _, bad := range []string{"{{VARS:name}}{{name}}", "{{INCLUDE:loop}}", "{{CHOICE:Pick|a|b}}"} {
+		ctx := store.NewTemplateContext(now)
+		ctx.Vars["pick"] = "c"
+		if _, err := RenderTemplate(bad, ctx); err == nil {
+			t.Errorf("RenderTemplate(%q): expected an error", bad)
+		}
+	}
+}
+
+// Templates written before variables existed may contain lowercase
+// {{placeholders}} meant as text; without a declaration they are kept.
+func TestRenderTemplateKeepsUndeclaredPlaceholders(t *testing.T) {
+	store := NewStore(t.TempDir())
+	old := "# Standup {{DATE}}\n\nCopy {{handlebars}} and {{user_name}} from the wiki.\n"
+	if err := store.CreateTemplate("standup", old); err != nil {
+		t.Fatal(err)
+	}
+	tmpl, err := store.GetTemplate("standup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := store.NewTemplateContext(time.Date(2026, 1, 5, 9, 30, 0, 0, time.UTC))
+	got, err := RenderTemplate(tmpl.Content, ctx)
+	if err != nil {
+		t.Fatalf("RenderTemplate failed: %v", err)
+	}
+	if want := "# Standup 2026-01-05\n\nCopy {{handlebars}} and {{user_name}} from the wiki.\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	ctx.Vars["user_name"] = "ann"
+	if got, _ := RenderTemplate(tmpl.Content, ctx); !strings.Contains(got, "{{handlebars}} and ann") {
+		t.Errorf("a --var value should still be used: %q", got)
+	}
+}
+
+// Templates written before blocks existed may hold a lone {{ or snippets of
+// other template languages; whatever is not a complete placeholder or a
+// paired block is kept as written.
+func TestRenderTemplateKeepsLegacyText(t *testing.T) {
+	ctx := NewStore(t.TempDir()).NewTemplateContext(time.Date(2026, 1, 5, 9, 30, 0, 0, time.UTC))
+	for tmpl, want := range map[string]string{
+		"# {{DATE}}\n\nUse {{ to open and }} to close; a {{ stays.\n":      "# 2026-01-05\n\nUse {{ to open and }} to close; a {{ stays.\n",
+		"Handlebars: {{else}} and {{ /if }} and {{#if user.name}}x{{/if}}": "Handlebars: {{else}} and {{ /if }} and {{#if user.name}}x{{/if}}",
+		"{{#if x}}open {{ user }}":                                         "{{#if x}}open {{ user }}",
+		"{{#if a}}{{#if date}}on {{DATE}}{{/if}}{{else}}":                  "{{#if a}}on 2026-01-05{{else}}",
+		"{{#if date}}a{{else}}b{{else}}c{{/if}}":                           "a",
+	} {
+		got, err := RenderTemplate(tmpl, ctx)
+		if err != nil {
+			t.Errorf("RenderTemplate(%q) failed: %v", tmpl, err)
+		} else if got != want {
+			t.Errorf("RenderTemplate(%q) = %q, want %q", tmpl, got, want)
+		}
+	}
+
+	// Unpaired #if tags are kept without re-reading the rest each time.
+	done := make(chan struct{})
+	go func() {
+		RenderTemplate(strings.Repeat("{{#if a}}{{#if b}}", 200), ctx)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("unpaired blocks took too long")
+	}
+}
+
 // Helper function for creating errors in tests
 func errorf(format string, args ...interface{}) error {
 	return &testError{msg: strings.TrimSpace(fmt.Sprintf(format, args...))}
@@ -0,0 +1,313 @@
+package oidc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// maxResponseBytes caps how much of a provider response is read into memory.
+const maxResponseBytes = 1 << 20
+
+// AuthMethod identifies how a client authenticates to the token, introspection
+// and revocation endpoints.
+type AuthMethod string
+
+const (
+	// AuthClientSecretBasic sends credentials in an HTTP Basic Authorization header.
+	AuthClientSecretBasic AuthMethod = "client_secret_basic"
+	// AuthClientSecretPost sends credentials as form parameters.
+	AuthClientSecretPost AuthMethod = "client_secret_post"
+	// AuthPrivateKeyJWT sends a signed client assertion (RFC 7523).
+	AuthPrivateKeyJWT AuthMethod = "private_key_jwt"
+	// AuthNone sends only the client_id, for public clients.
+	AuthNone AuthMethod = "none"
+)
+
+// clientAssertionType is the assertion type for private_key_jwt (RFC 7523 section 2.2).
+const clientAssertionType = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+// ParseAuthMethod converts a flag value into an AuthMethod.
+// The short forms "basic", "post" and "jwt" are accepted as aliases.
+func ParseAuthMethod(s string) (AuthMethod, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "basic", string(AuthClientSecretBasic):
+		return AuthClientSecretBasic, nil
+	case "post", string(AuthClientSecretPost):
+		return AuthClientSecretPost, nil
+	case "jwt", string(AuthPrivateKeyJWT):
+		return AuthPrivateKeyJWT, nil
+	case string(AuthNone):
+		return AuthNone, nil
+	default:
+		return "", fmt.Errorf("unsupported auth method: %s (use client_secret_basic, client_secret_post, private_key_jwt or none)", s)
+	}
+}
+
+// ClientAuth holds client credentials for authenticated endpoint calls.
+// Assertion must be a signed JWT when Method is AuthPrivateKeyJWT.
+type ClientAuth struct {
+	Method       AuthMethod
+	ClientID     string
+	ClientSecret string
+	Assertion    string
+}
+
+// apply adds the client credentials to a form and request.
+func (a ClientAuth) apply(form url.Values, req *http.Request) error {
+	if a.ClientID == "" {
+		return fmt.Errorf("client-id is required")
+	}
+
+	switch a.Method {
+	case AuthClientSecretBasic, "":
+		if a.ClientSecret == "" {
+			return fmt.Errorf("client secret is required for client_secret_basic")
+		}
+		// RFC 6749 section 2.3.1 requires form-encoding before Basic encoding.
+		req.SetBasicAuth(url.QueryEscape(a.ClientID), url.QueryEscape(a.ClientSecret))
+	case AuthClientSecretPost:
+		if a.ClientSecret == "" {
+			return fmt.Errorf("client secret is required for client_secret_post")
+		}
+		form.Set("client_id", a.ClientID)
+		form.Set("client_secret", a.ClientSecret)
+	case AuthPrivateKeyJWT:
+		if a.Assertion == "" {
+			return fmt.Errorf("client assertion is required for private_key_jwt")
+		}
+		form.Set("client_id", a.ClientID)
+		form.Set("client_assertion_type", clientAssertionType)
+		form.Set("client_assertion", a.Assertion)
+	case AuthNone:
+		form.Set("client_id", a.ClientID)
+	default:
+		return fmt.Errorf("unsupported auth method: %s", a.Method)
+	}
+	return nil
+}
+
+// TokenResponse represents a successful token endpoint response (RFC 6749 section 5.1).
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type,omitempty"`
+	ExpiresIn    int64  `json:"expires_in,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+}
+
+// IntrospectionResponse represents a token introspection response (RFC 7662 section 2.2).
+// Claims holds the complete response, including provider-specific members.
+type IntrospectionResponse struct {
+	Active    bool                   `json:"active"`
+	Scope     string                 `json:"scope,omitempty"`
+	ClientID  string                 `json:"client_id,omitempty"`
+	Username  string                 `json:"username,omitempty"`
+	TokenType string                 `json:"token_type,omitempty"`
+	Exp       int64                  `json:"exp,omitempty"`
+	Iat       int64                  `json:"iat,omitempty"`
+	Nbf       int64                  `json:"nbf,omitempty"`
+	Sub       string                 `json:"sub,omitempty"`
+	Aud       interface{}            `json:"aud,omitempty"`
+	Iss       string                 `json:"iss,omitempty"`
+	Jti       string                 `json:"jti,omitempty"`
+	Claims    map[string]interface{} `json:"-"`
+}
+
+// ProviderError represents an OAuth error response from a provider.
+type ProviderError struct {
+	StatusCode  int    `json:"status_code"`
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+func (e *ProviderError) Error() string {
+	msg := fmt.Sprintf("provider returned HTTP %d", e.StatusCode)
+	if e.Code != "" {
+		msg += ": " + e.Code
+	}
+	if e.Description != "" {
+		msg += " (" + e.Description + ")"
+	}
+	return msg
+}
+
+// Client performs OAuth 2.0 / OIDC endpoint calls.
+type Client struct {
+	HTTPClient *http.Client
+}
+
+// NewClient creates a Client using the given HTTP client, or http.DefaultClient when nil.
+func NewClient(httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{HTTPClient: httpClient}
+}
+
+// Refresh exchanges a refresh token for new tokens (RFC 6749 section 6).
+// An empty scope requests the originally granted scope.
+func (c *Client) Refresh(endpoint string, auth ClientAuth, refreshToken, scope string) (*TokenResponse, error) {
+	if refreshToken == "" {
+		return nil, fmt.Errorf("refresh token is required")
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "refresh_token")
+	form.Set("refresh_token", refreshToken)
+	if scope != "" {
+		form.Set("scope", scope)
+	}
+
+	body, err := c.postForm(endpoint, auth, form)
+	if err != nil {
+		return nil, err
+	}
+
+	var result TokenResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("invalid token response: %w", err)
+	}
+	if result.AccessToken == "" {
+		return nil, fmt.Errorf("token response did not include an access_token")
+	}
+	return &result, nil
+}
+
+// Introspect queries the state of a token (RFC 7662).
+// tokenTypeHint may be empty, "access_token" or "refresh_token".
+func (c *Client) Introspect(endpoint string, auth ClientAuth, token, tokenTypeHint string) (*IntrospectionResponse, error) {
+	if token == "" {
+		return nil, fmt.Errorf("token is required")
+	}
+
+	form := url.Values{}
+	form.Set("token", token)
+	if tokenTypeHint != "" {
+		form.Set("token_type_hint", tokenTypeHint)
+	}
+
+	body, err := c.postForm(endpoint, auth, form)
+	if err != nil {
+		return nil, err
+	}
+
+	var result IntrospectionResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("invalid introspection response: %w", err)
+	}
+	if err := json.Unmarshal(body, &result.Claims); err != nil {
+		return nil, fmt.Errorf("invalid introspection response: %w", err)
+	}
+	if _, ok := result.Claims["active"]; !ok {
+		return nil, fmt.Errorf("introspection response is missing the required 'active' member")
+	}
+	return &result, nil
+}
+
+// Revoke asks the provider to invalidate a token (RFC 7009).
+// Providers respond with 200 even for unknown tokens, so success does not
+// prove the token was ever valid.
+func (c *Client) Revoke(endpoint string, auth ClientAuth, token, tokenTypeHint string) error {
+	if token == "" {
+		return fmt.Errorf("token is required")
+	}
+
+	form := url.Values{}
+	form.Set("token", token)
+	if tokenTypeHint != "" {
+		form.Set("token_type_hint", tokenTypeHint)
+	}
+
+	_, err := c.postForm(endpoint, auth, form)
+	return err
+}
+
+// UserInfo fetches claims about the authenticated end-user using a bearer access token.
+func (c *Client) UserInfo(endpoint, accessToken string) (map[string]interface{}, error) {
+	if endpoint == "" {
+		return nil, fmt.Errorf("userinfo endpoint is required")
+	}
+	if accessToken == "" {
+		return nil, fmt.Errorf("access token is required")
+	}
+
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid userinfo endpoint: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+
+	body, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Signed or encrypted userinfo responses (application/jwt) are not decoded here.
+	var claims map[string]interface{}
+	if err := json.Unmarshal(body, &claims); err != nil {
+		return nil, fmt.Errorf("invalid userinfo response (signed responses are not supported): %w", err)
+	}
+	return claims, nil
+}
+
+// postForm sends an authenticated form POST and returns the response body.
+func (c *Client) postForm(endpoint string, auth ClientAuth, form url.Values) ([]byte, error) {
+	if endpoint == "" {
+		return nil, fmt.Errorf("endpoint is required")
+	}
+
+	req, err := http.NewRequest(http.MethodPost, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint URL: %w", err)
+	}
+	if err := auth.apply(form, req); err != nil {
+		return nil, err
+	}
+
+	encoded := form.Encode()
+	req.Body = io.NopCloser(strings.NewReader(encoded))
+	req.ContentLength = int64(len(encoded))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	return c.do(req)
+}
+
+// do executes a request and converts non-2xx responses into ProviderErrors.
+func (c *Client) do(req *http.Request) ([]byte, error) {
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request to %s failed: %w", req.URL.Host, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		perr := &ProviderError{StatusCode: resp.StatusCode}
+		// Error bodies are not always JSON; fall back to the status alone.
+		_ = json.Unmarshal(body, perr)
+		if perr.Code == "" {
+			if challenge := resp.Header.Get("WWW-Authenticate"); challenge != "" {
+				perr.Description = challenge
+			}
+		}
+		return nil, perr
+	}
+
+	return body, nil
+}
@@ -0,0 +1,264 @@
+package oidc
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newTokenServer returns a test server that records the last request form and
+// answers with the given status and JSON body.
+func newTokenServer(t *testing.T, status int, body interface{}, captured *http.Request) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("failed to parse form: %v", err)
+		}
+		*captured = *r
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// TestParseAuthMethod verifies flag values map to auth methods.
+func TestParseAuthMethod(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    AuthMethod
+		wantErr bool
+	}{
+		{"", AuthClientSecretBasic, false},
+		{"basic", AuthClientSecretBasic, false},
+		{"client_secret_post", AuthClientSecretPost, false},
+		{"post", AuthClientSecretPost, false},
+		{"private_key_jwt", AuthPrivateKeyJWT, false},
+		{"none", AuthNone, false},
+		{"mtls", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseAuthMethod(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseAuthMethod(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseAuthMethod(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// TestRefresh_ClientSecretBasic verifies refresh requests use Basic auth.
+func TestRefresh_ClientSecretBasic(t *testing.T) {
+	var req http.Request
+	srv := newTokenServer(t, http.StatusOK, map[string]interface{}{
+		"access_token":  "new-access",
+		"token_type":    "Bearer",
+		"expires_in":    3600,
+		"refresh_token": "new-refresh",
+	}, &req)
+
+	client := NewClient(srv.Client())
+	auth := ClientAuth{Method: AuthClientSecretBasic, ClientID: "my client", ClientSecret: "s3cr:t"}
+	resp, err := client.Refresh(srv.URL, auth, "old-refresh", "openid")
+	if err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+
+	if resp.AccessToken != "new-access" || resp.RefreshToken != "new-refresh" || resp.ExpiresIn != 3600 {
+		t.Errorf("unexpected token response: %+v", resp)
+	}
+	if got := req.PostForm.Get("grant_type"); got != "refresh_token" {
+		t.Errorf("grant_type = %q, want refresh_token", got)
+	}
+	if got := req.PostForm.Get("refresh_token"); got != "old-refresh" {
+		t.Errorf("refresh_token = %q, want old-refresh", got)
+	}
+	if got := req.PostForm.Get("scope"); got != "openid" {
+		t.Errorf("scope = %q, want openid", got)
+	}
+
+	user, pass, ok := req.BasicAuth()
+	if !ok {
+		t.Fatal("expected Basic Authorization header")
+	}
+	if user != "my+client" || pass != "s3cr%3At" {
+		t.Errorf("credentials should be form-encoded, got %q / %q", user, pass)
+	}
+	if req.PostForm.Get("client_secret") != "" {
+		t.Error("client_secret must not be sent in the body for client_secret_basic")
+	}
+}
+
+// TestRefresh_ClientSecretPost verifies credentials are sent in the form body.
+func TestRefresh_ClientSecretPost(t *testing.T) {
+	var req http.Request
+	srv := newTokenServer(t, http.StatusOK, map[string]interface{}{"access_token": "a"}, &req)
+
+	auth := ClientAuth{Method: AuthClientSecretPost, ClientID: "client", ClientSecret: "secret"}
+	if _, err := NewClient(srv.Client()).Refresh(srv.URL, auth, "rt", ""); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+
+	if _, _, ok := req.BasicAuth(); ok {
+		t.Error("Basic auth must not be used for client_secret_post")
+	}
+	if req.PostForm.Get("client_id") != "client" || req.PostForm.Get("client_secret") != "secret" {
+		t.Errorf("expected client credentials in form, got %v", req.PostForm)
+	}
+	if req.PostForm.Has("scope") {
+		t.Error("scope should be omitted when empty")
+	}
+}
+
+// TestRefresh_PrivateKeyJWT verifies the client assertion parameters.
+func TestRefresh_PrivateKeyJWT(t *testing.T) {
+	var req http.Request
+	srv := newTokenServer(t, http.StatusOK, map[string]interface{}{"access_token": "a"}, &req)
+
+	auth := ClientAuth{Method: AuthPrivateKeyJWT, ClientID: "client", Assertion: "header.payload.sig"}
+	if _, err := NewClient(srv.Client()).Refresh(srv.URL, auth, "rt", ""); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+
+	if got := req.PostForm.Get("client_assertion_type"); got != clientAssertionType {
+		t.Errorf("client_assertion_type = %q", got)
+	}
+	if got := req.PostForm.Get("client_assertion"); got != "header.payload.sig" {
+		t.Errorf("client_assertion = %q", got)
+	}
+}
+
+// TestRefresh_MissingCredentials verifies auth validation happens before any request.
+func TestRefresh_MissingCredentials(t *testing.T) {
+	tests := []struct {
+		name string
+		auth ClientAuth
+	}{
+		{"missing client id", ClientAuth{Method: AuthClientSecretBasic, ClientSecret: "s"}},
+		{"basic without secret", ClientAuth{Method: AuthClientSecretBasic, ClientID: "c"}},
+		{"post without secret", ClientAuth{Method: AuthClientSecretPost, ClientID: "c"}},
+		{"jwt without assertion", ClientAuth{Method: AuthPrivateKeyJWT, ClientID: "c"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewClient(nil).Refresh("http://127.0.0.1:0/token", tt.auth, "rt", ""); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
+
+// TestRefresh_ProviderError verifies OAuth error responses are surfaced.
+func TestRefresh_ProviderError(t *testing.T) {
+	var req http.Request
+	srv := newTokenServer(t, http.StatusBadRequest, map[string]string{
+		"error":             "invalid_grant",
+		"error_description": "refresh token expired",
+	}, &req)
+
+	auth := ClientAuth{Method: AuthNone, ClientID: "client"}
+	_, err := NewClient(srv.Client()).Refresh(srv.URL, auth, "rt", "")
+
+	var perr *ProviderError
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected ProviderError, got %v", err)
+	}
+	if perr.StatusCode != http.StatusBadRequest || perr.Code != "invalid_grant" || perr.Description != "refresh token expired" {
+		t.Errorf("unexpected provider error: %+v", perr)
+	}
+}
+
+// TestIntrospect verifies RFC 7662 requests and responses.
+func TestIntrospect(t *testing.T) {
+	var req http.Request
+	srv := newTokenServer(t, http.StatusOK, map[string]interface{}{
+		"active":    true,
+		"scope":     "openid email",
+		"client_id": "client",
+		"sub":       "user-1",
+		"exp":       1893456000,
+		"tenant":    "acme",
+	}, &req)
+
+	auth := ClientAuth{Method: AuthClientSecretBasic, ClientID: "client", ClientSecret: "secret"}
+	resp, err := NewClient(srv.Client()).Introspect(srv.URL, auth, "tok", "access_token")
+	if err != nil {
+		t.Fatalf("Introspect failed: %v", err)
+	}
+
+	if !resp.Active || resp.Sub != "user-1" || resp.Exp != 1893456000 {
+		t.Errorf("unexpected introspection response: %+v", resp)
+	}
+	if resp.Claims["tenant"] != "acme" {
+		t.Errorf("provider-specific members should be kept in Claims, got %v", resp.Claims)
+	}
+	if req.PostForm.Get("token") != "tok" || req.PostForm.Get("token_type_hint") != "access_token" {
+		t.Errorf("unexpected introspection form: %v", req.PostForm)
+	}
+}
+
+// TestIntrospect_MissingActive verifies malformed responses are rejected.
+func TestIntrospect_MissingActive(t *testing.T) {
+	var req http.Request
+	srv := newTokenServer(t, http.StatusOK, map[string]interface{}{"sub": "x"}, &req)
+
+	auth := ClientAuth{Method: AuthNone, ClientID: "client"}
+	if _, err := NewClient(srv.Client()).Introspect(srv.URL, auth, "tok", ""); err == nil {
+		t.Error("expected error for response without 'active'")
+	}
+}
+
+// TestRevoke verifies RFC 7009 requests.
+func TestRevoke(t *testing.T) {
+	var req http.Request
+	srv := newTokenServer(t, http.StatusOK, map[string]interface{}{}, &req)
+
+	auth := ClientAuth{Method: AuthClientSecretPost, ClientID: "client", ClientSecret: "secret"}
+	if err := NewClient(srv.Client()).Revoke(srv.URL, auth, "tok", "refresh_token"); err != nil {
+		t.Fatalf("Revoke failed: %v", err)
+	}
+	if req.Method != http.MethodPost {
+		t.Errorf("method = %s, want POST", req.Method)
+	}
+	if req.PostForm.Get("token") != "tok" || req.PostForm.Get("token_type_hint") != "refresh_token" {
+		t.Errorf("unexpected revocation form: %v", req.PostForm)
+	}
+}
+
+// TestUserInfo verifies bearer token usage and claim parsing.
+func TestUserInfo(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer access" {
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"sub": "user-1", "email": "a@example.com"})
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.Client())
+	claims, err := client.UserInfo(srv.URL, "access")
+	if err != nil {
+		t.Fatalf("UserInfo failed: %v", err)
+	}
+	if claims["email"] != "a@example.com" {
+		t.Errorf("unexpected claims: %v", claims)
+	}
+
+	_, err = client.UserInfo(srv.URL, "wrong")
+	var perr *ProviderError
+	if !errors.As(err, &perr) || perr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 ProviderError, got %v", err)
+	}
+	if perr.Description == "" {
+		t.Error("expected WWW-Authenticate challenge in error description")
+	}
+}
@@ -0,0 +1,175 @@
+package oidc
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultDiscoveryTTL is how long a cached discovery document is trusted
+// before it is fetched again.
+const DefaultDiscoveryTTL = 24 * time.Hour
+
+// Discovery represents the subset of an OpenID Provider configuration document
+// (/.well-known/openid-configuration) used by nightwatch.
+type Discovery struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint,omitempty"`
+	TokenEndpoint                     string   `json:"token_endpoint,omitempty"`
+	UserinfoEndpoint                  string   `json:"userinfo_endpoint,omitempty"`
+	IntrospectionEndpoint             string   `json:"introspection_endpoint,omitempty"`
+	RevocationEndpoint                string   `json:"revocation_endpoint,omitempty"`
+	JWKSURI                           string   `json:"jwks_uri,omitempty"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported,omitempty"`
+	ScopesSupported                   []string `json:"scopes_supported,omitempty"`
+}
+
+// DiscoveryURL returns the well-known configuration URL for an issuer.
+func DiscoveryURL(issuer string) string {
+	return strings.TrimRight(issuer, "/") + "/.well-known/openid-configuration"
+}
+
+// FetchDiscovery downloads and parses the discovery document for an issuer.
+// The issuer in the returned document must match the requested issuer.
+func FetchDiscovery(client *http.Client, issuer string) (*Discovery, error) {
+	if issuer == "" {
+		return nil, fmt.Errorf("issuer is required")
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Get(DiscoveryURL(issuer))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch discovery document: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read discovery document: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("discovery request failed: %s", resp.Status)
+	}
+
+	var doc Discovery
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("invalid discovery document: %w", err)
+	}
+	if strings.TrimRight(doc.Issuer, "/") != strings.TrimRight(issuer, "/") {
+		return nil, fmt.Errorf("issuer mismatch: expected %q, discovery document reports %q", issuer, doc.Issuer)
+	}
+
+	return &doc, nil
+}
+
+// DiscoveryStore caches discovery documents on disk, one file per issuer.
+type DiscoveryStore struct {
+	Dir    string
+	TTL    time.Duration
+	Client *http.Client
+}
+
+// cachedDiscovery is the on-disk representation of a cached document.
+type cachedDiscovery struct {
+	FetchedAt time.Time `json:"fetched_at"`
+	Document  Discovery `json:"document"`
+}
+
+// DefaultDiscoveryDir returns the shared discovery cache directory.
+// It checks NIGHTWATCH_OIDC_CACHE_DIR, otherwise uses <user config dir>/nightwatch/oidc.
+func DefaultDiscoveryDir() (string, error) {
+	if dir := os.Getenv("NIGHTWATCH_OIDC_CACHE_DIR"); dir != "" {
+		return dir, nil
+	}
+
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("unable to determine config directory: %w", err)
+	}
+	return filepath.Join(configDir, "nightwatch", "oidc"), nil
+}
+
+// NewDiscoveryStore creates a store rooted at dir using the default TTL.
+func NewDiscoveryStore(dir string, client *http.Client) *DiscoveryStore {
+	return &DiscoveryStore{Dir: dir, TTL: DefaultDiscoveryTTL, Client: client}
+}
+
+// path returns the cache file path for an issuer.
+func (s *DiscoveryStore) path(issuer string) string {
+	sum := sha256.Sum256([]byte(strings.TrimRight(issuer, "/")))
+	return filepath.Join(s.Dir, hex.EncodeToString(sum[:8])+".json")
+}
+
+// Get returns the discovery document for issuer, using the cache when it is
+// fresh. When refresh is true the cache is bypassed and rewritten.
+func (s *DiscoveryStore) Get(issuer string, refresh bool) (*Discovery, error) {
+	if !refresh {
+		if doc, ok := s.load(issuer); ok {
+			return doc, nil
+		}
+	}
+
+	doc, err := FetchDiscovery(s.Client, issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.save(doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// load reads a cached document, reporting false when it is missing or stale.
+func (s *DiscoveryStore) load(issuer string) (*Discovery, bool) {
+	data, err := os.ReadFile(s.path(issuer))
+	if err != nil {
+		return nil, false
+	}
+
+	var cached cachedDiscovery
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return nil, false
+	}
+	if s.TTL > 0 && time.Since(cached.FetchedAt) > s.TTL {
+		return nil, false
+	}
+	return &cached.Document, true
+}
+
+// save writes a document to the cache with user-only permissions.
+func (s *DiscoveryStore) save(doc *Discovery) error {
+	if err := os.MkdirAll(s.Dir, 0700); err != nil {
+		return fmt.Errorf("failed to create discovery cache directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(cachedDiscovery{
+		FetchedAt: time.Now().UTC(),
+		Document:  *doc,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal discovery document: %w", err)
+	}
+
+	if err := os.WriteFile(s.path(doc.Issuer), data, 0600); err != nil {
+		return fmt.Errorf("failed to write discovery cache: %w", err)
+	}
+	return nil
+}
+
+// Clear removes the cached document for an issuer, if any.
+func (s *DiscoveryStore) Clear(issuer string) error {
+	if err := os.Remove(s.path(issuer)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove discovery cache: %w", err)
+	}
+	return nil
+}
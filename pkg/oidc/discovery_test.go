@@ -0,0 +1,116 @@
+package oidc
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+// newDiscoveryServer serves a discovery document for its own URL and counts requests.
+func newDiscoveryServer(t *testing.T, hits *int) *httptest.Server {
+	t.Helper()
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/.well-known/openid-configuration" {
+			http.NotFound(w, r)
+			return
+		}
+		*hits++
+		_ = json.NewEncoder(w).Encode(Discovery{
+			Issuer:                srv.URL,
+			TokenEndpoint:         srv.URL + "/token",
+			IntrospectionEndpoint: srv.URL + "/introspect",
+			RevocationEndpoint:    srv.URL + "/revoke",
+			UserinfoEndpoint:      srv.URL + "/userinfo",
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// TestFetchDiscovery verifies the well-known document is fetched and parsed.
+func TestFetchDiscovery(t *testing.T) {
+	hits := 0
+	srv := newDiscoveryServer(t, &hits)
+
+	doc, err := FetchDiscovery(srv.Client(), srv.URL+"/")
+	if err != nil {
+		t.Fatalf("FetchDiscovery failed: %v", err)
+	}
+	if doc.TokenEndpoint != srv.URL+"/token" {
+		t.Errorf("TokenEndpoint = %q", doc.TokenEndpoint)
+	}
+}
+
+// TestFetchDiscovery_IssuerMismatch verifies documents for other issuers are rejected.
+func TestFetchDiscovery_IssuerMismatch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(Discovery{Issuer: "https://attacker.example.com"})
+	}))
+	defer srv.Close()
+
+	if _, err := FetchDiscovery(srv.Client(), srv.URL); err == nil {
+		t.Error("expected issuer mismatch error")
+	}
+}
+
+// TestDiscoveryStore_Caching verifies cached documents are reused until refreshed or stale.
+func TestDiscoveryStore_Caching(t *testing.T) {
+	hits := 0
+	srv := newDiscoveryServer(t, &hits)
+	store := NewDiscoveryStore(t.TempDir(), srv.Client())
+
+	for i := 0; i < 3; i++ {
+		if _, err := store.Get(srv.URL, false); err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+	}
+	if hits != 1 {
+		t.Errorf("expected 1 fetch with a warm cache, got %d", hits)
+	}
+
+	if _, err := store.Get(srv.URL, true); err != nil {
+		t.Fatalf("Get with refresh failed: %v", err)
+	}
+	if hits != 2 {
+		t.Errorf("expected refresh to bypass the cache, got %d fetches", hits)
+	}
+
+	store.TTL = time.Nanosecond
+	time.Sleep(time.Millisecond)
+	if _, err := store.Get(srv.URL, false); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if hits != 3 {
+		t.Errorf("expected stale cache to be refetched, got %d fetches", hits)
+	}
+}
+
+// TestDiscoveryStore_FilePermissions verifies cache files are user-only.
+func TestDiscoveryStore_FilePermissions(t *testing.T) {
+	hits := 0
+	srv := newDiscoveryServer(t, &hits)
+	store := NewDiscoveryStore(t.TempDir(), srv.Client())
+
+	if _, err := store.Get(srv.URL, false); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+
+	info, err := os.Stat(store.path(srv.URL))
+	if err != nil {
+		t.Fatalf("cache file missing: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("cache file permissions = %o, want 600", perm)
+	}
+
+	if err := store.Clear(srv.URL); err != nil {
+		t.Fatalf("Clear failed: %v", err)
+	}
+	if _, err := os.Stat(store.path(srv.URL)); !os.IsNotExist(err) {
+		t.Error("expected cache file to be removed")
+	}
+}
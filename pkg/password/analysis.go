@@ -0,0 +1,523 @@
+package password
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// PatternMatch describes a guessable pattern found in a password.
+// Start and End are byte offsets; End is exclusive.
+type PatternMatch struct {
+	Pattern      string  `json:"pattern"`
+	Token        string  `json:"token"`
+	Start        int     `json:"start"`
+	End          int     `json:"end"`
+	GuessesLog10 float64 `json:"guesses_log10"`
+	Detail       string  `json:"detail,omitempty"`
+}
+
+// Pattern names reported in PatternMatch.
+const (
+	PatternDictionary = "dictionary"
+	PatternL33t       = "l33t"
+	PatternKeyboard   = "keyboard"
+	PatternSequence   = "sequence"
+	PatternRepeat     = "repeat"
+	PatternDate       = "date"
+	PatternYear       = "year"
+)
+
+// Keyboard rows and columns used for walk detection.
+var keyboardRows = []string{
+	"1234567890", "qwertyuiop", "asdfghjkl", "zxcvbnm",
+	"1qaz", "2wsx", "3edc", "4rfv", "5tgb", "6yhn", "7ujm", "8ik", "9ol",
+}
+
+// l33t substitutions; '1' is ambiguous and tried as both i and l.
+var l33tTable = map[byte][]byte{
+	'4': {'a'}, '@': {'a'}, '8': {'b'}, '(': {'c'}, '3': {'e'},
+	'6': {'g'}, '1': {'i', 'l'}, '!': {'i'}, '|': {'l'}, '0': {'o'},
+	'$': {'s'}, '5': {'s'}, '7': {'t'}, '+': {'t'}, '2': {'z'},
+}
+
+const (
+	// Average keyboard starting positions and neighbours, as in zxcvbn.
+	keyboardStarts = 47
+	keyboardDegree = 4
+
+	minYearSpace = 20
+	minMatchLen  = 3
+)
+
+// referenceYear anchors date and year guesses; tests may override it.
+var referenceYear = time.Now().Year()
+
+// AnalyzePatterns finds guessable patterns in password and returns the
+// matches on the cheapest decomposition along with log10 of the estimated
+// number of guesses. Characters not covered by a pattern are charged as
+// brute force over the password's own character set.
+func AnalyzePatterns(password string) ([]PatternMatch, float64) {
+	if password == "" {
+		return nil, 0
+	}
+
+	var matches []PatternMatch
+	matches = append(matches, dictionaryMatches(password)...)
+	matches = append(matches, keyboardMatches(password)...)
+	matches = append(matches, sequenceMatches(password)...)
+	matches = append(matches, repeatMatches(password)...)
+	matches = append(matches, dateMatches(password)...)
+
+	bruteforce := math.Log10(float64(max(charsetCardinality(password), 10)))
+
+	// best[i] is the cheapest log10 guesses for password[:i].
+	n := len(password)
+	best := make([]float64, n+1)
+	from := make([]int, n+1)
+	for i := 1; i <= n; i++ {
+		best[i] = best[i-1] + bruteforce
+		from[i] = -1
+	}
+	sort.Slice(matches, func(a, b int) bool { return matches[a].End < matches[b].End })
+	for i := 1; i <= n; i++ {
+		for idx, m := range matches {
+			if m.End != i {
+				continue
+			}
+			if cost := best[m.Start] + m.GuessesLog10; cost < best[i] {
+				best[i] = cost
+				from[i] = idx
+			}
+		}
+	}
+
+	var path []PatternMatch
+	for i := n; i > 0; {
+		if from[i] < 0 {
+			i--
+			continue
+		}
+		m := matches[from[i]]
+		path = append(path, m)
+		i = m.Start
+	}
+	for l, r := 0, len(path)-1; l < r; l, r = l+1, r-1 {
+		path[l], path[r] = path[r], path[l]
+	}
+	return path, best[n]
+}
+
+// GuessesToScore maps log10 guesses to a 0-4 score using zxcvbn's thresholds.
+func GuessesToScore(guessesLog10 float64) int {
+	switch {
+	case guessesLog10 < 3:
+		return 0
+	case guessesLog10 < 6:
+		return 1
+	case guessesLog10 < 8:
+		return 2
+	case guessesLog10 < 10:
+		return 3
+	default:
+		return 4
+	}
+}
+
+// charsetCardinality estimates the alphabet size from the classes present.
+func charsetCardinality(s string) int {
+	var lower, upper, digit, symbol bool
+	for _, r := range s {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+	size := 0
+	if lower {
+		size += 26
+	}
+	if upper {
+		size += 26
+	}
+	if digit {
+		size += 10
+	}
+	if symbol {
+		size += 32
+	}
+	return size
+}
+
+// dictionaryMatches finds common passwords, directly or behind l33t
+// substitutions. Guesses are the word's rank, doubled for capitalisation
+// and for each substituted character.
+func dictionaryMatches(password string) []PatternMatch {
+	lower := asciiLower(password)
+	variants := unl33t(lower)
+
+	var matches []PatternMatch
+	for rank, word := range commonPasswords {
+		for _, v := range variants {
+			for off := 0; off+len(word) <= len(v); {
+				i := strings.Index(v[off:], word)
+				if i < 0 {
+					break
+				}
+				start, end := off+i, off+i+len(word)
+				token := password[start:end]
+				guesses := math.Log10(float64(rank + 1))
+				guesses += math.Log10(float64(caseVariations(token)))
+
+				pattern, detail := PatternDictionary, ""
+				if subs := countSubstitutions(lower[start:end], word); subs > 0 {
+					pattern, detail = PatternL33t, word
+					guesses += float64(subs) * math.Log10(2)
+				}
+				matches = append(matches, PatternMatch{
+					Pattern:      pattern,
+					Token:        token,
+					Start:        start,
+					End:          end,
+					GuessesLog10: guesses,
+					Detail:       detail,
+				})
+				off = start + 1
+			}
+		}
+	}
+	return matches
+}
+
+// unl33t returns s plus each distinct de-l33ted reading of it.
+func unl33t(s string) []string {
+	variants := []string{s}
+	for i := 0; i < len(s); i++ {
+		subs, ok := l33tTable[s[i]]
+		if !ok {
+			continue
+		}
+		var next []string
+		for _, v := range variants {
+			next = append(next, v)
+			for _, sub := range subs {
+				b := []byte(v)
+				b[i] = sub
+				next = append(next, string(b))
+			}
+		}
+		variants = next
+		// Bound the expansion for long passwords full of digits.
+		if len(variants) > 256 {
+			variants = variants[:256]
+		}
+	}
+	return variants
+}
+
+func countSubstitutions(token, word string) int {
+	n := 0
+	for i := 0; i < len(token) && i < len(word); i++ {
+		if token[i] != word[i] {
+			n++
+		}
+	}
+	return n
+}
+
+// caseVariations approximates how many capitalisations of token an attacker
+// tries before reaching it.
+func caseVariations(token string) int {
+	upper := 0
+	for _, r := range token {
+		if unicode.IsUpper(r) {
+			upper++
+		}
+	}
+	switch {
+	case upper == 0:
+		return 1
+	case upper == 1 && unicode.IsUpper(rune(token[0])):
+		return 2
+	case upper == len(token):
+		return 2
+	default:
+		return 1 << min(upper, 16)
+	}
+}
+
+// keyboardMatches finds runs of at least four adjacent keys along a row or
+// column, in either direction.
+func keyboardMatches(password string) []PatternMatch {
+	lower := asciiLower(password)
+	var matches []PatternMatch
+	for _, row := range keyboardRows {
+		for _, line := range []string{row, reverse(row)} {
+			for start := 0; start < len(lower); start++ {
+				end := start
+				for end < len(lower) {
+					i := strings.IndexByte(line, lower[end])
+					if i < 0 || (end > start && (i == 0 || line[i-1] != lower[end-1])) {
+						break
+					}
+					end++
+				}
+				if end-start >= 4 {
+					length := end - start
+					matches = append(matches, PatternMatch{
+						Pattern:      PatternKeyboard,
+						Token:        password[start:end],
+						Start:        start,
+						End:          end,
+						GuessesLog10: math.Log10(float64(keyboardStarts * keyboardDegree * (length - 1) * caseVariations(password[start:end]))),
+					})
+					start = end - 1
+				}
+			}
+		}
+	}
+	return matches
+}
+
+// sequenceMatches finds runs like "abcd", "7654" or "acegi" with a constant
+// step within a single character class.
+func sequenceMatches(password string) []PatternMatch {
+	var matches []PatternMatch
+	for start := 0; start+minMatchLen <= len(password); {
+		delta := int(password[start+1]) - int(password[start])
+		end := start + 1
+		for end < len(password) && int(password[end])-int(password[end-1]) == delta && sameClass(password[start], password[end]) {
+			end++
+		}
+		if delta != 0 && abs(delta) <= 5 && end-start >= minMatchLen {
+			token := password[start:end]
+			base := 26.0
+			switch {
+			case strings.ContainsAny(token[:1], "aAzZ019"):
+				base = 4
+			case unicode.IsDigit(rune(token[0])):
+				base = 10
+			}
+			if delta < 0 {
+				base *= 2
+			}
+			matches = append(matches, PatternMatch{
+				Pattern:      PatternSequence,
+				Token:        token,
+				Start:        start,
+				End:          end,
+				GuessesLog10: math.Log10(base * float64(len(token)) * float64(abs(delta))),
+			})
+			start = end
+			continue
+		}
+		start++
+	}
+	return matches
+}
+
+func sameClass(a, b byte) bool {
+	class := func(c byte) int {
+		switch {
+		case c >= 'a' && c <= 'z':
+			return 1
+		case c >= 'A' && c <= 'Z':
+			return 2
+		case c >= '0' && c <= '9':
+			return 3
+		}
+		return 0
+	}
+	return class(a) != 0 && class(a) == class(b)
+}
+
+// repeatMatches finds repeated characters ("aaa") and repeated units
+// ("abcabc"). Guesses are those of the unit times the repeat count.
+func repeatMatches(password string) []PatternMatch {
+	var matches []PatternMatch
+	for start := 0; start < len(password); start++ {
+		for unit := 1; start+2*unit <= len(password); unit++ {
+			u := password[start : start+unit]
+			end := start + unit
+			for end+unit <= len(password) && password[end:end+unit] == u {
+				end += unit
+			}
+			count := (end - start) / unit
+			if count < 2 || (unit == 1 && count < minMatchLen) {
+				continue
+			}
+			unitGuesses := float64(unit) * math.Log10(float64(max(charsetCardinality(u), 10)))
+			matches = append(matches, PatternMatch{
+				Pattern:      PatternRepeat,
+				Token:        password[start:end],
+				Start:        start,
+				End:          end,
+				GuessesLog10: unitGuesses + math.Log10(float64(count)),
+				Detail:       u,
+			})
+		}
+	}
+	return matches
+}
+
+// dateMatches finds years (1900-2099) and dates with or without separators
+// in day-month-year, month-day-year and year-month-day order.
+func dateMatches(password string) []PatternMatch {
+	var matches []PatternMatch
+	for start := 0; start < len(password); start++ {
+		for end := start + 4; end <= len(password) && end-start <= 10; end++ {
+			token := password[start:end]
+			if year, ok := parseYear(token); ok {
+				matches = append(matches, PatternMatch{
+					Pattern:      PatternYear,
+					Token:        token,
+					Start:        start,
+					End:          end,
+					GuessesLog10: math.Log10(yearSpace(year)),
+				})
+				continue
+			}
+			if year, sep, ok := parseDate(token); ok {
+				guesses := 365 * yearSpace(year)
+				if sep {
+					guesses *= 4
+				}
+				matches = append(matches, PatternMatch{
+					Pattern:      PatternDate,
+					Token:        token,
+					Start:        start,
+					End:          end,
+					GuessesLog10: math.Log10(guesses),
+				})
+			}
+		}
+	}
+	return matches
+}
+
+func yearSpace(year int) float64 {
+	return float64(max(abs(year-referenceYear), minYearSpace))
+}
+
+func parseYear(s string) (int, bool) {
+	if len(s) != 4 || !isDigits(s) {
+		return 0, false
+	}
+	y, _ := strconv.Atoi(s)
+	return y, y >= 1900 && y <= 2099
+}
+
+// parseDate reports whether s is a plausible calendar date, returning its
+// year and whether separators were used.
+func parseDate(s string) (int, bool, bool) {
+	var parts []string
+	sep := false
+	if isDigits(s) {
+		if len(s) < 4 || len(s) > 8 {
+			return 0, false, false
+		}
+		for _, split := range dateSplits[len(s)] {
+			parts = []string{s[:split[0]], s[split[0]:split[1]], s[split[1]:]}
+			if year, ok := validDate(parts); ok {
+				return year, false, true
+			}
+		}
+		return 0, false, false
+	}
+
+	for _, c := range " -/._\\" {
+		if p := strings.Split(s, string(c)); len(p) == 3 {
+			parts, sep = p, true
+			break
+		}
+	}
+	if !sep {
+		return 0, false, false
+	}
+	for _, p := range parts {
+		if p == "" || len(p) > 4 || !isDigits(p) {
+			return 0, false, false
+		}
+	}
+	year, ok := validDate(parts)
+	return year, true, ok
+}
+
+// dateSplits lists the two cut points to try for undelimited dates by length.
+var dateSplits = map[int][][2]int{
+	4: {{1, 2}, {2, 3}},
+	5: {{1, 3}, {2, 3}},
+	6: {{1, 2}, {2, 4}, {4, 5}},
+	7: {{1, 3}, {2, 3}, {4, 5}, {4, 6}},
+	8: {{2, 4}, {4, 6}},
+}
+
+// validDate interprets three numeric parts as a date in any common order.
+func validDate(parts []string) (int, bool) {
+	n := make([]int, 3)
+	for i, p := range parts {
+		n[i], _ = strconv.Atoi(p)
+	}
+	type order struct{ y, m, d int }
+	for _, o := range []order{{2, 1, 0}, {2, 0, 1}, {0, 1, 2}} {
+		year, month, day := n[o.y], n[o.m], n[o.d]
+		if len(parts[o.y]) == 2 {
+			year += 1900
+			if year < 1950 {
+				year += 100
+			}
+		} else if len(parts[o.y]) != 4 {
+			continue
+		}
+		if year < 1900 || year > 2099 || month < 1 || month > 12 || day < 1 || day > 31 {
+			continue
+		}
+		return year, true
+	}
+	return 0, false
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// asciiLower lowercases ASCII letters only, keeping byte offsets aligned
+// with the original string.
+func asciiLower(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if c >= 'A' && c <= 'Z' {
+			b[i] = c + 'a' - 'A'
+		}
+	}
+	return string(b)
+}
+
+func reverse(s string) string {
+	b := []byte(s)
+	for l, r := 0, len(b)-1; l < r; l, r = l+1, r-1 {
+		b[l], b[r] = b[r], b[l]
+	}
+	return string(b)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,199 @@
+package password
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidCorpusLine is returned when a corpus line is not HASH:COUNT.
+var ErrInvalidCorpusLine = errors.New("invalid breach corpus line (expected HASH:COUNT)")
+
+// hashPrefixLen is the k-anonymity prefix length used by the HIBP range API
+// and the per-prefix files written by its downloader.
+const hashPrefixLen = 5
+
+// maxCorpusLine bounds the length of a single HASH:COUNT line.
+const maxCorpusLine = 128
+
+// BreachCorpus is an offline copy of the Pwned Passwords SHA-1 list.
+//
+// Two layouts are supported, both as produced by the HIBP downloader:
+//   - a single file of "HASH:COUNT" lines sorted by hash
+//   - a directory of "<PREFIX>.txt" files holding "SUFFIX:COUNT" lines,
+//     where only the file for the password's 5-character prefix is read
+//
+// Lookups binary-search the sorted lines without loading the file.
+type BreachCorpus struct {
+	path  string
+	isDir bool
+}
+
+// OpenBreachCorpus opens a corpus file or per-prefix directory.
+func OpenBreachCorpus(path string) (*BreachCorpus, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open breach corpus: %w", err)
+	}
+	return &BreachCorpus{path: path, isDir: info.IsDir()}, nil
+}
+
+// Lookup returns how many times password appears in the corpus (0 if absent).
+func (c *BreachCorpus) Lookup(password string) (int, error) {
+	sum := sha1.Sum([]byte(password))
+	return c.LookupHash(hex.EncodeToString(sum[:]))
+}
+
+// LookupHash returns the breach count for a hex SHA-1 hash.
+func (c *BreachCorpus) LookupHash(hash string) (int, error) {
+	hash = strings.ToUpper(strings.TrimSpace(hash))
+	if len(hash) != sha1.Size*2 {
+		return 0, fmt.Errorf("invalid SHA-1 hash: %q", hash)
+	}
+	if _, err := hex.DecodeString(hash); err != nil {
+		return 0, fmt.Errorf("invalid SHA-1 hash: %q", hash)
+	}
+
+	path, key := c.path, hash
+	if c.isDir {
+		path = filepath.Join(c.path, hash[:hashPrefixLen]+".txt")
+		key = hash[hashPrefixLen:]
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		if c.isDir && os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to open breach corpus: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat breach corpus: %w", err)
+	}
+	return searchSortedCorpus(f, info.Size(), key)
+}
+
+// searchSortedCorpus binary-searches r for a line starting with key+":".
+// The invariant is that the matching line, if any, starts in [lo, hi).
+func searchSortedCorpus(r io.ReaderAt, size int64, key string) (int, error) {
+	lo, hi := int64(0), size
+	for hi-lo > 4*maxCorpusLine {
+		mid := lo + (hi-lo)/2
+		start, line, err := lineAfter(r, size, mid)
+		if err != nil {
+			return 0, err
+		}
+		if start >= hi || line == nil {
+			hi = mid
+			continue
+		}
+		lineKey, count, err := parseCorpusLine(line)
+		if err != nil {
+			return 0, err
+		}
+		switch cmp := strings.Compare(lineKey, key); {
+		case cmp == 0:
+			return count, nil
+		case cmp < 0:
+			lo = start + int64(len(line)) + 1
+		default:
+			hi = start
+		}
+	}
+
+	// Scan the remaining window, reading a little past hi so the last line
+	// starting before hi is complete.
+	end := min(hi+maxCorpusLine, size)
+	buf := make([]byte, end-lo)
+	if _, err := r.ReadAt(buf, lo); err != nil && err != io.EOF {
+		return 0, fmt.Errorf("failed to read breach corpus: %w", err)
+	}
+	for off := int64(0); off < int64(len(buf)) && lo+off < hi; {
+		line := buf[off:]
+		next := int64(len(line))
+		if i := bytes.IndexByte(line, '\n'); i >= 0 {
+			line, next = line[:i], int64(i)+1
+		}
+		off += next
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		lineKey, count, err := parseCorpusLine(line)
+		if err != nil {
+			return 0, err
+		}
+		if lineKey == key {
+			return count, nil
+		}
+	}
+	return 0, nil
+}
+
+// lineAfter returns the first complete line starting at or after pos.
+// A nil line means no line starts in [pos, size).
+func lineAfter(r io.ReaderAt, size, pos int64) (int64, []byte, error) {
+	start := pos
+	if pos > 0 {
+		buf := make([]byte, min(int64(maxCorpusLine), size-pos+1))
+		if _, err := r.ReadAt(buf, pos-1); err != nil && err != io.EOF {
+			return 0, nil, fmt.Errorf("failed to read breach corpus: %w", err)
+		}
+		i := bytes.IndexByte(buf, '\n')
+		if i < 0 {
+			if pos-1+int64(len(buf)) >= size {
+				return size, nil, nil
+			}
+			return 0, nil, ErrInvalidCorpusLine
+		}
+		start = pos + int64(i)
+	}
+	if start >= size {
+		return start, nil, nil
+	}
+
+	buf := make([]byte, min(int64(maxCorpusLine), size-start))
+	if _, err := r.ReadAt(buf, start); err != nil && err != io.EOF {
+		return 0, nil, fmt.Errorf("failed to read breach corpus: %w", err)
+	}
+	if i := bytes.IndexByte(buf, '\n'); i >= 0 {
+		buf = buf[:i]
+	}
+	return start, buf, nil
+}
+
+// parseCorpusLine splits "HASH:COUNT" (with optional trailing CR).
+func parseCorpusLine(line []byte) (string, int, error) {
+	key, countStr, ok := strings.Cut(strings.TrimSpace(string(line)), ":")
+	if !ok {
+		return "", 0, ErrInvalidCorpusLine
+	}
+	count, err := strconv.Atoi(countStr)
+	if err != nil {
+		return "", 0, ErrInvalidCorpusLine
+	}
+	return strings.ToUpper(key), count, nil
+}
+
+// CheckBreach looks password up in corpus and records the result on the report.
+func (r *StrengthReport) CheckBreach(corpus *BreachCorpus, password string) error {
+	count, err := corpus.Lookup(password)
+	if err != nil {
+		return err
+	}
+	r.Breached = count > 0
+	r.BreachCount = count
+	if r.Breached {
+		r.Strength = "weak"
+	}
+	return nil
+}
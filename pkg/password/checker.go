@@ -1,6 +1,7 @@
 package password
 
 import (
+	"math"
 	"strings"
 	"unicode"
 )
@@ -13,6 +14,22 @@ type StrengthReport struct {
 	Strength    string   `json:"strength"`
 	HasCommon   bool     `json:"has_common,omitempty"`
 	HasKeyboard bool     `json:"has_keyboard,omitempty"`
+
+	// Patterns, GuessesLog10 and Score come from zxcvbn-style analysis of
+	// dictionary words, l33t, keyboard walks, sequences, repeats and dates.
+	Patterns     []PatternMatch `json:"patterns,omitempty"`
+	GuessesLog10 float64        `json:"guesses_log10"`
+	Score        int            `json:"score"`
+
+	// BreachCount is set by CheckBreach when a corpus is consulted.
+	Breached    bool `json:"breached,omitempty"`
+	BreachCount int  `json:"breach_count,omitempty"`
+}
+
+// EffectiveEntropy returns the lower of the character-set entropy and the
+// pattern-aware guess estimate, in bits.
+func (r *StrengthReport) EffectiveEntropy() float64 {
+	return math.Min(r.Entropy, r.GuessesLog10*math.Log2(10))
 }
 
 // Common weak passwords to check against.
@@ -106,9 +123,17 @@ func CheckPassword(password string) *StrengthReport {
 		}
 	}
 
+	report.Patterns, report.GuessesLog10 = AnalyzePatterns(password)
+	report.Score = GuessesToScore(report.GuessesLog10)
+	for _, m := range report.Patterns {
+		if m.Pattern == PatternKeyboard {
+			report.HasKeyboard = true
+		}
+	}
+
 	// Determine strength rating
 	// Penalize if common pattern detected
-	effectiveEntropy := report.Entropy
+	effectiveEntropy := report.EffectiveEntropy()
 	if report.HasCommon || report.HasKeyboard {
 		// Significantly reduce effective entropy for common patterns
 		effectiveEntropy = effectiveEntropy * 0.3
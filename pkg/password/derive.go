@@ -0,0 +1,244 @@
+package password
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"gopkg.in/yaml.v3"
+)
+
+// DeriveParams are the Argon2id cost parameters for DerivePassword.
+type DeriveParams struct {
+	Time    uint32
+	Memory  uint32 // KiB
+	Threads uint8
+}
+
+// DefaultDeriveParams match the wiki encryption parameters in pkg/crypto.
+// They are pinned here rather than shared: changing them changes every
+// derived password.
+var DefaultDeriveParams = DeriveParams{Time: 3, Memory: 128 * 1024, Threads: 4}
+
+// deriveContext versions the salt layout so the scheme can evolve without
+// silently producing different passwords.
+const deriveContext = "nightwatch-derive-v1"
+
+// DeriveInput identifies one credential.
+type DeriveInput struct {
+	Site    string
+	User    string
+	Counter int
+}
+
+// ErrEmptyMaster is returned when no master passphrase is supplied.
+var ErrEmptyMaster = errors.New("master passphrase cannot be empty")
+
+// NormalizeSite lowercases a site name and strips any URL scheme, path and
+// trailing dot so "https://Example.com/login" and "example.com" match.
+func NormalizeSite(site string) string {
+	site = strings.ToLower(strings.TrimSpace(site))
+	if i := strings.Index(site, "://"); i >= 0 {
+		site = site[i+3:]
+	}
+	if i := strings.IndexAny(site, "/?#"); i >= 0 {
+		site = site[:i]
+	}
+	return strings.TrimSuffix(site, ".")
+}
+
+// DeriveKey stretches the master passphrase into 32 bytes bound to the site,
+// user and counter.
+func DeriveKey(master string, in DeriveInput, params DeriveParams) ([]byte, error) {
+	if master == "" {
+		return nil, ErrEmptyMaster
+	}
+	site := NormalizeSite(in.Site)
+	if site == "" {
+		return nil, errors.New("site cannot be empty")
+	}
+	if in.Counter < 1 {
+		return nil, fmt.Errorf("counter must be at least 1, got %d", in.Counter)
+	}
+
+	var salt []byte
+	salt = append(salt, deriveContext...)
+	salt = append(salt, 0)
+	salt = append(salt, site...)
+	salt = append(salt, 0)
+	salt = append(salt, in.User...)
+	salt = binary.BigEndian.AppendUint32(append(salt, 0), uint32(in.Counter))
+
+	return argon2.IDKey([]byte(master), salt, params.Time, params.Memory, params.Threads, 32), nil
+}
+
+// DerivePassword regenerates the same password for the same master
+// passphrase, input and profile. The derived key seeds a StreamRNG that
+// drives the regular pattern or character-set generator.
+func DerivePassword(master string, in DeriveInput, profile SiteProfile, params DeriveParams) (string, error) {
+	key, err := DeriveKey(master, in, params)
+	if err != nil {
+		return "", err
+	}
+	rng := NewStreamRNG(key)
+
+	if profile.Pattern != "" {
+		tokens, err := ParsePattern(profile.Pattern)
+		if err != nil {
+			return "", err
+		}
+		return GenerateFromPattern(tokens, rng)
+	}
+	return NewGenerator(rng).GeneratePassword(profile.Options())
+}
+
+// StreamRNG is a deterministic decide.RNG backed by HMAC-SHA256 in counter
+// mode. It is only as unpredictable as its key.
+type StreamRNG struct {
+	key     []byte
+	block   uint64
+	buf     []byte
+	scratch [8]byte
+}
+
+// NewStreamRNG returns a StreamRNG keyed by key.
+func NewStreamRNG(key []byte) *StreamRNG {
+	return &StreamRNG{key: append([]byte(nil), key...)}
+}
+
+func (s *StreamRNG) next32() uint32 {
+	if len(s.buf) < 4 {
+		mac := hmac.New(sha256.New, s.key)
+		binary.BigEndian.PutUint64(s.scratch[:], s.block)
+		mac.Write(s.scratch[:])
+		s.buf = mac.Sum(nil)
+		s.block++
+	}
+	v := binary.BigEndian.Uint32(s.buf)
+	s.buf = s.buf[4:]
+	return v
+}
+
+// Intn returns a uniform integer in [0, n) using rejection sampling.
+func (s *StreamRNG) Intn(n int) (int, error) {
+	if n <= 0 {
+		return 0, fmt.Errorf("invalid range: %d", n)
+	}
+	if uint64(n) > 1<<32 {
+		return 0, fmt.Errorf("range too large: %d", n)
+	}
+	limit := uint64(1<<32) - uint64(1<<32)%uint64(n)
+	for {
+		if v := uint64(s.next32()); v < limit {
+			return int(v % uint64(n)), nil
+		}
+	}
+}
+
+// SiteProfile overrides generation rules for one site. Nil character-set
+// fields keep the DefaultPasswordOptions value. When Pattern is set it is
+// used instead of the character-set options.
+type SiteProfile struct {
+	Length         int    `yaml:"length,omitempty" json:"length,omitempty"`
+	Lowercase      *bool  `yaml:"lowercase,omitempty" json:"lowercase,omitempty"`
+	Uppercase      *bool  `yaml:"uppercase,omitempty" json:"uppercase,omitempty"`
+	Digits         *bool  `yaml:"digits,omitempty" json:"digits,omitempty"`
+	Symbols        *bool  `yaml:"symbols,omitempty" json:"symbols,omitempty"`
+	AllowAmbiguous bool   `yaml:"allow_ambiguous,omitempty" json:"allow_ambiguous,omitempty"`
+	Pattern        string `yaml:"pattern,omitempty" json:"pattern,omitempty"`
+	User           string `yaml:"user,omitempty" json:"user,omitempty"`
+	Counter        int    `yaml:"counter,omitempty" json:"counter,omitempty"`
+}
+
+// Options converts the profile into PasswordOptions.
+func (p SiteProfile) Options() PasswordOptions {
+	opts := DefaultPasswordOptions()
+	if p.Length > 0 {
+		opts.Length = p.Length
+	}
+	set := func(dst *bool, v *bool) {
+		if v != nil {
+			*dst = *v
+		}
+	}
+	set(&opts.CharSet.Lowercase, p.Lowercase)
+	set(&opts.CharSet.Uppercase, p.Uppercase)
+	set(&opts.CharSet.Digits, p.Digits)
+	set(&opts.CharSet.Symbols, p.Symbols)
+	opts.CharSet.AllowAmbiguous = p.AllowAmbiguous
+	return opts
+}
+
+// Entropy returns the entropy in bits of passwords generated for the profile.
+func (p SiteProfile) Entropy() (float64, error) {
+	if p.Pattern != "" {
+		tokens, err := ParsePattern(p.Pattern)
+		if err != nil {
+			return 0, err
+		}
+		return CalculatePatternEntropy(tokens), nil
+	}
+	opts := p.Options()
+	size, err := GetCharSetSize(opts.CharSet)
+	if err != nil {
+		return 0, err
+	}
+	return CalculatePasswordEntropy(size, opts.Length), nil
+}
+
+// SiteProfiles maps normalized site names to their profiles.
+type SiteProfiles map[string]SiteProfile
+
+// DefaultSiteProfilesPath returns <user config dir>/nightwatch/sites.yaml.
+func DefaultSiteProfilesPath() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("unable to determine config directory: %w", err)
+	}
+	return filepath.Join(configDir, "nightwatch", "sites.yaml"), nil
+}
+
+// LoadSiteProfiles reads a YAML or JSON profile file. A missing file yields
+// an empty set.
+func LoadSiteProfiles(path string) (SiteProfiles, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return SiteProfiles{}, nil
+		}
+		return nil, fmt.Errorf("failed to read site profiles: %w", err)
+	}
+
+	raw := SiteProfiles{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse site profiles %s: %w", path, err)
+	}
+	profiles := make(SiteProfiles, len(raw))
+	for site, p := range raw {
+		profiles[NormalizeSite(site)] = p
+	}
+	return profiles, nil
+}
+
+// Lookup returns the profile for site, falling back to parent domains so a
+// profile for "example.com" also covers "login.example.com".
+func (sp SiteProfiles) Lookup(site string) (SiteProfile, bool) {
+	site = NormalizeSite(site)
+	for site != "" {
+		if p, ok := sp[site]; ok {
+			return p, true
+		}
+		i := strings.IndexByte(site, '.')
+		if i < 0 {
+			break
+		}
+		site = site[i+1:]
+	}
+	return SiteProfile{}, false
+}
@@ -1,7 +1,12 @@
 package password
 
 import (
+	"crypto/sha1"
+	"fmt"
 	"math"
+	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 	"testing"
 
@@ -659,3 +664,367 @@ func TestGenerateAPIKey(t *testing.T) {
 		})
 	}
 }
+
+func TestAnalyzePatterns(t *testing.T) {
+	referenceYear = 2025
+	tests := []struct {
+		name     string
+		password string
+		want     string
+	}{
+		{"dictionary", "sunshine", PatternDictionary},
+		{"l33t", "p4$$w0rd", PatternL33t},
+		{"keyboard", "zxcvbnm", PatternKeyboard},
+		{"sequence", "lmnopq", PatternSequence},
+		{"descending digits", "98765", PatternSequence},
+		{"repeat", "xxxxxx", PatternRepeat},
+		{"repeated unit", "k9Lk9Lk9L", PatternRepeat},
+		{"separated date", "12/05/1990", PatternDate},
+		{"compact date", "19900512", PatternDate},
+		{"year", "1987", PatternYear},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches, guesses := AnalyzePatterns(tt.password)
+			found := false
+			for _, m := range matches {
+				if m.Pattern == tt.want {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("AnalyzePatterns(%q) = %+v, want a %s match", tt.password, matches, tt.want)
+			}
+			bruteforce := float64(len(tt.password)) * math.Log10(float64(max(charsetCardinality(tt.password), 10)))
+			if guesses >= bruteforce {
+				t.Errorf("guesses_log10 = %.2f, want below brute force %.2f", guesses, bruteforce)
+			}
+		})
+	}
+}
+
+func TestAnalyzePatternsRandom(t *testing.T) {
+	matches, guesses := AnalyzePatterns("Xk9#mP2@qR5!tY7")
+	if len(matches) != 0 {
+		t.Errorf("unexpected matches in random password: %+v", matches)
+	}
+	if score := GuessesToScore(guesses); score != 4 {
+		t.Errorf("score = %d, want 4", score)
+	}
+}
+
+func TestCheckPasswordPatterns(t *testing.T) {
+	plain := CheckPassword("P@ssw0rd1990")
+	if plain.Score > 2 {
+		t.Errorf("score = %d, want at most 2 for l33t word plus year", plain.Score)
+	}
+	if plain.Strength != "weak" && plain.Strength != "fair" {
+		t.Errorf("strength = %q, want pattern penalty to apply", plain.Strength)
+	}
+	if plain.EffectiveEntropy() >= plain.Entropy {
+		t.Errorf("effective entropy %.1f should be below charset entropy %.1f", plain.EffectiveEntropy(), plain.Entropy)
+	}
+}
+
+func TestPolicyCheck(t *testing.T) {
+	p := &Policy{
+		MinLength:        10,
+		RequireUppercase: true,
+		RequireSymbols:   true,
+		MaxRepeat:        2,
+		BannedWords:      []string{"acme"},
+		MinEntropy:       40,
+	}
+
+	tests := []struct {
+		password string
+		want     []string
+	}{
+		{"Gx7!qP2m#Zr9", nil},
+		{"short", []string{"shorter than", "missing uppercase", "missing symbols", "entropy"}},
+		{"Acm3!Gx7qPzz", []string{"banned word"}},
+		{"Gx7!qP2mmmZr9", []string{"repeats"}},
+	}
+
+	for _, tt := range tests {
+		got := p.Check(tt.password)
+		if len(tt.want) == 0 && len(got) > 0 {
+			t.Errorf("Check(%q) = %v, want no violations", tt.password, got)
+		}
+		for _, w := range tt.want {
+			if !strings.Contains(strings.Join(got, "; "), w) {
+				t.Errorf("Check(%q) = %v, want violation containing %q", tt.password, got, w)
+			}
+		}
+	}
+}
+
+func TestPolicyValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  Policy
+		wantErr bool
+	}{
+		{"empty", Policy{}, false},
+		{"min above max", Policy{MinLength: 20, MaxLength: 10}, true},
+		{"too many classes", Policy{MinClasses: 5}, true},
+		{"max too short for classes", Policy{MaxLength: 2, MinClasses: 3}, true},
+		{"negative", Policy{MaxRepeat: -1}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.policy.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestGenerateWithPolicy(t *testing.T) {
+	seed := int64(7)
+	gen := NewGenerator(decide.NewRNG(&seed))
+	p := &Policy{MinLength: 20, RequireSymbols: true, MinClasses: 4, MaxRepeat: 2, MinEntropy: 80}
+
+	opts := DefaultPasswordOptions()
+	opts.Length = 8
+	for i := 0; i < 20; i++ {
+		pwd, err := gen.GenerateWithPolicy(opts, p)
+		if err != nil {
+			t.Fatalf("GenerateWithPolicy() error = %v", err)
+		}
+		if v := p.Check(pwd); len(v) > 0 {
+			t.Errorf("generated %q violates policy: %v", pwd, v)
+		}
+	}
+
+	impossible := &Policy{MaxLength: 6, MinEntropy: 200}
+	if _, err := gen.GenerateWithPolicy(opts, impossible); err != ErrPolicyUnsatisfiable {
+		t.Errorf("expected ErrPolicyUnsatisfiable, got %v", err)
+	}
+}
+
+func TestLoadPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	data := "min_length: 14\nrequire_digits: true\nbanned_words: [acme, winter]\n"
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := LoadPolicy(path)
+	if err != nil {
+		t.Fatalf("LoadPolicy() error = %v", err)
+	}
+	if p.MinLength != 14 || !p.RequireDigits || len(p.BannedWords) != 2 {
+		t.Errorf("unexpected policy: %+v", p)
+	}
+
+	jsonPath := filepath.Join(t.TempDir(), "policy.json")
+	if err := os.WriteFile(jsonPath, []byte(`{"min_length": 30, "max_length": 10}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadPolicy(jsonPath); err == nil {
+		t.Error("expected validation error for min_length > max_length")
+	}
+}
+
+func writeCorpus(t *testing.T, passwords map[string]int, extra int) string {
+	t.Helper()
+	var lines []string
+	for pwd, count := range passwords {
+		sum := sha1.Sum([]byte(pwd))
+		lines = append(lines, fmt.Sprintf("%X:%d", sum, count))
+	}
+	// Pad with synthetic hashes so the binary search has work to do.
+	for i := 0; i < extra; i++ {
+		sum := sha1.Sum([]byte(fmt.Sprintf("filler-%d", i)))
+		lines = append(lines, fmt.Sprintf("%X:%d", sum, i+1))
+	}
+	sort.Strings(lines)
+
+	path := filepath.Join(t.TempDir(), "pwned.txt")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\r\n")+"\r\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestBreachCorpusFile(t *testing.T) {
+	known := map[string]int{"password": 9545824, "hunter2": 17043, "correct horse": 3}
+	corpus, err := OpenBreachCorpus(writeCorpus(t, known, 5000))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for pwd, want := range known {
+		got, err := corpus.Lookup(pwd)
+		if err != nil {
+			t.Fatalf("Lookup(%q) error = %v", pwd, err)
+		}
+		if got != want {
+			t.Errorf("Lookup(%q) = %d, want %d", pwd, got, want)
+		}
+	}
+
+	for i := 0; i < 5000; i += 397 {
+		got, err := corpus.Lookup(fmt.Sprintf("filler-%d", i))
+		if err != nil || got != i+1 {
+			t.Errorf("Lookup(filler-%d) = %d, %v; want %d", i, got, err, i+1)
+		}
+	}
+
+	if got, err := corpus.Lookup("not in the corpus"); err != nil || got != 0 {
+		t.Errorf("Lookup(absent) = %d, %v; want 0", got, err)
+	}
+	if _, err := corpus.LookupHash("xyz"); err == nil {
+		t.Error("expected error for malformed hash")
+	}
+}
+
+func TestBreachCorpusDir(t *testing.T) {
+	dir := t.TempDir()
+	sum := fmt.Sprintf("%X", sha1.Sum([]byte("letmein")))
+	content := "0000000000000000000000000000000000A:1\n" + sum[5:] + ":42\nFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF:7\n"
+	if err := os.WriteFile(filepath.Join(dir, sum[:5]+".txt"), []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	corpus, err := OpenBreachCorpus(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := corpus.Lookup("letmein"); err != nil || got != 42 {
+		t.Errorf("Lookup(letmein) = %d, %v; want 42", got, err)
+	}
+	if got, err := corpus.Lookup("missing prefix file"); err != nil || got != 0 {
+		t.Errorf("Lookup(absent) = %d, %v; want 0", got, err)
+	}
+
+	report := CheckPassword("letmein")
+	if err := report.CheckBreach(corpus, "letmein"); err != nil {
+		t.Fatal(err)
+	}
+	if !report.Breached || report.BreachCount != 42 || report.Strength != "weak" {
+		t.Errorf("unexpected breach report: %+v", report)
+	}
+}
+
+var testDeriveParams = DeriveParams{Time: 1, Memory: 1024, Threads: 1}
+
+func TestDerivePassword(t *testing.T) {
+	in := DeriveInput{Site: "example.com", User: "alice", Counter: 1}
+
+	first, err := DerivePassword("master", in, SiteProfile{}, testDeriveParams)
+	if err != nil {
+		t.Fatalf("DerivePassword() error = %v", err)
+	}
+	again, _ := DerivePassword("master", DeriveInput{Site: "https://EXAMPLE.com/login", User: "alice", Counter: 1}, SiteProfile{}, testDeriveParams)
+	if first != again {
+		t.Errorf("derivation not deterministic: %q vs %q", first, again)
+	}
+	if len(first) != DefaultPasswordOptions().Length {
+		t.Errorf("length = %d, want default %d", len(first), DefaultPasswordOptions().Length)
+	}
+
+	variants := []struct {
+		name   string
+		master string
+		in     DeriveInput
+	}{
+		{"master", "other", in},
+		{"site", "master", DeriveInput{Site: "example.org", User: "alice", Counter: 1}},
+		{"user", "master", DeriveInput{Site: "example.com", User: "bob", Counter: 1}},
+		{"counter", "master", DeriveInput{Site: "example.com", User: "alice", Counter: 2}},
+	}
+	for _, v := range variants {
+		got, err := DerivePassword(v.master, v.in, SiteProfile{}, testDeriveParams)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got == first {
+			t.Errorf("changing %s did not change the password", v.name)
+		}
+	}
+
+	if _, err := DerivePassword("", in, SiteProfile{}, testDeriveParams); err != ErrEmptyMaster {
+		t.Errorf("expected ErrEmptyMaster, got %v", err)
+	}
+	if _, err := DerivePassword("master", DeriveInput{Site: "x", Counter: 0}, SiteProfile{}, testDeriveParams); err == nil {
+		t.Error("expected error for counter 0")
+	}
+}
+
+func TestDerivePasswordProfile(t *testing.T) {
+	in := DeriveInput{Site: "bank.test", User: "alice", Counter: 1}
+	no := false
+	yes := true
+
+	pwd, err := DerivePassword("master", in, SiteProfile{Length: 8, Lowercase: &no, Uppercase: &no, Digits: &yes}, testDeriveParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pwd) != 8 || strings.Trim(pwd, "0123456789") != "" {
+		t.Errorf("PIN profile produced %q", pwd)
+	}
+
+	pwd, err = DerivePassword("master", in, SiteProfile{Pattern: "XXXX-9999-xxxx"}, testDeriveParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pwd) != 14 || pwd[4] != '-' || pwd[9] != '-' {
+		t.Errorf("pattern profile produced %q", pwd)
+	}
+}
+
+func TestStreamRNG(t *testing.T) {
+	a, b := NewStreamRNG([]byte("key")), NewStreamRNG([]byte("key"))
+	counts := make([]int, 7)
+	for i := 0; i < 7000; i++ {
+		x, err := a.Intn(7)
+		if err != nil {
+			t.Fatal(err)
+		}
+		y, _ := b.Intn(7)
+		if x != y {
+			t.Fatal("StreamRNG is not deterministic")
+		}
+		counts[x]++
+	}
+	for v, c := range counts {
+		if c < 800 || c > 1200 {
+			t.Errorf("value %d drawn %d times, expected ~1000", v, c)
+		}
+	}
+	if _, err := a.Intn(0); err == nil {
+		t.Error("expected error for n=0")
+	}
+}
+
+func TestLoadSiteProfiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sites.yaml")
+	data := "Example.com:\n  length: 24\n  symbols: true\nbank.test:\n  pattern: \"9999\"\n  counter: 3\n"
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	profiles, err := LoadSiteProfiles(path)
+	if err != nil {
+		t.Fatalf("LoadSiteProfiles() error = %v", err)
+	}
+	p, ok := profiles.Lookup("https://login.example.com/")
+	if !ok || p.Length != 24 || p.Symbols == nil || !*p.Symbols {
+		t.Errorf("subdomain lookup = %+v, %v", p, ok)
+	}
+	if !p.Options().CharSet.Symbols || p.Options().Length != 24 {
+		t.Errorf("unexpected options: %+v", p.Options())
+	}
+	if p, ok := profiles.Lookup("bank.test"); !ok || p.Counter != 3 {
+		t.Errorf("bank.test lookup = %+v, %v", p, ok)
+	}
+	if _, ok := profiles.Lookup("other.test"); ok {
+		t.Error("unexpected profile for other.test")
+	}
+
+	missing, err := LoadSiteProfiles(filepath.Join(t.TempDir(), "none.yaml"))
+	if err != nil || len(missing) != 0 {
+		t.Errorf("missing file = %v, %v; want empty", missing, err)
+	}
+}
@@ -0,0 +1,217 @@
+package password
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Policy describes the rules a password must satisfy. Zero values disable a rule.
+// Policy files are YAML or JSON using the field tags below.
+type Policy struct {
+	MinLength        int      `yaml:"min_length" json:"min_length,omitempty"`
+	MaxLength        int      `yaml:"max_length" json:"max_length,omitempty"`
+	RequireLowercase bool     `yaml:"require_lowercase" json:"require_lowercase,omitempty"`
+	RequireUppercase bool     `yaml:"require_uppercase" json:"require_uppercase,omitempty"`
+	RequireDigits    bool     `yaml:"require_digits" json:"require_digits,omitempty"`
+	RequireSymbols   bool     `yaml:"require_symbols" json:"require_symbols,omitempty"`
+	MinClasses       int      `yaml:"min_classes" json:"min_classes,omitempty"`
+	MaxRepeat        int      `yaml:"max_repeat" json:"max_repeat,omitempty"` // longest run of one character
+	BannedWords      []string `yaml:"banned_words" json:"banned_words,omitempty"`
+	MinEntropy       float64  `yaml:"min_entropy" json:"min_entropy,omitempty"` // bits, after pattern analysis
+}
+
+// maxPolicyAttempts bounds regeneration when a random password misses the policy.
+const maxPolicyAttempts = 1000
+
+// ErrPolicyUnsatisfiable is returned when generation cannot meet a policy.
+var ErrPolicyUnsatisfiable = errors.New("could not generate a password satisfying the policy; relax the policy or increase length")
+
+// LoadPolicy reads a YAML or JSON policy file.
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy: %w", err)
+	}
+
+	var p Policy
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse policy %s: %w", path, err)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid policy %s: %w", path, err)
+	}
+	return &p, nil
+}
+
+// Validate checks that the policy is internally consistent.
+func (p *Policy) Validate() error {
+	if p.MinLength < 0 || p.MaxLength < 0 || p.MinClasses < 0 || p.MaxRepeat < 0 || p.MinEntropy < 0 {
+		return errors.New("policy values cannot be negative")
+	}
+	if p.MaxLength > 0 && p.MinLength > p.MaxLength {
+		return fmt.Errorf("min_length %d exceeds max_length %d", p.MinLength, p.MaxLength)
+	}
+	if p.MinClasses > 4 {
+		return fmt.Errorf("min_classes %d exceeds the 4 available classes", p.MinClasses)
+	}
+	if required := p.requiredClasses(); p.MaxLength > 0 && required > p.MaxLength {
+		return fmt.Errorf("max_length %d is too short for %d required classes", p.MaxLength, required)
+	}
+	return nil
+}
+
+func (p *Policy) requiredClasses() int {
+	n := 0
+	for _, b := range []bool{p.RequireLowercase, p.RequireUppercase, p.RequireDigits, p.RequireSymbols} {
+		if b {
+			n++
+		}
+	}
+	return max(n, p.MinClasses)
+}
+
+// Check returns a description of each rule the password violates.
+func (p *Policy) Check(password string) []string {
+	var violations []string
+	length := len([]rune(password))
+
+	if p.MinLength > 0 && length < p.MinLength {
+		violations = append(violations, fmt.Sprintf("shorter than %d characters", p.MinLength))
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		violations = append(violations, fmt.Sprintf("longer than %d characters", p.MaxLength))
+	}
+
+	report := CheckPassword(password)
+	has := make(map[string]bool, len(report.CharSets))
+	for _, cs := range report.CharSets {
+		has[cs] = true
+	}
+	for _, req := range []struct {
+		required bool
+		set      string
+	}{
+		{p.RequireLowercase, Lowercase.Name},
+		{p.RequireUppercase, Uppercase.Name},
+		{p.RequireDigits, Digits.Name},
+		{p.RequireSymbols, Symbols.Name},
+	} {
+		if req.required && !has[req.set] {
+			violations = append(violations, "missing "+req.set)
+		}
+	}
+	if p.MinClasses > 0 && len(report.CharSets) < p.MinClasses {
+		violations = append(violations, fmt.Sprintf("uses %d character classes, need %d", len(report.CharSets), p.MinClasses))
+	}
+
+	if p.MaxRepeat > 0 {
+		if run := longestRun(password); run > p.MaxRepeat {
+			violations = append(violations, fmt.Sprintf("repeats a character %d times in a row (max %d)", run, p.MaxRepeat))
+		}
+	}
+
+	if len(p.BannedWords) > 0 {
+		variants := unl33t(asciiLower(password))
+		for _, word := range p.BannedWords {
+			word = strings.ToLower(strings.TrimSpace(word))
+			if word == "" {
+				continue
+			}
+			for _, v := range variants {
+				if strings.Contains(v, word) {
+					violations = append(violations, fmt.Sprintf("contains banned word %q", word))
+					break
+				}
+			}
+		}
+	}
+
+	if p.MinEntropy > 0 {
+		if bits := report.EffectiveEntropy(); bits < p.MinEntropy {
+			violations = append(violations, fmt.Sprintf("entropy %.1f bits is below %.1f", bits, p.MinEntropy))
+		}
+	}
+
+	return violations
+}
+
+// Apply adjusts generation options so generated passwords can satisfy the
+// policy: the length is clamped and required character sets are enabled.
+func (p *Policy) Apply(opts PasswordOptions) PasswordOptions {
+	if p.MinLength > 0 && opts.Length < p.MinLength {
+		opts.Length = p.MinLength
+	}
+	if p.MaxLength > 0 && opts.Length > p.MaxLength {
+		opts.Length = p.MaxLength
+	}
+
+	if p.RequireLowercase {
+		opts.CharSet.Lowercase = true
+	}
+	if p.RequireUppercase {
+		opts.CharSet.Uppercase = true
+	}
+	if p.RequireDigits {
+		opts.CharSet.Digits = true
+	}
+	if p.RequireSymbols {
+		opts.CharSet.Symbols = true
+	}
+
+	enabled := 0
+	for _, b := range []bool{opts.CharSet.Lowercase, opts.CharSet.Uppercase, opts.CharSet.Digits, opts.CharSet.Symbols} {
+		if b {
+			enabled++
+		}
+	}
+	for _, set := range []*bool{&opts.CharSet.Lowercase, &opts.CharSet.Uppercase, &opts.CharSet.Digits, &opts.CharSet.Symbols} {
+		if enabled >= p.MinClasses {
+			break
+		}
+		if !*set {
+			*set = true
+			enabled++
+		}
+	}
+
+	if p.requiredClasses() > 0 {
+		opts.RequireAll = true
+	}
+	return opts
+}
+
+// GenerateWithPolicy generates passwords with opts adjusted by the policy,
+// retrying until one passes every rule.
+func (g *Generator) GenerateWithPolicy(opts PasswordOptions, p *Policy) (string, error) {
+	opts = p.Apply(opts)
+	for i := 0; i < maxPolicyAttempts; i++ {
+		pwd, err := g.GeneratePassword(opts)
+		if err != nil {
+			return "", err
+		}
+		if len(p.Check(pwd)) == 0 {
+			return pwd, nil
+		}
+	}
+	return "", ErrPolicyUnsatisfiable
+}
+
+// longestRun returns the length of the longest run of one repeated character.
+func longestRun(s string) int {
+	best, run := 0, 0
+	var prev rune
+	for i, r := range []rune(s) {
+		if i > 0 && r == prev {
+			run++
+		} else {
+			run = 1
+		}
+		prev = r
+		best = max(best, run)
+	}
+	return best
+}
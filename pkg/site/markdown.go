@@ -0,0 +1,408 @@
+package site
+
+import (
+	"fmt"
+	"html"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gitlab.com/caffeinatedjack/sleepless/pkg/markdown"
+)
+
+var (
+	headingRe   = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*$`)
+	hrRe        = regexp.MustCompile(`^(?:-{3,}|\*{3,}|_{3,})$`)
+	listItemRe  = regexp.MustCompile(`^(\s*)([-*+]|\d+[.)])\s+(.*)$`)
+	tableSepRe  = regexp.MustCompile(`^\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?$`)
+	codeSpanRe  = regexp.MustCompile("`[^`]+`")
+	wikiLinkRe  = regexp.MustCompile(`(!?)\[\[([^\[\]|#]*)(#[^\[\]|]*)?(?:\|([^\[\]]*))?\]\]`)
+	imageRe     = regexp.MustCompile(`!\[([^\[\]]*)\]\((<[^<>]+>|[^()\s]+)(?:\s+"[^"]*")?\)`)
+	mdLinkRe    = regexp.MustCompile(`\[([^\[\]]+)\]\((<[^<>]+>|[^()\s]+)(?:\s+"[^"]*")?\)`)
+	urlRe       = regexp.MustCompile(`https?://[^\s<>()\[\]]*[^\s<>()\[\].,;:!?'"]`)
+	refRe       = regexp.MustCompile(`@note:([a-f0-9]{6,8}|\d{4}-\d{2}-\d{2})\b|@task:([a-f0-9]+)`)
+	tagRe       = regexp.MustCompile(`(^|\s)(#\S+)`)
+	boldRe      = regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__`)
+	italicRe    = regexp.MustCompile(`\*([^*\s][^*]*)\*|(?:^|\s)_([^_]+)_(?:$|\s)`)
+	strikeRe    = regexp.MustCompile(`~~([^~]+)~~`)
+	schemeRe    = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*:`)
+	imageExtRe  = regexp.MustCompile(`(?i)\.(png|jpe?g|gif|webp|svg|avif|bmp)$`)
+	tokenRe     = regexp.MustCompile("\x00(\\d+)\x00")
+	nonAnchorRe = regexp.MustCompile(`[^\p{L}\p{N}]+`)
+)
+
+// renderer converts a page's markdown to HTML. It covers what notes, goals
+// and recipes use: headings, paragraphs, nested and task lists, quotes,
+// fenced code, tables, emphasis, links, images, #tags and @note/@task
+// references.
+type renderer struct {
+	// link returns the href for a link target, or false if it names a wiki
+	// page that is not in the site. wiki is set for [[wiki links]].
+	link func(target string, wiki bool) (string, bool)
+	// tag returns the href of a tag's page.
+	tag func(tag string) string
+	// task returns the href of a goal by ID prefix, or false.
+	task func(id string) (string, bool)
+
+	ids map[string]int
+}
+
+// render converts a markdown document.
+func (r *renderer) render(src string) string {
+	var b strings.Builder
+	r.blocks(&b, strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n"))
+	return b.String()
+}
+
+func (r *renderer) blocks(b *strings.Builder, lines []string) {
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			i++
+
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			fence, lang := trimmed[:3], strings.TrimSpace(trimmed[3:])
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), fence); i++ {
+				code = append(code, lines[i])
+			}
+			i++
+			b.WriteString("<pre><code")
+			if lang != "" {
+				fmt.Fprintf(b, ` class="language-%s"`, html.EscapeString(lang))
+			}
+			b.WriteString(">" + html.EscapeString(strings.Join(code, "\n")) + "</code></pre>\n")
+
+		case headingRe.MatchString(trimmed):
+			m := headingRe.FindStringSubmatch(trimmed)
+			level := len(m[1])
+			fmt.Fprintf(b, "<h%d id=\"%s\">%s</h%d>\n", level, r.anchor(m[2]), r.inline(m[2]), level)
+			i++
+
+		case hrRe.MatchString(trimmed):
+			b.WriteString("<hr>\n")
+			i++
+
+		case strings.HasPrefix(trimmed, ">"):
+			var quote []string
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">"); i++ {
+				q := strings.TrimPrefix(strings.TrimSpace(lines[i]), ">")
+				quote = append(quote, strings.TrimPrefix(q, " "))
+			}
+			b.WriteString("<blockquote>\n")
+			r.blocks(b, quote)
+			b.WriteString("</blockquote>\n")
+
+		case listItemRe.MatchString(line):
+			end := listEnd(lines, i)
+			r.list(b, lines[i:end])
+			i = end
+
+		case strings.HasPrefix(trimmed, "|") && i+1 < len(lines) && tableSepRe.MatchString(strings.TrimSpace(lines[i+1])):
+			end := i + 2
+			for end < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[end]), "|") {
+				end++
+			}
+			r.table(b, lines[i], lines[i+2:end])
+			i = end
+
+		default:
+			var para []string
+			for ; i < len(lines) && !startsBlock(lines[i]); i++ {
+				para = append(para, strings.TrimSpace(lines[i]))
+			}
+			if len(para) == 0 {
+				// A line that only looked like the start of a block.
+				para, i = []string{trimmed}, i+1
+			}
+			b.WriteString("<p>" + r.inline(strings.Join(para, "\n")) + "</p>\n")
+		}
+	}
+}
+
+// startsBlock reports whether line ends a paragraph.
+func startsBlock(line string) bool {
+	t := strings.TrimSpace(line)
+	return t == "" || strings.HasPrefix(t, "```") || strings.HasPrefix(t, "~~~") || strings.HasPrefix(t, ">") ||
+		headingRe.MatchString(t) || hrRe.MatchString(t) || listItemRe.MatchString(line)
+}
+
+func indentOf(line string) int {
+	return len(strings.ReplaceAll(line, "\t", "    ")) - len(strings.TrimLeft(strings.ReplaceAll(line, "\t", "    "), " "))
+}
+
+// listEnd returns the index after the list starting at lines[start]: the
+// list runs on through indented lines and blank lines followed by more of it.
+func listEnd(lines []string, start int) int {
+	base := indentOf(lines[start])
+	i := start + 1
+	for i < len(lines) {
+		line := lines[i]
+		if strings.TrimSpace(line) == "" {
+			j := i + 1
+			for j < len(lines) && strings.TrimSpace(lines[j]) == "" {
+				j++
+			}
+			if j < len(lines) && (indentOf(lines[j]) > base || listItemRe.MatchString(lines[j]) && indentOf(lines[j]) == base) {
+				i = j
+				continue
+			}
+			return i
+		}
+		if indentOf(line) <= base && !listItemRe.MatchString(line) {
+			return i
+		}
+		i++
+	}
+	return i
+}
+
+// list renders a list whose first line is an item; more indented lines
+// belong to the item above them.
+func (r *renderer) list(b *strings.Builder, lines []string) {
+	base := indentOf(lines[0])
+	tag := "ul"
+	if m := listItemRe.FindStringSubmatch(lines[0]); m[2] != "-" && m[2] != "*" && m[2] != "+" {
+		tag = "ol"
+		if n, err := strconv.Atoi(strings.TrimRight(m[2], ".)")); err == nil && n != 1 {
+			tag = fmt.Sprintf(`ol start="%d"`, n)
+		}
+	}
+	b.WriteString("<" + tag + ">\n")
+
+	type item struct {
+		text string
+		rest []string
+	}
+	var items []item
+	for _, line := range lines {
+		if m := listItemRe.FindStringSubmatch(line); m != nil && indentOf(line) <= base+1 {
+			items = append(items, item{text: m[3]})
+			continue
+		}
+		if len(items) > 0 {
+			last := &items[len(items)-1]
+			last.rest = append(last.rest, line)
+		}
+	}
+
+	for _, it := range items {
+		text, class := it.text, ""
+		switch {
+		case strings.HasPrefix(text, "[ ] "):
+			text, class = `<input type="checkbox" disabled> `+r.inline(text[4:]), ` class="task"`
+		case strings.HasPrefix(text, "[x] "), strings.HasPrefix(text, "[X] "):
+			text, class = `<input type="checkbox" disabled checked> `+r.inline(text[4:]), ` class="task done"`
+		default:
+			text = r.inline(text)
+		}
+		b.WriteString("<li" + class + ">" + text)
+		if len(it.rest) > 0 {
+			b.WriteString("\n")
+			r.blocks(b, dedent(it.rest))
+		}
+		b.WriteString("</li>\n")
+	}
+	b.WriteString("</" + strings.Fields(tag)[0] + ">\n")
+}
+
+// dedent removes the indentation the lines share.
+func dedent(lines []string) []string {
+	min := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) != "" && (min < 0 || indentOf(line) < min) {
+			min = indentOf(line)
+		}
+	}
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		line = strings.ReplaceAll(line, "\t", "    ")
+		if len(line) >= min && min > 0 {
+			line = line[min:]
+		}
+		out[i] = line
+	}
+	return out
+}
+
+func (r *renderer) table(b *strings.Builder, header string, rows []string) {
+	b.WriteString("<table>\n<thead><tr>")
+	for _, cell := range tableCells(header) {
+		b.WriteString("<th>" + r.inline(cell) + "</th>")
+	}
+	b.WriteString("</tr></thead>\n<tbody>\n")
+	for _, row := range rows {
+		b.WriteString("<tr>")
+		for _, cell := range tableCells(row) {
+			b.WriteString("<td>" + r.inline(cell) + "</td>")
+		}
+		b.WriteString("</tr>\n")
+	}
+	b.WriteString("</tbody>\n</table>\n")
+}
+
+func tableCells(row string) []string {
+	row = strings.TrimSpace(row)
+	row = strings.TrimSuffix(strings.TrimPrefix(row, "|"), "|")
+	cells := strings.Split(row, "|")
+	for i := range cells {
+		cells[i] = strings.TrimSpace(cells[i])
+	}
+	return cells
+}
+
+// anchor returns a unique id for a heading.
+func (r *renderer) anchor(text string) string {
+	id := anchorID(text)
+	if r.ids == nil {
+		r.ids = make(map[string]int)
+	}
+	r.ids[id]++
+	if n := r.ids[id]; n > 1 {
+		id = fmt.Sprintf("%s-%d", id, n)
+	}
+	return id
+}
+
+// anchorID turns heading text into an id, as links to headings use it.
+func anchorID(text string) string {
+	id := strings.Trim(nonAnchorRe.ReplaceAllString(strings.ToLower(text), "-"), "-")
+	if id == "" {
+		return "section"
+	}
+	return id
+}
+
+// inline converts the markdown inside a block. Code, links and references
+// are set aside as tokens first, so that escaping and emphasis only touch
+// plain text. NUL marks the tokens, so any in the text are replaced first.
+func (r *renderer) inline(s string) string {
+	s = strings.ReplaceAll(s, "\x00", "\uFFFD")
+	var tokens []string
+	untoken := func(s string) string {
+		return tokenRe.ReplaceAllStringFunc(s, func(m string) string {
+			if n, err := strconv.Atoi(strings.Trim(m, "\x00")); err == nil && n < len(tokens) {
+				return tokens[n]
+			}
+			return ""
+		})
+	}
+	keep := func(h string) string {
+		// A link's text may hold code set aside earlier; it is put back
+		// now so that one pass at the end restores every token.
+		tokens = append(tokens, untoken(h))
+		return fmt.Sprintf("\x00%d\x00", len(tokens)-1)
+	}
+
+	s = codeSpanRe.ReplaceAllStringFunc(s, func(m string) string {
+		return keep("<code>" + html.EscapeString(strings.Trim(m, "`")) + "</code>")
+	})
+	s = wikiLinkRe.ReplaceAllStringFunc(s, func(m string) string {
+		g := wikiLinkRe.FindStringSubmatch(m)
+		embed, target, anchor, text := g[1] == "!", strings.TrimSpace(g[2]), g[3], g[4]
+		if text == "" {
+			text = strings.TrimSpace(target + anchor)
+		}
+		if embed && imageExtRe.MatchString(target) {
+			if href, ok := r.link(target, true); ok {
+				return keep(fmt.Sprintf(`<img src="%s" alt="%s">`, attr(href), attr(text)))
+			}
+		}
+		if href, ok := r.link(target+anchor, true); ok {
+			return keep(fmt.Sprintf(`<a href="%s">%s</a>`, attr(href), r.text(text)))
+		}
+		return keep(fmt.Sprintf(`<span class="missing" title="%s">%s</span>`, attr(target), r.text(text)))
+	})
+	s = imageRe.ReplaceAllStringFunc(s, func(m string) string {
+		g := imageRe.FindStringSubmatch(m)
+		if href, ok := r.link(strings.Trim(g[2], "<>"), false); ok {
+			return keep(fmt.Sprintf(`<img src="%s" alt="%s">`, attr(href), attr(g[1])))
+		}
+		return keep(html.EscapeString(g[1]))
+	})
+	s = mdLinkRe.ReplaceAllStringFunc(s, func(m string) string {
+		g := mdLinkRe.FindStringSubmatch(m)
+		if href, ok := r.link(strings.Trim(g[2], "<>"), false); ok {
+			return keep(fmt.Sprintf(`<a href="%s">%s</a>`, attr(href), r.text(g[1])))
+		}
+		return keep(fmt.Sprintf(`<span class="missing" title="%s">%s</span>`, attr(g[2]), r.text(g[1])))
+	})
+	s = urlRe.ReplaceAllStringFunc(s, func(m string) string {
+		return keep(fmt.Sprintf(`<a href="%s">%s</a>`, attr(m), html.EscapeString(m)))
+	})
+	s = refRe.ReplaceAllStringFunc(s, func(m string) string {
+		g := refRe.FindStringSubmatch(m)
+		if g[1] != "" {
+			if href, ok := r.link(g[1], true); ok {
+				return keep(fmt.Sprintf(`<a class="ref" href="%s">%s</a>`, attr(href), html.EscapeString(m)))
+			}
+		} else if r.task != nil {
+			if href, ok := r.task(g[2]); ok {
+				return keep(fmt.Sprintf(`<a class="ref" href="%s">%s</a>`, attr(href), html.EscapeString(m)))
+			}
+		}
+		return m
+	})
+	s = tagRe.ReplaceAllStringFunc(s, func(m string) string {
+		// Tags are read as notes read them, so every tag linked here is one
+		// the note is listed under. A word run into a link is left alone.
+		g := tagRe.FindStringSubmatch(m)
+		tag := markdown.InlineTag(g[2])
+		if tag == "" || strings.Contains(g[2], "\x00") {
+			return m
+		}
+		word := strings.TrimRight(g[2], ".,;:!?")
+		return g[1] + keep(fmt.Sprintf(`<a class="tag" href="%s">%s</a>`, attr(r.tag(tag)), html.EscapeString(word))) + g[2][len(word):]
+	})
+
+	return untoken(r.text(s))
+}
+
+// text escapes plain text and applies emphasis. Line breaks within a
+// paragraph are kept, as notes are often written line by line.
+func (r *renderer) text(s string) string {
+	s = html.EscapeString(s)
+	s = boldRe.ReplaceAllString(s, "<strong>$1$2</strong>")
+	s = italicRe.ReplaceAllStringFunc(s, func(m string) string {
+		g := italicRe.FindStringSubmatch(m)
+		if g[1] != "" {
+			return "<em>" + g[1] + "</em>"
+		}
+		lead, trail := m[:strings.Index(m, "_")], m[strings.LastIndex(m, "_")+1:]
+		return lead + "<em>" + g[2] + "</em>" + trail
+	})
+	s = strikeRe.ReplaceAllString(s, "<del>$1</del>")
+	return strings.ReplaceAll(s, "\n", "<br>\n")
+}
+
+func attr(s string) string {
+	return html.EscapeString(s)
+}
+
+// plainText reduces markdown to searchable text: link text is kept and
+// markup is dropped.
+func plainText(md string) string {
+	md = wikiLinkRe.ReplaceAllStringFunc(md, func(m string) string {
+		g := wikiLinkRe.FindStringSubmatch(m)
+		if g[4] != "" {
+			return g[4]
+		}
+		return path.Base(g[2])
+	})
+	md = imageRe.ReplaceAllString(md, "$1")
+	md = mdLinkRe.ReplaceAllString(md, "$1")
+	var words []string
+	for _, line := range strings.Split(md, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "```") || strings.HasPrefix(line, "|") && tableSepRe.MatchString(line) {
+			continue
+		}
+		line = strings.TrimLeft(line, "#>-*+ ")
+		line = strings.NewReplacer("*", "", "__", "", "`", "", "[ ] ", "", "[x] ", "").Replace(line)
+		words = append(words, strings.Fields(line)...)
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,681 @@
+package site
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"time"
+
+	"gitlab.com/caffeinatedjack/sleepless/pkg/notes"
+	"gitlab.com/caffeinatedjack/sleepless/pkg/task"
+)
+
+var esc = html.EscapeString
+
+// kindNames are the headings of each kind of page on tag pages, in order.
+var kindNames = []struct{ kind, name string }{
+	{"note", "Notes"},
+	{"review", "Reviews"},
+	{"daily", "Daily notes"},
+	{"goal", "Goals"},
+	{"recipe", "Recipes"},
+}
+
+// noteTitle returns a note's first heading. Notes without one, such as
+// those from "note add --floating", are titled by their first words.
+func noteTitle(n *notes.Note) string {
+	if line, _, _ := strings.Cut(strings.TrimSpace(n.Body), "\n"); strings.HasPrefix(line, "# ") {
+		if title := strings.TrimSpace(line[2:]); title != "" {
+			return title
+		}
+	}
+	if title := summary(n, 60); title != "" {
+		return title
+	}
+	return n.Key()
+}
+
+func noteKind(n *notes.Note) string {
+	switch {
+	case n.Type == notes.NoteTypeDaily:
+		return "daily"
+	case notes.IsPeriodType(n.Type):
+		return "review"
+	}
+	return "note"
+}
+
+// summary returns the start of a note's text after its title.
+func summary(n *notes.Note, max int) string {
+	body := strings.TrimSpace(n.Body)
+	if strings.HasPrefix(body, "# ") {
+		_, body, _ = strings.Cut(body, "\n")
+	}
+	text := []rune(plainText(body))
+	if len(text) > max {
+		return strings.TrimSpace(string(text[:max-1])) + "…"
+	}
+	return string(text)
+}
+
+func (b *builder) link(root, key, text string) string {
+	if u, ok := b.urls[key]; ok {
+		return fmt.Sprintf(`<a href="%s%s">%s</a>`, root, esc(u), esc(text))
+	}
+	return esc(text)
+}
+
+func (b *builder) tagLinks(root string, tags []string) string {
+	var links []string
+	for _, tag := range cleanTags(tags) {
+		links = append(links, fmt.Sprintf(`<a class="tag" href="%stags/%s">#%s</a>`, root, esc(tagFile(tag)), esc(tag)))
+	}
+	return strings.Join(links, " ")
+}
+
+// pager renders previous and next links around a centre label.
+func pager(prev, centre, next string) string {
+	return `<nav class="pager"><span>` + prev + `</span><span>` + centre + `</span><span>` + next + "</span></nav>\n"
+}
+
+// backlinks renders the pages linking to key.
+func (b *builder) backlinks(key, root string) string {
+	seen := make(map[string]bool)
+	var items []string
+	for _, l := range b.graph.Backlinks(key) {
+		if seen[l.Source] {
+			continue
+		}
+		seen[l.Source] = true
+		// Pages that are not exported, such as the goals index, are left out.
+		u, ok := b.urls[l.Source]
+		if !ok {
+			continue
+		}
+		title := b.graph.Pages[l.Source]
+		if title == "" {
+			title = l.Source
+		}
+		items = append(items, fmt.Sprintf(`<li><a href="%s%s">%s</a></li>`, root, esc(u), esc(title)))
+	}
+	if len(items) == 0 {
+		return ""
+	}
+	return "<section class=\"backlinks\">\n<h2>Linked from</h2>\n<ul>\n" + strings.Join(items, "\n") + "\n</ul>\n</section>\n"
+}
+
+// checkIn describes a daily note's check-in fields.
+func checkIn(n *notes.Note) string {
+	var parts []string
+	if n.Mood != nil {
+		parts = append(parts, fmt.Sprintf("mood %d/5", *n.Mood))
+	}
+	if n.Energy != nil {
+		parts = append(parts, fmt.Sprintf("energy %d/5", *n.Energy))
+	}
+	if n.Sleep != nil {
+		parts = append(parts, fmt.Sprintf("slept %gh", *n.Sleep))
+	}
+	habits := make([]string, 0, len(n.Habits))
+	for name := range n.Habits {
+		habits = append(habits, name)
+	}
+	sort.Strings(habits)
+	for _, name := range habits {
+		mark := "✗"
+		if n.Habits[name] {
+			mark = "✓"
+		}
+		parts = append(parts, mark+" "+name)
+	}
+	return strings.Join(parts, " · ")
+}
+
+func (b *builder) writeNotes() error {
+	var daily []*notes.Note
+	for _, n := range b.notes {
+		if n.Type == notes.NoteTypeDaily {
+			daily = append(daily, n)
+		}
+	}
+	dailyAt := make(map[string]int)
+	for i, n := range daily {
+		dailyAt[n.Date] = i
+	}
+
+	for _, n := range b.notes {
+		key, title, kind := n.Key(), noteTitle(n), noteKind(n)
+		e := entry{Title: title, URL: b.urls[key], Kind: kind}
+		for _, tag := range n.Tags {
+			b.addTag(tag, e)
+		}
+		b.addSearch(e, n.Tags, n.Body)
+
+		var h strings.Builder
+		switch kind {
+		case "daily":
+			i := dailyAt[n.Date]
+			var prev, next string
+			if i > 0 {
+				prev = `<a href="` + daily[i-1].Date + `.html">← ` + daily[i-1].Date + `</a>`
+			}
+			if i < len(daily)-1 {
+				next = `<a href="` + daily[i+1].Date + `.html">` + daily[i+1].Date + ` →</a>`
+			}
+			month := n.Date[:7]
+			day, _ := time.Parse("2006-01-02", n.Date)
+			h.WriteString(pager(prev, `<a href="../calendar/`+month+`.html">`+day.Format("January 2006")+`</a>`, next))
+		case "review":
+			if p, ok := notes.ParsePeriod(n.Period); ok {
+				prev, next := p.Prev().Key(), p.Next().Key()
+				h.WriteString(pager(b.link("../", prev, "← "+prev), esc(p.Start.Format("2 Jan"))+" – "+esc(p.Last().Format("2 Jan 2006")), b.link("../", next, next+" →")))
+			}
+		}
+
+		body := strings.TrimSpace(n.Body)
+		r := b.renderer("notes", "../")
+		if line, rest, _ := strings.Cut(body, "\n"); strings.HasPrefix(line, "# ") {
+			h.WriteString(r.render(line))
+			body = rest
+		} else {
+			h.WriteString("<h1>" + esc(title) + "</h1>\n")
+		}
+
+		var meta []string
+		switch kind {
+		case "daily":
+			day, _ := time.Parse("2006-01-02", n.Date)
+			meta = append(meta, day.Format("Monday 2 January 2006"))
+		case "review":
+			meta = append(meta, task.TitleCase(string(n.Type))+" review")
+		default:
+			if !n.Created.IsZero() {
+				meta = append(meta, "Created "+n.Created.Format("2 Jan 2006"))
+			}
+		}
+		if !n.Updated.IsZero() {
+			meta = append(meta, "updated "+n.Updated.Format("2 Jan 2006 15:04"))
+		}
+		if c := checkIn(n); c != "" {
+			meta = append(meta, esc(c))
+		}
+		if tags := b.tagLinks("../", n.Tags); tags != "" {
+			meta = append(meta, tags)
+		}
+		h.WriteString(`<p class="meta">` + strings.Join(meta, " · ") + "</p>\n")
+		h.WriteString(r.render(body))
+		h.WriteString(b.backlinks(key, "../"))
+
+		if err := b.page(e.URL, title, "Notes", h.String()); err != nil {
+			return err
+		}
+		b.report.Notes++
+	}
+	return b.writeNotesIndex(daily)
+}
+
+func (b *builder) writeNotesIndex(daily []*notes.Note) error {
+	var floating, reviews []*notes.Note
+	for _, n := range b.notes {
+		switch noteKind(n) {
+		case "note":
+			floating = append(floating, n)
+		case "review":
+			reviews = append(reviews, n)
+		}
+	}
+	sort.SliceStable(floating, func(i, j int) bool {
+		return strings.ToLower(noteTitle(floating[i])) < strings.ToLower(noteTitle(floating[j]))
+	})
+	sort.SliceStable(reviews, func(i, j int) bool { return reviews[i].Key() > reviews[j].Key() })
+
+	var h strings.Builder
+	h.WriteString("<h1>Notes</h1>\n")
+	if len(daily) > 0 {
+		fmt.Fprintf(&h, `<p>%d daily notes, from %s to %s. Browse them in the <a href="../calendar/index.html">calendar</a>.</p>`+"\n",
+			len(daily), daily[0].Date, daily[len(daily)-1].Date)
+	}
+	h.WriteString("<h2>Notes</h2>\n")
+	if len(floating) == 0 {
+		h.WriteString("<p>No notes yet.</p>\n")
+	} else {
+		h.WriteString("<ul class=\"index\">\n")
+		for _, n := range floating {
+			fmt.Fprintf(&h, "<li>%s <span class=\"meta\">%s</span></li>\n", b.link("../", n.Key(), noteTitle(n)), b.tagLinks("../", n.Tags))
+		}
+		h.WriteString("</ul>\n")
+	}
+	if len(reviews) > 0 {
+		h.WriteString("<h2>Reviews</h2>\n<ul class=\"index\">\n")
+		for _, n := range reviews {
+			fmt.Fprintf(&h, "<li>%s <span class=\"meta\">%s</span></li>\n", b.link("../", n.Key(), noteTitle(n)), esc(n.Period))
+		}
+		h.WriteString("</ul>\n")
+	}
+	return b.page("notes/index.html", "Notes", "Notes", h.String())
+}
+
+// months returns the months with daily notes, oldest first, and the daily
+// notes by date.
+func (b *builder) months() ([]string, map[string]*notes.Note) {
+	byDate := make(map[string]*notes.Note)
+	var months []string
+	for _, n := range b.notes {
+		if n.Type != notes.NoteTypeDaily {
+			continue
+		}
+		byDate[n.Date] = n
+		if m := n.Date[:7]; len(months) == 0 || months[len(months)-1] != m {
+			months = append(months, m)
+		}
+	}
+	return months, byDate
+}
+
+// monthGrid renders a month as a calendar, with links to its daily notes
+// and to each week's review note.
+func (b *builder) monthGrid(month, root string, byDate map[string]*notes.Note) string {
+	first, _ := time.Parse("2006-01", month)
+	start := first.AddDate(0, 0, -((int(first.Weekday()) + 6) % 7))
+	today := b.site.Now.Format("2006-01-02")
+
+	var h strings.Builder
+	h.WriteString("<table class=\"calendar\">\n<thead><tr><th>Wk</th>")
+	for _, d := range []string{"Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"} {
+		h.WriteString("<th>" + d + "</th>")
+	}
+	h.WriteString("</tr></thead>\n<tbody>\n")
+	for week := start; week.Before(first.AddDate(0, 1, 0)); week = week.AddDate(0, 0, 7) {
+		p, _ := notes.PeriodOf(notes.NoteTypeWeekly, week)
+		_, wk := week.ISOWeek()
+		h.WriteString("<tr><th>" + b.link(root, p.Key(), fmt.Sprint(wk)) + "</th>")
+		for d := week; d.Before(week.AddDate(0, 0, 7)); d = d.AddDate(0, 0, 1) {
+			date := d.Format("2006-01-02")
+			var class []string
+			if date == today {
+				class = append(class, "today")
+			}
+			cell := ""
+			if d.Month() == first.Month() {
+				cell = fmt.Sprint(d.Day())
+				if n, ok := byDate[date]; ok {
+					class = append(class, "note")
+					cell = fmt.Sprintf(`<a href="%s%s" title="%s">%d</a>`, root, b.urls[date], esc(summary(n, 80)), d.Day())
+				}
+			}
+			if len(class) > 0 {
+				fmt.Fprintf(&h, `<td class="%s">%s</td>`, strings.Join(class, " "), cell)
+			} else {
+				h.WriteString("<td>" + cell + "</td>")
+			}
+		}
+		h.WriteString("</tr>\n")
+	}
+	h.WriteString("</tbody>\n</table>\n")
+	return h.String()
+}
+
+func (b *builder) writeCalendar() error {
+	months, byDate := b.months()
+	for i, month := range months {
+		first, _ := time.Parse("2006-01", month)
+		var prev, next string
+		if i > 0 {
+			p, _ := time.Parse("2006-01", months[i-1])
+			prev = `<a href="` + months[i-1] + `.html">← ` + p.Format("January 2006") + `</a>`
+		}
+		if i < len(months)-1 {
+			n, _ := time.Parse("2006-01", months[i+1])
+			next = `<a href="` + months[i+1] + `.html">` + n.Format("January 2006") + ` →</a>`
+		}
+
+		var h strings.Builder
+		h.WriteString(pager(prev, `<a href="index.html">All months</a>`, next))
+		h.WriteString("<h1>" + first.Format("January 2006") + "</h1>\n")
+		if _, ok := b.urls[month]; ok {
+			h.WriteString(`<p class="meta">` + b.link("../", month, "Monthly review") + "</p>\n")
+		}
+		h.WriteString(b.monthGrid(month, "../", byDate))
+		h.WriteString("<ul class=\"index\">\n")
+		for d := first; d.Month() == first.Month(); d = d.AddDate(0, 0, 1) {
+			if n, ok := byDate[d.Format("2006-01-02")]; ok {
+				fmt.Fprintf(&h, "<li>%s <span class=\"meta\">%s</span></li>\n", b.link("../", n.Date, d.Format("Mon 2")), esc(summary(n, 100)))
+			}
+		}
+		h.WriteString("</ul>\n")
+		if err := b.page("calendar/"+month+".html", first.Format("January 2006"), "Calendar", h.String()); err != nil {
+			return err
+		}
+	}
+
+	var h strings.Builder
+	h.WriteString("<h1>Calendar</h1>\n")
+	if len(months) == 0 {
+		h.WriteString("<p>No daily notes yet.</p>\n")
+	}
+	counts := make(map[string]int)
+	for date := range byDate {
+		counts[date[:7]]++
+	}
+	for i := len(months) - 1; i >= 0; i-- {
+		year := months[i][:4]
+		if i == len(months)-1 || months[i+1][:4] != year {
+			if i != len(months)-1 {
+				h.WriteString("</ul>\n")
+			}
+			h.WriteString("<h2>" + b.link("../", year, year) + "</h2>\n<ul class=\"index\">\n")
+		}
+		m, _ := time.Parse("2006-01", months[i])
+		fmt.Fprintf(&h, "<li><a href=\"%s.html\">%s</a> <span class=\"meta\">%d notes</span></li>\n", months[i], m.Format("January"), counts[months[i]])
+	}
+	if len(months) > 0 {
+		h.WriteString("</ul>\n")
+	}
+	return b.page("calendar/index.html", "Calendar", "Calendar", h.String())
+}
+
+// walk calls fn for each goal and subtask.
+func walk(tasks []*task.Task, fn func(*task.Task)) {
+	for _, t := range tasks {
+		fn(t)
+		walk(t.Subtasks, fn)
+	}
+}
+
+// goal renders a goal and its subtasks as a list item.
+func (b *builder) goal(h *strings.Builder, r *renderer, t *task.Task) {
+	class, checked := "goal", ""
+	if t.IsComplete() {
+		class, checked = "goal done", " checked"
+	}
+	fmt.Fprintf(h, `<li class="%s" id="task-%s"><input type="checkbox" disabled%s> %s`, class, esc(t.ID), checked, r.inline(t.Title))
+	if t.Priority != "" && t.Priority != task.PriorityMedium {
+		fmt.Fprintf(h, ` <span class="priority %s">%s</span>`, esc(string(t.Priority)), esc(string(t.Priority)))
+	}
+	if t.Due != nil {
+		due := "due"
+		if t.IsOverdue() {
+			due = "due overdue"
+		}
+		fmt.Fprintf(h, ` <span class="%s">due %s</span>`, due, t.Due.Format("2 Jan 2006"))
+	}
+	if tags := b.tagLinks("../", t.Tags); tags != "" {
+		h.WriteString(" " + tags)
+	}
+	if len(t.Notes) > 0 {
+		h.WriteString("\n<ul class=\"notes\">\n")
+		for _, note := range t.Notes {
+			h.WriteString("<li>" + r.inline(note) + "</li>\n")
+		}
+		h.WriteString("</ul>")
+	}
+	if len(t.Subtasks) > 0 {
+		h.WriteString("\n<ul>\n")
+		for _, sub := range t.Subtasks {
+			b.goal(h, r, sub)
+		}
+		h.WriteString("</ul>")
+	}
+	h.WriteString("</li>\n")
+}
+
+// dueGoals returns the open goals with a due date, soonest first.
+func (b *builder) dueGoals() []*task.Task {
+	var due []*task.Task
+	for _, tp := range b.topics {
+		if tp.name == "archived" {
+			continue
+		}
+		walk(tp.tasks, func(t *task.Task) {
+			if !t.IsComplete() && t.Due != nil {
+				due = append(due, t)
+			}
+		})
+	}
+	sort.SliceStable(due, func(i, j int) bool { return due[i].Due.Before(*due[j].Due) })
+	return due
+}
+
+// dueList renders goals with a due date as a list, linking to each.
+func (b *builder) dueList(root string, goals []*task.Task) string {
+	var h strings.Builder
+	h.WriteString("<ul class=\"index\">\n")
+	for _, t := range goals {
+		class := "due"
+		if t.IsOverdue() {
+			class = "due overdue"
+		}
+		fmt.Fprintf(&h, `<li class="goal"><a href="%sgoals/%s.html#task-%s">%s</a> <span class="%s">%s</span></li>`+"\n",
+			root, esc(t.Topic), esc(t.ID), esc(t.Title), class, t.Due.Format("Mon 2 Jan"))
+	}
+	h.WriteString("</ul>\n")
+	return h.String()
+}
+
+func (b *builder) writeGoals() error {
+	if len(b.topics) == 0 {
+		return nil
+	}
+
+	var index strings.Builder
+	index.WriteString("<h1>Goals</h1>\n<table>\n<thead><tr><th>Topic</th><th>Open</th><th>Done</th><th>Progress</th></tr></thead>\n<tbody>\n")
+	for _, tp := range b.topics {
+		url := "goals/" + tp.name + ".html"
+		r := b.renderer(wikiRel(b.site.Notes.WikiDir, b.site.Goals.Path), "../")
+		open, done := 0, 0
+		walk(tp.tasks, func(t *task.Task) {
+			if t.IsComplete() {
+				done++
+			} else {
+				open++
+			}
+			e := entry{Title: t.Title, URL: url + "#task-" + t.ID, Kind: "goal"}
+			for _, tag := range t.Tags {
+				b.addTag(tag, e)
+			}
+			b.addSearch(e, t.Tags, tp.title+"\n"+strings.Join(t.Notes, "\n"))
+			b.report.Goals++
+		})
+
+		var h strings.Builder
+		h.WriteString("<h1>" + esc(tp.title) + "</h1>\n")
+		fmt.Fprintf(&h, "<p class=\"meta\">%d open · %d done</p>\n", open, done)
+		if len(tp.tasks) == 0 {
+			h.WriteString("<p>No goals.</p>\n")
+		} else {
+			h.WriteString("<ul class=\"goals\">\n")
+			for _, t := range tp.tasks {
+				b.goal(&h, r, t)
+			}
+			h.WriteString("</ul>\n")
+		}
+		if rel := wikiRel(b.site.Notes.WikiDir, b.site.Goals.Path); rel != "" {
+			h.WriteString(b.backlinks(rel+"/"+tp.name+".md", "../"))
+		}
+		if err := b.page(url, tp.title, "Goals", h.String()); err != nil {
+			return err
+		}
+		b.report.Topics++
+
+		percent := 0
+		if open+done > 0 {
+			percent = done * 100 / (open + done)
+		}
+		fmt.Fprintf(&index, `<tr><td><a href="%s.html">%s</a></td><td>%d</td><td>%d</td><td><span class="progress"><span style="width: %d%%"></span></span> %d%%</td></tr>`+"\n",
+			esc(tp.name), esc(tp.title), open, done, percent, percent)
+	}
+	index.WriteString("</tbody>\n</table>\n")
+	if due := b.dueGoals(); len(due) > 0 {
+		index.WriteString("<h2>Due</h2>\n" + b.dueList("../", due))
+	}
+	return b.page("goals/index.html", "Goals", "Goals", index.String())
+}
+
+func (b *builder) writeRecipes() error {
+	if len(b.recipes) == 0 {
+		return nil
+	}
+	dir := wikiRel(b.site.Notes.WikiDir, b.site.RecipesDir)
+	if dir == "" {
+		dir = "recipes"
+	}
+
+	var index strings.Builder
+	index.WriteString("<h1>Recipes</h1>\n<ul class=\"index\">\n")
+	for _, rc := range b.recipes {
+		url := "recipes/" + rc.name + ".html"
+		body, err := readPage(rc.ref.Path)
+		if err != nil {
+			return err
+		}
+		e := entry{Title: rc.ref.Title, URL: url, Kind: "recipe"}
+		for _, tag := range rc.ref.Tags {
+			b.addTag(tag, e)
+		}
+		b.addSearch(e, rc.ref.Tags, body)
+
+		var h strings.Builder
+		h.WriteString(b.renderer(dir, "../").render(body))
+		if rc.key != "" {
+			h.WriteString(b.backlinks(rc.key, "../"))
+		}
+		if err := b.page(url, rc.ref.Title, "Recipes", h.String()); err != nil {
+			return err
+		}
+		b.report.Recipes++
+		fmt.Fprintf(&index, "<li><a href=\"%s.html\">%s</a> <span class=\"meta\">%s</span></li>\n", esc(rc.name), esc(rc.ref.Title), b.tagLinks("../", rc.ref.Tags))
+	}
+	index.WriteString("</ul>\n")
+	return b.page("recipes/index.html", "Recipes", "Recipes", index.String())
+}
+
+func (b *builder) writeTags() error {
+	tags := b.sortedTags()
+	var index strings.Builder
+	index.WriteString("<h1>Tags</h1>\n")
+	if len(tags) == 0 {
+		index.WriteString("<p>No tags yet.</p>\n")
+	} else {
+		index.WriteString("<ul class=\"tags-cloud\">\n")
+	}
+	for _, tag := range tags {
+		entries := b.tags[tag]
+		seen := make(map[string]bool)
+		var h strings.Builder
+		h.WriteString("<h1>#" + esc(tag) + "</h1>\n")
+		for _, k := range kindNames {
+			var items []string
+			for _, e := range entries {
+				if e.Kind == k.kind && !seen[e.URL] {
+					seen[e.URL] = true
+					items = append(items, fmt.Sprintf(`<li><a href="../%s">%s</a></li>`, esc(e.URL), esc(e.Title)))
+				}
+			}
+			if len(items) > 0 {
+				h.WriteString("<h2>" + k.name + "</h2>\n<ul class=\"index\">\n" + strings.Join(items, "\n") + "\n</ul>\n")
+			}
+		}
+		if err := b.page("tags/"+tagFile(tag), "#"+tag, "Tags", h.String()); err != nil {
+			return err
+		}
+		fmt.Fprintf(&index, "<li><a class=\"tag\" href=\"%s\">#%s</a> <span class=\"meta\">%d</span></li>\n", esc(tagFile(tag)), esc(tag), len(seen))
+	}
+	if len(tags) > 0 {
+		index.WriteString("</ul>\n")
+	}
+	b.report.Tags = len(tags)
+	return b.page("tags/index.html", "Tags", "Tags", index.String())
+}
+
+func (b *builder) writeSearch() error {
+	data, err := json.Marshal(b.search)
+	if err != nil {
+		return err
+	}
+	if err := b.write("search-index.js", []byte("var searchIndex = "+string(data)+";\n")); err != nil {
+		return err
+	}
+	body := "<h1>Search</h1>\n<p id=\"summary\" class=\"meta\"></p>\n<ul id=\"results\"></ul>\n" +
+		"<noscript><p>Search needs JavaScript.</p></noscript>\n"
+	return b.page("search.html", "Search", "", body, "search-index.js", "search.js")
+}
+
+func (b *builder) writeIndex() error {
+	months, byDate := b.months()
+	var h strings.Builder
+	h.WriteString("<h1>" + esc(b.site.Title) + "</h1>\n")
+	var counts []string
+	for _, c := range []struct {
+		n    int
+		name string
+		url  string
+	}{
+		{b.report.Notes, "notes", "notes/index.html"},
+		{b.report.Goals, "goals", "goals/index.html"},
+		{b.report.Recipes, "recipes", "recipes/index.html"},
+		{b.report.Tags, "tags", "tags/index.html"},
+	} {
+		if c.n > 0 {
+			counts = append(counts, fmt.Sprintf(`<a href="%s">%d %s</a>`, c.url, c.n, c.name))
+		}
+	}
+	if len(counts) > 0 {
+		h.WriteString(`<p class="meta">` + strings.Join(counts, " · ") + "</p>\n")
+	}
+
+	if len(months) > 0 {
+		// This month, or the last month with daily notes.
+		month := b.site.Now.Format("2006-01")
+		if i := sort.SearchStrings(months, month); i == len(months) || months[i] != month {
+			month = months[len(months)-1]
+		}
+		m, _ := time.Parse("2006-01", month)
+		h.WriteString(`<h2><a href="calendar/` + month + `.html">` + m.Format("January 2006") + "</a></h2>\n")
+		h.WriteString(b.monthGrid(month, "", byDate))
+
+		h.WriteString("<h2>Recent daily notes</h2>\n<ul class=\"index\">\n")
+		shown := 0
+		for i := len(b.notes) - 1; i >= 0 && shown < 7; i-- {
+			if n := b.notes[i]; n.Type == notes.NoteTypeDaily {
+				day, _ := time.Parse("2006-01-02", n.Date)
+				fmt.Fprintf(&h, "<li>%s <span class=\"meta\">%s</span></li>\n", b.link("", n.Date, day.Format("Mon 2 Jan 2006")), esc(summary(n, 100)))
+				shown++
+			}
+		}
+		h.WriteString("</ul>\n")
+	}
+
+	var floating []*notes.Note
+	for _, n := range b.notes {
+		if noteKind(n) == "note" {
+			floating = append(floating, n)
+		}
+	}
+	updated := func(n *notes.Note) time.Time {
+		if n.Updated.IsZero() {
+			return n.Created
+		}
+		return n.Updated
+	}
+	sort.SliceStable(floating, func(i, j int) bool { return updated(floating[i]).After(updated(floating[j])) })
+	if len(floating) > 0 {
+		h.WriteString("<h2>Recently updated</h2>\n<ul class=\"index\">\n")
+		for i, n := range floating {
+			if i == 8 {
+				break
+			}
+			fmt.Fprintf(&h, "<li>%s <span class=\"meta\">%s</span></li>\n", b.link("", n.Key(), noteTitle(n)), updated(n).Format("2 Jan 2006"))
+		}
+		h.WriteString("</ul>\n")
+	}
+
+	if due := b.dueGoals(); len(due) > 0 {
+		if len(due) > 8 {
+			due = due[:8]
+		}
+		h.WriteString("<h2>Goals due</h2>\n" + b.dueList("", due))
+	}
+	return b.page("index.html", b.site.Title, "", h.String())
+}
@@ -0,0 +1,580 @@
+// Package site renders the wiki as a static HTML site: notes, goal topics
+// and recipes, with tag pages, a calendar of daily notes, backlinks and a
+// client-side search index.
+package site
+
+import (
+	"bytes"
+	"crypto/sha256"
+	_ "embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gitlab.com/caffeinatedjack/sleepless/pkg/markdown"
+	"gitlab.com/caffeinatedjack/sleepless/pkg/notes"
+	"gitlab.com/caffeinatedjack/sleepless/pkg/recipes"
+	"gitlab.com/caffeinatedjack/sleepless/pkg/storage"
+	"gitlab.com/caffeinatedjack/sleepless/pkg/task"
+)
+
+//go:embed theme/style.css
+var styleCSS string
+
+//go:embed theme/search.js
+var searchJS string
+
+// markerFile marks a directory written by Build, which a later build may
+// replace.
+const markerFile = ".regimen-site"
+
+// ErrEncrypted is returned when the wiki, or any file in it, is encrypted.
+var ErrEncrypted = errors.New("wiki is encrypted. Run 'regimen decrypt' first")
+
+// Themes lists the accepted values of Site.Theme.
+var Themes = []string{"auto", "light", "dark"}
+
+// Site describes what to export.
+type Site struct {
+	Notes *notes.Store
+	// Goals reads the goal topics; nil leaves goals out.
+	Goals *storage.Storage
+	// RecipesDir is the recipe directory; "" leaves recipes out.
+	RecipesDir string
+	// Title is shown in the header of every page.
+	Title string
+	// Theme is auto, to follow the browser's light or dark setting, light
+	// or dark.
+	Theme string
+	// Now marks today in the calendar.
+	Now time.Time
+}
+
+// Report counts what Build exported.
+type Report struct {
+	Notes       int
+	Topics      int
+	Goals       int
+	Recipes     int
+	Tags        int
+	Attachments int
+	Pages       int
+}
+
+// Build renders the site into out. The site is written to a temporary
+// directory first and then moved into place, replacing an earlier export;
+// out must otherwise be empty or not exist.
+func (s *Site) Build(out string) (*Report, error) {
+	if s.Notes.IsEncrypted() {
+		return nil, ErrEncrypted
+	}
+	if n := countEncrypted(s.Notes.WikiDir); n > 0 {
+		return nil, fmt.Errorf("%w (%d encrypted files found)", ErrEncrypted, n)
+	}
+	switch s.Theme {
+	case "":
+		s.Theme = "auto"
+	case "auto", "light", "dark":
+	default:
+		return nil, fmt.Errorf("unknown theme %q (use auto, light or dark)", s.Theme)
+	}
+	if s.Now.IsZero() {
+		s.Now = time.Now()
+	}
+
+	out, err := filepath.Abs(out)
+	if err != nil {
+		return nil, err
+	}
+	wiki, err := filepath.Abs(s.Notes.WikiDir)
+	if err != nil {
+		return nil, err
+	}
+	if out == wiki || strings.HasPrefix(wiki, out+string(filepath.Separator)) {
+		return nil, fmt.Errorf("cannot export into %s: it contains the wiki", out)
+	}
+	if entries, err := os.ReadDir(out); err == nil && len(entries) > 0 {
+		if _, err := os.Stat(filepath.Join(out, markerFile)); err != nil {
+			return nil, fmt.Errorf("%s is not empty and is not an earlier export", out)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(out), 0755); err != nil {
+		return nil, err
+	}
+	tmp, err := os.MkdirTemp(filepath.Dir(out), ".site-")
+	if err != nil {
+		return nil, err
+	}
+	b := &builder{site: s, out: tmp, urls: make(map[string]string), tags: make(map[string][]entry)}
+	if err := b.build(); err != nil {
+		os.RemoveAll(tmp)
+		return nil, err
+	}
+	if err := os.Chmod(tmp, 0755); err != nil {
+		os.RemoveAll(tmp)
+		return nil, err
+	}
+	if err := os.RemoveAll(out); err != nil {
+		os.RemoveAll(tmp)
+		return nil, fmt.Errorf("failed to replace %s: %w", out, err)
+	}
+	if err := os.Rename(tmp, out); err != nil {
+		os.RemoveAll(tmp)
+		return nil, fmt.Errorf("failed to move the site into %s: %w", out, err)
+	}
+	return &b.report, nil
+}
+
+// countEncrypted returns the number of encrypted (.enc) files in the wiki.
+// They are left behind by an interrupted encrypt or decrypt, and would
+// otherwise go missing from the export without a word.
+func countEncrypted(wiki string) int {
+	n := 0
+	filepath.WalkDir(wiki, func(p string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() && filepath.Ext(p) == ".enc" {
+			n++
+		}
+		return nil
+	})
+	return n
+}
+
+// entry is a page listed on a tag page or in the search index.
+type entry struct {
+	Title string
+	URL   string
+	Kind  string
+}
+
+type topic struct {
+	name  string
+	title string
+	tasks []*task.Task
+}
+
+type recipe struct {
+	ref  recipes.RecipeRef
+	name string
+	key  string
+}
+
+// builder holds the state of one build.
+type builder struct {
+	site   *Site
+	out    string
+	report Report
+
+	graph *notes.Graph
+	notes []*notes.Note
+	// urls maps link graph keys (note keys and wiki-relative paths) to
+	// site-relative page URLs.
+	urls    map[string]string
+	topics  []topic
+	recipes []recipe
+	tags    map[string][]entry
+	search  []searchEntry
+}
+
+// searchEntry is one page in the search index. The short JSON names keep
+// the index small.
+type searchEntry struct {
+	Title string   `json:"t"`
+	URL   string   `json:"u"`
+	Kind  string   `json:"k"`
+	Tags  []string `json:"g"`
+	Text  string   `json:"x"`
+}
+
+// maxSearchText is the number of characters of each page kept in the
+// search index.
+const maxSearchText = 4000
+
+func (b *builder) build() error {
+	if err := b.load(); err != nil {
+		return err
+	}
+
+	steps := []func() error{
+		b.writeAssets,
+		b.writeNotes,
+		b.writeCalendar,
+		b.writeGoals,
+		b.writeRecipes,
+		b.writeTags,
+		b.writeSearch,
+		b.writeIndex,
+		b.copyAttachments,
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(filepath.Join(b.out, markerFile), []byte("Written by regimen export html.\n"), 0644)
+}
+
+// load reads the notes, goals and recipes, and assigns each page its URL.
+func (b *builder) load() error {
+	s := b.site
+	var err error
+	if b.notes, err = s.Notes.LoadAll(); err != nil {
+		return fmt.Errorf("failed to load notes: %w", err)
+	}
+	if b.graph, err = s.Notes.LinkGraph(); err != nil {
+		return err
+	}
+	for _, n := range b.notes {
+		b.urls[n.Key()] = "notes/" + n.Key() + ".html"
+	}
+
+	if s.Goals != nil && isDir(s.Goals.Path) {
+		rel := wikiRel(s.Notes.WikiDir, s.Goals.Path)
+		names := s.Goals.ListTopics()
+		if _, err := os.Stat(filepath.Join(s.Goals.Path, "archived.md")); err == nil {
+			names = append(names, "archived")
+		}
+		for _, name := range names {
+			tasks, err := s.Goals.LoadTasks(name)
+			if err != nil {
+				return fmt.Errorf("failed to load goals: %w", err)
+			}
+			t := topic{name: name, title: task.TitleCase(name), tasks: tasks}
+			if rel != "" {
+				key := path.Join(rel, name+".md")
+				b.urls[key] = "goals/" + name + ".html"
+				if title := b.graph.Pages[key]; title != "" {
+					t.title = title
+				}
+			}
+			b.topics = append(b.topics, t)
+		}
+	}
+
+	if s.RecipesDir != "" && isDir(s.RecipesDir) {
+		refs, err := recipes.Find(s.RecipesDir)
+		if err != nil {
+			return err
+		}
+		rel := wikiRel(s.Notes.WikiDir, s.RecipesDir)
+		for _, ref := range refs {
+			r := recipe{ref: ref, name: strings.TrimSuffix(filepath.Base(ref.Path), filepath.Ext(ref.Path))}
+			if rel != "" {
+				r.key = path.Join(rel, filepath.Base(ref.Path))
+				b.urls[r.key] = "recipes/" + r.name + ".html"
+			}
+			b.recipes = append(b.recipes, r)
+		}
+	}
+	return nil
+}
+
+// wikiRel returns dir relative to the wiki with forward slashes, or "" if
+// it is outside the wiki.
+func wikiRel(wiki, dir string) string {
+	rel, err := filepath.Rel(wiki, dir)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return ""
+	}
+	return filepath.ToSlash(rel)
+}
+
+func fileExists(p string) bool {
+	_, err := os.Stat(p)
+	return err == nil
+}
+
+func isDir(p string) bool {
+	info, err := os.Stat(p)
+	return err == nil && info.IsDir()
+}
+
+// renderer returns a markdown renderer for a page read from dir, relative
+// to the wiki, and written root levels below the top of the site.
+func (b *builder) renderer(dir, root string) *renderer {
+	return &renderer{
+		link: func(target string, wiki bool) (string, bool) {
+			if schemeRe.MatchString(target) || strings.HasPrefix(target, "#") {
+				return target, safeLink(target)
+			}
+			t, anchor, _ := strings.Cut(target, "#")
+			if anchor != "" {
+				anchor = "#" + anchorID(anchor)
+			}
+			if key := b.graph.Resolve(t, dir, wiki); key != "" {
+				if u, ok := b.urls[key]; ok {
+					return root + u + anchor, true
+				}
+				return "", false
+			}
+			// A bare ![[name]] embed names a file in the attachments
+			// directory, as Obsidian finds it wherever it is.
+			if wiki && !strings.Contains(t, "/") && !fileExists(filepath.Join(b.site.Notes.WikiDir, filepath.FromSlash(dir), t)) &&
+				fileExists(filepath.Join(b.site.Notes.NotesDir, notes.AttachmentsDir, t)) {
+				return root + "notes/" + notes.AttachmentsDir + "/" + t, true
+			}
+			// Attachments and other files keep their path.
+			return target, safeLink(target)
+		},
+		tag: func(tag string) string {
+			return root + "tags/" + tagFile(tag)
+		},
+		task: func(id string) (string, bool) {
+			var match string
+			for _, tp := range b.topics {
+				for _, t := range task.FindTasksByPrefix(tp.tasks, id) {
+					if match != "" {
+						return "", false
+					}
+					match = "goals/" + tp.name + ".html#task-" + t.ID
+				}
+			}
+			return root + match, match != ""
+		},
+	}
+}
+
+// linkSchemes are the URL schemes a page may link to. Others, such as
+// javascript:, are rendered as missing links.
+var linkSchemes = map[string]bool{"http": true, "https": true, "mailto": true}
+
+// safeLink reports whether target may be used as an href: a relative path or
+// a URL in one of linkSchemes. Spaces and control characters, which browsers
+// drop from URLs, cannot hide a scheme.
+func safeLink(target string) bool {
+	clean := strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, target)
+	scheme := schemeRe.FindString(clean)
+	return scheme == "" || linkSchemes[strings.ToLower(strings.TrimSuffix(scheme, ":"))]
+}
+
+var (
+	tagFileRe  = regexp.MustCompile(`[^\p{L}\p{N}_-]+`)
+	plainTagRe = regexp.MustCompile(`^[\p{L}\p{N}_-]+$`)
+)
+
+// tagFile returns the file name of a tag's page. A tag that is safe as a
+// file name is used as it is; any other is reduced to one and given a hash
+// of the tag, after a dot no plain tag has, so that #c++ and #c-- do not
+// share a page.
+func tagFile(tag string) string {
+	tag = strings.ToLower(tag)
+	if plainTagRe.MatchString(tag) {
+		return tag + ".html"
+	}
+	slug := strings.Trim(tagFileRe.ReplaceAllString(tag, "-"), "-")
+	if slug == "" {
+		slug = "tag"
+	}
+	sum := sha256.Sum256([]byte(tag))
+	return fmt.Sprintf("%s.%x.html", slug, sum[:4])
+}
+
+// cleanTags lowercases tags and drops the heading markers that can be
+// picked up as inline tags.
+func cleanTags(tags []string) []string {
+	out := []string{}
+	for _, tag := range tags {
+		if tag = strings.ToLower(strings.TrimPrefix(tag, "#")); strings.Trim(tag, "#") != "" {
+			out = append(out, tag)
+		}
+	}
+	return out
+}
+
+// addTag lists a page on a tag's page.
+func (b *builder) addTag(tag string, e entry) {
+	for _, tag := range cleanTags([]string{tag}) {
+		b.tags[tag] = append(b.tags[tag], e)
+	}
+}
+
+// addSearch adds a page to the search index.
+func (b *builder) addSearch(e entry, tags []string, md string) {
+	text := []rune(plainText(md))
+	if len(text) > maxSearchText {
+		text = text[:maxSearchText]
+	}
+	b.search = append(b.search, searchEntry{Title: e.Title, URL: e.URL, Kind: e.Kind, Tags: cleanTags(tags), Text: string(text)})
+}
+
+var layout = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html lang="en"{{if ne .Theme "auto"}} data-theme="{{.Theme}}"{{end}}>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}} · {{.Site}}</title>
+<link rel="stylesheet" href="{{.Root}}style.css">
+</head>
+<body>
+<header>
+<a class="site" href="{{.Root}}index.html">{{.Site}}</a>
+<nav>
+{{- range .Nav}}
+<a href="{{$.Root}}{{.URL}}"{{if eq .Name $.Section}} class="current"{{end}}>{{.Name}}</a>
+{{- end}}
+</nav>
+<form action="{{.Root}}search.html" method="get"><input type="search" id="q" name="q" placeholder="Search" aria-label="Search"></form>
+</header>
+<main>
+{{.Body}}
+</main>
+<footer>Exported from regimen on {{.Exported}}</footer>
+{{- range .Scripts}}
+<script src="{{$.Root}}{{.}}"></script>
+{{- end}}
+</body>
+</html>
+`))
+
+type navLink struct {
+	Name string
+	URL  string
+}
+
+type layoutData struct {
+	Site     string
+	Title    string
+	Theme    string
+	Root     string
+	Section  string
+	Nav      []navLink
+	Body     template.HTML
+	Exported string
+	Scripts  []string
+}
+
+// nav returns the header links, leaving out empty sections.
+func (b *builder) nav() []navLink {
+	links := []navLink{{"Notes", "notes/index.html"}, {"Calendar", "calendar/index.html"}, {"Tags", "tags/index.html"}}
+	if len(b.topics) > 0 {
+		links = append(links, navLink{"Goals", "goals/index.html"})
+	}
+	if len(b.recipes) > 0 {
+		links = append(links, navLink{"Recipes", "recipes/index.html"})
+	}
+	return links
+}
+
+// page writes a page at rel, a slash-separated path in the site. body is
+// HTML.
+func (b *builder) page(rel, title, section, body string, scripts ...string) error {
+	var buf bytes.Buffer
+	err := layout.Execute(&buf, layoutData{
+		Site:     b.site.Title,
+		Title:    title,
+		Theme:    b.site.Theme,
+		Root:     rootOf(rel),
+		Section:  section,
+		Nav:      b.nav(),
+		Body:     template.HTML(body),
+		Exported: b.site.Now.Format("2 January 2006 15:04"),
+		Scripts:  scripts,
+	})
+	if err != nil {
+		return err
+	}
+	b.report.Pages++
+	return b.write(rel, buf.Bytes())
+}
+
+// rootOf returns the relative path from the page at rel to the top of the
+// site, so the site works from any location, including opened from disk.
+func rootOf(rel string) string {
+	return strings.Repeat("../", strings.Count(rel, "/"))
+}
+
+func (b *builder) write(rel string, data []byte) error {
+	p := filepath.Join(b.out, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(p, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", rel, err)
+	}
+	return nil
+}
+
+func (b *builder) writeAssets() error {
+	if err := b.write("style.css", []byte(styleCSS)); err != nil {
+		return err
+	}
+	return b.write("search.js", []byte(searchJS))
+}
+
+// copyAttachments copies notes/attachments, which imported notes link to,
+// next to the note pages.
+func (b *builder) copyAttachments() error {
+	src := filepath.Join(b.site.Notes.NotesDir, notes.AttachmentsDir)
+	if !isDir(src) {
+		return nil
+	}
+	return filepath.WalkDir(src, func(p string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		if err := copyFile(p, filepath.Join(b.out, "notes", notes.AttachmentsDir, rel)); err != nil {
+			return fmt.Errorf("failed to copy %s: %w", rel, err)
+		}
+		b.report.Attachments++
+		return nil
+	})
+}
+
+func copyFile(from, to string) error {
+	if err := os.MkdirAll(filepath.Dir(to), 0755); err != nil {
+		return err
+	}
+	in, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(to)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// readPage reads a markdown page, without its frontmatter.
+func readPage(p string) (string, error) {
+	content, err := os.ReadFile(p)
+	if err != nil {
+		return "", err
+	}
+	if _, body, err := markdown.ParseFrontmatter(string(content)); err == nil {
+		return body, nil
+	}
+	return string(content), nil
+}
+
+// sortedTags returns the keys of b.tags in order.
+func (b *builder) sortedTags() []string {
+	tags := make([]string, 0, len(b.tags))
+	for tag := range b.tags {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
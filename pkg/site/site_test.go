@@ -0,0 +1,243 @@
+package site
+
+import (
+	"errors"
+	"html"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/caffeinatedjack/sleepless/pkg/notes"
+	"gitlab.com/caffeinatedjack/sleepless/pkg/storage"
+)
+
+func TestRender(t *testing.T) {
+	r := &renderer{
+		link: func(target string, wiki bool) (string, bool) {
+			if target == "Nowhere" {
+				return "", false
+			}
+			return "page/" + target + ".html", true
+		},
+		tag:  func(tag string) string { return "tags/" + tag + ".html" },
+		task: func(id string) (string, bool) { return "goals/work.html#task-" + id, true },
+	}
+	got := r.render("# Plan\n\n## Plan\n\nSee [[Roadmap|the roadmap]], [[Nowhere]] and @task:abc1.\n" +
+		"Tagged #Work, **bold** and *it* <b>\n\n- [ ] open\n- [x] done\n  - nested\n\n" +
+		"| a | b |\n|---|---|\n| 1 | `x|y` |\n\n```go\nif a < b {}\n```\n")
+
+	for _, want := range []string{
+		`<h1 id="plan">Plan</h1>`,
+		`<h2 id="plan-2">Plan</h2>`,
+		`<a href="page/Roadmap.html">the roadmap</a>`,
+		`<span class="missing" title="Nowhere">Nowhere</span>`,
+		`<a class="ref" href="goals/work.html#task-abc1">@task:abc1</a>`,
+		`<a class="tag" href="tags/work.html">#Work</a>`,
+		`<strong>bold</strong> and <em>it</em> &lt;b&gt;`,
+		`<li class="task"><input type="checkbox" disabled> open</li>`,
+		"<li class=\"task done\"><input type=\"checkbox\" disabled checked> done\n<ul>\n<li>nested</li>",
+		`<th>a</th><th>b</th>`,
+		`<pre><code class="language-go">if a &lt; b {}</code></pre>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("render missing %q in:\n%s", want, got)
+		}
+	}
+}
+
+func TestRenderNUL(t *testing.T) {
+	r := &renderer{
+		link: func(target string, wiki bool) (string, bool) { return target + ".html", true },
+		tag:  func(tag string) string { return tag + ".html" },
+	}
+	for _, src := range []string{"a\x00b", "\x0099\x00 and \x000\x00", "[[x|`code`]] \x00"} {
+		done := make(chan string, 1)
+		go func() { done <- r.render(src) }()
+		select {
+		case got := <-done:
+			if strings.Contains(got, "\x00") {
+				t.Errorf("render(%q) = %q, kept NUL", src, got)
+			}
+			if strings.Contains(src, "`code`") && !strings.Contains(got, `<a href="x.html"><code>code</code></a>`) {
+				t.Errorf("render(%q) = %q, lost the code in the link", src, got)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("render(%q) did not return", src)
+		}
+	}
+}
+
+func TestSafeLink(t *testing.T) {
+	for target, want := range map[string]bool{
+		"https://example.com":     true,
+		"HTTP://example.com":      true,
+		"mailto:ann@example.com":  true,
+		"../recipes/pasta.md":     true,
+		"attachments/my file.pdf": true,
+		"#method":                 true,
+		"javascript:alert%281%29": false,
+		"JavaScript:alert(1)":     false,
+		"\x01java\tscript:x":      false,
+		"data:text/html,x":        false,
+		"file:///etc/passwd":      false,
+	} {
+		if got := safeLink(target); got != want {
+			t.Errorf("safeLink(%q) = %v, want %v", target, got, want)
+		}
+	}
+}
+
+func TestPlainText(t *testing.T) {
+	got := plainText("# Title\n\n- [ ] **Do** the [[a/Thing|thing]] with [docs](x.md)\n> *quoted*\n")
+	if want := "Title Do the thing with docs quoted"; got != want {
+		t.Errorf("plainText = %q, want %q", got, want)
+	}
+}
+
+func writeWiki(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func newSite(wiki string) *Site {
+	return &Site{
+		Notes:      notes.NewStore(wiki),
+		Goals:      storage.New(filepath.Join(wiki, "tasks")),
+		RecipesDir: filepath.Join(wiki, "recipes"),
+		Title:      "Test",
+		Now:        time.Date(2026, 1, 21, 9, 0, 0, 0, time.UTC),
+	}
+}
+
+func readFile(t *testing.T, p string) string {
+	t.Helper()
+	data, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestBuild(t *testing.T) {
+	wiki := writeWiki(t, map[string]string{
+		"notes/2026-01-20.md": "---\ntype: daily\ndate: \"2026-01-20\"\n---\n# 2026-01-20\n\nWorked on [[a1b2c3d4]] #work\n",
+		"notes/a1b2c3d4.md": "---\ntype: floating\nid: a1b2c3d4\ntags: [research]\n---\n# Caching plan\n\n" +
+			"See [pasta](../recipes/pasta.md) and @task:7b58.\n\n![[chart.png]]\n\nNot [x](javascript:alert%281%29).\n",
+		"notes/attachments/chart.png": "PNG",
+		"tasks/work.md":               "# Work\n\n- [ ] Migrate the database {#7b5809833efb75a430440e91cc105c2d}\n  - due: 2026-01-22\n  - tags: infra\n",
+		"recipes/pasta.md":            "# Pasta\n\nTags: dinner\n\n## Method\n\n1. Boil\n",
+	})
+	out := filepath.Join(t.TempDir(), "site")
+
+	report, err := newSite(wiki).Build(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Notes != 2 || report.Topics != 1 || report.Goals != 1 || report.Recipes != 1 || report.Attachments != 1 {
+		t.Errorf("report = %+v", report)
+	}
+	for _, name := range []string{"index.html", "style.css", "search.html", "search-index.js", "calendar/index.html",
+		"goals/work.html", "recipes/pasta.html", "tags/infra.html", "tags/dinner.html", "notes/attachments/chart.png"} {
+		if _, err := os.Stat(filepath.Join(out, name)); err != nil {
+			t.Errorf("missing %s", name)
+		}
+	}
+
+	note := readFile(t, filepath.Join(out, "notes", "a1b2c3d4.html"))
+	for _, want := range []string{
+		`<a href="../recipes/pasta.html">pasta</a>`,
+		`href="../goals/work.html#task-7b5809833efb75a430440e91cc105c2d"`,
+		`<img src="../notes/attachments/chart.png"`,
+		`<a href="../notes/2026-01-20.html">2026-01-20</a>`, // backlink
+		`<span class="missing" title="javascript:alert%281%29">x</span>`,
+	} {
+		if !strings.Contains(note, want) {
+			t.Errorf("note page missing %q in:\n%s", want, note)
+		}
+	}
+	if tag := readFile(t, filepath.Join(out, "tags", "work.html")); !strings.Contains(tag, "2026-01-20") {
+		t.Errorf("tag page does not list the daily note:\n%s", tag)
+	}
+	if index := readFile(t, filepath.Join(out, "search-index.js")); !strings.Contains(index, `"t":"Caching plan"`) {
+		t.Errorf("search index = %s", index)
+	}
+
+	// An earlier export is replaced.
+	if _, err := newSite(wiki).Build(out); err != nil {
+		t.Errorf("rebuild: %v", err)
+	}
+}
+
+func TestBuildTags(t *testing.T) {
+	wiki := writeWiki(t, map[string]string{
+		"notes/a1b2c3d4.md": "---\ntype: floating\nid: a1b2c3d4\ntags: [C++]\n---\n# Langs\n\n## Compared\n\nOn #c++ and #c--, also #Go.\n",
+	})
+	out := filepath.Join(t.TempDir(), "site")
+	if _, err := newSite(wiki).Build(out); err != nil {
+		t.Fatal(err)
+	}
+	if tagFile("c++") == tagFile("c--") {
+		t.Errorf("c++ and c-- share %s", tagFile("c++"))
+	}
+
+	// Every tag link, in the body and in the meta line, names a tag page.
+	note := readFile(t, filepath.Join(out, "notes", "a1b2c3d4.html"))
+	hrefs := regexp.MustCompile(`class="tag" href="\.\./tags/([^"]+)"`).FindAllStringSubmatch(note, -1)
+	files := map[string]bool{}
+	for _, h := range hrefs {
+		files[h[1]] = true
+		if _, err := os.Stat(filepath.Join(out, "tags", html.UnescapeString(h[1]))); err != nil {
+			t.Errorf("tag link to missing page %s", h[1])
+		}
+	}
+	if len(files) != 3 {
+		t.Errorf("tag pages linked = %v, want c++, c-- and go", files)
+	}
+	if !strings.Contains(note, `>#Go</a>.`) {
+		t.Errorf("trailing punctuation should stay outside the tag link:\n%s", note)
+	}
+	if _, err := os.Stat(filepath.Join(out, "tags", "go.html")); err != nil {
+		t.Error("missing tags/go.html")
+	}
+}
+
+func TestBuildRefuses(t *testing.T) {
+	wiki := writeWiki(t, map[string]string{"notes/2026-01-20.md": "# 2026-01-20\n"})
+
+	other := writeWiki(t, map[string]string{"keep.txt": "mine"})
+	if _, err := newSite(wiki).Build(other); err == nil || !strings.Contains(err.Error(), "not empty") {
+		t.Errorf("non-empty dir: err = %v", err)
+	}
+	if _, err := newSite(wiki).Build(wiki); err == nil {
+		t.Error("exporting into the wiki should fail")
+	}
+	s := newSite(wiki)
+	s.Theme = "neon"
+	if _, err := s.Build(filepath.Join(t.TempDir(), "site")); err == nil {
+		t.Error("unknown theme should fail")
+	}
+
+	if err := os.WriteFile(filepath.Join(wiki, "notes", "a1b2c3d4.md.enc"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(t.TempDir(), "site")
+	if _, err := newSite(wiki).Build(out); !errors.Is(err, ErrEncrypted) {
+		t.Errorf("encrypted file: err = %v", err)
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Error("refused export wrote output")
+	}
+}
@@ -0,0 +1,75 @@
+// Client-side search over searchIndex, loaded from search-index.js as a
+// script so that it also works when the site is opened from disk.
+(function () {
+  var input = document.getElementById("q");
+  var results = document.getElementById("results");
+  var summary = document.getElementById("summary");
+  if (!input || !results || typeof searchIndex === "undefined") {
+    return;
+  }
+
+  function escape(s) {
+    return s.replace(/[&<>"]/g, function (c) {
+      return { "&": "&amp;", "<": "&lt;", ">": "&gt;", '"': "&quot;" }[c];
+    });
+  }
+
+  function snippet(text, terms) {
+    var lower = text.toLowerCase();
+    var at = -1;
+    for (var i = 0; i < terms.length && at < 0; i++) {
+      at = lower.indexOf(terms[i]);
+    }
+    var start = Math.max(0, at - 60);
+    var s = (start > 0 ? "…" : "") + text.substr(start, 200) + (start + 200 < text.length ? "…" : "");
+    s = escape(s);
+    terms.forEach(function (t) {
+      s = s.replace(new RegExp("(" + t.replace(/[.*+?^${}()|[\]\\]/g, "\\$&") + ")", "gi"), "<mark>$1</mark>");
+    });
+    return s;
+  }
+
+  function search(query) {
+    var terms = query.toLowerCase().split(/\s+/).filter(Boolean);
+    results.innerHTML = "";
+    if (terms.length === 0) {
+      summary.textContent = searchIndex.length + " pages";
+      return;
+    }
+    var hits = [];
+    searchIndex.forEach(function (page) {
+      var title = page.t.toLowerCase();
+      var tags = page.g.join(" ");
+      var text = page.x.toLowerCase();
+      var score = 0;
+      for (var i = 0; i < terms.length; i++) {
+        var t = terms[i].replace(/^#/, "");
+        var s = 0;
+        if (title.indexOf(t) >= 0) s += 10;
+        if (tags.indexOf(t) >= 0) s += 5;
+        if (text.indexOf(t) >= 0) s += 1;
+        if (s === 0) return;
+        score += s;
+      }
+      hits.push({ page: page, score: score });
+    });
+    hits.sort(function (a, b) {
+      return b.score - a.score || a.page.t.localeCompare(b.page.t);
+    });
+    summary.textContent = hits.length + (hits.length === 1 ? " result" : " results");
+    hits.slice(0, 100).forEach(function (hit) {
+      var li = document.createElement("li");
+      li.innerHTML = '<a href="' + escape(hit.page.u) + '">' + escape(hit.page.t) + "</a>" +
+        '<span class="kind">' + escape(hit.page.k) + "</span><p>" + snippet(hit.page.x, terms) + "</p>";
+      results.appendChild(li);
+    });
+  }
+
+  var params = new URLSearchParams(window.location.search);
+  input.value = params.get("q") || "";
+  input.addEventListener("input", function () {
+    history.replaceState(null, "", "?q=" + encodeURIComponent(input.value));
+    search(input.value);
+  });
+  search(input.value);
+})();
@@ -14,6 +14,14 @@ type Config struct {
 	Aliases map[string]string `json:"aliases"`
 	// Configured is the list of zones to display in the world clock.
 	Configured []string `json:"configured"`
+	// Hours maps aliases or zones to their working hours.
+	Hours map[string]WorkHours `json:"hours,omitempty"`
+	// People maps names to the zone and hours of a colleague.
+	People map[string]Person `json:"people,omitempty"`
+	// Teams maps team names to people, aliases or zones.
+	Teams map[string][]string `json:"teams,omitempty"`
+	// Calendars holds imported holiday calendars by name.
+	Calendars map[string][]Holiday `json:"calendars,omitempty"`
 }
 
 // NewConfig creates an empty configuration.
@@ -0,0 +1,181 @@
+package when
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// WorkHours is a working day in local time. End before Start means the
+// shift runs past midnight.
+type WorkHours struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+	// Weekend lists the days off, e.g. ["sat", "sun"].
+	Weekend []string `json:"weekend,omitempty"`
+	// Holidays names the holiday calendars that apply.
+	Holidays []string `json:"holidays,omitempty"`
+}
+
+// DefaultWorkHours is used for zones without configured hours.
+var DefaultWorkHours = WorkHours{Start: "09:00", End: "17:00", Weekend: []string{"sat", "sun"}}
+
+// workWindow is WorkHours parsed into minutes after midnight.
+type workWindow struct {
+	start, end int
+	weekend    map[time.Weekday]bool
+	// holidays maps YYYY-MM-DD to the holiday's name.
+	holidays map[string]string
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// ParseWeekday accepts full or three-letter English day names.
+func ParseWeekday(s string) (time.Weekday, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if len(s) >= 3 {
+		if d, ok := weekdayNames[s[:3]]; ok && strings.HasPrefix(strings.ToLower(d.String()), s) {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown weekday %q", s)
+}
+
+// ParseWorkHours parses a range such as "09:00-17:30" or "22:00-06:00".
+func ParseWorkHours(s string, weekend []string) (WorkHours, error) {
+	start, end, ok := strings.Cut(s, "-")
+	if !ok {
+		return WorkHours{}, fmt.Errorf("working hours must look like 09:00-17:00, got %q", s)
+	}
+	h := WorkHours{Start: strings.TrimSpace(start), End: strings.TrimSpace(end), Weekend: weekend}
+	if _, err := h.window(); err != nil {
+		return WorkHours{}, err
+	}
+	return h, nil
+}
+
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		if s == "24:00" {
+			return 24 * 60, nil
+		}
+		return 0, fmt.Errorf("invalid time %q (use HH:MM)", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func (h WorkHours) window() (workWindow, error) {
+	w := workWindow{weekend: make(map[time.Weekday]bool)}
+	var err error
+	if w.start, err = parseClock(h.Start); err != nil {
+		return w, err
+	}
+	if w.end, err = parseClock(h.End); err != nil {
+		return w, err
+	}
+	if w.start == w.end {
+		return w, fmt.Errorf("working hours %s-%s are empty", h.Start, h.End)
+	}
+	for _, d := range h.Weekend {
+		wd, err := ParseWeekday(d)
+		if err != nil {
+			return w, err
+		}
+		w.weekend[wd] = true
+	}
+	return w, nil
+}
+
+// String formats the hours as "09:00-17:00 (off sat, sun; holidays uk)".
+func (h WorkHours) String() string {
+	var notes []string
+	if len(h.Weekend) > 0 {
+		notes = append(notes, "off "+strings.Join(h.Weekend, ", "))
+	}
+	if len(h.Holidays) > 0 {
+		notes = append(notes, "holidays "+strings.Join(h.Holidays, ", "))
+	}
+	s := h.Start + "-" + h.End
+	if len(notes) > 0 {
+		s += " (" + strings.Join(notes, "; ") + ")"
+	}
+	return s
+}
+
+// shiftDay returns the date of the working day local time t belongs to: a
+// shift past midnight belongs to the day it started on.
+func (w workWindow) shiftDay(t time.Time) time.Time {
+	if w.start > w.end && t.Hour()*60+t.Minute() < w.end {
+		return t.AddDate(0, 0, -1)
+	}
+	return t
+}
+
+// holiday reports the holiday, if any, on the working day of local time t.
+func (w workWindow) holiday(t time.Time) (string, bool) {
+	name, ok := w.holidays[w.shiftDay(t).Format("2006-01-02")]
+	return name, ok
+}
+
+// dayOff reports whether the working day of local time t is a weekend or
+// holiday.
+func (w workWindow) dayOff(t time.Time) bool {
+	if _, ok := w.holiday(t); ok {
+		return true
+	}
+	return w.weekend[w.shiftDay(t).Weekday()]
+}
+
+// working reports whether local time t is inside the window.
+func (w workWindow) working(t time.Time) bool {
+	m := t.Hour()*60 + t.Minute()
+	if w.dayOff(t) {
+		return false
+	}
+	if w.start < w.end {
+		return m >= w.start && m < w.end
+	}
+	return m >= w.start || m < w.end
+}
+
+// offHours is how far local time t is from the window, in hours. Weekends
+// and holidays count as a full working day away.
+func (w workWindow) offHours(t time.Time) float64 {
+	if w.working(t) {
+		return 0
+	}
+	if w.dayOff(t) {
+		return 12
+	}
+	m := t.Hour()*60 + t.Minute()
+	// Distance forwards to the next start and back to the last end.
+	before := (w.start - m + 24*60) % (24 * 60)
+	after := (m - w.end + 24*60) % (24 * 60)
+	if before < after {
+		return float64(before) / 60
+	}
+	return float64(after) / 60
+}
+
+// HoursFor returns the working hours configured for a zone label, or the
+// defaults.
+func (c *Config) HoursFor(label string) WorkHours {
+	if c != nil {
+		if h, ok := c.Hours[label]; ok {
+			return h
+		}
+	}
+	return DefaultWorkHours
+}
+
+// SetHours stores working hours for a zone label.
+func (c *Config) SetHours(label string, h WorkHours) {
+	if c.Hours == nil {
+		c.Hours = make(map[string]WorkHours)
+	}
+	c.Hours[label] = h
+}
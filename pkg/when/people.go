@@ -0,0 +1,213 @@
+package when
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"gitlab.com/caffeinatedjack/sleepless/pkg/ics"
+)
+
+// Person is a named colleague in a zone. Hours, when set, override the
+// hours configured for the zone.
+type Person struct {
+	Zone  string     `json:"zone"`
+	Hours *WorkHours `json:"hours,omitempty"`
+}
+
+// Holiday is one day off in a holiday calendar.
+type Holiday struct {
+	Date string `json:"date"` // YYYY-MM-DD
+	Name string `json:"name"`
+}
+
+// Availability states reported by Status.
+const (
+	StatusAvailable = "available"
+	StatusOffHours  = "off-hours"
+	StatusHoliday   = "holiday"
+)
+
+// Status says whether someone is at work at a given moment.
+type Status struct {
+	State string `json:"state"`
+	// Holiday is the holiday's name when State is StatusHoliday.
+	Holiday string `json:"holiday,omitempty"`
+}
+
+// String formats the status for display, e.g. "holiday (Christmas Day)".
+func (s Status) String() string {
+	if s.Holiday != "" {
+		return s.State + " (" + s.Holiday + ")"
+	}
+	return s.State
+}
+
+// AddPerson adds or updates a person and shows them in the world clock.
+func (c *Config) AddPerson(name string, p Person) {
+	if c.People == nil {
+		c.People = make(map[string]Person)
+	}
+	c.People[name] = p
+	if !contains(c.Configured, name) {
+		c.Configured = append(c.Configured, name)
+	}
+}
+
+// RemovePerson removes a person from the config, the world clock and every
+// team.
+func (c *Config) RemovePerson(name string) bool {
+	if _, ok := c.People[name]; !ok {
+		return false
+	}
+	delete(c.People, name)
+	c.Configured = without(c.Configured, name)
+	for team, members := range c.Teams {
+		c.Teams[team] = without(members, name)
+	}
+	return true
+}
+
+// SetTeam replaces the members of a team. Members must be people, aliases
+// or zones that resolve.
+func (c *Config) SetTeam(name string, members []string) error {
+	if len(members) == 0 {
+		return fmt.Errorf("team %q needs at least one member", name)
+	}
+	for _, m := range members {
+		if _, err := ResolveZone(m, c); err != nil {
+			return fmt.Errorf("team %q: %w", name, err)
+		}
+	}
+	if c.Teams == nil {
+		c.Teams = make(map[string][]string)
+	}
+	c.Teams[name] = members
+	return nil
+}
+
+// ExpandTeams replaces team names in tokens with their members, keeping
+// the order and dropping repeats.
+func (c *Config) ExpandTeams(tokens []string) []string {
+	var out []string
+	for _, t := range tokens {
+		members, ok := c.Teams[t]
+		if !ok {
+			members = []string{t}
+		}
+		for _, m := range members {
+			if !contains(out, m) {
+				out = append(out, m)
+			}
+		}
+	}
+	return out
+}
+
+// HolidaysFromCalendar takes the all-day events of a calendar as holidays.
+// It returns how many timed or recurring events were skipped; recurrences
+// are not expanded, so holiday files must list each date.
+func HolidaysFromCalendar(cal *ics.Calendar) ([]Holiday, int) {
+	var holidays []Holiday
+	skipped := 0
+	for _, ev := range cal.Events {
+		if !ev.AllDay || ev.RRule != "" {
+			skipped++
+			continue
+		}
+		for _, d := range ev.Days() {
+			holidays = append(holidays, Holiday{Date: d, Name: ev.Summary})
+		}
+	}
+	return holidays, skipped
+}
+
+// ImportHolidays stores holidays as a named calendar, replacing any
+// calendar of the same name.
+func (c *Config) ImportHolidays(name string, holidays []Holiday) {
+	if c.Calendars == nil {
+		c.Calendars = make(map[string][]Holiday)
+	}
+	sort.Slice(holidays, func(i, j int) bool { return holidays[i].Date < holidays[j].Date })
+	c.Calendars[name] = holidays
+}
+
+// RemoveCalendar removes a holiday calendar.
+func (c *Config) RemoveCalendar(name string) bool {
+	if _, ok := c.Calendars[name]; !ok {
+		return false
+	}
+	delete(c.Calendars, name)
+	return true
+}
+
+// HoursForZone returns the working hours for a resolved zone: the person's
+// own hours, then hours set for the label, then for the IANA zone.
+func (c *Config) HoursForZone(z *ResolvedZone) WorkHours {
+	if c != nil {
+		if p, ok := c.People[z.Label]; ok && p.Hours != nil {
+			return *p.Hours
+		}
+		if h, ok := c.Hours[z.Label]; ok {
+			return h
+		}
+	}
+	return c.HoursFor(z.Zone)
+}
+
+// holidays maps date to holiday name for the calendars named in h.
+func (c *Config) holidays(h WorkHours) map[string]string {
+	out := make(map[string]string)
+	if c == nil {
+		return out
+	}
+	for _, name := range h.Holidays {
+		for _, d := range c.Calendars[name] {
+			out[d.Date] = d.Name
+		}
+	}
+	return out
+}
+
+// Participant builds a planning participant for a resolved zone.
+func (c *Config) Participant(z *ResolvedZone) Participant {
+	h := c.HoursForZone(z)
+	return Participant{Label: z.Label, Location: z.Location, Hours: h, Holidays: c.holidays(h)}
+}
+
+// StatusAt reports whether the zone's working day includes t.
+func (c *Config) StatusAt(z *ResolvedZone, t time.Time) (Status, error) {
+	h := c.HoursForZone(z)
+	w, err := h.window()
+	if err != nil {
+		return Status{}, fmt.Errorf("%s: %w", z.Label, err)
+	}
+	w.holidays = c.holidays(h)
+	local := t.In(z.Location)
+	if name, ok := w.holiday(local); ok {
+		return Status{State: StatusHoliday, Holiday: name}, nil
+	}
+	if w.working(local) {
+		return Status{State: StatusAvailable}, nil
+	}
+	return Status{State: StatusOffHours}, nil
+}
+
+func contains(items []string, s string) bool {
+	for _, item := range items {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func without(items []string, s string) []string {
+	var out []string
+	for _, item := range items {
+		if item != s {
+			out = append(out, item)
+		}
+	}
+	return out
+}
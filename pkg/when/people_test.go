@@ -0,0 +1,127 @@
+package when
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/caffeinatedjack/sleepless/pkg/ics"
+)
+
+func peopleConfig(t *testing.T) *Config {
+	t.Helper()
+	cfg := NewConfig()
+	cal, err := ics.Parse(strings.NewReader("BEGIN:VCALENDAR\n" +
+		"BEGIN:VEVENT\nDTSTART;VALUE=DATE:20250825\nSUMMARY:Summer bank holiday\nEND:VEVENT\n" +
+		"BEGIN:VEVENT\nDTSTART:20250826T090000Z\nSUMMARY:Not a holiday\nEND:VEVENT\n" +
+		"END:VCALENDAR\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	holidays, skipped := HolidaysFromCalendar(cal)
+	if len(holidays) != 1 || skipped != 1 {
+		t.Fatalf("expected one holiday and one skipped event, got %v, %d", holidays, skipped)
+	}
+	cfg.ImportHolidays("uk", holidays)
+
+	hours := WorkHours{Start: "09:00", End: "17:00", Weekend: []string{"sat", "sun"}, Holidays: []string{"uk"}}
+	cfg.AddPerson("alice", Person{Zone: "Europe/London", Hours: &hours})
+	cfg.AddPerson("bob", Person{Zone: "America/New_York"})
+	cfg.SetHours("America/New_York", WorkHours{Start: "08:00", End: "16:00"})
+	return cfg
+}
+
+func TestResolveZone_Person(t *testing.T) {
+	cfg := peopleConfig(t)
+	z, err := ResolveZone("alice", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if z.Label != "alice" || z.Zone != "Europe/London" {
+		t.Errorf("unexpected resolution %+v", z)
+	}
+	if h := cfg.HoursForZone(z); len(h.Holidays) != 1 {
+		t.Errorf("expected alice's own hours, got %v", h)
+	}
+	bob, _ := ResolveZone("bob", cfg)
+	if h := cfg.HoursForZone(bob); h.Start != "08:00" {
+		t.Errorf("expected bob to fall back to his zone's hours, got %v", h)
+	}
+}
+
+func TestResolveZone_PersonInAlias(t *testing.T) {
+	cfg := peopleConfig(t)
+	cfg.Aliases["office"] = "Asia/Tokyo"
+	cfg.AddPerson("carol", Person{Zone: "office"})
+	cfg.AddPerson("dave", Person{Zone: "carol"})
+
+	z, err := ResolveZone("carol", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if z.Label != "carol" || z.Zone != "Asia/Tokyo" {
+		t.Errorf("unexpected resolution %+v", z)
+	}
+	if _, err := ResolveZone("dave", cfg); err == nil {
+		t.Error("a person's zone should not resolve through another person")
+	}
+}
+
+func TestStatusAt(t *testing.T) {
+	cfg := peopleConfig(t)
+	alice, _ := ResolveZone("alice", cfg)
+	london := mustLoad(t, "Europe/London")
+
+	for at, want := range map[time.Time]Status{
+		time.Date(2025, 8, 25, 11, 0, 0, 0, london): {State: StatusHoliday, Holiday: "Summer bank holiday"},
+		time.Date(2025, 8, 26, 11, 0, 0, 0, london): {State: StatusAvailable},
+		time.Date(2025, 8, 26, 18, 0, 0, 0, london): {State: StatusOffHours},
+		time.Date(2025, 8, 30, 11, 0, 0, 0, london): {State: StatusOffHours},
+	} {
+		got, err := cfg.StatusAt(alice, at)
+		if err != nil || got != want {
+			t.Errorf("StatusAt(%v) = %v, %v; want %v", at, got, err, want)
+		}
+	}
+}
+
+func TestTeams(t *testing.T) {
+	cfg := peopleConfig(t)
+	if err := cfg.SetTeam("core", []string{"alice", "bob", "Tokyo"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.SetTeam("bad", []string{"alice", "Atlantis"}); err == nil {
+		t.Error("expected error for an unknown member")
+	}
+
+	got := cfg.ExpandTeams([]string{"core", "alice", "UTC"})
+	if strings.Join(got, ",") != "alice,bob,Tokyo,UTC" {
+		t.Errorf("unexpected expansion %v", got)
+	}
+
+	cfg.RemovePerson("bob")
+	if strings.Join(cfg.Teams["core"], ",") != "alice,Tokyo" || contains(cfg.Configured, "bob") {
+		t.Errorf("bob not removed everywhere: %v, %v", cfg.Teams, cfg.Configured)
+	}
+}
+
+func TestPlan_Holidays(t *testing.T) {
+	cfg := peopleConfig(t)
+	alice, _ := ResolveZone("alice", cfg)
+	london := mustLoad(t, "Europe/London")
+	days, err := Plan([]Participant{cfg.Participant(alice)}, PlanOptions{
+		From: time.Date(2025, 8, 25, 0, 0, 0, 0, london), Days: 2,
+		Duration: time.Hour, Step: time.Hour, Reference: london,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range days[0].Slots {
+		if s.Available > 0 {
+			t.Fatalf("slot on a holiday counted as available: %v", s.Start)
+		}
+	}
+	if Rank(days)[0].Start.Day() != 26 {
+		t.Error("expected the best slot on the day after the holiday")
+	}
+}
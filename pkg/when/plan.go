@@ -0,0 +1,138 @@
+package when
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// planSample is how finely a meeting is checked against working hours.
+const planSample = 5 * time.Minute
+
+// Participant is one zone (or person) taking part in a meeting.
+type Participant struct {
+	Label    string
+	Location *time.Location
+	Hours    WorkHours
+	// Holidays maps YYYY-MM-DD to the holiday's name.
+	Holidays map[string]string
+}
+
+// PlanOptions controls which slots Plan considers.
+type PlanOptions struct {
+	// From is the first moment a meeting may start. Days are counted from
+	// its date in Reference.
+	From      time.Time
+	Days      int
+	Duration  time.Duration
+	Step      time.Duration
+	Reference *time.Location
+}
+
+// SlotPerson is how a slot looks to one participant.
+type SlotPerson struct {
+	Label     string    `json:"label"`
+	Local     time.Time `json:"local"`
+	Available bool      `json:"available"`
+	// OffHours is how far outside working hours the meeting reaches.
+	OffHours float64 `json:"off_hours"`
+}
+
+// Slot is a candidate meeting time.
+type Slot struct {
+	Start     time.Time    `json:"start"`
+	End       time.Time    `json:"end"`
+	Available int          `json:"available"`
+	Pain      float64      `json:"pain"`
+	People    []SlotPerson `json:"people"`
+}
+
+// PlanDay holds the slots starting on one day in the reference zone.
+type PlanDay struct {
+	Date  time.Time
+	Slots []Slot
+}
+
+// Plan scores every slot of the coming days. Days are walked in the
+// reference zone's calendar and slots step through absolute time, so a day
+// with a DST change has 23 or 25 hours of slots.
+func Plan(people []Participant, opts PlanOptions) ([]PlanDay, error) {
+	if len(people) == 0 {
+		return nil, fmt.Errorf("need at least one zone")
+	}
+	if opts.Duration <= 0 || opts.Step <= 0 || opts.Days < 1 {
+		return nil, fmt.Errorf("duration, step and days must be positive")
+	}
+	if opts.Reference == nil {
+		opts.Reference = time.Local
+	}
+	windows := make([]workWindow, len(people))
+	for i, p := range people {
+		w, err := p.Hours.window()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", p.Label, err)
+		}
+		w.holidays = p.Holidays
+		windows[i] = w
+	}
+
+	from := opts.From.In(opts.Reference)
+	y, m, d := from.Date()
+	days := make([]PlanDay, 0, opts.Days)
+	for i := 0; i < opts.Days; i++ {
+		dayStart := time.Date(y, m, d+i, 0, 0, 0, 0, opts.Reference)
+		dayEnd := time.Date(y, m, d+i+1, 0, 0, 0, 0, opts.Reference)
+		day := PlanDay{Date: dayStart}
+		for t := dayStart; t.Before(dayEnd); t = t.Add(opts.Step) {
+			if t.Before(opts.From) {
+				continue
+			}
+			day.Slots = append(day.Slots, scoreSlot(people, windows, t, opts.Duration))
+		}
+		days = append(days, day)
+	}
+	return days, nil
+}
+
+func scoreSlot(people []Participant, windows []workWindow, start time.Time, duration time.Duration) Slot {
+	slot := Slot{Start: start, End: start.Add(duration)}
+	for i, p := range people {
+		person := SlotPerson{Label: p.Label, Local: start.In(p.Location)}
+		for t := start; t.Before(slot.End); t = t.Add(planSample) {
+			if off := windows[i].offHours(t.In(p.Location)); off > person.OffHours {
+				person.OffHours = off
+			}
+		}
+		// The last minute of the meeting must be inside working hours too.
+		if off := windows[i].offHours(slot.End.Add(-time.Minute).In(p.Location)); off > person.OffHours {
+			person.OffHours = off
+		}
+		person.Available = person.OffHours == 0
+		if person.Available {
+			slot.Available++
+		}
+		slot.Pain += person.OffHours
+		slot.People = append(slot.People, person)
+	}
+	return slot
+}
+
+// Rank orders slots best first: most people inside working hours, then the
+// least time outside them, then the earliest.
+func Rank(days []PlanDay) []Slot {
+	var all []Slot
+	for _, d := range days {
+		all = append(all, d.Slots...)
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		a, b := all[i], all[j]
+		if a.Available != b.Available {
+			return a.Available > b.Available
+		}
+		if a.Pain != b.Pain {
+			return a.Pain < b.Pain
+		}
+		return a.Start.Before(b.Start)
+	})
+	return all
+}
@@ -0,0 +1,110 @@
+package when
+
+import (
+	"testing"
+	"time"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("zone %s not available: %v", name, err)
+	}
+	return loc
+}
+
+func TestWorkWindow(t *testing.T) {
+	night, err := ParseWorkHours("22:00-06:00", []string{"sunday"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, _ := night.window()
+	// 2025-06-14 is a Saturday.
+	sat := func(h, m int) time.Time { return time.Date(2025, 6, 14, h, m, 0, 0, time.UTC) }
+	if !w.working(sat(23, 0)) || !w.working(sat(5, 59)) || w.working(sat(6, 0)) {
+		t.Error("overnight shift not recognised")
+	}
+	// Early Monday belongs to Sunday's shift, which is a day off.
+	if w.working(time.Date(2025, 6, 16, 1, 0, 0, 0, time.UTC)) {
+		t.Error("expected the shift starting on a day off to be off")
+	}
+
+	day, _ := DefaultWorkHours.window()
+	if got := day.offHours(time.Date(2025, 6, 16, 7, 30, 0, 0, time.UTC)); got != 1.5 {
+		t.Errorf("expected 1.5 hours before start, got %v", got)
+	}
+	if got := day.offHours(time.Date(2025, 6, 16, 19, 0, 0, 0, time.UTC)); got != 2 {
+		t.Errorf("expected 2 hours after end, got %v", got)
+	}
+	if got := day.offHours(sat(12, 0)); got != 12 {
+		t.Errorf("expected a weekend penalty, got %v", got)
+	}
+
+	for _, bad := range []string{"9-17", "09:00", "09:00-09:00", "25:00-26:00"} {
+		if _, err := ParseWorkHours(bad, nil); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+	if _, err := ParseWorkHours("09:00-17:00", []string{"funday"}); err == nil {
+		t.Error("expected error for an unknown weekday")
+	}
+}
+
+func TestPlan_DST(t *testing.T) {
+	london := mustLoad(t, "Europe/London")
+	// UK clocks go forward on 2025-03-30 and back on 2025-10-26.
+	for date, want := range map[string]int{"2025-03-29": 48, "2025-03-30": 46, "2025-10-26": 50} {
+		from, _ := time.ParseInLocation("2006-01-02", date, london)
+		days, err := Plan([]Participant{{Label: "london", Location: london, Hours: DefaultWorkHours}},
+			PlanOptions{From: from, Days: 1, Duration: time.Hour, Step: 30 * time.Minute, Reference: london})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := len(days[0].Slots); got != want {
+			t.Errorf("%s: %d slots, want %d", date, got, want)
+		}
+	}
+}
+
+func TestPlan_Rank(t *testing.T) {
+	london := mustLoad(t, "Europe/London")
+	ny := mustLoad(t, "America/New_York")
+	tokyo := mustLoad(t, "Asia/Tokyo")
+	people := []Participant{
+		{Label: "london", Location: london, Hours: DefaultWorkHours},
+		{Label: "ny", Location: ny, Hours: WorkHours{Start: "08:00", End: "16:00", Weekend: []string{"sat", "sun"}}},
+		{Label: "tokyo", Location: tokyo, Hours: DefaultWorkHours},
+	}
+	// Friday 2025-06-13 to Monday 2025-06-16.
+	from := time.Date(2025, 6, 13, 0, 0, 0, 0, time.UTC)
+	days, err := Plan(people, PlanOptions{From: from, Days: 4, Duration: 45 * time.Minute, Step: 30 * time.Minute, Reference: time.UTC})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ranked := Rank(days)
+
+	best := ranked[0]
+	// London and New York overlap 12:00-16:00 UTC on weekdays; Tokyo is
+	// asleep then, so the best slot has two people available.
+	if best.Available != 2 || best.People[2].Available {
+		t.Fatalf("unexpected best slot %+v", best)
+	}
+	if wd := best.Start.Weekday(); wd == time.Saturday || wd == time.Sunday {
+		t.Errorf("best slot on a weekend: %v", best.Start)
+	}
+	for i := 1; i < len(ranked); i++ {
+		a, b := ranked[i-1], ranked[i]
+		if a.Available < b.Available || (a.Available == b.Available && a.Pain > b.Pain) {
+			t.Fatalf("slots out of order at %d: %+v then %+v", i, a, b)
+		}
+	}
+
+	// London works until 16:00 UTC in summer, so a 45-minute meeting at
+	// 15:30 UTC overruns its day.
+	for _, s := range days[0].Slots {
+		if s.Start.Hour() == 15 && s.Start.Minute() == 30 && s.People[0].Available {
+			t.Errorf("meeting overrunning London's day counted as available")
+		}
+	}
+}
@@ -150,7 +150,7 @@ func (e *UnknownZoneError) Error() string {
 }
 
 // ResolveZone resolves a zone token to a ResolvedZone.
-// Resolution order: alias -> IANA -> abbreviation -> city
+// Resolution order: alias -> person -> IANA -> abbreviation -> city
 func ResolveZone(token string, cfg *Config) (*ResolvedZone, error) {
 	// 1. Check aliases first
 	if cfg != nil {
@@ -163,6 +163,19 @@ func ResolveZone(token string, cfg *Config) (*ResolvedZone, error) {
 		}
 	}
 
+	// People resolve to their zone under their own name. The zone may be
+	// an alias, but not another person, so a loop cannot form.
+	if cfg != nil {
+		if p, ok := cfg.People[token]; ok {
+			resolved, err := ResolveZone(p.Zone, &Config{Aliases: cfg.Aliases})
+			if err != nil {
+				return nil, fmt.Errorf("invalid zone for '%s': %w", token, err)
+			}
+			resolved.Label = token
+			return resolved, nil
+		}
+	}
+
 	// 2. Try as IANA zone
 	if loc, err := time.LoadLocation(token); err == nil {
 		return &ResolvedZone{Label: token, Zone: token, Location: loc}, nil